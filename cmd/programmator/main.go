@@ -19,7 +19,7 @@ func main() {
 	fillVersionFromBuildInfo()
 	cli.SetVersionInfo(version, commit, date)
 	if err := cli.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
 
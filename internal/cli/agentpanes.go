@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// agentPaneHistory is how many of its latest lines an expanded agent pane
+// shows.
+const agentPaneHistory = 5
+
+// agentPane holds the latest activity of a review agent, shown in the
+// footer under the review progress: collapsed to the last line, or
+// expanded to the last agentPaneHistory lines.
+type agentPane struct {
+	lines    []string
+	expanded bool
+}
+
+// recordAgentActivityLocked keeps the line of ev in the pane of its agent
+// and redraws the footer. Activity is only shown in the footer, so it is
+// dropped without a TTY.
+func (w *Writer) recordAgentActivityLocked(ev event.Event) {
+	if !w.isTTY || ev.Agent == "" {
+		return
+	}
+	if w.agentPanes == nil {
+		w.agentPanes = make(map[string]*agentPane)
+	}
+	pane := w.agentPanes[ev.Agent]
+	if pane == nil {
+		pane = &agentPane{}
+		w.agentPanes[ev.Agent] = pane
+	}
+	pane.lines = append(pane.lines, ev.Text)
+	if len(pane.lines) > agentPaneHistory {
+		pane.lines = pane.lines[len(pane.lines)-agentPaneHistory:]
+	}
+	w.redrawFooterLocked()
+}
+
+// toggleAgentPane expands or collapses the pane of the n-th agent of the
+// running review phase, counted from 1 as the review progress line lists
+// them.
+func (w *Writer) toggleAgentPane(n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.footer == nil || w.footer.state == nil || w.footer.state.Review == nil {
+		return
+	}
+	agents := w.footer.state.Review.Agents
+	if n < 1 || n > len(agents) {
+		return
+	}
+	if pane := w.agentPanes[agents[n-1].Name]; pane != nil {
+		pane.expanded = !pane.expanded
+		w.redrawFooterLocked()
+	}
+}
+
+// agentPaneLines renders the panes of the running agents of p that have
+// shown activity, in the order p lists them. With keyboard input on, each
+// pane is numbered with the key that toggles it.
+func (w *Writer) agentPaneLines(p *safety.ReviewProgress) []string {
+	var lines []string
+	for i, a := range p.Agents {
+		pane := w.agentPanes[a.Name]
+		if a.Done || pane == nil || len(pane.lines) == 0 {
+			continue
+		}
+		marker := "▸"
+		if pane.expanded {
+			marker = "▾"
+		}
+		if w.onSkip != nil && i < 9 {
+			marker += fmt.Sprintf(" %d", i+1)
+		}
+		header := "  " + w.style(w.theme.muted, marker) + " " + w.style(w.theme.text, sanitizeTerminalText(a.Name))
+
+		if !pane.expanded {
+			last := pane.lines[len(pane.lines)-1]
+			width := w.width - len([]rune(marker)) - len([]rune(a.Name)) - 6
+			lines = append(lines, header+w.style(w.theme.muted, ": ")+w.style(w.theme.subtle, truncateRunes(last, width)))
+			continue
+		}
+		lines = append(lines, header)
+		for _, line := range pane.lines {
+			lines = append(lines, "      "+w.style(w.theme.subtle, truncateRunes(line, w.width-6)))
+		}
+	}
+	return lines
+}
@@ -32,31 +32,12 @@ func fgBold(color int, text string) string {
 	return fmt.Sprintf("\033[1;38;5;%dm%s\033[0m", color, text)
 }
 
+// fgReverse wraps text with a 256-color foreground in reverse video.
+func fgReverse(color int, text string) string {
+	return fmt.Sprintf("\033[7;38;5;%dm%s\033[0m", color, text)
+}
+
 // stdoutIsTTY returns true when stdout is a terminal.
 func stdoutIsTTY() bool {
 	return term.IsTerminal(int(os.Stdout.Fd()))
 }
-
-// maybeBold returns bold text in TTY mode, plain text otherwise.
-func maybeBold(tty bool, text string) string {
-	if tty {
-		return bold(text)
-	}
-	return text
-}
-
-// maybeDim returns dim text in TTY mode, plain text otherwise.
-func maybeDim(tty bool, text string) string {
-	if tty {
-		return dim(text)
-	}
-	return text
-}
-
-// maybeFgBold returns colored bold text in TTY mode, plain text otherwise.
-func maybeFgBold(tty bool, color int, text string) string {
-	if tty {
-		return fgBold(color, text)
-	}
-	return text
-}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+// artifactsDir is where run artifact directories are created, relative to
+// the working directory.
+const artifactsDir = ".programmator/runs"
+
+// runArtifacts collects the files of one run in its own directory, so a
+// strange run can be zipped up and attached to a bug report: the progress
+// log, every event, the prompt of each iteration, each review's report, and
+// the final diff.
+type runArtifacts struct {
+	dir      string
+	repo     *gitutil.Repo // nil outside a git repository
+	base     string        // HEAD when the run started; the final diff is taken from here
+	progress *progressLog
+
+	mu      sync.Mutex
+	events  *os.File
+	enc     *json.Encoder
+	reviews int
+}
+
+// openRunArtifacts creates the artifact directory of a new run of sourceID
+// and deletes all but the newest keep run directories (0 = keep all).
+func openRunArtifacts(sourceID, workingDir string, labels []string, keep int) (*runArtifacts, error) {
+	root := filepath.Join(workingDir, artifactsDir)
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create artifacts dir: %w", err)
+	}
+	// Keep artifacts out of auto-commits and snapshots.
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*\n"), 0o644); err != nil { //nolint:gosec // not a secret
+		return nil, fmt.Errorf("write artifacts .gitignore: %w", err)
+	}
+
+	name := time.Now().Format("20060102-150405") + "-" + runLogName(sourceID)
+	dir := filepath.Join(root, name)
+	for n := 2; ; n++ {
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("create run artifacts dir: %w", err)
+		}
+		dir = filepath.Join(root, name+"-"+strconv.Itoa(n))
+	}
+	if err := pruneRunArtifacts(root, keep); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not delete old run artifacts: %v\n", err)
+	}
+
+	a := &runArtifacts{dir: dir}
+	if repo, err := gitutil.NewRepo(workingDir); err == nil {
+		a.repo = repo
+		a.base, _ = gitutil.HeadCommit(workingDir)
+	}
+	progress, err := openProgressLogAt(filepath.Join(dir, "progress.log"), sourceID, workingDir, labels)
+	if err != nil {
+		return nil, err
+	}
+	a.progress = progress
+	f, err := os.OpenFile(filepath.Join(dir, "events.jsonl"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) //nolint:gosec // path under the artifacts dir
+	if err != nil {
+		progress.finish(nil, nil)
+		return nil, fmt.Errorf("open events log: %w", err)
+	}
+	a.events, a.enc = f, json.NewEncoder(f)
+	return a, nil
+}
+
+// pruneRunArtifacts deletes all but the newest keep run directories in root.
+// Directory names start with their creation time, so they sort oldest first.
+func pruneRunArtifacts(root string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	var runs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			runs = append(runs, e.Name())
+		}
+	}
+	slices.Sort(runs)
+	for _, name := range runs[:max(len(runs)-keep, 0)] {
+		if err := os.RemoveAll(filepath.Join(root, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// event records ev in events.jsonl, and in progress.log as the progress log
+// would.
+func (a *runArtifacts) event(ev event.Event) {
+	a.progress.event(ev)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_ = a.enc.Encode(newRecordedEvent(ev))
+}
+
+// iteration saves the prompt of an iteration as prompts/iter-NNN.md.
+func (a *runArtifacts) iteration(rec loop.IterationRecord) {
+	a.write(filepath.Join("prompts", fmt.Sprintf("iter-%03d.md", rec.Iteration)), rec.Prompt)
+}
+
+// review saves the report of a review iteration as reviews/review-N.md.
+func (a *runArtifacts) review(res *review.RunResult) {
+	a.mu.Lock()
+	a.reviews++
+	n := a.reviews
+	a.mu.Unlock()
+
+	status := "passed"
+	if !res.Passed {
+		status = "failed"
+	}
+	report := fmt.Sprintf("# Review %d\n\nStatus: %s\nIssues: %d\nDuration: %s\n\n%s",
+		n, status, res.TotalIssues, formatElapsed(res.Duration), review.FormatIssuesMarkdown(res.Results))
+	a.write(filepath.Join("reviews", fmt.Sprintf("review-%d.md", n)), report)
+}
+
+// finish records how the run ended, saves the diff of the working tree
+// against the commit the run started from, and closes the logs.
+func (a *runArtifacts) finish(result *loop.Result, runErr error) {
+	a.progress.finish(result, runErr)
+	if a.repo != nil && a.base != "" {
+		if diff, err := a.repo.DiffFrom(a.base); err == nil {
+			a.write("final.diff", diff)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events.Close()
+}
+
+// write saves content to name in the run directory. Artifacts are best
+// effort: a failed write is a warning.
+func (a *runArtifacts) write(name, content string) {
+	path := filepath.Join(a.dir, name)
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err == nil {
+		err = os.WriteFile(path, []byte(content), 0o600)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save run artifact %s: %v\n", name, err)
+	}
+}
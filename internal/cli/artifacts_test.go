@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestRunArtifacts(t *testing.T) {
+	dir := initPreflightRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Project\n"), 0o600))
+
+	a, err := openRunArtifacts("plans/feature.md", dir, []string{"q3"}, 10)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(a.dir, filepath.Join(dir, ".programmator", "runs")+string(filepath.Separator)))
+	assert.True(t, strings.HasSuffix(a.dir, "-feature"))
+
+	a.event(event.Prog("Starting iteration 1"))
+	a.event(event.Event{Kind: event.KindToolUse, Text: "Read main.go"})
+	a.iteration(loop.IterationRecord{Iteration: 1, Phase: "Task 1", Prompt: "Implement the feature"})
+	a.iteration(loop.IterationRecord{Iteration: 2, ReviewFix: true, Prompt: "Fix the issues"})
+	a.review(&review.RunResult{Passed: false, TotalIssues: 1, Results: []*review.Result{
+		{AgentName: "quality", Issues: []review.Issue{{File: "main.go", Line: 3, Severity: review.SeverityHigh, Description: "Unchecked error"}}},
+	}})
+	a.review(&review.RunResult{Passed: true})
+	a.finish(&loop.Result{ExitReason: safety.ExitReasonComplete, Iterations: 1}, nil)
+
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(a.dir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+	progress := read("progress.log")
+	assert.Contains(t, progress, "id: plans/feature.md")
+	assert.Contains(t, progress, "labels: q3")
+	assert.Contains(t, progress, "Starting iteration 1")
+	assert.Contains(t, progress, "exit: complete")
+
+	events := read("events.jsonl")
+	assert.Contains(t, events, `"kind":"prog","text":"Starting iteration 1"`)
+	assert.Contains(t, events, `"kind":"tool_use","text":"Read main.go"`)
+
+	assert.Equal(t, "Implement the feature", read("prompts/iter-001.md"))
+	assert.Equal(t, "Fix the issues", read("prompts/iter-002.md"))
+	assert.NoFileExists(t, filepath.Join(a.dir, "prompts", "index.jsonl"))
+	first := read("reviews/review-1.md")
+	assert.Contains(t, first, "Status: failed\nIssues: 1")
+	assert.Contains(t, first, "`main.go:3` - Unchecked error")
+	assert.Contains(t, read("reviews/review-2.md"), "Status: passed")
+	assert.Contains(t, read("final.diff"), "+# Project")
+
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "?? README.md", strings.TrimSpace(string(status)), "artifacts are ignored by git")
+}
+
+func TestPruneRunArtifacts(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"20260101-100000-a", "20260102-100000-b", "20260103-100000-a"} {
+		require.NoError(t, os.Mkdir(filepath.Join(root, name), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*\n"), 0o600))
+
+	require.NoError(t, pruneRunArtifacts(root, 0))
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4, "0 keeps every run")
+
+	require.NoError(t, pruneRunArtifacts(root, 2))
+	entries, err = os.ReadDir(root)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{".gitignore", "20260102-100000-b", "20260103-100000-a"}, names)
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+// Limits on what an ask prompt embeds. The log keeps its tail, where the
+// run ended; the rest keep their head.
+const (
+	askLogLimit     = 60000
+	askReviewsLimit = 30000
+	askPromptLimit  = 20000
+	askDiffLimit    = 60000
+)
+
+var askWorkingDir string
+
+var askCmd = &cobra.Command{
+	Use:   "ask <run> <question>",
+	Short: "Answer a question about a past run from its artifacts",
+	Long: `Answer a question about a run collected with artifacts.enabled.
+
+The run's progress log (iteration summaries and statuses), review reports,
+last prompt, and final diff are given to the configured executor in a single
+read-only invocation: it answers from them and cannot change any files.
+Only the claude and codex executors have a read-only mode.
+
+<run> is the name of a directory under .programmator/runs, a unique part of
+it such as the plan or ticket name, "last" for the newest run, or a path.
+
+Examples:
+  programmator ask last "why did it stagnate on phase 2?"
+  programmator ask 20260101-120000-feature "which review issue kept coming back?"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runAsk,
+}
+
+func init() {
+	askCmd.Flags().StringVarP(&askWorkingDir, "dir", "d", "", "Working directory (default: current directory)")
+}
+
+func runAsk(_ *cobra.Command, args []string) error {
+	wd, err := resolveWorkingDir(askWorkingDir)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadForDir(wd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	runDir, err := findRunArtifacts(wd, args[0])
+	if err != nil {
+		return err
+	}
+	inv, err := executor.New(cfg.ToExecutorConfig())
+	if err != nil {
+		return fmt.Errorf("create invoker: %w", err)
+	}
+
+	fmt.Printf("Asking about %s\n\n", runDir)
+	answer, err := askAboutRun(context.Background(), inv, cfg, runDir, args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Println(answer)
+	return nil
+}
+
+// findRunArtifacts returns the artifact directory of the run named run in
+// workingDir: an exact directory name, "last", a unique substring of a
+// name, or a path to the directory.
+func findRunArtifacts(workingDir, run string) (string, error) {
+	if info, err := os.Stat(run); err == nil && info.IsDir() && strings.ContainsRune(run, filepath.Separator) {
+		return run, nil
+	}
+
+	root := filepath.Join(workingDir, artifactsDir)
+	entries, err := os.ReadDir(root)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("read run artifacts: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no run artifacts in %s (enable artifacts.enabled to collect them)", root)
+	}
+	slices.Sort(names)
+
+	if run == "last" {
+		return filepath.Join(root, names[len(names)-1]), nil
+	}
+	if slices.Contains(names, run) {
+		return filepath.Join(root, run), nil
+	}
+	var matches []string
+	for _, name := range names {
+		if strings.Contains(name, run) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no run matching %q in %s", run, root)
+	case 1:
+		return filepath.Join(root, matches[0]), nil
+	default:
+		return "", fmt.Errorf("%q matches %d runs, use the full name: %s", run, len(matches), strings.Join(matches, ", "))
+	}
+}
+
+// askAboutRun asks inv question about the run whose artifacts are in
+// runDir, in a single invocation that may not change files.
+func askAboutRun(ctx context.Context, inv llm.Invoker, cfg *config.Config, runDir, question string) (string, error) {
+	progress, err := os.ReadFile(filepath.Join(runDir, "progress.log")) //nolint:gosec // path under the artifacts dir
+	if err != nil {
+		return "", fmt.Errorf("read run progress log: %w", err)
+	}
+
+	flags, err := cfg.ToExecutorConfig().ReadOnlyFlags(llm.PurposeReview)
+	if err != nil {
+		return "", err
+	}
+
+	prompt := buildAskPrompt(question, string(progress), readRunReviews(runDir), readLastPrompt(runDir), readArtifact(runDir, "final.diff"))
+	out, err := inv.Invoke(ctx, prompt, llm.InvokeOptions{
+		WorkingDir: runDir,
+		ExtraFlags: flags,
+		Purpose:    llm.PurposeReview,
+		Timeout:    cfg.Timeout,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invoke executor: %w", err)
+	}
+	answer := strings.TrimSpace(out.Text)
+	if answer == "" {
+		return "", fmt.Errorf("executor returned an empty answer")
+	}
+	return answer, nil
+}
+
+// readRunReviews returns the review reports of a run, in order.
+func readRunReviews(runDir string) string {
+	var reports []string
+	for n := 1; ; n++ {
+		report := readArtifact(runDir, filepath.Join("reviews", fmt.Sprintf("review-%d.md", n)))
+		if report == "" {
+			break
+		}
+		reports = append(reports, strings.TrimSpace(report))
+	}
+	return strings.Join(reports, "\n\n")
+}
+
+// readLastPrompt returns the prompt of a run's last iteration.
+func readLastPrompt(runDir string) string {
+	paths, _ := filepath.Glob(filepath.Join(runDir, "prompts", "iter-*.md"))
+	if len(paths) == 0 {
+		return ""
+	}
+	slices.Sort(paths)
+	return readArtifact(runDir, filepath.Join("prompts", filepath.Base(paths[len(paths)-1])))
+}
+
+// readArtifact returns the contents of the artifact name, "" when missing.
+func readArtifact(runDir, name string) string {
+	data, err := os.ReadFile(filepath.Join(runDir, name)) //nolint:gosec // path under the artifacts dir
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// buildAskPrompt gives the artifacts of a run and asks question about it.
+func buildAskPrompt(question, progress, reviews, lastPrompt, diff string) string {
+	var b strings.Builder
+
+	b.WriteString("You are answering a question about a finished run of an autonomous coding agent. ")
+	b.WriteString("Answer only from the run's artifacts below. Do not modify any files or run commands. ")
+	b.WriteString("Quote the log lines your answer rests on, and say so when the artifacts do not tell.\n\n")
+
+	b.WriteString("## Progress log\n\n")
+	if len(progress) > askLogLimit {
+		progress = "(earlier lines truncated)\n" + progress[len(progress)-askLogLimit:]
+	}
+	writeFenced(&b, "", strings.TrimSpace(progress))
+
+	if reviews != "" {
+		b.WriteString("## Review reports\n\n")
+		fmt.Fprintf(&b, "%s\n\n", truncateHead(reviews, askReviewsLimit))
+	}
+	if lastPrompt != "" {
+		b.WriteString("## Prompt of the last iteration\n\n")
+		writeFenced(&b, "markdown", truncateHead(strings.TrimSpace(lastPrompt), askPromptLimit))
+	}
+
+	b.WriteString("## Final diff\n\n")
+	if strings.TrimSpace(diff) == "" {
+		b.WriteString("(no changes or diff unavailable)\n\n")
+	} else {
+		writeFenced(&b, "diff", truncateHead(strings.TrimSpace(diff), askDiffLimit))
+	}
+
+	fmt.Fprintf(&b, "## Question\n\n%s\n\nOutput only the answer, in markdown.", strings.TrimSpace(question))
+	return b.String()
+}
+
+// writeFenced writes content in a code block whose fence it cannot close, so
+// a run's artifacts cannot continue as prompt text.
+func writeFenced(b *strings.Builder, lang, content string) {
+	fence := review.Fence(content)
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, lang, content, fence)
+}
+
+// truncateHead keeps the first limit bytes of s, noting the cut.
+func truncateHead(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "\n(truncated)"
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/ticket"
+)
+
+// batchTicket is a ticket of a batch and the ID start runs it by, with the
+// workspace prefix of a workspace query.
+type batchTicket struct {
+	ID     string
+	Ticket *ticket.Ticket
+}
+
+// batchEntry is how one ticket of a batch went.
+type batchEntry struct {
+	batchTicket
+	Result  *loop.Result // nil when the ticket did not run
+	Err     error        // the error start would have ended with
+	Skipped string       // why the ticket was not started
+}
+
+// batchRunFunc runs one ticket with at most maxIterations iterations.
+type batchRunFunc func(id string, maxIterations int) (*loop.Result, error)
+
+// runQueryBatch runs every ticket matching query, highest priority first,
+// one after another, with budget iterations between them (0 = no limit but
+// each ticket's max_iterations).
+func runQueryBatch(wd, query string, budget int) error {
+	if budget < 0 {
+		return fmt.Errorf("--budget must be positive")
+	}
+	q, err := ticket.ParseQuery(query)
+	if err != nil {
+		return fmt.Errorf("--query: %w", err)
+	}
+	cfg, err := config.LoadForDir(wd)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	tickets, err := queryTickets(cfg, q)
+	if err != nil {
+		return err
+	}
+	if len(tickets) == 0 {
+		fmt.Printf("No tickets match %q\n", query)
+		return nil
+	}
+	fmt.Printf("Running %d tickets matching %q:\n", len(tickets), query)
+	for _, t := range tickets {
+		fmt.Printf("  %s  %s  %s\n", formatPriority(t.Ticket), t.ID, t.Ticket.Title)
+	}
+
+	entries := runBatch(tickets, cfg.MaxIterations, budget, func(id string, maxIterations int) (*loop.Result, error) {
+		fmt.Printf("\n=== %s ===\n", id)
+		// Nobody may be watching: an unfinished ticket resumes without
+		// asking, and one an earlier run completed fails.
+		return startLoop(id, startOptions{workingDir: wd, maxIterations: maxIterations, priorRun: priorRunAutoResume})
+	})
+	printBatchSummary(os.Stdout, query, entries, budget)
+	return batchExitError(entries)
+}
+
+// queryTickets lists the tickets of the query's workspace, or TICKETS_DIR,
+// that match it, highest priority first.
+func queryTickets(cfg *config.Config, q ticket.Query) ([]batchTicket, error) {
+	client := ticket.NewClient(cfg.TicketCommand)
+	prefix := ""
+	if q.Workspace != "" {
+		ws, ok := cfg.TicketWorkspaces[q.Workspace]
+		if !ok {
+			return nil, fmt.Errorf("unknown ticket workspace %q", q.Workspace)
+		}
+		client = ticket.NewWorkspaceClient(ws, cfg.TicketCommand)
+		prefix = q.Workspace + ":"
+	}
+	all, err := client.List()
+	if err != nil {
+		return nil, fmt.Errorf("list tickets: %w", err)
+	}
+	var matched []*ticket.Ticket
+	for _, t := range all {
+		if q.Match(t) {
+			matched = append(matched, t)
+		}
+	}
+	ticket.SortByPriority(matched)
+	tickets := make([]batchTicket, len(matched))
+	for i, t := range matched {
+		tickets[i] = batchTicket{ID: prefix + t.ID, Ticket: t}
+	}
+	return tickets, nil
+}
+
+// runBatch runs tickets in order, each with at most maxIterations
+// iterations and no more than what is left of budget. Once the budget is
+// spent, or a run is interrupted, the remaining tickets are skipped. A
+// ticket that fails or does not complete does not stop the others.
+func runBatch(tickets []batchTicket, maxIterations, budget int, run batchRunFunc) []batchEntry {
+	entries := make([]batchEntry, 0, len(tickets))
+	used := 0
+	skip := ""
+	for _, t := range tickets {
+		entry := batchEntry{batchTicket: t}
+		limit := maxIterations
+		if budget > 0 {
+			limit = min(limit, budget-used)
+			if limit <= 0 && skip == "" {
+				skip = "budget spent"
+			}
+		}
+		if skip != "" {
+			entry.Skipped = skip
+			entries = append(entries, entry)
+			continue
+		}
+
+		entry.Result, entry.Err = run(t.ID, limit)
+		if entry.Result != nil {
+			used += entry.Result.Iterations
+			if entry.Result.ExitReason == safety.ExitReasonUserInterrupt {
+				skip = "batch interrupted"
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// printBatchSummary prints how each ticket of the batch went, and the
+// totals.
+func printBatchSummary(out io.Writer, query string, entries []batchEntry, budget int) {
+	fmt.Fprintf(out, "\nBatch summary for %q:\n", query)
+	completed, iterations := 0, 0
+	for _, e := range entries {
+		var outcome string
+		switch {
+		case e.Skipped != "":
+			outcome = "skipped: " + e.Skipped
+		case e.Result == nil:
+			outcome = "failed: " + firstLine(e.Err)
+		default:
+			outcome = fmt.Sprintf("%-20s  %3d iter  %8s", e.Result.ExitReason, e.Result.Iterations, formatElapsed(e.Result.Duration))
+			iterations += e.Result.Iterations
+			if e.Result.ExitReason == safety.ExitReasonComplete {
+				completed++
+			}
+		}
+		line := fmt.Sprintf("  %s  %-16s  %-32s  %s", formatPriority(e.Ticket), e.ID, truncateRunes(e.Ticket.Title, 32), outcome)
+		fmt.Fprintln(out, strings.TrimRight(line, " "))
+	}
+	total := fmt.Sprintf("%d iterations", iterations)
+	if budget > 0 {
+		total = fmt.Sprintf("%d of %d budgeted iterations", iterations, budget)
+	}
+	fmt.Fprintf(out, "Completed %d of %d tickets, %s\n", completed, len(entries), total)
+}
+
+// batchExitError returns the error ending the batch: nil when every ticket
+// completed, otherwise an ExitError with the exit code of the first ticket
+// that did not, ExitCodeMaxIterations for one skipped for the budget. The
+// summary already said why, so it prints nothing.
+func batchExitError(entries []batchEntry) error {
+	for _, e := range entries {
+		switch {
+		case e.Err != nil:
+			return &ExitError{Code: ExitCode(e.Err)}
+		case e.Skipped != "":
+			return &ExitError{Code: ExitCodeMaxIterations}
+		}
+	}
+	return nil
+}
+
+// formatPriority formats a ticket's priority as "P1", or "P-" when it has
+// none.
+func formatPriority(t *ticket.Ticket) string {
+	if !t.HasPriority() {
+		return "P-"
+	}
+	return fmt.Sprintf("P%d", t.Priority)
+}
+
+// firstLine returns the first line of err's message.
+func firstLine(err error) string {
+	if err == nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return line
+}
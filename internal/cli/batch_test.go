@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/ticket"
+)
+
+func writeTicketFile(t *testing.T, dir, id, frontmatter string) {
+	t.Helper()
+	content := "---\n" + frontmatter + "\n---\n# " + id + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, id+".md"), []byte(content), 0o644))
+}
+
+func TestQueryTickets(t *testing.T) {
+	personal := t.TempDir()
+	t.Setenv("TICKETS_DIR", personal)
+	writeTicketFile(t, personal, "p-low", "status: open\npriority: 3\ntags: [programmator]")
+	writeTicketFile(t, personal, "p-high", "status: open\npriority: 0\ntags: [programmator]")
+	writeTicketFile(t, personal, "p-none", "status: open\ntags: [programmator]")
+	writeTicketFile(t, personal, "p-closed", "status: closed\npriority: 0\ntags: [programmator]")
+	writeTicketFile(t, personal, "p-other", "status: open\npriority: 0\ntags: [docs]")
+	work := t.TempDir()
+	writeTicketFile(t, work, "w-1", "status: open\npriority: 1\ntags: [programmator]")
+	cfg := &config.Config{TicketWorkspaces: map[string]ticket.Workspace{"work": {Dir: work}}}
+
+	ids := func(query string) []string {
+		q, err := ticket.ParseQuery(query)
+		require.NoError(t, err)
+		tickets, err := queryTickets(cfg, q)
+		require.NoError(t, err)
+		var out []string
+		for _, bt := range tickets {
+			out = append(out, bt.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"p-high", "p-low", "p-none"}, ids("status:open label:programmator"))
+	assert.Equal(t, []string{"work:w-1"}, ids("workspace:work label:programmator"))
+
+	q, err := ticket.ParseQuery("workspace:missing")
+	require.NoError(t, err)
+	_, err = queryTickets(cfg, q)
+	assert.ErrorContains(t, err, `unknown ticket workspace "missing"`)
+}
+
+func TestRunBatch(t *testing.T) {
+	tickets := []batchTicket{
+		{ID: "t-1", Ticket: &ticket.Ticket{ID: "t-1"}},
+		{ID: "t-2", Ticket: &ticket.Ticket{ID: "t-2"}},
+		{ID: "t-3", Ticket: &ticket.Ticket{ID: "t-3"}},
+		{ID: "t-4", Ticket: &ticket.Ticket{ID: "t-4"}},
+	}
+	type call struct {
+		id    string
+		limit int
+	}
+	tests := []struct {
+		name      string
+		budget    int
+		results   map[string]*loop.Result
+		wantCalls []call
+		wantSkips []string
+	}{
+		{
+			name:   "no budget runs every ticket",
+			budget: 0,
+			results: map[string]*loop.Result{
+				"t-2": {ExitReason: safety.ExitReasonBlocked, Iterations: 9},
+			},
+			wantCalls: []call{{"t-1", 10}, {"t-2", 10}, {"t-3", 10}, {"t-4", 10}},
+			wantSkips: []string{"", "", "", ""},
+		},
+		{
+			name:   "budget caps and then skips",
+			budget: 15,
+			results: map[string]*loop.Result{
+				"t-1": {ExitReason: safety.ExitReasonComplete, Iterations: 8},
+				"t-2": {ExitReason: safety.ExitReasonMaxIterations, Iterations: 7},
+			},
+			wantCalls: []call{{"t-1", 10}, {"t-2", 7}},
+			wantSkips: []string{"", "", "budget spent", "budget spent"},
+		},
+		{
+			name:   "interrupt stops the batch",
+			budget: 0,
+			results: map[string]*loop.Result{
+				"t-2": {ExitReason: safety.ExitReasonUserInterrupt, Iterations: 1},
+			},
+			wantCalls: []call{{"t-1", 10}, {"t-2", 10}},
+			wantSkips: []string{"", "", "batch interrupted", "batch interrupted"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []call
+			entries := runBatch(tickets, 10, tc.budget, func(id string, maxIterations int) (*loop.Result, error) {
+				calls = append(calls, call{id, maxIterations})
+				if r, ok := tc.results[id]; ok {
+					return r, runExitError(r, nil)
+				}
+				return &loop.Result{ExitReason: safety.ExitReasonComplete, Iterations: 1}, nil
+			})
+			assert.Equal(t, tc.wantCalls, calls)
+			require.Len(t, entries, len(tickets))
+			for i, e := range entries {
+				assert.Equal(t, tc.wantSkips[i], e.Skipped, e.ID)
+			}
+		})
+	}
+}
+
+func TestPrintBatchSummary(t *testing.T) {
+	withPriority := &ticket.Ticket{Title: "Add caching", Priority: 1, Metadata: map[string]string{"priority": "1"}}
+	entries := []batchEntry{
+		{batchTicket: batchTicket{ID: "t-1", Ticket: withPriority},
+			Result: &loop.Result{ExitReason: safety.ExitReasonComplete, Iterations: 4, Duration: 90 * time.Second}},
+		{batchTicket: batchTicket{ID: "t-2", Ticket: &ticket.Ticket{Title: "Fix login"}},
+			Err: errors.New("run lock held by pid 42\nsecond line")},
+		{batchTicket: batchTicket{ID: "t-3", Ticket: &ticket.Ticket{Title: "Docs"}}, Skipped: "budget spent"},
+	}
+
+	var buf bytes.Buffer
+	printBatchSummary(&buf, "status:open", entries, 10)
+	out := buf.String()
+	assert.Contains(t, out, `Batch summary for "status:open":`)
+	assert.Contains(t, out, "  P1  t-1               Add caching                       complete                4 iter    1m 30s\n")
+	assert.Contains(t, out, "  P-  t-2               Fix login                         failed: run lock held by pid 42\n")
+	assert.Contains(t, out, "  P-  t-3               Docs                              skipped: budget spent\n")
+	assert.Contains(t, out, "Completed 1 of 3 tickets, 4 of 10 budgeted iterations\n")
+
+	buf.Reset()
+	printBatchSummary(&buf, "", entries[:1], 0)
+	assert.Contains(t, buf.String(), "Completed 1 of 1 tickets, 4 iterations\n")
+}
+
+func TestBatchExitError(t *testing.T) {
+	complete := batchEntry{Result: &loop.Result{ExitReason: safety.ExitReasonComplete}}
+	blocked := batchEntry{Result: &loop.Result{ExitReason: safety.ExitReasonBlocked}, Err: &ExitError{Code: ExitCodeBlocked}}
+	failed := batchEntry{Err: errors.New("invalid config")}
+	skipped := batchEntry{Skipped: "budget spent"}
+
+	assert.NoError(t, batchExitError([]batchEntry{complete, complete}))
+	assert.Equal(t, ExitCodeBlocked, ExitCode(batchExitError([]batchEntry{complete, blocked, failed})))
+	assert.Equal(t, ExitCodeFailure, ExitCode(batchExitError([]batchEntry{failed, blocked})))
+	assert.Equal(t, ExitCodeMaxIterations, ExitCode(batchExitError([]batchEntry{complete, skipped})))
+}
+
+// NOTE: Do not add t.Parallel() - this test mutates package-level variables.
+func TestRunRunQueryFlags(t *testing.T) {
+	defer func() { runQuery, runBudget, runMaxTurns = "", 0, 0 }()
+
+	runQuery = "status:open"
+	assert.ErrorContains(t, runRun(nil, []string{"a prompt"}), "--query runs tickets")
+	runMaxTurns = 5
+	assert.ErrorContains(t, runRun(nil, nil), "--query runs tickets")
+
+	runQuery, runMaxTurns, runBudget = "", 0, 10
+	assert.ErrorContains(t, runRun(nil, []string{"a prompt"}), "--budget requires --query")
+
+	runQuery, runBudget = "owner:me", 0
+	assert.ErrorContains(t, runRun(nil, nil), "unknown query field")
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+var calibrateWorkDir string
+
+var reviewCalibrateCmd = &cobra.Command{
+	Use:   "calibrate <cases-dir>",
+	Short: "Measure the review agents' precision and recall on known changes",
+	Long: `Run the configured review agents and validators on a set of known changes
+and report each agent's precision and recall, to tune focus lists and
+validator strictness before trusting the review.
+
+Each directory under <cases-dir> is one case:
+  change.diff     the change to review (a unified diff)
+  expected.yaml   the issues a review should find (optional)
+  base/           the files the diff applies to (optional when it only adds files)
+
+expected.yaml lists issues by file, with an optional line (findings within
+3 lines match) and agent (only that agent is expected to find it):
+
+  issues:
+    - file: internal/auth/token.go
+      line: 42
+      agent: bug-deep
+      description: token expiry is never checked
+
+Precision is the share of an agent's findings that match an expected issue;
+recall the share of the issues expected from it (its own and those without an
+agent) that it found. Cases run in scratch repositories, one at a time.
+
+Examples:
+  programmator review calibrate ./review-cases
+  programmator review calibrate ./review-cases -d /path/to/project`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReviewCalibrate,
+}
+
+func init() {
+	reviewCalibrateCmd.Flags().StringVarP(&calibrateWorkDir, "dir", "d", "", "Project whose review config is calibrated (default: current directory)")
+	reviewCmd.AddCommand(reviewCalibrateCmd)
+}
+
+func runReviewCalibrate(cmd *cobra.Command, args []string) error {
+	wd, err := resolveWorkingDir(calibrateWorkDir)
+	if err != nil {
+		return err
+	}
+	cases, err := review.LoadCalibrationCases(args[0])
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadForDir(wd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	llm.SetMaxConcurrentInvocations(cfg.MaxConcurrentInvocations)
+	reviewConfig, err := cfg.ToReviewConfig()
+	if err != nil {
+		return fmt.Errorf("invalid review config: %w", err)
+	}
+	reviewConfig = calibrationReviewConfig(reviewConfig, wd)
+
+	out := cmd.OutOrStdout()
+	cal := review.NewCalibration(reviewConfig.Agents)
+	for _, c := range cases {
+		if err := calibrateCase(context.Background(), out, reviewConfig, cal, c); err != nil {
+			fmt.Fprintf(out, "%s: %v\n", c.Name, err)
+		}
+	}
+	fmt.Fprintln(out)
+	printCalibration(out, cal)
+	return nil
+}
+
+// calibrationReviewConfig adapts the project's review config to scratch
+// repositories: every agent runs at every severity, prompt files resolve
+// against the project, and nothing persists between cases.
+func calibrationReviewConfig(cfg review.Config, wd string) review.Config {
+	agents := make([]review.AgentConfig, len(cfg.Agents))
+	for i, a := range cfg.Agents {
+		if a.PromptFile != "" && !filepath.IsAbs(a.PromptFile) {
+			a.PromptFile = filepath.Join(wd, a.PromptFile)
+		}
+		agents[i] = a
+	}
+	cfg.Agents = agents
+	cfg.Schedule = nil
+	cfg.HistoryDir = ""
+	cfg.SummaryDir = ""
+	cfg.FinalCheck = false
+	cfg.AcceptanceCheck = false
+	cfg.CodeOwners = false
+	cfg.FileDeferred = false
+	cfg.Severities = nil
+	return cfg
+}
+
+// calibrateCase reviews c in a scratch repository, its base committed and
+// its diff applied on top, and adds the findings to cal.
+func calibrateCase(ctx context.Context, out io.Writer, reviewConfig review.Config, cal *review.Calibration, c review.CalibrationCase) error {
+	dir, err := os.MkdirTemp("", "programmator-calibrate-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if base := filepath.Join(c.Dir, review.CalibrationBaseDir); isDir(base) {
+		if err := os.CopyFS(dir, os.DirFS(base)); err != nil {
+			return fmt.Errorf("copy base: %w", err)
+		}
+	}
+	if _, err := gitutil.InitRepo(dir, "calibration base"); err != nil {
+		return err
+	}
+	files, err := gitutil.PatchFiles(dir, c.Diff)
+	if err != nil {
+		return err
+	}
+	if err := gitutil.ApplyPatch(dir, c.Diff, false); err != nil {
+		return err
+	}
+
+	result, err := review.NewRunner(reviewConfig).RunIteration(ctx, dir, files)
+	if err != nil {
+		return fmt.Errorf("review: %w", err)
+	}
+	before := cal.Total
+	cal.Add(c, dir, result.Results)
+	fmt.Fprintf(out, "%s: %d finding(s), %d of %d expected issue(s) found\n",
+		c.Name, cal.Total.Findings-before.Findings, cal.Total.Found-before.Found, len(c.Expected))
+	return nil
+}
+
+// printCalibration prints a row per agent and one for all agents together.
+func printCalibration(out io.Writer, cal *review.Calibration) {
+	fmt.Fprintf(out, "%-24s %9s %10s %8s %7s\n", "AGENT", "FINDINGS", "PRECISION", "RECALL", "ERRORS")
+	row := func(s *review.AgentCalibration) {
+		fmt.Fprintf(out, "%-24s %9d %9.0f%% %7.0f%% %7d\n", s.Agent, s.Findings, 100*s.Precision(), 100*s.Recall(), s.Errors)
+	}
+	for _, s := range cal.Sorted() {
+		row(s)
+	}
+	row(&cal.Total)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+// issueInvoker reports the same issue for every review prompt.
+type issueInvoker struct{}
+
+func (issueInvoker) Invoke(context.Context, string, llm.InvokeOptions) (*llm.InvokeResult, error) {
+	return &llm.InvokeResult{Text: `REVIEW_RESULT:
+  issues:
+    - file: "token.go"
+      line: 3
+      severity: high
+      category: "bug"
+      description: "Expiry is never checked"
+  summary: "Found 1 issue"
+`}, nil
+}
+
+func TestCalibrateCase(t *testing.T) {
+	caseDir := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.MkdirAll(filepath.Join(caseDir, "base"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(caseDir, "base", "token.go"), []byte("package auth\n\nfunc valid() bool { return true }\n"), 0o644))
+	diff := `diff --git a/token.go b/token.go
+--- a/token.go
++++ b/token.go
+@@ -1,3 +1,3 @@
+ package auth
+ 
+-func valid() bool { return true }
++func valid() bool { return false }
+`
+	c := review.CalibrationCase{Name: "token", Dir: caseDir, Diff: diff,
+		Expected: []review.ExpectedIssue{{File: "token.go", Line: 3}, {File: "token.go", Agent: "tests"}}}
+
+	agents := []review.AgentConfig{{Name: "bugs"}, {Name: "tests"}}
+	cfg := calibrationReviewConfig(review.Config{Agents: agents, Invoker: issueInvoker{}, HistoryDir: t.TempDir()}, "/project")
+	cal := review.NewCalibration(agents)
+	var out bytes.Buffer
+	require.NoError(t, calibrateCase(context.Background(), &out, cfg, cal, c))
+
+	assert.Equal(t, "token: 2 finding(s), 2 of 2 expected issue(s) found\n", out.String())
+	assert.Equal(t, 2, cal.Total.Correct)
+
+	out.Reset()
+	printCalibration(&out, cal)
+	assert.Contains(t, out.String(), "AGENT")
+	assert.Regexp(t, `bugs\s+1\s+100%\s+100%\s+0`, out.String())
+	assert.Regexp(t, `all\s+2\s+100%\s+100%\s+0`, out.String())
+}
+
+func TestCalibrationReviewConfig(t *testing.T) {
+	cfg := calibrationReviewConfig(review.Config{
+		Agents:          []review.AgentConfig{{Name: "custom", PromptFile: "prompts/custom.md"}, {Name: "abs", PromptFile: "/etc/abs.md"}},
+		Schedule:        []review.StrictnessStep{{MinSeverity: review.SeverityHigh}},
+		HistoryDir:      "/state/history",
+		FinalCheck:      true,
+		AcceptanceCheck: true,
+	}, "/project")
+
+	assert.Equal(t, "/project/prompts/custom.md", cfg.Agents[0].PromptFile)
+	assert.Equal(t, "/etc/abs.md", cfg.Agents[1].PromptFile)
+	assert.Nil(t, cfg.Schedule)
+	assert.Empty(t, cfg.HistoryDir)
+	assert.False(t, cfg.FinalCheck)
+	assert.False(t, cfg.AcceptanceCheck)
+}
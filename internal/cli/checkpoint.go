@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// checkpointPath returns where the cumulative counts of sourceID's runs are
+// kept when limits apply to the work item's lifetime.
+func checkpointPath(sourceID string) string {
+	return strings.TrimSuffix(progressLogPath(sourceID), ".log") + ".checkpoint.json"
+}
+
+// workItemLimits carries iteration and token counts across runs of one
+// work item, so that restarting a run does not reset max_iterations.
+type workItemLimits struct {
+	path string
+	err  error // first failure to save, reported after the run
+}
+
+// attachWorkItemLimits makes l continue the counts saved by earlier runs of
+// sourceID and save its own after every iteration.
+func attachWorkItemLimits(l *loop.Loop, sourceID string) *workItemLimits {
+	wl := &workItemLimits{path: checkpointPath(sourceID)}
+	cp, err := safety.LoadCheckpoint(wl.path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; counting iterations from zero\n", err)
+	} else {
+		l.SetResumeCheckpoint(cp)
+	}
+	l.SetCheckpointRecorder(func(cp safety.Checkpoint) {
+		if err := safety.SaveCheckpoint(wl.path, cp); err != nil && wl.err == nil {
+			wl.err = err
+		}
+	})
+	return wl
+}
+
+// finish reports a failure to save and removes the checkpoint of a
+// completed work item, so a later run on it starts from zero.
+func (wl *workItemLimits) finish(result *loop.Result) {
+	if wl.err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save iteration counts: %v\n", wl.err)
+	}
+	if result != nil && result.ExitReason == safety.ExitReasonComplete {
+		if err := os.Remove(wl.path); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove %s: %v\n", wl.path, err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestWorkItemLimits_Finish(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	path := checkpointPath("plans/feature.md")
+	require.NoError(t, safety.SaveCheckpoint(path, safety.Checkpoint{Iterations: 4, Sessions: 1}))
+
+	wl := &workItemLimits{path: path}
+	wl.finish(&loop.Result{ExitReason: safety.ExitReasonMaxIterations})
+	assert.FileExists(t, path, "kept for the next run")
+
+	wl.finish(&loop.Result{ExitReason: safety.ExitReasonComplete})
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "removed once the work item completes")
+}
+
+func TestCheckpointPath(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	assert.Equal(t, checkpointPath("feature.md"), checkpointPath("plans/feature.md"))
+	assert.Contains(t, checkpointPath("feature.md"), "feature.checkpoint.json")
+}
@@ -2,11 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
 )
 
 var configCmd = &cobra.Command{
@@ -24,8 +27,9 @@ where each value came from.
 Configuration is loaded from multiple sources with the following precedence:
   1. Embedded defaults (built into binary)
   2. Global config (~/.config/programmator/config.yaml)
-  3. Local config (.programmator/config.yaml)
-  4. CLI flags (highest precedence)`,
+  3. Project config (.programmator.yaml in the working directory or a parent)
+  4. Local config (.programmator/config.yaml)
+  5. CLI flags (highest precedence)`,
 	RunE: runConfigShow,
 }
 
@@ -49,6 +53,11 @@ func runConfigShow(_ *cobra.Command, _ []string) error {
 
 	fmt.Println("## Directories")
 	fmt.Printf("  Global config: %s\n", cfg.ConfigDir())
+	if cfg.ProjectFile() != "" {
+		fmt.Printf("  Project config: %s\n", cfg.ProjectFile())
+	} else {
+		fmt.Printf("  Project config: (none detected)\n")
+	}
 	if cfg.LocalDir() != "" {
 		fmt.Printf("  Local config:  %s\n", cfg.LocalDir())
 	} else {
@@ -68,6 +77,16 @@ func runConfigShow(_ *cobra.Command, _ []string) error {
 
 	fmt.Println("## Executor Settings")
 	fmt.Printf("  executor: %s\n", cfg.Executor)
+	if len(cfg.ExecutorEnv) > 0 {
+		fmt.Println("  executor_env:")
+		for _, name := range slices.Sorted(maps.Keys(cfg.ExecutorEnv)) {
+			value := cfg.ExecutorEnv[name]
+			if llm.IsSecretEnv(name) {
+				value = "(set)"
+			}
+			fmt.Printf("    %s: %s\n", name, value)
+		}
+	}
 	fmt.Println()
 
 	fmt.Println("## Claude Settings")
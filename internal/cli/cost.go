@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// costEstimate is the estimated USD spend shown in the footer.
+type costEstimate struct {
+	iteration float64 // the running invocation; zero between invocations
+	total     float64 // the whole run, including the running invocation
+	live      bool    // an invocation is running
+}
+
+// estimateCost prices the token counts in state. Tokens of models missing
+// from prices are left out; ok is false when nothing could be priced.
+func estimateCost(state *safety.State, prices llm.PriceTable) (est costEstimate, ok bool) {
+	if state == nil || len(prices) == 0 {
+		return est, false
+	}
+	for model, tokens := range state.TokensByModel {
+		if price, found := prices.Lookup(model); found {
+			est.total += price.Cost(tokens.InputTokens, tokens.OutputTokens)
+			ok = true
+		}
+	}
+	if cur := state.CurrentIterTokens; cur != nil {
+		if price, found := prices.Lookup(state.Model); found {
+			est.iteration = price.Cost(cur.InputTokens, cur.OutputTokens)
+			est.total += est.iteration
+			est.live = true
+			ok = true
+		}
+	}
+	return est, ok
+}
+
+// formatCost renders the footer cost segment. Past the warning threshold it
+// alternates between two styles on each redraw so it flashes.
+func (w *Writer) formatCost(est costEstimate) string {
+	text := fmt.Sprintf("~$%.2f total", est.total)
+	if est.live {
+		text = fmt.Sprintf("~$%.2f iter, %s", est.iteration, text)
+	}
+
+	if w.costWarning <= 0 || est.total < w.costWarning {
+		return w.style(w.theme.text, text)
+	}
+	text = fmt.Sprintf("%s (over $%.2f)", text, w.costWarning)
+	w.costFlash = !w.costFlash
+	if w.costFlash && w.colorEnabled() {
+		return ansi(7, fgBold(w.theme.failure, text))
+	}
+	return w.styleBold(w.theme.failure, text)
+}
+
+// SetCostDisplay sets the price table for the footer cost estimate and the
+// run total, in USD, past which it flashes (0 = never). A nil table hides
+// the estimate.
+func (w *Writer) SetCostDisplay(prices llm.PriceTable, warnAt float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.prices = prices
+	w.costWarning = warnAt
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestEstimateCost(t *testing.T) {
+	prices := llm.PriceTable{"sonnet": {Input: 3, Output: 15}}
+
+	state := safety.NewState()
+	state.TokensByModel["claude-sonnet-4-5"] = &safety.ModelTokens{InputTokens: 1_000_000, OutputTokens: 100_000}
+	state.TokensByModel["local-llama"] = &safety.ModelTokens{InputTokens: 5_000_000}
+
+	est, ok := estimateCost(state, prices)
+	assert.True(t, ok)
+	assert.False(t, est.live)
+	assert.InDelta(t, 4.5, est.total, 1e-9, "unpriced models are left out")
+
+	state.Model = "claude-sonnet-4-5"
+	state.SetCurrentIterTokens(100_000, 0)
+	est, ok = estimateCost(state, prices)
+	assert.True(t, ok)
+	assert.True(t, est.live)
+	assert.InDelta(t, 0.3, est.iteration, 1e-9)
+	assert.InDelta(t, 4.8, est.total, 1e-9)
+
+	_, ok = estimateCost(safety.NewState(), prices)
+	assert.False(t, ok, "no tokens yet")
+	_, ok = estimateCost(state, nil)
+	assert.False(t, ok, "no price table")
+}
+
+func TestWriter_FormatCost(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriter(&buf)
+
+	assert.Equal(t, "~$1.50 total", w.formatCost(costEstimate{total: 1.5}))
+	assert.Equal(t, "~$0.25 iter, ~$1.50 total", w.formatCost(costEstimate{iteration: 0.25, total: 1.5, live: true}))
+
+	w.SetCostDisplay(llm.DefaultPrices(), 1)
+	assert.Equal(t, "~$1.50 total (over $1.00)", w.formatCost(costEstimate{total: 1.5}))
+
+	tty := newTestWriterTTY(&buf)
+	tty.SetCostDisplay(llm.DefaultPrices(), 1)
+	first := tty.formatCost(costEstimate{total: 1.5})
+	second := tty.formatCost(costEstimate{total: 1.5})
+	assert.NotEqual(t, first, second, "over the threshold the cost flashes between redraws")
+	assert.Contains(t, first, "\033[7m")
+	assert.Equal(t, first, tty.formatCost(costEstimate{total: 1.5}))
+	assert.Equal(t, fg(tty.theme.text, "~$0.50 total"), tty.formatCost(costEstimate{total: 0.5}))
+}
+
+func TestWriter_BuildFooterShowsCost(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriter(&buf)
+	w.prices = llm.PriceTable{"sonnet": {Input: 3, Output: 15}}
+
+	state := safety.NewState()
+	state.Iteration = 2
+	state.TokensByModel["claude-sonnet-4-5"] = &safety.ModelTokens{InputTokens: 1_000_000}
+
+	lines := w.buildFooter(state, nil, safety.Config{MaxIterations: 10})
+	assert.Contains(t, lines[1], "| ~$3.00 total")
+
+	w.prices = nil
+	lines = w.buildFooter(state, nil, safety.Config{MaxIterations: 10})
+	assert.NotContains(t, lines[1], "$")
+}
@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/cron"
+	"github.com/alexander-akhmetov/programmator/internal/plan"
+)
+
+// daemonStopGrace is how long a scheduled run gets to exit after SIGINT when
+// the daemon shuts down, before it is killed.
+const daemonStopGrace = 30 * time.Second
+
+var daemonWorkingDir string
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run configured schedules",
+	Long: `Run the entries under "schedules" in the config on their cron schedules
+until interrupted.
+
+Each schedule runs "programmator start" or "programmator review" as a child
+process, optionally with a config profile layered on top. A schedule whose
+target is a directory runs every incomplete plan in it, one after another.
+A schedule is skipped while another scheduled run is still working in the
+same directory.
+
+Example config:
+  schedules:
+    - name: nightly-review
+      cron: "0 2 * * *"
+      command: review
+      args: ["--base", "main"]
+    - name: weekend-backlog
+      cron: "0 9 * * sat,sun"
+      command: start
+      target: plans/backlog
+      profile: grind`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+func init() {
+	daemonCmd.Flags().StringVarP(&daemonWorkingDir, "dir", "d", "", "Working directory (default: current directory)")
+}
+
+func runDaemon(_ *cobra.Command, _ []string) error {
+	wd, err := resolveWorkingDir(daemonWorkingDir)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadForDir(wd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if len(cfg.Schedules) == 0 {
+		return fmt.Errorf("no schedules configured")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate programmator binary: %w", err)
+	}
+
+	out := &lockedWriter{w: os.Stdout}
+	d, err := newDaemon(cfg.Schedules, wd, out, execScheduledRun(exe, out))
+	if err != nil {
+		return err
+	}
+	d.workHours = cfg.Pacing.WorkHoursWindow()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	d.run(ctx)
+	return nil
+}
+
+// scheduledRunFunc runs one firing of a schedule in dir.
+type scheduledRunFunc func(ctx context.Context, s config.ScheduleConfig, dir string) error
+
+type scheduleJob struct {
+	cfg  config.ScheduleConfig
+	cron *cron.Schedule
+	dir  string
+	next time.Time
+}
+
+// daemon fires schedules and keeps at most one scheduled run per directory.
+type daemon struct {
+	jobs      []*scheduleJob
+	out       io.Writer
+	runJob    scheduledRunFunc
+	workHours cron.Window // schedules firing outside it are skipped
+
+	mu   sync.Mutex
+	busy map[string]string // dir -> name of the schedule running there
+	wg   sync.WaitGroup
+}
+
+func newDaemon(schedules []config.ScheduleConfig, workDir string, out io.Writer, runJob scheduledRunFunc) (*daemon, error) {
+	d := &daemon{out: out, runJob: runJob, busy: make(map[string]string)}
+	for _, s := range schedules {
+		c, err := cron.Parse(s.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", s.Name, err)
+		}
+		dir := workDir
+		if s.Dir != "" {
+			dir = s.Dir
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(workDir, dir)
+			}
+		}
+		d.jobs = append(d.jobs, &scheduleJob{cfg: s, cron: c, dir: filepath.Clean(dir)})
+	}
+	return d, nil
+}
+
+// run fires schedules until ctx is canceled, then waits for runs in flight.
+func (d *daemon) run(ctx context.Context) {
+	now := time.Now()
+	for _, j := range d.jobs {
+		j.next = j.cron.Next(now)
+		if j.next.IsZero() {
+			d.logf("%s: never fires, ignoring", j.cfg.Name)
+			continue
+		}
+		d.logf("%s: next run at %s", j.cfg.Name, j.next.Format("2006-01-02 15:04"))
+	}
+
+	for {
+		wake := d.nextWake()
+		if wake.IsZero() {
+			d.logf("no schedule will fire again")
+			break
+		}
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			d.logf("stopping, waiting for running schedules")
+			d.wg.Wait()
+			return
+		case <-timer.C:
+		}
+		d.dispatch(ctx, time.Now())
+	}
+	d.wg.Wait()
+}
+
+func (d *daemon) nextWake() time.Time {
+	var wake time.Time
+	for _, j := range d.jobs {
+		if !j.next.IsZero() && (wake.IsZero() || j.next.Before(wake)) {
+			wake = j.next
+		}
+	}
+	return wake
+}
+
+// dispatch starts every schedule due at now. A due schedule whose directory
+// is busy with another scheduled run, or that fires outside the work hours,
+// is skipped until its next activation.
+func (d *daemon) dispatch(ctx context.Context, now time.Time) {
+	for _, j := range d.jobs {
+		if j.next.IsZero() || j.next.After(now) {
+			continue
+		}
+		j.next = j.cron.Next(now)
+		if !d.workHours.Contains(now) {
+			d.logf("%s: skipped, outside work hours (%s)", j.cfg.Name, d.workHours)
+			continue
+		}
+
+		d.mu.Lock()
+		if owner, ok := d.busy[j.dir]; ok {
+			d.mu.Unlock()
+			d.logf("%s: skipped, %s is still running in %s", j.cfg.Name, owner, j.dir)
+			continue
+		}
+		d.busy[j.dir] = j.cfg.Name
+		d.mu.Unlock()
+
+		d.wg.Add(1)
+		go func(j *scheduleJob) {
+			defer d.wg.Done()
+			start := time.Now()
+			d.logf("%s: starting %s in %s", j.cfg.Name, j.cfg.Command, j.dir)
+			err := d.runJob(ctx, j.cfg, j.dir)
+
+			d.mu.Lock()
+			delete(d.busy, j.dir)
+			d.mu.Unlock()
+
+			elapsed := formatElapsed(time.Since(start))
+			if err != nil {
+				d.logf("%s: failed after %s: %v", j.cfg.Name, elapsed, err)
+			} else {
+				d.logf("%s: finished in %s", j.cfg.Name, elapsed)
+			}
+		}(j)
+	}
+}
+
+func (d *daemon) logf(format string, args ...any) {
+	fmt.Fprintf(d.out, "%s [daemon] %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+}
+
+// execScheduledRun returns a scheduledRunFunc that runs schedules as child
+// processes of exe, with output prefixed by the schedule name. A run that
+// ends blocked, at a limit, or stagnated does not stop the runs of the
+// other targets; it is reported once they are done.
+func execScheduledRun(exe string, out io.Writer) scheduledRunFunc {
+	return func(ctx context.Context, s config.ScheduleConfig, dir string) error {
+		targets, err := scheduleTargets(s, dir)
+		if err != nil {
+			return err
+		}
+		var unfinished []error
+		for _, target := range targets {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			cmd := exec.CommandContext(ctx, exe, scheduleArgs(s, dir, target)...)
+			cmd.Dir = dir
+			cmd.Env = os.Environ()
+			if s.Profile != "" {
+				cmd.Env = append(cmd.Env, config.ProfileEnv+"="+s.Profile)
+			}
+			// A non-file writer gives the child a pipe, so it prints plain
+			// text instead of drawing a footer.
+			w := &prefixWriter{prefix: "[" + s.Name + "] ", w: out}
+			cmd.Stdout = w
+			cmd.Stderr = w
+			cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+			cmd.WaitDelay = daemonStopGrace
+
+			err := cmd.Run()
+			w.Flush()
+			if err != nil {
+				if target != "" {
+					err = fmt.Errorf("%s: %w", target, err)
+				}
+				if !runUnfinished(err) {
+					return errors.Join(append(unfinished, err)...)
+				}
+				unfinished = append(unfinished, err)
+			}
+		}
+		return errors.Join(unfinished...)
+	}
+}
+
+// runUnfinished reports whether err is the exit of a child run that ended
+// blocked, at a limit, or stagnated, rather than failing.
+func runUnfinished(err error) bool {
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		return false
+	}
+	switch exit.ExitCode() {
+	case ExitCodeBlocked, ExitCodeMaxIterations, ExitCodeStagnation:
+		return true
+	}
+	return false
+}
+
+// scheduleTargets lists what a schedule runs on: nothing for review, the
+// target for start, or every plan with open tasks when the target is a
+// directory.
+func scheduleTargets(s config.ScheduleConfig, dir string) ([]string, error) {
+	if s.Command != "start" {
+		return []string{""}, nil
+	}
+	path := s.Target
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		// Not a directory: a plan file or ticket ID for start to resolve.
+		return []string{s.Target}, nil //nolint:nilerr // start reports missing sources itself
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var targets []string
+	for _, f := range files {
+		p, err := plan.ParseFile(f)
+		if err != nil || len(p.Tasks) == 0 || p.AllTasksComplete() {
+			continue
+		}
+		targets = append(targets, f)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no plans with open tasks in %s", s.Target)
+	}
+	return targets, nil
+}
+
+// scheduleArgs builds the child command line for one run.
+func scheduleArgs(s config.ScheduleConfig, dir, target string) []string {
+	args := []string{s.Command}
+	if target != "" {
+		args = append(args, target)
+	}
+	args = append(args, "--dir", dir)
+	return append(args, s.Args...)
+}
+
+// lockedWriter serializes writes from concurrent scheduled runs.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// prefixWriter writes complete lines to w, each starting with prefix.
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+	buf    bytes.Buffer
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf.Write(b)
+	for {
+		line, err := p.buf.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			// Keep the partial line for the next write.
+			p.buf.WriteString(line)
+			return len(b), nil
+		}
+		if _, err := io.WriteString(p.w, p.prefix+line); err != nil {
+			return len(b), err
+		}
+	}
+}
+
+// Flush writes any trailing partial line.
+func (p *prefixWriter) Flush() {
+	if p.buf.Len() > 0 {
+		io.WriteString(p.w, p.prefix+strings.TrimRight(p.buf.String(), "\n")+"\n") //nolint:errcheck // best effort
+		p.buf.Reset()
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/cron"
+)
+
+func TestDaemon_DispatchSkipsBusyDir(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var started []string
+	runJob := func(_ context.Context, s config.ScheduleConfig, _ string) error {
+		mu.Lock()
+		started = append(started, s.Name)
+		mu.Unlock()
+		<-release
+		return nil
+	}
+
+	var out bytes.Buffer
+	d, err := newDaemon([]config.ScheduleConfig{
+		{Name: "review", Cron: "* * * * *", Command: "review"},
+		{Name: "grind", Cron: "* * * * *", Command: "start", Target: "plans"},
+		{Name: "other", Cron: "* * * * *", Command: "review", Dir: "sub"},
+	}, "/repo", &lockedWriter{w: &out}, runJob)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for _, j := range d.jobs {
+		j.next = now
+	}
+	d.dispatch(context.Background(), now)
+
+	// The second firing of the same schedule overlaps with the first.
+	for _, j := range d.jobs {
+		j.next = now
+	}
+	d.dispatch(context.Background(), now)
+	close(release)
+	d.wg.Wait()
+
+	assert.ElementsMatch(t, []string{"review", "other"}, started)
+	assert.Contains(t, out.String(), "grind: skipped, review is still running in /repo")
+	assert.Contains(t, out.String(), "review: skipped, review is still running in /repo")
+	assert.Contains(t, out.String(), "other: starting review in "+filepath.Join("/repo", "sub"))
+	for _, j := range d.jobs {
+		assert.True(t, j.next.After(now), "next run should be rescheduled")
+	}
+	assert.Empty(t, d.busy)
+}
+
+func TestDaemon_DispatchSkipsOutsideWorkHours(t *testing.T) {
+	var started []string
+	runJob := func(_ context.Context, s config.ScheduleConfig, _ string) error {
+		started = append(started, s.Name)
+		return nil
+	}
+	var out bytes.Buffer
+	d, err := newDaemon([]config.ScheduleConfig{{Name: "nightly", Cron: "* * * * *", Command: "review"}}, "/repo", &out, runJob)
+	require.NoError(t, err)
+	d.workHours, err = cron.ParseWindow("22:00-06:00")
+	require.NoError(t, err)
+
+	noon := time.Date(2026, 3, 2, 12, 0, 0, 0, time.Local)
+	d.jobs[0].next = noon
+	d.dispatch(context.Background(), noon)
+	d.wg.Wait()
+	assert.Empty(t, started)
+	assert.Contains(t, out.String(), "nightly: skipped, outside work hours (22:00-06:00)")
+	assert.True(t, d.jobs[0].next.After(noon), "next run should be rescheduled")
+
+	night := time.Date(2026, 3, 2, 23, 0, 0, 0, time.Local)
+	d.jobs[0].next = night
+	d.dispatch(context.Background(), night)
+	d.wg.Wait()
+	assert.Equal(t, []string{"nightly"}, started)
+}
+
+func TestScheduleTargets_PlanDirectory(t *testing.T) {
+	dir := t.TempDir()
+	plans := filepath.Join(dir, "plans")
+	require.NoError(t, os.MkdirAll(plans, 0o755))
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(plans, name), []byte(content), 0o600))
+	}
+	write("b.md", "# B\n\n- [ ] Task 1\n")
+	write("a.md", "# A\n\n- [ ] Task 1\n")
+	write("done.md", "# Done\n\n- [x] Task 1\n")
+	write("notes.md", "# Notes\n\nno tasks\n")
+
+	targets, err := scheduleTargets(config.ScheduleConfig{Command: "start", Target: "plans"}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(plans, "a.md"), filepath.Join(plans, "b.md")}, targets)
+
+	write("a.md", "# A\n\n- [x] Task 1\n")
+	write("b.md", "# B\n\n- [x] Task 1\n")
+	_, err = scheduleTargets(config.ScheduleConfig{Command: "start", Target: "plans"}, dir)
+	require.ErrorContains(t, err, "no plans with open tasks")
+
+	targets, err = scheduleTargets(config.ScheduleConfig{Command: "start", Target: "TICKET-1"}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TICKET-1"}, targets)
+}
+
+func TestScheduleArgs(t *testing.T) {
+	assert.Equal(t,
+		[]string{"review", "--dir", "/repo", "--base", "main"},
+		scheduleArgs(config.ScheduleConfig{Command: "review", Args: []string{"--base", "main"}}, "/repo", ""),
+	)
+	assert.Equal(t,
+		[]string{"start", "plan.md", "--dir", "/repo", "--auto-commit"},
+		scheduleArgs(config.ScheduleConfig{Command: "start", Args: []string{"--auto-commit"}}, "/repo", "plan.md"),
+	)
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := &prefixWriter{prefix: "[x] ", w: &out}
+	_, err := w.Write([]byte("one\ntw"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("o\nthree"))
+	require.NoError(t, err)
+	w.Flush()
+	assert.Equal(t, "[x] one\n[x] two\n[x] three\n", out.String())
+}
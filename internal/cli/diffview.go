@@ -0,0 +1,331 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+)
+
+// Diff styles selectable with ui.diff, besides the default "unified".
+const (
+	diffStyleWord       = "word"
+	diffStyleSideBySide = "side-by-side"
+)
+
+// diffRenderer renders the diff lines of one edit, as the loop emits them,
+// into output lines.
+type diffRenderer func(w *Writer, lines []event.Event) []string
+
+// diffRenderers are the diff styles that render an edit's lines together.
+// Unified diffs are printed line by line, as they come.
+var diffRenderers = map[string]diffRenderer{
+	diffStyleWord:       renderWordDiff,
+	diffStyleSideBySide: renderSideBySideDiff,
+}
+
+const (
+	// diffIndent is how far the loop indents the lines of an edit's diff.
+	diffIndent = "      "
+	// diffFlushDelay is how long the writer waits for more lines of an
+	// edit before rendering the ones it has.
+	diffFlushDelay = 50 * time.Millisecond
+	// minSideBySideColumn is the narrowest column a side-by-side diff is
+	// drawn with; narrower terminals get a word diff.
+	minSideBySideColumn = 30
+	// maxWordDiffTokens caps the tokens compared per line pair, as the
+	// comparison is quadratic.
+	maxWordDiffTokens = 400
+)
+
+var diffTokenRegex = regexp.MustCompile(`[\p{L}\p{N}_]+|\s+|.`)
+
+// bufferDiffLocked holds back ev when it is a diff line and a renderer is
+// set, and schedules the held lines to be rendered once the edit's lines
+// stop coming.
+func (w *Writer) bufferDiffLocked(ev event.Event) bool {
+	if w.diffRenderer == nil || !w.isTTY {
+		return false
+	}
+	switch ev.Kind {
+	case event.KindDiffAdd, event.KindDiffDel, event.KindDiffCtx:
+	default:
+		return false
+	}
+	w.pendingDiff = append(w.pendingDiff, ev)
+	if w.diffTimer == nil {
+		w.diffTimer = time.AfterFunc(diffFlushDelay, func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			w.flushDiffLocked()
+		})
+	} else {
+		w.diffTimer.Reset(diffFlushDelay)
+	}
+	return true
+}
+
+// flushDiffLocked renders and prints the held diff lines.
+func (w *Writer) flushDiffLocked() {
+	if len(w.pendingDiff) == 0 {
+		return
+	}
+	lines := w.diffRenderer(w, w.pendingDiff)
+	w.pendingDiff = nil
+	w.diffTimer.Stop()
+	w.printLineLocked(strings.Join(lines, "\n"))
+}
+
+// diffBlock is a context line, or deleted lines with the lines added in
+// their place.
+type diffBlock struct {
+	context   string
+	isContext bool
+	del, add  []string
+}
+
+// diffBlocks groups an edit's diff lines, without their indentation and
+// signs.
+func diffBlocks(lines []event.Event) []diffBlock {
+	var blocks []diffBlock
+	for _, ev := range lines {
+		body := strings.TrimPrefix(ev.Text, diffIndent)
+		if body != "" && strings.ContainsRune("-+ ", rune(body[0])) {
+			body = body[1:]
+		}
+		switch {
+		case ev.Kind == event.KindDiffCtx:
+			blocks = append(blocks, diffBlock{context: body, isContext: true})
+			continue
+		case len(blocks) == 0 || blocks[len(blocks)-1].isContext,
+			ev.Kind == event.KindDiffDel && len(blocks[len(blocks)-1].add) > 0:
+			blocks = append(blocks, diffBlock{})
+		}
+		b := &blocks[len(blocks)-1]
+		if ev.Kind == event.KindDiffDel {
+			b.del = append(b.del, body)
+		} else {
+			b.add = append(b.add, body)
+		}
+	}
+	return blocks
+}
+
+// diffSegment is a run of a changed line, marked when it differs from the
+// line it is paired with.
+type diffSegment struct {
+	text    string
+	changed bool
+}
+
+// pairSegments splits the k-th deleted and added lines of b into segments,
+// marking the words that differ when both exist.
+func pairSegments(b diffBlock, k int) (del, add []diffSegment) {
+	switch {
+	case k < len(b.del) && k < len(b.add):
+		return wordDiff(b.del[k], b.add[k])
+	case k < len(b.del):
+		return []diffSegment{{text: b.del[k]}}, nil
+	default:
+		return nil, []diffSegment{{text: b.add[k]}}
+	}
+}
+
+// wordDiff compares two lines word by word and returns each as segments,
+// with the words not in their longest common subsequence marked. Lines
+// without a word in common, or too long to compare, are left unmarked.
+func wordDiff(a, b string) ([]diffSegment, []diffSegment) {
+	ta := diffTokenRegex.FindAllString(a, -1)
+	tb := diffTokenRegex.FindAllString(b, -1)
+	plain := func() ([]diffSegment, []diffSegment) {
+		return []diffSegment{{text: a}}, []diffSegment{{text: b}}
+	}
+	if len(ta) > maxWordDiffTokens || len(tb) > maxWordDiffTokens {
+		return plain()
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of ta[i:]
+	// and tb[j:].
+	lcs := make([][]int, len(ta)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(tb)+1)
+	}
+	for i := len(ta) - 1; i >= 0; i-- {
+		for j := len(tb) - 1; j >= 0; j-- {
+			if ta[i] == tb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var da, db []diffSegment
+	common := false
+	i, j := 0, 0
+	for i < len(ta) || j < len(tb) {
+		switch {
+		case i < len(ta) && j < len(tb) && ta[i] == tb[j]:
+			da = appendSegment(da, ta[i], false)
+			db = appendSegment(db, tb[j], false)
+			common = common || strings.TrimSpace(ta[i]) != ""
+			i++
+			j++
+		case j == len(tb) || (i < len(ta) && lcs[i+1][j] >= lcs[i][j+1]):
+			da = appendSegment(da, ta[i], true)
+			i++
+		default:
+			db = appendSegment(db, tb[j], true)
+			j++
+		}
+	}
+	if !common {
+		return plain()
+	}
+	return da, db
+}
+
+// appendSegment adds text to segs, joining it to the last segment when
+// both are marked alike.
+func appendSegment(segs []diffSegment, text string, changed bool) []diffSegment {
+	if n := len(segs); n > 0 && segs[n-1].changed == changed {
+		segs[n-1].text += text
+		return segs
+	}
+	return append(segs, diffSegment{text: text, changed: changed})
+}
+
+// renderWordDiff renders an edit as a unified diff whose changed lines mark
+// the words that differ from the line they replace or were replaced with:
+// in reverse video, or as [-word-] and {+word+} without color.
+func renderWordDiff(w *Writer, lines []event.Event) []string {
+	var out []string
+	for _, b := range diffBlocks(lines) {
+		if b.isContext {
+			out = append(out, w.formatDiffCtx(diffIndent+" "+b.context))
+			continue
+		}
+		added := make([]string, 0, len(b.add))
+		for k := range max(len(b.del), len(b.add)) {
+			del, add := pairSegments(b, k)
+			if del != nil {
+				out = append(out, w.paintDiffLine(diffIndent+"-", del, w.theme.diffDel, "[-", "-]"))
+			}
+			if add != nil {
+				added = append(added, w.paintDiffLine(diffIndent+"+", add, w.theme.diffAdd, "{+", "+}"))
+			}
+		}
+		out = append(out, added...)
+	}
+	return out
+}
+
+// paintDiffLine renders prefix and segs in color, changed segments in
+// reverse video; without color, changed segments are wrapped in open and
+// closing.
+func (w *Writer) paintDiffLine(prefix string, segs []diffSegment, color int, open, closing string) string {
+	var b strings.Builder
+	if !w.colorEnabled() {
+		b.WriteString(prefix)
+		for _, s := range segs {
+			if s.changed {
+				b.WriteString(open + s.text + closing)
+			} else {
+				b.WriteString(s.text)
+			}
+		}
+		return b.String()
+	}
+	b.WriteString(fg(color, prefix))
+	for _, s := range segs {
+		if s.changed {
+			b.WriteString(fgReverse(color, s.text))
+		} else {
+			b.WriteString(fg(color, s.text))
+		}
+	}
+	return b.String()
+}
+
+// renderSideBySideDiff renders an edit in two columns, the old lines on the
+// left and the new ones on the right, with the words that differ marked in
+// color. Lines too long for their column are cut. Terminals too narrow for
+// two columns get a word diff.
+func renderSideBySideDiff(w *Writer, lines []event.Event) []string {
+	column := (w.width - len(diffIndent) - 3) / 2
+	if column < minSideBySideColumn {
+		return renderWordDiff(w, lines)
+	}
+	row := func(left, right string) string {
+		return diffIndent + left + w.style(w.theme.muted, " │ ") + right
+	}
+
+	var out []string
+	for _, b := range diffBlocks(lines) {
+		if b.isContext {
+			cell := w.diffCell(" ", []diffSegment{{text: b.context}}, w.theme.diffCtx, column)
+			out = append(out, row(cell, cell))
+			continue
+		}
+		for k := range max(len(b.del), len(b.add)) {
+			del, add := pairSegments(b, k)
+			left := strings.Repeat(" ", column)
+			if del != nil {
+				left = w.diffCell("-", del, w.theme.diffDel, column)
+			}
+			right := ""
+			if add != nil {
+				right = w.diffCell("+", add, w.theme.diffAdd, column)
+			}
+			out = append(out, strings.TrimRight(row(left, right), " "))
+		}
+	}
+	return out
+}
+
+// diffCell renders sign and segs as a side-by-side column of width runes,
+// cut with "..." or padded with spaces. Changed segments are in reverse
+// video; without color they are not marked, to keep the columns aligned.
+func (w *Writer) diffCell(sign string, segs []diffSegment, color, width int) string {
+	segs = append([]diffSegment{{text: sign}}, segs...)
+	total := 0
+	for _, s := range segs {
+		total += utf8.RuneCountInString(s.text)
+	}
+	pad := width - total
+	if total > width {
+		segs = cutSegments(segs, width-3)
+		segs = append(segs, diffSegment{text: "..."})
+		pad = 0
+	}
+
+	var b strings.Builder
+	for _, s := range segs {
+		switch {
+		case !w.colorEnabled():
+			b.WriteString(s.text)
+		case s.changed:
+			b.WriteString(fgReverse(color, s.text))
+		default:
+			b.WriteString(fg(color, s.text))
+		}
+	}
+	b.WriteString(strings.Repeat(" ", pad))
+	return b.String()
+}
+
+// cutSegments keeps the first n runes of segs.
+func cutSegments(segs []diffSegment, n int) []diffSegment {
+	var out []diffSegment
+	for _, s := range segs {
+		runes := []rune(s.text)
+		if len(runes) >= n {
+			return append(out, diffSegment{text: string(runes[:n]), changed: s.changed})
+		}
+		out = append(out, s)
+		n -= len(runes)
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+)
+
+// editLines are the events the loop emits for an edit.
+func editLines() []event.Event {
+	return []event.Event{
+		event.DiffCtx(diffIndent + " func sum(a, b int) int {"),
+		event.DiffDel(diffIndent + "-\treturn a * b"),
+		event.DiffAdd(diffIndent + "+\treturn a + b"),
+		event.DiffAdd(diffIndent + "+\t// added"),
+		event.DiffCtx(diffIndent + " }"),
+	}
+}
+
+func newPlainTTYWriter(buf *bytes.Buffer) *Writer {
+	w := newTestWriterTTY(buf)
+	w.theme.noColor = true
+	return w
+}
+
+func TestWordDiff(t *testing.T) {
+	del, add := wordDiff("x := foo(1, bar)", "x := foo(2, bar)")
+	assert.Equal(t, []diffSegment{{text: "x := foo("}, {text: "1", changed: true}, {text: ", bar)"}}, del)
+	assert.Equal(t, []diffSegment{{text: "x := foo("}, {text: "2", changed: true}, {text: ", bar)"}}, add)
+
+	del, add = wordDiff("alpha beta", "gamma delta")
+	assert.Equal(t, []diffSegment{{text: "alpha beta"}}, del, "nothing in common is not marked")
+	assert.Equal(t, []diffSegment{{text: "gamma delta"}}, add)
+}
+
+func TestDiffBlocks(t *testing.T) {
+	blocks := diffBlocks(editLines())
+	require.Len(t, blocks, 3)
+	assert.Equal(t, diffBlock{context: "func sum(a, b int) int {", isContext: true}, blocks[0])
+	assert.Equal(t, diffBlock{del: []string{"\treturn a * b"}, add: []string{"\treturn a + b", "\t// added"}}, blocks[1])
+	assert.Equal(t, diffBlock{context: "}", isContext: true}, blocks[2])
+}
+
+func TestRenderWordDiff(t *testing.T) {
+	var buf bytes.Buffer
+	lines := renderWordDiff(newPlainTTYWriter(&buf), editLines())
+	assert.Equal(t, []string{
+		diffIndent + " func sum(a, b int) int {",
+		diffIndent + "-\treturn a [-*-] b",
+		diffIndent + "+\treturn a {+++} b",
+		diffIndent + "+\t// added",
+		diffIndent + " }",
+	}, lines)
+
+	colored := renderWordDiff(newTestWriterTTY(&buf), editLines())
+	assert.Contains(t, colored[1], fmt.Sprintf("\033[7;38;5;%dm*\033[0m", colorRed))
+	assert.Contains(t, colored[2], fmt.Sprintf("\033[7;38;5;%dm+\033[0m", colorGreen))
+}
+
+func TestRenderSideBySideDiff(t *testing.T) {
+	var buf bytes.Buffer
+	w := newPlainTTYWriter(&buf)
+	w.width = 6 + 3 + 2*30
+
+	lines := renderSideBySideDiff(w, []event.Event{
+		event.DiffDel(diffIndent + "-old " + strings.Repeat("x", 40)),
+		event.DiffAdd(diffIndent + "+new"),
+		event.DiffAdd(diffIndent + "+more"),
+	})
+	assert.Equal(t, []string{
+		diffIndent + "-old " + strings.Repeat("x", 22) + "..." + " │ +new",
+		diffIndent + strings.Repeat(" ", 30) + " │ +more",
+	}, lines)
+
+	w.width = 40
+	assert.Equal(t, renderWordDiff(w, editLines()), renderSideBySideDiff(w, editLines()), "narrow terminals get a word diff")
+}
+
+func TestWriter_DiffStyleRendersEditTogether(t *testing.T) {
+	var buf bytes.Buffer
+	w := newPlainTTYWriter(&buf)
+	w.SetDiffStyle("word")
+
+	for _, ev := range editLines() {
+		w.WriteEvent(ev)
+	}
+	assert.Empty(t, buf.String(), "diff lines wait for the rest of the edit")
+
+	w.WriteEvent(event.ToolUse("Read main.go"))
+	output := buf.String()
+	assert.Contains(t, output, diffIndent+"-    return a [-*-] b\n")
+	assert.Less(t, strings.Index(output, "[-*-]"), strings.Index(output, "Read main.go"))
+
+	buf.Reset()
+	w.WriteEvent(event.DiffDel(diffIndent + "-gone"))
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return strings.Contains(buf.String(), "-gone")
+	}, time.Second, 10*time.Millisecond, "held lines are printed once no more come")
+}
+
+func TestWriter_UnifiedDiffStyleIsNotHeld(t *testing.T) {
+	var buf bytes.Buffer
+	w := newPlainTTYWriter(&buf)
+	w.SetDiffStyle("unified")
+
+	w.WriteEvent(event.DiffDel(diffIndent + "-old"))
+	assert.Contains(t, buf.String(), "-old")
+}
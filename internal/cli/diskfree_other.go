@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package cli
+
+import "errors"
+
+// diskFree is not implemented on this platform; the preflight check is skipped.
+func diskFree(string) (uint64, error) {
+	return 0, errors.New("not supported on this platform")
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// Limits on what a post-mortem prompt embeds. The log keeps its tail, where
+// the failure is; the diff and work item keep their head.
+const (
+	doctorLogLimit      = 60000
+	doctorDiffLimit     = 60000
+	doctorWorkItemLimit = 20000
+)
+
+var doctorWorkingDir string
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose past runs",
+}
+
+var doctorRunCmd = &cobra.Command{
+	Use:   "run <ticket-id>",
+	Short: "Write a post-mortem of the last run of a plan or ticket",
+	Long: `Analyze the last run of a plan or ticket, typically one that stagnated,
+got blocked, or hit a limit.
+
+The progress log, iteration summaries, the diff since the run started, and the
+plan or ticket are given to the configured executor, which writes a post-mortem:
+what went wrong, suggested plan changes, and safer limits. The post-mortem is
+printed and saved next to the progress log as <name>.postmortem.md.
+
+Examples:
+  programmator doctor run ./plans/feature.md
+  programmator doctor run pro-1a2b`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDoctorRun,
+}
+
+func init() {
+	doctorRunCmd.Flags().StringVarP(&doctorWorkingDir, "dir", "d", "", "Working directory (default: current directory)")
+	doctorCmd.AddCommand(doctorRunCmd)
+}
+
+func runDoctorRun(_ *cobra.Command, args []string) error {
+	sourceID := args[0]
+
+	wd, err := resolveWorkingDir(doctorWorkingDir)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadForDir(wd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	inv, err := executor.New(cfg.ToExecutorConfig())
+	if err != nil {
+		return fmt.Errorf("create invoker: %w", err)
+	}
+
+	logPath := progressLogPath(sourceID)
+	fmt.Printf("Analyzing last run from %s\n\n", logPath)
+	outPath, report, err := writePostMortem(context.Background(), inv, cfg, sourceID, wd, logPath)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report)
+	fmt.Printf("\nPost-mortem saved to %s\n", outPath)
+	return nil
+}
+
+// writePostMortem asks inv to analyze the last run in logPath, in an
+// invocation that may not change files, and saves the report next to it. Returns the report path and contents.
+func writePostMortem(ctx context.Context, inv llm.Invoker, cfg *config.Config, sourceID, workingDir, logPath string) (string, string, error) {
+	data, err := os.ReadFile(logPath) //nolint:gosec // path under the state dir
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", fmt.Errorf("no progress log for %s at %s", sourceID, logPath)
+		}
+		return "", "", fmt.Errorf("read progress log: %w", err)
+	}
+	run, err := lastProgressRun(string(data))
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", logPath, err)
+	}
+
+	runDir := run.Dir
+	if runDir == "" {
+		runDir = workingDir
+	}
+	diff := ""
+	if run.Base != "" {
+		if repo, err := gitutil.NewRepo(runDir); err == nil {
+			diff, _ = repo.DiffFrom(run.Base)
+		}
+	}
+	workItem := ""
+	id := sourceID
+	if source.IsPlanPath(id) && !filepath.IsAbs(id) {
+		id = filepath.Join(workingDir, id)
+	}
+	src, id := source.Detect(id, cfg.TicketCommand, cfg.TicketWorkspaces)
+	if item, err := src.Get(id); err == nil {
+		workItem = item.RawContent
+	}
+
+	flags, err := cfg.ToExecutorConfig().ReadOnlyFlags(llm.PurposeReview)
+	if err != nil {
+		return "", "", err
+	}
+
+	out, err := inv.Invoke(ctx, buildPostMortemPrompt(sourceID, run.Log, diff, workItem, cfg), llm.InvokeOptions{
+		WorkingDir: runDir,
+		ExtraFlags: flags,
+		Purpose:    llm.PurposeReview,
+		Timeout:    cfg.Timeout,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("invoke executor: %w", err)
+	}
+	report := strings.TrimSpace(out.Text)
+	if report == "" {
+		return "", "", fmt.Errorf("executor returned an empty post-mortem")
+	}
+
+	outPath := strings.TrimSuffix(logPath, ".log") + ".postmortem.md"
+	if err := os.WriteFile(outPath, []byte(report+"\n"), 0o600); err != nil {
+		return "", "", fmt.Errorf("write post-mortem: %w", err)
+	}
+	return outPath, report, nil
+}
+
+// buildPostMortemPrompt describes the finished run and asks for an analysis.
+func buildPostMortemPrompt(sourceID, runLog, diff, workItem string, cfg *config.Config) string {
+	var b strings.Builder
+
+	b.WriteString("You are reviewing a finished run of an autonomous coding agent that did not go well. ")
+	b.WriteString("Do not modify any files. Analyze what happened and write a post-mortem in markdown.\n\n")
+
+	fmt.Fprintf(&b, "## Work item\n\n%s\n\n", sourceID)
+	if workItem != "" {
+		if len(workItem) > doctorWorkItemLimit {
+			workItem = workItem[:doctorWorkItemLimit] + "\n(truncated)"
+		}
+		fmt.Fprintf(&b, "```markdown\n%s\n```\n\n", strings.TrimSpace(workItem))
+	}
+
+	fmt.Fprintf(&b, "## Limits used\n\n- max_iterations: %d\n- stagnation_limit: %d\n- timeout: %ds per invocation\n- review.max_iterations: %d\n\n",
+		cfg.MaxIterations, cfg.StagnationLimit, cfg.Timeout, cfg.Review.MaxIterations)
+
+	b.WriteString("## Progress log\n\n")
+	if len(runLog) > doctorLogLimit {
+		runLog = "(earlier lines truncated)\n" + runLog[len(runLog)-doctorLogLimit:]
+	}
+	fmt.Fprintf(&b, "```\n%s\n```\n\n", strings.TrimSpace(runLog))
+
+	b.WriteString("## Diff since the run started\n\n")
+	switch {
+	case diff == "":
+		b.WriteString("(no changes or diff unavailable)\n\n")
+	case len(diff) > doctorDiffLimit:
+		fmt.Fprintf(&b, "```diff\n%s\n```\n(diff truncated)\n\n", diff[:doctorDiffLimit])
+	default:
+		fmt.Fprintf(&b, "```diff\n%s\n```\n\n", strings.TrimSpace(diff))
+	}
+
+	b.WriteString(`## Report
+
+Write the post-mortem with exactly these sections:
+
+### What went wrong
+The root cause, with evidence from the log and diff. Distinguish agent mistakes from plan problems and environment issues.
+
+### Suggested plan changes
+Concrete edits to the plan or ticket: tasks to split, reorder, clarify, or drop, and missing context or validation commands.
+
+### Suggested limits
+Values for the limits above that would have stopped the run earlier or let it finish, with a one-line reason each. Say "keep" when a limit was fine.
+
+Output only the markdown report.`)
+
+	return b.String()
+}
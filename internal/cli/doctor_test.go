@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+type promptRecorder struct {
+	prompt string
+	reply  string
+}
+
+func (p *promptRecorder) Invoke(_ context.Context, prompt string, _ llm.InvokeOptions) (*llm.InvokeResult, error) {
+	p.prompt = prompt
+	return &llm.InvokeResult{Text: p.reply}, nil
+}
+
+func TestWritePostMortem(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	wd := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(wd, "feature.md"), []byte("# Plan: Feature\n\n- [ ] Task 1: do it\n"), 0o600))
+
+	plog, err := openProgressLog("feature.md", wd, nil)
+	require.NoError(t, err)
+	plog.event(event.Prog("Summary: tried the same fix again"))
+	plog.finish(&loop.Result{ExitReason: safety.ExitReasonStagnation, Iterations: 3}, nil)
+
+	cfg, err := config.LoadWithDirs(t.TempDir(), "")
+	require.NoError(t, err)
+	inv := &optionsRecorder{promptRecorder: promptRecorder{reply: "### What went wrong\nLooped.\n"}}
+
+	outPath, report, err := writePostMortem(context.Background(), inv, cfg, "feature.md", wd, progressLogPath("feature.md"))
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(filepath.Dir(progressLogPath("feature.md")), "feature.postmortem.md"), outPath)
+	assert.Equal(t, "### What went wrong\nLooped.", report)
+	saved, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Equal(t, report+"\n", string(saved))
+
+	assert.Contains(t, inv.prompt, "Summary: tried the same fix again")
+	assert.Contains(t, inv.prompt, "exit: stagnation")
+	assert.Contains(t, inv.prompt, "- [ ] Task 1: do it")
+	assert.Contains(t, inv.prompt, "- max_iterations: 50")
+	assert.Contains(t, inv.prompt, "### Suggested limits")
+	assert.Contains(t, inv.opts.ExtraFlags, "--settings", "claude runs read-only")
+}
+
+func TestWritePostMortem_NoLog(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	cfg, err := config.LoadWithDirs(t.TempDir(), "")
+	require.NoError(t, err)
+
+	_, _, err = writePostMortem(context.Background(), &promptRecorder{}, cfg, "missing", t.TempDir(), progressLogPath("missing"))
+	require.ErrorContains(t, err, "no progress log for missing")
+}
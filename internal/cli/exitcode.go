@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// Process exit codes of start and rerun by how the run ended. They are
+// stable, so CI scripts can branch on them.
+const (
+	ExitCodeComplete      = 0
+	ExitCodeFailure       = 1 // the command failed before or outside a run: flags, config, run lock
+	ExitCodeBlocked       = 2
+	ExitCodeMaxIterations = 3 // max_iterations and max_review_retries
+	ExitCodeStagnation    = 4 // stagnation and review_not_converging
+	ExitCodeError         = 5 // error and review_failed
+	ExitCodeInterrupted   = 130
+)
+
+// exitCodes maps every exit reason to its process exit code.
+var exitCodes = map[safety.ExitReason]int{
+	safety.ExitReasonComplete:         ExitCodeComplete,
+	safety.ExitReasonBlocked:          ExitCodeBlocked,
+	safety.ExitReasonMaxIterations:    ExitCodeMaxIterations,
+	safety.ExitReasonMaxReviewRetries: ExitCodeMaxIterations,
+	safety.ExitReasonStagnation:       ExitCodeStagnation,
+	safety.ExitReasonReviewStuck:      ExitCodeStagnation,
+	safety.ExitReasonError:            ExitCodeError,
+	safety.ExitReasonReviewFailed:     ExitCodeError,
+	safety.ExitReasonUserInterrupt:    ExitCodeInterrupted,
+}
+
+// ExitCodeFor returns the process exit code for a run that ended with
+// reason; ExitCodeError for reasons it does not know.
+func ExitCodeFor(reason safety.ExitReason) int {
+	if code, ok := exitCodes[reason]; ok {
+		return code
+	}
+	return ExitCodeError
+}
+
+// ExitError ends the process with Code. Without Err nothing is printed:
+// the run summary already said how the run ended.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for err, the error Execute
+// returned: 0 for nil, the code of an ExitError, ExitCodeFailure otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeComplete
+	}
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+	return ExitCodeFailure
+}
+
+// runExitError returns the error ending start or rerun after Run returned
+// result and runErr: nil for a completed run.
+func runExitError(result *loop.Result, runErr error) error {
+	if runErr != nil {
+		return &ExitError{Code: ExitCodeError, Err: fmt.Errorf("loop error: %w", runErr)}
+	}
+	if code := ExitCodeFor(result.ExitReason); code != ExitCodeComplete {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
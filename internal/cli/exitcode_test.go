@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	for _, reason := range safety.ExitReasons {
+		_, ok := exitCodes[reason]
+		assert.True(t, ok, "exit reason %q has no exit code", reason)
+	}
+
+	assert.Equal(t, 0, ExitCodeFor(safety.ExitReasonComplete))
+	assert.Equal(t, 2, ExitCodeFor(safety.ExitReasonBlocked))
+	assert.Equal(t, 3, ExitCodeFor(safety.ExitReasonMaxIterations))
+	assert.Equal(t, 4, ExitCodeFor(safety.ExitReasonStagnation))
+	assert.Equal(t, 5, ExitCodeFor(safety.ExitReasonError))
+	assert.Equal(t, 130, ExitCodeFor(safety.ExitReasonUserInterrupt))
+	assert.Equal(t, 5, ExitCodeFor("unknown"))
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, ExitCode(nil))
+	assert.Equal(t, 1, ExitCode(errors.New("invalid config")))
+	assert.Equal(t, 4, ExitCode(fmt.Errorf("wrapped: %w", &ExitError{Code: 4})))
+}
+
+func TestRunExitError(t *testing.T) {
+	require.NoError(t, runExitError(&loop.Result{ExitReason: safety.ExitReasonComplete}, nil))
+
+	err := runExitError(&loop.Result{ExitReason: safety.ExitReasonStagnation}, nil)
+	assert.Equal(t, 4, ExitCode(err))
+	var exit *ExitError
+	require.ErrorAs(t, err, &exit)
+	assert.Nil(t, exit.Err, "the summary reports the outcome")
+
+	err = runExitError(&loop.Result{ExitReason: safety.ExitReasonError}, errors.New("source unavailable"))
+	assert.Equal(t, 5, ExitCode(err))
+	assert.EqualError(t, err, "loop error: source unavailable")
+}
+
+func TestRunUnfinished(t *testing.T) {
+	for code, want := range map[int]bool{2: true, 3: true, 4: true, 1: false, 5: false, 130: false} {
+		err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+		assert.Equal(t, want, runUnfinished(err), "exit %d", code)
+	}
+	assert.False(t, runUnfinished(errors.New("start failed")))
+}
@@ -0,0 +1,425 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+)
+
+// Transcript export formats.
+const (
+	exportFormatMarkdown = "markdown"
+	exportFormatHTML     = "html"
+)
+
+var (
+	exportRun    int
+	exportFormat string
+	exportOutput string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <ticket-id>",
+	Short: "Export a past run's iterations as a Markdown or HTML transcript",
+	Long: `Export what the agent did during a past run of a plan or ticket as a
+readable document, for reviewers who were not watching the terminal.
+
+Every run records, next to its progress log, each iteration's prompt, the
+tool calls the executor made, the status block it returned, and the lines it
+changed per file. Export collates them into one Markdown or HTML document.
+
+Examples:
+  programmator export ./plans/feature.md > transcript.md
+  programmator export pro-1a2b --format html -o transcript.html
+  programmator export pro-1a2b --run 2`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().IntVar(&exportRun, "run", 1, "Export the Nth most recent run (1 = last)")
+	exportCmd.Flags().StringVar(&exportFormat, "format", exportFormatMarkdown, "Output format: markdown or html")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of stdout")
+}
+
+func runExport(_ *cobra.Command, args []string) error {
+	if exportFormat != exportFormatMarkdown && exportFormat != exportFormatHTML {
+		return fmt.Errorf("unknown format %q (supported: %s, %s)", exportFormat, exportFormatMarkdown, exportFormatHTML)
+	}
+
+	path := transcriptLogPath(args[0])
+	runs, err := readTranscriptRuns(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no recorded runs for %s at %s", args[0], path)
+		}
+		return err
+	}
+	if exportRun < 1 || exportRun > len(runs) {
+		return fmt.Errorf("--run %d: %s has %d recorded run(s)", exportRun, path, len(runs))
+	}
+	run := runs[len(runs)-exportRun]
+
+	out := io.Writer(os.Stdout)
+	if exportOutput != "" {
+		f, err := os.Create(exportOutput)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", exportOutput, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if exportFormat == exportFormatHTML {
+		return writeTranscriptHTML(out, run)
+	}
+	writeTranscriptMarkdown(out, run)
+	return nil
+}
+
+// transcriptLogPath returns where the iterations of sourceID's runs are
+// recorded, next to its progress log.
+func transcriptLogPath(sourceID string) string {
+	return strings.TrimSuffix(progressLogPath(sourceID), ".log") + ".transcript.jsonl"
+}
+
+// transcriptEntry is one line of a transcript log. Exactly one field is set:
+// a run opens with Start, records an Iteration per invocation, and closes
+// with Exit.
+type transcriptEntry struct {
+	Start     *transcriptStart      `json:"start,omitempty"`
+	Iteration *loop.IterationRecord `json:"iteration,omitempty"`
+	Exit      *transcriptExit       `json:"exit,omitempty"`
+}
+
+type transcriptStart struct {
+	ID     string    `json:"id"`
+	Dir    string    `json:"dir"`
+	Time   time.Time `json:"time"`
+	Labels []string  `json:"labels,omitempty"`
+}
+
+type transcriptExit struct {
+	Reason       string   `json:"reason"`
+	Message      string   `json:"message,omitempty"`
+	Iterations   int      `json:"iterations"`
+	FilesChanged []string `json:"files_changed,omitempty"`
+	Duration     string   `json:"duration,omitempty"`
+}
+
+// transcriptRun is a run read back from a transcript log.
+type transcriptRun struct {
+	Start      transcriptStart
+	Iterations []loop.IterationRecord
+	Exit       *transcriptExit // nil when the run was interrupted
+}
+
+// transcriptLog appends a run's iterations to a JSON-lines file.
+type transcriptLog struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func openTranscriptLog(sourceID, workingDir string, labels []string) (*transcriptLog, error) {
+	path := transcriptLogPath(sourceID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create logs dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gosec // path under the state dir
+	if err != nil {
+		return nil, fmt.Errorf("open transcript log: %w", err)
+	}
+	t := &transcriptLog{f: f, enc: json.NewEncoder(f)}
+	_ = t.enc.Encode(transcriptEntry{Start: &transcriptStart{ID: sourceID, Dir: workingDir, Time: time.Now(), Labels: labels}})
+	return t, nil
+}
+
+func (t *transcriptLog) record(rec loop.IterationRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_ = t.enc.Encode(transcriptEntry{Iteration: &rec})
+}
+
+// finish records how the run ended and closes the log.
+func (t *transcriptLog) finish(result *loop.Result, runErr error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	exit := &transcriptExit{}
+	switch {
+	case runErr != nil:
+		exit.Reason = "error"
+		exit.Message = runErr.Error()
+	case result != nil:
+		exit.Reason = string(result.ExitReason)
+		exit.Message = result.ExitMessage
+	}
+	if result != nil {
+		exit.Iterations = result.Iterations
+		exit.FilesChanged = result.TotalFilesChanged
+		exit.Duration = formatElapsed(result.Duration)
+	}
+	_ = t.enc.Encode(transcriptEntry{Exit: exit})
+	t.f.Close()
+}
+
+// readTranscriptRuns reads a transcript log and splits it into runs, oldest
+// first.
+func readTranscriptRuns(path string) ([]transcriptRun, error) {
+	f, err := os.Open(path) //nolint:gosec // path under the state dir
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []transcriptRun
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e transcriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if e.Start != nil || len(runs) == 0 {
+			runs = append(runs, transcriptRun{})
+		}
+		run := &runs[len(runs)-1]
+		switch {
+		case e.Start != nil:
+			run.Start = *e.Start
+		case e.Iteration != nil:
+			run.Iterations = append(run.Iterations, *e.Iteration)
+		case e.Exit != nil:
+			run.Exit = e.Exit
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read transcript log: %w", err)
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("%s has no recorded runs", path)
+	}
+	return runs, nil
+}
+
+// toolSummary counts tool calls by tool name, most used first, e.g.
+// "Read ×4, Edit ×2".
+func toolSummary(rec loop.IterationRecord) string {
+	counts := make(map[string]int)
+	var names []string
+	for _, line := range rec.Tools {
+		name, _, _ := strings.Cut(line, " ")
+		if counts[name] == 0 {
+			names = append(names, name)
+		}
+		counts[name]++
+	}
+	slices.SortStableFunc(names, func(a, b string) int { return counts[b] - counts[a] })
+
+	parts := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s ×%d", name, counts[name]))
+	}
+	if rec.ToolsDropped > 0 {
+		parts = append(parts, fmt.Sprintf("%d more not recorded", rec.ToolsDropped))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// iterationTitle names an iteration by what it worked on.
+func iterationTitle(rec loop.IterationRecord) string {
+	title := fmt.Sprintf("Iteration %d", rec.Iteration)
+	switch {
+	case rec.ReviewFix:
+		title += " — review fixes"
+	case rec.Phase != "":
+		title += " — " + rec.Phase
+	}
+	return title
+}
+
+// runOutcome describes how a run ended in one line.
+func runOutcome(run transcriptRun) string {
+	if run.Exit == nil {
+		return "did not finish (interrupted or still running)"
+	}
+	s := run.Exit.Reason
+	if run.Exit.Message != "" {
+		s += " (" + run.Exit.Message + ")"
+	}
+	return fmt.Sprintf("%s after %d iteration(s), %d file(s) changed in %s",
+		s, run.Exit.Iterations, len(run.Exit.FilesChanged), run.Exit.Duration)
+}
+
+// mdFence returns a code fence longer than any backtick run in s.
+func mdFence(s string) string {
+	longest, cur := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			cur++
+			longest = max(longest, cur)
+		} else {
+			cur = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func writeTranscriptMarkdown(w io.Writer, run transcriptRun) {
+	fmt.Fprintf(w, "# Run transcript: %s\n\n", run.Start.ID)
+	if !run.Start.Time.IsZero() {
+		fmt.Fprintf(w, "- Started: %s\n", run.Start.Time.Local().Format("2006-01-02 15:04:05"))
+	}
+	if run.Start.Dir != "" {
+		fmt.Fprintf(w, "- Directory: `%s`\n", run.Start.Dir)
+	}
+	if len(run.Start.Labels) > 0 {
+		fmt.Fprintf(w, "- Labels: %s\n", strings.Join(run.Start.Labels, ", "))
+	}
+	fmt.Fprintf(w, "- Outcome: %s\n", runOutcome(run))
+
+	for _, rec := range run.Iterations {
+		fmt.Fprintf(w, "\n## %s\n\n", iterationTitle(rec))
+		if !rec.Time.IsZero() {
+			fmt.Fprintf(w, "_%s, %s_\n\n", rec.Time.Local().Format("15:04:05"), rec.Duration)
+		}
+
+		label := "Prompt"
+		if rec.Resumed {
+			label = "Prompt (continued session)"
+		}
+		fence := mdFence(rec.Prompt)
+		fmt.Fprintf(w, "<details>\n<summary>%s</summary>\n\n%stext\n%s\n%s\n\n</details>\n\n",
+			label, fence, strings.TrimRight(rec.Prompt, "\n"), fence)
+
+		fmt.Fprintf(w, "**Tools:** %s\n\n", toolSummary(rec))
+		if len(rec.Tools) > 0 {
+			b := strings.Join(rec.Tools, "\n")
+			fence := mdFence(b)
+			fmt.Fprintf(w, "<details>\n<summary>Tool calls</summary>\n\n%s\n%s\n%s\n\n</details>\n\n", fence, b, fence)
+		}
+
+		switch {
+		case rec.Error != "":
+			fmt.Fprintf(w, "**Error:** %s\n\n", rec.Error)
+		case rec.Status != nil:
+			st := rec.Status
+			fmt.Fprintf(w, "**Status:** `%s`", st.Status)
+			if st.PhaseCompleted != "" {
+				fmt.Fprintf(w, ", completed %q", st.PhaseCompleted)
+			}
+			fmt.Fprintln(w)
+			fmt.Fprintln(w)
+			if st.Summary != "" {
+				fmt.Fprintf(w, "> %s\n\n", strings.ReplaceAll(strings.TrimSpace(st.Summary), "\n", "\n> "))
+			}
+			if st.Error != "" {
+				fmt.Fprintf(w, "**Reported error:** %s\n\n", st.Error)
+			}
+			for _, q := range st.Questions {
+				fmt.Fprintf(w, "- Question: %s\n", q)
+			}
+			if len(st.Questions) > 0 {
+				fmt.Fprintln(w)
+			}
+		}
+
+		if len(rec.DiffStat) > 0 {
+			fmt.Fprintln(w, "| File | + | - |\n| --- | ---: | ---: |")
+			for _, fs := range rec.DiffStat {
+				fmt.Fprintf(w, "| `%s` | %d | %d |\n", fs.Path, fs.Added, fs.Removed)
+			}
+		} else {
+			fmt.Fprintln(w, "_No changes._")
+		}
+	}
+}
+
+var transcriptHTML = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"title":   iterationTitle,
+	"tools":   toolSummary,
+	"outcome": runOutcome,
+	"when": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Local().Format("2006-01-02 15:04:05")
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Run transcript: {{.Start.ID}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; }
+pre { background: #f5f5f5; padding: .75em; overflow-x: auto; white-space: pre-wrap; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ddd; padding: .2em .6em; }
+td.n { text-align: right; }
+.add { color: #1a7f37; } .del { color: #cf222e; } .err { color: #cf222e; }
+blockquote { border-left: 3px solid #ddd; margin-left: 0; padding-left: 1em; }
+</style>
+</head>
+<body>
+<h1>Run transcript: {{.Start.ID}}</h1>
+<ul>
+{{- with when .Start.Time}}<li>Started: {{.}}</li>{{end}}
+{{- with .Start.Dir}}<li>Directory: <code>{{.}}</code></li>{{end}}
+{{- range .Start.Labels}}<li>Label: {{.}}</li>{{end}}
+<li>Outcome: {{outcome .}}</li>
+</ul>
+{{range .Iterations}}
+<h2>{{title .}}</h2>
+{{with when .Time}}<p><em>{{.}}</em></p>{{end}}
+<details><summary>Prompt{{if .Resumed}} (continued session){{end}}</summary><pre>{{.Prompt}}</pre></details>
+<p><strong>Tools:</strong> {{tools .}}</p>
+{{- if .Tools}}
+<details><summary>Tool calls</summary><pre>{{range .Tools}}{{.}}
+{{end}}</pre></details>
+{{- end}}
+{{- if .Error}}
+<p class="err"><strong>Error:</strong> {{.Error}}</p>
+{{- else if .Status}}{{with .Status}}
+<p><strong>Status:</strong> <code>{{.Status}}</code>{{with .PhaseCompleted}}, completed “{{.}}”{{end}}</p>
+{{- with .Summary}}<blockquote>{{.}}</blockquote>{{end}}
+{{- with .Error}}<p class="err"><strong>Reported error:</strong> {{.}}</p>{{end}}
+{{- range .Questions}}<p>Question: {{.}}</p>{{end}}
+{{- end}}{{end}}
+{{- if .DiffStat}}
+<table>
+<tr><th>File</th><th>+</th><th>-</th></tr>
+{{- range .DiffStat}}
+<tr><td><code>{{.Path}}</code></td><td class="n add">{{.Added}}</td><td class="n del">{{.Removed}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p><em>No changes.</em></p>
+{{- end}}
+{{end}}
+</body>
+</html>
+`))
+
+func writeTranscriptHTML(w io.Writer, run transcriptRun) error {
+	return transcriptHTML.Execute(w, run)
+}
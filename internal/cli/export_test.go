@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestTranscriptLogPath(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", "/state")
+	assert.Equal(t, "/state/logs/feature.transcript.jsonl", transcriptLogPath("./plans/feature.md"))
+}
+
+func recordTranscript(t *testing.T, sourceID string, recs []loop.IterationRecord, result *loop.Result, runErr error) {
+	t.Helper()
+	xlog, err := openTranscriptLog(sourceID, "/work", []string{"q3"})
+	require.NoError(t, err)
+	for _, rec := range recs {
+		xlog.record(rec)
+	}
+	xlog.finish(result, runErr)
+}
+
+func TestTranscriptLog_Runs(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+
+	recordTranscript(t, "feature.md", nil, nil, errors.New("no such plan"))
+	recordTranscript(t, "feature.md", []loop.IterationRecord{
+		{
+			Iteration: 1,
+			Time:      time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC),
+			Duration:  "42s",
+			Phase:     "Task 1: Parser",
+			Prompt:    "Implement the parser.\n```go\nfunc x() {}\n```",
+			Tools:     []string{"Read parser.go", "Edit parser.go", "Read lexer.go"},
+			Status:    &parser.ParsedStatus{Status: protocol.StatusContinue, PhaseCompleted: "Task 1: Parser", Summary: "Added <parser>"},
+			DiffStat:  []loop.FileStat{{Path: "parser.go", Added: 10, Removed: 2}},
+		},
+		{Iteration: 2, ReviewFix: true, Prompt: "Fix the issues", Error: "invocation failed: timeout"},
+	}, &loop.Result{ExitReason: safety.ExitReasonComplete, Iterations: 2, TotalFilesChanged: []string{"parser.go"}, Duration: 90 * time.Second}, nil)
+
+	runs, err := readTranscriptRuns(transcriptLogPath("feature.md"))
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Empty(t, runs[0].Iterations)
+	require.NotNil(t, runs[0].Exit)
+	assert.Equal(t, "error", runs[0].Exit.Reason)
+
+	run := runs[1]
+	assert.Equal(t, "feature.md", run.Start.ID)
+	assert.Equal(t, []string{"q3"}, run.Start.Labels)
+	require.Len(t, run.Iterations, 2)
+
+	var md bytes.Buffer
+	writeTranscriptMarkdown(&md, run)
+	out := md.String()
+	assert.Contains(t, out, "# Run transcript: feature.md")
+	assert.Contains(t, out, "- Outcome: complete after 2 iteration(s), 1 file(s) changed in 1m 30s")
+	assert.Contains(t, out, "## Iteration 1 — Task 1: Parser")
+	assert.Contains(t, out, "````text\nImplement the parser.", "the fence outgrows backticks in the prompt")
+	assert.Contains(t, out, "**Tools:** Read ×2, Edit ×1")
+	assert.Contains(t, out, "**Status:** `CONTINUE`, completed \"Task 1: Parser\"")
+	assert.Contains(t, out, "| `parser.go` | 10 | 2 |")
+	assert.Contains(t, out, "## Iteration 2 — review fixes")
+	assert.Contains(t, out, "**Error:** invocation failed: timeout")
+
+	var html bytes.Buffer
+	require.NoError(t, writeTranscriptHTML(&html, run))
+	out = html.String()
+	assert.Contains(t, out, "<h2>Iteration 1 — Task 1: Parser</h2>")
+	assert.Contains(t, out, "Added &lt;parser&gt;", "status text is escaped")
+	assert.Contains(t, out, `<td class="n add">10</td>`)
+	assert.Contains(t, out, "<strong>Error:</strong> invocation failed: timeout")
+}
+
+func TestToolSummary(t *testing.T) {
+	assert.Equal(t, "none", toolSummary(loop.IterationRecord{}))
+	assert.Equal(t, "Bash ×1, 3 more not recorded",
+		toolSummary(loop.IterationRecord{Tools: []string{"Bash go test ./..."}, ToolsDropped: 3}))
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/guard"
+)
+
+var guardInitForce bool
+
+var guardCmd = &cobra.Command{
+	Use:   "guard",
+	Short: "Set up and check dcg, the destructive command guard",
+	Long: `Set up and check dcg (destructive_command_guard), the Claude Code hook
+that blocks destructive shell commands during autonomous runs.
+
+Before each run with the claude executor, dcg and its config are checked
+according to guard.mode:
+  auto     fail when dcg is installed but its config is missing or broken,
+           warn when dcg is not installed (default)
+  require  fail unless dcg and its config are both in place
+  off      skip the check`,
+}
+
+var guardInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a default dcg config",
+	Long: `Write a default dcg config to $XDG_CONFIG_HOME/dcg/config.toml
+(~/.config/dcg/config.toml). It blocks destructive commands, history
+rewrites of .git, and reading common secret files.`,
+	Args: cobra.NoArgs,
+	RunE: runGuardInit,
+}
+
+var guardCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Verify the dcg binary and config",
+	Args:  cobra.NoArgs,
+	RunE:  runGuardCheck,
+}
+
+func init() {
+	guardInitCmd.Flags().BoolVar(&guardInitForce, "force", false, "Overwrite an existing config")
+	guardCmd.AddCommand(guardInitCmd)
+	guardCmd.AddCommand(guardCheckCmd)
+}
+
+func runGuardInit(_ *cobra.Command, _ []string) error {
+	path := guard.ConfigPath()
+	if err := guard.WriteDefault(path, guardInitForce); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
+
+func runGuardCheck(_ *cobra.Command, _ []string) error {
+	st := guard.Inspect()
+	printGuardStatus(os.Stdout, st)
+	warning, err := st.Check(guard.ModeRequire)
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		fmt.Println(warning)
+	}
+	return nil
+}
+
+func printGuardStatus(w io.Writer, st guard.Status) {
+	binary := st.BinaryPath
+	if binary == "" {
+		binary = "(not found on PATH)"
+	}
+	config := st.ConfigPath
+	if st.ConfigErr != nil {
+		config += fmt.Sprintf(" (%v)", st.ConfigErr)
+	}
+	fmt.Fprintf(w, "  binary: %s\n", binary)
+	fmt.Fprintf(w, "  config: %s\n", config)
+}
+
+// checkGuard verifies dcg before a run that executes commands with the
+// claude executor, the only one whose hooks run dcg. Warnings go to stderr.
+func checkGuard(cfg *config.Config) error {
+	if cfg.Executor != "" && cfg.Executor != "claude" {
+		return nil
+	}
+	warning, err := guard.Inspect().Check(cfg.Guard.Mode)
+	if err != nil {
+		return fmt.Errorf("guard: %w", err)
+	}
+	if warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/dirs"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+var (
+	historyLabels []string
+	historyLimit  int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past runs",
+	Long: `List past runs recorded in the progress logs, newest last, with their
+exit reason, iterations, duration, executor invocations (count, latency,
+retries, and failures by class: timeout, rate_limit, exit, parse), and labels.
+
+Filter by label with --label (repeatable; a run must have every label given).
+When runs have labels, totals per label are printed after the list.
+
+Examples:
+  programmator history
+  programmator history --label q3-cleanup
+  programmator history -n 50`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringSliceVar(&historyLabels, "label", nil, "Only show runs with this label (repeatable)")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Show at most N runs (0 = all)")
+}
+
+func runHistory(_ *cobra.Command, _ []string) error {
+	runs, err := loadRunHistory(dirs.LogsDir())
+	if err != nil {
+		return err
+	}
+	runs = filterRunsByLabels(runs, historyLabels)
+	if len(runs) == 0 {
+		fmt.Println("No runs found")
+		return nil
+	}
+	if historyLimit > 0 && len(runs) > historyLimit {
+		runs = runs[len(runs)-historyLimit:]
+	}
+	printRunHistory(os.Stdout, runs)
+	return nil
+}
+
+// loadRunHistory reads every run from the progress logs in dir, oldest first.
+func loadRunHistory(dir string) ([]*progressRun, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return nil, err
+	}
+	var runs []*progressRun
+	for _, f := range files {
+		data, err := os.ReadFile(f) //nolint:gosec // path under the state dir
+		if err != nil {
+			return nil, fmt.Errorf("read progress log: %w", err)
+		}
+		runs = append(runs, progressRuns(string(data))...)
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+	return runs, nil
+}
+
+// filterRunsByLabels keeps runs tagged with every label in labels.
+func filterRunsByLabels(runs []*progressRun, labels []string) []*progressRun {
+	if len(labels) == 0 {
+		return runs
+	}
+	var out []*progressRun
+	for _, run := range runs {
+		if !slices.ContainsFunc(labels, func(l string) bool { return !slices.Contains(run.Labels, l) }) {
+			out = append(out, run)
+		}
+	}
+	return out
+}
+
+func printRunHistory(out io.Writer, runs []*progressRun) {
+	for _, run := range runs {
+		exit := run.Exit
+		if exit == "" {
+			exit = "unfinished"
+		}
+		line := fmt.Sprintf("%s  %-24s  %-20s  %3d iter  %8s",
+			run.Started.Local().Format("2006-01-02 15:04"), run.ID, exit, run.Iterations, run.Duration)
+		if run.Invocations > 0 {
+			line += fmt.Sprintf("  %d calls, %s", run.Invocations, run.Latency)
+			if run.Retries > 0 {
+				line += fmt.Sprintf(", %d retries", run.Retries)
+			}
+			if run.Failures != "" {
+				line += " (failed: " + run.Failures + ")"
+			}
+		}
+		if len(run.Labels) > 0 {
+			line += "  [" + strings.Join(run.Labels, ", ") + "]"
+		}
+		fmt.Fprintln(out, strings.TrimRight(line, " "))
+	}
+
+	type labelTotals struct {
+		runs, complete, iterations int
+	}
+	totals := make(map[string]*labelTotals)
+	for _, run := range runs {
+		for _, label := range run.Labels {
+			t := totals[label]
+			if t == nil {
+				t = &labelTotals{}
+				totals[label] = t
+			}
+			t.runs++
+			t.iterations += run.Iterations
+			if run.Exit == string(safety.ExitReasonComplete) {
+				t.complete++
+			}
+		}
+	}
+	if len(totals) == 0 {
+		return
+	}
+	labels := make([]string, 0, len(totals))
+	for label := range totals {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	fmt.Fprintln(out, "\nBy label:")
+	for _, label := range labels {
+		t := totals[label]
+		fmt.Fprintf(out, "  %-20s  %d runs, %d complete, %d iterations\n", label, t.runs, t.complete, t.iterations)
+	}
+}
+
+// normalizeLabels trims labels, drops empty and repeated ones, and rejects
+// labels with whitespace, which would not survive the progress log format.
+func normalizeLabels(labels []string) ([]string, error) {
+	var out []string
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || slices.Contains(out, label) {
+			continue
+		}
+		if strings.ContainsAny(label, " \t\n") {
+			return nil, fmt.Errorf("invalid label %q: labels cannot contain whitespace", label)
+		}
+		out = append(out, label)
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRunHistory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "feature.log"), []byte(`=== run 2025-01-15T10:00:00Z ===
+id: plans/feature.md
+dir: /work
+base: abc123
+labels: refactor, q3-cleanup
+10:00:01 Status: CONTINUE
+exit: complete
+iterations: 4
+files changed: 3
+duration: 5m 3s
+=== run 2025-01-17T09:00:00Z ===
+id: plans/feature.md
+dir: /work
+base: def456
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pro-1a2b.log"), []byte(`=== run 2025-01-16T12:00:00Z ===
+id: pro-1a2b
+dir: /work
+base: abc123
+labels: refactor
+exit: stagnation (no changes)
+iterations: 7
+files changed: 0
+duration: 12m 0s
+invocations: 9
+latency: mean 1m 20s, max 4m 2s
+retries: 2
+failures: rate_limit 2
+`), 0o600))
+
+	runs, err := loadRunHistory(dir)
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	assert.Equal(t, "plans/feature.md", runs[0].ID)
+	assert.Equal(t, []string{"refactor", "q3-cleanup"}, runs[0].Labels)
+	assert.Equal(t, "complete", runs[0].Exit)
+	assert.Equal(t, 4, runs[0].Iterations)
+	assert.Equal(t, "5m 3s", runs[0].Duration)
+	assert.Equal(t, "pro-1a2b", runs[1].ID)
+	assert.Equal(t, "stagnation", runs[1].Exit)
+	assert.Empty(t, runs[2].Exit)
+	assert.Nil(t, runs[2].Labels)
+
+	filtered := filterRunsByLabels(runs, []string{"refactor"})
+	require.Len(t, filtered, 2)
+	filtered = filterRunsByLabels(runs, []string{"refactor", "q3-cleanup"})
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "plans/feature.md", filtered[0].ID)
+
+	var buf bytes.Buffer
+	printRunHistory(&buf, runs)
+	out := buf.String()
+	assert.Contains(t, out, "[refactor, q3-cleanup]")
+	assert.Contains(t, out, "unfinished")
+	assert.Contains(t, out, "12m 0s  9 calls, mean 1m 20s, max 4m 2s, 2 retries (failed: rate_limit 2)")
+	assert.Contains(t, out, "By label:")
+	assert.Regexp(t, `refactor\s+2 runs, 1 complete, 11 iterations`, out)
+	assert.Regexp(t, `q3-cleanup\s+1 runs, 1 complete, 4 iterations`, out)
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	labels, err := normalizeLabels([]string{" refactor", "q3-cleanup", "refactor", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"refactor", "q3-cleanup"}, labels)
+
+	_, err = normalizeLabels([]string{"two words"})
+	require.Error(t, err)
+}
@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+)
+
+var (
+	initProject bool
+	initForce   bool
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a config file interactively",
+	Long: `Ask a few questions and write a commented config file: which executor to
+use (installed ones are detected on PATH), the ticket CLI and directory, a
+review preset, and the git workflow.
+
+The file is written to the global config directory
+(~/.config/programmator/config.yaml), or with --project to .programmator.yaml
+in the current directory, which does not pick the executor. Press enter to
+accept the default in brackets.`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+func init() {
+	initCmd.Flags().BoolVar(&initProject, "project", false, "Write .programmator.yaml in the current directory instead of the global config")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing config file")
+}
+
+// initExecutors are the executors the wizard offers, in order of preference
+// when picking the default.
+var initExecutors = []string{"claude", "codex", "pi", "opencode"}
+
+// reviewPresets are the review setups the wizard offers.
+var reviewPresets = []string{"standard", "fast", "thorough"}
+
+// initAnswers holds the wizard's answers.
+type initAnswers struct {
+	Executor      string
+	TicketCommand string
+	TicketsDir    string
+	ReviewPreset  string
+	BaseBranch    string
+	BranchPrefix  string
+	AutoCommit    bool
+	AutoPush      bool
+	MovePlans     bool
+}
+
+// initWizard asks the setup questions on in and out.
+type initWizard struct {
+	in       *bufio.Reader
+	out      io.Writer
+	lookPath func(string) (string, error)
+	dir      string // working directory, for the base branch default
+	project  bool   // writing the project file, which may not pick the executor
+}
+
+func runInit(_ *cobra.Command, _ []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	path := filepath.Join(config.DefaultConfigDir(), "config.yaml")
+	if initProject {
+		path = filepath.Join(wd, config.ProjectConfigFile)
+	}
+	if _, err := os.Stat(path); err == nil && !initForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+
+	w := &initWizard{in: bufio.NewReader(os.Stdin), out: os.Stdout, lookPath: exec.LookPath, dir: wd, project: initProject}
+	answers, err := w.run()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(renderInitConfig(answers)), 0o600); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	fmt.Printf("\nWrote %s\n", path)
+	if answers.TicketsDir != defaultTicketsDir() {
+		fmt.Printf("Tickets are read from $TICKETS_DIR; add this to your shell profile:\n  export TICKETS_DIR=%s\n", answers.TicketsDir)
+	}
+	return nil
+}
+
+// run asks every question and returns the answers.
+func (w *initWizard) run() (initAnswers, error) {
+	var a initAnswers
+	var err error
+
+	if !w.project {
+		if a.Executor, err = w.chooseExecutor(); err != nil {
+			return a, err
+		}
+	}
+
+	ticketCommand := "tk"
+	if _, err := w.lookPath("tk"); err != nil {
+		if _, err := w.lookPath("ticket"); err == nil {
+			ticketCommand = "ticket"
+		}
+	}
+	if a.TicketCommand, err = w.ask("Ticket CLI", ticketCommand); err != nil {
+		return a, err
+	}
+	if a.TicketsDir, err = w.ask("Ticket directory", defaultTicketsDir()); err != nil {
+		return a, err
+	}
+
+	fmt.Fprintln(w.out, "Review presets: standard (all agents), fast (bugs and tests only, 2 rounds), thorough (all agents with the diff, 5 rounds)")
+	if a.ReviewPreset, err = w.choose("Review preset", reviewPresets, "standard"); err != nil {
+		return a, err
+	}
+
+	if a.BaseBranch, err = w.ask("Base branch", detectBaseBranch(w.dir)); err != nil {
+		return a, err
+	}
+	if a.AutoCommit, err = w.confirm("Commit after each completed phase?", true); err != nil {
+		return a, err
+	}
+	if a.AutoCommit {
+		if a.AutoPush, err = w.confirm("Push the commits?", false); err != nil {
+			return a, err
+		}
+	}
+	if a.BranchPrefix, err = w.ask("Branch prefix", "programmator/"); err != nil {
+		return a, err
+	}
+	if a.MovePlans, err = w.confirm("Move completed plans to plans/completed?", false); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// ask prints question with its default and returns the answer, or def for an
+// empty one.
+func (w *initWizard) ask(question, def string) (string, error) {
+	fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	line, err := w.in.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		return "", errors.New("input stream closed")
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read input: %w", err)
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// choose asks until the answer is one of options.
+func (w *initWizard) choose(question string, options []string, def string) (string, error) {
+	for {
+		answer, err := w.ask(question+" ("+strings.Join(options, ", ")+")", def)
+		if err != nil {
+			return "", err
+		}
+		if slices.Contains(options, answer) {
+			return answer, nil
+		}
+		fmt.Fprintf(w.out, "Unknown choice %q.\n", answer)
+	}
+}
+
+// confirm asks a yes/no question until the answer is one.
+func (w *initWizard) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := w.ask(question, hint)
+		if err != nil {
+			return false, err
+		}
+		if answer == hint {
+			return def, nil
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(w.out, "Please answer y or n.")
+	}
+}
+
+// detectBaseBranch returns master for repositories that have a master
+// branch but no main, and main otherwise.
+func detectBaseBranch(dir string) string {
+	repo, err := gitutil.NewRepo(dir)
+	if err != nil {
+		return "main"
+	}
+	if ok, _ := repo.BranchExists("main"); ok {
+		return "main"
+	}
+	if ok, _ := repo.BranchExists("master"); ok {
+		return "master"
+	}
+	return "main"
+}
+
+// defaultTicketsDir is the directory the ticket client reads when no
+// workspace is given.
+func defaultTicketsDir() string {
+	if dir := os.Getenv("TICKETS_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	return filepath.Join(home, ".tickets")
+}
+
+// chooseExecutor asks which executor to use, defaulting to the first one
+// installed.
+func (w *initWizard) chooseExecutor() (string, error) {
+	var found []string
+	for _, name := range initExecutors {
+		if _, err := w.lookPath(name); err == nil {
+			found = append(found, name)
+		}
+	}
+	if len(found) == 0 {
+		fmt.Fprintln(w.out, "No executor found on PATH (claude, codex, pi, opencode).")
+		found = initExecutors[:1]
+	} else {
+		fmt.Fprintf(w.out, "Found on PATH: %s\n", strings.Join(found, ", "))
+	}
+	return w.choose("Executor", initExecutors, found[0])
+}
+
+// renderInitConfig returns the config file for a, with comments explaining
+// each setting. Settings left at their defaults are written too, so the file
+// shows what can be changed. Without an executor, as for the project file,
+// the executor line is left out.
+func renderInitConfig(a initAnswers) string {
+	var s strings.Builder
+	s.WriteString("# Programmator configuration, created by `programmator init`.\n")
+	s.WriteString("# Every setting and its default: `programmator config show` and the README.\n\n")
+
+	if a.Executor != "" {
+		fmt.Fprintf(&s, "executor: %s # Which coding agent to use (claude, codex, pi, or opencode)\n", a.Executor)
+	} else {
+		s.WriteString("# The executor and its flags are set in the global or local config, not here.\n")
+	}
+	s.WriteString("max_iterations: 50 # Maximum loop iterations before forced exit\n")
+	s.WriteString("stagnation_limit: 3 # Exit after N consecutive iterations with no file changes\n\n")
+
+	s.WriteString("# Tickets are read from $TICKETS_DIR (default ~/.tickets).\n")
+	fmt.Fprintf(&s, "ticket_command: %s # Binary name for the ticket CLI (tk or ticket)\n\n", a.TicketCommand)
+
+	s.WriteString("review:\n")
+	switch a.ReviewPreset {
+	case "fast":
+		s.WriteString("  # fast preset: only the bug and test agents, at most 2 fix rounds\n")
+		s.WriteString("  max_iterations: 2 # Maximum review fix iterations\n")
+		s.WriteString("  include: [bug-shallow, bug-deep, tests-and-linters] # Subset of default agents; empty = all\n")
+	case "thorough":
+		s.WriteString("  # thorough preset: every agent sees the full diff, up to 5 fix rounds\n")
+		s.WriteString("  max_iterations: 5 # Maximum review fix iterations\n")
+		s.WriteString("  include_diff: true # Embed the unified diff in agent prompts\n")
+	default:
+		s.WriteString("  # standard preset: all default agents\n")
+		s.WriteString("  max_iterations: 3 # Maximum review fix iterations\n")
+	}
+	s.WriteString("  parallel: true # Run agents in parallel\n\n")
+
+	s.WriteString("git:\n")
+	fmt.Fprintf(&s, "  base_branch: %s # Branch the work is based on\n", a.BaseBranch)
+	fmt.Fprintf(&s, "  branch_prefix: %q # Prefix for auto-created branches\n", a.BranchPrefix)
+	fmt.Fprintf(&s, "  auto_commit: %t # Commit after each phase completion\n", a.AutoCommit)
+	fmt.Fprintf(&s, "  auto_push: %t # Push auto-commits (sets upstream on the first push)\n", a.AutoPush)
+	fmt.Fprintf(&s, "  move_completed_plans: %t # Move completed plans to plans/completed\n", a.MovePlans)
+	return s.String()
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+)
+
+func testInitWizard(input string, installed ...string) (*initWizard, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &initWizard{
+		in:  bufio.NewReader(strings.NewReader(input)),
+		out: &out,
+		lookPath: func(name string) (string, error) {
+			for _, bin := range installed {
+				if bin == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+	}, &out
+}
+
+func TestInitWizard_Defaults(t *testing.T) {
+	t.Setenv("TICKETS_DIR", "/srv/tickets")
+	w, out := testInitWizard("\n\n\n\n\n\n\n\n\n", "codex", "ticket")
+	w.dir = initPreflightRepo(t)
+
+	a, err := w.run()
+	require.NoError(t, err)
+	assert.Equal(t, initAnswers{
+		Executor:      "codex",
+		TicketCommand: "ticket",
+		TicketsDir:    "/srv/tickets",
+		ReviewPreset:  "standard",
+		BaseBranch:    "main",
+		BranchPrefix:  "programmator/",
+		AutoCommit:    true,
+	}, a)
+	assert.Contains(t, out.String(), "Found on PATH: codex\n")
+	assert.Contains(t, out.String(), "Executor (claude, codex, pi, opencode) [codex]: ")
+	assert.Contains(t, out.String(), "Commit after each completed phase? [Y/n]: ")
+}
+
+func TestInitWizard_Answers(t *testing.T) {
+	input := strings.Join([]string{
+		"gemini", "pi", // unknown executor, asked again
+		"tk", "~/work/tickets",
+		"fast",
+		"develop",
+		"maybe", "y", // commit
+		"yes", // push
+		"feat/",
+		"n",
+	}, "\n") + "\n"
+	w, out := testInitWizard(input)
+	w.dir = t.TempDir()
+
+	a, err := w.run()
+	require.NoError(t, err)
+	assert.Equal(t, initAnswers{
+		Executor:      "pi",
+		TicketCommand: "tk",
+		TicketsDir:    "~/work/tickets",
+		ReviewPreset:  "fast",
+		BaseBranch:    "develop",
+		BranchPrefix:  "feat/",
+		AutoCommit:    true,
+		AutoPush:      true,
+	}, a)
+	assert.Contains(t, out.String(), "No executor found on PATH")
+	assert.Contains(t, out.String(), `Unknown choice "gemini".`)
+	assert.Contains(t, out.String(), "Please answer y or n.")
+}
+
+func TestInitWizard_Project(t *testing.T) {
+	t.Setenv("TICKETS_DIR", "/srv/tickets")
+	w, out := testInitWizard("\n\n\n\n\n\n\n\n", "codex", "ticket")
+	w.dir = initPreflightRepo(t)
+	w.project = true
+
+	a, err := w.run()
+	require.NoError(t, err)
+	assert.Empty(t, a.Executor)
+	assert.NotContains(t, out.String(), "Executor (")
+
+	content := renderInitConfig(a)
+	assert.NotContains(t, content, "executor:")
+	assert.Contains(t, content, "# The executor and its flags are set in the global or local config")
+}
+
+func TestInitWizard_InputClosed(t *testing.T) {
+	w, _ := testInitWizard("claude\n", "claude")
+	w.dir = t.TempDir()
+	_, err := w.run()
+	require.EqualError(t, err, "input stream closed")
+}
+
+func TestRenderInitConfig(t *testing.T) {
+	for _, preset := range reviewPresets {
+		t.Run(preset, func(t *testing.T) {
+			dir := t.TempDir()
+			content := renderInitConfig(initAnswers{
+				Executor:      "codex",
+				TicketCommand: "ticket",
+				ReviewPreset:  preset,
+				BaseBranch:    "develop",
+				BranchPrefix:  "feat/",
+				AutoCommit:    true,
+			})
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o600))
+
+			cfg, err := config.LoadWithDirs(dir, "")
+			require.NoError(t, err)
+			require.NoError(t, cfg.Validate())
+			assert.Equal(t, "codex", cfg.Executor)
+			assert.Equal(t, "ticket", cfg.TicketCommand)
+			assert.Equal(t, "develop", cfg.Git.BaseBranch)
+			assert.Equal(t, "feat/", cfg.Git.BranchPrefix)
+			assert.True(t, cfg.Git.AutoCommit)
+			assert.False(t, cfg.Git.AutoPush)
+
+			switch preset {
+			case "fast":
+				assert.Equal(t, 2, cfg.Review.MaxIterations)
+				assert.Equal(t, []string{"bug-shallow", "bug-deep", "tests-and-linters"}, cfg.Review.Include)
+			case "thorough":
+				assert.Equal(t, 5, cfg.Review.MaxIterations)
+				assert.True(t, cfg.Review.IncludeDiff)
+			default:
+				assert.Equal(t, 3, cfg.Review.MaxIterations)
+				assert.Empty(t, cfg.Review.Include)
+			}
+		})
+	}
+}
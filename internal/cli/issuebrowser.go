@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+// issueContextLines is how many lines around an issue the context pane shows
+// on each side.
+const issueContextLines = 5
+
+// browserIssue is one row of the issue browser.
+type browserIssue struct {
+	agent string
+	issue review.Issue
+}
+
+type editorDoneMsg struct{ err error }
+
+// issueBrowserClosedMsg closes a browser shown in the run's footer.
+type issueBrowserClosedMsg struct{}
+
+// issueBrowser lists review issues and opens the selected one in $EDITOR at
+// its line, or shows the code around it in a pane below the list.
+type issueBrowser struct {
+	issues      []browserIssue
+	cursor      int
+	showContext bool
+	height      int // terminal rows (0 = unknown)
+	status      string
+	theme       *theme
+	editor      []string // $VISUAL or $EDITOR, split into words
+	inRun       bool     // shown in the footer of a run, which goes on when it closes
+}
+
+func newIssueBrowser(results []*review.Result, t *theme) *issueBrowser {
+	b := &issueBrowser{
+		theme:  t,
+		editor: strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"))),
+	}
+	if len(b.editor) == 0 {
+		b.editor = []string{"vi"}
+	}
+	for _, res := range results {
+		for _, issue := range res.Issues {
+			b.issues = append(b.issues, browserIssue{agent: res.AgentName, issue: issue})
+		}
+	}
+	return b
+}
+
+// runIssueBrowser shows the issues of results until the user quits.
+func runIssueBrowser(results []*review.Result, t *theme) error {
+	b := newIssueBrowser(results, t)
+	if len(b.issues) == 0 {
+		return nil
+	}
+	_, err := tea.NewProgram(b, tea.WithAltScreen()).Run()
+	return err
+}
+
+// SetReviewIssues keeps the issues of the last review for the issue
+// browser, which the issues key opens during a run.
+func (w *Writer) SetReviewIssues(results []*review.Result) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.reviewIssues = results
+}
+
+// openIssueBrowser shows the issues of the last review in place of the
+// footer, until the user closes it. Keys go to the browser meanwhile.
+func (w *Writer) openIssueBrowser() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.teaActive {
+		return
+	}
+	b := newIssueBrowser(w.reviewIssues, w.theme)
+	if len(b.issues) == 0 {
+		return
+	}
+	b.inRun = true
+	b.height = w.height
+	w.tea.Send(bubbleBrowserMsg{browser: b})
+}
+
+// countIssues returns the number of issues in results.
+func countIssues(results []*review.Result) int {
+	n := 0
+	for _, res := range results {
+		n += len(res.Issues)
+	}
+	return n
+}
+
+func (b *issueBrowser) Init() tea.Cmd {
+	return nil
+}
+
+func (b *issueBrowser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.height = msg.Height
+	case editorDoneMsg:
+		b.status = ""
+		if msg.err != nil {
+			b.status = "editor: " + msg.err.Error()
+		}
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			if b.inRun {
+				return b, func() tea.Msg { return issueBrowserClosedMsg{} }
+			}
+			return b, tea.Quit
+		case "up", "k":
+			b.cursor = max(b.cursor-1, 0)
+		case "down", "j":
+			b.cursor = min(b.cursor+1, len(b.issues)-1)
+		case "c", "tab":
+			b.showContext = !b.showContext
+		case "enter", "o":
+			issue := b.issues[b.cursor].issue
+			if issue.Path == "" {
+				b.status = "the issue has no file"
+				return b, nil
+			}
+			args := append(slices.Clone(b.editor[1:]), editorArgs(b.editor[0], issue.Path, issue.Line)...)
+			cmd := exec.Command(b.editor[0], args...) //nolint:gosec // the user's own editor
+			return b, tea.ExecProcess(cmd, func(err error) tea.Msg { return editorDoneMsg{err: err} })
+		}
+	}
+	return b, nil
+}
+
+func (b *issueBrowser) View() string {
+	t := b.theme
+	var s strings.Builder
+	s.WriteString(t.bold(fmt.Sprintf("Review issues (%d)", len(b.issues))))
+	quit := "quit"
+	if b.inRun {
+		quit = "back to the run"
+	}
+	s.WriteString(t.dim("  enter: open in " + filepath.Base(b.editor[0]) + "  c: context  q: " + quit))
+	s.WriteString("\n\n")
+
+	var pane []string
+	if b.showContext {
+		pane = b.contextPane()
+	}
+	rows := len(b.issues)
+	if b.height > 0 {
+		rows = max(b.height-4-len(pane), 1)
+	}
+	first := min(max(b.cursor-rows/2, 0), max(len(b.issues)-rows, 0))
+	for i := first; i < min(first+rows, len(b.issues)); i++ {
+		s.WriteString(b.issueLine(i))
+		s.WriteString("\n")
+	}
+
+	if len(pane) > 0 {
+		s.WriteString("\n")
+		s.WriteString(strings.Join(pane, "\n"))
+		s.WriteString("\n")
+	}
+	if b.status != "" {
+		s.WriteString(t.fg(t.failure, b.status) + "\n")
+	}
+	return s.String()
+}
+
+// issueLine renders row i of the list.
+func (b *issueBrowser) issueLine(i int) string {
+	t := b.theme
+	row := b.issues[i]
+	cursor := "  "
+	if i == b.cursor {
+		cursor = t.fgBold(t.accent, "> ")
+	}
+	location := cmp.Or(row.issue.File, "(no file)")
+	if row.issue.Line > 0 {
+		location += ":" + strconv.Itoa(row.issue.Line)
+	}
+	description, _, _ := strings.Cut(sanitizeTerminalText(row.issue.Description), "\n")
+	return cursor +
+		t.fgBold(t.severityColor(row.issue.Severity), "["+string(row.issue.Severity)+"]") + " " +
+		t.fg(t.highlight, location) + " " +
+		description + t.dim(" ("+row.agent+")")
+}
+
+// contextPane renders the lines around the selected issue, marking the
+// lines it covers.
+func (b *issueBrowser) contextPane() []string {
+	t := b.theme
+	issue := b.issues[b.cursor].issue
+	if issue.Path == "" || issue.Line <= 0 {
+		return []string{t.dim("no line to show")}
+	}
+	lines, err := surroundingLines(issue.Path, issue.Line, max(issue.LineEnd, issue.Line), issueContextLines)
+	if err != nil {
+		return []string{t.dim(err.Error())}
+	}
+	pane := []string{t.dim("── " + issue.Location() + " ──")}
+	for _, l := range lines {
+		text := fmt.Sprintf("%5d │ %s", l.number, sanitizeTerminalText(l.text))
+		if l.marked {
+			pane = append(pane, t.fgBold(t.accent, "▶")+text)
+			continue
+		}
+		pane = append(pane, " "+t.dim(text))
+	}
+	return pane
+}
+
+type sourceLine struct {
+	number int
+	text   string
+	marked bool
+}
+
+// surroundingLines reads lines start..end of path with n lines of context on
+// each side; the lines in start..end are marked.
+func surroundingLines(path string, start, end, n int) ([]sourceLine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	all := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if start > len(all) {
+		return nil, fmt.Errorf("line %d is past the end of %s (%d lines)", start, filepath.Base(path), len(all))
+	}
+	var lines []sourceLine
+	for i := max(start-n, 1); i <= min(end+n, len(all)); i++ {
+		lines = append(lines, sourceLine{number: i, text: all[i-1], marked: i >= start && i <= end})
+	}
+	return lines, nil
+}
+
+// editorArgs returns the arguments that open path at line in the editor
+// binary. Editors are matched by name; unknown ones get the "+line" argument
+// that vi, emacs, nano and most others accept.
+func editorArgs(binary, path string, line int) []string {
+	if line <= 0 {
+		return []string{path}
+	}
+	name := strings.TrimSuffix(filepath.Base(binary), ".exe")
+	location := path + ":" + strconv.Itoa(line)
+	switch name {
+	case "code", "code-insiders", "codium", "cursor", "windsurf":
+		return []string{"--goto", location}
+	case "subl", "zed", "hx", "helix":
+		return []string{location}
+	case "idea", "goland", "pycharm", "webstorm":
+		return []string{"--line", strconv.Itoa(line), path}
+	default:
+		return []string{"+" + strconv.Itoa(line), path}
+	}
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+func TestEditorArgs(t *testing.T) {
+	tests := []struct {
+		binary string
+		line   int
+		want   []string
+	}{
+		{binary: "vim", line: 12, want: []string{"+12", "/repo/a.go"}},
+		{binary: "/usr/bin/nvim", line: 12, want: []string{"+12", "/repo/a.go"}},
+		{binary: "code", line: 12, want: []string{"--goto", "/repo/a.go:12"}},
+		{binary: "subl", line: 12, want: []string{"/repo/a.go:12"}},
+		{binary: "goland", line: 12, want: []string{"--line", "12", "/repo/a.go"}},
+		{binary: "vim", line: 0, want: []string{"/repo/a.go"}},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, editorArgs(tc.binary, "/repo/a.go", tc.line), tc.binary)
+	}
+}
+
+func TestSurroundingLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	require.NoError(t, os.WriteFile(path, []byte("one\ntwo\nthree\nfour\nfive\nsix\n"), 0o600))
+
+	lines, err := surroundingLines(path, 3, 4, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []sourceLine{
+		{number: 2, text: "two"},
+		{number: 3, text: "three", marked: true},
+		{number: 4, text: "four", marked: true},
+		{number: 5, text: "five"},
+	}, lines)
+
+	lines, err = surroundingLines(path, 1, 1, 5)
+	require.NoError(t, err)
+	assert.Len(t, lines, 6, "clamped to the file")
+
+	_, err = surroundingLines(path, 9, 9, 1)
+	require.ErrorContains(t, err, "line 9 is past the end of a.go (6 lines)")
+}
+
+func TestIssueBrowser(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {\n\tpanic(nil)\n}\n"), 0o600))
+
+	b := newIssueBrowser([]*review.Result{
+		{AgentName: "quality", Issues: []review.Issue{
+			{File: "main.go", Path: path, Line: 4, Severity: review.SeverityHigh, Description: "Panics on start"},
+		}},
+		{AgentName: "security"},
+		{AgentName: "tests", Issues: []review.Issue{
+			{Severity: review.SeverityLow, Description: "No tests"},
+		}},
+	}, plainTheme())
+	require.Len(t, b.issues, 2)
+	assert.Equal(t, []string{"code", "--wait"}, b.editor)
+
+	view := b.View()
+	assert.Contains(t, view, "Review issues (2)  enter: open in code")
+	assert.Contains(t, view, "> [high] main.go:4 Panics on start (quality)")
+	assert.Contains(t, view, "  [low] (no file) No tests (tests)")
+
+	b.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	assert.Contains(t, b.View(), "▶    4 │     panic(nil)")
+	assert.Contains(t, b.View(), "     1 │ package main")
+
+	b.Update(tea.KeyMsg{Type: tea.KeyDown})
+	b.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.Equal(t, 1, b.cursor, "stops at the last issue")
+	assert.Contains(t, b.View(), "no line to show")
+
+	_, cmd := b.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Nil(t, cmd)
+	assert.Contains(t, b.View(), "the issue has no file")
+
+	b.Update(tea.KeyMsg{Type: tea.KeyUp})
+	_, cmd = b.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.NotNil(t, cmd, "opens the editor")
+}
+
+func TestBubbleModel_IssueBrowser(t *testing.T) {
+	var keys []string
+	m := &bubbleModel{footer: []string{"Working on: Task 1"}, onKey: func(key string) { keys = append(keys, key) }}
+	b := newIssueBrowser([]*review.Result{
+		{AgentName: "quality", Issues: []review.Issue{{File: "a.go", Line: 3, Severity: review.SeverityHigh, Description: "Leak"}}},
+	}, plainTheme())
+	b.inRun = true
+
+	m.Update(bubbleBrowserMsg{browser: b})
+	assert.Contains(t, m.View(), "> [high] a.go:3 Leak (quality)")
+	assert.Contains(t, m.View(), "q: back to the run")
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	assert.Empty(t, keys, "the browser gets the keys while open")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	require.NotNil(t, cmd)
+	m.Update(cmd())
+	assert.Equal(t, "Working on: Task 1", m.View())
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	assert.Equal(t, []string{"s"}, keys)
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/dirs"
+)
+
+// runLock keeps two programmator processes from running the same work item,
+// where they would overwrite each other's checkbox updates and fight over
+// its branch. The lock is a file under the state directory naming the
+// process holding it; a lock whose process is gone is stale and replaced.
+type runLock struct {
+	path  string
+	owner lockOwner
+}
+
+// lockOwner is the content of a lock file.
+type lockOwner struct {
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	WorkItem   string    `json:"work_item"`
+	WorkingDir string    `json:"working_dir"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// runLockPath returns the lock file of the work item identified by key.
+// The name keeps the work item readable; the hash tells apart plans with
+// the same file name in different directories.
+func runLockPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dirs.StateDir(), "locks", fmt.Sprintf("%s-%x.lock", runLogName(key), sum[:4]))
+}
+
+// workItemKey identifies sourceID however it was spelled: plan files by
+// their absolute path with symlinks resolved, other IDs as given.
+func workItemKey(sourceID string) string {
+	if _, err := os.Stat(sourceID); err != nil {
+		return sourceID
+	}
+	abs, err := filepath.Abs(sourceID)
+	if err != nil {
+		return sourceID
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
+
+// acquireRunLock takes the lock of sourceID's work item. A lock held by a
+// live process is an error unless force is set, in which case it is taken
+// over; a stale lock is replaced with a warning.
+func acquireRunLock(sourceID, workingDir string, force bool) (*runLock, error) {
+	key := workItemKey(sourceID)
+	host, _ := os.Hostname()
+	lock := &runLock{
+		path: runLockPath(key),
+		owner: lockOwner{
+			PID:        os.Getpid(),
+			Host:       host,
+			WorkItem:   key,
+			WorkingDir: workingDir,
+			StartedAt:  time.Now(),
+		},
+	}
+	if err := os.MkdirAll(filepath.Dir(lock.path), 0o700); err != nil {
+		return nil, fmt.Errorf("create lock dir: %w", err)
+	}
+	data, err := json.Marshal(lock.owner)
+	if err != nil {
+		return nil, fmt.Errorf("marshal run lock: %w", err)
+	}
+
+	// Write the whole lock to a temporary file and link it into place, so
+	// another process never reads a partly written lock.
+	tmp, err := os.CreateTemp(filepath.Dir(lock.path), ".lock-*")
+	if err != nil {
+		return nil, fmt.Errorf("create run lock: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write run lock: %w", err)
+	}
+
+	for range 3 {
+		err := os.Link(tmp.Name(), lock.path)
+		if err == nil {
+			return lock, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create run lock: %w", err)
+		}
+
+		held, err := readLockOwner(lock.path)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Warning: replacing unreadable run lock %s: %v\n", lock.path, err)
+		case held.stale(host):
+			fmt.Fprintf(os.Stderr, "Warning: replacing stale run lock of pid %d (no longer running)\n", held.PID)
+		case force:
+			fmt.Fprintf(os.Stderr, "Warning: --force: taking over the run lock of pid %d\n", held.PID)
+		default:
+			return nil, fmt.Errorf("%s is already being run by pid %d on %s in %s (since %s); use --force to run anyway",
+				sourceID, held.PID, held.Host, held.WorkingDir, held.StartedAt.Format(time.DateTime))
+		}
+		if err := os.Remove(lock.path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove run lock: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("could not take the run lock %s: another process keeps taking it", lock.path)
+}
+
+func readLockOwner(path string) (lockOwner, error) {
+	var owner lockOwner
+	data, err := os.ReadFile(path) //nolint:gosec // path under the state dir
+	if err != nil {
+		return owner, err
+	}
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return owner, fmt.Errorf("parse run lock: %w", err)
+	}
+	return owner, nil
+}
+
+// stale reports whether the lock's process is known to be gone. Locks of
+// other hosts cannot be checked and are never stale.
+func (o lockOwner) stale(host string) bool {
+	return o.Host == host && (o.PID <= 0 || !isProcessRunning(o.PID))
+}
+
+// release removes the lock unless another process took it over.
+func (l *runLock) release() {
+	if held, err := readLockOwner(l.path); err == nil && held.PID == l.owner.PID && held.StartedAt.Equal(l.owner.StartedAt) {
+		os.Remove(l.path)
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLock(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	plan := filepath.Join(t.TempDir(), "plan.md")
+	require.NoError(t, os.WriteFile(plan, []byte("# Plan\n"), 0o600))
+
+	lock, err := acquireRunLock(plan, "/work", false)
+	require.NoError(t, err)
+	assert.FileExists(t, lock.path)
+
+	// The same plan by a relative path is the same work item.
+	t.Chdir(filepath.Dir(plan))
+	_, err = acquireRunLock("plan.md", "/work", false)
+	require.ErrorContains(t, err, "is already being run by pid")
+	require.ErrorContains(t, err, "--force")
+
+	forced, err := acquireRunLock("plan.md", "/work", true)
+	require.NoError(t, err)
+
+	lock.release()
+	assert.FileExists(t, forced.path, "a lock taken over is not released by its old owner")
+	forced.release()
+	assert.NoFileExists(t, forced.path)
+}
+
+func TestRunLock_Stale(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+	host, _ := os.Hostname()
+	path := runLockPath("t-1")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+	data, err := json.Marshal(lockOwner{PID: cmd.Process.Pid, Host: host, WorkItem: "t-1", StartedAt: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	lock, err := acquireRunLock("t-1", "/work", false)
+	require.NoError(t, err, "the process holding the lock has exited")
+	defer lock.release()
+
+	held, err := readLockOwner(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), held.PID)
+
+	other := lockOwner{PID: cmd.Process.Pid, Host: host + "-other"}
+	assert.False(t, other.stale(host), "locks of other hosts cannot be checked")
+}
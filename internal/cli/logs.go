@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+)
+
+// logsPollInterval is how often a followed log is checked for new lines.
+const logsPollInterval = 500 * time.Millisecond
+
+var logsFollow bool
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [work-item]",
+	Short: "Print or follow the progress log of a run",
+	Long: `Print the progress log of the last run of a plan or ticket, with
+iterations, warnings, and the exit reason highlighted. With -f, keep
+printing lines as the run appends them, so a run can be watched from
+another terminal without access to its TUI. Follow stops on Ctrl+C.
+
+Without a work item, the single running session is used.
+
+Examples:
+  programmator logs -f
+  programmator logs -f ./plans/feature.md
+  programmator logs pro-1a2b`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLogs,
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new lines as the run appends them")
+}
+
+func runLogs(_ *cobra.Command, args []string) error {
+	var sourceID string
+	if len(args) == 1 {
+		sourceID = args[0]
+	} else {
+		id, err := runningWorkItem()
+		if err != nil {
+			return err
+		}
+		sourceID = id
+	}
+
+	ui := config.UIConfig{}
+	if wd, err := os.Getwd(); err == nil {
+		if cfg, err := config.LoadForDir(wd); err == nil {
+			ui = cfg.UI
+		}
+	}
+	t, err := newTheme(ui, noColor || os.Getenv("NO_COLOR") != "" || !stdoutIsTTY())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	return tailProgressLog(ctx, progressLogPath(sourceID), os.Stdout, t, logsFollow, logsPollInterval)
+}
+
+// runningWorkItem returns the work item of the only running session.
+func runningWorkItem() (string, error) {
+	sessions := liveSessions()
+	switch len(sessions) {
+	case 0:
+		return "", fmt.Errorf("no running sessions; pass the plan or ticket whose log to show")
+	case 1:
+		return sessions[0].WorkItemID, nil
+	}
+	ids := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		ids = append(ids, s.WorkItemID)
+	}
+	return "", fmt.Errorf("%d sessions are running (%s); pass the one whose log to show", len(sessions), strings.Join(ids, ", "))
+}
+
+// tailProgressLog prints the last run in the progress log at path and, with
+// follow, the lines appended after it until ctx is done. A missing log is
+// waited for when following. A log that shrinks, because it was replaced,
+// is read again from the start.
+func tailProgressLog(ctx context.Context, path string, out io.Writer, t *theme, follow bool, poll time.Duration) error {
+	data, err := os.ReadFile(path) //nolint:gosec // path under the state dir
+	switch {
+	case os.IsNotExist(err) && follow:
+		fmt.Fprintln(out, t.dim(fmt.Sprintf("Waiting for %s...", path)))
+	case os.IsNotExist(err):
+		return fmt.Errorf("no progress log at %s", path)
+	case err != nil:
+		return fmt.Errorf("read progress log: %w", err)
+	}
+
+	offset := int64(len(data))
+	var partial []byte
+	printLogLines(out, t, &partial, lastRunSection(data))
+	if !follow {
+		flushLogLine(out, t, &partial)
+		return nil
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushLogLine(out, t, &partial)
+			return nil
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue // not created yet, or being replaced
+		}
+		if info.Size() < offset {
+			offset, partial = 0, nil
+		}
+		if info.Size() == offset {
+			continue
+		}
+		chunk, err := readFrom(path, offset)
+		if err != nil {
+			return fmt.Errorf("read progress log: %w", err)
+		}
+		offset += int64(len(chunk))
+		printLogLines(out, t, &partial, chunk)
+	}
+}
+
+// lastRunSection returns data from the start of its last run marker.
+func lastRunSection(data []byte) []byte {
+	if i := bytes.LastIndex(data, []byte("\n"+progressRunMarker)); i >= 0 {
+		return data[i+1:]
+	}
+	return data
+}
+
+func readFrom(path string, offset int64) ([]byte, error) {
+	f, err := os.Open(path) //nolint:gosec // path under the state dir
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+// printLogLines prints the complete lines of chunk, keeping an unfinished
+// last line in partial until the rest of it arrives.
+func printLogLines(out io.Writer, t *theme, partial *[]byte, chunk []byte) {
+	*partial = append(*partial, chunk...)
+	for {
+		i := bytes.IndexByte(*partial, '\n')
+		if i < 0 {
+			return
+		}
+		fmt.Fprintln(out, colorizeLogLine(t, string((*partial)[:i])))
+		*partial = (*partial)[i+1:]
+	}
+}
+
+func flushLogLine(out io.Writer, t *theme, partial *[]byte) {
+	if len(*partial) > 0 {
+		fmt.Fprintln(out, colorizeLogLine(t, string(*partial)))
+		*partial = nil
+	}
+}
+
+// colorizeLogLine highlights the markers of a progress log line: run
+// headers, iteration boundaries, warnings and errors, and the exit reason.
+func colorizeLogLine(t *theme, line string) string {
+	if strings.HasPrefix(line, progressRunMarker) {
+		return t.fgBold(t.accent, line)
+	}
+
+	key, value, _ := strings.Cut(line, ": ")
+	switch key {
+	case "exit":
+		color := t.failure
+		if strings.HasPrefix(value, "complete") {
+			color = t.success
+		}
+		return t.dim("exit: ") + t.fgBold(color, value)
+	case "id", "dir", "base", "labels", "iterations", "files changed", "duration", "recent":
+		return t.dim(line)
+	}
+
+	ts, text, ok := strings.Cut(line, " ")
+	if !ok || len(ts) != len("15:04:05") || ts[2] != ':' || ts[5] != ':' {
+		return t.severityBadges(line)
+	}
+	lower := strings.ToLower(text)
+	switch {
+	case strings.HasPrefix(text, "--- iteration "):
+		text = t.fgBold(t.accent, text)
+	case strings.Contains(lower, "warning"), strings.Contains(lower, "error"), strings.Contains(lower, "failed"):
+		text = t.fg(t.failure, text)
+	default:
+		text = t.severityBadges(text)
+	}
+	return t.dim(ts) + " " + text
+}
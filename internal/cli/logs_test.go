@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe to read while another goroutine writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func plainTheme() *theme {
+	t := defaultTheme()
+	t.noColor = true
+	return t
+}
+
+const twoRunsLog = `=== run 2026-01-01T10:00:00Z ===
+id: feature.md
+10:00:01 first run
+exit: blocked
+=== run 2026-01-02T10:00:00Z ===
+id: feature.md
+10:00:01 --- iteration 1/50 ---
+10:00:02 second run
+`
+
+func TestTailProgressLog_PrintsLastRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feature.log")
+	require.NoError(t, os.WriteFile(path, []byte(twoRunsLog), 0o600))
+
+	var out bytes.Buffer
+	require.NoError(t, tailProgressLog(context.Background(), path, &out, plainTheme(), false, time.Millisecond))
+	assert.Equal(t, "=== run 2026-01-02T10:00:00Z ===\nid: feature.md\n10:00:01 --- iteration 1/50 ---\n10:00:02 second run\n", out.String())
+
+	err := tailProgressLog(context.Background(), filepath.Join(t.TempDir(), "missing.log"), &out, plainTheme(), false, time.Millisecond)
+	assert.ErrorContains(t, err, "no progress log")
+}
+
+func TestTailProgressLog_Follows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feature.log")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+	go func() { done <- tailProgressLog(ctx, path, out, plainTheme(), true, 5*time.Millisecond) }()
+
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "Waiting for") }, time.Second, 5*time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("=== run 2026-01-02T10:00:00Z ===\n10:00:01 halfway"), 0o600))
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(" there\nexit: complete\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "exit: complete") }, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.Contains(t, out.String(), "10:00:01 halfway there\n", "a line split across writes is printed once whole")
+}
+
+func TestColorizeLogLine(t *testing.T) {
+	th := defaultTheme()
+
+	assert.Equal(t, fgBold(th.accent, "=== run x ==="), colorizeLogLine(th, "=== run x ==="))
+	assert.Equal(t, dim("exit: ")+fgBold(th.success, "complete"), colorizeLogLine(th, "exit: complete"))
+	assert.Equal(t, dim("exit: ")+fgBold(th.failure, "blocked (stuck)"), colorizeLogLine(th, "exit: blocked (stuck)"))
+	assert.Equal(t, dim("10:00:01")+" "+fgBold(th.accent, "--- iteration 2/50 ---"), colorizeLogLine(th, "10:00:01 --- iteration 2/50 ---"))
+	assert.Equal(t, dim("10:00:01")+" "+fg(th.failure, "Warning: push failed"), colorizeLogLine(th, "10:00:01 Warning: push failed"))
+	assert.Equal(t, dim("10:00:01")+" Invoking claude...", colorizeLogLine(th, "10:00:01 Invoking claude..."))
+	assert.Equal(t, "plain", colorizeLogLine(plainTheme(), "plain"))
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/messages"
+	"github.com/alexander-akhmetov/programmator/internal/notify"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// notifyTimeout bounds the run-end notifications, which are sent after the
+// run's own context may have been canceled.
+const notifyTimeout = 30 * time.Second
+
+// notifyRunEnd sends the run-end notification, in catalog's language, if n
+// wants the run's exit reason. Failures are warnings: the run has already
+// ended.
+func notifyRunEnd(n *notify.Notifier, catalog *messages.Catalog, sourceID, workingDir string, result *loop.Result, runErr error) {
+	reason := safety.ExitReasonError
+	if result != nil && result.ExitReason != "" {
+		reason = result.ExitReason
+	}
+	if !n.Wants(string(reason)) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := n.Notify(ctx, string(reason), runEndMessage(catalog, sourceID, workingDir, reason, result, runErr)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// runEndMessage describes how a run ended.
+func runEndMessage(catalog *messages.Catalog, sourceID, workingDir string, reason safety.ExitReason, result *loop.Result, runErr error) notify.Message {
+	args := messages.Args{
+		"ID":     sourceID,
+		"Reason": string(reason),
+		"Dir":    workingDir,
+		"Result": result != nil,
+	}
+	if result != nil {
+		args["ExitMessage"] = result.ExitMessage
+		args["Iterations"] = result.Iterations
+		args["FilesChanged"] = len(result.TotalFilesChanged)
+		args["Duration"] = formatElapsed(result.Duration)
+		args["Labels"] = strings.Join(result.Labels, ", ")
+	}
+	if runErr != nil {
+		args["Error"] = runErr.Error()
+	}
+
+	return notify.Message{
+		Subject: catalog.Format(messages.RunEndSubject, args),
+		Body:    strings.TrimSuffix(catalog.Format(messages.RunEndBody, args), "\n"),
+	}
+}
+
+// notifyOwners emails each owner in routes the issues in their files, when
+// the last review of the run did not pass. Failures are warnings.
+func notifyOwners(n *notify.Notifier, catalog *messages.Catalog, routes map[string][]string, sourceID string, last *review.RunResult) {
+	if len(routes) == 0 || last == nil || last.Passed || !n.CanRoute() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	for _, owner := range slices.Sorted(maps.Keys(routes)) {
+		msg, ok := ownerMessage(catalog, owner, sourceID, last.Results)
+		if !ok {
+			continue
+		}
+		if err := n.Route(ctx, routes[owner], msg); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: notifying %s: %v\n", owner, err)
+		}
+	}
+}
+
+// ownerMessage lists the issues owned by owner, and reports false when there
+// are none.
+func ownerMessage(catalog *messages.Catalog, owner, sourceID string, results []*review.Result) (notify.Message, bool) {
+	var owned []*review.Result
+	count := 0
+	for _, res := range results {
+		var issues []review.Issue
+		for _, issue := range res.Issues {
+			if slices.Contains(issue.Owners, owner) {
+				issues = append(issues, issue)
+			}
+		}
+		if len(issues) > 0 {
+			owned = append(owned, &review.Result{AgentName: res.AgentName, Issues: issues})
+			count += len(issues)
+		}
+	}
+	if count == 0 {
+		return notify.Message{}, false
+	}
+	args := messages.Args{
+		"ID":     sourceID,
+		"Owner":  owner,
+		"Count":  count,
+		"Issues": review.FormatIssuesMarkdown(owned),
+	}
+	return notify.Message{
+		Subject: catalog.Format(messages.OwnerSubject, args),
+		Body:    strings.TrimRight(catalog.Format(messages.OwnerBody, args), "\n"),
+	}, true
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/notify"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+type recordingSender struct {
+	sent []notify.Message
+}
+
+func (r *recordingSender) Name() string { return "recording" }
+
+func (r *recordingSender) Send(_ context.Context, msg notify.Message) error {
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+type routingSender struct {
+	recordingSender
+	routed map[string][]notify.Message
+}
+
+func (r *routingSender) SendTo(_ context.Context, to []string, msg notify.Message) error {
+	for _, addr := range to {
+		r.routed[addr] = append(r.routed[addr], msg)
+	}
+	return nil
+}
+
+func TestRunEndMessage(t *testing.T) {
+	result := &loop.Result{
+		ExitReason:        safety.ExitReasonBlocked,
+		ExitMessage:       "needs credentials",
+		Iterations:        4,
+		TotalFilesChanged: []string{"a.go", "b.go"},
+		Duration:          90 * time.Second,
+		Labels:            []string{"overnight"},
+	}
+
+	msg := runEndMessage(nil, "plans/feature.md", "/repo", result.ExitReason, result, nil)
+
+	assert.Equal(t, "programmator: plans/feature.md blocked", msg.Subject)
+	assert.Equal(t, `Exit: blocked (needs credentials)
+Work item: plans/feature.md
+Directory: /repo
+Iterations: 4
+Files changed: 2
+Duration: 1m 30s
+Labels: overnight`, msg.Body)
+}
+
+func TestNotifyRunEnd(t *testing.T) {
+	t.Run("sends for wanted exit reasons", func(t *testing.T) {
+		s := &recordingSender{}
+		n := notify.New([]string{"complete"}, s)
+
+		notifyRunEnd(n, nil, "pro-1", "/repo", &loop.Result{ExitReason: safety.ExitReasonComplete}, nil)
+		notifyRunEnd(n, nil, "pro-1", "/repo", &loop.Result{ExitReason: safety.ExitReasonStagnation}, nil)
+
+		require.Len(t, s.sent, 1)
+		assert.Equal(t, "programmator: pro-1 complete", s.sent[0].Subject)
+	})
+
+	t.Run("a run error without a result is an error exit", func(t *testing.T) {
+		s := &recordingSender{}
+		notifyRunEnd(notify.New(nil, s), nil, "pro-1", "/repo", nil, errors.New("source unavailable"))
+
+		require.Len(t, s.sent, 1)
+		assert.Equal(t, "programmator: pro-1 error", s.sent[0].Subject)
+		assert.Contains(t, s.sent[0].Body, "Error: source unavailable")
+	})
+
+	t.Run("nil notifier", func(_ *testing.T) {
+		notifyRunEnd(nil, nil, "pro-1", "/repo", &loop.Result{ExitReason: safety.ExitReasonComplete}, nil)
+	})
+}
+
+func TestNotifyOwners(t *testing.T) {
+	last := &review.RunResult{Results: []*review.Result{
+		{AgentName: "quality", Issues: []review.Issue{
+			{File: "billing/charge.go", Line: 12, Severity: review.SeverityHigh, Description: "Rounding error", Owners: []string{"@org/billing"}},
+			{File: "main.go", Severity: review.SeverityLow, Description: "Unused import", Owners: []string{"@org/core"}},
+		}},
+	}}
+	routes := map[string][]string{"@org/billing": {"billing@example.com"}, "@org/search": {"search@example.com"}}
+
+	s := &routingSender{routed: map[string][]notify.Message{}}
+	n := notify.New([]string{"complete"}, s)
+	notifyOwners(n, nil, routes, "pro-1", last)
+
+	require.Len(t, s.routed["billing@example.com"], 1)
+	msg := s.routed["billing@example.com"][0]
+	assert.Equal(t, "programmator: pro-1 review issues for @org/billing", msg.Subject)
+	assert.Contains(t, msg.Body, "ended with 1 unresolved review issue(s) in files owned by @org/billing")
+	assert.Contains(t, msg.Body, "`billing/charge.go:12` - Rounding error")
+	assert.NotContains(t, msg.Body, "Unused import")
+	assert.Empty(t, s.routed["search@example.com"], "owners without issues are not emailed")
+	assert.Empty(t, s.sent, "routing does not send the run-end notification")
+
+	s.routed = map[string][]notify.Message{}
+	notifyOwners(n, nil, routes, "pro-1", &review.RunResult{Passed: true})
+	notifyOwners(n, nil, routes, "pro-1", nil)
+	assert.Empty(t, s.routed, "nothing unresolved")
+}
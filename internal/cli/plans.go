@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/plan"
+)
+
+var plansWorkDir string
+
+var plansCmd = &cobra.Command{
+	Use:   "plans",
+	Short: "Work with completed plans",
+}
+
+var plansSearchCmd = &cobra.Command{
+	Use:   "search [query...]",
+	Short: "Find completed plans and their outcomes",
+	Long: `Search the index of the completed plans directory (git.completed_plans_dir,
+default plans/completed). The index is written when git.move_completed_plans
+moves a plan there, with its title, completion date, and run stats.
+
+A plan matches when its title, file name, labels, or content contain every
+word of the query, ignoring case. Without a query every plan is listed.
+Matches are listed newest first.
+
+Examples:
+  programmator plans search caching
+  programmator plans search auth token -d /path/to/repo`,
+	RunE: runPlansSearch,
+}
+
+func init() {
+	plansSearchCmd.Flags().StringVarP(&plansWorkDir, "dir", "d", "", "Working directory (default: current directory)")
+	plansCmd.AddCommand(plansSearchCmd)
+}
+
+func runPlansSearch(cmd *cobra.Command, args []string) error {
+	wd, err := resolveWorkingDir(plansWorkDir)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadForDir(wd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dir := completedPlansDir(wd, cfg.Git.CompletedPlansDir)
+	matches, err := plan.Search(dir, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No completed plans found in %s\n", dir)
+		return nil
+	}
+	printPlanMatches(cmd.OutOrStdout(), dir, matches)
+	return nil
+}
+
+// completedPlansDir resolves the completed plans directory the way the loop
+// does: relative to wd, plans/completed by default.
+func completedPlansDir(wd, configured string) string {
+	switch {
+	case configured == "":
+		return filepath.Join(wd, "plans", "completed")
+	case filepath.IsAbs(configured):
+		return configured
+	default:
+		return filepath.Join(wd, configured)
+	}
+}
+
+// printPlanMatches prints one line per plan, its path relative to the
+// working directory when possible.
+func printPlanMatches(out io.Writer, dir string, matches []plan.IndexEntry) {
+	for _, e := range matches {
+		path := filepath.Join(dir, e.File)
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil && filepath.IsLocal(rel) {
+				path = rel
+			}
+		}
+		stats := fmt.Sprintf("%d iterations, %s, %d files changed", e.Iterations,
+			time.Duration(e.Duration*float64(time.Second)).String(), e.FilesChanged)
+		if e.Branch != "" {
+			stats += ", branch " + e.Branch
+		}
+		if len(e.Labels) > 0 {
+			stats += ", labels " + strings.Join(e.Labels, ",")
+		}
+		fmt.Fprintf(out, "%s  %s\n    %s (%s)\n", e.CompletedAt.Local().Format("2006-01-02 15:04"), e.Title, path, stats)
+	}
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/plan"
+)
+
+func TestCompletedPlansDir(t *testing.T) {
+	assert.Equal(t, filepath.Join("/repo", "plans", "completed"), completedPlansDir("/repo", ""))
+	assert.Equal(t, filepath.Join("/repo", "done"), completedPlansDir("/repo", "done"))
+	assert.Equal(t, "/archive", completedPlansDir("/repo", "/archive"))
+}
+
+func TestRunPlansSearch(t *testing.T) {
+	wd := t.TempDir()
+	dir := completedPlansDir(wd, "")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, plan.AddToIndex(dir, plan.IndexEntry{
+		File: "cache.md", Title: "Add caching", CompletedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Iterations: 4, Duration: 754, FilesChanged: 6, Branch: "programmator/cache",
+	}))
+
+	defer func() { plansWorkDir = "" }()
+	plansWorkDir = wd
+	var out bytes.Buffer
+	plansSearchCmd.SetOut(&out)
+	defer plansSearchCmd.SetOut(nil)
+
+	require.NoError(t, runPlansSearch(plansSearchCmd, []string{"caching"}))
+	assert.Contains(t, out.String(), "Add caching")
+	assert.Contains(t, out.String(), "cache.md (4 iterations, 12m34s, 6 files changed, branch programmator/cache)")
+
+	out.Reset()
+	require.NoError(t, runPlansSearch(plansSearchCmd, []string{"billing"}))
+	assert.Contains(t, out.String(), "No completed plans found")
+}
@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// preflightPingTimeout bounds the executor check, in seconds.
+const preflightPingTimeout = 120
+
+// preflightPingPrompt is the cheapest invocation that proves the executor
+// binary runs and is authenticated.
+const preflightPingPrompt = "Reply with the single word OK. Do not use any tools."
+
+// errPreflightFailed is returned when a preflight check fails; the checklist
+// has already been printed.
+var errPreflightFailed = errors.New("preflight checks failed")
+
+// preflightCheck is one line of the preflight checklist.
+type preflightCheck struct {
+	name    string
+	detail  string
+	err     error // the check failed
+	skipped bool
+}
+
+// preflight checks the repository and the executor before a run, so that a
+// missing base branch or an expired login fails the run before the first
+// iteration instead of in the middle of it.
+type preflight struct {
+	cfg        *config.Config
+	workingDir string
+	validation []string    // commands expected to pass before the run
+	invoker    llm.Invoker // nil when the executor is not pinged
+	diskFree   func(path string) (uint64, error)
+	runCommand func(ctx context.Context, dir, command string) ([]byte, error)
+}
+
+// newPreflight returns the preflight checks for a run of sourceID in
+// workingDir, as configured in cfg.Preflight.
+func newPreflight(cfg *config.Config, sourceID, workingDir string) (*preflight, error) {
+	p := &preflight{
+		cfg:        cfg,
+		workingDir: workingDir,
+		diskFree:   diskFree,
+		runCommand: runShellCommand,
+	}
+	if cfg.Preflight.Validation {
+		p.validation = cfg.ValidationCommands
+		src, id := source.Detect(sourceID, cfg.TicketCommand, cfg.TicketWorkspaces)
+		if item, err := src.Get(id); err == nil && len(item.ValidationCommands) > 0 {
+			p.validation = item.ValidationCommands
+		}
+	}
+	if cfg.Preflight.PingExecutor {
+		inv, err := executor.New(cfg.ToExecutorConfig())
+		if err != nil {
+			return nil, fmt.Errorf("create invoker: %w", err)
+		}
+		p.invoker = inv
+	}
+	return p, nil
+}
+
+// runPreflight runs the preflight checks for a run of sourceID and prints the
+// checklist to stdout.
+func runPreflight(cfg *config.Config, sourceID, workingDir string, t *theme) error {
+	p, err := newPreflight(cfg, sourceID, workingDir)
+	if err != nil {
+		return err
+	}
+	return printPreflight(os.Stdout, t, p.run(context.Background()))
+}
+
+// run runs every check, including those after a failure, so the checklist
+// shows all problems at once.
+func (p *preflight) run(ctx context.Context) []preflightCheck {
+	return []preflightCheck{
+		p.checkWorktree(),
+		p.checkBaseBranch(),
+		p.checkDiskSpace(),
+		p.checkValidation(ctx),
+		p.checkExecutor(ctx),
+	}
+}
+
+func (p *preflight) checkWorktree() preflightCheck {
+	c := preflightCheck{name: "Working tree"}
+	repo, err := gitutil.NewRepo(p.workingDir)
+	if err != nil {
+		c.err = errors.New("not a git repository")
+		return c
+	}
+	files, err := repo.UncommittedFiles()
+	switch {
+	case err != nil:
+		c.err = err
+	case len(files) == 0:
+		c.detail = "clean"
+	default:
+		c.err = repo.CanStash()
+		c.detail = fmt.Sprintf("%d uncommitted file(s), can be stashed", len(files))
+	}
+	return c
+}
+
+func (p *preflight) checkBaseBranch() preflightCheck {
+	base := p.cfg.Git.BaseBranch
+	c := preflightCheck{name: "Base branch"}
+	if base == "" {
+		c.skipped, c.detail = true, "git.base_branch not set"
+		return c
+	}
+	c.detail = base
+	repo, err := gitutil.NewRepo(p.workingDir)
+	if err != nil {
+		c.skipped, c.detail = true, "not a git repository"
+		return c
+	}
+	exists, err := repo.BranchExists(base)
+	switch {
+	case err != nil:
+		c.err = err
+	case !exists:
+		c.err = fmt.Errorf("branch %s not found locally or on origin", base)
+	}
+	return c
+}
+
+func (p *preflight) checkDiskSpace() preflightCheck {
+	c := preflightCheck{name: "Disk space"}
+	minMB := p.cfg.Preflight.MinFreeDiskMB
+	if minMB <= 0 {
+		c.skipped, c.detail = true, "preflight.min_free_disk_mb is 0"
+		return c
+	}
+	free, err := p.diskFree(p.workingDir)
+	if err != nil {
+		c.skipped, c.detail = true, err.Error()
+		return c
+	}
+	freeMB := free / (1 << 20)
+	c.detail = fmt.Sprintf("%d MB free", freeMB)
+	if freeMB < uint64(minMB) {
+		c.err = fmt.Errorf("%d MB free, %d MB required", freeMB, minMB)
+	}
+	return c
+}
+
+func (p *preflight) checkValidation(ctx context.Context) preflightCheck {
+	c := preflightCheck{name: "Validation commands"}
+	if !p.cfg.Preflight.Validation {
+		c.skipped, c.detail = true, "preflight.validation is off"
+		return c
+	}
+	if len(p.validation) == 0 {
+		c.skipped, c.detail = true, "none configured"
+		return c
+	}
+	if p.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(p.cfg.Timeout)*time.Second)
+		defer cancel()
+	}
+	for _, command := range p.validation {
+		out, err := p.runCommand(ctx, p.workingDir, command)
+		if err != nil {
+			c.err = fmt.Errorf("%s: %w%s", command, err, outputTail(out, 5))
+			return c
+		}
+	}
+	c.detail = fmt.Sprintf("%d passed", len(p.validation))
+	return c
+}
+
+func (p *preflight) checkExecutor(ctx context.Context) preflightCheck {
+	execCfg := p.cfg.ToExecutorConfig()
+	c := preflightCheck{name: "Executor", detail: cmp.Or(execCfg.Name, "claude")}
+	if p.invoker == nil {
+		c.skipped, c.detail = true, "preflight.ping_executor is off"
+		return c
+	}
+	res, err := p.invoker.Invoke(ctx, preflightPingPrompt, llm.InvokeOptions{
+		WorkingDir: p.workingDir,
+		ExtraFlags: execCfg.FlagsFor(llm.PurposeMain),
+		Purpose:    llm.PurposeMain,
+		Timeout:    preflightPingTimeout,
+	})
+	switch {
+	case err != nil:
+		c.err = err
+	case strings.TrimSpace(res.Text) == "":
+		c.err = errors.New("empty reply")
+	}
+	return c
+}
+
+// printPreflight writes the checklist to w and returns errPreflightFailed if
+// any check failed.
+func printPreflight(w io.Writer, t *theme, checks []preflightCheck) error {
+	failed := 0
+	fmt.Fprintln(w, t.bold("Preflight checks"))
+	for _, c := range checks {
+		mark, line := t.fg(t.success, "✓"), c.name
+		switch {
+		case c.err != nil:
+			failed++
+			mark, line = t.fg(t.failure, "✗"), c.name+": "+c.err.Error()
+		case c.skipped:
+			mark = t.dim("-")
+			line += t.dim(" (skipped: " + c.detail + ")")
+		case c.detail != "":
+			line += t.dim(" (" + c.detail + ")")
+		}
+		fmt.Fprintf(w, "  %s %s\n", mark, line)
+	}
+	fmt.Fprintln(w)
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", errPreflightFailed, failed, len(checks))
+	}
+	return nil
+}
+
+// runShellCommand runs a validation command through the platform shell.
+func runShellCommand(ctx context.Context, dir, command string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+// outputTail returns the last n non-empty lines of out, indented for the
+// checklist, or "" without output.
+func outputTail(out []byte, n int) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return ""
+	}
+	lines = lines[max(len(lines)-n, 0):]
+	return "\n      " + strings.Join(lines, "\n      ")
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+)
+
+func initPreflightRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "Test"},
+		{"commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	return dir
+}
+
+func testPreflight(t *testing.T, dir string) *preflight {
+	t.Helper()
+	cfg, err := config.LoadWithDirs(t.TempDir(), "")
+	require.NoError(t, err)
+	return &preflight{
+		cfg:        cfg,
+		workingDir: dir,
+		validation: []string{"true"},
+		invoker:    &promptRecorder{reply: "OK"},
+		diskFree:   func(string) (uint64, error) { return 10 << 30, nil },
+		runCommand: runShellCommand,
+	}
+}
+
+func TestPreflight_AllPass(t *testing.T) {
+	dir := initPreflightRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plan.md"), []byte("- [ ] Task\n"), 0o600))
+	p := testPreflight(t, dir)
+
+	checks := p.run(context.Background())
+	var out bytes.Buffer
+	require.NoError(t, printPreflight(&out, plainTheme(), checks))
+
+	assert.Contains(t, out.String(), "✓ Working tree (1 uncommitted file(s), can be stashed)")
+	assert.Contains(t, out.String(), "✓ Base branch (main)")
+	assert.Contains(t, out.String(), "✓ Disk space (10240 MB free)")
+	assert.Contains(t, out.String(), "✓ Validation commands (1 passed)")
+	assert.Contains(t, out.String(), "✓ Executor (claude)")
+	assert.Equal(t, preflightPingPrompt, p.invoker.(*promptRecorder).prompt)
+}
+
+func TestPreflight_Failures(t *testing.T) {
+	dir := initPreflightRepo(t)
+	p := testPreflight(t, dir)
+	p.cfg.Git.BaseBranch = "develop"
+	p.diskFree = func(string) (uint64, error) { return 100 << 20, nil }
+	p.validation = []string{"true", "echo 'FAIL: TestParse' && exit 1"}
+	p.invoker = &promptRecorder{}
+
+	checks := p.run(context.Background())
+	var out bytes.Buffer
+	err := printPreflight(&out, plainTheme(), checks)
+	require.ErrorIs(t, err, errPreflightFailed)
+	assert.EqualError(t, err, "preflight checks failed: 4 of 5")
+
+	assert.Contains(t, out.String(), "✓ Working tree (clean)")
+	assert.Contains(t, out.String(), "✗ Base branch: branch develop not found locally or on origin")
+	assert.Contains(t, out.String(), "✗ Disk space: 100 MB free, 1024 MB required")
+	assert.Contains(t, out.String(), "✗ Validation commands: echo 'FAIL: TestParse' && exit 1: exit status 1\n      FAIL: TestParse")
+	assert.Contains(t, out.String(), "✗ Executor: empty reply")
+}
+
+func TestPreflight_Skipped(t *testing.T) {
+	dir := t.TempDir()
+	p := testPreflight(t, dir)
+	p.cfg.Preflight.MinFreeDiskMB = 0
+	p.cfg.Preflight.Validation = false
+	p.invoker = nil
+	p.diskFree = func(string) (uint64, error) { return 0, errors.New("unreachable") }
+
+	checks := p.run(context.Background())
+	var out bytes.Buffer
+	err := printPreflight(&out, plainTheme(), checks)
+	require.ErrorIs(t, err, errPreflightFailed)
+
+	assert.Contains(t, out.String(), "✗ Working tree: not a git repository")
+	assert.Contains(t, out.String(), "- Base branch (skipped: not a git repository)")
+	assert.Contains(t, out.String(), "- Disk space (skipped: preflight.min_free_disk_mb is 0)")
+	assert.Contains(t, out.String(), "- Validation commands (skipped: preflight.validation is off)")
+	assert.Contains(t, out.String(), "- Executor (skipped: preflight.ping_executor is off)")
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// Choices offered when the last run of a work item did not complete.
+const (
+	priorRunResume     = "Resume where it stopped"
+	priorRunPostMortem = "Show the post-mortem of the last run"
+	priorRunCancel     = "Cancel"
+)
+
+// priorRunPolicy is how a run treats an unfinished last run of its work
+// item; a completed one always needs --rerun.
+type priorRunPolicy int
+
+const (
+	priorRunPrompt     priorRunPolicy = iota // ask what to do, in a terminal
+	priorRunAutoResume                       // resume without asking, for unattended runs
+)
+
+var errRunCancelled = errors.New("run cancelled")
+
+// checkPriorRun looks up the last run of the work item in the progress log
+// at logPath before a new one starts, so it is not repeated by accident. A
+// completed work item is only run again with rerun. After a run that did
+// not complete, ask, when set and without rerun, offers to resume or to
+// show the post-mortem of that run: the one saved by doctor run, or else
+// one from postMortem. Without ask the new run resumes.
+func checkPriorRun(ctx context.Context, logPath string, rerun bool, ask Collector, out io.Writer, postMortem func(context.Context) (string, error)) error {
+	data, err := os.ReadFile(logPath) //nolint:gosec // path under the state dir
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(out, "Warning: cannot check for earlier runs: %v\n", err)
+		}
+		return nil
+	}
+	runs := progressRuns(string(data))
+	if len(runs) == 0 {
+		return nil
+	}
+	last := runs[len(runs)-1]
+	when := last.Started.Local().Format("2006-01-02 15:04")
+
+	if last.Exit == string(safety.ExitReasonComplete) {
+		if !rerun {
+			return fmt.Errorf("%s was completed by the run on %s (%d iterations); pass --rerun to run it again", last.ID, when, last.Iterations)
+		}
+		fmt.Fprintf(out, "Running %s again: it was completed by the run on %s\n", last.ID, when)
+		return nil
+	}
+
+	exit := last.Exit
+	if exit == "" {
+		exit = "unfinished"
+	}
+	fmt.Fprintf(out, "The last run of %s, on %s, ended with %s after %d iterations\n", last.ID, when, exit, last.Iterations)
+	if ask == nil || rerun {
+		return nil
+	}
+
+	options := []string{priorRunResume, priorRunPostMortem, priorRunCancel}
+	for {
+		answer, err := ask.AskQuestion(ctx, "What now?", options)
+		if err != nil {
+			return err
+		}
+		switch answer {
+		case priorRunResume:
+			return nil
+		case priorRunPostMortem:
+			report, err := savedPostMortem(logPath, last)
+			if report == "" && err == nil {
+				fmt.Fprintln(out, "Writing a post-mortem of the last run...")
+				report, err = postMortem(ctx)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "\n%s\n\n", report)
+			options = []string{priorRunResume, priorRunCancel}
+		default:
+			return errRunCancelled
+		}
+	}
+}
+
+// savedPostMortem returns the post-mortem saved next to the progress log
+// at logPath, when it was written after run started; "" otherwise.
+func savedPostMortem(logPath string, run *progressRun) (string, error) {
+	path := strings.TrimSuffix(logPath, ".log") + ".postmortem.md"
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().Before(run.Started) {
+		return "", nil
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // path under the state dir
+	if err != nil {
+		return "", fmt.Errorf("read post-mortem: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
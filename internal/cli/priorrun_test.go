@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// scriptedCollector answers questions in order and records the options.
+type scriptedCollector struct {
+	answers []string
+	options [][]string
+}
+
+func (c *scriptedCollector) AskQuestion(_ context.Context, _ string, options []string) (string, error) {
+	c.options = append(c.options, options)
+	answer := c.answers[0]
+	c.answers = c.answers[1:]
+	return answer, nil
+}
+
+func logRun(t *testing.T, sourceID string, exit safety.ExitReason) {
+	t.Helper()
+	plog, err := openProgressLog(sourceID, t.TempDir(), nil)
+	require.NoError(t, err)
+	plog.finish(&loop.Result{ExitReason: exit, Iterations: 4}, nil)
+}
+
+func TestCheckPriorRun_NoRuns(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	var out bytes.Buffer
+
+	require.NoError(t, checkPriorRun(context.Background(), progressLogPath("feature.md"), false, nil, &out, nil))
+	assert.Empty(t, out.String())
+}
+
+func TestCheckPriorRun_Completed(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	logRun(t, "feature.md", safety.ExitReasonComplete)
+	logPath := progressLogPath("feature.md")
+	var out bytes.Buffer
+
+	err := checkPriorRun(context.Background(), logPath, false, nil, &out, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "feature.md was completed by the run on")
+	assert.Contains(t, err.Error(), "pass --rerun")
+
+	require.NoError(t, checkPriorRun(context.Background(), logPath, true, nil, &out, nil))
+	assert.Contains(t, out.String(), "Running feature.md again")
+}
+
+func TestCheckPriorRun_FailedResumesWithoutTerminal(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	logRun(t, "feature.md", safety.ExitReasonStagnation)
+	var out bytes.Buffer
+
+	require.NoError(t, checkPriorRun(context.Background(), progressLogPath("feature.md"), false, nil, &out, nil))
+	assert.Contains(t, out.String(), "ended with stagnation after 4 iterations")
+}
+
+func TestCheckPriorRun_FailedOffersPostMortem(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	logRun(t, "feature.md", safety.ExitReasonBlocked)
+	logPath := progressLogPath("feature.md")
+
+	written := 0
+	postMortem := func(context.Context) (string, error) {
+		written++
+		return "### What went wrong\nLooped.", nil
+	}
+	ask := &scriptedCollector{answers: []string{priorRunPostMortem, priorRunResume}}
+	var out bytes.Buffer
+
+	require.NoError(t, checkPriorRun(context.Background(), logPath, false, ask, &out, postMortem))
+	assert.Equal(t, 1, written)
+	assert.Contains(t, out.String(), "Looped.")
+	assert.Equal(t, [][]string{
+		{priorRunResume, priorRunPostMortem, priorRunCancel},
+		{priorRunResume, priorRunCancel},
+	}, ask.options)
+
+	// A post-mortem saved after the run is shown instead of writing one.
+	saved := "### What went wrong\nSaved."
+	savedPath := strings.TrimSuffix(logPath, ".log") + ".postmortem.md"
+	require.NoError(t, os.WriteFile(savedPath, []byte(saved+"\n"), 0o600))
+	require.NoError(t, os.Chtimes(savedPath, time.Now(), time.Now().Add(time.Minute)))
+	ask = &scriptedCollector{answers: []string{priorRunPostMortem, priorRunCancel}}
+	out.Reset()
+
+	require.ErrorIs(t, checkPriorRun(context.Background(), logPath, false, ask, &out, postMortem), errRunCancelled)
+	assert.Equal(t, 1, written)
+	assert.Contains(t, out.String(), "Saved.")
+}
@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/dirs"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/forge"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+// progressRunMarker starts each run's section in a progress log.
+const progressRunMarker = "=== run "
+
+var unsafeLogNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// progressLogPath returns where runs of sourceID are logged. Plans are keyed
+// by file name, so "plans/foo.md" and "foo.md" share a log; pull requests by
+// their short reference, so a URL and "pr:owner/repo#1" share one too.
+func progressLogPath(sourceID string) string {
+	return filepath.Join(dirs.LogsDir(), runLogName(sourceID)+".log")
+}
+
+// runLogName is the file name stem for the logs of sourceID's runs.
+func runLogName(sourceID string) string {
+	var name string
+	if ref, ok := forge.ParseRef(sourceID); ok {
+		name = ref.String()
+	} else {
+		name = filepath.Base(sourceID)
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	name = strings.Trim(unsafeLogNameChars.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		name = "run"
+	}
+	return name
+}
+
+// progressLog appends a plain-text record of a run: progress and review
+// events, iteration boundaries, and the exit reason. Each run starts with a
+// header recording the working directory and the commit it started from, so
+// `programmator doctor run` can reconstruct the run's diff.
+type progressLog struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func openProgressLog(sourceID, workingDir string, labels []string) (*progressLog, error) {
+	return openProgressLogAt(progressLogPath(sourceID), sourceID, workingDir, labels)
+}
+
+// openProgressLogAt opens a progress log at path, appending a new run.
+func openProgressLogAt(path, sourceID, workingDir string, labels []string) (*progressLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create logs dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gosec // path under the state dir
+	if err != nil {
+		return nil, fmt.Errorf("open progress log: %w", err)
+	}
+
+	base, _ := gitutil.HeadCommit(workingDir)
+	fmt.Fprintf(f, "%s%s ===\nid: %s\ndir: %s\nbase: %s\n",
+		progressRunMarker, time.Now().Format(time.RFC3339), sourceID, workingDir, base)
+	if len(labels) > 0 {
+		fmt.Fprintf(f, "labels: %s\n", strings.Join(labels, ", "))
+	}
+	return &progressLog{f: f}, nil
+}
+
+// event records progress, review, and iteration events. Tool and streaming
+// output is left out to keep the log readable.
+func (p *progressLog) event(ev event.Event) {
+	var text string
+	switch ev.Kind {
+	case event.KindProg, event.KindReview, event.KindPhaseAmbiguous:
+		text = ev.Text
+	case event.KindIterationSeparator:
+		fields := strings.Split(ev.Text, "\t")
+		if len(fields) == 3 {
+			text = fmt.Sprintf("--- iteration %s/%s ---", fields[1], fields[2])
+		}
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ts := time.Now().Format("15:04:05")
+	for line := range strings.SplitSeq(text, "\n") {
+		fmt.Fprintf(p.f, "%s %s\n", ts, line)
+	}
+}
+
+// finish records how the run ended and closes the log.
+func (p *progressLog) finish(result *loop.Result, runErr error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w := bufio.NewWriter(p.f)
+	switch {
+	case runErr != nil:
+		fmt.Fprintf(w, "exit: error (%v)\n", runErr)
+	case result != nil:
+		fmt.Fprintf(w, "exit: %s", result.ExitReason)
+		if result.ExitMessage != "" {
+			fmt.Fprintf(w, " (%s)", result.ExitMessage)
+		}
+		fmt.Fprintf(w, "\niterations: %d\nfiles changed: %d\nduration: %s\n",
+			result.Iterations, len(result.TotalFilesChanged), formatElapsed(result.Duration))
+		if inv := result.Invocations; inv.Invocations > 0 {
+			fmt.Fprintf(w, "invocations: %d\nlatency: mean %s, max %s\nretries: %d\n",
+				inv.Invocations, formatElapsed(inv.MeanLatency()), formatElapsed(inv.MaxLatency), inv.Retries)
+			if failures := inv.FormatFailures(); failures != "" {
+				fmt.Fprintf(w, "failures: %s\n", failures)
+			}
+		}
+		if len(result.ReviewLifecycle) > 0 {
+			fmt.Fprintf(w, "review issues: %s\n", review.SummarizeLifecycles(result.ReviewLifecycle))
+			for i := range result.ReviewLifecycle {
+				fmt.Fprintf(w, "review issue: %s\n", result.ReviewLifecycle[i].String())
+			}
+		}
+		for _, s := range result.RecentSummaries {
+			fmt.Fprintf(w, "recent: %s\n", s)
+		}
+	}
+	w.Flush()
+	p.f.Close()
+}
+
+// progressRun is one run recorded in a progress log.
+type progressRun struct {
+	Started    time.Time
+	ID         string
+	Dir        string
+	Base       string
+	Labels     []string
+	Exit       string // exit reason; empty while running or if the run crashed
+	Iterations int
+	Duration   string
+	// Invocation metrics; zero in logs written before they were recorded.
+	Invocations int
+	Latency     string // "mean 41s, max 3m 2s"
+	Retries     int
+	Failures    string // "timeout 1, rate_limit 2"
+	Log         string // the run's section of the log, header included
+}
+
+// progressRuns parses every run section in a progress log, oldest first.
+func progressRuns(content string) []*progressRun {
+	var runs []*progressRun
+	for {
+		idx := strings.Index(content, progressRunMarker)
+		if idx < 0 {
+			return runs
+		}
+		content = content[idx:]
+		end := strings.Index(content[len(progressRunMarker):], progressRunMarker)
+		section := content
+		if end >= 0 {
+			section = content[:len(progressRunMarker)+end]
+		}
+		runs = append(runs, parseProgressRun(section))
+		content = content[len(section):]
+	}
+}
+
+// parseProgressRun reads the header and exit fields of one run section.
+// Event lines start with a timestamp, so they never look like fields.
+func parseProgressRun(section string) *progressRun {
+	run := &progressRun{Log: section}
+	for line := range strings.Lines(section) {
+		line = strings.TrimRight(line, "\n")
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "id":
+			setOnce(&run.ID, value)
+		case "dir":
+			setOnce(&run.Dir, value)
+		case "base":
+			setOnce(&run.Base, value)
+		case "labels":
+			if run.Labels == nil {
+				run.Labels = strings.Split(value, ", ")
+			}
+		case "exit":
+			reason, _, _ := strings.Cut(value, " ")
+			run.Exit = reason
+		case "iterations":
+			run.Iterations, _ = strconv.Atoi(value)
+		case "duration":
+			run.Duration = value
+		case "invocations":
+			run.Invocations, _ = strconv.Atoi(value)
+		case "latency":
+			run.Latency = value
+		case "retries":
+			run.Retries, _ = strconv.Atoi(value)
+		case "failures":
+			run.Failures = value
+		default:
+			if ts, ok := strings.CutPrefix(line, progressRunMarker); ok && run.Started.IsZero() {
+				run.Started, _ = time.Parse(time.RFC3339, strings.TrimSuffix(ts, " ==="))
+			}
+		}
+	}
+	return run
+}
+
+func setOnce(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// lastProgressRun returns the last run section in a progress log.
+func lastProgressRun(content string) (*progressRun, error) {
+	runs := progressRuns(content)
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("progress log has no runs")
+	}
+	return runs[len(runs)-1], nil
+}
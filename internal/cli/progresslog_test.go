@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestProgressLogPath(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", "/state")
+
+	assert.Equal(t, "/state/logs/feature.log", progressLogPath("./plans/feature.md"))
+	assert.Equal(t, "/state/logs/feature.log", progressLogPath("feature.md"))
+	assert.Equal(t, "/state/logs/pro-1a2b.log", progressLogPath("pro-1a2b"))
+	assert.Equal(t, "/state/logs/a-b.log", progressLogPath("a b"))
+	assert.Equal(t, "/state/logs/pr-o-my.repo-7.log", progressLogPath("https://github.com/o/my.repo/pull/7"))
+	assert.Equal(t, "/state/logs/pr-o-my.repo-7.log", progressLogPath("pr:o/my.repo#7"))
+}
+
+func TestProgressLog_RecordsRuns(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	wd := t.TempDir()
+
+	first, err := openProgressLog("plan.md", wd, nil)
+	require.NoError(t, err)
+	first.finish(nil, errors.New("boom"))
+
+	plog, err := openProgressLog("plan.md", wd, []string{"refactor", "q3"})
+	require.NoError(t, err)
+	plog.event(event.IterationSeparator("ITER\t1\t10"))
+	plog.event(event.Prog("Summary: did things\nsecond line"))
+	plog.event(event.ToolUse("Read file.go"))
+	plog.finish(&loop.Result{
+		ExitReason:      safety.ExitReasonStagnation,
+		ExitMessage:     "no changes",
+		Iterations:      3,
+		Duration:        90 * time.Second,
+		RecentSummaries: []string{"iter 3: nothing"},
+		Invocations: llm.InvocationStats{
+			Invocations:  4,
+			Retries:      1,
+			TotalLatency: 80 * time.Second,
+			MaxLatency:   40 * time.Second,
+			Failures:     map[llm.FailureClass]int{llm.FailureParse: 1, llm.FailureTimeout: 1},
+		},
+		ReviewLifecycle: []review.IssueLifecycle{{
+			Agent:       "quality",
+			Issue:       review.Issue{File: "a.go", Line: 4, Description: "unchecked error"},
+			Transitions: []review.IssueTransition{{Iteration: 1, State: review.IssueIntroduced}, {Iteration: 2, State: review.IssueFixed}},
+		}},
+	}, nil)
+
+	data, err := os.ReadFile(progressLogPath("plan.md"))
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "exit: error (boom)")
+	assert.Contains(t, content, "--- iteration 1/10 ---")
+	assert.Contains(t, content, " Summary: did things\n")
+	assert.Contains(t, content, " second line\n")
+	assert.NotContains(t, content, "Read file.go")
+	assert.Contains(t, content, "exit: stagnation (no changes)\niterations: 3\n")
+	assert.Contains(t, content, "recent: iter 3: nothing")
+	assert.Contains(t, content, "review issues: 1 found, 1 fixed, 0 open\nreview issue: [quality] a.go:4 unchecked error: introduced 1, fixed 2\n")
+	assert.Contains(t, content, "invocations: 4\nlatency: mean 20s, max 40s\nretries: 1\nfailures: timeout 1, parse 1\n")
+
+	run, err := lastProgressRun(content)
+	require.NoError(t, err)
+	assert.Equal(t, wd, run.Dir)
+	assert.Equal(t, []string{"refactor", "q3"}, run.Labels)
+	assert.Equal(t, "stagnation", run.Exit)
+	assert.Equal(t, 3, run.Iterations)
+	assert.Equal(t, 4, run.Invocations)
+	assert.Equal(t, "mean 20s, max 40s", run.Latency)
+	assert.Equal(t, 1, run.Retries)
+	assert.Equal(t, "timeout 1, parse 1", run.Failures)
+	assert.NotContains(t, run.Log, "boom")
+	assert.Contains(t, run.Log, "stagnation")
+}
+
+func TestLastProgressRun_Empty(t *testing.T) {
+	_, err := lastProgressRun("")
+	require.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+)
+
+var (
+	promptsRun       int
+	promptsPhase     string
+	promptsIteration int
+)
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts <ticket-id>",
+	Short: "Diff the prompts of consecutive iterations on the same phase",
+	Long: `Show how the prompt changed between iterations of a past run that
+worked on the same phase, to debug why the executor behaved differently
+from one iteration to the next. Each iteration's prompt is diffed against
+the prompt of the previous iteration on its phase; review fix iterations
+count as one phase. The first prompt of a phase is only summarized.
+
+Prompts are read from the transcript every run records next to its
+progress log.
+
+Examples:
+  programmator prompts ./plans/feature.md
+  programmator prompts pro-1a2b --phase "Task 2"
+  programmator prompts pro-1a2b --iteration 5 --run 2`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPrompts,
+}
+
+func init() {
+	promptsCmd.Flags().IntVar(&promptsRun, "run", 1, "Diff the Nth most recent run (1 = last)")
+	promptsCmd.Flags().StringVar(&promptsPhase, "phase", "", "Only iterations whose phase contains this text")
+	promptsCmd.Flags().IntVar(&promptsIteration, "iteration", 0, "Only the diff ending at this iteration")
+}
+
+func runPrompts(_ *cobra.Command, args []string) error {
+	path := transcriptLogPath(args[0])
+	runs, err := readTranscriptRuns(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no recorded runs for %s at %s", args[0], path)
+		}
+		return err
+	}
+	if promptsRun < 1 || promptsRun > len(runs) {
+		return fmt.Errorf("--run %d: %s has %d recorded run(s)", promptsRun, path, len(runs))
+	}
+
+	ui := config.UIConfig{}
+	if wd, err := os.Getwd(); err == nil {
+		if cfg, err := config.LoadForDir(wd); err == nil {
+			ui = cfg.UI
+		}
+	}
+	t, err := newTheme(ui, noColor || os.Getenv("NO_COLOR") != "" || !stdoutIsTTY())
+	if err != nil {
+		return err
+	}
+
+	diffs := promptDiffs(runs[len(runs)-promptsRun].Iterations)
+	shown := 0
+	for _, d := range diffs {
+		if promptsIteration > 0 && d.cur.Iteration != promptsIteration {
+			continue
+		}
+		if promptsPhase != "" && !strings.Contains(strings.ToLower(d.phase), strings.ToLower(promptsPhase)) {
+			continue
+		}
+		writePromptDiff(os.Stdout, t, d)
+		shown++
+	}
+	if shown == 0 {
+		return fmt.Errorf("no iterations of %s match", args[0])
+	}
+	return nil
+}
+
+// promptDiff pairs an iteration with the previous iteration on its phase,
+// if there was one.
+type promptDiff struct {
+	phase string
+	prev  *loop.IterationRecord
+	cur   loop.IterationRecord
+}
+
+// promptDiffs pairs each iteration with the previous one on the same phase,
+// in iteration order.
+func promptDiffs(iterations []loop.IterationRecord) []promptDiff {
+	last := make(map[string]*loop.IterationRecord)
+	diffs := make([]promptDiff, 0, len(iterations))
+	for i := range iterations {
+		rec := &iterations[i]
+		phase := promptPhase(*rec)
+		diffs = append(diffs, promptDiff{phase: phase, prev: last[phase], cur: *rec})
+		last[phase] = rec
+	}
+	return diffs
+}
+
+// promptPhase is what an iteration worked on, for grouping its prompt.
+func promptPhase(rec loop.IterationRecord) string {
+	switch {
+	case rec.ReviewFix:
+		return "review fixes"
+	case rec.Phase != "":
+		return rec.Phase
+	}
+	return "no phase"
+}
+
+// writePromptDiff prints d as a header and the unified diff of the two
+// prompts, or a summary of the first prompt of a phase.
+func writePromptDiff(w io.Writer, t *theme, d promptDiff) {
+	header := fmt.Sprintf("Iteration %d — %s", d.cur.Iteration, d.phase)
+	if d.cur.Resumed {
+		header += " (continued session)"
+	}
+	fmt.Fprintln(w, t.fgBold(t.accent, header))
+
+	if d.prev == nil {
+		fmt.Fprintln(w, t.dim(fmt.Sprintf("first prompt of the phase, %d lines", promptLines(d.cur.Prompt))))
+		fmt.Fprintln(w)
+		return
+	}
+	diff := udiff.Unified(fmt.Sprintf("iteration %d", d.prev.Iteration), fmt.Sprintf("iteration %d", d.cur.Iteration),
+		d.prev.Prompt, d.cur.Prompt)
+	if diff == "" {
+		fmt.Fprintln(w, t.dim(fmt.Sprintf("unchanged since iteration %d", d.prev.Iteration)))
+		fmt.Fprintln(w)
+		return
+	}
+	for line := range strings.SplitSeq(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			line = t.dim(line)
+		case strings.HasPrefix(line, "@@"):
+			line = t.fg(t.diffHunk, line)
+		case strings.HasPrefix(line, "-"):
+			line = t.fg(t.diffDel, line)
+		case strings.HasPrefix(line, "+"):
+			line = t.fg(t.diffAdd, line)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
+
+func promptLines(prompt string) int {
+	return len(strings.Split(strings.TrimRight(prompt, "\n"), "\n"))
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+)
+
+func TestPromptDiffs(t *testing.T) {
+	diffs := promptDiffs([]loop.IterationRecord{
+		{Iteration: 1, Phase: "Task 1", Prompt: "a"},
+		{Iteration: 2, Phase: "Task 2", Prompt: "b"},
+		{Iteration: 3, Phase: "Task 1", Prompt: "c"},
+		{Iteration: 4, ReviewFix: true, Phase: "Task 1", Prompt: "d"},
+		{Iteration: 5, Phase: "Task 1", Prompt: "e"},
+	})
+	require.Len(t, diffs, 5)
+
+	prev := func(i int) int {
+		if diffs[i].prev == nil {
+			return 0
+		}
+		return diffs[i].prev.Iteration
+	}
+	assert.Equal(t, []int{0, 0, 1, 0, 3}, []int{prev(0), prev(1), prev(2), prev(3), prev(4)},
+		"review fixes are a phase of their own")
+	assert.Equal(t, "review fixes", diffs[3].phase)
+}
+
+func TestWritePromptDiff(t *testing.T) {
+	th, err := newTheme(config.UIConfig{}, true)
+	require.NoError(t, err)
+
+	first := loop.IterationRecord{Iteration: 1, Phase: "Task 1", Prompt: "Implement the parser.\nStatus: pending\n"}
+	second := loop.IterationRecord{Iteration: 3, Phase: "Task 1", Resumed: true, Prompt: "Implement the parser.\nStatus: blocked\n"}
+
+	var buf bytes.Buffer
+	writePromptDiff(&buf, th, promptDiff{phase: "Task 1", cur: first})
+	assert.Equal(t, "Iteration 1 — Task 1\nfirst prompt of the phase, 2 lines\n\n", buf.String())
+
+	buf.Reset()
+	writePromptDiff(&buf, th, promptDiff{phase: "Task 1", prev: &first, cur: second})
+	out := buf.String()
+	assert.Contains(t, out, "Iteration 3 — Task 1 (continued session)\n")
+	assert.Contains(t, out, "--- iteration 1\n+++ iteration 3\n")
+	assert.Contains(t, out, "-Status: pending\n+Status: blocked\n")
+
+	buf.Reset()
+	writePromptDiff(&buf, th, promptDiff{phase: "Task 1", prev: &first, cur: loop.IterationRecord{Iteration: 2, Prompt: first.Prompt}})
+	assert.Contains(t, buf.String(), "unchanged since iteration 1")
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+)
+
+var replayRun int
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <ticket-id>",
+	Short: "Replay the loop decisions of a past run",
+	Long: `Replay the decisions the loop engine made during a past run of a plan or
+ticket, to see why the run exited when it did.
+
+Every run records the engine's decisions, with the executor statuses, review
+results, and safety counters they were made from, next to its progress log.
+Replay feeds those inputs through the current engine in memory, without
+invoking the executor or review agents, prints the timeline, and names the
+decision that ended the run. Decisions the engine now makes differently are
+marked with "!".
+
+Examples:
+  programmator replay ./plans/feature.md
+  programmator replay pro-1a2b --run 2`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReplay,
+}
+
+func init() {
+	replayCmd.Flags().IntVar(&replayRun, "run", 1, "Replay the Nth most recent run (1 = last)")
+}
+
+func runReplay(_ *cobra.Command, args []string) error {
+	path := transitionLogPath(args[0])
+	runs, err := readTransitionRuns(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no recorded runs for %s at %s", args[0], path)
+		}
+		return err
+	}
+	if replayRun < 1 || replayRun > len(runs) {
+		return fmt.Errorf("--run %d: %s has %d recorded run(s)", replayRun, path, len(runs))
+	}
+	printReplay(os.Stdout, loop.Replay(runs[len(runs)-replayRun]))
+	return nil
+}
+
+// transitionLogPath returns where the engine decisions of sourceID's runs
+// are recorded, next to its progress log.
+func transitionLogPath(sourceID string) string {
+	return strings.TrimSuffix(progressLogPath(sourceID), ".log") + ".transitions.jsonl"
+}
+
+// transitionLog appends a run's engine transitions to a JSON-lines file.
+type transitionLog struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func openTransitionLog(sourceID string) (*transitionLog, error) {
+	path := transitionLogPath(sourceID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create logs dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gosec // path under the state dir
+	if err != nil {
+		return nil, fmt.Errorf("open transition log: %w", err)
+	}
+	return &transitionLog{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (l *transitionLog) record(t loop.Transition) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t.Time = time.Now()
+	_ = l.enc.Encode(t)
+}
+
+func (l *transitionLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.f.Close()
+}
+
+// readTransitionRuns reads a transition log and splits it into runs, oldest
+// first. Each run begins with a start transition.
+func readTransitionRuns(path string) ([][]loop.Transition, error) {
+	f, err := os.Open(path) //nolint:gosec // path under the state dir
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs [][]loop.Transition
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var t loop.Transition
+		if err := json.Unmarshal(scanner.Bytes(), &t); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if t.Kind == loop.TransitionStart || len(runs) == 0 {
+			runs = append(runs, nil)
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read transition log: %w", err)
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("%s has no recorded runs", path)
+	}
+	return runs, nil
+}
+
+// printReplay prints the replayed timeline and what ended the run.
+func printReplay(out io.Writer, steps []loop.ReplayStep) {
+	if len(steps) > 0 && !steps[0].Recorded.Time.IsZero() {
+		fmt.Fprintf(out, "Run started %s, %d decisions\n\n",
+			steps[0].Recorded.Time.Local().Format("2006-01-02 15:04:05"), len(steps))
+	}
+
+	diverged := 0
+	for _, s := range steps {
+		marker := " "
+		if s.Diverged() {
+			marker = "!"
+			diverged++
+		}
+		iter := ""
+		if s.Recorded.Iteration > 0 {
+			iter = fmt.Sprintf("iter %d", s.Recorded.Iteration)
+		}
+		line := fmt.Sprintf("%s %4d  %-8s  %s", marker, s.Recorded.Seq, iter, s.Recorded.Describe())
+		if s.Replayed.Decision != "" && s.Recorded.Kind != loop.TransitionExit {
+			line += " -> " + formatDecision(s.Replayed)
+		}
+		if s.Diverged() {
+			line += fmt.Sprintf(" (recorded: %s)", formatDecision(s.Recorded))
+		}
+		fmt.Fprintln(out, line)
+	}
+
+	fmt.Fprintln(out)
+	if len(steps) == 0 || steps[len(steps)-1].Recorded.Kind != loop.TransitionExit {
+		fmt.Fprintln(out, "The run has no exit record: it is still running or was killed.")
+	} else {
+		exit := steps[len(steps)-1].Recorded
+		if cause, ok := loop.ExitCause(steps); ok {
+			fmt.Fprintf(out, "Exit %s was decided at #%d: %s -> %s\n",
+				exit.ExitReason, cause.Recorded.Seq, cause.Recorded.Describe(), formatDecision(cause.Replayed))
+		} else {
+			fmt.Fprintf(out, "Exit %s was not an engine decision", exit.ExitReason)
+			if exit.Decision != "" {
+				fmt.Fprintf(out, " (%s)", exit.Decision)
+			}
+			fmt.Fprintln(out)
+		}
+	}
+	if diverged > 0 {
+		fmt.Fprintf(out, "%d decision(s) differ from the recording (marked !)\n", diverged)
+	} else {
+		fmt.Fprintln(out, "All decisions match the recording")
+	}
+}
+
+func formatDecision(t loop.Transition) string {
+	s := t.Decision
+	if t.ExitReason != "" && t.Decision != string(t.ExitReason) {
+		s += " (" + string(t.ExitReason)
+		if t.Message != "" {
+			s += ": " + t.Message
+		}
+		s += ")"
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestTransitionLogPath(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", "/state")
+	assert.Equal(t, "/state/logs/feature.transitions.jsonl", transitionLogPath("./plans/feature.md"))
+}
+
+// recordRun writes one run of engine decisions to the transition log of sourceID.
+func recordRun(t *testing.T, sourceID string, run func(e *loop.Engine)) {
+	t.Helper()
+	tlog, err := openTransitionLog(sourceID)
+	require.NoError(t, err)
+	defer tlog.close()
+
+	e := &loop.Engine{SafetyConfig: safety.Config{MaxIterations: 10, StagnationLimit: 2}, MaxReviewIter: 3}
+	e.OnTransition = tlog.record
+	e.Start()
+	run(e)
+}
+
+func TestTransitionLog_ReplayRuns(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+
+	recordRun(t, "feature.md", func(e *loop.Engine) {
+		e.Finish(safety.ExitReasonError, "invoke failed")
+	})
+	recordRun(t, "feature.md", func(e *loop.Engine) {
+		e.CheckSafety(&safety.State{Iteration: 1, ConsecutiveNoChanges: 1})
+		e.CheckSafety(&safety.State{Iteration: 2, ConsecutiveNoChanges: 2})
+		e.Finish(safety.ExitReasonStagnation, "")
+	})
+
+	runs, err := readTransitionRuns(transitionLogPath("feature.md"))
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Len(t, runs[0], 2)
+	require.Len(t, runs[1], 4)
+	assert.False(t, runs[1][0].Time.IsZero(), "the recorder stamps each transition")
+
+	var out bytes.Buffer
+	printReplay(&out, loop.Replay(runs[1]))
+	assert.Contains(t, out.String(), "safety check: no-change streak 2, error streak 0, review iterations 0 -> exit (stagnation: No file changes for multiple iterations)")
+	assert.Contains(t, out.String(), "Exit stagnation was decided at #3")
+	assert.Contains(t, out.String(), "All decisions match the recording")
+
+	out.Reset()
+	printReplay(&out, loop.Replay(runs[0]))
+	assert.Contains(t, out.String(), "Exit error was not an engine decision (invoke failed)")
+}
+
+func TestPrintReplay_Divergence(t *testing.T) {
+	steps := loop.Replay([]loop.Transition{
+		{Seq: 1, Kind: loop.TransitionStart, Config: &loop.EngineConfig{Safety: safety.Config{MaxIterations: 10, StagnationLimit: 5}}},
+		{Seq: 2, Kind: loop.TransitionSafety, Iteration: 2, Safety: &loop.SafetySnapshot{Iteration: 2, ConsecutiveNoChanges: 2},
+			Decision: loop.DecisionExit, ExitReason: safety.ExitReasonStagnation},
+	})
+
+	var out bytes.Buffer
+	printReplay(&out, steps)
+	assert.Contains(t, out.String(), "!    2  iter 2")
+	assert.Contains(t, out.String(), "-> continue (recorded: exit (stagnation))")
+	assert.Contains(t, out.String(), "The run has no exit record")
+	assert.Contains(t, out.String(), "1 decision(s) differ from the recording")
+}
+
+func TestReadTransitionRuns_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := readTransitionRuns(dir + "/missing.jsonl")
+	require.True(t, os.IsNotExist(err))
+
+	bad := dir + "/bad.jsonl"
+	require.NoError(t, os.WriteFile(bad, []byte("{not json\n"), 0o600))
+	_, err = readTransitionRuns(bad)
+	require.ErrorContains(t, err, "bad.jsonl:1")
+
+	empty := dir + "/empty.jsonl"
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+	_, err = readTransitionRuns(empty)
+	require.ErrorContains(t, err, "no recorded runs")
+}
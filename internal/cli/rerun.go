@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+var rerunFromPhase string
+
+var rerunCmd = &cobra.Command{
+	Use:   "rerun <ticket-id> --from-phase <phase>",
+	Short: "Reopen a phase and run the loop from there",
+	Long: `Reopen a completed phase of a ticket, plan file, or pull request, along
+with its sub-phases and every later phase that depends on it, then start the
+loop as "programmator start" does. Without explicit dependencies a phase
+depends on every phase before it, so all later phases are reopened too.
+
+The phase is matched by name, ignoring case and a "Phase N:" prefix; a
+unique part of the name is enough. All flags of start apply.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return startRun(args[0], rerunFromPhase)
+	},
+}
+
+func init() {
+	addStartFlags(rerunCmd)
+	rerunCmd.Flags().StringVar(&rerunFromPhase, "from-phase", "", "Phase to run again, with the phases depending on it")
+	_ = rerunCmd.MarkFlagRequired("from-phase")
+}
+
+// reopenFromPhase clears the completion of the phase of sourceID named
+// phaseName and of the phases depending on it, and prints them.
+func reopenFromPhase(cfg *config.Config, sourceID, phaseName string) error {
+	src, id := source.Detect(sourceID, cfg.TicketCommand, cfg.TicketWorkspaces)
+	reopener, ok := src.(source.PhaseReopener)
+	if !ok {
+		return fmt.Errorf("%s sources cannot reopen phases", src.Type())
+	}
+	item, err := src.Get(id)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", sourceID, err)
+	}
+	idx, err := findPhase(item, phaseName)
+	if err != nil {
+		return err
+	}
+
+	indices := item.RerunPhases(idx)
+	if len(indices) == 0 {
+		fmt.Printf("Phase %q and the phases depending on it are not completed; nothing to reopen\n", item.Phases[idx].Name)
+		return nil
+	}
+	if err := reopener.ReopenPhases(id, indices); err != nil {
+		return fmt.Errorf("reopen phases: %w", err)
+	}
+	fmt.Printf("Reopened %d phase(s):\n", len(indices))
+	for _, i := range indices {
+		fmt.Printf("  %s\n", item.Phases[i].Name)
+	}
+	fmt.Println()
+	return nil
+}
+
+// findPhase returns the index of the phase of item named name: the one whose
+// normalized name equals it, or else the only one containing it, "Phase N:"
+// prefix included, so "Phase 3" finds "Phase 3: Wire up the CLI".
+func findPhase(item *domain.WorkItem, name string) (int, error) {
+	want := domain.NormalizePhaseName(name)
+	if want == "" {
+		return -1, fmt.Errorf("--from-phase is empty")
+	}
+
+	var partial []int
+	for i, phase := range item.Phases {
+		if domain.NormalizePhaseName(phase.Name) == want {
+			return i, nil
+		}
+		full := strings.Join(strings.Fields(strings.ToLower(phase.Name)), " ")
+		if containsPhrase(full, strings.Join(strings.Fields(strings.ToLower(name)), " ")) {
+			partial = append(partial, i)
+		}
+	}
+	switch len(partial) {
+	case 1:
+		return partial[0], nil
+	case 0:
+		return -1, fmt.Errorf("no phase matches %q; phases: %s", name, phaseNames(item, nil))
+	default:
+		return -1, fmt.Errorf("%q matches several phases: %s", name, phaseNames(item, partial))
+	}
+}
+
+// containsPhrase reports whether s contains sub not followed by a digit, so
+// "phase 1" is not found in "phase 12".
+func containsPhrase(s, sub string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], sub)
+		if j < 0 {
+			return false
+		}
+		end := i + j + len(sub)
+		if end == len(s) || s[end] < '0' || s[end] > '9' {
+			return true
+		}
+		i += j + 1
+	}
+}
+
+// phaseNames lists the names of the phases at indices, or of all phases when
+// indices is nil, quoted and separated by commas.
+func phaseNames(item *domain.WorkItem, indices []int) string {
+	var names []string
+	for i, phase := range item.Phases {
+		if indices == nil || slices.Contains(indices, i) {
+			names = append(names, fmt.Sprintf("%q", phase.Name))
+		}
+	}
+	return strings.Join(names, ", ")
+}
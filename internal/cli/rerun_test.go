@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+)
+
+func TestFindPhase(t *testing.T) {
+	item := &domain.WorkItem{Phases: []domain.Phase{
+		{Name: "Phase 1: Add tests"},
+		{Name: "Phase 2: Add tests for parser"},
+		{Name: "Phase 3: Wire up the CLI"},
+	}}
+	twelve := &domain.WorkItem{Phases: []domain.Phase{{Name: "Phase 1: A"}, {Name: "Phase 12: B"}}}
+	idx, err := findPhase(twelve, "Phase 1")
+	require.NoError(t, err, "phase 1 is not phase 12")
+	assert.Equal(t, 0, idx)
+
+	idx, err = findPhase(item, "Phase 3")
+	require.NoError(t, err)
+	assert.Equal(t, 2, idx)
+
+	idx, err = findPhase(item, "phase 3: wire up  the cli")
+	require.NoError(t, err)
+	assert.Equal(t, 2, idx)
+
+	idx, err = findPhase(item, "Add tests")
+	require.NoError(t, err, "an exact match wins over partial ones")
+	assert.Equal(t, 0, idx)
+
+	idx, err = findPhase(item, "CLI")
+	require.NoError(t, err)
+	assert.Equal(t, 2, idx)
+
+	_, err = findPhase(item, "add")
+	require.ErrorContains(t, err, "matches several phases")
+
+	_, err = findPhase(item, "Phase 30")
+	require.Error(t, err)
+
+	_, err = findPhase(item, "Deploy")
+	require.ErrorContains(t, err, `"Phase 1: Add tests"`)
+}
@@ -3,21 +3,35 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"github.com/alexander-akhmetov/programmator/internal/config"
 	"github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
 	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
 )
 
 var errReviewFailed = fmt.Errorf("review failed: issues found")
 
 var (
-	reviewBaseBranch string
-	reviewWorkDir    string
+	reviewBaseBranch  string
+	reviewWorkDir     string
+	reviewWorkingTree bool
+	reviewFix         bool
+	reviewFixBranch   bool
+	reviewForce       bool
+	reviewBrowse      bool
+	reviewLast        int
+	reviewAuthor      string
+	reviewSince       string
 )
 
 var reviewCmd = &cobra.Command{
@@ -26,12 +40,31 @@ var reviewCmd = &cobra.Command{
 	Long: `Run code review on the current git diff without requiring a ticket.
 
 By default, reviews changes from main branch to HEAD (main...HEAD).
-Use --base to specify a different base branch.
+Use --base to specify a different base branch, or --working-tree to review
+the staged, unstaged, and untracked changes that are not committed yet.
+--last, --author, and --since review the files touched by the matching
+commits on the current branch instead, with the diff starting before the
+oldest of them.
+
+With --fix, the executor fixes the issues found and the changes are
+reviewed again until a review passes or the review limits are reached.
+Fixes are left uncommitted, unless --fix-branch commits them to a new
+programmator/review-<time> branch.
+
+With --browse, a failed review opens an issue browser: pick an issue and
+press enter to open the file at its line in $VISUAL or $EDITOR, or c to
+show the code around it.
 
 Examples:
-  programmator review                    # Review changes vs main
-  programmator review --base=develop     # Review changes vs develop
-  programmator review -d /path/to/repo   # Review specific directory`,
+  programmator review                          # Review changes vs main
+  programmator review --base=develop           # Review changes vs develop
+  programmator review --working-tree           # Review uncommitted changes
+  programmator review --working-tree --fix     # Review and fix uncommitted changes
+  programmator review --last 3                 # Review the last 3 commits
+  programmator review --author alice --since 2.weeks  # Review alice's recent commits
+  programmator review --fix --fix-branch       # Fix, committing to a new branch
+  programmator review --browse                 # Browse the issues found
+  programmator review -d /path/to/repo         # Review specific directory`,
 	SilenceErrors: true,
 	RunE:          runReview,
 }
@@ -39,9 +72,39 @@ Examples:
 func init() {
 	reviewCmd.Flags().StringVar(&reviewBaseBranch, "base", "main", "Base branch to diff against (default: main)")
 	reviewCmd.Flags().StringVarP(&reviewWorkDir, "dir", "d", "", "Working directory (default: current directory)")
+	reviewCmd.Flags().BoolVar(&reviewWorkingTree, "working-tree", false, "Review uncommitted changes (staged, unstaged, and untracked) instead of a branch diff")
+	reviewCmd.Flags().IntVar(&reviewLast, "last", 0, "Review the files changed in the last N commits")
+	reviewCmd.Flags().StringVar(&reviewAuthor, "author", "", "Review the files changed in commits by this author (pattern, as git log --author)")
+	reviewCmd.Flags().StringVar(&reviewSince, "since", "", "Review the files changed in commits since this date (as git log --since)")
+	reviewCmd.Flags().BoolVar(&reviewFix, "fix", false, "Have the executor fix the issues found, reviewing again until the review passes")
+	reviewCmd.Flags().BoolVar(&reviewFixBranch, "fix-branch", false, "With --fix, commit the fixes to a new branch instead of leaving them uncommitted")
+	reviewCmd.Flags().BoolVar(&reviewForce, "force", false, "With --fix, run even if another process holds the run lock of the working directory")
+	reviewCmd.Flags().BoolVar(&reviewBrowse, "browse", false, "Browse the issues of a failed review and open them in $EDITOR")
 }
 
-func runReview(_ *cobra.Command, _ []string) error {
+func runReview(cmd *cobra.Command, _ []string) error {
+	if reviewWorkingTree && cmd.Flags().Changed("base") {
+		return fmt.Errorf("--base and --working-tree cannot be used together")
+	}
+	if reviewLast < 0 {
+		return fmt.Errorf("--last must not be negative")
+	}
+	if reviewByCommits() && (reviewWorkingTree || cmd.Flags().Changed("base")) {
+		return fmt.Errorf("--last, --author, and --since cannot be used with --base or --working-tree")
+	}
+	if reviewFixBranch && !reviewFix {
+		return fmt.Errorf("--fix-branch requires --fix")
+	}
+	if reviewForce && !reviewFix {
+		return fmt.Errorf("--force requires --fix")
+	}
+	if reviewBrowse && reviewFix {
+		return fmt.Errorf("--browse and --fix cannot be used together")
+	}
+	if reviewBrowse && !stdoutIsTTY() {
+		return fmt.Errorf("--browse requires a terminal")
+	}
+
 	wd, err := resolveWorkingDir(reviewWorkDir)
 	if err != nil {
 		return err
@@ -51,7 +114,7 @@ func runReview(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("not a git repository: %s", wd)
 	}
 
-	filesChanged, err := git.ChangedFiles(wd, reviewBaseBranch)
+	filesChanged, commitsBase, err := reviewChangedFiles(wd)
 	if err != nil {
 		return fmt.Errorf("failed to get changed files: %w", err)
 	}
@@ -61,24 +124,46 @@ func runReview(_ *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	fmt.Printf("Reviewing %d changed files (vs %s):\n", len(filesChanged), reviewBaseBranch)
+	fmt.Printf("Reviewing %d changed files (%s):\n", len(filesChanged), reviewScope())
 	for _, f := range filesChanged {
 		fmt.Printf("  %s\n", f)
 	}
 	fmt.Println()
 
-	cfg, err := config.Load()
+	cfg, err := config.LoadForDir(wd)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("invalid config: %w", err)
 	}
+	theme, err := themeFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	llm.SetMaxConcurrentInvocations(cfg.MaxConcurrentInvocations)
 
 	reviewConfig, err := cfg.ToReviewConfig()
 	if err != nil {
 		return fmt.Errorf("invalid review config: %w", err)
 	}
+	switch {
+	case reviewByCommits():
+		reviewConfig.DiffBase = commitsBase
+	case !reviewWorkingTree:
+		reviewConfig.BaseBranch = reviewBaseBranch
+		reviewConfig.DiffBase = reviewBaseBranch
+		if repo, err := git.NewRepo(wd); err == nil {
+			if base, err := repo.MergeBase(reviewBaseBranch); err == nil {
+				reviewConfig.DiffBase = base
+			}
+		}
+	}
+
+	if reviewFix {
+		return runReviewFix(cfg, theme, reviewConfig, wd, filesChanged)
+	}
 
 	runner := review.NewRunner(reviewConfig)
 
@@ -87,15 +172,139 @@ func runReview(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("review failed: %w", err)
 	}
 
-	printReviewSummary(result)
+	printReviewSummary(result, theme)
 
 	if !result.Passed {
+		if reviewBrowse {
+			if err := runIssueBrowser(result.Results, theme); err != nil {
+				return fmt.Errorf("issue browser: %w", err)
+			}
+		}
 		return errReviewFailed
 	}
 
 	return nil
 }
 
+// reviewChangedFiles lists the files to review: the uncommitted ones with
+// --working-tree, the ones touched by the commits --last, --author, and
+// --since select, or the ones changed since the base branch. With commits
+// selected it also returns the revision their diff starts from.
+func reviewChangedFiles(wd string) ([]string, string, error) {
+	if !reviewWorkingTree && !reviewByCommits() {
+		files, err := git.ChangedFiles(wd, reviewBaseBranch)
+		return files, "", err
+	}
+	repo, err := git.NewRepo(wd)
+	if err != nil {
+		return nil, "", fmt.Errorf("open git repo: %w", err)
+	}
+	if reviewWorkingTree {
+		files, err := repo.UncommittedFiles()
+		return files, "", err
+	}
+	return repo.ChangedFilesInCommits(git.CommitFilter{Last: reviewLast, Author: reviewAuthor, Since: reviewSince})
+}
+
+// reviewByCommits reports whether the review covers selected commits
+// rather than a branch diff or the working tree.
+func reviewByCommits() bool {
+	return reviewLast > 0 || reviewAuthor != "" || reviewSince != ""
+}
+
+// reviewScope describes what the review covers, for the file list header.
+func reviewScope() string {
+	switch {
+	case reviewWorkingTree:
+		return "working tree"
+	case !reviewByCommits():
+		return "vs " + reviewBaseBranch
+	}
+	var parts []string
+	if reviewLast > 0 {
+		parts = append(parts, fmt.Sprintf("last %d", reviewLast))
+	}
+	if reviewAuthor != "" {
+		parts = append(parts, "by "+reviewAuthor)
+	}
+	if reviewSince != "" {
+		parts = append(parts, "since "+reviewSince)
+	}
+	return "commits " + strings.Join(parts, ", ")
+}
+
+// runReviewFix runs the review-only fix loop over filesChanged: review, have
+// the executor fix the issues, and review again until a review passes. It
+// holds the run lock of wd, so two fix loops don't edit the same checkout.
+func runReviewFix(cfg *config.Config, theme *theme, reviewConfig review.Config, wd string, filesChanged []string) error {
+	if err := checkGuard(cfg); err != nil {
+		return err
+	}
+	lock, err := acquireRunLock(wd, wd, reviewForce)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	promptBuilder, err := prompt.NewBuilder(cfg.Prompts)
+	if err != nil {
+		return fmt.Errorf("failed to create prompt builder: %w", err)
+	}
+	notifier, err := cfg.ToNotifier()
+	if err != nil {
+		return fmt.Errorf("invalid notifications config: %w", err)
+	}
+
+	isTTY := term.IsTerminal(int(os.Stdout.Fd()))
+	termWidth, termHeight := 0, 0
+	if isTTY {
+		termWidth, termHeight, _ = term.GetSize(int(os.Stdout.Fd()))
+	}
+
+	gitConfig := loop.GitWorkflowConfig{
+		AutoCommit:   reviewFixBranch,
+		AutoBranch:   reviewFixBranch,
+		BranchPrefix: cfg.Git.BranchPrefix,
+	}
+	if !reviewWorkingTree && !reviewByCommits() {
+		gitConfig.BaseBranch = reviewBaseBranch
+	}
+
+	result, err := Run(context.Background(), "review", wd, RunConfig{
+		SafetyConfig:      cfg.ToSafetyConfig(),
+		ReviewOnlyFiles:   filesChanged,
+		ReviewConfig:      reviewConfig,
+		PromptBuilder:     promptBuilder,
+		GitWorkflowConfig: gitConfig,
+		ExecutorConfig:    cfg.ToExecutorConfig(),
+		Theme:             theme,
+		Keys:              keyBindingsFromConfig(cfg.UI.Keys),
+		DiffStyle:         cfg.UI.Diff,
+		Prices:            llm.DefaultPrices().WithOverrides(cfg.Pricing),
+		CostWarning:       cfg.UI.CostWarning,
+		IsTTY:             isTTY,
+		KeyInput:          isTTY && term.IsTerminal(int(os.Stdin.Fd())),
+		TermWidth:         termWidth,
+		TermHeight:        termHeight,
+		Notifier:          notifier,
+		Artifacts:         cfg.Artifacts.Enabled,
+		ArtifactsKeep:     cfg.Artifacts.Keep,
+		StatusKey:         cfg.StatusBlock.RunKey(),
+		OwnerRoutes:       cfg.Notifications.Owners,
+		Messages:          cfg.Messages,
+		Pacing:            pacingFromConfig(cfg.Pacing),
+		Fallback:          fallbackFromConfig(cfg),
+		ActiveFiles:       activeFilesFromConfig(cfg.ActiveFiles),
+	})
+	if err != nil {
+		return fmt.Errorf("loop error: %w", err)
+	}
+	if result.ExitReason != safety.ExitReasonComplete {
+		return errReviewFailed
+	}
+	return nil
+}
+
 func formatReviewDuration(d time.Duration) string {
 	d = d.Round(time.Second)
 	m := int64(d / time.Minute)
@@ -108,26 +317,30 @@ func formatReviewDuration(d time.Duration) string {
 	return fmt.Sprintf("%ds", s)
 }
 
-func printReviewSummary(result *review.RunResult) {
-	tty := stdoutIsTTY()
+func printReviewSummary(result *review.RunResult, t *theme) {
+	if !stdoutIsTTY() {
+		plain := *t
+		plain.noColor = true
+		t = &plain
+	}
 	var b strings.Builder
 
 	b.WriteString("\n")
-	b.WriteString(maybeBold(tty, "REVIEW COMPLETE") + "\n\n")
+	b.WriteString(t.bold("REVIEW COMPLETE") + "\n\n")
 
 	if result.Passed {
-		b.WriteString(maybeDim(tty, "Status:     ") + maybeFgBold(tty, colorGreen, "PASSED") + "\n")
+		b.WriteString(t.dim("Status:     ") + t.fgBold(t.success, "PASSED") + "\n")
 	} else {
-		b.WriteString(maybeDim(tty, "Status:     ") + maybeFgBold(tty, colorRed, "FAILED") + "\n")
+		b.WriteString(t.dim("Status:     ") + t.fgBold(t.failure, "FAILED") + "\n")
 	}
 
-	b.WriteString(maybeDim(tty, "Iterations: ") + fmt.Sprintf("%d", result.Iteration) + "\n")
-	b.WriteString(maybeDim(tty, "Issues:     ") + fmt.Sprintf("%d", result.TotalIssues) + "\n")
-	b.WriteString(maybeDim(tty, "Duration:   ") + formatReviewDuration(result.Duration) + "\n")
+	b.WriteString(t.dim("Iterations: ") + fmt.Sprintf("%d", result.Iteration) + "\n")
+	b.WriteString(t.dim("Issues:     ") + fmt.Sprintf("%d", result.TotalIssues) + "\n")
+	b.WriteString(t.dim("Duration:   ") + formatReviewDuration(result.Duration) + "\n")
 
 	if !result.Passed && len(result.Results) > 0 {
-		b.WriteString("\n" + maybeDim(tty, "Remaining issues:") + "\n")
-		b.WriteString(review.FormatIssuesMarkdown(result.Results))
+		b.WriteString("\n" + t.dim("Remaining issues:") + "\n")
+		b.WriteString(t.severityBadges(review.FormatIssuesMarkdown(result.Results)))
 	}
 
 	fmt.Println(b.String())
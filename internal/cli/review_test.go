@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/alexander-akhmetov/programmator/internal/config"
 	"github.com/alexander-akhmetov/programmator/internal/git"
 	"github.com/alexander-akhmetov/programmator/internal/review"
 )
@@ -107,6 +108,103 @@ func TestRunReviewNoChanges(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestRunReviewWorkingTreeNoChanges(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestGitRepo(t, tmpDir)
+
+	oldWorkingDir := reviewWorkDir
+	defer func() {
+		reviewWorkDir = oldWorkingDir
+		reviewWorkingTree = false
+	}()
+
+	reviewWorkDir = tmpDir
+	reviewWorkingTree = true
+
+	err := runReview(reviewCmd, nil)
+	assert.NoError(t, err)
+}
+
+func TestReviewChangedFilesWorkingTree(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestGitRepo(t, tmpDir)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "README.md"), []byte("# Changed\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "new.txt"), []byte("new\n"), 0644))
+
+	defer func() { reviewWorkingTree = false }()
+	reviewWorkingTree = true
+
+	files, _, err := reviewChangedFiles(tmpDir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"README.md", "new.txt"}, files)
+}
+
+func TestReviewChangedFilesByCommits(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestGitRepoWithBranch(t, tmpDir)
+	head, err := git.HeadCommit(tmpDir)
+	require.NoError(t, err)
+
+	defer func() { reviewLast = 0 }()
+	reviewLast = 1
+
+	files, base, err := reviewChangedFiles(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new_file.go"}, files)
+	assert.NotEqual(t, head, base)
+	assert.Equal(t, "commits last 1", reviewScope())
+}
+
+func TestRunReviewFlagConflicts(t *testing.T) {
+	defer func() {
+		reviewWorkingTree = false
+		reviewFix = false
+		reviewFixBranch = false
+		reviewBaseBranch = "main"
+		require.NoError(t, reviewCmd.Flags().Set("base", "main"))
+		reviewCmd.Flags().Lookup("base").Changed = false
+	}()
+
+	reviewFixBranch = true
+	err := runReview(reviewCmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--fix-branch requires --fix")
+
+	reviewFixBranch = false
+	reviewForce = true
+	err = runReview(reviewCmd, nil)
+	reviewForce = false
+	require.ErrorContains(t, err, "--force requires --fix")
+
+	reviewFixBranch = false
+	reviewWorkingTree = true
+	require.NoError(t, reviewCmd.Flags().Set("base", "develop"))
+	err = runReview(reviewCmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be used together")
+
+	reviewWorkingTree = false
+	reviewLast = 2
+	err = runReview(reviewCmd, nil)
+	reviewLast = 0
+	require.ErrorContains(t, err, "cannot be used with --base or --working-tree")
+}
+
+func TestRunReviewFix_RunLock(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	wd := t.TempDir()
+	cfg, err := config.LoadWithDirs(t.TempDir(), "")
+	require.NoError(t, err)
+	cfg.Guard.Mode = "off"
+
+	lock, err := acquireRunLock(wd, wd, false)
+	require.NoError(t, err)
+	defer lock.release()
+
+	err = runReviewFix(cfg, nil, review.Config{}, wd, []string{"a.go"})
+	require.ErrorContains(t, err, "is already being run by pid")
+}
+
 func TestFormatReviewDuration(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -211,7 +309,7 @@ func TestPrintReviewSummary(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			output := captureStdout(t, func() {
-				printReviewSummary(tt.result)
+				printReviewSummary(tt.result, defaultTheme())
 			})
 
 			for _, s := range tt.contains {
@@ -231,6 +329,10 @@ func TestReviewCmdAllFlagDefaults(t *testing.T) {
 	}{
 		{"base branch", "base", "main"},
 		{"working dir", "dir", ""},
+		{"working tree", "working-tree", "false"},
+		{"fix", "fix", "false"},
+		{"fix branch", "fix-branch", "false"},
+		{"force", "force", "false"},
 	}
 
 	for _, tc := range tests {
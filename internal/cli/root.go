@@ -2,7 +2,9 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -27,17 +29,40 @@ var rootCmd = &cobra.Command{
 	Long: `Programmator reads a ticket, identifies the current phase, invokes the configured
 coding agent with a structured prompt, parses the response, and loops until all
 phases are complete or safety limits are reached.`,
-	SilenceUsage: true,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
-// Execute runs the root command.
+// Execute runs the root command and prints the error it fails with. The
+// process should exit with ExitCode of the returned error.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	var exit *ExitError
+	if err != nil && (!errors.As(err, &exit) || exit.Err != nil) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	return err
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also set by NO_COLOR)")
+
 	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(rerunCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(reviewCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(askCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(plansCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(promptsCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(guardCmd)
 }
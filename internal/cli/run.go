@@ -22,6 +22,8 @@ var (
 	runNonInteractive bool
 	runMaxTurns       int
 	runExecutor       string
+	runQuery          string
+	runBudget         int
 )
 
 var runCmd = &cobra.Command{
@@ -31,11 +33,20 @@ var runCmd = &cobra.Command{
 
 The prompt can be provided as an argument or piped via stdin.
 
+With --query, run the tickets matching a query instead, as "programmator
+start" would, one after another with the highest priority (lowest number)
+first. A query is space-separated field:value terms that must all match,
+each with comma-separated alternatives: status, label (or tag), type, and
+priority; workspace:<name> searches a ticket workspace instead of
+TICKETS_DIR. --budget caps the iterations of the whole batch. A batch
+summary is printed at the end.
+
 Examples:
   programmator run "explain this codebase"
   programmator run "fix the bug in main.go"
   echo "add tests for the parser" | programmator run
-  programmator run --max-turns 5 "refactor the auth module"`,
+  programmator run --max-turns 5 "refactor the auth module"
+  programmator run --query "status:open label:programmator" --budget 100`,
 	RunE: runRun,
 }
 
@@ -44,6 +55,8 @@ func init() {
 	runCmd.Flags().BoolVar(&runNonInteractive, "print", false, "Non-interactive mode: print output directly")
 	runCmd.Flags().IntVar(&runMaxTurns, "max-turns", 0, "Maximum agentic turns (0 = unlimited)")
 	runCmd.Flags().StringVar(&runExecutor, "executor", "", "Executor to use: claude, pi, opencode, codex (default: claude)")
+	runCmd.Flags().StringVar(&runQuery, "query", "", `Run the tickets matching a query, e.g. "status:open label:programmator"`)
+	runCmd.Flags().IntVar(&runBudget, "budget", 0, "With --query, the most iterations of all tickets together (0 = each ticket's max_iterations only)")
 }
 
 // buildRunPrompt assembles the prompt from CLI args or stdin.
@@ -69,6 +82,20 @@ func buildRunPrompt(args []string, stdin io.Reader) (string, error) {
 }
 
 func runRun(_ *cobra.Command, args []string) error {
+	if runQuery != "" {
+		if len(args) > 0 || runNonInteractive || runMaxTurns != 0 || runExecutor != "" {
+			return fmt.Errorf("--query runs tickets and takes no prompt, --print, --max-turns, or --executor")
+		}
+		wd, err := resolveWorkingDir(runWorkingDir)
+		if err != nil {
+			return err
+		}
+		return runQueryBatch(wd, runQuery, runBudget)
+	}
+	if runBudget != 0 {
+		return fmt.Errorf("--budget requires --query")
+	}
+
 	prompt, err := buildRunPrompt(args, os.Stdin)
 	if err != nil {
 		return err
@@ -79,7 +106,7 @@ func runRun(_ *cobra.Command, args []string) error {
 		return err
 	}
 
-	cfg, err := config.Load()
+	cfg, err := config.LoadForDir(wd)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
@@ -6,30 +6,77 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/alexander-akhmetov/programmator/internal/domain"
 	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
 	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/messages"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/notify"
 	"github.com/alexander-akhmetov/programmator/internal/prompt"
 	"github.com/alexander-akhmetov/programmator/internal/review"
 	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/ticket"
 )
 
 // RunConfig holds all configuration needed to run the loop.
 type RunConfig struct {
-	SafetyConfig      safety.Config
-	ReviewConfig      review.Config
-	PromptBuilder     *prompt.Builder
-	TicketCommand     string
-	GitWorkflowConfig loop.GitWorkflowConfig
-	ExecutorConfig    executor.Config
-	Out               io.Writer // output writer (default: os.Stdout)
-	IsTTY             bool
-	TermWidth         int
-	TermHeight        int
+	SafetyConfig       safety.Config
+	LimitScope         string   // safety.LimitScopeWorkItem continues the counts of earlier runs
+	ReviewOnlyFiles    []string // run a review-only fix loop over these files instead of the work item
+	ReviewConfig       review.Config
+	PromptBuilder      *prompt.Builder
+	TicketCommand      string
+	TicketWorkspaces   map[string]ticket.Workspace
+	GitWorkflowConfig  loop.GitWorkflowConfig
+	ExecutorConfig     executor.Config
+	Secrets            []string            // values masked in the output and logs, such as secret executor_env values
+	ParallelPhases     int                 // max independent phases run concurrently (<2 = sequential)
+	ReuseSessions      bool                // resume executor sessions within a phase
+	SupervisorMax      int                 // max supervisor interventions per run (0 = disabled)
+	SplitAfter         int                 // split a phase still open after this many iterations (0 = never)
+	SplitMaxLength     int                 // split phases with longer descriptions, in characters (0 = never)
+	DocsPhase          bool                // update documentation after all phases complete
+	Coverage           loop.CoverageConfig // gate on the test coverage of changed Go packages
+	AutoPhase          bool                // plan phases for phaseless work items
+	MinimalPermissions bool                // infer per-phase tool access from phase names
+	VerifyFilesChanged bool                // check reported changed files against git status
+	Fresh              bool                // reopen completed phases and repeat notes an earlier run wrote
+	ValidationCommands []string            // fallback validation commands for work items without their own
+	DiffScanner        *safety.Scanner     // checks each invocation's diff; nil disables
+	Labels             []string            // run labels for history, status, and the summary
+	Notes              notes.Config        // which notes reach the work item and how they are written
+	Notifier           *notify.Notifier    // sends a notification when the run ends (nil = none)
+	OwnerRoutes        map[string][]string // CODEOWNERS owner to email addresses for unresolved review issues
+	Messages           *messages.Catalog   // progress and notification messages (nil = English)
+	Artifacts          bool                // collect the run's files under .programmator/runs in the working directory
+	RecordEvents       string              // file to record the run's events and footer updates to, for "tui --replay" ("" = none)
+	ArtifactsKeep      int                 // newest run artifact directories kept (0 = all)
+	StatusKey          string              // key of the executor's status block ("" = PROGRAMMATOR_STATUS)
+	Pacing             loop.PacingConfig   // cool-down between iterations and work hours
+	Fallback           loop.FallbackConfig // executors to switch to when invocations keep failing
+	Theme              *theme              // output colors (default: defaultTheme)
+	Keys               keyBindings         // remapped keys; zero value uses the defaults
+	DiffStyle          string              // how edits are shown, see Writer.SetDiffStyle
+	Prices             llm.PriceTable      // per-model prices for the footer cost estimate (nil: llm.DefaultPrices)
+	CostWarning        float64             // USD run total past which the footer cost flashes (0 = never)
+	Out                io.Writer           // output writer (default: os.Stdout)
+	IsTTY              bool
+	KeyInput           bool // read key bindings (skip phase) and answers to BLOCKED questions from stdin; requires IsTTY
+	TermWidth          int
+	TermHeight         int
+
+	// ActiveFiles publishes the files the run edits and checks them for
+	// outside edits between invocations.
+	ActiveFiles loop.ActiveFilesConfig
 }
 
 // Run creates a loop, wires callbacks to a Writer, and runs synchronously.
@@ -43,29 +90,118 @@ func Run(ctx context.Context, sourceID, workingDir string, cfg RunConfig) (*loop
 	w := NewWriter(out, cfg.IsTTY, cfg.TermWidth, cfg.TermHeight)
 	w.SetExecutorName(cfg.ExecutorConfig.Name)
 	w.SetClaudeConfigDir(cfg.ExecutorConfig.Claude.ClaudeConfigDir)
+	if cfg.Theme != nil {
+		w.SetTheme(cfg.Theme)
+	}
+	if cfg.Keys != (keyBindings{}) {
+		w.SetKeyBindings(cfg.Keys)
+	}
+	w.SetDiffStyle(cfg.DiffStyle)
+	prices := cfg.Prices
+	if prices == nil {
+		prices = llm.DefaultPrices()
+	}
+	w.SetCostDisplay(prices, cfg.CostWarning)
+	var recording *eventRecording
+	if cfg.RecordEvents != "" {
+		rec, err := openEventRecording(cfg.RecordEvents, cfg.ExecutorConfig.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: event recording unavailable: %v\n", err)
+		} else {
+			recording = rec
+			defer recording.close()
+		}
+	}
 	var footerMu sync.RWMutex
 	var latestState *safety.State
 	var latestItem *domain.WorkItem
 
+	status, err := startStatusServer(sourceID, workingDir, cfg.SafetyConfig.MaxIterations, cfg.Labels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: status socket unavailable: %v\n", err)
+	} else {
+		defer status.Close()
+	}
+
 	l := loop.New(
 		cfg.SafetyConfig,
 		workingDir,
 		func(state *safety.State, workItem *domain.WorkItem, _ []string) {
 			stateSnap := snapshotFooterState(state)
 			itemSnap := snapshotFooterWorkItem(workItem)
+			if status != nil {
+				status.updateState(state, workItem)
+			}
 
 			footerMu.Lock()
 			latestState = stateSnap
 			latestItem = itemSnap
 			footerMu.Unlock()
 
+			if recording != nil {
+				recording.footer(stateSnap, itemSnap, cfg.SafetyConfig)
+			}
 			w.UpdateFooter(stateSnap, itemSnap, cfg.SafetyConfig)
 		},
 		true,
 	)
 
+	plog, err := openProgressLog(sourceID, workingDir, cfg.Labels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: progress log unavailable: %v\n", err)
+	}
+	tlog, err := openTransitionLog(sourceID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: decision log unavailable: %v\n", err)
+	} else {
+		defer tlog.close()
+		l.SetTransitionRecorder(tlog.record)
+	}
+	xlog, err := openTranscriptLog(sourceID, workingDir, cfg.Labels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: transcript log unavailable: %v\n", err)
+	}
+	var artifacts *runArtifacts
+	if cfg.Artifacts {
+		artifacts, err = openRunArtifacts(sourceID, workingDir, cfg.Labels, cfg.ArtifactsKeep)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: run artifacts unavailable: %v\n", err)
+		}
+	}
+	var lastReview *review.RunResult
+	l.SetReviewRecorder(func(res *review.RunResult) {
+		lastReview = res
+		w.SetReviewIssues(res.Results)
+		if artifacts != nil {
+			artifacts.review(res)
+		}
+	})
+	l.SetSecrets(cfg.Secrets)
+	if xlog != nil || artifacts != nil {
+		l.SetIterationRecorder(func(rec loop.IterationRecord) {
+			if xlog != nil {
+				xlog.record(rec)
+			}
+			if artifacts != nil {
+				artifacts.iteration(rec)
+			}
+		})
+	}
+
 	l.SetEventCallback(func(ev event.Event) {
 		w.WriteEvent(ev)
+		if status != nil {
+			status.recordEvent(ev)
+		}
+		if plog != nil {
+			plog.event(ev)
+		}
+		if artifacts != nil {
+			artifacts.event(ev)
+		}
+		if recording != nil {
+			recording.event(ev)
+		}
 	})
 	l.SetProcessStatsCallback(func(pid int, memoryKB int64) {
 		w.SetProcessStats(pid, memoryKB)
@@ -84,11 +220,36 @@ func Run(ctx context.Context, sourceID, workingDir string, cfg RunConfig) (*loop
 	if cfg.PromptBuilder != nil {
 		l.SetPromptBuilder(cfg.PromptBuilder)
 	}
+	l.SetStatusKey(cfg.StatusKey)
+	l.SetMessages(cfg.Messages)
+	l.SetPacing(cfg.Pacing)
+	l.SetActiveFiles(cfg.ActiveFiles)
 	if cfg.TicketCommand != "" {
 		l.SetTicketCommand(cfg.TicketCommand)
 	}
+	l.SetTicketWorkspaces(cfg.TicketWorkspaces)
 	l.SetGitWorkflowConfig(cfg.GitWorkflowConfig)
+	l.SetPrices(prices)
 	l.SetExecutorConfig(cfg.ExecutorConfig)
+	l.SetFallback(cfg.Fallback)
+	l.SetParallelPhases(cfg.ParallelPhases)
+	l.SetSessionReuse(cfg.ReuseSessions)
+	l.SetSupervisor(cfg.SupervisorMax)
+	l.SetPhaseSplitting(cfg.SplitAfter, cfg.SplitMaxLength)
+	l.SetDocsPhase(cfg.DocsPhase)
+	l.SetCoverageGate(cfg.Coverage)
+	l.SetAutoPhasing(cfg.AutoPhase)
+	l.SetMinimalPermissions(cfg.MinimalPermissions)
+	l.SetVerifyFilesChanged(cfg.VerifyFilesChanged)
+	l.SetFresh(cfg.Fresh)
+	l.SetValidationCommands(cfg.ValidationCommands)
+	l.SetDiffScanner(cfg.DiffScanner)
+	l.SetLabels(cfg.Labels)
+	l.SetNotesConfig(cfg.Notes)
+	var limits *workItemLimits
+	if cfg.LimitScope == safety.LimitScopeWorkItem {
+		limits = attachWorkItemLimits(l, sourceID)
+	}
 
 	// Signal handling — stop loop on SIGINT/SIGTERM.
 	ctx, cancel := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
@@ -101,10 +262,42 @@ func Run(ctx context.Context, sourceID, workingDir string, cfg RunConfig) (*loop
 		l.Stop()
 	}()
 
-	result, err := l.Run(sourceID)
+	if cfg.KeyInput && cfg.IsTTY {
+		w.SetKeyHandlers(l.SkipPhase, cancel)
+		l.SetQuestionHandler(func(blockedError string, questions []string) (string, error) {
+			return w.AskQuestions(ctx, blockedError, questions)
+		})
+		l.SetPhasePicker(func(reported string, candidates []string) (string, error) {
+			return w.PickPhase(ctx, reported, candidates)
+		})
+		l.SetSignOffApprover(func(phase string, files []string) (bool, error) {
+			return w.ApproveSignOff(ctx, phase, files)
+		})
+	}
+
+	var result *loop.Result
+	if cfg.ReviewOnlyFiles != nil {
+		result, err = l.RunReviewOnly(cfg.ReviewOnlyFiles)
+	} else {
+		result, err = l.Run(sourceID)
+	}
+	if plog != nil {
+		plog.finish(result, err)
+	}
+	if xlog != nil {
+		xlog.finish(result, err)
+	}
+	if artifacts != nil {
+		artifacts.finish(result, err)
+	}
 
 	// Always clean up the footer before returning.
 	w.ClearFooter()
+	if limits != nil {
+		limits.finish(result)
+	}
+	notifyRunEnd(cfg.Notifier, cfg.Messages, sourceID, workingDir, result, err)
+	notifyOwners(cfg.Notifier, cfg.Messages, cfg.OwnerRoutes, sourceID, lastReview)
 
 	if err != nil {
 		return result, err
@@ -112,6 +305,9 @@ func Run(ctx context.Context, sourceID, workingDir string, cfg RunConfig) (*loop
 
 	// Print final summary.
 	printRunSummary(w, result)
+	if artifacts != nil {
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Artifacts:"), w.style(w.theme.text, artifacts.dir))
+	}
 
 	fmt.Fprint(w.out, "\n\n")
 
@@ -125,26 +321,107 @@ func printRunSummary(w *Writer, result *loop.Result) {
 	}
 
 	fmt.Fprintln(w.out)
-	fmt.Fprintln(w.out, w.style(colorDim, "────────────────────────────"))
+	fmt.Fprintln(w.out, w.style(w.theme.muted, "────────────────────────────"))
 
-	status := w.styleBold(colorGreen, string(result.ExitReason))
+	status := w.styleBold(w.theme.success, string(result.ExitReason))
 	if result.ExitReason == safety.ExitReasonBlocked ||
 		result.ExitReason == safety.ExitReasonError ||
-		result.ExitReason == safety.ExitReasonReviewFailed {
-		status = w.styleBold(colorRed, string(result.ExitReason))
+		result.ExitReason == safety.ExitReasonReviewFailed ||
+		result.ExitReason == safety.ExitReasonReviewStuck {
+		status = w.styleBold(w.theme.failure, string(result.ExitReason))
 	}
 
-	fmt.Fprintf(w.out, "%s %s", w.style(colorDim, "Exit:"), status)
+	fmt.Fprintf(w.out, "%s %s", w.style(w.theme.muted, "Exit:"), status)
 	if result.ExitMessage != "" {
-		fmt.Fprintf(w.out, " %s", w.style(colorDim, "("+result.ExitMessage+")"))
+		fmt.Fprintf(w.out, " %s", w.style(w.theme.muted, "("+result.ExitMessage+")"))
 	}
 	fmt.Fprintln(w.out)
 
 	fmt.Fprintf(w.out, "%s %s  %s %s  %s %s\n",
-		w.style(colorDim, "Iterations:"), w.style(colorWhite, fmt.Sprintf("%d", result.Iterations)),
-		w.style(colorDim, "Files:"), w.style(colorWhite, fmt.Sprintf("%d", len(result.TotalFilesChanged))),
-		w.style(colorDim, "Duration:"), w.style(colorWhite, formatElapsed(result.Duration)),
+		w.style(w.theme.muted, "Iterations:"), w.style(w.theme.text, fmt.Sprintf("%d", result.Iterations)),
+		w.style(w.theme.muted, "Files:"), w.style(w.theme.text, fmt.Sprintf("%d", len(result.TotalFilesChanged))),
+		w.style(w.theme.muted, "Duration:"), w.style(w.theme.text, formatElapsed(result.Duration)),
 	)
+	if inv := result.Invocations; inv.Invocations > 0 {
+		text := fmt.Sprintf("%d (mean %s, max %s", inv.Invocations, formatElapsed(inv.MeanLatency()), formatElapsed(inv.MaxLatency))
+		if inv.Retries > 0 {
+			text += fmt.Sprintf(", %d retries", inv.Retries)
+		}
+		text += ")"
+		fmt.Fprintf(w.out, "%s %s", w.style(w.theme.muted, "Invocations:"), w.style(w.theme.text, text))
+		if failures := inv.FormatFailures(); failures != "" {
+			fmt.Fprintf(w.out, "  %s %s", w.style(w.theme.muted, "Failures:"), w.style(w.theme.failure, failures))
+		}
+		fmt.Fprintln(w.out)
+	}
+	if n := len(result.ContextOverflows); n > 0 {
+		iterations := make([]string, 0, n)
+		for _, o := range result.ContextOverflows {
+			iterations = append(iterations, strconv.Itoa(o.Iteration))
+		}
+		text := fmt.Sprintf("%d (iterations %s)", n, strings.Join(iterations, ", "))
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Context overflows:"), w.style(w.theme.failure, text))
+	}
+	if len(result.ExecutorSwitches) > 0 {
+		switches := make([]string, 0, len(result.ExecutorSwitches))
+		for _, sw := range result.ExecutorSwitches {
+			switches = append(switches, fmt.Sprintf("%s → %s at iteration %d", sw.From, sw.To, sw.Iteration))
+		}
+		text := fmt.Sprintf("%s (%d iterations on a fallback)", strings.Join(switches, ", "), len(result.FallbackIterations))
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Executor fallback:"), w.style(w.theme.failure, text))
+	}
+	if len(result.ResidualIssues) > 0 {
+		n := 0
+		for _, r := range result.ResidualIssues {
+			n += len(r.Issues)
+		}
+		text := fmt.Sprintf("%d (score %d)", n, review.Score(result.ResidualIssues))
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Residual review issues:"), w.style(w.theme.text, text))
+	}
+	if len(result.ReviewLifecycle) > 0 {
+		summary := review.SummarizeLifecycles(result.ReviewLifecycle)
+		color := w.theme.text
+		if summary.Reappeared > 0 {
+			color = w.theme.failure
+		}
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Review issues:"), w.style(color, summary.String()))
+	}
+	if n := len(result.PhantomChanges); n > 0 {
+		iterations := make([]string, 0, n)
+		for _, it := range result.PhantomChanges {
+			iterations = append(iterations, strconv.Itoa(it))
+		}
+		text := fmt.Sprintf("%d (iterations %s)", n, strings.Join(iterations, ", "))
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Phantom changes:"), w.style(w.theme.failure, text))
+	}
+	if len(result.ConcurrentEdits) > 0 {
+		var files, iterations []string
+		var paused time.Duration
+		for _, e := range result.ConcurrentEdits {
+			for _, f := range e.Files {
+				if !slices.Contains(files, f) {
+					files = append(files, f)
+				}
+			}
+			iterations = append(iterations, strconv.Itoa(e.Iteration))
+			paused += e.Paused
+		}
+		text := fmt.Sprintf("%s (before iterations %s", strings.Join(files, ", "), strings.Join(iterations, ", "))
+		if paused > 0 {
+			text += "; paused " + formatElapsed(paused)
+		}
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Edited outside the run:"), w.style(w.theme.failure, text+")"))
+	}
+	if len(result.SignOffFiles) > 0 {
+		text := strings.Join(result.SignOffFiles, ", ") + " (staged, not committed)"
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Awaiting sign-off:"), w.style(w.theme.failure, text))
+	}
+	if len(result.Labels) > 0 {
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Labels:"), w.style(w.theme.text, strings.Join(result.Labels, ", ")))
+	}
+	if result.FollowUp != "" {
+		fmt.Fprintf(w.out, "%s %s\n", w.style(w.theme.muted, "Deferred findings filed as:"), w.style(w.theme.text, result.FollowUp))
+	}
 }
 
 // snapshotFooterState captures the state fields used in the footer to avoid
@@ -159,11 +436,27 @@ func snapshotFooterState(state *safety.State) *safety.State {
 		setCopy[k] = struct{}{}
 	}
 
+	tokens := make(map[string]*safety.ModelTokens, len(state.TokensByModel))
+	for model, t := range state.TokensByModel {
+		tokens[model] = &safety.ModelTokens{InputTokens: t.InputTokens, OutputTokens: t.OutputTokens}
+	}
+	var current *safety.ModelTokens
+	if state.CurrentIterTokens != nil {
+		c := *state.CurrentIterTokens
+		current = &c
+	}
+
 	return &safety.State{
 		Iteration:            state.Iteration,
 		ConsecutiveNoChanges: state.ConsecutiveNoChanges,
 		TotalFilesChanged:    setCopy,
 		StartTime:            state.StartTime,
+		Model:                state.Model,
+		TokensByModel:        tokens,
+		CurrentIterTokens:    current,
+		InReviewPhase:        state.InReviewPhase,
+		Review:               state.Review, // a fresh snapshot on every callback, never modified
+		ActiveFiles:          state.ActiveFiles,
 	}
 }
 
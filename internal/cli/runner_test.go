@@ -8,7 +8,9 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/review"
 	"github.com/alexander-akhmetov/programmator/internal/safety"
 )
 
@@ -37,6 +39,67 @@ func TestPrintRunSummary(t *testing.T) {
 			},
 			contains: []string{"blocked", "cannot proceed"},
 		},
+		{
+			name: "invocations",
+			result: &loop.Result{
+				ExitReason: safety.ExitReasonComplete,
+				Invocations: llm.InvocationStats{
+					Invocations:  3,
+					Retries:      1,
+					TotalLatency: 3 * time.Minute,
+					MaxLatency:   2 * time.Minute,
+					Failures:     map[llm.FailureClass]int{llm.FailureExit: 1},
+				},
+			},
+			contains: []string{"Invocations: 3 (mean 1m 0s, max 2m 0s, 1 retries)", "Failures: exit 1"},
+		},
+		{
+			name: "deferred findings",
+			result: &loop.Result{
+				ExitReason: safety.ExitReasonComplete,
+				FollowUp:   "plans/feature-followup.md",
+			},
+			contains: []string{"Deferred findings filed as: plans/feature-followup.md"},
+		},
+		{
+			name: "context overflows",
+			result: &loop.Result{
+				ExitReason:       safety.ExitReasonComplete,
+				ContextOverflows: []loop.ContextOverflow{{Iteration: 2}, {Iteration: 5}},
+			},
+			contains: []string{"Context overflows: 2 (iterations 2, 5)"},
+		},
+		{
+			name: "executor fallback",
+			result: &loop.Result{
+				ExitReason:         safety.ExitReasonComplete,
+				ExecutorSwitches:   []loop.ExecutorSwitch{{Iteration: 3, From: "claude", To: "claude (sonnet)"}},
+				FallbackIterations: []int{3, 4},
+			},
+			contains: []string{"Executor fallback: claude → claude (sonnet) at iteration 3 (2 iterations on a fallback)"},
+		},
+		{
+			name: "concurrent edits",
+			result: &loop.Result{
+				ExitReason: safety.ExitReasonComplete,
+				ConcurrentEdits: []loop.ConcurrentEdit{
+					{Iteration: 3, Files: []string{"a.go", "b.go"}},
+					{Iteration: 5, Files: []string{"a.go"}, Paused: 45 * time.Second},
+				},
+			},
+			contains: []string{"Edited outside the run: a.go, b.go (before iterations 3, 5; paused 45s)"},
+		},
+		{
+			name: "review lifecycle",
+			result: &loop.Result{
+				ExitReason: safety.ExitReasonComplete,
+				ReviewLifecycle: []review.IssueLifecycle{
+					{Agent: "quality", Transitions: []review.IssueTransition{{Iteration: 1, State: review.IssueIntroduced}, {Iteration: 2, State: review.IssueFixed}}},
+					{Agent: "quality", Transitions: []review.IssueTransition{{Iteration: 2, State: review.IssueIntroduced}}},
+				},
+			},
+			contains: []string{"Review issues: 2 found, 1 fixed, 1 open"},
+		},
 		{
 			name:   "nil result",
 			result: nil,
@@ -80,7 +143,10 @@ func TestSnapshotFooterState(t *testing.T) {
 		TotalFilesChanged: map[string]struct{}{
 			"a.go": {},
 		},
-		StartTime: time.Now().Add(-10 * time.Second),
+		StartTime:         time.Now().Add(-10 * time.Second),
+		Model:             "claude-sonnet-4-5",
+		TokensByModel:     map[string]*safety.ModelTokens{"claude-sonnet-4-5": {InputTokens: 100, OutputTokens: 10}},
+		CurrentIterTokens: &safety.ModelTokens{InputTokens: 5},
 	}
 
 	snap := snapshotFooterState(original)
@@ -92,6 +158,12 @@ func TestSnapshotFooterState(t *testing.T) {
 
 	original.TotalFilesChanged["b.go"] = struct{}{}
 	assert.Len(t, snap.TotalFilesChanged, 1, "snapshot map must be independent from original")
+
+	original.TokensByModel["claude-sonnet-4-5"].InputTokens = 999
+	original.CurrentIterTokens.InputTokens = 999
+	assert.Equal(t, "claude-sonnet-4-5", snap.Model)
+	assert.Equal(t, 100, snap.TokensByModel["claude-sonnet-4-5"].InputTokens, "snapshot tokens must be independent from original")
+	assert.Equal(t, 5, snap.CurrentIterTokens.InputTokens)
 }
 
 func TestSnapshotFooterWorkItem(t *testing.T) {
@@ -4,13 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
 	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
 	"github.com/alexander-akhmetov/programmator/internal/loop"
 	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/review"
 )
 
 var (
@@ -23,6 +28,26 @@ var (
 	startAutoCommit         bool
 	startMoveCompletedPlans bool
 	startAutoBranch         bool
+	startAutoPush           bool
+	startDocsPhase          bool
+	startAutoPhase          bool
+
+	startParallelPhases int
+
+	startLabels []string
+	startEnv    []string
+
+	startPreflight bool
+	startForce     bool
+	startRerun     bool
+	startFresh     bool
+
+	// Review flags, for this run only
+	startReviewSkip       bool
+	startReviewSeverities []string
+	startReviewAgents     []string
+
+	startRecordEvents string
 )
 
 var startCmd = &cobra.Command{
@@ -43,37 +68,101 @@ In non-TTY mode (pipes, CI), output is plain text without ANSI escapes.`,
 }
 
 func init() {
-	startCmd.Flags().StringVarP(&startWorkingDir, "dir", "d", "", "Working directory (default: current directory)")
-	startCmd.Flags().IntVarP(&startMaxIterations, "max-iterations", "n", 0, "Maximum iterations")
-	startCmd.Flags().IntVar(&startStagnationLimit, "stagnation-limit", 0, "Stagnation limit")
-	startCmd.Flags().IntVar(&startTimeout, "timeout", 0, "Timeout per Claude invocation in seconds")
-
-	startCmd.Flags().BoolVar(&startAutoCommit, "auto-commit", false, "Auto-commit changes after each phase completion")
-	startCmd.Flags().BoolVar(&startMoveCompletedPlans, "move-completed", false, "Move completed plan files to plans/completed/")
-	startCmd.Flags().BoolVar(&startAutoBranch, "branch", false, "Create a new branch (programmator/<source>) before starting")
+	addStartFlags(startCmd)
+}
+
+// addStartFlags registers the flags of start on cmd, for the commands that
+// start a run.
+func addStartFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&startWorkingDir, "dir", "d", "", "Working directory (default: current directory)")
+	cmd.Flags().IntVarP(&startMaxIterations, "max-iterations", "n", 0, "Maximum iterations")
+	cmd.Flags().IntVar(&startStagnationLimit, "stagnation-limit", 0, "Stagnation limit")
+	cmd.Flags().IntVar(&startTimeout, "timeout", 0, "Timeout per Claude invocation in seconds")
+
+	cmd.Flags().BoolVar(&startAutoCommit, "auto-commit", false, "Auto-commit changes after each phase completion")
+	cmd.Flags().BoolVar(&startMoveCompletedPlans, "move-completed", false, "Move completed plan files to plans/completed/")
+	cmd.Flags().BoolVar(&startAutoBranch, "branch", false, "Create a new branch (programmator/<source>) before starting")
+	cmd.Flags().BoolVar(&startAutoPush, "auto-push", false, "Push auto-commits to the configured remote")
+	cmd.Flags().BoolVar(&startDocsPhase, "docs", false, "Update documentation for changed files after all tasks complete")
+	cmd.Flags().BoolVar(&startAutoPhase, "auto-phase", false, "Plan phases for a plan or ticket without any before starting")
+	cmd.Flags().IntVar(&startParallelPhases, "parallel", 0, "Run up to N independent plan tasks concurrently in git worktrees")
+	cmd.Flags().StringSliceVar(&startLabels, "label", nil, "Tag the run with a label, shown in history and status (repeatable)")
+	cmd.Flags().StringArrayVar(&startEnv, "env", nil, "Set KEY=VALUE in the executor's environment for this run, on top of executor_env (repeatable)")
+	cmd.Flags().BoolVar(&startPreflight, "preflight", false, "Check the repository and executor before starting (see preflight in the config)")
+	cmd.Flags().BoolVar(&startForce, "force", false, "Run even if another process holds the work item's run lock")
+	cmd.Flags().BoolVar(&startRerun, "rerun", false, "Run even if an earlier run completed the work item, without asking about an unfinished one")
+	cmd.Flags().BoolVar(&startFresh, "fresh", false, "Start over: reopen completed phases and write notes an earlier run already wrote")
+	cmd.Flags().BoolVar(&startReviewSkip, "review-skip", false, "Complete without code review once all phases are done")
+	cmd.Flags().StringSliceVar(&startReviewSeverities, "review-only-severity", nil, "Count only review issues of these severities: critical, high, medium, low, info")
+	cmd.Flags().StringSliceVar(&startReviewAgents, "review-agents", nil, "Run only these review agents")
+	cmd.Flags().StringVar(&startRecordEvents, "record-events", "", "Record the run's output to a file for programmator tui --replay")
 }
 
 func runStart(_ *cobra.Command, args []string) error {
-	sourceID := args[0]
+	return startRun(args[0], "")
+}
+
+// startOptions are the settings of a run that its caller decides, rather
+// than the start flags.
+type startOptions struct {
+	workingDir    string         // "" = current directory
+	maxIterations int            // 0 = max_iterations from the config
+	fromPhase     string         // reopen this phase and the phases depending on it first
+	priorRun      priorRunPolicy // what to do after an unfinished last run
+}
+
+// startRun runs the loop on sourceID with the start flags. A fromPhase
+// reopens that phase and the phases depending on it first.
+func startRun(sourceID, fromPhase string) error {
+	_, err := startLoop(sourceID, startOptions{
+		workingDir:    startWorkingDir,
+		maxIterations: startMaxIterations,
+		fromPhase:     fromPhase,
+	})
+	return err
+}
+
+// startLoop runs the loop on sourceID with opts and the other start flags,
+// and returns the run's result too; it is nil when the command failed
+// before the loop started.
+func startLoop(sourceID string, opts startOptions) (*loop.Result, error) {
+	wd, err := resolveWorkingDir(opts.workingDir)
+	if err != nil {
+		return nil, err
+	}
 
-	cfg, err := config.Load()
+	cfg, err := config.LoadForDir(wd)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid config: %w", err)
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	cfg.ApplyCLIFlags(startMaxIterations, startStagnationLimit, startTimeout)
+	if err := checkGuard(cfg); err != nil {
+		return nil, err
+	}
 
-	wd, err := resolveWorkingDir(startWorkingDir)
+	if startFresh && opts.fromPhase != "" {
+		return nil, fmt.Errorf("--fresh cannot be combined with --from-phase")
+	}
+	cfg.ApplyCLIFlags(opts.maxIterations, startStagnationLimit, startTimeout)
+	if err := cfg.ApplyEnvFlags(startEnv); err != nil {
+		return nil, err
+	}
+	labels, err := normalizeLabels(startLabels)
+	if err != nil {
+		return nil, err
+	}
+	theme, err := themeFromConfig(cfg)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
+	llm.SetMaxConcurrentInvocations(cfg.MaxConcurrentInvocations)
 
 	promptBuilder, err := prompt.NewBuilder(cfg.Prompts)
 	if err != nil {
-		return fmt.Errorf("failed to create prompt builder: %w", err)
+		return nil, fmt.Errorf("failed to create prompt builder: %w", err)
 	}
 
 	isTTY := term.IsTerminal(int(os.Stdout.Fd()))
@@ -83,32 +172,174 @@ func runStart(_ *cobra.Command, args []string) error {
 	}
 
 	runCfg := RunConfig{
-		SafetyConfig:  cfg.ToSafetyConfig(),
-		PromptBuilder: promptBuilder,
-		TicketCommand: cfg.TicketCommand,
+		SafetyConfig:     cfg.ToSafetyConfig(),
+		PromptBuilder:    promptBuilder,
+		TicketCommand:    cfg.TicketCommand,
+		TicketWorkspaces: cfg.TicketWorkspaces,
 		GitWorkflowConfig: loop.GitWorkflowConfig{
 			AutoCommit:         startAutoCommit || cfg.Git.AutoCommit,
 			MoveCompletedPlans: startMoveCompletedPlans || cfg.Git.MoveCompletedPlans,
 			CompletedPlansDir:  cfg.Git.CompletedPlansDir,
 			BranchPrefix:       cfg.Git.BranchPrefix,
 			AutoBranch:         startAutoBranch,
+			AutoPush:           startAutoPush || cfg.Git.AutoPush,
+			PushRemote:         cfg.Git.PushRemote,
+			PushEvery:          cfg.Git.PushEvery,
+			BaseBranch:         cfg.Git.BaseBranch,
+			BaseSync:           cfg.Git.BaseSync,
+			Snapshots:          cfg.Git.Snapshots.Enabled,
+			SquashSnapshots:    cfg.Git.Snapshots.Squash,
+			PRComment:          cfg.Git.PRComment,
+			PRStatus:           cfg.Git.PRStatus,
+			Squash:             cfg.Git.Squash,
+			SignOffPaths:       cfg.Git.SignOffPaths,
 		},
-		ExecutorConfig: cfg.ToExecutorConfig(),
-		IsTTY:          isTTY,
-		TermWidth:      termWidth,
-		TermHeight:     termHeight,
+		LimitScope:         cfg.LimitScope,
+		ExecutorConfig:     cfg.ToExecutorConfig(),
+		Secrets:            cfg.ExecutorSecrets(),
+		ParallelPhases:     cfg.ParallelPhases,
+		ReuseSessions:      cfg.ReuseSessions,
+		DocsPhase:          startDocsPhase || cfg.DocsPhase,
+		Coverage:           loop.CoverageConfig(cfg.Coverage),
+		AutoPhase:          startAutoPhase || cfg.AutoPhase,
+		MinimalPermissions: cfg.MinimalPermissions,
+		VerifyFilesChanged: cfg.VerifyFilesChanged,
+		Fresh:              startFresh,
+		ValidationCommands: cfg.ValidationCommands,
+		Labels:             labels,
+		Notes:              cfg.ToNotesConfig(),
+		Artifacts:          cfg.Artifacts.Enabled,
+		RecordEvents:       startRecordEvents,
+		ArtifactsKeep:      cfg.Artifacts.Keep,
+		StatusKey:          cfg.StatusBlock.RunKey(),
+		OwnerRoutes:        cfg.Notifications.Owners,
+		Messages:           cfg.Messages,
+		Pacing:             pacingFromConfig(cfg.Pacing),
+		Fallback:           fallbackFromConfig(cfg),
+		ActiveFiles:        activeFilesFromConfig(cfg.ActiveFiles),
+		Theme:              theme,
+		Keys:               keyBindingsFromConfig(cfg.UI.Keys),
+		DiffStyle:          cfg.UI.Diff,
+		Prices:             llm.DefaultPrices().WithOverrides(cfg.Pricing),
+		CostWarning:        cfg.UI.CostWarning,
+		IsTTY:              isTTY,
+		KeyInput:           isTTY && term.IsTerminal(int(os.Stdin.Fd())),
+		TermWidth:          termWidth,
+		TermHeight:         termHeight,
 	}
 
 	reviewCfg, err := cfg.ToReviewConfig()
 	if err != nil {
-		return fmt.Errorf("invalid review config: %w", err)
+		return nil, fmt.Errorf("invalid review config: %w", err)
+	}
+	if err := applyReviewFlags(&reviewCfg, startReviewSkip, startReviewSeverities, startReviewAgents); err != nil {
+		return nil, err
 	}
 	runCfg.ReviewConfig = reviewCfg
+	if cfg.Supervisor.Enabled {
+		runCfg.SupervisorMax = cfg.Supervisor.MaxInterventions
+	}
+	if cfg.Split.Enabled {
+		runCfg.SplitAfter = cfg.Split.AfterAttempts
+		runCfg.SplitMaxLength = cfg.Split.MaxPhaseLength
+	}
+	runCfg.DiffScanner, err = cfg.ToDiffScanner()
+	if err != nil {
+		return nil, fmt.Errorf("invalid diff_scan config: %w", err)
+	}
+	runCfg.Notifier, err = cfg.ToNotifier()
+	if err != nil {
+		return nil, fmt.Errorf("invalid notifications config: %w", err)
+	}
+	if startParallelPhases > 0 {
+		runCfg.ParallelPhases = startParallelPhases
+	}
 
-	_, err = Run(context.Background(), sourceID, wd, runCfg)
+	lock, err := acquireRunLock(sourceID, wd, startForce)
 	if err != nil {
-		return fmt.Errorf("loop error: %w", err)
+		return nil, err
+	}
+	defer lock.release()
+
+	// Reruns from a phase and fresh starts repeat work on purpose.
+	if opts.fromPhase == "" && !startFresh {
+		var ask Collector
+		if runCfg.KeyInput && opts.priorRun == priorRunPrompt {
+			ask = NewTerminalCollector()
+		}
+		logPath := progressLogPath(sourceID)
+		postMortem := func(ctx context.Context) (string, error) {
+			inv, err := executor.New(cfg.ToExecutorConfig())
+			if err != nil {
+				return "", fmt.Errorf("create invoker: %w", err)
+			}
+			_, report, err := writePostMortem(ctx, inv, cfg, sourceID, wd, logPath)
+			return report, err
+		}
+		if err := checkPriorRun(context.Background(), logPath, startRerun, ask, os.Stdout, postMortem); err != nil {
+			return nil, err
+		}
+	}
+
+	// Preflight checks the tree as the user left it: reopening phases of a
+	// plan file edits it.
+	if startPreflight || cfg.Preflight.Enabled {
+		if err := runPreflight(cfg, sourceID, wd, theme); err != nil {
+			return nil, err
+		}
 	}
 
+	if opts.fromPhase != "" {
+		if err := reopenFromPhase(cfg, sourceID, opts.fromPhase); err != nil {
+			return nil, err
+		}
+	}
+
+	result, runErr := Run(context.Background(), sourceID, wd, runCfg)
+	return result, runExitError(result, runErr)
+}
+
+// applyReviewFlags applies the review flags of a single run to cfg.
+func applyReviewFlags(cfg *review.Config, skip bool, severities, agents []string) error {
+	if skip && (len(severities) > 0 || len(agents) > 0) {
+		return fmt.Errorf("--review-skip cannot be combined with --review-only-severity or --review-agents")
+	}
+	cfg.Skip = skip
+	for _, s := range severities {
+		severity := review.Severity(strings.ToLower(strings.TrimSpace(s)))
+		if !review.ValidSeverity(severity) {
+			return fmt.Errorf("unknown --review-only-severity %q (supported: critical, high, medium, low, info)", s)
+		}
+		cfg.Severities = append(cfg.Severities, severity)
+	}
+	if len(agents) > 0 {
+		if err := cfg.SelectAgents(agents); err != nil {
+			return fmt.Errorf("--review-agents: %w", err)
+		}
+	}
 	return nil
 }
+
+// pacingFromConfig converts the pacing settings for the loop.
+func pacingFromConfig(pc config.PacingConfig) loop.PacingConfig {
+	return loop.PacingConfig{
+		Cooldown:    time.Duration(pc.Cooldown) * time.Second,
+		Adaptive:    pc.Adaptive,
+		MaxCooldown: time.Duration(pc.MaxCooldown) * time.Second,
+		WorkHours:   pc.WorkHoursWindow(),
+	}
+}
+
+// activeFilesFromConfig converts the active files settings for the loop.
+func activeFilesFromConfig(ac config.ActiveFilesConfig) loop.ActiveFilesConfig {
+	return loop.ActiveFilesConfig{
+		StatusFile:  ac.StatusFile,
+		OnConflict:  ac.OnConflict,
+		QuietPeriod: time.Duration(ac.QuietPeriod) * time.Second,
+	}
+}
+
+// fallbackFromConfig converts the executor fallback chain for the loop.
+func fallbackFromConfig(cfg *config.Config) loop.FallbackConfig {
+	return loop.FallbackConfig{After: cfg.Fallback.After, Chain: cfg.ToFallbackExecutors()}
+}
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/review"
 )
 
 func TestStartCmdDefinition(t *testing.T) {
@@ -36,4 +38,43 @@ func TestStartCmdFlags(t *testing.T) {
 
 	branchFlag := flags.Lookup("branch")
 	require.NotNil(t, branchFlag)
+
+	docsFlag := flags.Lookup("docs")
+	require.NotNil(t, docsFlag)
+
+	for _, name := range []string{"review-skip", "review-only-severity", "review-agents"} {
+		require.NotNil(t, flags.Lookup(name), name)
+	}
+}
+
+func TestApplyReviewFlags(t *testing.T) {
+	agents := []review.AgentConfig{{Name: "bug-shallow"}, {Name: "architect"}, {Name: "comments"}}
+
+	t.Run("none", func(t *testing.T) {
+		cfg := review.Config{Agents: agents}
+		require.NoError(t, applyReviewFlags(&cfg, false, nil, nil))
+		require.False(t, cfg.Skip)
+		require.Empty(t, cfg.Severities)
+		require.Equal(t, agents, cfg.Agents)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		cfg := review.Config{Agents: agents}
+		require.NoError(t, applyReviewFlags(&cfg, true, nil, nil))
+		require.True(t, cfg.Skip)
+	})
+
+	t.Run("severities and agents", func(t *testing.T) {
+		cfg := review.Config{Agents: agents}
+		require.NoError(t, applyReviewFlags(&cfg, false, []string{"critical", " High"}, []string{"comments", "bug-shallow"}))
+		require.Equal(t, []review.Severity{review.SeverityCritical, review.SeverityHigh}, cfg.Severities)
+		require.Equal(t, []review.AgentConfig{{Name: "bug-shallow"}, {Name: "comments"}}, cfg.Agents)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cfg := review.Config{Agents: agents}
+		require.ErrorContains(t, applyReviewFlags(&cfg, false, []string{"urgent"}, nil), `unknown --review-only-severity "urgent"`)
+		require.ErrorContains(t, applyReviewFlags(&cfg, false, nil, []string{"typos"}), `--review-agents: unknown review agent "typos" (configured: bug-shallow, architect, comments)`)
+		require.ErrorContains(t, applyReviewFlags(&cfg, true, nil, []string{"comments"}), "cannot be combined")
+	})
 }
@@ -3,8 +3,10 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -20,7 +22,21 @@ type sessionInfo struct {
 	PID        int    `json:"pid"`
 }
 
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of running programmator sessions",
+	Long: `Connect to every running programmator session and print its work item,
+iteration, current phase, elapsed time, last summary, and recent events.
+Useful when a run is executing in tmux or in the background.`,
+	Args: cobra.NoArgs,
+	RunE: runStatus,
+}
+
 func runStatus(_ *cobra.Command, _ []string) error {
+	if printLiveSessions(os.Stdout) > 0 {
+		return nil
+	}
+
 	path := sessionFilePath()
 
 	data, err := os.ReadFile(path)
@@ -59,6 +75,74 @@ func runStatus(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
+// printLiveSessions prints every session answering on a status socket and
+// returns how many were found.
+func printLiveSessions(out io.Writer) int {
+	sessions := liveSessions()
+	for i, snap := range sessions {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		printStatusSnapshot(out, snap)
+	}
+	return len(sessions)
+}
+
+// liveSessions queries every session answering on a status socket. Sockets
+// nobody listens on are removed.
+func liveSessions() []*statusSnapshot {
+	sockets, _ := filepath.Glob(filepath.Join(statusSocketDir(), "*.sock"))
+	var sessions []*statusSnapshot
+	for _, path := range sockets {
+		snap, err := queryStatus(path)
+		if err != nil {
+			os.Remove(path)
+			continue
+		}
+		sessions = append(sessions, snap)
+	}
+	return sessions
+}
+
+func printStatusSnapshot(out io.Writer, snap *statusSnapshot) {
+	fmt.Fprintln(out, "Active programmator session:")
+	workItem := snap.WorkItemID
+	if snap.WorkItemTitle != "" {
+		workItem += " - " + snap.WorkItemTitle
+	}
+	fmt.Fprintf(out, "  Work item:    %s\n", workItem)
+	fmt.Fprintf(out, "  Working dir:  %s\n", snap.WorkingDir)
+	if len(snap.Labels) > 0 {
+		fmt.Fprintf(out, "  Labels:       %s\n", strings.Join(snap.Labels, ", "))
+	}
+	fmt.Fprintf(out, "  PID:          %d\n", snap.PID)
+	fmt.Fprintf(out, "  Iteration:    %d/%d\n", snap.Iteration, snap.MaxIterations)
+	if snap.PhasesTotal > 0 {
+		phase := snap.Phase
+		if phase == "" {
+			phase = "all complete"
+		}
+		fmt.Fprintf(out, "  Phase:        %s (%d/%d done)\n", phase, snap.PhasesDone, snap.PhasesTotal)
+	}
+	if snap.ReviewPhase != "" {
+		review := snap.ReviewPhase
+		if len(snap.ReviewAgents) > 0 {
+			review += " (" + strings.Join(snap.ReviewAgents, ", ") + ")"
+		}
+		fmt.Fprintf(out, "  Review:       %s\n", review)
+	}
+	fmt.Fprintf(out, "  Elapsed:      %s\n", formatElapsed(time.Since(snap.StartedAt)))
+	if snap.LastSummary != "" {
+		fmt.Fprintf(out, "  Last summary: %s\n", snap.LastSummary)
+	}
+	if len(snap.RecentEvents) > 0 {
+		fmt.Fprintln(out, "  Recent events:")
+		for _, ev := range snap.RecentEvents {
+			fmt.Fprintf(out, "    %s\n", ev)
+		}
+	}
+}
+
 func isProcessRunning(pid int) bool {
 	process, err := os.FindProcess(pid)
 	if err != nil {
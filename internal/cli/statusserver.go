@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/dirs"
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// statusEventLimit is how many recent events a status server keeps.
+const statusEventLimit = 20
+
+// statusSnapshot is the run state served over the status socket.
+type statusSnapshot struct {
+	WorkItemID    string    `json:"work_item_id"`
+	WorkItemTitle string    `json:"work_item_title,omitempty"`
+	WorkingDir    string    `json:"working_dir"`
+	Labels        []string  `json:"labels,omitempty"`
+	PID           int       `json:"pid"`
+	StartedAt     time.Time `json:"started_at"`
+	Iteration     int       `json:"iteration"`
+	MaxIterations int       `json:"max_iterations"`
+	Phase         string    `json:"phase,omitempty"`
+	ReviewPhase   string    `json:"review_phase,omitempty"`  // set while a review pass runs
+	ReviewAgents  []string  `json:"review_agents,omitempty"` // review agents running now
+	PhasesDone    int       `json:"phases_done"`
+	PhasesTotal   int       `json:"phases_total"`
+	LastSummary   string    `json:"last_summary,omitempty"`
+	RecentEvents  []string  `json:"recent_events"`
+}
+
+// statusServer answers `programmator status` queries for a running session.
+// Each connection receives one JSON-encoded statusSnapshot.
+type statusServer struct {
+	path     string
+	listener net.Listener
+
+	mu   sync.Mutex
+	snap statusSnapshot
+}
+
+// statusSocketDir returns the directory holding the sockets of running sessions.
+func statusSocketDir() string {
+	return filepath.Join(dirs.StateDir(), "sockets")
+}
+
+// startStatusServer listens on a per-process socket in statusSocketDir.
+func startStatusServer(sourceID, workingDir string, maxIterations int, labels []string) (*statusServer, error) {
+	dir := statusSocketDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create socket dir: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%d.sock", os.Getpid()))
+	_ = os.Remove(path) // left over from a crashed process with a recycled PID
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on status socket: %w", err)
+	}
+
+	s := &statusServer{
+		path:     path,
+		listener: listener,
+		snap: statusSnapshot{
+			WorkItemID:    sourceID,
+			WorkingDir:    workingDir,
+			Labels:        labels,
+			PID:           os.Getpid(),
+			StartedAt:     time.Now(),
+			MaxIterations: maxIterations,
+		},
+	}
+	go s.serve()
+	return s, nil
+}
+
+func (s *statusServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		snap := s.snapshot()
+		_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+		_ = json.NewEncoder(conn).Encode(snap)
+		conn.Close()
+	}
+}
+
+// Close stops serving and removes the socket file.
+func (s *statusServer) Close() {
+	s.listener.Close()
+	os.Remove(s.path)
+}
+
+// updateState copies the fields shown by `programmator status`.
+func (s *statusServer) updateState(state *safety.State, item *domain.WorkItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if state != nil {
+		s.snap.Iteration = state.Iteration
+		s.snap.StartedAt = state.StartTime
+		s.snap.ReviewPhase, s.snap.ReviewAgents = "", nil
+		if state.Review != nil {
+			s.snap.ReviewPhase = state.Review.Phase
+			s.snap.ReviewAgents = state.Review.Running()
+		}
+	}
+	if item != nil {
+		s.snap.WorkItemID = item.ID
+		s.snap.WorkItemTitle = item.Title
+		s.snap.PhasesTotal = len(item.Phases)
+		s.snap.PhasesDone = 0
+		for _, p := range item.Phases {
+			if p.Completed {
+				s.snap.PhasesDone++
+			}
+		}
+		s.snap.Phase = ""
+		if phase := item.CurrentPhase(); phase != nil {
+			s.snap.Phase = phase.Name
+		}
+	}
+}
+
+// recordEvent keeps progress and review messages for the recent-events tail.
+// Streaming output and diffs are too noisy to be useful here.
+func (s *statusServer) recordEvent(ev event.Event) {
+	switch ev.Kind {
+	case event.KindProg, event.KindReview, event.KindToolUse:
+	default:
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ev.Summary != "" {
+		s.snap.LastSummary = ev.Summary
+	}
+	s.snap.RecentEvents = append(s.snap.RecentEvents, ev.Text)
+	if len(s.snap.RecentEvents) > statusEventLimit {
+		s.snap.RecentEvents = s.snap.RecentEvents[len(s.snap.RecentEvents)-statusEventLimit:]
+	}
+}
+
+func (s *statusServer) snapshot() statusSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.snap
+	snap.RecentEvents = append([]string(nil), s.snap.RecentEvents...)
+	return snap
+}
+
+// queryStatus reads the snapshot served on the socket at path.
+func queryStatus(path string) (*statusSnapshot, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var snap statusSnapshot
+	if err := json.NewDecoder(conn).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("read status: %w", err)
+	}
+	return &snap, nil
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestStatusServer_ServesSnapshot(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+
+	srv, err := startStatusServer("plan.md", "/work", 50, []string{"refactor"})
+	require.NoError(t, err)
+
+	state := safety.NewState()
+	state.Iteration = 3
+	srv.updateState(state, &domain.WorkItem{
+		ID:    "plan.md",
+		Title: "Add feature",
+		Phases: []domain.Phase{
+			{Name: "Task 1", Completed: true},
+			{Name: "Task 2"},
+		},
+	})
+	srv.recordEvent(event.Prog("Invoking claude"))
+	srv.recordEvent(event.StreamingText("noise"))
+	// The message shown is localized; the summary is taken from the event.
+	srv.recordEvent(event.IterationSummary("Résumé : Implemented task 1", "Implemented task 1"))
+
+	snap, err := queryStatus(srv.path)
+	require.NoError(t, err)
+	assert.Equal(t, "plan.md", snap.WorkItemID)
+	assert.Equal(t, "Add feature", snap.WorkItemTitle)
+	assert.Equal(t, 3, snap.Iteration)
+	assert.Equal(t, 50, snap.MaxIterations)
+	assert.Equal(t, []string{"refactor"}, snap.Labels)
+	assert.Equal(t, "Task 2", snap.Phase)
+	assert.Equal(t, 1, snap.PhasesDone)
+	assert.Equal(t, 2, snap.PhasesTotal)
+	assert.Equal(t, "Implemented task 1", snap.LastSummary)
+	assert.Equal(t, []string{"Invoking claude", "Résumé : Implemented task 1"}, snap.RecentEvents)
+	assert.Equal(t, os.Getpid(), snap.PID)
+
+	srv.Close()
+	assert.NoFileExists(t, srv.path)
+}
+
+func TestStatusServer_ReviewProgress(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+
+	srv, err := startStatusServer("plan.md", "/work", 10, nil)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	state := safety.NewState()
+	state.Review = &safety.ReviewProgress{Phase: "validation", Agents: []safety.AgentProgress{
+		{Name: "bugs", Done: true},
+		{Name: "issue-validator"},
+	}}
+	srv.updateState(state, nil)
+	snap := srv.snapshot()
+	assert.Equal(t, "validation", snap.ReviewPhase)
+	assert.Equal(t, []string{"issue-validator"}, snap.ReviewAgents)
+
+	state.Review = nil
+	srv.updateState(state, nil)
+	snap = srv.snapshot()
+	assert.Empty(t, snap.ReviewPhase)
+	assert.Empty(t, snap.ReviewAgents)
+}
+
+func TestStatusServer_KeepsRecentEvents(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+
+	srv, err := startStatusServer("plan.md", "/work", 10, nil)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	for i := range statusEventLimit + 5 {
+		srv.recordEvent(event.Prog(fmt.Sprintf("event %d", i)))
+	}
+
+	snap := srv.snapshot()
+	require.Len(t, snap.RecentEvents, statusEventLimit)
+	assert.Equal(t, "event 5", snap.RecentEvents[0])
+}
+
+func TestPrintLiveSessions(t *testing.T) {
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+
+	srv, err := startStatusServer("plan.md", "/work", 10, nil)
+	require.NoError(t, err)
+	defer srv.Close()
+	srv.recordEvent(event.IterationSummary("Summary: Did things", "Did things"))
+
+	stale := filepath.Join(statusSocketDir(), "1.sock")
+	require.NoError(t, os.WriteFile(stale, nil, 0o600))
+
+	var buf bytes.Buffer
+	assert.Equal(t, 1, printLiveSessions(&buf))
+
+	output := buf.String()
+	assert.Contains(t, output, "Work item:    plan.md")
+	assert.Contains(t, output, "Iteration:    0/10")
+	assert.Contains(t, output, "Last summary: Did things")
+	assert.Contains(t, output, "Recent events:")
+	assert.NoFileExists(t, stale, "unreachable sockets should be removed")
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+// noColor is set by the global --no-color flag.
+var noColor bool
+
+var severityBadgeRegex = regexp.MustCompile(`\[(critical|high|medium|low|info)\]`)
+
+// theme maps output roles to 256-color codes.
+type theme struct {
+	noColor bool // print plain text, as when stdout is not a terminal
+	noFaint bool // render dim text in the muted color; faint text is hard to read on some terminals
+
+	accent    int // programmator prefix, separators, current phase
+	success   int
+	failure   int
+	info      int // review progress
+	muted     int
+	subtle    int
+	text      int
+	highlight int // work item IDs
+
+	diffAdd  int
+	diffDel  int
+	diffCtx  int
+	diffHunk int
+
+	severityCritical int
+	severityHigh     int
+	severityMedium   int
+	severityLow      int
+	severityInfo     int
+}
+
+func defaultTheme() *theme {
+	return &theme{
+		accent:    colorOrange,
+		success:   colorGreen,
+		failure:   colorRed,
+		info:      colorCyan,
+		muted:     colorDim,
+		subtle:    colorDimmer,
+		text:      colorWhite,
+		highlight: colorMagenta,
+
+		diffAdd:  colorGreen,
+		diffDel:  colorRed,
+		diffCtx:  colorDim,
+		diffHunk: colorCyan,
+
+		severityCritical: colorPink,
+		severityHigh:     colorRed,
+		severityMedium:   colorOrange,
+		severityLow:      colorCyan,
+		severityInfo:     colorDim,
+	}
+}
+
+// highContrastTheme uses the bright end of the 256-color cube and avoids
+// faint text.
+func highContrastTheme() *theme {
+	return &theme{
+		noFaint: true,
+
+		accent:    226, // yellow
+		success:   46,  // green
+		failure:   196, // red
+		info:      51,  // cyan
+		muted:     250, // light grey
+		subtle:    253,
+		text:      231, // white
+		highlight: 213, // pink
+
+		diffAdd:  46,
+		diffDel:  196,
+		diffCtx:  250,
+		diffHunk: 51,
+
+		severityCritical: 201,
+		severityHigh:     196,
+		severityMedium:   226,
+		severityLow:      51,
+		severityInfo:     250,
+	}
+}
+
+// newTheme builds the theme selected by ui, with its color overrides applied.
+// disableColor forces the no-color theme.
+func newTheme(ui config.UIConfig, disableColor bool) (*theme, error) {
+	var t *theme
+	switch ui.Theme {
+	case "high-contrast":
+		t = highContrastTheme()
+	case "no-color":
+		t = defaultTheme()
+		t.noColor = true
+	default:
+		t = defaultTheme()
+	}
+	if disableColor {
+		t.noColor = true
+	}
+
+	roles := t.roles()
+	for role, color := range ui.Colors {
+		field, ok := roles[role]
+		if !ok {
+			names := make([]string, 0, len(roles))
+			for name := range roles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown ui.colors role %q (supported: %s)", role, strings.Join(names, ", "))
+		}
+		*field = color
+	}
+	return t, nil
+}
+
+// themeFromConfig builds the theme for cfg, honoring --no-color and the
+// NO_COLOR convention (https://no-color.org).
+func themeFromConfig(cfg *config.Config) (*theme, error) {
+	return newTheme(cfg.UI, noColor || os.Getenv("NO_COLOR") != "")
+}
+
+// roles returns the overridable colors by their ui.colors name.
+func (t *theme) roles() map[string]*int {
+	return map[string]*int{
+		"accent":            &t.accent,
+		"success":           &t.success,
+		"error":             &t.failure,
+		"info":              &t.info,
+		"muted":             &t.muted,
+		"subtle":            &t.subtle,
+		"text":              &t.text,
+		"highlight":         &t.highlight,
+		"diff_add":          &t.diffAdd,
+		"diff_del":          &t.diffDel,
+		"diff_ctx":          &t.diffCtx,
+		"diff_hunk":         &t.diffHunk,
+		"severity_critical": &t.severityCritical,
+		"severity_high":     &t.severityHigh,
+		"severity_medium":   &t.severityMedium,
+		"severity_low":      &t.severityLow,
+		"severity_info":     &t.severityInfo,
+	}
+}
+
+func (t *theme) fg(color int, text string) string {
+	if t.noColor {
+		return text
+	}
+	return fg(color, text)
+}
+
+func (t *theme) fgBold(color int, text string) string {
+	if t.noColor {
+		return text
+	}
+	return fgBold(color, text)
+}
+
+func (t *theme) bold(text string) string {
+	if t.noColor {
+		return text
+	}
+	return bold(text)
+}
+
+func (t *theme) dim(text string) string {
+	switch {
+	case t.noColor:
+		return text
+	case t.noFaint:
+		return fg(t.muted, text)
+	}
+	return dim(text)
+}
+
+func (t *theme) severityColor(severity review.Severity) int {
+	switch severity {
+	case review.SeverityCritical:
+		return t.severityCritical
+	case review.SeverityHigh:
+		return t.severityHigh
+	case review.SeverityMedium:
+		return t.severityMedium
+	case review.SeverityLow:
+		return t.severityLow
+	default:
+		return t.severityInfo
+	}
+}
+
+// severityBadges colors "[high]"-style severity badges in review output.
+func (t *theme) severityBadges(text string) string {
+	if t.noColor || !strings.Contains(text, "[") {
+		return text
+	}
+	return severityBadgeRegex.ReplaceAllStringFunc(text, func(badge string) string {
+		severity := review.Severity(strings.Trim(badge, "[]"))
+		return t.fgBold(t.severityColor(severity), badge)
+	})
+}
+
+// keyBindings maps remappable keys to their actions during a run.
+type keyBindings struct {
+	skipPhase string
+	interrupt string
+	issues    string
+}
+
+func defaultKeyBindings() keyBindings {
+	return keyBindings{skipPhase: "s", interrupt: "ctrl+c", issues: "i"}
+}
+
+// keyBindingsFromConfig applies the ui.keys overrides to the defaults.
+func keyBindingsFromConfig(keys config.UIKeysConfig) keyBindings {
+	k := defaultKeyBindings()
+	if keys.SkipPhase != "" {
+		k.skipPhase = keys.SkipPhase
+	}
+	if keys.Interrupt != "" {
+		k.interrupt = keys.Interrupt
+	}
+	if keys.Issues != "" {
+		k.issues = keys.Issues
+	}
+	return k
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestNewTheme(t *testing.T) {
+	th, err := newTheme(config.UIConfig{Theme: "default", Colors: map[string]int{"diff_add": 34, "error": 160}}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 34, th.diffAdd)
+	assert.Equal(t, 160, th.failure)
+	assert.Equal(t, colorOrange, th.accent)
+	assert.False(t, th.noColor)
+
+	th, err = newTheme(config.UIConfig{Theme: "high-contrast"}, false)
+	require.NoError(t, err)
+	assert.True(t, th.noFaint)
+	assert.Equal(t, fmt.Sprintf("\033[38;5;%dmx\033[0m", th.muted), th.dim("x"))
+
+	th, err = newTheme(config.UIConfig{Theme: "default"}, true)
+	require.NoError(t, err)
+	assert.True(t, th.noColor)
+	assert.Equal(t, "x", th.fgBold(th.accent, "x"))
+
+	_, err = newTheme(config.UIConfig{Colors: map[string]int{"background": 0}}, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown ui.colors role "background"`)
+}
+
+func TestTheme_SeverityBadges(t *testing.T) {
+	th := defaultTheme()
+	th.severityHigh = 200
+
+	out := th.severityBadges("- **[high]** `a.go:3` - bug\n- **[note]** other")
+	assert.Contains(t, out, "\033[1;38;5;200m[high]\033[0m")
+	assert.Contains(t, out, "**[note]**")
+
+	th.noColor = true
+	assert.Equal(t, "- [high] bug", th.severityBadges("- [high] bug"))
+}
+
+func TestWriter_NoColorTheme(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriterTTY(&buf)
+	th := defaultTheme()
+	th.noColor = true
+	w.SetTheme(th)
+
+	assert.Equal(t, "programmator: hello", w.formatEventLine(event.Prog("hello")))
+	assert.Equal(t, "+added", w.formatEventLine(event.DiffAdd("+added")))
+
+	item := &domain.WorkItem{ID: "plain", Phases: []domain.Phase{{Name: "Task"}}}
+	w.UpdateFooter(nil, item, safety.Config{MaxIterations: 10})
+	assert.NotContains(t, strings.Join(w.lastFooter, "\n"), "\033[")
+}
+
+func TestWriter_RemappedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriterTTY(&buf)
+
+	skipped, interrupted := 0, 0
+	w.SetKeyHandlers(func() { skipped++ }, func() { interrupted++ })
+	w.SetKeyBindings(keyBindingsFromConfig(config.UIKeysConfig{SkipPhase: "ctrl+s"}))
+
+	w.handleKey("s")
+	w.handleKey("ctrl+s")
+	w.handleKey("ctrl+c")
+	assert.Equal(t, 1, skipped)
+	assert.Equal(t, 1, interrupted)
+
+	item := &domain.WorkItem{ID: "keys", Phases: []domain.Phase{{Name: "Optional task"}}}
+	w.UpdateFooter(nil, item, safety.Config{MaxIterations: 10})
+	footer := stripANSISequences(strings.Join(w.lastFooter, "\n"))
+	assert.Contains(t, footer, "(ctrl+s: skip phase)")
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// maxReplayPause caps the pause between two replayed events, so the long
+// silences of executor invocations do not stall a replay.
+const maxReplayPause = 3 * time.Second
+
+// footerKind is the kind of the recording lines holding a footer update.
+const footerKind = "footer"
+
+var (
+	tuiReplay string
+	tuiSpeed  float64
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui --replay <file>",
+	Short: "Play a recorded run back through the terminal UI",
+	Long: `Play back a run recorded with "programmator start --record-events <file>":
+every event and footer update is fed to the terminal UI as the run produced
+them, without invoking the executor. Useful to work on the UI or reproduce a
+display bug.
+
+Events keep their original pacing, sped up by --speed; pauses are capped at
+3 seconds. --speed 0 plays everything at once. The events.jsonl file of run
+artifacts replays too, without the footer.
+
+Examples:
+  programmator tui --replay run.jsonl
+  programmator tui --replay run.jsonl --speed 4`,
+	Args: cobra.NoArgs,
+	RunE: runTUI,
+}
+
+func init() {
+	tuiCmd.Flags().StringVar(&tuiReplay, "replay", "", "Recorded event stream to play back")
+	tuiCmd.Flags().Float64Var(&tuiSpeed, "speed", 1, "Playback speed multiplier (0 = no pauses)")
+	_ = tuiCmd.MarkFlagRequired("replay")
+}
+
+func runTUI(_ *cobra.Command, _ []string) error {
+	if tuiSpeed < 0 {
+		return fmt.Errorf("--speed must not be negative")
+	}
+	f, err := os.Open(tuiReplay)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	isTTY := stdoutIsTTY()
+	width, height := 0, 0
+	if isTTY {
+		width, height, _ = term.GetSize(int(os.Stdout.Fd()))
+	}
+	w := NewWriter(os.Stdout, isTTY, width, height)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = playRecording(ctx, w, f, tuiSpeed, sleepContext)
+	w.ClearFooter()
+	return err
+}
+
+// recordedEvent is one line of an event recording: an event, or a footer
+// update when Kind is "footer". The events.jsonl of run artifacts uses the
+// same lines, without footer updates.
+type recordedEvent struct {
+	Time   time.Time              `json:"time"`
+	Kind   string                 `json:"kind"`
+	Text   string                 `json:"text,omitempty"`
+	Agent  string                 `json:"agent,omitempty"`
+	Phases []string               `json:"phases,omitempty"`
+	Issue  *event.IssueTransition `json:"issue,omitempty"`
+	Footer *recordedFooter        `json:"footer,omitempty"`
+}
+
+// recordedFooter is what the footer was drawn from.
+type recordedFooter struct {
+	Executor string           `json:"executor,omitempty"`
+	State    *safety.State    `json:"state,omitempty"`
+	WorkItem *domain.WorkItem `json:"work_item,omitempty"`
+	Config   safety.Config    `json:"config"`
+}
+
+// eventRecording writes the events and footer updates of a run to a
+// JSON-lines file, for "programmator tui --replay".
+type eventRecording struct {
+	executor string
+
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func openEventRecording(path, executor string) (*eventRecording, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create recording dir: %w", err)
+	}
+	f, err := os.Create(path) //nolint:gosec // path given by the user
+	if err != nil {
+		return nil, fmt.Errorf("open event recording: %w", err)
+	}
+	return &eventRecording{executor: executor, f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (r *eventRecording) event(ev event.Event) {
+	r.write(newRecordedEvent(ev))
+}
+
+// newRecordedEvent records ev as it happens now.
+func newRecordedEvent(ev event.Event) recordedEvent {
+	return recordedEvent{Time: time.Now(), Kind: ev.Kind.String(), Text: ev.Text, Agent: ev.Agent, Phases: ev.Phases, Issue: ev.Issue}
+}
+
+// footer records a footer update. state and item are snapshots, which the
+// footer callback takes anyway.
+func (r *eventRecording) footer(state *safety.State, item *domain.WorkItem, cfg safety.Config) {
+	r.write(recordedEvent{Time: time.Now(), Kind: footerKind, Footer: &recordedFooter{
+		Executor: r.executor, State: state, WorkItem: item, Config: cfg,
+	}})
+}
+
+func (r *eventRecording) write(rec recordedEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(rec)
+}
+
+func (r *eventRecording) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.f.Close()
+}
+
+// playRecording feeds the recorded events of f to w, pausing between them
+// as long as the run did, divided by speed and capped at maxReplayPause.
+// Footers show the elapsed time of the recorded run.
+func playRecording(ctx context.Context, w *Writer, f *os.File, speed float64, sleep func(context.Context, time.Duration) error) error {
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var prev time.Time
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec recordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("%s:%d: %w", f.Name(), n, err)
+		}
+
+		if speed > 0 && !prev.IsZero() {
+			pause := min(time.Duration(float64(rec.Time.Sub(prev))/speed), maxReplayPause)
+			if err := sleep(ctx, pause); err != nil {
+				return nil // interrupted
+			}
+		}
+		prev = rec.Time
+
+		if rec.Kind == footerKind {
+			if ft := rec.Footer; ft != nil {
+				if ft.Executor != "" {
+					w.SetExecutorName(ft.Executor)
+				}
+				if ft.State != nil {
+					ft.State.StartTime = time.Now().Add(-rec.Time.Sub(ft.State.StartTime))
+				}
+				w.UpdateFooter(ft.State, ft.WorkItem, ft.Config)
+			}
+			continue
+		}
+		if kind, ok := event.ParseKind(rec.Kind); ok {
+			w.WriteEvent(event.Event{Kind: kind, Text: rec.Text, Agent: rec.Agent, Phases: rec.Phases, Issue: rec.Issue})
+		}
+	}
+	return scanner.Err()
+}
+
+// sleepContext waits for d, or returns ctx's error once it is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
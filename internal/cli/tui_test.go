@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestEventRecordingReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec", "run.jsonl")
+	rec, err := openEventRecording(path, "claude")
+	require.NoError(t, err)
+	rec.event(event.IterationSeparator("1/10"))
+	rec.footer(&safety.State{Iteration: 1, StartTime: time.Now()}, &domain.WorkItem{ID: "plan.md"}, safety.Config{MaxIterations: 10})
+	rec.event(event.Prog("Invoking claude"))
+	rec.event(event.ToolUse("Read main.go"))
+	rec.close()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, false, 0, 0)
+	var pauses []time.Duration
+	sleep := func(_ context.Context, d time.Duration) error {
+		pauses = append(pauses, d)
+		return nil
+	}
+	require.NoError(t, playRecording(context.Background(), w, f, 2, sleep))
+
+	assert.Contains(t, buf.String(), "Invoking claude")
+	assert.Contains(t, buf.String(), "Read main.go")
+	assert.Equal(t, "claude", w.executorName)
+	require.Len(t, pauses, 3, "one pause before every line but the first")
+	for _, d := range pauses {
+		assert.LessOrEqual(t, d, maxReplayPause)
+	}
+}
+
+func TestPlayRecording_PacingAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) *os.File {
+		t.Helper()
+		path := filepath.Join(dir, "rec.jsonl")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+
+	f := write(`{"time":"2026-01-01T10:00:00Z","kind":"prog","text":"a"}
+{"time":"2026-01-01T10:00:02Z","kind":"prog","text":"b"}
+{"time":"2026-01-01T10:05:00Z","kind":"prog","text":"c"}
+`)
+	var pauses []time.Duration
+	sleep := func(_ context.Context, d time.Duration) error {
+		pauses = append(pauses, d)
+		return nil
+	}
+	var buf bytes.Buffer
+	require.NoError(t, playRecording(context.Background(), NewWriter(&buf, false, 0, 0), f, 4, sleep))
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, maxReplayPause}, pauses)
+
+	pauses = nil
+	f = write(`{"time":"2026-01-01T10:00:00Z","kind":"prog","text":"a"}
+{"time":"2026-01-01T10:00:02Z","kind":"prog","text":"b"}
+`)
+	require.NoError(t, playRecording(context.Background(), NewWriter(&buf, false, 0, 0), f, 0, sleep))
+	assert.Empty(t, pauses, "speed 0 plays without pauses")
+
+	f = write("{\"time\":\"2026-01-01T10:00:00Z\",\"kind\":\"prog\",\"text\":\"a\"}\nnot json\n")
+	require.ErrorContains(t, playRecording(context.Background(), NewWriter(&buf, false, 0, 0), f, 1, sleep), "rec.jsonl:2")
+}
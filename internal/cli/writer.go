@@ -1,8 +1,13 @@
 package cli
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -12,6 +17,9 @@ import (
 
 	"github.com/alexander-akhmetov/programmator/internal/domain"
 	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/review"
 	"github.com/alexander-akhmetov/programmator/internal/safety"
 )
 
@@ -28,16 +36,24 @@ const (
 	colorPink    = 97  // Wisteria (#8e44ad)
 
 	footerIDPrefixChars = 12
+	footerActiveFiles   = 3 // files listed on the Editing line before "+N more"
 )
 
 type bubbleFooterMsg struct {
 	lines []string
 }
 
+// bubbleBrowserMsg shows the issue browser in place of the footer.
+type bubbleBrowserMsg struct {
+	browser *issueBrowser
+}
+
 type bubbleModel struct {
-	footer []string
-	ready  chan struct{}
-	once   sync.Once
+	footer  []string
+	browser *issueBrowser // shown in place of the footer while open; it gets the keys
+	ready   chan struct{}
+	once    sync.Once
+	onKey   func(key string)
 }
 
 func (m *bubbleModel) Init() tea.Cmd {
@@ -50,13 +66,32 @@ func (m *bubbleModel) Init() tea.Cmd {
 }
 
 func (m *bubbleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	if msg, ok := msg.(bubbleFooterMsg); ok {
+	if m.browser != nil {
+		switch msg.(type) {
+		case tea.KeyMsg, editorDoneMsg:
+			_, cmd := m.browser.Update(msg)
+			return m, cmd
+		}
+	}
+	switch msg := msg.(type) {
+	case bubbleFooterMsg:
 		m.footer = append([]string(nil), msg.lines...)
+	case bubbleBrowserMsg:
+		m.browser = msg.browser
+	case issueBrowserClosedMsg:
+		m.browser = nil
+	case tea.KeyMsg:
+		if m.onKey != nil {
+			m.onKey(msg.String())
+		}
 	}
 	return m, nil
 }
 
 func (m *bubbleModel) View() string {
+	if m.browser != nil {
+		return m.browser.View()
+	}
 	return strings.Join(m.footer, "\n")
 }
 
@@ -77,6 +112,7 @@ type Writer struct {
 
 	footerLines int
 	lastFooter  []string
+	footer      *footerInput // what lastFooter was built from
 	midLine     bool
 	pendingLine string
 
@@ -88,6 +124,39 @@ type Writer struct {
 	tea       *tea.Program
 	teaDone   chan struct{}
 	teaActive bool
+
+	theme *theme
+	keys  keyBindings
+
+	// Diff lines of an edit held back for diffRenderer, which renders them
+	// together; nil renderer prints them as they come.
+	diffRenderer diffRenderer
+	pendingDiff  []event.Event
+	diffTimer    *time.Timer
+
+	// Latest activity of the review agents, shown in the footer during a
+	// review pass.
+	agentPanes map[string]*agentPane
+
+	prices      llm.PriceTable // nil hides the cost estimate
+	costWarning float64        // USD total past which the cost flashes (0 = never)
+	costFlash   bool           // alternates on each redraw while over costWarning
+
+	// Key handlers; nil unless SetKeyHandlers enabled keyboard input.
+	onSkip      func()
+	onInterrupt func()
+
+	reviewIssues []*review.Result // of the last review, for the issue browser
+
+	in          io.Reader // answers to BLOCKED questions
+	answersOnce sync.Once
+	answers     chan answerLine // lines of in, from a reader started on the first question
+}
+
+// answerLine is a line the user typed, or the error that ended the input.
+type answerLine struct {
+	line string
+	err  error
 }
 
 // NewWriter creates a Writer. If width is <= 0, defaults to 80.
@@ -102,6 +171,10 @@ func NewWriter(out io.Writer, isTTY bool, width, height int) *Writer {
 		width:  width,
 		height: height,
 		useTea: isTTY,
+		theme:  defaultTheme(),
+		keys:   defaultKeyBindings(),
+		prices: llm.DefaultPrices(),
+		in:     os.Stdin,
 	}
 
 	if isTTY {
@@ -118,7 +191,25 @@ func NewWriter(out io.Writer, isTTY bool, width, height int) *Writer {
 }
 
 func (w *Writer) colorEnabled() bool {
-	return w.isTTY
+	return w.isTTY && !w.theme.noColor
+}
+
+// SetTheme sets the output colors. A no-color theme also turns off markdown
+// styling. Must be called before the first event is written.
+func (w *Writer) SetTheme(t *theme) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.theme = t
+	if t.noColor {
+		w.renderer = nil
+	}
+}
+
+// SetKeyBindings remaps the keys handled after SetKeyHandlers.
+func (w *Writer) SetKeyBindings(keys keyBindings) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.keys = keys
 }
 
 func (w *Writer) ensureTeaLocked() {
@@ -128,9 +219,14 @@ func (w *Writer) ensureTeaLocked() {
 
 	ready := make(chan struct{})
 	model := &bubbleModel{ready: ready}
+	input := tea.WithInput(nil)
+	if w.onSkip != nil {
+		model.onKey = w.handleKey
+		input = tea.WithInput(os.Stdin)
+	}
 	p := tea.NewProgram(
 		model,
-		tea.WithInput(nil),
+		input,
 		tea.WithOutput(w.out),
 		// Let programmator's signal.NotifyContext own SIGINT/SIGTERM handling.
 		tea.WithoutSignalHandler(),
@@ -155,6 +251,163 @@ func (w *Writer) ensureTeaLocked() {
 	}
 }
 
+// SetDiffStyle selects how edits are shown in TTY mode: "unified" (the
+// default), "word", or "side-by-side"; see diffRenderers.
+func (w *Writer) SetDiffStyle(style string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.diffRenderer = diffRenderers[style]
+}
+
+// SetKeyHandlers enables keyboard input in TTY mode: "s" skips the current
+// phase, "i" browses the issues of the last review, and ctrl+c interrupts
+// the run, unless remapped with SetKeyBindings. Bubble Tea reads stdin in raw mode,
+// so ctrl+c arrives as a key press rather than SIGINT. Must be called before
+// the first event is written.
+func (w *Writer) SetKeyHandlers(onSkip, onInterrupt func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onSkip = onSkip
+	w.onInterrupt = onInterrupt
+}
+
+func (w *Writer) handleKey(key string) {
+	switch key {
+	case w.keys.skipPhase:
+		w.onSkip()
+	case w.keys.interrupt:
+		w.onInterrupt()
+	case w.keys.issues:
+		go w.openIssueBrowser()
+	default:
+		if n, err := strconv.Atoi(key); err == nil && n >= 1 && n <= 9 {
+			// Keys arrive on the Bubble Tea goroutine, which the footer
+			// redraw sends to.
+			go w.toggleAgentPane(n)
+		}
+	}
+}
+
+// AskQuestions shows the questions from a BLOCKED status and reads a
+// one-line answer. The footer is taken down while waiting so the terminal
+// is back in line mode; the next event redraws it. Returns an empty answer
+// if ctx is canceled first.
+func (w *Writer) AskQuestions(ctx context.Context, blockedError string, questions []string) (string, error) {
+	w.ClearFooter()
+
+	w.mu.Lock()
+	if w.midLine {
+		fmt.Fprintln(w.out)
+		w.midLine = false
+	}
+	fmt.Fprintln(w.out)
+	fmt.Fprintln(w.out, w.styleBold(w.theme.accent, "BLOCKED: ")+blockedError)
+	for i, q := range questions {
+		fmt.Fprintf(w.out, "  %d. %s\n", i+1, q)
+	}
+	fmt.Fprint(w.out, w.style(w.theme.muted, "Answer (empty to stop the run): "))
+	w.mu.Unlock()
+
+	return w.readAnswer(ctx)
+}
+
+// PickPhase asks the user which of the candidate phases the executor meant
+// by reported. Returns the chosen phase, or "" for none.
+func (w *Writer) PickPhase(ctx context.Context, reported string, candidates []string) (string, error) {
+	w.ClearFooter()
+
+	w.mu.Lock()
+	if w.midLine {
+		fmt.Fprintln(w.out)
+		w.midLine = false
+	}
+	fmt.Fprintln(w.out)
+	fmt.Fprintln(w.out, w.styleBold(w.theme.accent, "Ambiguous phase: ")+fmt.Sprintf("%q matches several phases", reported))
+	for i, c := range candidates {
+		fmt.Fprintf(w.out, "  %d. %s\n", i+1, c)
+	}
+	fmt.Fprint(w.out, w.style(w.theme.muted, "Mark which (number, empty for none): "))
+	w.mu.Unlock()
+
+	answer, err := w.readAnswer(ctx)
+	if err != nil || answer == "" {
+		return "", err
+	}
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(candidates) {
+		return "", fmt.Errorf("invalid choice %q: enter a number from 1 to %d", answer, len(candidates))
+	}
+	return candidates[n-1], nil
+}
+
+// ApproveSignOff asks the user whether to commit phase's changes to files
+// under the configured sign-off paths. Anything but yes declines.
+func (w *Writer) ApproveSignOff(ctx context.Context, phase string, files []string) (bool, error) {
+	w.ClearFooter()
+
+	w.mu.Lock()
+	if w.midLine {
+		fmt.Fprintln(w.out)
+		w.midLine = false
+	}
+	fmt.Fprintln(w.out)
+	fmt.Fprintln(w.out, w.styleBold(w.theme.accent, "Sign-off needed: ")+fmt.Sprintf("%q changes high-risk paths", phase))
+	for _, f := range files {
+		fmt.Fprintf(w.out, "  %s\n", f)
+	}
+	fmt.Fprint(w.out, w.style(w.theme.muted, "Commit them? [y/N, no leaves them staged for you]: "))
+	w.mu.Unlock()
+
+	answer, err := w.readAnswer(ctx)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// readAnswer reads a line from the user. End of input and a canceled ctx
+// read as an empty answer.
+func (w *Writer) readAnswer(ctx context.Context) (string, error) {
+	w.answersOnce.Do(func() {
+		w.answers = make(chan answerLine)
+		go w.readAnswers()
+	})
+
+	select {
+	case <-ctx.Done():
+		fmt.Fprintln(w.out)
+		return "", nil
+	case res, ok := <-w.answers:
+		if !ok {
+			return "", nil
+		}
+		return res.line, res.err
+	}
+}
+
+// readAnswers reads the lines of w.in into w.answers until the input ends.
+// A single reader serves every question: reading in can't be interrupted,
+// so a reader per question would be left behind by each canceled one, and
+// it would lose what the previous one had buffered.
+func (w *Writer) readAnswers() {
+	defer close(w.answers)
+	reader := bufio.NewReader(w.in)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			if !errors.Is(err, io.EOF) {
+				w.answers <- answerLine{err: err}
+			}
+			return
+		}
+		w.answers <- answerLine{line: strings.TrimSpace(line)}
+	}
+}
+
 // WriteEvent prints a single event to the output stream.
 func (w *Writer) WriteEvent(ev event.Event) {
 	w.mu.Lock()
@@ -167,52 +420,72 @@ func (w *Writer) WriteEvent(ev event.Event) {
 	}
 	w.ensureTeaLocked()
 
+	if ev.Kind == event.KindAgentActivity {
+		w.recordAgentActivityLocked(ev)
+		return
+	}
+	if ev.Kind == event.KindIssueLifecycle {
+		return // summarized by the review's own log lines
+	}
+	if w.bufferDiffLocked(ev) {
+		return
+	}
+	w.flushDiffLocked()
+
+	if ev.Kind != event.KindStreamingText {
+		w.printLineLocked(w.formatEventLine(ev))
+		return
+	}
+
 	if w.teaActive {
-		if ev.Kind == event.KindStreamingText {
-			w.writeTeaStreamingLocked(ev.Text)
-			return
-		}
+		w.writeTeaStreamingLocked(ev.Text)
+		return
+	}
 
-		w.flushTeaPendingLocked()
+	// Fallback mode (non-TTY or Bubble Tea unavailable).
+	if !w.isTTY {
+		fmt.Fprint(w.out, ev.Text)
+		w.midLine = !strings.HasSuffix(ev.Text, "\n")
+		return
+	}
 
-		line := w.formatEventLine(ev)
+	w.legacyEraseFooter()
+	fmt.Fprint(w.out, ev.Text)
+	w.midLine = !strings.HasSuffix(ev.Text, "\n")
+	w.legacyRedrawFooter()
+}
+
+// printLineLocked prints a formatted line, or several joined by newlines,
+// above the footer.
+func (w *Writer) printLineLocked(line string) {
+	if w.teaActive {
+		w.flushTeaPendingLocked()
 		w.tea.Println(line)
 		return
 	}
 
 	// Fallback mode (non-TTY or Bubble Tea unavailable).
 	if !w.isTTY {
-		if ev.Kind == event.KindStreamingText {
-			fmt.Fprint(w.out, ev.Text)
-			w.midLine = !strings.HasSuffix(ev.Text, "\n")
-			return
-		}
 		if w.midLine {
 			fmt.Fprintln(w.out)
 			w.midLine = false
 		}
-		fmt.Fprintln(w.out, w.formatEventLine(ev))
+		fmt.Fprintln(w.out, line)
 		return
 	}
 
 	w.legacyEraseFooter()
-	if ev.Kind == event.KindStreamingText {
-		fmt.Fprint(w.out, ev.Text)
-		w.midLine = !strings.HasSuffix(ev.Text, "\n")
-		w.legacyRedrawFooter()
-		return
-	}
 	if w.midLine {
 		fmt.Fprintln(w.out)
 		w.midLine = false
 	}
-	fmt.Fprintln(w.out, w.formatEventLine(ev))
+	fmt.Fprintln(w.out, line)
 	w.legacyRedrawFooter()
 }
 
 func (w *Writer) formatEventLine(ev event.Event) string {
 	switch ev.Kind {
-	case event.KindProg:
+	case event.KindProg, event.KindPhaseAmbiguous:
 		return w.formatProg(ev.Text)
 	case event.KindToolUse:
 		return w.formatTool(ev.Text)
@@ -239,6 +512,13 @@ func (w *Writer) formatEventLine(ev event.Event) string {
 	}
 }
 
+// footerInput is what the footer is built from.
+type footerInput struct {
+	state *safety.State
+	item  *domain.WorkItem
+	cfg   safety.Config
+}
+
 // UpdateFooter redraws the sticky footer with current state.
 func (w *Writer) UpdateFooter(state *safety.State, item *domain.WorkItem, cfg safety.Config) {
 	if !w.isTTY {
@@ -248,7 +528,20 @@ func (w *Writer) UpdateFooter(state *safety.State, item *domain.WorkItem, cfg sa
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	lines := w.buildFooter(state, item, cfg)
+	w.footer = &footerInput{state: state, item: item, cfg: cfg}
+	if state == nil || state.Review == nil {
+		w.agentPanes = nil
+	}
+	w.redrawFooterLocked()
+}
+
+// redrawFooterLocked draws the footer from the input of the last
+// UpdateFooter.
+func (w *Writer) redrawFooterLocked() {
+	if w.footer == nil {
+		return
+	}
+	lines := w.buildFooter(w.footer.state, w.footer.item, w.footer.cfg)
 	if w.height > 0 {
 		maxFooterLines := max(w.height-1, 0)
 		if maxFooterLines <= 0 {
@@ -283,6 +576,7 @@ func (w *Writer) ClearFooter() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	w.flushDiffLocked()
 	if w.teaActive {
 		w.flushTeaPendingLocked()
 		w.tea.Send(bubbleFooterMsg{lines: nil})
@@ -293,6 +587,7 @@ func (w *Writer) ClearFooter() {
 		w.teaDone = nil
 		w.footerLines = 0
 		w.lastFooter = nil
+		w.footer = nil
 		w.midLine = false
 		w.pendingLine = ""
 		if done != nil {
@@ -307,6 +602,7 @@ func (w *Writer) ClearFooter() {
 	w.legacyEraseFooter()
 	w.footerLines = 0
 	w.lastFooter = nil
+	w.footer = nil
 
 	if w.midLine {
 		fmt.Fprintln(w.out)
@@ -403,57 +699,105 @@ func (w *Writer) buildFooter(state *safety.State, item *domain.WorkItem, cfg saf
 
 	// Orange separator line.
 	sep := strings.Repeat("─", w.width)
-	lines = append(lines, w.style(colorOrange, sep))
+	lines = append(lines, w.style(w.theme.accent, sep))
 
 	stageName := ""
 	if item != nil {
-		if phase := item.CurrentPhase(); phase != nil {
-			stageName = phase.Name
+		if idx := item.CurrentPhaseIndex(); idx >= 0 {
+			stageName = prompt.FormatPhasePath(item.PhasePath(idx))
 		} else if item.AllPhasesComplete() {
 			stageName = "complete"
 		}
 	}
 
-	// Status line: [claude_dir] | item | iteration | elapsed | pid
+	// Status line: [claude_dir] | item | iteration | pid | elapsed | cost
 	var parts []string
 	if w.claudeConfigDir != "" {
-		parts = append(parts, w.style(colorDim, "claude_dir=")+w.style(colorDimmer, sanitizeTerminalText(w.claudeConfigDir)))
+		parts = append(parts, w.style(w.theme.muted, "claude_dir=")+w.style(w.theme.subtle, sanitizeTerminalText(w.claudeConfigDir)))
 	}
 	if item != nil {
-		parts = append(parts, w.styleBold(colorMagenta, sanitizeTerminalText(truncateRunes(item.ID, footerIDPrefixChars))))
+		parts = append(parts, w.styleBold(w.theme.highlight, sanitizeTerminalText(truncateRunes(item.ID, footerIDPrefixChars))))
 		if state != nil {
-			parts = append(parts, w.style(colorWhite, fmt.Sprintf("iteration %d of %d", state.Iteration, cfg.MaxIterations)))
+			parts = append(parts, w.style(w.theme.text, fmt.Sprintf("iteration %d of %d", state.Iteration, cfg.MaxIterations)))
 		}
 	} else if state != nil {
-		parts = append(parts, w.style(colorWhite, fmt.Sprintf("iteration %d of %d", state.Iteration, cfg.MaxIterations)))
+		parts = append(parts, w.style(w.theme.text, fmt.Sprintf("iteration %d of %d", state.Iteration, cfg.MaxIterations)))
 	}
 	if w.pid > 0 {
 		name := w.executorName
 		if name == "" {
 			name = "claude"
 		}
-		parts = append(parts, w.style(colorDim, fmt.Sprintf("%s pid %d", name, w.pid)))
+		parts = append(parts, w.style(w.theme.muted, fmt.Sprintf("%s pid %d", name, w.pid)))
 	}
 	if len(parts) > 0 {
 		parts = sanitizeSlice(parts)
-		statusLine := strings.Join(parts, w.style(colorDim, " | "))
+		statusLine := strings.Join(parts, w.style(w.theme.muted, " | "))
 		if state != nil && !state.StartTime.IsZero() {
-			statusLine += w.style(colorDim, " | ") + w.style(colorWhite, formatElapsed(time.Since(state.StartTime)))
+			statusLine += w.style(w.theme.muted, " | ") + w.style(w.theme.text, formatElapsed(time.Since(state.StartTime)))
+		}
+		if est, ok := estimateCost(state, w.prices); ok {
+			statusLine += w.style(w.theme.muted, " | ") + w.formatCost(est)
 		}
 		lines = append(lines, statusLine)
 	}
 
-	// Current work line on its own row.
-	if stageName != "" {
-		lines = append(lines,
-			w.style(colorDim, "Working on: ")+
-				w.style(colorDimmer, sanitizeTerminalText(stageName)),
-		)
+	// Current work line on its own row; a running review replaces it.
+	if state != nil && state.Review != nil {
+		lines = append(lines, w.reviewProgressLine(state.Review))
+		lines = append(lines, w.agentPaneLines(state.Review)...)
+	} else if stageName != "" {
+		line := w.style(w.theme.muted, "Working on: ") + w.style(w.theme.subtle, sanitizeTerminalText(stageName))
+		if w.onSkip != nil {
+			var hints []string
+			if stageName != "complete" {
+				hints = append(hints, w.keys.skipPhase+": skip phase")
+			}
+			if countIssues(w.reviewIssues) > 0 {
+				hints = append(hints, w.keys.issues+": review issues")
+			}
+			if len(hints) > 0 {
+				line += w.style(w.theme.muted, "  ("+strings.Join(hints, ", ")+")")
+			}
+		}
+		lines = append(lines, line)
+	}
+	if state != nil && len(state.ActiveFiles) > 0 {
+		lines = append(lines, w.activeFilesLine(state.ActiveFiles))
 	}
 
 	return lines
 }
 
+// activeFilesLine lists the files the running invocation edits, so whoever
+// works in the same checkout can leave them alone.
+func (w *Writer) activeFilesLine(files []string) string {
+	shown := files[:min(len(files), footerActiveFiles)]
+	line := w.style(w.theme.muted, "Editing: ") + w.style(w.theme.subtle, sanitizeTerminalText(strings.Join(shown, ", ")))
+	if n := len(files) - len(shown); n > 0 {
+		line += w.style(w.theme.muted, fmt.Sprintf(" (+%d more)", n))
+	}
+	return line
+}
+
+// reviewProgressLine shows the review phase and its agents, each with the
+// time it has been running or took.
+func (w *Writer) reviewProgressLine(p *safety.ReviewProgress) string {
+	line := w.style(w.theme.muted, "Reviewing: ")
+	if p.Phase == "" {
+		return line + w.style(w.theme.subtle, "starting")
+	}
+	line += w.style(w.theme.subtle, sanitizeTerminalText(p.Phase))
+	for _, a := range p.Agents {
+		status := formatElapsed(a.Elapsed)
+		if a.Done {
+			status += " done"
+		}
+		line += w.style(w.theme.muted, " | ") + w.style(w.theme.text, sanitizeTerminalText(a.Name)) + " " + w.style(w.theme.muted, status)
+	}
+	return line
+}
+
 func sanitizeSlice(in []string) []string {
 	out := make([]string, 0, len(in))
 	for _, s := range in {
@@ -483,9 +827,9 @@ func (w *Writer) formatProg(text string) string {
 	isFailure := strings.HasPrefix(strings.ToLower(strings.TrimSpace(text)), "invocation failed:")
 	if w.colorEnabled() {
 		if isFailure {
-			return fgBold(colorRed, "X "+prefix) + text
+			return fgBold(w.theme.failure, "X "+prefix) + text
 		}
-		return fgBold(colorOrange, prefix) + text
+		return fgBold(w.theme.accent, prefix) + w.theme.severityBadges(text)
 	}
 	if isFailure {
 		return "X " + prefix + text
@@ -495,49 +839,49 @@ func (w *Writer) formatProg(text string) string {
 
 func (w *Writer) formatTool(text string) string {
 	if w.colorEnabled() {
-		return fg(colorDim, "> "+text)
+		return fg(w.theme.muted, "> "+text)
 	}
 	return "> " + text
 }
 
 func (w *Writer) formatToolResult(text string) string {
 	if w.colorEnabled() {
-		return fg(colorDimmer, text)
+		return fg(w.theme.subtle, text)
 	}
 	return text
 }
 
 func (w *Writer) formatReview(text string) string {
 	if w.colorEnabled() {
-		return fg(colorCyan, text)
+		return fg(w.theme.info, text)
 	}
 	return text
 }
 
 func (w *Writer) formatDiffAdd(text string) string {
 	if w.colorEnabled() {
-		return fg(colorGreen, text)
+		return fg(w.theme.diffAdd, text)
 	}
 	return text
 }
 
 func (w *Writer) formatDiffDel(text string) string {
 	if w.colorEnabled() {
-		return fg(colorRed, text)
+		return fg(w.theme.diffDel, text)
 	}
 	return text
 }
 
 func (w *Writer) formatDiffCtx(text string) string {
 	if w.colorEnabled() {
-		return fg(colorDim, text)
+		return fg(w.theme.diffCtx, text)
 	}
 	return text
 }
 
 func (w *Writer) formatDiffHunk(text string) string {
 	if w.colorEnabled() {
-		return fg(colorCyan, text)
+		return fg(w.theme.diffHunk, text)
 	}
 	return text
 }
@@ -564,7 +908,7 @@ func (w *Writer) formatIterSep(text string) string {
 func (w *Writer) formatIterationHeader(iter, maxIter string) string {
 	line := strings.Repeat("─", 36)
 	if w.colorEnabled() {
-		return dim(line) + "\n  " + dim("Iteration ") + fgBold(colorWhite, iter) + dim("/"+maxIter)
+		return w.theme.dim(line) + "\n  " + w.theme.dim("Iteration ") + fgBold(w.theme.text, iter) + w.theme.dim("/"+maxIter)
 	}
 	return "── Iteration " + iter + "/" + maxIter + " ──"
 }
@@ -581,24 +925,24 @@ func (w *Writer) formatStartBanner(text string) string {
 		case trimmed == "":
 			// Preserve empty lines.
 		case strings.HasPrefix(trimmed, "──"):
-			lines[i] = dim(line)
+			lines[i] = w.theme.dim(line)
 		case trimmed == "[programmator]":
-			lines[i] = fgBold(colorOrange, trimmed)
+			lines[i] = fgBold(w.theme.accent, trimmed)
 		case strings.HasPrefix(line, "Starting "):
 			lines[i] = w.colorizeStartingLine(line)
 		case strings.Contains(trimmed, "✓"):
 			before, after, _ := strings.Cut(line, "✓")
-			lines[i] = dim(before) + fg(colorGreen, "✓") + dim(after)
+			lines[i] = w.theme.dim(before) + fg(w.theme.success, "✓") + w.theme.dim(after)
 		case strings.Contains(trimmed, "→"):
 			before, after, _ := strings.Cut(line, "→")
 			name := strings.TrimSpace(after)
-			lines[i] = dim(before) + fgBold(colorOrange, "→") + " " + fgBold(colorWhite, name)
+			lines[i] = w.theme.dim(before) + fgBold(w.theme.accent, "→") + " " + fgBold(w.theme.text, name)
 		case strings.Contains(trimmed, "○"):
-			lines[i] = dim(line)
+			lines[i] = w.theme.dim(line)
 		case strings.HasSuffix(trimmed, ":"):
-			lines[i] = dim(line)
+			lines[i] = w.theme.dim(line)
 		default:
-			lines[i] = bold(line)
+			lines[i] = w.theme.bold(line)
 		}
 	}
 	return strings.Join(lines, "\n")
@@ -612,20 +956,20 @@ func (w *Writer) colorizeStartingLine(line string) string {
 	// Parse "Starting <type> <id>: <title>"
 	const prefix = "Starting "
 	if !strings.HasPrefix(line, prefix) {
-		return bold(line)
+		return w.theme.bold(line)
 	}
 
 	rest := line[len(prefix):]
 	srcType, remainder, found := strings.Cut(rest, " ")
 	if !found {
-		return bold(line)
+		return w.theme.bold(line)
 	}
 
 	id, title, hasTitle := strings.Cut(remainder, ": ")
 	if !hasTitle {
-		return dim("Starting "+srcType+" ") + fgBold(colorMagenta, remainder)
+		return w.theme.dim("Starting "+srcType+" ") + fgBold(w.theme.highlight, remainder)
 	}
-	return dim("Starting "+srcType+" ") + fgBold(colorMagenta, id) + dim(": ") + fgBold(colorWhite, title)
+	return w.theme.dim("Starting "+srcType+" ") + fgBold(w.theme.highlight, id) + w.theme.dim(": ") + fgBold(w.theme.text, title)
 }
 
 // style wraps text with 256-color foreground in TTY mode, plain otherwise.
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"testing"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/alexander-akhmetov/programmator/internal/domain"
 	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/review"
 	"github.com/alexander-akhmetov/programmator/internal/safety"
 )
 
@@ -22,6 +25,8 @@ func newTestWriter(buf *bytes.Buffer) *Writer {
 		isTTY: false,
 		width: 80,
 		mu:    sync.Mutex{},
+		theme: defaultTheme(),
+		keys:  defaultKeyBindings(),
 	}
 }
 
@@ -31,6 +36,8 @@ func newTestWriterTTY(buf *bytes.Buffer) *Writer {
 		isTTY: true,
 		width: 80,
 		mu:    sync.Mutex{},
+		theme: defaultTheme(),
+		keys:  defaultKeyBindings(),
 	}
 }
 
@@ -41,6 +48,8 @@ func newTestWriterTTYWithHeight(buf *bytes.Buffer, height int) *Writer {
 		width:  80,
 		height: height,
 		mu:     sync.Mutex{},
+		theme:  defaultTheme(),
+		keys:   defaultKeyBindings(),
 	}
 }
 
@@ -365,6 +374,152 @@ func TestUpdateFooter_PhaseOnSecondLineAndPIDOnFirst(t *testing.T) {
 	assert.Contains(t, w.lastFooter[2], fmt.Sprintf("\033[38;5;%dm", colorDimmer))
 }
 
+func TestWriter_KeyHandlers(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriterTTY(&buf)
+
+	skipped, interrupted := 0, 0
+	w.SetKeyHandlers(func() { skipped++ }, func() { interrupted++ })
+
+	w.handleKey("s")
+	w.handleKey("x")
+	w.handleKey("ctrl+c")
+	assert.Equal(t, 1, skipped)
+	assert.Equal(t, 1, interrupted)
+
+	item := &domain.WorkItem{ID: "keys", Phases: []domain.Phase{{Name: "Optional task"}}}
+	w.UpdateFooter(nil, item, safety.Config{MaxIterations: 10})
+	footer := stripANSISequences(strings.Join(w.lastFooter, "\n"))
+	assert.Contains(t, footer, "Working on: Optional task  (s: skip phase)")
+
+	w.SetReviewIssues([]*review.Result{{AgentName: "quality", Issues: []review.Issue{{Description: "Leak"}}}})
+	w.UpdateFooter(nil, item, safety.Config{MaxIterations: 10})
+	footer = stripANSISequences(strings.Join(w.lastFooter, "\n"))
+	assert.Contains(t, footer, "Working on: Optional task  (s: skip phase, i: review issues)")
+}
+
+func TestUpdateFooter_NestedPhasePath(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriterTTY(&buf)
+
+	item := &domain.WorkItem{ID: "nested", Phases: []domain.Phase{
+		{Name: "Backend"},
+		{Name: "Schema", Depth: 1, Completed: true},
+		{Name: "API", Depth: 1},
+	}}
+	w.UpdateFooter(nil, item, safety.Config{MaxIterations: 10})
+	footer := stripANSISequences(strings.Join(w.lastFooter, "\n"))
+	assert.Contains(t, footer, "Working on: Backend › API")
+}
+
+func TestUpdateFooter_ReviewProgress(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriterTTY(&buf)
+
+	item := &domain.WorkItem{ID: "plan", Phases: []domain.Phase{{Name: "Task 1", Completed: true}}}
+	state := safety.NewState()
+	state.Review = &safety.ReviewProgress{Phase: "agents, step 2/3", Agents: []safety.AgentProgress{
+		{Name: "bugs", Elapsed: 95 * time.Second, Done: true},
+		{Name: "security", Elapsed: 12 * time.Second},
+	}}
+	w.UpdateFooter(state, item, safety.Config{MaxIterations: 10})
+	footer := stripANSISequences(strings.Join(w.lastFooter, "\n"))
+	assert.Contains(t, footer, "Reviewing: agents, step 2/3 | bugs 1m 35s done | security 12s")
+	assert.NotContains(t, footer, "Working on:")
+}
+
+func TestUpdateFooter_ActiveFiles(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriterTTY(&buf)
+
+	item := &domain.WorkItem{ID: "plan", Phases: []domain.Phase{{Name: "Task 1"}}}
+	state := safety.NewState()
+	w.UpdateFooter(state, item, safety.Config{MaxIterations: 10})
+	assert.NotContains(t, stripANSISequences(strings.Join(w.lastFooter, "\n")), "Editing:")
+
+	state.ActiveFiles = []string{"a.go", "b.go", "c.go", "d.go", "e.go"}
+	w.UpdateFooter(state, item, safety.Config{MaxIterations: 10})
+	footer := stripANSISequences(strings.Join(w.lastFooter, "\n"))
+	assert.Contains(t, footer, "Working on: Task 1")
+	assert.Contains(t, footer, "Editing: a.go, b.go, c.go (+2 more)")
+}
+
+func TestWriter_AskQuestions(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriter(&buf)
+	w.in = strings.NewReader("Use Postgres\n")
+
+	answer, err := w.AskQuestions(context.Background(), "Storage backend is unspecified", []string{"Redis or Postgres?"})
+	require.NoError(t, err)
+	assert.Equal(t, "Use Postgres", answer)
+	assert.Contains(t, buf.String(), "BLOCKED: Storage backend is unspecified")
+	assert.Contains(t, buf.String(), "1. Redis or Postgres?")
+
+	// The input has ended.
+	answer, err = w.AskQuestions(context.Background(), "Stuck", []string{"Stuck"})
+	require.NoError(t, err)
+	assert.Empty(t, answer)
+}
+
+func TestWriter_PickPhase(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriter(&buf)
+	candidates := []string{"Add tests for parser", "Add tests for lexer"}
+
+	w.in = strings.NewReader("2\n\n3\n")
+	picked, err := w.PickPhase(context.Background(), "Add tests", candidates)
+	require.NoError(t, err)
+	assert.Equal(t, "Add tests for lexer", picked)
+	assert.Contains(t, buf.String(), `Ambiguous phase: "Add tests" matches several phases`)
+	assert.Contains(t, buf.String(), "1. Add tests for parser")
+
+	picked, err = w.PickPhase(context.Background(), "Add tests", candidates)
+	require.NoError(t, err)
+	assert.Empty(t, picked)
+
+	_, err = w.PickPhase(context.Background(), "Add tests", candidates)
+	require.Error(t, err)
+}
+
+func TestWriter_ApproveSignOff(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriter(&buf)
+	files := []string{"migrations/0042_users.sql"}
+
+	w.in = strings.NewReader("y\n\nn\nlater\n")
+	approved, err := w.ApproveSignOff(context.Background(), "Add users table", files)
+	require.NoError(t, err)
+	assert.True(t, approved)
+	assert.Contains(t, buf.String(), `Sign-off needed: "Add users table" changes high-risk paths`)
+	assert.Contains(t, buf.String(), "migrations/0042_users.sql")
+
+	for range 3 { // "", "n", "later"
+		approved, err = w.ApproveSignOff(context.Background(), "Add users table", files)
+		require.NoError(t, err)
+		assert.False(t, approved)
+	}
+}
+
+func TestWriter_AskQuestionsCanceled(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriter(&buf)
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	w.in = pr
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	answer, err := w.AskQuestions(ctx, "Stuck", []string{"Stuck"})
+	require.NoError(t, err)
+	assert.Empty(t, answer)
+
+	// The next question is answered by the same reader.
+	go func() { _, _ = pw.Write([]byte("Use Redis\n")) }()
+	answer, err = w.AskQuestions(context.Background(), "Stuck again", []string{"Stuck"})
+	require.NoError(t, err)
+	assert.Equal(t, "Use Redis", answer)
+}
+
 func TestWriter_ConcurrentWrites(t *testing.T) {
 	var buf bytes.Buffer
 	w := newTestWriter(&buf)
@@ -1100,3 +1255,42 @@ func stripANSISlice(lines []string) []string {
 	}
 	return out
 }
+
+func TestWriter_AgentActivityPanes(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriterTTY(&buf)
+	w.onSkip = func() {}
+
+	state := safety.NewState()
+	state.Review = &safety.ReviewProgress{Phase: "agents", Agents: []safety.AgentProgress{
+		{Name: "bugs", Elapsed: 5 * time.Second, Done: true},
+		{Name: "security", Elapsed: 12 * time.Second},
+		{Name: "quality", Elapsed: 12 * time.Second},
+	}}
+	w.UpdateFooter(state, nil, safety.Config{MaxIterations: 10})
+	for i := range 7 {
+		w.WriteEvent(event.AgentActivity("security", fmt.Sprintf("Read file%d.go", i)))
+	}
+	w.WriteEvent(event.AgentActivity("bugs", "Read done.go"))
+
+	footer := stripANSISequences(strings.Join(w.lastFooter, "\n"))
+	assert.Contains(t, footer, "▸ 2 security: Read file6.go")
+	assert.NotContains(t, footer, "done.go", "finished agents have no pane")
+	assert.NotContains(t, footer, "quality:", "agents without activity have no pane")
+
+	w.toggleAgentPane(2)
+	footer = stripANSISequences(strings.Join(w.lastFooter, "\n"))
+	assert.Contains(t, footer, "▾ 2 security\n      Read file2.go\n")
+	assert.Contains(t, footer, "      Read file6.go")
+	assert.NotContains(t, footer, "file1.go")
+
+	w.UpdateFooter(safety.NewState(), nil, safety.Config{MaxIterations: 10})
+	assert.Nil(t, w.agentPanes, "panes are dropped between review passes")
+}
+
+func TestWriter_AgentActivityWithoutTTY(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestWriter(&buf)
+	w.WriteEvent(event.AgentActivity("security", "Read main.go"))
+	assert.Empty(t, buf.String())
+}
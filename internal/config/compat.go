@@ -1,15 +1,23 @@
 package config
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 
+	"github.com/alexander-akhmetov/programmator/internal/dirs"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/llm/claude"
 	"github.com/alexander-akhmetov/programmator/internal/llm/codex"
 	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
 	"github.com/alexander-akhmetov/programmator/internal/llm/opencode"
 	"github.com/alexander-akhmetov/programmator/internal/llm/pi"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/notify"
 	"github.com/alexander-akhmetov/programmator/internal/review"
 	"github.com/alexander-akhmetov/programmator/internal/safety"
 )
@@ -18,10 +26,43 @@ import (
 // For Claude, always injects --dangerously-skip-permissions because the
 // permission system has been removed; dcg is the sole safety layer.
 func (c *Config) ToExecutorConfig() executor.Config {
-	return buildExecutorConfig(c.Executor, c.Claude, c.Pi, c.OpenCode, c.Codex)
+	cfg := buildExecutorConfig(c.Executor, c.executorEnv(), c.Claude, c.Pi, c.OpenCode, c.Codex)
+	cfg.ContextOverflow = c.ContextOverflow
+	return cfg
+}
+
+// ToFallbackExecutors returns the executors of the fallback chain, each
+// configured like the main executor with the entry's model.
+func (c *Config) ToFallbackExecutors() []executor.Config {
+	chain := make([]executor.Config, 0, len(c.Fallback.Chain))
+	for _, e := range c.Fallback.Chain {
+		cfg := buildExecutorConfig(cmp.Or(e.Executor, c.Executor), c.executorEnv(), c.Claude, c.Pi, c.OpenCode, c.Codex)
+		cfg.ContextOverflow = c.ContextOverflow
+		chain = append(chain, cfg.WithModel(e.Model, ""))
+	}
+	return chain
+}
+
+// executorEnv returns ExecutorEnv with $VAR references in the values
+// expanded from the environment.
+func (c *Config) executorEnv() map[string]string {
+	if len(c.ExecutorEnv) == 0 {
+		return nil
+	}
+	env := make(map[string]string, len(c.ExecutorEnv))
+	for name, value := range c.ExecutorEnv {
+		env[name] = os.ExpandEnv(value)
+	}
+	return env
 }
 
-func buildExecutorConfig(name string, claudeCfg ClaudeConfig, piCfg PiConfig, opencodeCfg OpenCodeConfig, codexCfg CodexConfig) executor.Config {
+// ExecutorSecrets returns the executor_env values to mask in output and
+// logs: those of variables whose names look like credentials.
+func (c *Config) ExecutorSecrets() []string {
+	return llm.SecretEnvValues(c.executorEnv())
+}
+
+func buildExecutorConfig(name string, env map[string]string, claudeCfg ClaudeConfig, piCfg PiConfig, opencodeCfg OpenCodeConfig, codexCfg CodexConfig) executor.Config {
 	cfg := executor.Config{Name: name}
 
 	switch name {
@@ -31,34 +72,70 @@ func buildExecutorConfig(name string, claudeCfg ClaudeConfig, piCfg PiConfig, op
 			Provider:  piCfg.Provider,
 			Model:     piCfg.Model,
 			APIKey:    piCfg.APIKey,
+			Vars:      env,
 		}
 		cfg.ExtraFlags = strings.Fields(piCfg.Flags)
+		cfg.PurposeFlags = piCfg.PurposeFlags.toExecutor()
+		cfg.ContextWindow = piCfg.ContextWindow
 	case "opencode":
 		cfg.OpenCode = opencode.Config{
 			Model:     opencodeCfg.Model,
 			APIKey:    opencodeCfg.APIKey,
 			ConfigDir: opencodeCfg.ConfigDir,
+			Vars:      env,
 		}
 		cfg.ExtraFlags = strings.Fields(opencodeCfg.Flags)
+		cfg.PurposeFlags = opencodeCfg.PurposeFlags.toExecutor()
+		cfg.ContextWindow = opencodeCfg.ContextWindow
 	case "codex":
 		cfg.Codex = codex.Config{
-			Model:  codexCfg.Model,
-			APIKey: codexCfg.APIKey,
+			Model:   codexCfg.Model,
+			APIKey:  codexCfg.APIKey,
+			Sandbox: codexCfg.Sandbox,
+			Vars:    env,
 		}
 		flags := strings.Fields(codexCfg.Flags)
-		cfg.ExtraFlags = ensureFlag(flags, "--dangerously-bypass-approvals-and-sandbox")
+		if codexCfg.Sandbox == "" {
+			flags = ensureFlag(flags, "--dangerously-bypass-approvals-and-sandbox")
+		}
+		cfg.ExtraFlags = flags
+		cfg.PurposeFlags = codexCfg.PurposeFlags.toExecutor()
+		cfg.ContextWindow = codexCfg.ContextWindow
 	default: // "claude" or ""
 		cfg.Claude = claude.Config{
 			ClaudeConfigDir: claudeCfg.ConfigDir,
 			AnthropicAPIKey: claudeCfg.AnthropicAPIKey,
+			Vars:            env,
 		}
 		flags := strings.Fields(claudeCfg.Flags)
 		cfg.ExtraFlags = ensureFlag(flags, "--dangerously-skip-permissions")
+		cfg.PurposeFlags = claudeCfg.PurposeFlags.toExecutor()
+		cfg.ContextWindow = claudeCfg.ContextWindow
 	}
 
 	return cfg
 }
 
+// toExecutor splits the flag strings of the set purposes.
+func (p PurposeFlags) toExecutor() map[llm.Purpose][]string {
+	byPurpose := map[llm.Purpose]string{
+		llm.PurposeMain:      p.Main,
+		llm.PurposeReview:    p.Review,
+		llm.PurposeValidator: p.Validator,
+		llm.PurposePlanning:  p.Planning,
+	}
+	var out map[llm.Purpose][]string
+	for purpose, flags := range byPurpose {
+		if fields := strings.Fields(flags); len(fields) > 0 {
+			if out == nil {
+				out = make(map[llm.Purpose][]string)
+			}
+			out[purpose] = fields
+		}
+	}
+	return out
+}
+
 func ensureFlag(flags []string, flag string) []string {
 	if slices.Contains(flags, flag) {
 		return flags
@@ -72,26 +149,92 @@ func (c *Config) ToSafetyConfig() safety.Config {
 		MaxIterations:       c.MaxIterations,
 		StagnationLimit:     c.StagnationLimit,
 		Timeout:             c.Timeout,
+		LivenessTimeout:     c.LivenessTimeout,
 		MaxReviewIterations: c.Review.MaxIterations,
 	}
 }
 
+// ToDiffScanner builds the diff scanner from diff_scan settings, or returns
+// nil when scanning is disabled.
+func (c *Config) ToDiffScanner() (*safety.Scanner, error) {
+	if !c.DiffScan.Enabled {
+		return nil, nil
+	}
+	var rules []safety.ScanRule
+	if c.DiffScan.DefaultRules {
+		rules = safety.DefaultScanRules()
+	}
+	rules = append(rules, c.DiffScan.Rules...)
+	return safety.NewScanner(rules)
+}
+
+// ToNotifier builds the notifier for the enabled notification senders. The
+// SMTP password is read from the environment variable named by
+// notifications.email.password_env.
+func (c *Config) ToNotifier() (*notify.Notifier, error) {
+	var senders []notify.Sender
+	if c.Notifications.Desktop.Enabled {
+		senders = append(senders, notify.NewDesktop())
+	}
+	if email := c.Notifications.Email; email.Enabled {
+		var password string
+		if email.PasswordEnv != "" {
+			password = os.Getenv(email.PasswordEnv)
+		}
+		sender, err := notify.NewEmail(notify.EmailConfig{
+			Host:     email.Host,
+			Port:     email.Port,
+			Username: email.Username,
+			Password: password,
+			From:     email.From,
+			To:       email.To,
+		})
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, sender)
+	}
+	return notify.New(c.Notifications.OnExit, senders...), nil
+}
+
+// ToNotesConfig converts notes settings to notes.Config.
+func (c *Config) ToNotesConfig() notes.Config {
+	cfg := notes.Config{Style: c.Notes.toStyle()}
+	if len(c.Notes.Sources) > 0 {
+		cfg.Sources = make(map[string]notes.Style, len(c.Notes.Sources))
+		for sourceType, style := range c.Notes.Sources {
+			cfg.Sources[sourceType] = style.toStyle()
+		}
+	}
+	return cfg
+}
+
+func (s NoteStyleConfig) toStyle() notes.Style {
+	return notes.Style{Level: s.Level, Template: s.Template}
+}
+
 // toReviewExecutorConfig converts review-specific executor settings to executor.Config.
 // It inherits top-level executor settings and applies review.executor overrides.
 func (c *Config) toReviewExecutorConfig() executor.Config {
 	name := c.Executor
+	if c.Review.Executor.Name != "" {
+		name = c.Review.Executor.Name
+	}
+	return c.reviewExecutorConfigFor(name)
+}
+
+// reviewExecutorConfigFor builds the review executor config for the named
+// executor, with the review.executor overrides applied.
+func (c *Config) reviewExecutorConfigFor(name string) executor.Config {
 	claudeCfg := c.Claude
 	piCfg := c.Pi
 	opencodeCfg := c.OpenCode
 	codexCfg := c.Codex
 
-	if c.Review.Executor.Name != "" {
-		name = c.Review.Executor.Name
-	}
-
 	if c.Review.Executor.Claude.Flags != "" {
 		claudeCfg.Flags = c.Review.Executor.Claude.Flags
 	}
+	claudeCfg.PurposeFlags.merge(c.Review.Executor.Claude.PurposeFlags)
 	if c.Review.Executor.Claude.ConfigDir != "" {
 		claudeCfg.ConfigDir = c.Review.Executor.Claude.ConfigDir
 	}
@@ -101,6 +244,7 @@ func (c *Config) toReviewExecutorConfig() executor.Config {
 	if c.Review.Executor.Pi.Flags != "" {
 		piCfg.Flags = c.Review.Executor.Pi.Flags
 	}
+	piCfg.PurposeFlags.merge(c.Review.Executor.Pi.PurposeFlags)
 	if c.Review.Executor.Pi.ConfigDir != "" {
 		piCfg.ConfigDir = c.Review.Executor.Pi.ConfigDir
 	}
@@ -116,6 +260,7 @@ func (c *Config) toReviewExecutorConfig() executor.Config {
 	if c.Review.Executor.OpenCode.Flags != "" {
 		opencodeCfg.Flags = c.Review.Executor.OpenCode.Flags
 	}
+	opencodeCfg.PurposeFlags.merge(c.Review.Executor.OpenCode.PurposeFlags)
 	if c.Review.Executor.OpenCode.ConfigDir != "" {
 		opencodeCfg.ConfigDir = c.Review.Executor.OpenCode.ConfigDir
 	}
@@ -128,6 +273,7 @@ func (c *Config) toReviewExecutorConfig() executor.Config {
 	if c.Review.Executor.Codex.Flags != "" {
 		codexCfg.Flags = c.Review.Executor.Codex.Flags
 	}
+	codexCfg.PurposeFlags.merge(c.Review.Executor.Codex.PurposeFlags)
 	if c.Review.Executor.Codex.Model != "" {
 		codexCfg.Model = c.Review.Executor.Codex.Model
 	}
@@ -135,7 +281,7 @@ func (c *Config) toReviewExecutorConfig() executor.Config {
 		codexCfg.APIKey = c.Review.Executor.Codex.APIKey
 	}
 
-	return buildExecutorConfig(name, claudeCfg, piCfg, opencodeCfg, codexCfg)
+	return buildExecutorConfig(name, c.executorEnv(), claudeCfg, piCfg, opencodeCfg, codexCfg)
 }
 
 func cloneAgentConfig(a review.AgentConfig) review.AgentConfig {
@@ -160,6 +306,15 @@ func (c *Config) resolveReviewAgents() ([]review.AgentConfig, error) {
 			if agent.Prompt != "" && agent.PromptFile != "" {
 				return nil, fmt.Errorf("review.agents[%s]: prompt and prompt_file are mutually exclusive", agent.Name)
 			}
+			if agent.MinSeverity != "" && !review.ValidSeverity(agent.MinSeverity) {
+				return nil, fmt.Errorf("review.agents[%s]: unknown min_severity %q", agent.Name, agent.MinSeverity)
+			}
+			if agent.Effort != "" && !llm.ValidEffort(agent.Effort) {
+				return nil, fmt.Errorf("review.agents[%s]: unknown effort %q (supported: low, medium, high)", agent.Name, agent.Effort)
+			}
+			if err := agent.When.Validate(); err != nil {
+				return nil, fmt.Errorf("review.agents[%s].when: %w", agent.Name, err)
+			}
 			custom = append(custom, cloneAgentConfig(agent))
 		}
 		return custom, nil
@@ -223,6 +378,15 @@ func (c *Config) resolveReviewAgents() ([]review.AgentConfig, error) {
 			if override.Prompt != "" && override.PromptFile != "" {
 				return nil, fmt.Errorf("review.overrides[%s]: prompt and prompt_file are mutually exclusive", override.Name)
 			}
+			if override.MinSeverity != "" && !review.ValidSeverity(override.MinSeverity) {
+				return nil, fmt.Errorf("review.overrides[%s]: unknown min_severity %q", override.Name, override.MinSeverity)
+			}
+			if override.Effort != "" && !llm.ValidEffort(override.Effort) {
+				return nil, fmt.Errorf("review.overrides[%s]: unknown effort %q (supported: low, medium, high)", override.Name, override.Effort)
+			}
+			if err := override.When.Validate(); err != nil {
+				return nil, fmt.Errorf("review.overrides[%s].when: %w", override.Name, err)
+			}
 
 			i, ok := index[override.Name]
 			if !ok {
@@ -241,6 +405,18 @@ func (c *Config) resolveReviewAgents() ([]review.AgentConfig, error) {
 				merged.PromptFile = override.PromptFile
 				merged.Prompt = ""
 			}
+			if override.MinSeverity != "" {
+				merged.MinSeverity = override.MinSeverity
+			}
+			if !override.When.IsZero() {
+				merged.When = override.When
+			}
+			if override.Model != "" {
+				merged.Model = override.Model
+			}
+			if override.Effort != "" {
+				merged.Effort = override.Effort
+			}
 			selected[i] = merged
 		}
 	}
@@ -254,14 +430,68 @@ func (c *Config) ToReviewConfig() (review.Config, error) {
 	if err != nil {
 		return review.Config{}, err
 	}
+	for i, step := range c.Review.Schedule {
+		if err := step.Validate(agents); err != nil {
+			return review.Config{}, fmt.Errorf("review.schedule[%d]: %w", i, err)
+		}
+	}
 
-	return review.Config{
-		MaxIterations:           c.Review.MaxIterations,
-		Parallel:                c.Review.Parallel,
-		Timeout:                 c.Timeout,
-		Agents:                  agents,
-		ExecutorConfig:          c.toReviewExecutorConfig(),
-		ValidateIssues:          c.Review.Validators.Issue,
-		ValidateSimplifications: c.Review.Validators.Simplification,
-	}, nil
+	cfg := review.Config{
+		MaxIterations:               c.Review.MaxIterations,
+		Parallel:                    c.Review.Parallel,
+		Timeout:                     c.Timeout,
+		Agents:                      agents,
+		ExecutorConfig:              c.toReviewExecutorConfig(),
+		ValidateIssues:              c.Review.Validators.Issue,
+		ValidateSimplifications:     c.Review.Validators.Simplification,
+		IssueValidatorWhen:          c.Review.Validators.IssueWhen,
+		SimplificationValidatorWhen: c.Review.Validators.SimplificationWhen,
+		ValidatorTimeout:            c.Review.Validators.Timeout,
+		IncludeDiff:                 c.Review.IncludeDiff,
+		DiffLimit:                   c.Review.DiffLimit,
+		FixExcerptLines:             c.Review.FixExcerptLines,
+		FixGroupBy:                  c.Review.FixGroupBy,
+		FixMaxIssues:                c.Review.FixMaxIssues,
+		FixSnippetLines:             c.Review.FixSnippetLines,
+		ScoreThreshold:              c.Review.ScoreThreshold,
+		SuggestPatches:              c.Review.Patches.Suggest,
+		AutoApplyPatches:            c.Review.Patches.AutoApply,
+		SanitizeInputs:              c.Review.Injection.Sanitize,
+		InjectionDetector:           c.Review.Injection.Detector,
+		Schedule:                    c.Review.Schedule,
+		CodeOwners:                  c.Review.CodeOwners,
+		FileDeferred:                c.Review.FileDeferred,
+	}
+	if len(c.Review.Consensus.Executors) >= 2 {
+		for _, name := range c.Review.Consensus.Executors {
+			cfg.ConsensusExecutors = append(cfg.ConsensusExecutors, c.reviewExecutorConfigFor(name))
+		}
+		cfg.ConsensusMode = c.Review.Consensus.Mode
+	}
+	if rc := c.Review.Remote; rc.Host != "" {
+		// One runner for all agents, so concurrent agents share its syncs.
+		runner := llm.NewRemoteRunner(llm.RemoteConfig{
+			Host:     rc.Host,
+			Dir:      rc.Dir,
+			Sync:     rc.Sync,
+			SSHFlags: rc.SSHFlags,
+			Forward:  slices.Sorted(maps.Keys(c.ExecutorEnv)),
+		})
+		cfg.ExecutorConfig.Runner = runner
+		for i := range cfg.ConsensusExecutors {
+			cfg.ConsensusExecutors[i].Runner = runner
+		}
+	}
+	if c.Review.Recurring.Enabled {
+		cfg.HistoryDir = filepath.Join(dirs.StateDir(), "review-history")
+		cfg.EscalateRecurringAfter = c.Review.Recurring.EscalateAfter
+	}
+	if c.Review.RepoSummary {
+		cfg.SummaryDir = filepath.Join(dirs.StateDir(), "review-summaries")
+	}
+	cfg.ContextFiles = c.Review.ContextFiles
+	cfg.FinalCheck = c.Review.FinalCheck
+	cfg.AcceptanceCheck = c.Review.AcceptanceCheck
+	cfg.ValidationCommands = c.ValidationCommands
+	return cfg, nil
 }
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"path/filepath"
 	"testing"
 
+	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/review"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,6 +15,7 @@ func TestToSafetyConfig(t *testing.T) {
 		MaxIterations:   100,
 		StagnationLimit: 5,
 		Timeout:         600,
+		LivenessTimeout: 300,
 		Executor:        "claude",
 		Review: ReviewConfig{
 			MaxIterations: 10,
@@ -23,6 +26,7 @@ func TestToSafetyConfig(t *testing.T) {
 	assert.Equal(t, 100, sc.MaxIterations)
 	assert.Equal(t, 5, sc.StagnationLimit)
 	assert.Equal(t, 600, sc.Timeout)
+	assert.Equal(t, 300, sc.LivenessTimeout)
 	assert.Equal(t, 10, sc.MaxReviewIterations)
 }
 
@@ -44,6 +48,28 @@ func TestToExecutorConfig_Claude(t *testing.T) {
 	assert.Equal(t, "test-key", ec.Claude.AnthropicAPIKey)
 }
 
+func TestToFallbackExecutors(t *testing.T) {
+	cfg := &Config{
+		Executor:        "claude",
+		Claude:          ClaudeConfig{Flags: "--verbose"},
+		Codex:           CodexConfig{Model: "gpt-5-codex"},
+		ContextOverflow: "truncate",
+		Fallback: FallbackConfig{After: 2, Chain: []FallbackEntry{
+			{Model: "sonnet"},
+			{Executor: "codex"},
+		}},
+	}
+
+	chain := cfg.ToFallbackExecutors()
+	require.Len(t, chain, 2)
+	assert.Equal(t, "claude", chain[0].Name)
+	assert.Equal(t, "sonnet", chain[0].Claude.Model)
+	assert.Equal(t, []string{"--verbose", "--dangerously-skip-permissions"}, chain[0].ExtraFlags)
+	assert.Equal(t, "truncate", chain[0].ContextOverflow)
+	assert.Equal(t, "codex", chain[1].Name)
+	assert.Equal(t, "gpt-5-codex", chain[1].Codex.Model, "the executor's configured model is kept")
+}
+
 func TestToExecutorConfig_Claude_YAMLConfigDir(t *testing.T) {
 	t.Setenv("CLAUDE_CONFIG_DIR", "/from/env")
 	cfg := &Config{
@@ -144,6 +170,53 @@ func TestToExecutorConfig_Codex(t *testing.T) {
 	assert.Contains(t, ec.ExtraFlags, "--dangerously-bypass-approvals-and-sandbox")
 }
 
+func TestToExecutorConfig_CodexSandbox(t *testing.T) {
+	cfg := &Config{
+		Executor: "codex",
+		Codex:    CodexConfig{Flags: "--verbose", Sandbox: "workspace-write"},
+	}
+
+	ec := cfg.ToExecutorConfig()
+	assert.Equal(t, "workspace-write", ec.Codex.Sandbox)
+	assert.Equal(t, []string{"--verbose"}, ec.ExtraFlags, "a sandbox mode replaces the bypass flag")
+}
+
+func TestToExecutorConfig_ContextWindow(t *testing.T) {
+	cfg := &Config{
+		Executor:        "codex",
+		Claude:          ClaudeConfig{ContextWindow: 200000},
+		Codex:           CodexConfig{ContextWindow: 400000},
+		ContextOverflow: "truncate",
+	}
+	ec := cfg.ToExecutorConfig()
+	assert.Equal(t, 400000, ec.ContextWindow, "the selected executor's window")
+	assert.Equal(t, "truncate", ec.ContextOverflow)
+
+	cfg.Executor = "claude"
+	assert.Equal(t, 200000, cfg.ToExecutorConfig().ContextWindow)
+}
+
+func TestToExecutorConfig_ExecutorEnv(t *testing.T) {
+	t.Setenv("STAGING_TOKEN", "t0k")
+	cfg := &Config{
+		Executor: "pi",
+		ExecutorEnv: map[string]string{
+			"API_URL":       "https://staging",
+			"API_TOKEN":     "${STAGING_TOKEN}",
+			"FEATURE_CACHE": "1",
+		},
+	}
+
+	want := map[string]string{"API_URL": "https://staging", "API_TOKEN": "t0k", "FEATURE_CACHE": "1"}
+	assert.Equal(t, want, cfg.ToExecutorConfig().Pi.Vars, "values expand from the environment")
+	assert.Equal(t, []string{"t0k"}, cfg.ExecutorSecrets())
+
+	cfg.Executor = "claude"
+	assert.Equal(t, want, cfg.ToExecutorConfig().Claude.Vars)
+	cfg.Review.Executor.Name = "codex"
+	assert.Equal(t, want, cfg.toReviewExecutorConfig().Codex.Vars)
+}
+
 func TestToExecutorConfig_Codex_DangerousFlagIdempotent(t *testing.T) {
 	cfg := &Config{
 		Executor: "codex",
@@ -201,6 +274,123 @@ func TestToReviewConfig_UsesReviewExecutorOpenCode(t *testing.T) {
 	assert.Equal(t, "openai/gpt-4o", rc.ExecutorConfig.OpenCode.Model)
 }
 
+func TestToReviewConfig_RecurringHistory(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("PROGRAMMATOR_STATE_DIR", stateDir)
+
+	cfg := &Config{Review: ReviewConfig{Recurring: ReviewRecurringConfig{Enabled: true, EscalateAfter: 2}}}
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(stateDir, "review-history"), rc.HistoryDir)
+	assert.Equal(t, 2, rc.EscalateRecurringAfter)
+
+	cfg.Review.Recurring.Enabled = false
+	rc, err = cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.Empty(t, rc.HistoryDir)
+	assert.Zero(t, rc.EscalateRecurringAfter)
+}
+
+func TestToReviewConfig_RepoSummary(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("PROGRAMMATOR_STATE_DIR", stateDir)
+
+	cfg := &Config{Review: ReviewConfig{RepoSummary: true}}
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(stateDir, "review-summaries"), rc.SummaryDir)
+
+	cfg.Review.RepoSummary = false
+	rc, err = cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.Empty(t, rc.SummaryDir)
+}
+
+func TestToReviewConfig_FinalCheck(t *testing.T) {
+	cfg := &Config{
+		ValidationCommands: []string{"go test ./..."},
+		Review:             ReviewConfig{FinalCheck: true, AcceptanceCheck: true},
+	}
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.True(t, rc.FinalCheck)
+	assert.True(t, rc.AcceptanceCheck)
+	assert.Equal(t, []string{"go test ./..."}, rc.ValidationCommands)
+}
+
+func TestToReviewConfig_Patches(t *testing.T) {
+	cfg := &Config{Review: ReviewConfig{Patches: ReviewPatchesConfig{Suggest: true, AutoApply: true}}}
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.True(t, rc.SuggestPatches)
+	assert.True(t, rc.AutoApplyPatches)
+}
+
+func TestToReviewConfig_Schedule(t *testing.T) {
+	cfg := &Config{Review: ReviewConfig{Schedule: []review.StrictnessStep{
+		{MinSeverity: review.SeverityCritical, Agents: []string{"bug-deep"}},
+		{},
+	}}}
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Review.Schedule, rc.Schedule)
+
+	cfg.Review.Exclude = []string{"bug-deep"}
+	_, err = cfg.ToReviewConfig()
+	require.ErrorContains(t, err, `review.schedule[0]: unknown review agent "bug-deep"`)
+
+	cfg.Review.Exclude = nil
+	cfg.Review.Schedule[1].MinSeverity = "blocker"
+	_, err = cfg.ToReviewConfig()
+	require.ErrorContains(t, err, `review.schedule[1]: unknown min_severity "blocker"`)
+}
+
+func TestToReviewConfig_Consensus(t *testing.T) {
+	cfg := &Config{
+		Executor: "claude",
+		Codex:    CodexConfig{Model: "gpt-5"},
+		Review: ReviewConfig{
+			Executor:  ReviewExecutorConfig{Codex: CodexConfig{Model: "o3"}},
+			Consensus: ReviewConsensusConfig{Executors: []string{"claude", "codex"}, Mode: "union"},
+		},
+	}
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	require.Len(t, rc.ConsensusExecutors, 2)
+	assert.Equal(t, "claude", rc.ConsensusExecutors[0].Name)
+	assert.Equal(t, "codex", rc.ConsensusExecutors[1].Name)
+	assert.Equal(t, "o3", rc.ConsensusExecutors[1].Codex.Model, "review.executor overrides apply")
+	assert.Equal(t, "union", rc.ConsensusMode)
+	assert.Equal(t, "claude", rc.ExecutorConfig.Name, "validators keep the review executor")
+
+	cfg.Review.Consensus.Executors = nil
+	rc, err = cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.Empty(t, rc.ConsensusExecutors)
+}
+
+func TestToReviewConfig_Remote(t *testing.T) {
+	cfg := &Config{
+		Executor: "claude",
+		Review: ReviewConfig{
+			Consensus: ReviewConsensusConfig{Executors: []string{"claude", "codex"}},
+			Remote:    ReviewRemoteConfig{Host: "devbox", Dir: "/src/app"},
+		},
+	}
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	require.NotNil(t, rc.ExecutorConfig.Runner)
+	require.Len(t, rc.ConsensusExecutors, 2)
+	assert.Same(t, rc.ExecutorConfig.Runner, rc.ConsensusExecutors[0].Runner, "agents share one runner")
+	assert.Same(t, rc.ExecutorConfig.Runner, rc.ConsensusExecutors[1].Runner)
+	assert.Equal(t, "/src/app", rc.ExecutorConfig.Runner.Dir("/home/me/app"))
+
+	cfg.Review.Remote.Host = ""
+	rc, err = cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.Nil(t, rc.ExecutorConfig.Runner)
+}
+
 func TestToReviewConfig_WithCustomAgents(t *testing.T) {
 	t.Setenv("CLAUDE_CONFIG_DIR", "")
 	cfg := &Config{
@@ -280,6 +470,24 @@ func TestToReviewConfig_DefaultAgentOverride(t *testing.T) {
 	assert.Equal(t, "my_prompt.md", found.PromptFile)
 }
 
+func TestToReviewConfig_SizeRules(t *testing.T) {
+	cfg := &Config{
+		Review: ReviewConfig{
+			Include:    []string{"bug-shallow", "architect"},
+			Overrides:  []review.AgentConfig{{Name: "architect", When: review.SizeRule{MinLines: 200}}},
+			Validators: ReviewValidatorsConfig{Issue: true, IssueWhen: review.SizeRule{MinFiles: 2}},
+		},
+	}
+
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	require.Len(t, rc.Agents, 2)
+	assert.True(t, rc.Agents[0].When.IsZero())
+	assert.Equal(t, review.SizeRule{MinLines: 200}, rc.Agents[1].When)
+	assert.Equal(t, review.SizeRule{MinFiles: 2}, rc.IssueValidatorWhen)
+	assert.True(t, rc.HasSizeRules())
+}
+
 func TestToReviewConfig_UsesReviewExecutorOverride(t *testing.T) {
 	cfg := &Config{
 		Executor: "pi",
@@ -305,6 +513,64 @@ func TestToReviewConfig_UsesReviewExecutorOverride(t *testing.T) {
 	assert.Contains(t, rc.ExecutorConfig.ExtraFlags, "--dangerously-skip-permissions")
 }
 
+func TestToExecutorConfig_PurposeFlags(t *testing.T) {
+	cfg := &Config{
+		Executor: "claude",
+		Claude: ClaudeConfig{
+			Flags: "--verbose",
+			PurposeFlags: PurposeFlags{
+				Review:   "--model opus",
+				Planning: "--max-turns 5",
+			},
+		},
+	}
+
+	ec := cfg.ToExecutorConfig()
+	assert.Equal(t, []string{"--verbose", "--dangerously-skip-permissions"}, ec.FlagsFor(llm.PurposeMain))
+	assert.Equal(t, []string{"--verbose", "--dangerously-skip-permissions", "--model", "opus"}, ec.FlagsFor(llm.PurposeReview))
+	assert.Equal(t, []string{"--verbose", "--dangerously-skip-permissions", "--max-turns", "5"}, ec.FlagsFor(llm.PurposePlanning))
+	assert.NotContains(t, ec.PurposeFlags, llm.PurposeValidator)
+}
+
+func TestToReviewConfig_ReviewExecutorPurposeFlags(t *testing.T) {
+	cfg := &Config{
+		Executor: "claude",
+		Claude: ClaudeConfig{
+			PurposeFlags: PurposeFlags{Review: "--model sonnet", Validator: "--model haiku"},
+		},
+		Review: ReviewConfig{
+			Executor: ReviewExecutorConfig{
+				Claude: ClaudeConfig{PurposeFlags: PurposeFlags{Review: "--model opus"}},
+			},
+		},
+	}
+
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--model", "opus"}, rc.ExecutorConfig.PurposeFlags[llm.PurposeReview])
+	assert.Equal(t, []string{"--model", "haiku"}, rc.ExecutorConfig.PurposeFlags[llm.PurposeValidator])
+}
+
+func TestToReviewConfig_AgentModels(t *testing.T) {
+	cfg := &Config{
+		Review: ReviewConfig{
+			Include: []string{"bug-deep", "comments"},
+			Overrides: []review.AgentConfig{
+				{Name: "bug-deep", Model: "opus", Effort: "high"},
+				{Name: "comments", Model: "haiku"},
+			},
+		},
+	}
+
+	rc, err := cfg.ToReviewConfig()
+	require.NoError(t, err)
+	require.Len(t, rc.Agents, 2)
+	assert.Equal(t, "opus", rc.Agents[0].Model)
+	assert.Equal(t, "high", rc.Agents[0].Effort)
+	assert.Equal(t, "haiku", rc.Agents[1].Model)
+	assert.Empty(t, rc.Agents[1].Effort)
+}
+
 func TestToReviewConfig_ValidationErrors(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -345,6 +611,33 @@ func TestToReviewConfig_ValidationErrors(t *testing.T) {
 			},
 			wantErr: "mutually exclusive",
 		},
+		{
+			name: "rejects unknown min_severity",
+			cfg: &Config{
+				Review: ReviewConfig{
+					Overrides: []review.AgentConfig{{Name: "bug-deep", MinSeverity: "urgent"}},
+				},
+			},
+			wantErr: "unknown min_severity",
+		},
+		{
+			name: "rejects unknown effort",
+			cfg: &Config{
+				Review: ReviewConfig{
+					Agents: []review.AgentConfig{{Name: "custom", Effort: "max"}},
+				},
+			},
+			wantErr: `review.agents[custom]: unknown effort "max" (supported: low, medium, high)`,
+		},
+		{
+			name: "rejects inverted size rule",
+			cfg: &Config{
+				Review: ReviewConfig{
+					Overrides: []review.AgentConfig{{Name: "architect", When: review.SizeRule{MinLines: 50, MaxLines: 10}}},
+				},
+			},
+			wantErr: "review.overrides[architect].when: min_lines 50 is above max_lines 10",
+		},
 	}
 
 	for _, tc := range tests {
@@ -1,23 +1,44 @@
 // Package config provides unified configuration management for programmator.
 // Configuration is loaded from multiple sources with the following precedence:
-// embedded defaults → global file → local file → CLI flags
+// embedded defaults → global file → project file → local file → profile → CLI flags
 package config
 
 import (
+	"cmp"
 	"embed"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/alexander-akhmetov/programmator/internal/cron"
 	"github.com/alexander-akhmetov/programmator/internal/dirs"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/llm/codex"
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/messages"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
 	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/ticket"
 	"gopkg.in/yaml.v3"
 )
 
 //go:embed defaults/config.yaml
 var defaultsFS embed.FS
 
+// ProjectConfigFile is the name of the repo-level config file. It is meant to
+// be committed so a team shares the same programmator behavior.
+const ProjectConfigFile = ".programmator.yaml"
+
+// ProfileEnv names a config profile applied on top of the local config: a
+// path to a YAML file, or a name resolved to profiles/<name>.yaml in the
+// global config directory. The daemon sets it for schedules with a profile.
+const ProfileEnv = "PROGRAMMATOR_PROFILE"
+
 // validExecutors is the set of supported executor names.
 var validExecutors = map[string]bool{
 	"claude":   true,
@@ -29,33 +50,67 @@ var validExecutors = map[string]bool{
 
 // ClaudeConfig holds Claude executor configuration.
 type ClaudeConfig struct {
-	Flags           string `yaml:"flags"`
-	ConfigDir       string `yaml:"config_dir"`
-	AnthropicAPIKey string `yaml:"anthropic_api_key"`
+	Flags           string       `yaml:"flags"`
+	PurposeFlags    PurposeFlags `yaml:"purpose_flags"`
+	ConfigDir       string       `yaml:"config_dir"`
+	AnthropicAPIKey string       `yaml:"anthropic_api_key"`
+	ContextWindow   int          `yaml:"context_window"` // tokens; 0 = no prompt size check
 }
 
 // PiConfig holds pi coding agent executor configuration.
 type PiConfig struct {
-	Flags     string `yaml:"flags"`
-	ConfigDir string `yaml:"config_dir"`
-	Provider  string `yaml:"provider"`
-	Model     string `yaml:"model"`
-	APIKey    string `yaml:"api_key"`
+	Flags         string       `yaml:"flags"`
+	PurposeFlags  PurposeFlags `yaml:"purpose_flags"`
+	ConfigDir     string       `yaml:"config_dir"`
+	Provider      string       `yaml:"provider"`
+	Model         string       `yaml:"model"`
+	APIKey        string       `yaml:"api_key"`
+	ContextWindow int          `yaml:"context_window"`
 }
 
 // OpenCodeConfig holds OpenCode executor configuration.
 type OpenCodeConfig struct {
-	Flags     string `yaml:"flags"`
-	Model     string `yaml:"model"`
-	APIKey    string `yaml:"api_key"`
-	ConfigDir string `yaml:"config_dir"`
+	Flags         string       `yaml:"flags"`
+	PurposeFlags  PurposeFlags `yaml:"purpose_flags"`
+	Model         string       `yaml:"model"`
+	APIKey        string       `yaml:"api_key"`
+	ConfigDir     string       `yaml:"config_dir"`
+	ContextWindow int          `yaml:"context_window"`
 }
 
 // CodexConfig holds Codex executor configuration.
 type CodexConfig struct {
-	Flags  string `yaml:"flags"`
-	Model  string `yaml:"model"`
-	APIKey string `yaml:"api_key"`
+	Flags         string       `yaml:"flags"`
+	PurposeFlags  PurposeFlags `yaml:"purpose_flags"`
+	Model         string       `yaml:"model"`
+	APIKey        string       `yaml:"api_key"`
+	ContextWindow int          `yaml:"context_window"`
+	Sandbox       string       `yaml:"sandbox"` // --sandbox of main loop invocations, reviews read-only ("" = no sandbox)
+}
+
+// PurposeFlags holds executor flags added, after the executor's flags, to
+// invocations of one purpose only.
+type PurposeFlags struct {
+	Main      string `yaml:"main"`      // main loop iterations
+	Review    string `yaml:"review"`    // review agents
+	Validator string `yaml:"validator"` // review issue and simplification validators
+	Planning  string `yaml:"planning"`  // phase planning, splitting, and supervision
+}
+
+// merge overrides the purposes set in src.
+func (p *PurposeFlags) merge(src PurposeFlags) {
+	if src.Main != "" {
+		p.Main = src.Main
+	}
+	if src.Review != "" {
+		p.Review = src.Review
+	}
+	if src.Validator != "" {
+		p.Validator = src.Validator
+	}
+	if src.Planning != "" {
+		p.Planning = src.Planning
+	}
 }
 
 // ReviewExecutorConfig holds review-specific executor overrides.
@@ -69,8 +124,45 @@ type ReviewExecutorConfig struct {
 
 // ReviewValidatorsConfig controls validation passes that run after review agents within each iteration.
 type ReviewValidatorsConfig struct {
-	Issue          bool `yaml:"issue"`
-	Simplification bool `yaml:"simplification"`
+	Issue              bool            `yaml:"issue"`
+	Simplification     bool            `yaml:"simplification"`
+	IssueWhen          review.SizeRule `yaml:"issue_when"`          // run the issue validator only for changes of this size
+	SimplificationWhen review.SizeRule `yaml:"simplification_when"` // run the simplification validator only for changes of this size
+	Timeout            int             `yaml:"timeout"`             // seconds per validator invocation (0 = the executor timeout only)
+}
+
+// ReviewRecurringConfig controls tracking of findings that repeat across runs.
+type ReviewRecurringConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	EscalateAfter int  `yaml:"escalate_after"`
+}
+
+// ReviewPatchesConfig controls patches suggested by review agents.
+type ReviewPatchesConfig struct {
+	Suggest   bool `yaml:"suggest"`
+	AutoApply bool `yaml:"auto_apply"`
+}
+
+// ReviewInjectionConfig guards agent prompts against instructions planted in
+// ticket content.
+type ReviewInjectionConfig struct {
+	Sanitize bool   `yaml:"sanitize"` // fence the ticket context and strip lines addressing the reviewer
+	Detector string `yaml:"detector"` // off, warn, or block
+}
+
+// ReviewConsensusConfig runs every review agent on several executors and
+// keeps the issues they agree on.
+type ReviewConsensusConfig struct {
+	Executors []string `yaml:"executors,omitempty"` // two or more executor names enable consensus
+	Mode      string   `yaml:"mode"`                // intersect or union
+}
+
+// ReviewRemoteConfig runs review agents on another host over SSH.
+type ReviewRemoteConfig struct {
+	Host     string   `yaml:"host"`                // ssh destination; empty runs reviews locally
+	Dir      string   `yaml:"dir"`                 // repository path on the host
+	Sync     string   `yaml:"sync"`                // rsync, git, or none
+	SSHFlags []string `yaml:"ssh_flags,omitempty"` // extra ssh options
 }
 
 // ReviewConfig holds review-specific configuration.
@@ -83,6 +175,91 @@ type ReviewConfig struct {
 	Overrides     []review.AgentConfig   `yaml:"overrides,omitempty"`
 	Agents        []review.AgentConfig   `yaml:"agents,omitempty"`
 	Validators    ReviewValidatorsConfig `yaml:"validators"`
+	Recurring     ReviewRecurringConfig  `yaml:"recurring"`
+	Patches       ReviewPatchesConfig    `yaml:"patches"`
+	Injection     ReviewInjectionConfig  `yaml:"injection"`
+	Consensus     ReviewConsensusConfig  `yaml:"consensus"`
+	Remote        ReviewRemoteConfig     `yaml:"remote"`
+	IncludeDiff   bool                   `yaml:"include_diff"`
+	DiffLimit     int                    `yaml:"diff_limit"`
+	CodeOwners    bool                   `yaml:"codeowners"`    // annotate issues with their CODEOWNERS owners
+	FileDeferred  bool                   `yaml:"file_deferred"` // file issues left open by max_iterations as a new work item
+	RepoSummary   bool                   `yaml:"repo_summary"`  // prepend a cached repository overview to reviewer prompts
+	ContextFiles  int                    `yaml:"context_files"` // max related Go files (same package, importers) listed for agents
+	FinalCheck    bool                   `yaml:"final_check"`   // run the validation commands as a review agent
+
+	// AcceptanceCheck verifies the work item's acceptance criteria in every
+	// review pass; unmet criteria become issues for the fix loop.
+	AcceptanceCheck bool `yaml:"acceptance_check"`
+
+	// FixExcerptLines caps the lines of code around reported issues embedded
+	// in fix prompts; fixes needing more get no excerpts (0 disables them).
+	FixExcerptLines int `yaml:"fix_excerpt_lines"`
+
+	// FixGroupBy groups the issues of fix prompts by agent, file, or
+	// severity; FixMaxIssues caps the issues per fix prompt, the rest
+	// following in later ones (0 = all); FixSnippetLines shows that many
+	// lines around each issue under it (0 = none).
+	FixGroupBy      string `yaml:"fix_group_by"`
+	FixMaxIssues    int    `yaml:"fix_max_issues"`
+	FixSnippetLines int    `yaml:"fix_snippet_lines"`
+
+	// ScoreThreshold passes a review whose severity-weighted score is below
+	// it, issues and all (0 = pass only with no issues).
+	ScoreThreshold int `yaml:"score_threshold"`
+
+	// Schedule narrows early review iterations to severe findings and a
+	// subset of agents; see review.Config.Schedule.
+	Schedule []review.StrictnessStep `yaml:"schedule,omitempty"`
+}
+
+// SupervisorConfig controls the supervisor agent consulted when a run gets stuck.
+type SupervisorConfig struct {
+	Enabled          bool `yaml:"enabled"`
+	MaxInterventions int  `yaml:"max_interventions"`
+}
+
+// SplitConfig controls automatic decomposition of phases that keep failing
+// or are too large to finish in one go.
+type SplitConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	AfterAttempts  int  `yaml:"after_attempts"`   // split a phase still open after this many iterations (0 = never)
+	MaxPhaseLength int  `yaml:"max_phase_length"` // split phases with longer descriptions, in characters (0 = never)
+}
+
+// CoverageConfig controls the gate on the Go test coverage of the packages a
+// run changed, checked before the run completes.
+type CoverageConfig struct {
+	Enabled          bool    `yaml:"enabled"`
+	Threshold        float64 `yaml:"threshold"`          // percent of statements a changed package may not drop below
+	MaxFixIterations int     `yaml:"max_fix_iterations"` // test-writing iterations before the run stops as blocked
+}
+
+// FallbackConfig is the chain of executors a run switches to when
+// invocations keep failing, e.g. with the provider overloaded or out of
+// quota.
+type FallbackConfig struct {
+	After int             `yaml:"after"`           // consecutive failed invocations before switching to the next entry
+	Chain []FallbackEntry `yaml:"chain,omitempty"` // fallbacks in the order they are tried
+}
+
+// FallbackEntry is one executor of the fallback chain: another executor,
+// another model, or both.
+type FallbackEntry struct {
+	Executor string `yaml:"executor"` // executor name (default: executor)
+	Model    string `yaml:"model"`    // model name (default: the executor's configured model)
+}
+
+type fallbackOverlay struct {
+	After *int            `yaml:"after"`
+	Chain []FallbackEntry `yaml:"chain,omitempty"`
+}
+
+// DiffScanConfig controls the post-invocation scan of the executor's diff.
+type DiffScanConfig struct {
+	Enabled      bool              `yaml:"enabled"`
+	DefaultRules bool              `yaml:"default_rules"`
+	Rules        []safety.ScanRule `yaml:"rules,omitempty"`
 }
 
 // GitConfig holds git workflow configuration.
@@ -91,6 +268,197 @@ type GitConfig struct {
 	MoveCompletedPlans bool   `yaml:"move_completed_plans"`
 	CompletedPlansDir  string `yaml:"completed_plans_dir"`
 	BranchPrefix       string `yaml:"branch_prefix"`
+	AutoPush           bool   `yaml:"auto_push"`
+	PushRemote         string `yaml:"push_remote"`
+	PushEvery          int    `yaml:"push_every"`
+	BaseBranch         string `yaml:"base_branch"`
+	BaseSync           string `yaml:"base_sync"`  // off, warn, or rebase
+	PRComment          bool   `yaml:"pr_comment"` // keep one summary comment on the pull request up to date
+	PRStatus           bool   `yaml:"pr_status"`  // set a commit status on the pull request's head commit
+	Squash             string `yaml:"squash"`     // off, phase, or run
+
+	// SignOffPaths are regexps on repo-relative paths whose changes are
+	// not auto-committed: they wait, staged, for a human to commit.
+	SignOffPaths []string `yaml:"sign_off_paths,omitempty"`
+
+	Snapshots GitSnapshotsConfig `yaml:"snapshots"`
+}
+
+// GitSnapshotsConfig controls the per-iteration snapshot commits made on a
+// scratch branch, next to the phase commits.
+type GitSnapshotsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Squash  bool `yaml:"squash"` // delete the scratch branch once the run completes
+}
+
+// ScheduleConfig is one entry run by `programmator daemon`.
+type ScheduleConfig struct {
+	Name    string   `yaml:"name"`
+	Cron    string   `yaml:"cron"`    // five-field cron expression or @daily etc.
+	Command string   `yaml:"command"` // start or review
+	Target  string   `yaml:"target"`  // plan, ticket, or directory of plans (start only)
+	Dir     string   `yaml:"dir"`     // working directory, default: the daemon's
+	Args    []string `yaml:"args,omitempty"`
+	Profile string   `yaml:"profile"` // config profile layered on top for this run
+}
+
+// UIKeysConfig remaps the keys read while a run is shown in a terminal. Names
+// use Bubble Tea notation: "s", "ctrl+k", "f2".
+type UIKeysConfig struct {
+	SkipPhase string `yaml:"skip_phase"`
+	Interrupt string `yaml:"interrupt"`
+	Issues    string `yaml:"issues"`
+}
+
+// UIConfig controls terminal colors, key bindings, the cost display, and how
+// edits are shown.
+type UIConfig struct {
+	Theme       string         `yaml:"theme"`            // default, high-contrast, or no-color
+	Colors      map[string]int `yaml:"colors,omitempty"` // 256-color overrides by role (accent, diff_add, severity_high, ...)
+	Keys        UIKeysConfig   `yaml:"keys"`
+	CostWarning float64        `yaml:"cost_warning"` // USD; the footer cost flashes once the run estimate exceeds it (0 = never)
+	Diff        string         `yaml:"diff"`         // unified, word, or side-by-side
+}
+
+// GuardConfig controls the dcg check before runs (see `programmator guard`).
+type GuardConfig struct {
+	Mode string `yaml:"mode"` // auto, require, or off
+}
+
+// NoteStyleConfig is how progress notes are written to a source.
+type NoteStyleConfig struct {
+	Level    string `yaml:"level"`    // all, phase, or final
+	Template string `yaml:"template"` // Go template for the note content (empty = "kind: message")
+}
+
+func (s *NoteStyleConfig) merge(src NoteStyleConfig) {
+	if src.Level != "" {
+		s.Level = src.Level
+	}
+	if src.Template != "" {
+		s.Template = src.Template
+	}
+}
+
+// NotesConfig controls the progress notes added to plans, tickets, and pull
+// requests, with overrides by source type.
+type NotesConfig struct {
+	NoteStyleConfig `yaml:",inline"`
+	Sources         map[string]NoteStyleConfig `yaml:"sources,omitempty"` // by source type: plan, ticket, pull_request
+}
+
+// DesktopNotifyConfig enables local desktop notifications.
+type DesktopNotifyConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// EmailNotifyConfig holds the SMTP settings for email notifications.
+type EmailNotifyConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Host        string   `yaml:"host"`
+	Port        int      `yaml:"port"`
+	Username    string   `yaml:"username"`
+	PasswordEnv string   `yaml:"password_env"` // environment variable holding the SMTP password
+	From        string   `yaml:"from"`
+	To          []string `yaml:"to,omitempty"`
+}
+
+// PreflightConfig controls the checks run by `start` before the first
+// iteration.
+type PreflightConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	MinFreeDiskMB int  `yaml:"min_free_disk_mb"` // 0 skips the disk space check
+	Validation    bool `yaml:"validation"`       // run the validation commands, expecting success
+	PingExecutor  bool `yaml:"ping_executor"`    // a minimal invocation to check the executor binary and login
+}
+
+type preflightOverlay struct {
+	Enabled       *bool `yaml:"enabled"`
+	MinFreeDiskMB *int  `yaml:"min_free_disk_mb"`
+	Validation    *bool `yaml:"validation"`
+	PingExecutor  *bool `yaml:"ping_executor"`
+}
+
+// ArtifactsConfig controls the per-run artifact directories under
+// .programmator/runs in the working directory.
+type ArtifactsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Keep    int  `yaml:"keep"` // newest run directories kept (0 = all)
+}
+
+type artifactsOverlay struct {
+	Enabled *bool `yaml:"enabled"`
+	Keep    *int  `yaml:"keep"`
+}
+
+// StatusBlockConfig controls the key the executor's status block starts
+// with.
+type StatusBlockConfig struct {
+	Key   string `yaml:"key"`
+	Nonce bool   `yaml:"nonce"` // suffix the key with a random value per run
+}
+
+type statusBlockOverlay struct {
+	Key   string `yaml:"key"`
+	Nonce *bool  `yaml:"nonce"`
+}
+
+// RunKey returns the status block key for a new run: Key, with a random
+// suffix when Nonce is set.
+func (c StatusBlockConfig) RunKey() string {
+	key := cmp.Or(c.Key, protocol.StatusBlockKey)
+	if c.Nonce {
+		return protocol.NonceStatusKey(key)
+	}
+	return key
+}
+
+// PacingConfig spaces out iterations and limits when they run.
+type PacingConfig struct {
+	Cooldown    int    `yaml:"cooldown"`     // seconds to sleep between iterations
+	Adaptive    bool   `yaml:"adaptive"`     // back off after rate-limited invocations
+	MaxCooldown int    `yaml:"max_cooldown"` // seconds; cap for the adaptive cool-down
+	WorkHours   string `yaml:"work_hours"`   // "HH:MM-HH:MM" in local time (empty = any time)
+}
+
+type pacingOverlay struct {
+	Cooldown    *int   `yaml:"cooldown"`
+	Adaptive    *bool  `yaml:"adaptive"`
+	MaxCooldown *int   `yaml:"max_cooldown"`
+	WorkHours   string `yaml:"work_hours"`
+}
+
+// WorkHoursWindow returns the parsed work hours; the zero window when they
+// are not set or invalid (Validate reports invalid ones).
+func (c PacingConfig) WorkHoursWindow() cron.Window {
+	w, _ := cron.ParseWindow(c.WorkHours)
+	return w
+}
+
+// ActiveFilesConfig publishes the files a run edits, for humans working in
+// the same checkout, and sets what happens when they edit them too.
+type ActiveFilesConfig struct {
+	StatusFile  bool   `yaml:"status_file"`  // write .programmator/status/active-files.json during the run
+	OnConflict  string `yaml:"on_conflict"`  // warn, pause, or ignore
+	QuietPeriod int    `yaml:"quiet_period"` // seconds edited files must stay unchanged before a paused run goes on
+}
+
+type activeFilesOverlay struct {
+	StatusFile  *bool  `yaml:"status_file"`
+	OnConflict  string `yaml:"on_conflict"`
+	QuietPeriod *int   `yaml:"quiet_period"`
+}
+
+// NotificationsConfig controls the notifications sent when a run ends.
+type NotificationsConfig struct {
+	OnExit  []string            `yaml:"on_exit,omitempty"` // exit reasons to notify on (empty = every one)
+	Desktop DesktopNotifyConfig `yaml:"desktop"`
+	Email   EmailNotifyConfig   `yaml:"email"`
+
+	// Owners maps CODEOWNERS owners (e.g. "@org/billing") to email
+	// addresses. When a run ends with unresolved review issues, each owner
+	// listed here is emailed the issues in their files.
+	Owners map[string][]string `yaml:"owners,omitempty"`
 }
 
 // Config holds all configuration settings for programmator.
@@ -99,6 +467,26 @@ type Config struct {
 	StagnationLimit int `yaml:"stagnation_limit"`
 	Timeout         int `yaml:"timeout"` // seconds
 
+	// LivenessTimeout aborts an invocation whose executor streams no output,
+	// not even a tool call, for this many seconds (0 = never).
+	LivenessTimeout int `yaml:"liveness_timeout"`
+
+	// LimitScope is what max_iterations counts: the iterations of one
+	// session, or of every session on the same work item (safety.LimitScope*).
+	LimitScope string `yaml:"limit_scope"`
+
+	ParallelPhases           int  `yaml:"parallel_phases"`
+	ReuseSessions            bool `yaml:"reuse_sessions"`
+	MaxConcurrentInvocations int  `yaml:"max_concurrent_invocations"` // 0 = unlimited
+	DocsPhase                bool `yaml:"docs_phase"`
+	AutoPhase                bool `yaml:"auto_phase"`
+	MinimalPermissions       bool `yaml:"minimal_permissions"`
+	VerifyFilesChanged       bool `yaml:"verify_files_changed"` // check reported files_changed against git status
+
+	// ValidationCommands are run after each phase when the work item does
+	// not list its own.
+	ValidationCommands []string `yaml:"validation_commands"`
+
 	Executor      string         `yaml:"executor"`
 	Claude        ClaudeConfig   `yaml:"claude"`
 	Pi            PiConfig       `yaml:"pi"`
@@ -106,33 +494,160 @@ type Config struct {
 	Codex         CodexConfig    `yaml:"codex"`
 	TicketCommand string         `yaml:"ticket_command"`
 
-	Git    GitConfig    `yaml:"git"`
-	Review ReviewConfig `yaml:"review"`
+	// ContextOverflow is what happens to a prompt too large for the
+	// executor's context_window: executor.OverflowWarn or OverflowTruncate.
+	ContextOverflow string `yaml:"context_overflow"`
+
+	// ExecutorEnv is set in the executor's environment, overriding inherited
+	// variables. Values expand $VAR and ${VAR} from programmator's own
+	// environment, so secrets can stay out of config files.
+	ExecutorEnv map[string]string `yaml:"executor_env,omitempty"`
+
+	// TicketWorkspaces are named ticket stores besides TICKETS_DIR, addressed
+	// as "name:ticket-id".
+	TicketWorkspaces map[string]ticket.Workspace `yaml:"ticket_workspaces,omitempty"`
+
+	Git        GitConfig        `yaml:"git"`
+	Review     ReviewConfig     `yaml:"review"`
+	Supervisor SupervisorConfig `yaml:"supervisor"`
+	Split      SplitConfig      `yaml:"split"`
+	Coverage   CoverageConfig   `yaml:"coverage"`
+	Fallback   FallbackConfig   `yaml:"fallback"`
+	DiffScan   DiffScanConfig   `yaml:"diff_scan"`
+	UI         UIConfig         `yaml:"ui"`
+	Guard      GuardConfig      `yaml:"guard"`
+	Notes      NotesConfig      `yaml:"notes"`
+
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Preflight     PreflightConfig     `yaml:"preflight"`
+	Artifacts     ArtifactsConfig     `yaml:"artifacts"`
+	StatusBlock   StatusBlockConfig   `yaml:"status_block"`
+	Pacing        PacingConfig        `yaml:"pacing"`
+	ActiveFiles   ActiveFilesConfig   `yaml:"active_files"`
+
+	// Pricing adds or replaces entries in the built-in per-model price
+	// table used for cost estimates, keyed by model name fragment.
+	Pricing map[string]llm.Price `yaml:"pricing,omitempty"`
+
+	Schedules []ScheduleConfig `yaml:"schedules,omitempty"`
+
+	// Language selects the translation of the messages in the catalog (main
+	// loop progress and notifications): messages/<language>.yaml in the
+	// config directories. Other output stays in English.
+	Language string `yaml:"language"`
 
 	// Prompts (loaded separately, not from YAML)
 	Prompts *Prompts `yaml:"-"`
 
+	// Messages are the progress and notification messages in Language
+	// (loaded separately, not from YAML).
+	Messages *messages.Catalog `yaml:"-"`
+
 	// Private: track where config was loaded from
-	configDir string
-	localDir  string
-	sources   []string
+	configDir   string
+	projectFile string
+	localDir    string
+	profile     string
+	sources     []string
 }
 
 // configOverlay is used for parsing override YAML files.
 // Pointer types distinguish "not set" (nil) from "explicitly set to zero/false".
 type configOverlay struct {
-	MaxIterations   *int           `yaml:"max_iterations"`
-	StagnationLimit *int           `yaml:"stagnation_limit"`
-	Timeout         *int           `yaml:"timeout"`
-	Executor        string         `yaml:"executor"`
-	Claude          ClaudeConfig   `yaml:"claude"`
-	Pi              PiConfig       `yaml:"pi"`
-	OpenCode        OpenCodeConfig `yaml:"opencode"`
-	Codex           CodexConfig    `yaml:"codex"`
-	TicketCommand   string         `yaml:"ticket_command"`
+	MaxIterations            *int           `yaml:"max_iterations"`
+	StagnationLimit          *int           `yaml:"stagnation_limit"`
+	Timeout                  *int           `yaml:"timeout"`
+	LivenessTimeout          *int           `yaml:"liveness_timeout"`
+	LimitScope               string         `yaml:"limit_scope"`
+	ParallelPhases           *int           `yaml:"parallel_phases"`
+	ReuseSessions            *bool          `yaml:"reuse_sessions"`
+	MaxConcurrentInvocations *int           `yaml:"max_concurrent_invocations"`
+	DocsPhase                *bool          `yaml:"docs_phase"`
+	AutoPhase                *bool          `yaml:"auto_phase"`
+	MinimalPermissions       *bool          `yaml:"minimal_permissions"`
+	VerifyFilesChanged       *bool          `yaml:"verify_files_changed"`
+	ValidationCommands       []string       `yaml:"validation_commands"`
+	Executor                 string         `yaml:"executor"`
+	Claude                   ClaudeConfig   `yaml:"claude"`
+	Pi                       PiConfig       `yaml:"pi"`
+	OpenCode                 OpenCodeConfig `yaml:"opencode"`
+	Codex                    CodexConfig    `yaml:"codex"`
+	TicketCommand            string         `yaml:"ticket_command"`
+	ContextOverflow          string         `yaml:"context_overflow"`
+	Language                 string         `yaml:"language"`
 
-	Git    gitOverlay    `yaml:"git"`
-	Review reviewOverlay `yaml:"review"`
+	ExecutorEnv      map[string]string           `yaml:"executor_env,omitempty"`
+	TicketWorkspaces map[string]ticket.Workspace `yaml:"ticket_workspaces,omitempty"`
+
+	Git        gitOverlay        `yaml:"git"`
+	Review     reviewOverlay     `yaml:"review"`
+	Supervisor supervisorOverlay `yaml:"supervisor"`
+	Split      splitOverlay      `yaml:"split"`
+	Coverage   coverageOverlay   `yaml:"coverage"`
+	Fallback   fallbackOverlay   `yaml:"fallback"`
+	DiffScan   diffScanOverlay   `yaml:"diff_scan"`
+	UI         uiOverlay         `yaml:"ui"`
+	Guard      GuardConfig       `yaml:"guard"`
+	Notes      NotesConfig       `yaml:"notes"`
+
+	Notifications notificationsOverlay `yaml:"notifications"`
+	Preflight     preflightOverlay     `yaml:"preflight"`
+	Artifacts     artifactsOverlay     `yaml:"artifacts"`
+	StatusBlock   statusBlockOverlay   `yaml:"status_block"`
+	Pacing        pacingOverlay        `yaml:"pacing"`
+	ActiveFiles   activeFilesOverlay   `yaml:"active_files"`
+
+	Pricing map[string]llm.Price `yaml:"pricing,omitempty"`
+
+	Schedules []ScheduleConfig `yaml:"schedules,omitempty"`
+}
+
+type notificationsOverlay struct {
+	OnExit  []string `yaml:"on_exit,omitempty"`
+	Desktop struct {
+		Enabled *bool `yaml:"enabled"`
+	} `yaml:"desktop"`
+	Email struct {
+		Enabled     *bool    `yaml:"enabled"`
+		Host        string   `yaml:"host"`
+		Port        *int     `yaml:"port"`
+		Username    string   `yaml:"username"`
+		PasswordEnv string   `yaml:"password_env"`
+		From        string   `yaml:"from"`
+		To          []string `yaml:"to,omitempty"`
+	} `yaml:"email"`
+	Owners map[string][]string `yaml:"owners,omitempty"`
+}
+
+type uiOverlay struct {
+	Theme       string         `yaml:"theme"`
+	Colors      map[string]int `yaml:"colors,omitempty"`
+	Keys        UIKeysConfig   `yaml:"keys"`
+	CostWarning *float64       `yaml:"cost_warning"`
+	Diff        string         `yaml:"diff"`
+}
+
+type diffScanOverlay struct {
+	Enabled      *bool             `yaml:"enabled"`
+	DefaultRules *bool             `yaml:"default_rules"`
+	Rules        []safety.ScanRule `yaml:"rules,omitempty"`
+}
+
+type supervisorOverlay struct {
+	Enabled          *bool `yaml:"enabled"`
+	MaxInterventions *int  `yaml:"max_interventions"`
+}
+
+type coverageOverlay struct {
+	Enabled          *bool    `yaml:"enabled"`
+	Threshold        *float64 `yaml:"threshold"`
+	MaxFixIterations *int     `yaml:"max_fix_iterations"`
+}
+
+type splitOverlay struct {
+	Enabled        *bool `yaml:"enabled"`
+	AfterAttempts  *int  `yaml:"after_attempts"`
+	MaxPhaseLength *int  `yaml:"max_phase_length"`
 }
 
 type reviewOverlay struct {
@@ -144,18 +659,73 @@ type reviewOverlay struct {
 	Overrides     []review.AgentConfig    `yaml:"overrides,omitempty"`
 	Agents        []review.AgentConfig    `yaml:"agents,omitempty"`
 	Validators    reviewValidatorsOverlay `yaml:"validators,omitempty"`
+	Recurring     reviewRecurringOverlay  `yaml:"recurring,omitempty"`
+	Patches       reviewPatchesOverlay    `yaml:"patches,omitempty"`
+	Injection     reviewInjectionOverlay  `yaml:"injection,omitempty"`
+	Consensus     ReviewConsensusConfig   `yaml:"consensus,omitempty"`
+	Remote        ReviewRemoteConfig      `yaml:"remote,omitempty"`
+	IncludeDiff   *bool                   `yaml:"include_diff"`
+	DiffLimit     *int                    `yaml:"diff_limit"`
+	CodeOwners    *bool                   `yaml:"codeowners"`
+	FileDeferred  *bool                   `yaml:"file_deferred"`
+	RepoSummary   *bool                   `yaml:"repo_summary"`
+	ContextFiles  *int                    `yaml:"context_files"`
+	FinalCheck    *bool                   `yaml:"final_check"`
+
+	AcceptanceCheck *bool `yaml:"acceptance_check"`
+
+	FixExcerptLines *int                    `yaml:"fix_excerpt_lines"`
+	FixGroupBy      string                  `yaml:"fix_group_by"`
+	FixMaxIssues    *int                    `yaml:"fix_max_issues"`
+	FixSnippetLines *int                    `yaml:"fix_snippet_lines"`
+	ScoreThreshold  *int                    `yaml:"score_threshold"`
+	Schedule        []review.StrictnessStep `yaml:"schedule,omitempty"`
+}
+
+type reviewRecurringOverlay struct {
+	Enabled       *bool `yaml:"enabled"`
+	EscalateAfter *int  `yaml:"escalate_after"`
+}
+
+type reviewPatchesOverlay struct {
+	Suggest   *bool `yaml:"suggest"`
+	AutoApply *bool `yaml:"auto_apply"`
+}
+
+type reviewInjectionOverlay struct {
+	Sanitize *bool  `yaml:"sanitize"`
+	Detector string `yaml:"detector"`
 }
 
 type reviewValidatorsOverlay struct {
-	Issue          *bool `yaml:"issue"`
-	Simplification *bool `yaml:"simplification"`
+	Issue              *bool            `yaml:"issue"`
+	Simplification     *bool            `yaml:"simplification"`
+	IssueWhen          *review.SizeRule `yaml:"issue_when"`
+	SimplificationWhen *review.SizeRule `yaml:"simplification_when"`
+	Timeout            *int             `yaml:"timeout"`
 }
 
 type gitOverlay struct {
-	AutoCommit         *bool  `yaml:"auto_commit"`
-	MoveCompletedPlans *bool  `yaml:"move_completed_plans"`
-	CompletedPlansDir  string `yaml:"completed_plans_dir"`
-	BranchPrefix       string `yaml:"branch_prefix"`
+	AutoCommit         *bool    `yaml:"auto_commit"`
+	MoveCompletedPlans *bool    `yaml:"move_completed_plans"`
+	CompletedPlansDir  string   `yaml:"completed_plans_dir"`
+	BranchPrefix       string   `yaml:"branch_prefix"`
+	AutoPush           *bool    `yaml:"auto_push"`
+	PushRemote         string   `yaml:"push_remote"`
+	PushEvery          *int     `yaml:"push_every"`
+	BaseBranch         string   `yaml:"base_branch"`
+	BaseSync           string   `yaml:"base_sync"`
+	PRComment          *bool    `yaml:"pr_comment"`
+	PRStatus           *bool    `yaml:"pr_status"`
+	Squash             string   `yaml:"squash"`
+	SignOffPaths       []string `yaml:"sign_off_paths,omitempty"`
+
+	Snapshots gitSnapshotsOverlay `yaml:"snapshots,omitempty"`
+}
+
+type gitSnapshotsOverlay struct {
+	Enabled *bool `yaml:"enabled"`
+	Squash  *bool `yaml:"squash"`
 }
 
 // Sources returns a human-readable description of where config values came from.
@@ -163,11 +733,22 @@ func (c *Config) Sources() []string {
 	return c.sources
 }
 
+// ProjectFile returns the repo-level config file if one was found.
+func (c *Config) ProjectFile() string {
+	return c.projectFile
+}
+
 // LocalDir returns the local project config directory if one was detected.
 func (c *Config) LocalDir() string {
 	return c.localDir
 }
 
+// Profile returns the config profile file applied on top of the other
+// layers, if any.
+func (c *Config) Profile() string {
+	return c.profile
+}
+
 // ConfigDir returns the global config directory.
 func (c *Config) ConfigDir() string {
 	return c.configDir
@@ -178,32 +759,392 @@ func (c *Config) Validate() error {
 	if !validExecutors[c.Executor] {
 		return fmt.Errorf("unknown executor %q (supported: claude, pi, opencode, codex)", c.Executor)
 	}
+	if c.Codex.Sandbox != "" && !codex.ValidSandbox(c.Codex.Sandbox) {
+		return fmt.Errorf("unknown codex.sandbox %q (supported: read-only, workspace-write, danger-full-access)", c.Codex.Sandbox)
+	}
 	if c.Review.Executor.Name != "" && !validExecutors[c.Review.Executor.Name] {
 		return fmt.Errorf("unknown review.executor.name %q (supported: claude, pi, opencode, codex)", c.Review.Executor.Name)
 	}
+	if err := c.Review.Validators.IssueWhen.Validate(); err != nil {
+		return fmt.Errorf("review.validators.issue_when: %w", err)
+	}
+	if err := c.Review.Validators.SimplificationWhen.Validate(); err != nil {
+		return fmt.Errorf("review.validators.simplification_when: %w", err)
+	}
+	if c.LivenessTimeout < 0 {
+		return fmt.Errorf("liveness_timeout must be >= 0, got %d", c.LivenessTimeout)
+	}
+	if c.Review.Validators.Timeout < 0 {
+		return fmt.Errorf("review.validators.timeout must be >= 0, got %d", c.Review.Validators.Timeout)
+	}
+	if err := validateReviewConsensus(c.Review.Consensus); err != nil {
+		return err
+	}
+	if err := c.validateReviewRemote(); err != nil {
+		return err
+	}
+	if !review.ValidGroupBy(c.Review.FixGroupBy) {
+		return fmt.Errorf("unknown review.fix_group_by %q (supported: agent, file, severity)", c.Review.FixGroupBy)
+	}
+	if c.Review.FixMaxIssues < 0 || c.Review.FixSnippetLines < 0 {
+		return fmt.Errorf("review.fix_max_issues and review.fix_snippet_lines must not be negative")
+	}
+	if c.Review.ContextFiles < 0 {
+		return fmt.Errorf("review.context_files must not be negative")
+	}
+	if c.Review.ScoreThreshold < 0 {
+		return fmt.Errorf("review.score_threshold must not be negative")
+	}
+	switch c.Review.Injection.Detector {
+	case "", review.InjectionOff, review.InjectionWarn, review.InjectionBlock:
+	default:
+		return fmt.Errorf("unknown review.injection.detector %q (supported: off, warn, block)", c.Review.Injection.Detector)
+	}
+	switch c.LimitScope {
+	case "", safety.LimitScopeSession, safety.LimitScopeWorkItem:
+	default:
+		return fmt.Errorf("unknown limit_scope %q (supported: session, work_item)", c.LimitScope)
+	}
+	switch c.ContextOverflow {
+	case "", executor.OverflowWarn, executor.OverflowTruncate:
+	default:
+		return fmt.Errorf("unknown context_overflow %q (supported: warn, truncate)", c.ContextOverflow)
+	}
+	switch c.Guard.Mode {
+	case "", "auto", "require", "off":
+	default:
+		return fmt.Errorf("unknown guard.mode %q (supported: auto, require, off)", c.Guard.Mode)
+	}
+	switch c.Git.BaseSync {
+	case "", "off", "warn", "rebase":
+	default:
+		return fmt.Errorf("unknown git.base_sync %q (supported: off, warn, rebase)", c.Git.BaseSync)
+	}
+	switch c.ActiveFiles.OnConflict {
+	case "", "warn", "pause", "ignore":
+	default:
+		return fmt.Errorf("unknown active_files.on_conflict %q (supported: warn, pause, ignore)", c.ActiveFiles.OnConflict)
+	}
+	if c.ActiveFiles.QuietPeriod < 0 {
+		return fmt.Errorf("active_files.quiet_period must not be negative")
+	}
+	switch c.Git.Squash {
+	case "", "off", "phase", "run":
+	default:
+		return fmt.Errorf("unknown git.squash %q (supported: off, phase, run)", c.Git.Squash)
+	}
+	for _, p := range c.Git.SignOffPaths {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("git.sign_off_paths: %w", err)
+		}
+	}
+	for name := range c.ExecutorEnv {
+		if !envNameRe.MatchString(name) {
+			return fmt.Errorf("invalid executor_env name %q", name)
+		}
+	}
+	if err := validateTicketWorkspaces(c.TicketWorkspaces); err != nil {
+		return err
+	}
+	if err := validateNotes(c.Notes); err != nil {
+		return err
+	}
+	if err := validateNotifications(c.Notifications); err != nil {
+		return err
+	}
+	if c.Preflight.MinFreeDiskMB < 0 {
+		return fmt.Errorf("preflight.min_free_disk_mb must not be negative")
+	}
+	if c.Artifacts.Keep < 0 {
+		return fmt.Errorf("artifacts.keep must not be negative")
+	}
+	if c.StatusBlock.Key != "" && !statusKeyRe.MatchString(c.StatusBlock.Key) {
+		return fmt.Errorf("status_block.key %q must be letters, digits, and underscores, starting with a letter", c.StatusBlock.Key)
+	}
+	if c.Coverage.Threshold < 0 || c.Coverage.Threshold > 100 {
+		return fmt.Errorf("coverage.threshold must be between 0 and 100, got %g", c.Coverage.Threshold)
+	}
+	if c.Coverage.MaxFixIterations < 0 {
+		return fmt.Errorf("coverage.max_fix_iterations must not be negative")
+	}
+	if err := validateFallback(c.Fallback); err != nil {
+		return err
+	}
+	if err := validatePacing(c.Pacing); err != nil {
+		return err
+	}
+	if err := validateUI(c.UI); err != nil {
+		return err
+	}
+	for model, price := range c.Pricing {
+		if price.Input < 0 || price.Output < 0 {
+			return fmt.Errorf("pricing.%s: prices must not be negative", model)
+		}
+	}
+	return validateSchedules(c.Schedules)
+}
+
+// validWorkspaceName matches ticket workspace names: they prefix ticket IDs
+// and must not be mistaken for pull request references (pr:, mr:).
+var validWorkspaceName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// statusKeyRe matches status block keys that are plain YAML keys.
+var statusKeyRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// envNameRe matches environment variable names.
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validateTicketWorkspaces(workspaces map[string]ticket.Workspace) error {
+	for name, ws := range workspaces {
+		if !validWorkspaceName.MatchString(name) || name == "pr" || name == "mr" {
+			return fmt.Errorf("invalid ticket_workspaces name %q", name)
+		}
+		if ws.Dir == "" {
+			return fmt.Errorf("ticket_workspaces.%s.dir is required", name)
+		}
+	}
 	return nil
 }
 
-// Load loads all configuration from the default locations.
-// It auto-detects .programmator/ in the current working directory for local overrides.
+func validateNotes(nc NotesConfig) error {
+	if err := nc.toStyle().Validate(); err != nil {
+		return fmt.Errorf("notes: %w", err)
+	}
+	for sourceType, style := range nc.Sources {
+		switch sourceType {
+		case protocol.SourceTypePlan, protocol.SourceTypeTicket, protocol.SourceTypePullRequest:
+		default:
+			return fmt.Errorf("unknown notes.sources entry %q (supported: plan, ticket, pull_request)", sourceType)
+		}
+		if err := style.toStyle().Validate(); err != nil {
+			return fmt.Errorf("notes.sources.%s: %w", sourceType, err)
+		}
+	}
+	return nil
+}
+
+func validatePacing(pc PacingConfig) error {
+	if pc.Cooldown < 0 || pc.MaxCooldown < 0 {
+		return fmt.Errorf("pacing.cooldown and pacing.max_cooldown must not be negative")
+	}
+	if _, err := cron.ParseWindow(pc.WorkHours); err != nil {
+		return fmt.Errorf("pacing.work_hours: %w", err)
+	}
+	return nil
+}
+
+func validateNotifications(nc NotificationsConfig) error {
+	for _, reason := range nc.OnExit {
+		if !safety.ValidExitReason(safety.ExitReason(reason)) {
+			return fmt.Errorf("unknown notifications.on_exit entry %q", reason)
+		}
+	}
+	if email := nc.Email; email.Enabled {
+		if email.Host == "" {
+			return fmt.Errorf("notifications.email.host is required when email notifications are enabled")
+		}
+		if email.From == "" || len(email.To) == 0 {
+			return fmt.Errorf("notifications.email.from and notifications.email.to are required when email notifications are enabled")
+		}
+		if email.Port < 0 || email.Port > 65535 {
+			return fmt.Errorf("notifications.email.port %d is out of range", email.Port)
+		}
+	}
+	if len(nc.Owners) > 0 && !nc.Email.Enabled {
+		return fmt.Errorf("notifications.owners needs email notifications (notifications.email.enabled)")
+	}
+	for owner, addrs := range nc.Owners {
+		if len(addrs) == 0 {
+			return fmt.Errorf("notifications.owners.%s has no email addresses", owner)
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateReviewRemote() error {
+	rc := c.Review.Remote
+	if rc.Host == "" {
+		return nil
+	}
+	if strings.HasPrefix(rc.Host, "-") {
+		return fmt.Errorf("invalid review.remote.host %q", rc.Host)
+	}
+	if rc.Dir == "" {
+		return fmt.Errorf("review.remote.dir is required with review.remote.host")
+	}
+	switch rc.Sync {
+	case "", llm.SyncRsync, llm.SyncGit, llm.SyncNone:
+	default:
+		return fmt.Errorf("unknown review.remote.sync %q (supported: rsync, git, none)", rc.Sync)
+	}
+	names := c.Review.Consensus.Executors
+	if len(names) < 2 {
+		names = []string{cmp.Or(c.Review.Executor.Name, c.Executor)}
+	}
+	for _, name := range names {
+		if !executor.SupportsRunner(name) {
+			return fmt.Errorf("review.remote: executor %q cannot run remotely (supported: claude, codex)", name)
+		}
+	}
+	return nil
+}
+
+// validateFallback checks the fallback chain. After is capped at 3, the
+// consecutive invocation failures that stop a run.
+func validateFallback(fc FallbackConfig) error {
+	if len(fc.Chain) == 0 {
+		return nil
+	}
+	if fc.After < 1 || fc.After > 3 {
+		return fmt.Errorf("fallback.after must be between 1 and 3, got %d", fc.After)
+	}
+	for i, e := range fc.Chain {
+		if e.Executor == "" && e.Model == "" {
+			return fmt.Errorf("fallback.chain[%d]: set executor, model, or both", i)
+		}
+		if e.Executor != "" && !validExecutors[e.Executor] {
+			return fmt.Errorf("fallback.chain[%d]: unknown executor %q (supported: claude, pi, opencode, codex)", i, e.Executor)
+		}
+	}
+	return nil
+}
+
+func validateReviewConsensus(rc ReviewConsensusConfig) error {
+	switch rc.Mode {
+	case "", review.ConsensusIntersect, review.ConsensusUnion:
+	default:
+		return fmt.Errorf("unknown review.consensus.mode %q (supported: intersect, union)", rc.Mode)
+	}
+	seen := make(map[string]bool, len(rc.Executors))
+	for _, name := range rc.Executors {
+		if name == "" || !validExecutors[name] {
+			return fmt.Errorf("unknown review.consensus.executors entry %q (supported: claude, pi, opencode, codex)", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("review.consensus.executors lists %q twice", name)
+		}
+		seen[name] = true
+	}
+	if len(rc.Executors) == 1 {
+		return fmt.Errorf("review.consensus.executors needs at least two executors, got %q", rc.Executors[0])
+	}
+	return nil
+}
+
+func validateUI(ui UIConfig) error {
+	switch ui.Theme {
+	case "", "default", "high-contrast", "no-color":
+	default:
+		return fmt.Errorf("unknown ui.theme %q (supported: default, high-contrast, no-color)", ui.Theme)
+	}
+	for role, color := range ui.Colors {
+		if color < 0 || color > 255 {
+			return fmt.Errorf("ui.colors.%s: %d is not a 256-color code (0-255)", role, color)
+		}
+	}
+	if ui.CostWarning < 0 {
+		return fmt.Errorf("ui.cost_warning: %v must not be negative", ui.CostWarning)
+	}
+	switch ui.Diff {
+	case "", "unified", "word", "side-by-side":
+	default:
+		return fmt.Errorf("unknown ui.diff %q (supported: unified, word, side-by-side)", ui.Diff)
+	}
+	keys := [][2]string{{"skip_phase", ui.Keys.SkipPhase}, {"interrupt", ui.Keys.Interrupt}, {"issues", ui.Keys.Issues}}
+	for i, a := range keys {
+		for _, b := range keys[i+1:] {
+			if a[1] != "" && a[1] == b[1] {
+				return fmt.Errorf("ui.keys: %s and %s are both bound to %q", a[0], b[0], a[1])
+			}
+		}
+	}
+	return nil
+}
+
+func validateSchedules(schedules []ScheduleConfig) error {
+	seen := make(map[string]bool, len(schedules))
+	for i, s := range schedules {
+		if s.Name == "" {
+			return fmt.Errorf("schedules[%d]: name is required", i)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("schedules: duplicate name %q", s.Name)
+		}
+		seen[s.Name] = true
+		if _, err := cron.Parse(s.Cron); err != nil {
+			return fmt.Errorf("schedule %q: %w", s.Name, err)
+		}
+		switch s.Command {
+		case "start":
+			if s.Target == "" {
+				return fmt.Errorf("schedule %q: start needs a target", s.Name)
+			}
+		case "review":
+		default:
+			return fmt.Errorf("schedule %q: unknown command %q (supported: start, review)", s.Name, s.Command)
+		}
+	}
+	return nil
+}
+
+// Load loads all configuration from the default locations for the current
+// working directory.
 func Load() (*Config, error) {
-	globalDir := DefaultConfigDir()
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("get working directory: %w", err)
+	}
+	return LoadForDir(cwd)
+}
 
+// LoadForDir loads all configuration from the default locations for workingDir.
+// It auto-detects .programmator/ in workingDir for local overrides and a
+// .programmator.yaml project file in workingDir or any parent directory.
+func LoadForDir(workingDir string) (*Config, error) {
 	var localDir string
-	if cwd, err := os.Getwd(); err == nil {
-		candidate := filepath.Join(cwd, ".programmator")
-		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
-			localDir = candidate
-		}
+	candidate := filepath.Join(workingDir, ".programmator")
+	if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+		localDir = candidate
 	}
 
-	return LoadWithDirs(globalDir, localDir)
+	return loadLayers(DefaultConfigDir(), FindProjectFile(workingDir), localDir)
+}
+
+// FindProjectFile walks up from dir looking for ProjectConfigFile. The search
+// stops at the repository root (the first directory containing .git) or the
+// filesystem root. Returns "" when no file is found.
+func FindProjectFile(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		candidate := filepath.Join(dir, ProjectConfigFile)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
 }
 
 // LoadWithDirs loads configuration with explicit global and local directories.
 // Local config (.programmator/) overrides global config (~/.config/programmator/) per-field.
 // If localDir is empty, only global config is used.
 func LoadWithDirs(globalDir, localDir string) (*Config, error) {
+	return loadLayers(globalDir, "", localDir)
+}
+
+// loadLayers merges embedded defaults, the global config, the project file,
+// the local config and the ProfileEnv profile, in that order. Empty paths are
+// skipped.
+func loadLayers(globalDir, projectFile, localDir string) (*Config, error) {
 	// 1. Start with embedded defaults
 	cfg, err := loadEmbedded()
 	if err != nil {
@@ -220,7 +1161,21 @@ func LoadWithDirs(globalDir, localDir string) (*Config, error) {
 		return nil, fmt.Errorf("load global config: %w", err)
 	}
 
-	// 3. Merge local config (highest file precedence)
+	// 3. Merge project config committed with the repository
+	if projectFile != "" {
+		overlay, err := loadOverlay(projectFile)
+		if err != nil {
+			return nil, fmt.Errorf("load project config: %w", err)
+		}
+		if keys := overlay.dropProjectRestricted(); len(keys) > 0 {
+			log.Printf("warning: %s sets %s, which only the global or local config may set; ignoring them",
+				projectFile, strings.Join(keys, ", "))
+		}
+		cfg.applyOverlay(overlay)
+		cfg.sources = append(cfg.sources, projectFile)
+	}
+
+	// 4. Merge local config (highest file precedence)
 	if localDir != "" {
 		localPath := filepath.Join(localDir, "config.yaml")
 		if overlay, err := loadOverlay(localPath); err == nil {
@@ -231,7 +1186,20 @@ func LoadWithDirs(globalDir, localDir string) (*Config, error) {
 		}
 	}
 
+	// 5. Merge the profile selected through the environment
+	if name := os.Getenv(ProfileEnv); name != "" {
+		profilePath := ProfilePath(globalDir, name)
+		overlay, err := loadOverlay(profilePath)
+		if err != nil {
+			return nil, fmt.Errorf("load profile %q: %w", name, err)
+		}
+		cfg.applyOverlay(overlay)
+		cfg.sources = append(cfg.sources, profilePath)
+		cfg.profile = profilePath
+	}
+
 	cfg.configDir = globalDir
+	cfg.projectFile = projectFile
 	cfg.localDir = localDir
 	cfg.applyEnvOverrides()
 
@@ -241,9 +1209,25 @@ func LoadWithDirs(globalDir, localDir string) (*Config, error) {
 	}
 	cfg.Prompts = prompts
 
+	catalog, err := messages.Load(cfg.Language, localDir, globalDir)
+	if err != nil {
+		return nil, fmt.Errorf("load messages: %w", err)
+	}
+	cfg.Messages = catalog
+
 	return cfg, nil
 }
 
+// ProfilePath resolves a profile name to its file. Names containing a path
+// separator or a .yaml/.yml extension are used as paths; anything else is
+// looked up as profiles/<name>.yaml in globalDir.
+func ProfilePath(globalDir, name string) string {
+	if strings.ContainsRune(name, filepath.Separator) || strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+		return name
+	}
+	return filepath.Join(globalDir, "profiles", name+".yaml")
+}
+
 // DefaultConfigDir returns the default global configuration directory path.
 func DefaultConfigDir() string {
 	return dirs.ConfigDir()
@@ -275,6 +1259,44 @@ func loadOverlay(path string) (*configOverlay, error) {
 	return &overlay, nil
 }
 
+// dropProjectRestricted clears the settings a project file may not make and
+// returns their keys: which executor runs, with which flags, config
+// directory, and environment, and the executors and ssh flags of reviews.
+// The project file is committed with the repository, so whoever can push to
+// it would otherwise decide what runs on everyone's machine, and with which
+// permissions.
+func (o *configOverlay) dropProjectRestricted() []string {
+	var keys []string
+	drop := func(key string, set bool, reset func()) {
+		if set {
+			keys = append(keys, key)
+			reset()
+		}
+	}
+	dropFlags := func(executor string, flags *string, purposeFlags *PurposeFlags) {
+		drop(executor+".flags", *flags != "", func() { *flags = "" })
+		drop(executor+".purpose_flags", *purposeFlags != PurposeFlags{}, func() { *purposeFlags = PurposeFlags{} })
+	}
+	dropConfigDir := func(executor string, dir *string) {
+		drop(executor+".config_dir", *dir != "", func() { *dir = "" })
+	}
+
+	drop("executor", o.Executor != "", func() { o.Executor = "" })
+	dropFlags("claude", &o.Claude.Flags, &o.Claude.PurposeFlags)
+	dropConfigDir("claude", &o.Claude.ConfigDir)
+	dropFlags("pi", &o.Pi.Flags, &o.Pi.PurposeFlags)
+	dropConfigDir("pi", &o.Pi.ConfigDir)
+	dropFlags("opencode", &o.OpenCode.Flags, &o.OpenCode.PurposeFlags)
+	dropConfigDir("opencode", &o.OpenCode.ConfigDir)
+	dropFlags("codex", &o.Codex.Flags, &o.Codex.PurposeFlags)
+	drop("codex.sandbox", o.Codex.Sandbox != "", func() { o.Codex.Sandbox = "" })
+	drop("executor_env", len(o.ExecutorEnv) > 0, func() { o.ExecutorEnv = nil })
+	drop("review.executor", o.Review.Executor != nil, func() { o.Review.Executor = nil })
+	drop("review.consensus.executors", len(o.Review.Consensus.Executors) > 0, func() { o.Review.Consensus.Executors = nil })
+	drop("review.remote.ssh_flags", len(o.Review.Remote.SSHFlags) > 0, func() { o.Review.Remote.SSHFlags = nil })
+	return keys
+}
+
 // applyOverlay merges non-nil/non-empty overlay values into the config.
 func (c *Config) applyOverlay(o *configOverlay) {
 	if o.MaxIterations != nil {
@@ -286,12 +1308,43 @@ func (c *Config) applyOverlay(o *configOverlay) {
 	if o.Timeout != nil {
 		c.Timeout = *o.Timeout
 	}
+	if o.LivenessTimeout != nil {
+		c.LivenessTimeout = *o.LivenessTimeout
+	}
+	if o.LimitScope != "" {
+		c.LimitScope = o.LimitScope
+	}
+	if o.ParallelPhases != nil {
+		c.ParallelPhases = *o.ParallelPhases
+	}
+	if o.ReuseSessions != nil {
+		c.ReuseSessions = *o.ReuseSessions
+	}
+	if o.MaxConcurrentInvocations != nil {
+		c.MaxConcurrentInvocations = *o.MaxConcurrentInvocations
+	}
+	if o.DocsPhase != nil {
+		c.DocsPhase = *o.DocsPhase
+	}
+	if o.AutoPhase != nil {
+		c.AutoPhase = *o.AutoPhase
+	}
+	if o.MinimalPermissions != nil {
+		c.MinimalPermissions = *o.MinimalPermissions
+	}
+	if o.VerifyFilesChanged != nil {
+		c.VerifyFilesChanged = *o.VerifyFilesChanged
+	}
+	if o.ValidationCommands != nil {
+		c.ValidationCommands = o.ValidationCommands
+	}
 	if o.Executor != "" {
 		c.Executor = o.Executor
 	}
 	if o.Claude.Flags != "" {
 		c.Claude.Flags = o.Claude.Flags
 	}
+	c.Claude.PurposeFlags.merge(o.Claude.PurposeFlags)
 	if o.Claude.ConfigDir != "" {
 		c.Claude.ConfigDir = o.Claude.ConfigDir
 	}
@@ -299,10 +1352,14 @@ func (c *Config) applyOverlay(o *configOverlay) {
 		log.Printf("warning: claude.anthropic_api_key loaded from config file — ensure this is a trusted source")
 		c.Claude.AnthropicAPIKey = o.Claude.AnthropicAPIKey
 	}
+	if o.Claude.ContextWindow != 0 {
+		c.Claude.ContextWindow = o.Claude.ContextWindow
+	}
 	// Pi
 	if o.Pi.Flags != "" {
 		c.Pi.Flags = o.Pi.Flags
 	}
+	c.Pi.PurposeFlags.merge(o.Pi.PurposeFlags)
 	if o.Pi.ConfigDir != "" {
 		c.Pi.ConfigDir = o.Pi.ConfigDir
 	}
@@ -316,8 +1373,17 @@ func (c *Config) applyOverlay(o *configOverlay) {
 		log.Printf("warning: pi.api_key loaded from config file — ensure this is a trusted source")
 		c.Pi.APIKey = o.Pi.APIKey
 	}
+	if o.Pi.ContextWindow != 0 {
+		c.Pi.ContextWindow = o.Pi.ContextWindow
+	}
 	applyOpenCodeOverlay(&c.OpenCode, &o.OpenCode)
 	applyCodexOverlay(&c.Codex, &o.Codex)
+	if o.ContextOverflow != "" {
+		c.ContextOverflow = o.ContextOverflow
+	}
+	if o.Language != "" {
+		c.Language = o.Language
+	}
 
 	if o.TicketCommand != "" {
 		c.TicketCommand = o.TicketCommand
@@ -351,6 +1417,93 @@ func (c *Config) applyOverlay(o *configOverlay) {
 	if o.Review.Validators.Simplification != nil {
 		c.Review.Validators.Simplification = *o.Review.Validators.Simplification
 	}
+	if o.Review.Validators.IssueWhen != nil {
+		c.Review.Validators.IssueWhen = *o.Review.Validators.IssueWhen
+	}
+	if o.Review.Validators.SimplificationWhen != nil {
+		c.Review.Validators.SimplificationWhen = *o.Review.Validators.SimplificationWhen
+	}
+	if o.Review.Validators.Timeout != nil {
+		c.Review.Validators.Timeout = *o.Review.Validators.Timeout
+	}
+	if o.Review.Recurring.Enabled != nil {
+		c.Review.Recurring.Enabled = *o.Review.Recurring.Enabled
+	}
+	if o.Review.Recurring.EscalateAfter != nil {
+		c.Review.Recurring.EscalateAfter = *o.Review.Recurring.EscalateAfter
+	}
+	if o.Review.Patches.Suggest != nil {
+		c.Review.Patches.Suggest = *o.Review.Patches.Suggest
+	}
+	if o.Review.Patches.AutoApply != nil {
+		c.Review.Patches.AutoApply = *o.Review.Patches.AutoApply
+	}
+	if o.Review.Injection.Sanitize != nil {
+		c.Review.Injection.Sanitize = *o.Review.Injection.Sanitize
+	}
+	if o.Review.Injection.Detector != "" {
+		c.Review.Injection.Detector = o.Review.Injection.Detector
+	}
+	if o.Review.Consensus.Executors != nil {
+		c.Review.Consensus.Executors = o.Review.Consensus.Executors
+	}
+	if o.Review.Consensus.Mode != "" {
+		c.Review.Consensus.Mode = o.Review.Consensus.Mode
+	}
+	if o.Review.Remote.Host != "" {
+		c.Review.Remote.Host = o.Review.Remote.Host
+	}
+	if o.Review.Remote.Dir != "" {
+		c.Review.Remote.Dir = o.Review.Remote.Dir
+	}
+	if o.Review.Remote.Sync != "" {
+		c.Review.Remote.Sync = o.Review.Remote.Sync
+	}
+	if o.Review.Remote.SSHFlags != nil {
+		c.Review.Remote.SSHFlags = o.Review.Remote.SSHFlags
+	}
+	if o.Review.IncludeDiff != nil {
+		c.Review.IncludeDiff = *o.Review.IncludeDiff
+	}
+	if o.Review.DiffLimit != nil {
+		c.Review.DiffLimit = *o.Review.DiffLimit
+	}
+	if o.Review.CodeOwners != nil {
+		c.Review.CodeOwners = *o.Review.CodeOwners
+	}
+	if o.Review.FileDeferred != nil {
+		c.Review.FileDeferred = *o.Review.FileDeferred
+	}
+	if o.Review.RepoSummary != nil {
+		c.Review.RepoSummary = *o.Review.RepoSummary
+	}
+	if o.Review.ContextFiles != nil {
+		c.Review.ContextFiles = *o.Review.ContextFiles
+	}
+	if o.Review.FinalCheck != nil {
+		c.Review.FinalCheck = *o.Review.FinalCheck
+	}
+	if o.Review.AcceptanceCheck != nil {
+		c.Review.AcceptanceCheck = *o.Review.AcceptanceCheck
+	}
+	if o.Review.FixExcerptLines != nil {
+		c.Review.FixExcerptLines = *o.Review.FixExcerptLines
+	}
+	if o.Review.FixGroupBy != "" {
+		c.Review.FixGroupBy = o.Review.FixGroupBy
+	}
+	if o.Review.FixMaxIssues != nil {
+		c.Review.FixMaxIssues = *o.Review.FixMaxIssues
+	}
+	if o.Review.FixSnippetLines != nil {
+		c.Review.FixSnippetLines = *o.Review.FixSnippetLines
+	}
+	if o.Review.ScoreThreshold != nil {
+		c.Review.ScoreThreshold = *o.Review.ScoreThreshold
+	}
+	if o.Review.Schedule != nil {
+		c.Review.Schedule = o.Review.Schedule
+	}
 
 	// Git
 	if o.Git.AutoCommit != nil {
@@ -365,6 +1518,200 @@ func (c *Config) applyOverlay(o *configOverlay) {
 	if o.Git.BranchPrefix != "" {
 		c.Git.BranchPrefix = o.Git.BranchPrefix
 	}
+	if o.Git.AutoPush != nil {
+		c.Git.AutoPush = *o.Git.AutoPush
+	}
+	if o.Git.PushRemote != "" {
+		c.Git.PushRemote = o.Git.PushRemote
+	}
+	if o.Git.PushEvery != nil {
+		c.Git.PushEvery = *o.Git.PushEvery
+	}
+	if o.Git.BaseBranch != "" {
+		c.Git.BaseBranch = o.Git.BaseBranch
+	}
+	if o.Git.BaseSync != "" {
+		c.Git.BaseSync = o.Git.BaseSync
+	}
+	if o.Git.PRComment != nil {
+		c.Git.PRComment = *o.Git.PRComment
+	}
+	if o.Git.PRStatus != nil {
+		c.Git.PRStatus = *o.Git.PRStatus
+	}
+	if o.Git.Squash != "" {
+		c.Git.Squash = o.Git.Squash
+	}
+	if o.Git.SignOffPaths != nil {
+		c.Git.SignOffPaths = o.Git.SignOffPaths
+	}
+	if o.Git.Snapshots.Enabled != nil {
+		c.Git.Snapshots.Enabled = *o.Git.Snapshots.Enabled
+	}
+	if o.Git.Snapshots.Squash != nil {
+		c.Git.Snapshots.Squash = *o.Git.Snapshots.Squash
+	}
+
+	// Supervisor
+	if o.Supervisor.Enabled != nil {
+		c.Supervisor.Enabled = *o.Supervisor.Enabled
+	}
+	if o.Supervisor.MaxInterventions != nil {
+		c.Supervisor.MaxInterventions = *o.Supervisor.MaxInterventions
+	}
+	// Phase splitting
+	if o.Split.Enabled != nil {
+		c.Split.Enabled = *o.Split.Enabled
+	}
+	if o.Split.AfterAttempts != nil {
+		c.Split.AfterAttempts = *o.Split.AfterAttempts
+	}
+	if o.Split.MaxPhaseLength != nil {
+		c.Split.MaxPhaseLength = *o.Split.MaxPhaseLength
+	}
+	// Diff scan
+	if o.DiffScan.Enabled != nil {
+		c.DiffScan.Enabled = *o.DiffScan.Enabled
+	}
+	if o.DiffScan.DefaultRules != nil {
+		c.DiffScan.DefaultRules = *o.DiffScan.DefaultRules
+	}
+	if o.DiffScan.Rules != nil {
+		c.DiffScan.Rules = o.DiffScan.Rules
+	}
+
+	// Coverage gate
+	if o.Coverage.Enabled != nil {
+		c.Coverage.Enabled = *o.Coverage.Enabled
+	}
+	if o.Coverage.Threshold != nil {
+		c.Coverage.Threshold = *o.Coverage.Threshold
+	}
+	if o.Coverage.MaxFixIterations != nil {
+		c.Coverage.MaxFixIterations = *o.Coverage.MaxFixIterations
+	}
+
+	// Executor fallback
+	if o.Fallback.After != nil {
+		c.Fallback.After = *o.Fallback.After
+	}
+	if o.Fallback.Chain != nil {
+		c.Fallback.Chain = o.Fallback.Chain
+	}
+
+	// UI
+	if o.UI.Theme != "" {
+		c.UI.Theme = o.UI.Theme
+	}
+	if len(o.UI.Colors) > 0 {
+		colors := make(map[string]int, len(c.UI.Colors)+len(o.UI.Colors))
+		maps.Copy(colors, c.UI.Colors)
+		maps.Copy(colors, o.UI.Colors)
+		c.UI.Colors = colors
+	}
+	if o.UI.Keys.SkipPhase != "" {
+		c.UI.Keys.SkipPhase = o.UI.Keys.SkipPhase
+	}
+	if o.UI.Keys.Interrupt != "" {
+		c.UI.Keys.Interrupt = o.UI.Keys.Interrupt
+	}
+	if o.UI.Keys.Issues != "" {
+		c.UI.Keys.Issues = o.UI.Keys.Issues
+	}
+	if o.UI.CostWarning != nil {
+		c.UI.CostWarning = *o.UI.CostWarning
+	}
+	if o.UI.Diff != "" {
+		c.UI.Diff = o.UI.Diff
+	}
+
+	if o.Guard.Mode != "" {
+		c.Guard.Mode = o.Guard.Mode
+	}
+
+	c.applyNotificationsOverlay(&o.Notifications)
+
+	if o.Preflight.Enabled != nil {
+		c.Preflight.Enabled = *o.Preflight.Enabled
+	}
+	if o.Preflight.MinFreeDiskMB != nil {
+		c.Preflight.MinFreeDiskMB = *o.Preflight.MinFreeDiskMB
+	}
+	if o.Preflight.Validation != nil {
+		c.Preflight.Validation = *o.Preflight.Validation
+	}
+	if o.Preflight.PingExecutor != nil {
+		c.Preflight.PingExecutor = *o.Preflight.PingExecutor
+	}
+	if o.Artifacts.Enabled != nil {
+		c.Artifacts.Enabled = *o.Artifacts.Enabled
+	}
+	if o.Artifacts.Keep != nil {
+		c.Artifacts.Keep = *o.Artifacts.Keep
+	}
+	if o.StatusBlock.Key != "" {
+		c.StatusBlock.Key = o.StatusBlock.Key
+	}
+	if o.StatusBlock.Nonce != nil {
+		c.StatusBlock.Nonce = *o.StatusBlock.Nonce
+	}
+	if o.Pacing.Cooldown != nil {
+		c.Pacing.Cooldown = *o.Pacing.Cooldown
+	}
+	if o.Pacing.Adaptive != nil {
+		c.Pacing.Adaptive = *o.Pacing.Adaptive
+	}
+	if o.Pacing.MaxCooldown != nil {
+		c.Pacing.MaxCooldown = *o.Pacing.MaxCooldown
+	}
+	if o.Pacing.WorkHours != "" {
+		c.Pacing.WorkHours = o.Pacing.WorkHours
+	}
+	if o.ActiveFiles.StatusFile != nil {
+		c.ActiveFiles.StatusFile = *o.ActiveFiles.StatusFile
+	}
+	if o.ActiveFiles.OnConflict != "" {
+		c.ActiveFiles.OnConflict = o.ActiveFiles.OnConflict
+	}
+	if o.ActiveFiles.QuietPeriod != nil {
+		c.ActiveFiles.QuietPeriod = *o.ActiveFiles.QuietPeriod
+	}
+
+	c.Notes.NoteStyleConfig.merge(o.Notes.NoteStyleConfig)
+	if len(o.Notes.Sources) > 0 {
+		sources := make(map[string]NoteStyleConfig, len(c.Notes.Sources)+len(o.Notes.Sources))
+		maps.Copy(sources, c.Notes.Sources)
+		for sourceType, style := range o.Notes.Sources {
+			merged := sources[sourceType]
+			merged.merge(style)
+			sources[sourceType] = merged
+		}
+		c.Notes.Sources = sources
+	}
+
+	if len(o.ExecutorEnv) > 0 {
+		env := make(map[string]string, len(c.ExecutorEnv)+len(o.ExecutorEnv))
+		maps.Copy(env, c.ExecutorEnv)
+		maps.Copy(env, o.ExecutorEnv)
+		c.ExecutorEnv = env
+	}
+	if len(o.TicketWorkspaces) > 0 {
+		workspaces := make(map[string]ticket.Workspace, len(c.TicketWorkspaces)+len(o.TicketWorkspaces))
+		maps.Copy(workspaces, c.TicketWorkspaces)
+		maps.Copy(workspaces, o.TicketWorkspaces)
+		c.TicketWorkspaces = workspaces
+	}
+
+	if len(o.Pricing) > 0 {
+		pricing := make(map[string]llm.Price, len(c.Pricing)+len(o.Pricing))
+		maps.Copy(pricing, c.Pricing)
+		maps.Copy(pricing, o.Pricing)
+		c.Pricing = pricing
+	}
+
+	if o.Schedules != nil {
+		c.Schedules = o.Schedules
+	}
 }
 
 func applyReviewExecutorOverlay(dst *ReviewExecutorConfig, src *ReviewExecutorConfig) {
@@ -375,6 +1722,7 @@ func applyReviewExecutorOverlay(dst *ReviewExecutorConfig, src *ReviewExecutorCo
 	if src.Claude.Flags != "" {
 		dst.Claude.Flags = src.Claude.Flags
 	}
+	dst.Claude.PurposeFlags.merge(src.Claude.PurposeFlags)
 	if src.Claude.ConfigDir != "" {
 		dst.Claude.ConfigDir = src.Claude.ConfigDir
 	}
@@ -382,10 +1730,14 @@ func applyReviewExecutorOverlay(dst *ReviewExecutorConfig, src *ReviewExecutorCo
 		log.Printf("warning: review.executor.claude.anthropic_api_key loaded from config file — ensure this is a trusted source")
 		dst.Claude.AnthropicAPIKey = src.Claude.AnthropicAPIKey
 	}
+	if src.Claude.ContextWindow != 0 {
+		dst.Claude.ContextWindow = src.Claude.ContextWindow
+	}
 
 	if src.Pi.Flags != "" {
 		dst.Pi.Flags = src.Pi.Flags
 	}
+	dst.Pi.PurposeFlags.merge(src.Pi.PurposeFlags)
 	if src.Pi.ConfigDir != "" {
 		dst.Pi.ConfigDir = src.Pi.ConfigDir
 	}
@@ -399,10 +1751,14 @@ func applyReviewExecutorOverlay(dst *ReviewExecutorConfig, src *ReviewExecutorCo
 		log.Printf("warning: review.executor.pi.api_key loaded from config file — ensure this is a trusted source")
 		dst.Pi.APIKey = src.Pi.APIKey
 	}
+	if src.Pi.ContextWindow != 0 {
+		dst.Pi.ContextWindow = src.Pi.ContextWindow
+	}
 
 	if src.OpenCode.Flags != "" {
 		dst.OpenCode.Flags = src.OpenCode.Flags
 	}
+	dst.OpenCode.PurposeFlags.merge(src.OpenCode.PurposeFlags)
 	if src.OpenCode.ConfigDir != "" {
 		dst.OpenCode.ConfigDir = src.OpenCode.ConfigDir
 	}
@@ -413,10 +1769,14 @@ func applyReviewExecutorOverlay(dst *ReviewExecutorConfig, src *ReviewExecutorCo
 		log.Printf("warning: review.executor.opencode.api_key loaded from config file — ensure this is a trusted source")
 		dst.OpenCode.APIKey = src.OpenCode.APIKey
 	}
+	if src.OpenCode.ContextWindow != 0 {
+		dst.OpenCode.ContextWindow = src.OpenCode.ContextWindow
+	}
 
 	if src.Codex.Flags != "" {
 		dst.Codex.Flags = src.Codex.Flags
 	}
+	dst.Codex.PurposeFlags.merge(src.Codex.PurposeFlags)
 	if src.Codex.Model != "" {
 		dst.Codex.Model = src.Codex.Model
 	}
@@ -424,12 +1784,16 @@ func applyReviewExecutorOverlay(dst *ReviewExecutorConfig, src *ReviewExecutorCo
 		log.Printf("warning: review.executor.codex.api_key loaded from config file — ensure this is a trusted source")
 		dst.Codex.APIKey = src.Codex.APIKey
 	}
+	if src.Codex.ContextWindow != 0 {
+		dst.Codex.ContextWindow = src.Codex.ContextWindow
+	}
 }
 
 func applyCodexOverlay(dst *CodexConfig, src *CodexConfig) {
 	if src.Flags != "" {
 		dst.Flags = src.Flags
 	}
+	dst.PurposeFlags.merge(src.PurposeFlags)
 	if src.Model != "" {
 		dst.Model = src.Model
 	}
@@ -437,12 +1801,19 @@ func applyCodexOverlay(dst *CodexConfig, src *CodexConfig) {
 		log.Printf("warning: codex.api_key loaded from config file — ensure this is a trusted source")
 		dst.APIKey = src.APIKey
 	}
+	if src.ContextWindow != 0 {
+		dst.ContextWindow = src.ContextWindow
+	}
+	if src.Sandbox != "" {
+		dst.Sandbox = src.Sandbox
+	}
 }
 
 func applyOpenCodeOverlay(dst *OpenCodeConfig, src *OpenCodeConfig) {
 	if src.Flags != "" {
 		dst.Flags = src.Flags
 	}
+	dst.PurposeFlags.merge(src.PurposeFlags)
 	if src.ConfigDir != "" {
 		dst.ConfigDir = src.ConfigDir
 	}
@@ -453,6 +1824,9 @@ func applyOpenCodeOverlay(dst *OpenCodeConfig, src *OpenCodeConfig) {
 		log.Printf("warning: opencode.api_key loaded from config file — ensure this is a trusted source")
 		dst.APIKey = src.APIKey
 	}
+	if src.ContextWindow != 0 {
+		dst.ContextWindow = src.ContextWindow
+	}
 }
 
 // applyEnvOverrides applies environment variable overrides to the config.
@@ -480,3 +1854,59 @@ func (c *Config) ApplyCLIFlags(maxIterations, stagnationLimit, timeout int) {
 		c.sources = append(c.sources, "cli:timeout")
 	}
 }
+
+// ApplyEnvFlags sets the KEY=VALUE assignments of --env flags in
+// ExecutorEnv, on top of the configured variables.
+func (c *Config) ApplyEnvFlags(assignments []string) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	env := maps.Clone(c.ExecutorEnv)
+	if env == nil {
+		env = make(map[string]string, len(assignments))
+	}
+	for _, a := range assignments {
+		name, value, ok := strings.Cut(a, "=")
+		if !ok || !envNameRe.MatchString(name) {
+			return fmt.Errorf("invalid --env %q (want KEY=VALUE)", a)
+		}
+		env[name] = value
+	}
+	c.ExecutorEnv = env
+	c.sources = append(c.sources, "cli:env")
+	return nil
+}
+
+func (c *Config) applyNotificationsOverlay(o *notificationsOverlay) {
+	if o.OnExit != nil {
+		c.Notifications.OnExit = o.OnExit
+	}
+	if o.Desktop.Enabled != nil {
+		c.Notifications.Desktop.Enabled = *o.Desktop.Enabled
+	}
+	email := &c.Notifications.Email
+	if o.Email.Enabled != nil {
+		email.Enabled = *o.Email.Enabled
+	}
+	if o.Email.Host != "" {
+		email.Host = o.Email.Host
+	}
+	if o.Email.Port != nil {
+		email.Port = *o.Email.Port
+	}
+	if o.Email.Username != "" {
+		email.Username = o.Email.Username
+	}
+	if o.Email.PasswordEnv != "" {
+		email.PasswordEnv = o.Email.PasswordEnv
+	}
+	if o.Email.From != "" {
+		email.From = o.Email.From
+	}
+	if o.Email.To != nil {
+		email.To = o.Email.To
+	}
+	if o.Owners != nil {
+		c.Notifications.Owners = o.Owners
+	}
+}
@@ -7,6 +7,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/messages"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/ticket"
 )
 
 func TestLoadEmbedded(t *testing.T) {
@@ -16,13 +22,99 @@ func TestLoadEmbedded(t *testing.T) {
 	assert.Equal(t, 50, cfg.MaxIterations)
 	assert.Equal(t, 3, cfg.StagnationLimit)
 	assert.Equal(t, 2700, cfg.Timeout)
+	assert.Equal(t, 0, cfg.LivenessTimeout)
+	assert.Equal(t, "session", cfg.LimitScope)
+	assert.Equal(t, "warn", cfg.ContextOverflow)
+	assert.Equal(t, "en", cfg.Language)
+	assert.Equal(t, 0, cfg.Claude.ContextWindow)
+	assert.Equal(t, 1, cfg.ParallelPhases)
+	assert.False(t, cfg.ReuseSessions)
+	assert.Equal(t, 0, cfg.MaxConcurrentInvocations)
+	assert.False(t, cfg.DocsPhase)
+	assert.False(t, cfg.AutoPhase)
+	assert.True(t, cfg.VerifyFilesChanged)
+	assert.False(t, cfg.MinimalPermissions)
+	assert.Empty(t, cfg.ValidationCommands)
 	assert.Equal(t, "claude", cfg.Executor)
+	assert.Empty(t, cfg.TicketWorkspaces)
+	assert.Empty(t, cfg.ExecutorEnv)
 	assert.Equal(t, "", cfg.Claude.Flags)
+	assert.Equal(t, PurposeFlags{}, cfg.Claude.PurposeFlags)
 	assert.Equal(t, 3, cfg.Review.MaxIterations)
 	assert.True(t, cfg.Review.Parallel)
+	assert.False(t, cfg.Review.IncludeDiff)
+	assert.Equal(t, 100000, cfg.Review.DiffLimit)
+	assert.True(t, cfg.Review.CodeOwners)
+	assert.False(t, cfg.Review.FileDeferred)
+	assert.False(t, cfg.Review.RepoSummary)
+	assert.Equal(t, 0, cfg.Review.ContextFiles)
+	assert.False(t, cfg.Review.FinalCheck)
+	assert.False(t, cfg.Review.AcceptanceCheck)
+	assert.Empty(t, cfg.Review.Remote.Host)
+	assert.Equal(t, "rsync", cfg.Review.Remote.Sync)
+	assert.Equal(t, 120, cfg.Review.FixExcerptLines)
+	assert.Equal(t, "agent", cfg.Review.FixGroupBy)
+	assert.Equal(t, 0, cfg.Review.FixMaxIssues)
+	assert.Equal(t, 0, cfg.Review.FixSnippetLines)
+	assert.Equal(t, 0, cfg.Review.ScoreThreshold)
+	assert.Empty(t, cfg.Review.Schedule)
 	assert.Empty(t, cfg.Review.Agents)
 	assert.True(t, cfg.Review.Validators.Issue)
 	assert.True(t, cfg.Review.Validators.Simplification)
+	assert.Equal(t, "auto", cfg.Guard.Mode)
+	assert.Equal(t, "all", cfg.Notes.Level)
+	assert.Empty(t, cfg.Notes.Template)
+	assert.Empty(t, cfg.Notes.Sources)
+	assert.Empty(t, cfg.Notifications.OnExit)
+	assert.False(t, cfg.Notifications.Desktop.Enabled)
+	assert.False(t, cfg.Notifications.Email.Enabled)
+	assert.Equal(t, 587, cfg.Notifications.Email.Port)
+	assert.Equal(t, "PROGRAMMATOR_SMTP_PASSWORD", cfg.Notifications.Email.PasswordEnv)
+	assert.Empty(t, cfg.Notifications.Owners)
+	assert.True(t, cfg.Review.Validators.IssueWhen.IsZero())
+	assert.True(t, cfg.Review.Validators.SimplificationWhen.IsZero())
+	assert.Equal(t, 300, cfg.Review.Validators.Timeout)
+	assert.True(t, cfg.Review.Recurring.Enabled)
+	assert.Equal(t, 0, cfg.Review.Recurring.EscalateAfter)
+	assert.False(t, cfg.Review.Patches.Suggest)
+	assert.True(t, cfg.Review.Patches.AutoApply)
+	assert.True(t, cfg.Review.Injection.Sanitize)
+	assert.Equal(t, "warn", cfg.Review.Injection.Detector)
+	assert.Empty(t, cfg.Review.Consensus.Executors)
+	assert.Equal(t, "intersect", cfg.Review.Consensus.Mode)
+	assert.False(t, cfg.Supervisor.Enabled)
+	assert.Equal(t, 2, cfg.Supervisor.MaxInterventions)
+	assert.False(t, cfg.Split.Enabled)
+	assert.Equal(t, 3, cfg.Split.AfterAttempts)
+	assert.Equal(t, 500, cfg.Split.MaxPhaseLength)
+	assert.Equal(t, CoverageConfig{Threshold: 80, MaxFixIterations: 2}, cfg.Coverage)
+	assert.Equal(t, 2, cfg.Fallback.After)
+	assert.Empty(t, cfg.Fallback.Chain)
+	assert.False(t, cfg.DiffScan.Enabled)
+	assert.True(t, cfg.DiffScan.DefaultRules)
+	assert.Empty(t, cfg.DiffScan.Rules)
+	assert.False(t, cfg.Git.AutoPush)
+	assert.Equal(t, "", cfg.Git.PushRemote)
+	assert.Equal(t, 1, cfg.Git.PushEvery)
+	assert.Equal(t, "main", cfg.Git.BaseBranch)
+	assert.Equal(t, "off", cfg.Git.BaseSync)
+	assert.False(t, cfg.Git.PRComment)
+	assert.False(t, cfg.Git.PRStatus)
+	assert.Equal(t, GitSnapshotsConfig{}, cfg.Git.Snapshots)
+	assert.Equal(t, PreflightConfig{MinFreeDiskMB: 1024, Validation: true, PingExecutor: true}, cfg.Preflight)
+	assert.Equal(t, ArtifactsConfig{Keep: 10}, cfg.Artifacts)
+	assert.Equal(t, StatusBlockConfig{Key: "PROGRAMMATOR_STATUS"}, cfg.StatusBlock)
+	assert.Equal(t, PacingConfig{MaxCooldown: 600}, cfg.Pacing)
+	assert.Equal(t, ActiveFilesConfig{OnConflict: "warn", QuietPeriod: 30}, cfg.ActiveFiles)
+	assert.Empty(t, cfg.Schedules)
+	assert.Equal(t, "default", cfg.UI.Theme)
+	assert.Empty(t, cfg.UI.Colors)
+	assert.Equal(t, "s", cfg.UI.Keys.SkipPhase)
+	assert.Equal(t, "i", cfg.UI.Keys.Issues)
+	assert.Equal(t, "ctrl+c", cfg.UI.Keys.Interrupt)
+	assert.Zero(t, cfg.UI.CostWarning)
+	assert.Equal(t, "unified", cfg.UI.Diff)
+	assert.Empty(t, cfg.Pricing)
 }
 
 func TestLoadWithDirs_GlobalOnly(t *testing.T) {
@@ -43,6 +135,23 @@ func TestLoadWithDirs_GlobalOnly(t *testing.T) {
 	assert.Equal(t, 2700, cfg.Timeout) // from embedded default
 }
 
+func TestLoadWithDirs_Messages(t *testing.T) {
+	globalDir := t.TempDir()
+	localDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.yaml"), []byte("language: de\n"), 0o600))
+
+	_, err := LoadWithDirs(globalDir, localDir)
+	require.ErrorContains(t, err, "no messages/de.yaml")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(globalDir, "messages"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(globalDir, "messages", "de.yaml"), []byte("loop.review_skipped: \"Review übersprungen\"\n"), 0o600))
+
+	cfg, err := LoadWithDirs(globalDir, localDir)
+	require.NoError(t, err)
+	assert.Equal(t, "de", cfg.Messages.Lang())
+	assert.Equal(t, "Review übersprungen", cfg.Messages.Format(messages.ReviewSkipped, nil))
+}
+
 func TestLoadWithDirs_LocalOverridesGlobal(t *testing.T) {
 	globalDir := t.TempDir()
 	localDir := t.TempDir()
@@ -69,6 +178,41 @@ func TestLoadWithDirs_LocalOverridesGlobal(t *testing.T) {
 	assert.Equal(t, 2700, cfg.Timeout)      // from embedded default
 }
 
+func TestLoadWithDirs_ReviewSizeRules(t *testing.T) {
+	globalDir := t.TempDir()
+	err := os.WriteFile(
+		filepath.Join(globalDir, "config.yaml"),
+		[]byte("review:\n  validators:\n    issue_when: {max_lines: 5000}\n  overrides:\n    - name: architect\n      when: {min_lines: 200, min_files: 3}\n"),
+		0o600,
+	)
+	require.NoError(t, err)
+
+	cfg, err := LoadWithDirs(globalDir, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, review.SizeRule{MaxLines: 5000}, cfg.Review.Validators.IssueWhen)
+	assert.True(t, cfg.Review.Validators.Issue) // from embedded default
+	require.Len(t, cfg.Review.Overrides, 1)
+	assert.Equal(t, review.SizeRule{MinLines: 200, MinFiles: 3}, cfg.Review.Overrides[0].When)
+}
+
+func TestLoadWithDirs_GitSnapshots(t *testing.T) {
+	globalDir := t.TempDir()
+	localDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(globalDir, "config.yaml"),
+		[]byte("git:\n  snapshots:\n    enabled: true\n    squash: true\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.yaml"),
+		[]byte("git:\n  snapshots:\n    squash: false\n"), 0o600))
+
+	cfg, err := LoadWithDirs(globalDir, localDir)
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Git.Snapshots.Enabled, "kept from the global config")
+	assert.False(t, cfg.Git.Snapshots.Squash)
+	assert.Empty(t, cfg.Git.SignOffPaths)
+	assert.Equal(t, "off", cfg.Git.Squash)
+}
+
 func TestLoadWithDirs_LocalOverridesWithZero(t *testing.T) {
 	globalDir := t.TempDir()
 	localDir := t.TempDir()
@@ -95,6 +239,163 @@ func TestLoadWithDirs_LocalOverridesWithZero(t *testing.T) {
 	assert.Equal(t, 0, cfg.StagnationLimit) // local overrides to 0
 }
 
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+	nested := filepath.Join(root, "pkg", "sub")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+
+	assert.Empty(t, FindProjectFile(nested))
+
+	projectFile := filepath.Join(root, ProjectConfigFile)
+	require.NoError(t, os.WriteFile(projectFile, []byte("max_iterations: 10\n"), 0o600))
+	assert.Equal(t, projectFile, FindProjectFile(nested))
+	assert.Equal(t, projectFile, FindProjectFile(root))
+
+	// The search stops at the repository root.
+	inner := filepath.Join(root, "vendor", "lib")
+	require.NoError(t, os.MkdirAll(filepath.Join(inner, ".git"), 0o755))
+	assert.Empty(t, FindProjectFile(inner))
+}
+
+func TestLoadLayers_ProjectFile(t *testing.T) {
+	globalDir := t.TempDir()
+	localDir := t.TempDir()
+	projectFile := filepath.Join(t.TempDir(), ProjectConfigFile)
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(globalDir, "config.yaml"),
+		[]byte("max_iterations: 100\nstagnation_limit: 5\ngit:\n  branch_prefix: me/\n"),
+		0o600,
+	))
+	require.NoError(t, os.WriteFile(
+		projectFile,
+		[]byte("max_iterations: 40\nstagnation_limit: 4\nvalidation_commands: [\"make check\"]\ngit:\n  branch_prefix: team/\n  auto_push: true\n"),
+		0o600,
+	))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(localDir, "config.yaml"),
+		[]byte("stagnation_limit: 2\n"),
+		0o600,
+	))
+
+	cfg, err := loadLayers(globalDir, projectFile, localDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, 40, cfg.MaxIterations)  // project overrides global
+	assert.Equal(t, 2, cfg.StagnationLimit) // local overrides project
+	assert.Equal(t, "team/", cfg.Git.BranchPrefix)
+	assert.True(t, cfg.Git.AutoPush)
+	assert.Equal(t, []string{"make check"}, cfg.ValidationCommands)
+	assert.Equal(t, projectFile, cfg.ProjectFile())
+	assert.Contains(t, cfg.Sources(), projectFile)
+}
+
+func TestLoadLayers_ProjectFileCannotPickExecutor(t *testing.T) {
+	globalDir := t.TempDir()
+	projectFile := filepath.Join(t.TempDir(), ProjectConfigFile)
+	require.NoError(t, os.WriteFile(
+		filepath.Join(globalDir, "config.yaml"),
+		[]byte("executor: codex\ncodex:\n  flags: --full-auto\n"),
+		0o600,
+	))
+	require.NoError(t, os.WriteFile(projectFile, []byte(`executor: claude
+max_iterations: 40
+claude:
+  flags: --dangerously-skip-permissions
+  purpose_flags:
+    review: --allowedTools Bash
+  config_dir: /tmp/claude
+  context_window: 100000
+codex:
+  sandbox: danger-full-access
+  model: o3
+executor_env:
+  PATH: /tmp/bin
+review:
+  executor:
+    name: pi
+  consensus:
+    executors: [claude, codex]
+  remote:
+    ssh_flags: ["-o", "ProxyCommand=sh"]
+`), 0o600))
+
+	cfg, err := loadLayers(globalDir, projectFile, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, 40, cfg.MaxIterations)
+	assert.Equal(t, "codex", cfg.Executor)
+	assert.Equal(t, "--full-auto", cfg.Codex.Flags)
+	assert.Empty(t, cfg.Claude.ConfigDir)
+	assert.Empty(t, cfg.Claude.PurposeFlags.Review)
+	assert.Equal(t, 100000, cfg.Claude.ContextWindow, "settings other than the executor's still apply")
+	assert.Equal(t, "o3", cfg.Codex.Model)
+	assert.Empty(t, cfg.Codex.Sandbox)
+	assert.NotContains(t, cfg.ExecutorEnv, "PATH")
+	assert.Empty(t, cfg.Review.Executor.Name)
+	assert.Empty(t, cfg.Review.Consensus.Executors)
+	assert.Empty(t, cfg.Review.Remote.SSHFlags)
+
+	var overlay configOverlay
+	overlay.Claude.Flags = "--verbose"
+	overlay.MaxIterations = new(int)
+	assert.Equal(t, []string{"claude.flags"}, overlay.dropProjectRestricted())
+	assert.Empty(t, overlay.Claude.Flags)
+	assert.NotNil(t, overlay.MaxIterations)
+}
+
+func TestLoadLayers_Profile(t *testing.T) {
+	globalDir := t.TempDir()
+	localDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(globalDir, "profiles"), 0o755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(globalDir, "profiles", "grind.yaml"),
+		[]byte("max_iterations: 200\ngit:\n  auto_commit: true\n"),
+		0o600,
+	))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(localDir, "config.yaml"),
+		[]byte("max_iterations: 10\nstagnation_limit: 2\n"),
+		0o600,
+	))
+
+	t.Run("named profile overrides local", func(t *testing.T) {
+		t.Setenv(ProfileEnv, "grind")
+		cfg, err := loadLayers(globalDir, "", localDir)
+		require.NoError(t, err)
+
+		profilePath := filepath.Join(globalDir, "profiles", "grind.yaml")
+		assert.Equal(t, 200, cfg.MaxIterations)
+		assert.Equal(t, 2, cfg.StagnationLimit)
+		assert.True(t, cfg.Git.AutoCommit)
+		assert.Equal(t, profilePath, cfg.Profile())
+		assert.Contains(t, cfg.Sources(), profilePath)
+	})
+
+	t.Run("missing profile is an error", func(t *testing.T) {
+		t.Setenv(ProfileEnv, "nope")
+		_, err := loadLayers(globalDir, "", localDir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `load profile "nope"`)
+	})
+}
+
+func TestProfilePath(t *testing.T) {
+	assert.Equal(t, filepath.Join("/cfg", "profiles", "night.yaml"), ProfilePath("/cfg", "night"))
+	assert.Equal(t, "/tmp/night.yaml", ProfilePath("/cfg", "/tmp/night.yaml"))
+	assert.Equal(t, "night.yml", ProfilePath("/cfg", "night.yml"))
+}
+
+func TestLoadLayers_InvalidProjectFile(t *testing.T) {
+	projectFile := filepath.Join(t.TempDir(), ProjectConfigFile)
+	require.NoError(t, os.WriteFile(projectFile, []byte("max_iterations: [\n"), 0o600))
+
+	_, err := loadLayers(t.TempDir(), projectFile, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "load project config")
+}
+
 func TestApplyCLIFlags(t *testing.T) {
 	cfg, err := loadEmbedded()
 	require.NoError(t, err)
@@ -106,6 +407,22 @@ func TestApplyCLIFlags(t *testing.T) {
 	assert.Equal(t, 1800, cfg.Timeout)
 }
 
+func TestApplyEnvFlags(t *testing.T) {
+	cfg := &Config{ExecutorEnv: map[string]string{"API_URL": "https://staging", "FEATURE_X": "0"}}
+
+	require.NoError(t, cfg.ApplyEnvFlags([]string{"FEATURE_X=1", "HTTPS_PROXY=http://proxy:3128", "EMPTY="}))
+	assert.Equal(t, map[string]string{
+		"API_URL":     "https://staging",
+		"FEATURE_X":   "1",
+		"HTTPS_PROXY": "http://proxy:3128",
+		"EMPTY":       "",
+	}, cfg.ExecutorEnv)
+	assert.Contains(t, cfg.Sources(), "cli:env")
+
+	require.ErrorContains(t, cfg.ApplyEnvFlags([]string{"FEATURE_X"}), `invalid --env "FEATURE_X"`)
+	require.ErrorContains(t, cfg.ApplyEnvFlags([]string{"1X=a"}), `invalid --env "1X=a"`)
+}
+
 func TestApplyCLIFlagsZeroNoOverride(t *testing.T) {
 	cfg, err := loadEmbedded()
 	require.NoError(t, err)
@@ -302,6 +619,17 @@ func TestApplyOverlay_PointerFields(t *testing.T) {
 	assert.Equal(t, 2700, base.Timeout)      // unchanged (nil)
 }
 
+func TestApplyOverlay_ParallelPhases(t *testing.T) {
+	base := &Config{ParallelPhases: 1}
+
+	base.applyOverlay(&configOverlay{})
+	assert.Equal(t, 1, base.ParallelPhases)
+
+	four := 4
+	base.applyOverlay(&configOverlay{ParallelPhases: &four})
+	assert.Equal(t, 4, base.ParallelPhases)
+}
+
 func TestLoadWithDirs_ExecutorConfig(t *testing.T) {
 	for _, key := range []string{"CLAUDE_CONFIG_DIR", "PROGRAMMATOR_CLAUDE_FLAGS", "PROGRAMMATOR_ANTHROPIC_API_KEY", "PROGRAMMATOR_EXECUTOR"} {
 		saved := os.Getenv(key)
@@ -316,6 +644,8 @@ executor: claude
 claude:
   flags: "--verbose"
   config_dir: "/custom/dir"
+  purpose_flags:
+    review: "--model opus"
 `
 	err := os.WriteFile(filepath.Join(globalDir, "config.yaml"), []byte(configContent), 0o600)
 	require.NoError(t, err)
@@ -326,6 +656,7 @@ claude:
 	assert.Equal(t, "claude", cfg.Executor)
 	assert.Equal(t, "--verbose", cfg.Claude.Flags)
 	assert.Equal(t, "/custom/dir", cfg.Claude.ConfigDir)
+	assert.Equal(t, "--model opus", cfg.Claude.PurposeFlags.Review)
 }
 
 func TestApplyEnvOverrides_ClaudeConfigDir(t *testing.T) {
@@ -441,3 +772,530 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate_ReviewConsensus(t *testing.T) {
+	tests := []struct {
+		name      string
+		consensus ReviewConsensusConfig
+		wantErr   string
+	}{
+		{name: "disabled", consensus: ReviewConsensusConfig{}},
+		{name: "two executors", consensus: ReviewConsensusConfig{Executors: []string{"claude", "codex"}, Mode: "union"}},
+		{name: "unknown mode", consensus: ReviewConsensusConfig{Mode: "majority"}, wantErr: "unknown review.consensus.mode"},
+		{name: "unknown executor", consensus: ReviewConsensusConfig{Executors: []string{"claude", "gpt"}}, wantErr: `"gpt"`},
+		{name: "empty executor", consensus: ReviewConsensusConfig{Executors: []string{"claude", ""}}, wantErr: `""`},
+		{name: "duplicate", consensus: ReviewConsensusConfig{Executors: []string{"codex", "codex"}}, wantErr: "twice"},
+		{name: "single executor", consensus: ReviewConsensusConfig{Executors: []string{"codex"}}, wantErr: "at least two"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Review: ReviewConfig{Consensus: tc.consensus}}
+			err := cfg.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestValidate_ReviewRemote(t *testing.T) {
+	tests := []struct {
+		name    string
+		review  ReviewConfig
+		wantErr string
+	}{
+		{name: "disabled", review: ReviewConfig{}},
+		{name: "rsync", review: ReviewConfig{Remote: ReviewRemoteConfig{Host: "devbox", Dir: "/src/app", Sync: "rsync"}}},
+		{name: "codex", review: ReviewConfig{Executor: ReviewExecutorConfig{Name: "codex"}, Remote: ReviewRemoteConfig{Host: "devbox", Dir: "/src/app"}}},
+		{name: "missing dir", review: ReviewConfig{Remote: ReviewRemoteConfig{Host: "devbox"}}, wantErr: "review.remote.dir is required"},
+		{name: "option as host", review: ReviewConfig{Remote: ReviewRemoteConfig{Host: "-oProxyCommand=x", Dir: "/src"}}, wantErr: "invalid review.remote.host"},
+		{name: "unknown sync", review: ReviewConfig{Remote: ReviewRemoteConfig{Host: "devbox", Dir: "/src", Sync: "scp"}}, wantErr: "unknown review.remote.sync"},
+		{name: "unsupported executor", review: ReviewConfig{Executor: ReviewExecutorConfig{Name: "pi"}, Remote: ReviewRemoteConfig{Host: "devbox", Dir: "/src"}}, wantErr: `executor "pi" cannot run remotely`},
+		{
+			name: "unsupported consensus executor",
+			review: ReviewConfig{
+				Consensus: ReviewConsensusConfig{Executors: []string{"claude", "opencode"}},
+				Remote:    ReviewRemoteConfig{Host: "devbox", Dir: "/src"},
+			},
+			wantErr: `executor "opencode" cannot run remotely`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Review: tc.review}
+			err := cfg.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestValidate_ReviewValidatorSizeRules(t *testing.T) {
+	cfg := &Config{Review: ReviewConfig{Validators: ReviewValidatorsConfig{IssueWhen: review.SizeRule{MaxFiles: 10}}}}
+	require.NoError(t, cfg.Validate())
+
+	cfg.Review.Validators.SimplificationWhen = review.SizeRule{MinLines: -1}
+	require.ErrorContains(t, cfg.Validate(), "review.validators.simplification_when: size bounds must not be negative")
+}
+
+func TestValidate_ReviewValidatorTimeout(t *testing.T) {
+	cfg := &Config{Review: ReviewConfig{Validators: ReviewValidatorsConfig{Timeout: 0}}}
+	require.NoError(t, cfg.Validate())
+
+	cfg.Review.Validators.Timeout = -1
+	require.ErrorContains(t, cfg.Validate(), "review.validators.timeout must be >= 0")
+}
+
+func TestValidate_GuardMode(t *testing.T) {
+	for _, mode := range []string{"", "auto", "require", "off"} {
+		cfg := &Config{Guard: GuardConfig{Mode: mode}}
+		require.NoError(t, cfg.Validate(), mode)
+	}
+	cfg := &Config{Guard: GuardConfig{Mode: "strict"}}
+	require.ErrorContains(t, cfg.Validate(), `unknown guard.mode "strict"`)
+}
+
+func TestValidate_GitSquash(t *testing.T) {
+	for _, mode := range []string{"", "off", "phase", "run"} {
+		cfg := &Config{Git: GitConfig{Squash: mode}}
+		require.NoError(t, cfg.Validate(), mode)
+	}
+	cfg := &Config{Git: GitConfig{Squash: "commit"}}
+	require.ErrorContains(t, cfg.Validate(), `unknown git.squash "commit"`)
+}
+
+func TestValidate_GitSignOffPaths(t *testing.T) {
+	cfg := &Config{Git: GitConfig{SignOffPaths: []string{`^migrations/`, `(^|/)auth/`}}}
+	require.NoError(t, cfg.Validate())
+
+	cfg.Git.SignOffPaths = append(cfg.Git.SignOffPaths, `infra/(`)
+	require.ErrorContains(t, cfg.Validate(), "git.sign_off_paths")
+}
+
+func TestValidate_LimitScope(t *testing.T) {
+	for _, scope := range []string{"", "session", "work_item"} {
+		cfg := &Config{LimitScope: scope}
+		require.NoError(t, cfg.Validate(), scope)
+	}
+	cfg := &Config{LimitScope: "lifetime"}
+	require.ErrorContains(t, cfg.Validate(), `unknown limit_scope "lifetime"`)
+}
+
+func TestApplyOverlay_ExecutorEnv(t *testing.T) {
+	base := &Config{ExecutorEnv: map[string]string{"API_URL": "https://prod", "FEATURE_X": "0"}}
+	base.applyOverlay(&configOverlay{ExecutorEnv: map[string]string{"API_URL": "https://staging", "HTTPS_PROXY": "http://proxy"}})
+
+	assert.Equal(t, map[string]string{
+		"API_URL":     "https://staging",
+		"FEATURE_X":   "0",
+		"HTTPS_PROXY": "http://proxy",
+	}, base.ExecutorEnv)
+}
+
+func TestValidate_ExecutorEnv(t *testing.T) {
+	cfg := &Config{ExecutorEnv: map[string]string{"API_URL": "x", "_FLAG2": ""}}
+	require.NoError(t, cfg.Validate())
+
+	cfg = &Config{ExecutorEnv: map[string]string{"API-URL": "x"}}
+	require.ErrorContains(t, cfg.Validate(), `invalid executor_env name "API-URL"`)
+}
+
+func TestValidate_LivenessTimeout(t *testing.T) {
+	cfg := &Config{LivenessTimeout: 600}
+	require.NoError(t, cfg.Validate())
+	cfg = &Config{LivenessTimeout: -1}
+	require.ErrorContains(t, cfg.Validate(), "liveness_timeout must be >= 0")
+}
+
+func TestValidate_ContextOverflow(t *testing.T) {
+	for _, mode := range []string{"", "warn", "truncate"} {
+		cfg := &Config{ContextOverflow: mode}
+		require.NoError(t, cfg.Validate(), mode)
+	}
+	cfg := &Config{ContextOverflow: "summarize"}
+	require.ErrorContains(t, cfg.Validate(), `unknown context_overflow "summarize"`)
+}
+
+func TestValidate_ReviewFixPrompt(t *testing.T) {
+	for _, groupBy := range []string{"", "agent", "file", "severity"} {
+		cfg := &Config{Review: ReviewConfig{FixGroupBy: groupBy, FixMaxIssues: 5}}
+		require.NoError(t, cfg.Validate(), groupBy)
+	}
+	cfg := &Config{Review: ReviewConfig{FixGroupBy: "category"}}
+	require.ErrorContains(t, cfg.Validate(), `unknown review.fix_group_by "category"`)
+	cfg = &Config{Review: ReviewConfig{FixMaxIssues: -1}}
+	require.ErrorContains(t, cfg.Validate(), "must not be negative")
+}
+
+func TestValidate_ReviewContextFiles(t *testing.T) {
+	cfg := &Config{Review: ReviewConfig{ContextFiles: 20}}
+	require.NoError(t, cfg.Validate())
+	cfg = &Config{Review: ReviewConfig{ContextFiles: -1}}
+	require.ErrorContains(t, cfg.Validate(), "review.context_files must not be negative")
+}
+
+func TestValidate_ReviewScoreThreshold(t *testing.T) {
+	cfg := &Config{Review: ReviewConfig{ScoreThreshold: 8}}
+	require.NoError(t, cfg.Validate())
+	cfg = &Config{Review: ReviewConfig{ScoreThreshold: -1}}
+	require.ErrorContains(t, cfg.Validate(), "review.score_threshold must not be negative")
+}
+
+func TestValidate_CodexSandbox(t *testing.T) {
+	for _, sandbox := range []string{"", "read-only", "workspace-write", "danger-full-access"} {
+		cfg := &Config{Executor: "codex", Codex: CodexConfig{Sandbox: sandbox}}
+		require.NoError(t, cfg.Validate(), sandbox)
+	}
+	cfg := &Config{Executor: "codex", Codex: CodexConfig{Sandbox: "none"}}
+	require.ErrorContains(t, cfg.Validate(), `unknown codex.sandbox "none"`)
+}
+
+func TestValidate_ReviewInjection(t *testing.T) {
+	for _, detector := range []string{"", "off", "warn", "block"} {
+		cfg := &Config{Review: ReviewConfig{Injection: ReviewInjectionConfig{Detector: detector}}}
+		require.NoError(t, cfg.Validate(), detector)
+	}
+	cfg := &Config{Review: ReviewConfig{Injection: ReviewInjectionConfig{Detector: "strict"}}}
+	require.ErrorContains(t, cfg.Validate(), `unknown review.injection.detector "strict"`)
+}
+
+func TestValidate_Preflight(t *testing.T) {
+	cfg := &Config{Preflight: PreflightConfig{MinFreeDiskMB: 0}}
+	require.NoError(t, cfg.Validate())
+	cfg = &Config{Preflight: PreflightConfig{MinFreeDiskMB: -1}}
+	require.ErrorContains(t, cfg.Validate(), "preflight.min_free_disk_mb must not be negative")
+}
+
+func TestValidate_Artifacts(t *testing.T) {
+	cfg := &Config{Artifacts: ArtifactsConfig{Enabled: true, Keep: 0}}
+	require.NoError(t, cfg.Validate())
+	cfg = &Config{Artifacts: ArtifactsConfig{Keep: -1}}
+	require.ErrorContains(t, cfg.Validate(), "artifacts.keep must not be negative")
+}
+
+func TestValidate_StatusBlock(t *testing.T) {
+	cfg := &Config{StatusBlock: StatusBlockConfig{Key: "MY_STATUS_2"}}
+	require.NoError(t, cfg.Validate())
+	cfg = &Config{StatusBlock: StatusBlockConfig{Key: "MY STATUS"}}
+	require.ErrorContains(t, cfg.Validate(), `status_block.key "MY STATUS" must be letters`)
+}
+
+func TestStatusBlockConfig_RunKey(t *testing.T) {
+	assert.Equal(t, "PROGRAMMATOR_STATUS", StatusBlockConfig{}.RunKey())
+	assert.Equal(t, "MY_STATUS", StatusBlockConfig{Key: "MY_STATUS"}.RunKey())
+
+	key := StatusBlockConfig{Key: "MY_STATUS", Nonce: true}.RunKey()
+	assert.Regexp(t, `^MY_STATUS_[0-9A-F]{6}$`, key)
+	assert.NotEqual(t, key, StatusBlockConfig{Key: "MY_STATUS", Nonce: true}.RunKey(), "a new suffix per run")
+}
+
+func TestValidate_Pacing(t *testing.T) {
+	cfg := &Config{Pacing: PacingConfig{Cooldown: 30, Adaptive: true, MaxCooldown: 600, WorkHours: "22:00-07:00"}}
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, "22:00-07:00", cfg.Pacing.WorkHoursWindow().String())
+
+	cfg = &Config{Pacing: PacingConfig{Cooldown: -1}}
+	require.ErrorContains(t, cfg.Validate(), "pacing.cooldown and pacing.max_cooldown must not be negative")
+	cfg = &Config{Pacing: PacingConfig{WorkHours: "nights"}}
+	require.ErrorContains(t, cfg.Validate(), `pacing.work_hours: window "nights"`)
+}
+
+func TestValidate_ActiveFiles(t *testing.T) {
+	cfg := &Config{ActiveFiles: ActiveFilesConfig{StatusFile: true, OnConflict: "pause", QuietPeriod: 10}}
+	require.NoError(t, cfg.Validate())
+
+	cfg = &Config{ActiveFiles: ActiveFilesConfig{OnConflict: "stop"}}
+	require.ErrorContains(t, cfg.Validate(), `unknown active_files.on_conflict "stop"`)
+	cfg = &Config{ActiveFiles: ActiveFilesConfig{QuietPeriod: -1}}
+	require.ErrorContains(t, cfg.Validate(), "active_files.quiet_period must not be negative")
+}
+
+func TestValidate_Coverage(t *testing.T) {
+	cfg := &Config{Coverage: CoverageConfig{Enabled: true, Threshold: 75.5, MaxFixIterations: 0}}
+	require.NoError(t, cfg.Validate())
+
+	cfg = &Config{Coverage: CoverageConfig{Threshold: 101}}
+	require.ErrorContains(t, cfg.Validate(), "coverage.threshold must be between 0 and 100, got 101")
+	cfg = &Config{Coverage: CoverageConfig{MaxFixIterations: -1}}
+	require.ErrorContains(t, cfg.Validate(), "coverage.max_fix_iterations must not be negative")
+}
+
+func TestValidate_Fallback(t *testing.T) {
+	cfg := &Config{Fallback: FallbackConfig{After: 2, Chain: []FallbackEntry{{Model: "sonnet"}, {Executor: "codex"}}}}
+	require.NoError(t, cfg.Validate())
+	cfg = &Config{Fallback: FallbackConfig{After: 0}}
+	require.NoError(t, cfg.Validate(), "after is not checked without a chain")
+
+	cfg = &Config{Fallback: FallbackConfig{After: 4, Chain: []FallbackEntry{{Model: "sonnet"}}}}
+	require.ErrorContains(t, cfg.Validate(), "fallback.after must be between 1 and 3, got 4")
+	cfg = &Config{Fallback: FallbackConfig{After: 2, Chain: []FallbackEntry{{Model: "sonnet"}, {}}}}
+	require.ErrorContains(t, cfg.Validate(), "fallback.chain[1]: set executor, model, or both")
+	cfg = &Config{Fallback: FallbackConfig{After: 2, Chain: []FallbackEntry{{Executor: "gpt"}}}}
+	require.ErrorContains(t, cfg.Validate(), `fallback.chain[0]: unknown executor "gpt"`)
+}
+
+func TestApplyOverlay_Fallback(t *testing.T) {
+	base := &Config{Fallback: FallbackConfig{After: 2}}
+
+	var overlay configOverlay
+	require.NoError(t, yaml.Unmarshal([]byte(`
+fallback:
+  after: 1
+  chain:
+    - model: sonnet
+    - executor: codex
+      model: o3
+`), &overlay))
+	base.applyOverlay(&overlay)
+
+	assert.Equal(t, FallbackConfig{After: 1, Chain: []FallbackEntry{{Model: "sonnet"}, {Executor: "codex", Model: "o3"}}}, base.Fallback)
+}
+
+func TestValidate_TicketWorkspaces(t *testing.T) {
+	cfg := &Config{TicketWorkspaces: map[string]ticket.Workspace{"work": {Dir: "/work/tickets"}, "home_2": {Dir: "~/t", Command: "ticket"}}}
+	require.NoError(t, cfg.Validate())
+
+	cfg = &Config{TicketWorkspaces: map[string]ticket.Workspace{"work": {}}}
+	require.ErrorContains(t, cfg.Validate(), "ticket_workspaces.work.dir is required")
+	for _, name := range []string{"pr", "mr", "a:b", "-x", ""} {
+		cfg = &Config{TicketWorkspaces: map[string]ticket.Workspace{name: {Dir: "/t"}}}
+		require.ErrorContains(t, cfg.Validate(), "invalid ticket_workspaces name", name)
+	}
+}
+
+func TestLoadWithDirs_TicketWorkspaces(t *testing.T) {
+	globalDir := t.TempDir()
+	localDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(globalDir, "config.yaml"),
+		[]byte("ticket_workspaces:\n  work: {dir: /work/tickets}\n  home: {dir: /home/tickets}\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.yaml"),
+		[]byte("ticket_workspaces:\n  work: {dir: /other/tickets, command: ticket}\n"), 0o600))
+
+	cfg, err := LoadWithDirs(globalDir, localDir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ticket.Workspace{
+		"work": {Dir: "/other/tickets", Command: "ticket"},
+		"home": {Dir: "/home/tickets"},
+	}, cfg.TicketWorkspaces)
+}
+
+func TestValidate_Notes(t *testing.T) {
+	valid := NotesConfig{
+		NoteStyleConfig: NoteStyleConfig{Level: "phase", Template: "{{.Kind}}: {{.Message}}"},
+		Sources:         map[string]NoteStyleConfig{"ticket": {Level: "final"}, "pull_request": {}},
+	}
+	require.NoError(t, (&Config{Notes: valid}).Validate())
+
+	tests := []struct {
+		name    string
+		notes   NotesConfig
+		wantErr string
+	}{
+		{"unknown level", NotesConfig{NoteStyleConfig: NoteStyleConfig{Level: "some"}}, `notes: unknown note level "some"`},
+		{"bad template", NotesConfig{NoteStyleConfig: NoteStyleConfig{Template: "{{.Kind"}}, "notes: parse note template"},
+		{"unknown source", NotesConfig{Sources: map[string]NoteStyleConfig{"jira": {}}}, `unknown notes.sources entry "jira"`},
+		{"bad source level", NotesConfig{Sources: map[string]NoteStyleConfig{"plan": {Level: "none"}}}, `notes.sources.plan: unknown note level "none"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorContains(t, (&Config{Notes: tc.notes}).Validate(), tc.wantErr)
+		})
+	}
+}
+
+func TestValidate_Notifications(t *testing.T) {
+	email := EmailNotifyConfig{Enabled: true, Host: "smtp.example.com", Port: 587, From: "a@example.com", To: []string{"b@example.com"}}
+	require.NoError(t, (&Config{Notifications: NotificationsConfig{OnExit: []string{"complete", "blocked"}, Email: email}}).Validate())
+	require.NoError(t, (&Config{Notifications: NotificationsConfig{Email: EmailNotifyConfig{Host: ""}}}).Validate(), "disabled email is not checked")
+
+	tests := []struct {
+		name    string
+		nc      NotificationsConfig
+		wantErr string
+	}{
+		{"unknown exit reason", NotificationsConfig{OnExit: []string{"done"}}, `unknown notifications.on_exit entry "done"`},
+		{"no host", NotificationsConfig{Email: EmailNotifyConfig{Enabled: true, From: "a@example.com", To: []string{"b@example.com"}}}, "notifications.email.host is required"},
+		{"no recipients", NotificationsConfig{Email: EmailNotifyConfig{Enabled: true, Host: "smtp.example.com", From: "a@example.com"}}, "notifications.email.from and notifications.email.to are required"},
+		{"bad port", NotificationsConfig{Email: EmailNotifyConfig{Enabled: true, Host: "h", Port: 70000, From: "a@example.com", To: []string{"b@example.com"}}}, "port 70000 is out of range"},
+		{"owners without email", NotificationsConfig{Owners: map[string][]string{"@org/billing": {"billing@example.com"}}}, "notifications.owners needs email notifications"},
+		{"owner without addresses", NotificationsConfig{Email: email, Owners: map[string][]string{"@org/billing": nil}}, "notifications.owners.@org/billing has no email addresses"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorContains(t, (&Config{Notifications: tc.nc}).Validate(), tc.wantErr)
+		})
+	}
+}
+
+func TestApplyOverlay_Notifications(t *testing.T) {
+	base := &Config{Notifications: NotificationsConfig{
+		Email: EmailNotifyConfig{Port: 587, PasswordEnv: "PROGRAMMATOR_SMTP_PASSWORD"},
+	}}
+
+	var overlay configOverlay
+	require.NoError(t, yaml.Unmarshal([]byte(`
+notifications:
+  on_exit: [complete, blocked]
+  desktop:
+    enabled: true
+  email:
+    enabled: true
+    host: smtp.example.com
+    port: 465
+    from: bot@example.com
+    to: [me@example.com]
+  owners:
+    "@org/billing": [billing@example.com]
+`), &overlay))
+	base.applyOverlay(&overlay)
+
+	nc := base.Notifications
+	assert.Equal(t, map[string][]string{"@org/billing": {"billing@example.com"}}, nc.Owners)
+	assert.Equal(t, []string{"complete", "blocked"}, nc.OnExit)
+	assert.True(t, nc.Desktop.Enabled)
+	assert.Equal(t, EmailNotifyConfig{
+		Enabled:     true,
+		Host:        "smtp.example.com",
+		Port:        465,
+		PasswordEnv: "PROGRAMMATOR_SMTP_PASSWORD",
+		From:        "bot@example.com",
+		To:          []string{"me@example.com"},
+	}, nc.Email)
+
+	t.Setenv("PROGRAMMATOR_SMTP_PASSWORD", "secret")
+	notifier, err := base.ToNotifier()
+	require.NoError(t, err)
+	assert.True(t, notifier.Wants("blocked"))
+	assert.False(t, notifier.Wants("max_iterations"))
+}
+
+func TestApplyOverlay_Notes(t *testing.T) {
+	base := &Config{Notes: NotesConfig{
+		NoteStyleConfig: NoteStyleConfig{Level: "all"},
+		Sources:         map[string]NoteStyleConfig{"ticket": {Level: "phase", Template: "{{.Message}}"}},
+	}}
+
+	base.applyOverlay(&configOverlay{Notes: NotesConfig{
+		NoteStyleConfig: NoteStyleConfig{Template: "[{{.Kind}}] {{.Message}}"},
+		Sources:         map[string]NoteStyleConfig{"ticket": {Level: "final"}, "plan": {Level: "phase"}},
+	}})
+
+	assert.Equal(t, "all", base.Notes.Level)
+	assert.Equal(t, "[{{.Kind}}] {{.Message}}", base.Notes.Template)
+	assert.Equal(t, NoteStyleConfig{Level: "final", Template: "{{.Message}}"}, base.Notes.Sources["ticket"])
+	assert.Equal(t, NoteStyleConfig{Level: "phase"}, base.Notes.Sources["plan"])
+
+	notes := base.ToNotesConfig()
+	assert.Equal(t, "final", notes.For("ticket").Level)
+	assert.Equal(t, "[{{.Kind}}] {{.Message}}", notes.For("pull_request").Template)
+}
+
+func TestValidate_Schedules(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedules []ScheduleConfig
+		wantErr   string
+	}{
+		{name: "valid", schedules: []ScheduleConfig{
+			{Name: "nightly", Cron: "0 2 * * *", Command: "review"},
+			{Name: "weekend", Cron: "0 9 * * sat,sun", Command: "start", Target: "plans/"},
+		}},
+		{name: "missing name", schedules: []ScheduleConfig{{Cron: "@daily", Command: "review"}}, wantErr: "name is required"},
+		{name: "duplicate name", schedules: []ScheduleConfig{
+			{Name: "a", Cron: "@daily", Command: "review"},
+			{Name: "a", Cron: "@hourly", Command: "review"},
+		}, wantErr: "duplicate name"},
+		{name: "bad cron", schedules: []ScheduleConfig{{Name: "a", Cron: "0 25 * * *", Command: "review"}}, wantErr: "hour"},
+		{name: "unknown command", schedules: []ScheduleConfig{{Name: "a", Cron: "@daily", Command: "run"}}, wantErr: "unknown command"},
+		{name: "start without target", schedules: []ScheduleConfig{{Name: "a", Cron: "@daily", Command: "start"}}, wantErr: "needs a target"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Executor: "claude", Schedules: tc.schedules}
+			err := cfg.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestLoadWithDirs_UIConfig(t *testing.T) {
+	globalDir := t.TempDir()
+	localDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(globalDir, "config.yaml"),
+		[]byte("ui:\n  theme: high-contrast\n  colors:\n    accent: 208\n    diff_add: 34\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.yaml"),
+		[]byte("ui:\n  colors:\n    diff_add: 40\n  keys:\n    skip_phase: ctrl+s\n  cost_warning: 2.5\n  diff: side-by-side\n"), 0o600))
+
+	cfg, err := LoadWithDirs(globalDir, localDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "high-contrast", cfg.UI.Theme)
+	assert.Equal(t, map[string]int{"accent": 208, "diff_add": 40}, cfg.UI.Colors)
+	assert.Equal(t, "ctrl+s", cfg.UI.Keys.SkipPhase)
+	assert.Equal(t, "ctrl+c", cfg.UI.Keys.Interrupt)
+	assert.InDelta(t, 2.5, cfg.UI.CostWarning, 1e-9)
+	assert.Equal(t, "side-by-side", cfg.UI.Diff)
+}
+
+func TestLoadWithDirs_Pricing(t *testing.T) {
+	globalDir := t.TempDir()
+	localDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(globalDir, "config.yaml"),
+		[]byte("pricing:\n  sonnet: {input: 2, output: 10}\n  llama: {input: 0, output: 0}\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "config.yaml"),
+		[]byte("pricing:\n  sonnet: {input: 1, output: 5}\n"), 0o600))
+
+	cfg, err := LoadWithDirs(globalDir, localDir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]llm.Price{
+		"sonnet": {Input: 1, Output: 5},
+		"llama":  {},
+	}, cfg.Pricing)
+
+	cfg.Pricing["sonnet"] = llm.Price{Input: -1}
+	require.ErrorContains(t, cfg.Validate(), "pricing.sonnet")
+}
+
+func TestValidate_UI(t *testing.T) {
+	tests := []struct {
+		name    string
+		ui      UIConfig
+		wantErr string
+	}{
+		{name: "defaults", ui: UIConfig{Theme: "default", Keys: UIKeysConfig{SkipPhase: "s", Interrupt: "ctrl+c", Issues: "i"}}},
+		{name: "no-color", ui: UIConfig{Theme: "no-color"}},
+		{name: "unknown theme", ui: UIConfig{Theme: "solarized"}, wantErr: "unknown ui.theme"},
+		{name: "color out of range", ui: UIConfig{Colors: map[string]int{"accent": 300}}, wantErr: "ui.colors.accent"},
+		{name: "same key twice", ui: UIConfig{Keys: UIKeysConfig{SkipPhase: "q", Interrupt: "q"}}, wantErr: "both bound"},
+		{name: "issues key taken", ui: UIConfig{Keys: UIKeysConfig{SkipPhase: "s", Issues: "s"}}, wantErr: "skip_phase and issues are both bound"},
+		{name: "negative cost warning", ui: UIConfig{CostWarning: -1}, wantErr: "ui.cost_warning"},
+		{name: "word diff", ui: UIConfig{Diff: "word"}},
+		{name: "unknown diff", ui: UIConfig{Diff: "split"}, wantErr: "unknown ui.diff"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Executor: "claude", UI: tc.ui}
+			err := cfg.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
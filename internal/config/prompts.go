@@ -21,6 +21,8 @@ type Prompts struct {
 	Phased      string // Template for phased execution (has checkboxed tasks)
 	Phaseless   string // Template for phaseless execution (single task)
 	ReviewFirst string // Template for review fix prompt
+	Docs        string // Template for the post-completion docs phase
+	Tests       string // Template for the coverage gate's test-writing iterations
 }
 
 // promptLoader handles loading prompts with fallback chain.
@@ -60,6 +62,16 @@ func (p *promptLoader) Load(globalDir, localDir string) (*Prompts, error) {
 		return nil, fmt.Errorf("load review_first prompt: %w", err)
 	}
 
+	prompts.Docs, err = p.loadPromptWithLocalFallback(localDir, globalDir, "docs.md")
+	if err != nil {
+		return nil, fmt.Errorf("load docs prompt: %w", err)
+	}
+
+	prompts.Tests, err = p.loadPromptWithLocalFallback(localDir, globalDir, "tests.md")
+	if err != nil {
+		return nil, fmt.Errorf("load tests prompt: %w", err)
+	}
+
 	return &prompts, nil
 }
 
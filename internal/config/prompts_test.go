@@ -19,6 +19,8 @@ func TestLoadPrompts_Embedded(t *testing.T) {
 	assert.NotEmpty(t, prompts.Phased, "phased prompt should be loaded")
 	assert.NotEmpty(t, prompts.Phaseless, "phaseless prompt should be loaded")
 	assert.NotEmpty(t, prompts.ReviewFirst, "review_first prompt should be loaded")
+	assert.NotEmpty(t, prompts.Docs, "docs prompt should be loaded")
+	assert.NotEmpty(t, prompts.Tests, "tests prompt should be loaded")
 
 	// Check that comment lines are stripped
 	assert.NotContains(t, prompts.Phased, "# Phased execution prompt")
@@ -30,6 +32,8 @@ func TestLoadPrompts_Embedded(t *testing.T) {
 	assert.Contains(t, prompts.Phased, "{{.CurrentPhase}}")
 	assert.Contains(t, prompts.Phaseless, "{{.ID}}")
 	assert.Contains(t, prompts.ReviewFirst, "{{.BaseBranch}}")
+	assert.Contains(t, prompts.Docs, "{{.FilesList}}")
+	assert.Contains(t, prompts.Tests, "{{.CoverageGaps}}")
 }
 
 func TestLoadPrompts_GlobalOverride(t *testing.T) {
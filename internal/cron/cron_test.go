@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"too few fields", "0 2 * *"},
+		{"too many fields", "0 2 * * * *"},
+		{"minute out of range", "60 * * * *"},
+		{"hour out of range", "0 24 * * *"},
+		{"day zero", "0 0 0 * *"},
+		{"bad name", "0 0 * foo *"},
+		{"reversed range", "0 5-2 * * *"},
+		{"bad step", "*/0 * * * *"},
+		{"not a number", "a * * * *"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// Wednesday 2025-01-15 10:30
+	base := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", base, base.Add(time.Minute)},
+		{"nightly later today", "0 22 * * *", base, time.Date(2025, 1, 15, 22, 0, 0, 0, time.UTC)},
+		{"nightly tomorrow", "0 2 * * *", base, time.Date(2025, 1, 16, 2, 0, 0, 0, time.UTC)},
+		{"strictly after", "30 10 * * *", base, time.Date(2025, 1, 16, 10, 30, 0, 0, time.UTC)},
+		{"step", "*/20 * * * *", base, time.Date(2025, 1, 15, 10, 40, 0, 0, time.UTC)},
+		{"weekends", "0 9 * * sat,sun", base, time.Date(2025, 1, 18, 9, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 9 * * 7", base, time.Date(2025, 1, 19, 9, 0, 0, 0, time.UTC)},
+		{"weekday range", "0 8 * * mon-fri", base, time.Date(2025, 1, 16, 8, 0, 0, 0, time.UTC)},
+		{"month name", "0 0 1 mar *", base, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"dom or dow", "0 0 20 * mon", base, time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"shorthand", "@daily", base, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", base, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"seconds ignored", "* * * * *", base.Add(45 * time.Second), base.Add(time.Minute)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, s.Next(tc.from))
+		})
+	}
+}
+
+func TestSchedule_NextNever(t *testing.T) {
+	s, err := Parse("0 0 31 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
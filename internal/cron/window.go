@@ -0,0 +1,84 @@
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time-of-day range, such as work hours. A window whose
+// end is before its start spans midnight ("22:00-06:00"). The zero Window
+// is always open.
+type Window struct {
+	start, end int // minutes after midnight
+	set        bool
+}
+
+// ParseWindow parses "HH:MM-HH:MM". An empty string is the always-open
+// window.
+func ParseWindow(spec string) (Window, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Window{}, nil
+	}
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("window %q: want HH:MM-HH:MM", spec)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return Window{}, fmt.Errorf("window %q: %w", spec, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return Window{}, fmt.Errorf("window %q: %w", spec, err)
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("window %q is empty", spec)
+	}
+	return Window{start: start, end: end, set: true}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q", strings.TrimSpace(s))
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// IsZero reports whether w is the always-open window.
+func (w Window) IsZero() bool { return !w.set }
+
+// Contains reports whether t, in its location, is inside w.
+func (w Window) Contains(t time.Time) bool {
+	if !w.set {
+		return true
+	}
+	m := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return m >= w.start && m < w.end
+	}
+	return m >= w.start || m < w.end
+}
+
+// NextOpen returns t when it is inside w, and otherwise the next time w
+// opens.
+func (w Window) NextOpen(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	open := time.Date(t.Year(), t.Month(), t.Day(), w.start/60, w.start%60, 0, 0, t.Location())
+	if !open.After(t) {
+		open = open.AddDate(0, 0, 1)
+	}
+	return open
+}
+
+// String returns w as "HH:MM-HH:MM", or "" for the zero Window.
+func (w Window) String() string {
+	if !w.set {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.start/60, w.start%60, w.end/60, w.end%60)
+}
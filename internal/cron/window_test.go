@@ -0,0 +1,55 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("")
+	require.NoError(t, err)
+	assert.True(t, w.IsZero())
+	assert.True(t, w.Contains(time.Now()))
+
+	w, err = ParseWindow(" 9:00 - 17:30 ")
+	require.NoError(t, err)
+	assert.Equal(t, "09:00-17:30", w.String())
+
+	for _, spec := range []string{"09:00", "9-17", "25:00-06:00", "08:00-08:00"} {
+		_, err := ParseWindow(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestWindow_Contains(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 2, h, m, 0, 0, time.UTC) }
+
+	day, err := ParseWindow("09:00-17:00")
+	require.NoError(t, err)
+	assert.False(t, day.Contains(at(8, 59)))
+	assert.True(t, day.Contains(at(9, 0)))
+	assert.True(t, day.Contains(at(16, 59)))
+	assert.False(t, day.Contains(at(17, 0)))
+
+	night, err := ParseWindow("22:00-06:00")
+	require.NoError(t, err)
+	assert.True(t, night.Contains(at(23, 0)))
+	assert.True(t, night.Contains(at(5, 59)))
+	assert.False(t, night.Contains(at(12, 0)))
+}
+
+func TestWindow_NextOpen(t *testing.T) {
+	at := func(d, h, m int) time.Time { return time.Date(2026, 3, d, h, m, 0, 0, time.UTC) }
+
+	night, err := ParseWindow("22:00-06:00")
+	require.NoError(t, err)
+	assert.Equal(t, at(2, 23, 0), night.NextOpen(at(2, 23, 0)), "already open")
+	assert.Equal(t, at(2, 22, 0), night.NextOpen(at(2, 12, 0)))
+
+	day, err := ParseWindow("09:00-17:00")
+	require.NoError(t, err)
+	assert.Equal(t, at(3, 9, 0), day.NextOpen(at(2, 18, 0)), "opens the next day")
+}
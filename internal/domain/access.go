@@ -0,0 +1,46 @@
+package domain
+
+import "strings"
+
+// Tool access levels for a phase, set with a "<!-- access: ... -->" plan
+// annotation or inferred from the phase name in minimal-permission mode.
+const (
+	AccessFull     = "full"      // no restriction
+	AccessReadOnly = "read-only" // no file edits and no shell
+	AccessNoBash   = "no-bash"   // file edits allowed, no shell
+)
+
+// ValidAccess reports whether access is a known access level.
+func ValidAccess(access string) bool {
+	switch access {
+	case AccessFull, AccessReadOnly, AccessNoBash:
+		return true
+	}
+	return false
+}
+
+var (
+	readOnlyVerbs = map[string]bool{
+		"investigate": true, "research": true, "explore": true, "analyze": true,
+		"analyse": true, "audit": true, "survey": true,
+	}
+	docsObjects = map[string]bool{"docs": true, "documentation": true, "readme": true, "changelog": true}
+)
+
+// InferAccess guesses the access a phase plausibly needs from how its name
+// starts: "Investigate ..." is read-only, "Document ..." and "Update README"
+// get no shell, and everything else gets full access.
+func InferAccess(phaseName string) string {
+	words := strings.FieldsFunc(NormalizePhaseName(phaseName), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	switch {
+	case len(words) == 0:
+		return AccessFull
+	case readOnlyVerbs[words[0]]:
+		return AccessReadOnly
+	case words[0] == "document", docsObjects[words[0]], len(words) > 1 && docsObjects[words[1]]:
+		return AccessNoBash
+	}
+	return AccessFull
+}
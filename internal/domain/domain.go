@@ -2,10 +2,39 @@
 // Phase, WorkItem, and their helper methods.
 package domain
 
+import (
+	"regexp"
+	"strings"
+)
+
+// contextRefRegex matches "<!-- context: docs/design.md, api/openapi.yaml -->".
+var contextRefRegex = regexp.MustCompile(`<!--\s*context:\s*([^>]*?)\s*-->`)
+
+var (
+	// acceptanceHeadingRegex matches the heading of an acceptance criteria
+	// section, as in "## Acceptance Criteria".
+	acceptanceHeadingRegex = regexp.MustCompile(`(?i)^(#{1,6})\s+acceptance\s+criteria:?\s*$`)
+	headingRegex           = regexp.MustCompile(`^(#{1,6})\s`)
+	// criterionRegex matches a list item, checkbox or not, and captures its text.
+	criterionRegex = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?(.+)$`)
+	checkboxRegex  = regexp.MustCompile(`^\s*[-*+]\s+\[[ xX]\]\s+\S`)
+)
+
 // Phase represents a single phase or task in a work item.
 type Phase struct {
 	Name      string
 	Completed bool
+	// Depth is the nesting level of the phase's checkbox: 0 for top-level
+	// phases, 1 for their indented sub-items, and so on. WorkItem.Phases
+	// lists a phase's sub-phases right after it.
+	Depth int
+	// DependsOn lists indices (into WorkItem.Phases) of phases that must be
+	// completed before this one can start. Nil means the phase depends on
+	// every phase before it, preserving strict sequential order.
+	DependsOn []int
+	// Access restricts the executor's tools while working on the phase (see
+	// Access* constants). Empty means no restriction was requested.
+	Access string
 }
 
 // WorkItem represents a ticket or plan that programmator operates on.
@@ -16,22 +45,265 @@ type WorkItem struct {
 	Title string
 	// Status is the current status (see protocol.WorkItem* constants).
 	Status string
-	// Phases are the checkboxed items to complete.
+	// Phases are the checkboxed items to complete, in document order. Nested
+	// items follow their parent (see Phase.Depth).
 	Phases []Phase
 	// RawContent is the full content of the source file.
 	RawContent string
 	// ValidationCommands are commands to run after each phase (plan files only).
 	ValidationCommands []string
+	// ContextFiles are the files referenced via ContextRefs, loaded for the prompt.
+	ContextFiles []ContextFile
+	// Metadata holds the custom frontmatter fields of a ticket (priority,
+	// component, ...) by name; lists and maps are rendered as YAML.
+	Metadata map[string]string
+}
+
+// ContextFile is an extra file inlined into the task prompt.
+type ContextFile struct {
+	Path string
+	// Content is the file content, cut to an excerpt when Truncated.
+	Content   string
+	Truncated bool
+	Size      int
+}
+
+// ContextRefs returns the file paths referenced by context annotations in the
+// raw content, de-duplicated in order of appearance.
+func (w *WorkItem) ContextRefs() []string {
+	var refs []string
+	seen := make(map[string]bool)
+	for _, match := range contextRefRegex.FindAllStringSubmatch(w.RawContent, -1) {
+		for ref := range strings.SplitSeq(match[1], ",") {
+			ref = strings.TrimSpace(ref)
+			if ref == "" || seen[ref] {
+				continue
+			}
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// AcceptanceCriteria returns the criteria listed in the acceptance criteria
+// sections of the raw content, in order.
+func (w *WorkItem) AcceptanceCriteria() []string {
+	return AcceptanceCriteria(w.RawContent)
+}
+
+// AcceptanceCriteria returns the list items, checkboxes or plain bullets, of
+// the "## Acceptance Criteria" sections of content.
+func AcceptanceCriteria(content string) []string {
+	lines := strings.Split(content, "\n")
+	var criteria []string
+	for i, in := range AcceptanceLines(lines) {
+		if !in {
+			continue
+		}
+		if match := criterionRegex.FindStringSubmatch(lines[i]); match != nil {
+			if text := strings.TrimSpace(match[1]); text != "" {
+				criteria = append(criteria, text)
+			}
+		}
+	}
+	return criteria
+}
+
+// AcceptanceCheckboxes returns how many checkboxes the acceptance criteria
+// sections of the raw content hold. Sources skip them as phases, so only the
+// acceptance check ever works through them.
+func (w *WorkItem) AcceptanceCheckboxes() int {
+	lines := strings.Split(w.RawContent, "\n")
+	n := 0
+	for i, in := range AcceptanceLines(lines) {
+		if in && checkboxRegex.MatchString(lines[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// AcceptanceLines reports which of lines belong to an acceptance criteria
+// section: its heading and everything up to the next heading of the same or
+// a higher level. Checkboxes there are criteria to verify, not phases.
+func AcceptanceLines(lines []string) []bool {
+	in := make([]bool, len(lines))
+	level := 0 // heading level of the open section, 0 outside one
+	for i, line := range lines {
+		if match := headingRegex.FindStringSubmatch(line); match != nil {
+			if h := acceptanceHeadingRegex.FindStringSubmatch(line); h != nil {
+				level = len(h[1])
+			} else if len(match[1]) <= level {
+				level = 0
+			}
+		}
+		in[i] = level > 0
+	}
+	return in
 }
 
-// CurrentPhase returns the first incomplete phase, or nil if all are complete.
+// CurrentPhase returns the deepest incomplete leaf of the first incomplete
+// phase, or nil if all are complete.
 func (w *WorkItem) CurrentPhase() *Phase {
+	if i := w.CurrentPhaseIndex(); i >= 0 {
+		return &w.Phases[i]
+	}
+	return nil
+}
+
+// CurrentPhaseIndex returns the index of CurrentPhase, or -1 if all phases
+// are complete.
+func (w *WorkItem) CurrentPhaseIndex() int {
 	for i := range w.Phases {
-		if !w.Phases[i].Completed {
-			return &w.Phases[i]
+		if w.workable(i) {
+			return i
 		}
 	}
-	return nil
+	return -1
+}
+
+// workable reports whether phase i is incomplete and has no incomplete
+// sub-phases. A parent whose sub-phases are all checked but which is not
+// checked itself is workable, so it still gets completed.
+func (w *WorkItem) workable(i int) bool {
+	if w.Phases[i].Completed {
+		return false
+	}
+	for j := i + 1; j < len(w.Phases) && w.Phases[j].Depth > w.Phases[i].Depth; j++ {
+		if !w.Phases[j].Completed {
+			return false
+		}
+	}
+	return true
+}
+
+// CompletePhase marks phase i complete along with its sub-phases, then every
+// enclosing phase left with no open sub-phases.
+func (w *WorkItem) CompletePhase(i int) {
+	for j := i; j < len(w.Phases) && (j == i || w.Phases[j].Depth > w.Phases[i].Depth); j++ {
+		w.Phases[j].Completed = true
+	}
+	for p := w.Parent(i); p >= 0 && w.workable(p); p = w.Parent(p) {
+		w.Phases[p].Completed = true
+	}
+}
+
+// RerunPhases returns the indices of the completed phases to reopen so that
+// phase i runs again: i and its sub-phases, every later phase depending on a
+// reopened one, directly or not, and the phases enclosing them. A phase
+// without explicit dependencies depends on every phase before it.
+func (w *WorkItem) RerunPhases(i int) []int {
+	reopen := make([]bool, len(w.Phases))
+	mark := func(j int) {
+		reopen[j] = true
+		for p := w.Parent(j); p >= 0; p = w.Parent(p) {
+			reopen[p] = true
+		}
+	}
+	for j := i; j < len(w.Phases) && (j == i || w.Phases[j].Depth > w.Phases[i].Depth); j++ {
+		mark(j)
+	}
+	for j := i + 1; j < len(w.Phases); j++ {
+		if !reopen[j] && w.dependsOnAny(j, reopen) {
+			mark(j)
+		}
+	}
+
+	var indices []int
+	for j, r := range reopen {
+		if r && w.Phases[j].Completed {
+			indices = append(indices, j)
+		}
+	}
+	return indices
+}
+
+// dependsOnAny reports whether phase idx depends on a phase in set, with
+// the dependencies dependenciesMet checks.
+func (w *WorkItem) dependsOnAny(idx int, set []bool) bool {
+	deps := w.Phases[idx].DependsOn
+	if deps == nil {
+		ancestor := w.Parent(idx)
+		for i := idx - 1; i >= 0; i-- {
+			if i == ancestor {
+				ancestor = w.Parent(i)
+				continue
+			}
+			if set[i] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, d := range deps {
+		if d >= 0 && d < len(set) && d != idx && set[d] {
+			return true
+		}
+	}
+	return false
+}
+
+// Parent returns the index of the phase enclosing phase i, or -1 for a
+// top-level phase.
+func (w *WorkItem) Parent(i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if w.Phases[j].Depth < w.Phases[i].Depth {
+			return j
+		}
+	}
+	return -1
+}
+
+// PhasePath returns the names of phase i and its enclosing phases, outermost
+// first.
+func (w *WorkItem) PhasePath(i int) []string {
+	var path []string
+	for ; i >= 0; i = w.Parent(i) {
+		path = append([]string{w.Phases[i].Name}, path...)
+	}
+	return path
+}
+
+// ReadyPhases returns the indices of workable phases whose dependencies are
+// all completed, in plan order. Without explicit dependencies this is at most
+// the current phase.
+func (w *WorkItem) ReadyPhases() []int {
+	var ready []int
+	for i := range w.Phases {
+		if !w.workable(i) || !w.dependenciesMet(i) {
+			continue
+		}
+		ready = append(ready, i)
+	}
+	return ready
+}
+
+func (w *WorkItem) dependenciesMet(idx int) bool {
+	deps := w.Phases[idx].DependsOn
+	if deps == nil {
+		// Every earlier phase except the ones enclosing idx.
+		ancestor := w.Parent(idx)
+		for i := idx - 1; i >= 0; i-- {
+			if i == ancestor {
+				ancestor = w.Parent(i)
+				continue
+			}
+			if !w.Phases[i].Completed {
+				return false
+			}
+		}
+		return true
+	}
+	for _, d := range deps {
+		if d < 0 || d >= len(w.Phases) || d == idx {
+			continue
+		}
+		if !w.Phases[d].Completed {
+			return false
+		}
+	}
+	return true
 }
 
 // AllPhasesComplete returns true if all phases are completed.
@@ -44,7 +316,42 @@ func (w *WorkItem) AllPhasesComplete() bool {
 	return len(w.Phases) > 0
 }
 
+// NestingDepths converts the leading whitespace of consecutive checkbox
+// lines into Phase.Depth values. An item indented deeper than the one before
+// it is nested under it; an item is a sibling of the nearest earlier item
+// with the same or less indentation. Tabs count as four spaces.
+func NestingDepths(indents []string) []int {
+	depths := make([]int, len(indents))
+	var stack []int // indentation widths of the open ancestors
+	for i, ws := range indents {
+		indent := len(strings.ReplaceAll(ws, "\t", "    "))
+		for len(stack) > 0 && stack[len(stack)-1] >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		depths[i] = len(stack)
+		stack = append(stack, indent)
+	}
+	return depths
+}
+
 // HasPhases returns true if the work item has any phases defined.
 func (w *WorkItem) HasPhases() bool {
 	return len(w.Phases) > 0
 }
+
+// AppendPhaseList appends phases as open checkboxes under a "## Plan"
+// heading at the end of content, where plans and tickets read phases from.
+func AppendPhaseList(content string, phases []string) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(content, "\n"))
+	if b.Len() > 0 {
+		b.WriteString("\n\n")
+	}
+	b.WriteString("## Plan\n\n")
+	for _, p := range phases {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			b.WriteString("- [ ] " + p + "\n")
+		}
+	}
+	return b.String()
+}
@@ -28,6 +28,26 @@ func TestWorkItem_CurrentPhase(t *testing.T) {
 			phases: []Phase{{Name: "A", Completed: true}, {Name: "B", Completed: false}},
 			want:   &Phase{Name: "B", Completed: false},
 		},
+		{
+			name: "deepest incomplete leaf",
+			phases: []Phase{
+				{Name: "Backend"},
+				{Name: "Schema", Depth: 1, Completed: true},
+				{Name: "API", Depth: 1},
+				{Name: "Handlers", Depth: 2},
+				{Name: "Frontend"},
+			},
+			want: &Phase{Name: "Handlers", Depth: 2},
+		},
+		{
+			name: "parent with all sub-phases done",
+			phases: []Phase{
+				{Name: "Backend"},
+				{Name: "Schema", Depth: 1, Completed: true},
+				{Name: "Frontend"},
+			},
+			want: &Phase{Name: "Backend"},
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -67,3 +87,226 @@ func TestWorkItem_HasPhases(t *testing.T) {
 	assert.False(t, (&WorkItem{Phases: []Phase{}}).HasPhases())
 	assert.True(t, (&WorkItem{Phases: []Phase{{Name: "A"}}}).HasPhases())
 }
+
+func TestWorkItem_ReadyPhases(t *testing.T) {
+	tests := []struct {
+		name   string
+		phases []Phase
+		want   []int
+	}{
+		{"no phases", nil, nil},
+		{"sequential default", []Phase{{Name: "A"}, {Name: "B"}}, []int{0}},
+		{"sequential after completion", []Phase{{Name: "A", Completed: true}, {Name: "B"}, {Name: "C"}}, []int{1}},
+		{"independent phases", []Phase{{Name: "A"}, {Name: "B", DependsOn: []int{}}, {Name: "C", DependsOn: []int{}}}, []int{0, 1, 2}},
+		{"explicit dependency pending", []Phase{{Name: "A"}, {Name: "B", DependsOn: []int{}}, {Name: "C", DependsOn: []int{0}}}, []int{0, 1}},
+		{"explicit dependency met", []Phase{{Name: "A", Completed: true}, {Name: "B"}, {Name: "C", DependsOn: []int{0}}}, []int{1, 2}},
+		{"invalid indices ignored", []Phase{{Name: "A", DependsOn: []int{5, 0}}}, []int{0}},
+		{"all complete", []Phase{{Name: "A", Completed: true}}, nil},
+		{"nested leaf after its parent", []Phase{{Name: "A"}, {Name: "A1", Depth: 1, Completed: true}, {Name: "A2", Depth: 1}, {Name: "B"}}, []int{2}},
+		{"independent nested leaves", []Phase{{Name: "A"}, {Name: "A1", Depth: 1, DependsOn: []int{}}, {Name: "A2", Depth: 1, DependsOn: []int{}}}, []int{1, 2}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &WorkItem{Phases: tc.phases}
+			assert.Equal(t, tc.want, w.ReadyPhases())
+		})
+	}
+}
+
+func TestWorkItem_PhasePath(t *testing.T) {
+	w := &WorkItem{Phases: []Phase{
+		{Name: "Backend"},
+		{Name: "API", Depth: 1},
+		{Name: "Handlers", Depth: 2},
+		{Name: "Tests", Depth: 1},
+		{Name: "Frontend"},
+	}}
+	assert.Equal(t, []string{"Backend", "API", "Handlers"}, w.PhasePath(2))
+	assert.Equal(t, []string{"Backend", "Tests"}, w.PhasePath(3))
+	assert.Equal(t, []string{"Frontend"}, w.PhasePath(4))
+	assert.Equal(t, -1, w.Parent(0))
+	assert.Equal(t, 0, w.Parent(3))
+}
+
+func TestWorkItem_CompletePhase(t *testing.T) {
+	newItem := func() *WorkItem {
+		return &WorkItem{Phases: []Phase{
+			{Name: "Backend"},
+			{Name: "API", Depth: 1},
+			{Name: "Handlers", Depth: 2},
+			{Name: "Tests", Depth: 1, Completed: true},
+			{Name: "Frontend"},
+		}}
+	}
+	completed := func(w *WorkItem) []bool {
+		var out []bool
+		for _, p := range w.Phases {
+			out = append(out, p.Completed)
+		}
+		return out
+	}
+
+	// The last open leaf completes its parents.
+	w := newItem()
+	w.CompletePhase(2)
+	assert.Equal(t, []bool{true, true, true, true, false}, completed(w))
+
+	// A parent completes its sub-phases.
+	w = newItem()
+	w.CompletePhase(1)
+	assert.Equal(t, []bool{true, true, true, true, false}, completed(w))
+
+	// Flat phases are unaffected by their neighbours.
+	w = &WorkItem{Phases: []Phase{{Name: "A"}, {Name: "B"}}}
+	w.CompletePhase(0)
+	assert.Equal(t, []bool{true, false}, completed(w))
+}
+
+func TestWorkItem_RerunPhases(t *testing.T) {
+	tests := []struct {
+		name   string
+		phases []Phase
+		from   int
+		want   []int
+	}{
+		{"sequential reopens later phases", []Phase{{Name: "A", Completed: true}, {Name: "B", Completed: true}, {Name: "C", Completed: true}}, 1, []int{1, 2}},
+		{"open phases are left alone", []Phase{{Name: "A", Completed: true}, {Name: "B", Completed: true}, {Name: "C"}}, 0, []int{0, 1}},
+		{"nothing completed", []Phase{{Name: "A"}, {Name: "B"}}, 0, nil},
+		{"independent phases stay done", []Phase{{Name: "A", Completed: true}, {Name: "B", Completed: true, DependsOn: []int{}}, {Name: "C", Completed: true, DependsOn: []int{0}}}, 0, []int{0, 2}},
+		{"transitive dependents", []Phase{{Name: "A", Completed: true, DependsOn: []int{}}, {Name: "B", Completed: true, DependsOn: []int{}}, {Name: "C", Completed: true, DependsOn: []int{1}}, {Name: "D", Completed: true, DependsOn: []int{2}}}, 1, []int{1, 2, 3}},
+		{"sub-phases and parent", []Phase{
+			{Name: "Backend", Completed: true},
+			{Name: "API", Depth: 1, Completed: true},
+			{Name: "Handlers", Depth: 2, Completed: true},
+			{Name: "Tests", Depth: 1, Completed: true, DependsOn: []int{}},
+			{Name: "Frontend", Completed: true, DependsOn: []int{}},
+		}, 1, []int{0, 1, 2}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &WorkItem{Phases: tc.phases}
+			assert.Equal(t, tc.want, w.RerunPhases(tc.from))
+		})
+	}
+}
+
+func TestNestingDepths(t *testing.T) {
+	assert.Equal(t, []int{0, 1, 2, 1, 0}, NestingDepths([]string{"", "  ", "    ", "  ", ""}))
+	assert.Equal(t, []int{0, 1, 1}, NestingDepths([]string{"", "\t", "    "}))
+	// Uneven indentation nests under the nearest shallower item.
+	assert.Equal(t, []int{0, 1, 1}, NestingDepths([]string{"", "    ", "  "}))
+	// A list indented as a whole stays top-level.
+	assert.Equal(t, []int{0, 0}, NestingDepths([]string{"  ", "  "}))
+}
+
+func TestWorkItem_ContextRefs(t *testing.T) {
+	w := &WorkItem{RawContent: `# Plan
+<!-- context: docs/design.md, api/openapi.yaml -->
+
+- [ ] Task 1 <!-- depends: none -->
+<!--context:docs/design.md,notes.txt-->
+`}
+	assert.Equal(t, []string{"docs/design.md", "api/openapi.yaml", "notes.txt"}, w.ContextRefs())
+
+	assert.Empty(t, (&WorkItem{RawContent: "no annotations"}).ContextRefs())
+}
+
+func TestWorkItem_AcceptanceCriteria(t *testing.T) {
+	w := &WorkItem{RawContent: `# Plan
+
+## Tasks
+- [ ] Task 1: Add cache
+
+## Acceptance Criteria
+- [ ] Cache hits skip the database
+- Entries expire after 5 minutes
+1. ` + "`go test ./cache`" + ` passes
+
+### Notes
+- still part of the criteria
+
+## Validation Commands
+- go test ./...
+`}
+	assert.Equal(t, []string{
+		"Cache hits skip the database",
+		"Entries expire after 5 minutes",
+		"`go test ./cache` passes",
+		"still part of the criteria",
+	}, w.AcceptanceCriteria())
+
+	assert.Empty(t, (&WorkItem{RawContent: "# Plan\n- [ ] Task 1\n"}).AcceptanceCriteria())
+}
+
+func TestWorkItem_AcceptanceCheckboxes(t *testing.T) {
+	w := &WorkItem{RawContent: "# Plan\n- [ ] Task 1\n\n## Acceptance Criteria\n- [ ] Works\n- [x] Is fast\n- Documented\n"}
+	assert.Equal(t, 2, w.AcceptanceCheckboxes())
+	assert.Zero(t, (&WorkItem{RawContent: "# Plan\n- [ ] Task 1\n"}).AcceptanceCheckboxes())
+}
+
+func TestAcceptanceLines(t *testing.T) {
+	lines := []string{"# Ticket", "- [ ] Phase", "## acceptance criteria:", "- [ ] Works", "## Design", "- [ ] Phase 2"}
+	assert.Equal(t, []bool{false, false, true, true, false, false}, AcceptanceLines(lines))
+}
+
+func TestNormalizePhaseName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"lowercase", "Setup Project", "setup project"},
+		{"trims whitespace", "  setup  ", "setup"},
+		{"strips Phase N:", "Phase 1: Setup", "setup"},
+		{"strips Phase N.", "Phase 2. Implementation", "implementation"},
+		{"strips Step N:", "Step 3: Testing", "testing"},
+		{"strips Task N:", "Task 4: Docs", "docs"},
+		{"no prefix", "just a task", "just a task"},
+		{"case insensitive prefix", "phase 1: Setup", "setup"},
+		{
+			name:  "normalizes escaped newlines and real newlines",
+			input: "Phase 2: Implement `Load()` and unescape `\\n` to `\n`",
+			want:  "implement `load()` and unescape `\\n` to `\\n`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizePhaseName(tt.input)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizePhaseName_EscapeVariantEquivalence(t *testing.T) {
+	fromTicket := "Phase 2: Implement `Load()` in `internal/cli/history.go` to read file entries, split lines, unescape `\\\\n` to `\\n`, and cap to `max`."
+	fromYAML := "Phase 2: Implement `Load()` in `internal/cli/history.go` to read file entries, split lines, unescape `\\n` to `\n`, and cap to `max`."
+	assert.Equal(t, NormalizePhaseName(fromTicket), NormalizePhaseName(fromYAML))
+}
+
+func TestInferAccess(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Investigate the flaky login test", AccessReadOnly},
+		{"Task 1: Research caching options", AccessReadOnly},
+		{"Step 2. analyse memory usage", AccessReadOnly},
+		{"Document the new config keys", AccessNoBash},
+		{"Update README", AccessNoBash},
+		{"Task 3: Update docs for the API", AccessNoBash},
+		{"Implement analyzer", AccessFull},
+		{"Add tests and update docs", AccessFull},
+		{"", AccessFull},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, InferAccess(tc.name))
+		})
+	}
+}
+
+func TestAppendPhaseList(t *testing.T) {
+	assert.Equal(t, "# Ticket\n\nDo it.\n\n## Plan\n\n- [ ] First\n- [ ] Second step\n",
+		AppendPhaseList("# Ticket\n\nDo it.\n\n", []string{"First", " Second  step ", ""}))
+	assert.Equal(t, "## Plan\n\n- [ ] Only\n", AppendPhaseList("", []string{"Only"}))
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	phaseNumberPrefix = regexp.MustCompile(`^(?:task|step|phase)\s*\d+[:.]\s*`)
+	// escapeSequenceCanonicalizer makes escaped and literal newlines and tabs
+	// compare equal, as phase names copied through YAML or JSON differ there.
+	escapeSequenceCanonicalizer = strings.NewReplacer(
+		`\\n`, `\n`,
+		`\\r`, `\r`,
+		`\\t`, `\t`,
+		"\r\n", "\n",
+		"\r", "\n",
+		"\n", `\n`,
+		"\t", `\t`,
+	)
+)
+
+// NormalizePhaseName normalizes a phase name for matching: case, "Task N:",
+// "Step N:" and "Phase N:" prefixes, escape sequences, and whitespace are
+// ignored. Every source and the loop match phase names through it, so they
+// agree on which phase a name means.
+func NormalizePhaseName(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = phaseNumberPrefix.ReplaceAllString(s, "")
+	s = escapeSequenceCanonicalizer.Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
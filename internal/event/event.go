@@ -3,6 +3,11 @@
 // [PROG]/[TOOL]/[REVIEW] log markers with structured types.
 package event
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Kind identifies the type of event.
 type Kind int
 
@@ -30,12 +35,78 @@ const (
 	KindStreamingText
 	// KindIterationSeparator is the header between loop iterations.
 	KindIterationSeparator
+	// KindAgentActivity is a tool use or output line of a review agent,
+	// named by Agent. Agents run in parallel, so their activity is shown
+	// per agent rather than in the main output.
+	KindAgentActivity
+	// KindPhaseAmbiguous reports a phase_completed name that matches several
+	// open phases, listed in Phases. Text describes it.
+	KindPhaseAmbiguous
+	// KindIssueLifecycle is the state a review finding reached in a review
+	// iteration, described by Issue. Writers leave it out of the output; the
+	// review logs a summary of each iteration's transitions.
+	KindIssueLifecycle
 )
 
+var kindNames = [...]string{
+	KindProg:               "prog",
+	KindToolUse:            "tool_use",
+	KindToolResult:         "tool_result",
+	KindReview:             "review",
+	KindDiffAdd:            "diff_add",
+	KindDiffDel:            "diff_del",
+	KindDiffCtx:            "diff_ctx",
+	KindDiffHunk:           "diff_hunk",
+	KindMarkdown:           "markdown",
+	KindStreamingText:      "streaming_text",
+	KindIterationSeparator: "iteration",
+	KindAgentActivity:      "agent_activity",
+	KindPhaseAmbiguous:     "phase_ambiguous",
+	KindIssueLifecycle:     "issue_lifecycle",
+}
+
+// String returns the kind's name, as written to event logs.
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// ParseKind returns the kind named name, as String writes it.
+func ParseKind(name string) (Kind, bool) {
+	for k, n := range kindNames {
+		if n == name {
+			return Kind(k), true
+		}
+	}
+	return 0, false
+}
+
 // Event is a single typed event emitted by the loop or review runner.
 type Event struct {
-	Kind Kind
-	Text string // the payload text (meaning depends on Kind)
+	Kind  Kind
+	Text  string // the payload text (meaning depends on Kind)
+	Agent string // the review agent, for KindAgentActivity
+	// Phases are the open phases a reported name matched, for
+	// KindPhaseAmbiguous.
+	Phases []string
+	// Issue is the review finding and its new state, for KindIssueLifecycle.
+	Issue *IssueTransition
+	// Summary is the executor's summary of an iteration, for the KindProg
+	// event reporting it. Text is the localized message showing it.
+	Summary string
+}
+
+// IssueTransition is the state a review finding reached in a review
+// iteration: "introduced", "persisted", "fixed", or "reappeared".
+type IssueTransition struct {
+	Agent       string `json:"agent"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+	Description string `json:"description"`
+	Iteration   int    `json:"iteration"`
+	State       string `json:"state"`
 }
 
 // Handler is a callback that receives typed events.
@@ -44,6 +115,12 @@ type Handler func(Event)
 // Prog creates a KindProg event.
 func Prog(text string) Event { return Event{Kind: KindProg, Text: text} }
 
+// IterationSummary creates a KindProg event reporting the executor's
+// summary, with text the message showing it.
+func IterationSummary(text, summary string) Event {
+	return Event{Kind: KindProg, Text: text, Summary: summary}
+}
+
 // ToolUse creates a KindToolUse event.
 func ToolUse(text string) Event { return Event{Kind: KindToolUse, Text: text} }
 
@@ -73,3 +150,25 @@ func StreamingText(text string) Event { return Event{Kind: KindStreamingText, Te
 
 // IterationSeparator creates a KindIterationSeparator event.
 func IterationSeparator(text string) Event { return Event{Kind: KindIterationSeparator, Text: text} }
+
+// AgentActivity creates a KindAgentActivity event for agent.
+func AgentActivity(agent, text string) Event {
+	return Event{Kind: KindAgentActivity, Text: text, Agent: agent}
+}
+
+// PhaseAmbiguous creates a KindPhaseAmbiguous event for the reported phase
+// name and the open phases it matched.
+func PhaseAmbiguous(reported string, candidates []string) Event {
+	return Event{
+		Kind: KindPhaseAmbiguous,
+		Text: fmt.Sprintf("Phase '%s' is ambiguous: it matches %d open phases: %s",
+			reported, len(candidates), strings.Join(candidates, "; ")),
+		Phases: candidates,
+	}
+}
+
+// IssueLifecycle creates a KindIssueLifecycle event for t, with text the
+// one-line description of the finding and its new state.
+func IssueLifecycle(text string, t IssueTransition) Event {
+	return Event{Kind: KindIssueLifecycle, Text: text, Issue: &t}
+}
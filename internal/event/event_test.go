@@ -38,7 +38,7 @@ func TestKindValues(t *testing.T) {
 	kinds := []Kind{
 		KindProg, KindToolUse, KindToolResult, KindReview,
 		KindDiffAdd, KindDiffDel, KindDiffCtx, KindDiffHunk,
-		KindMarkdown, KindIterationSeparator,
+		KindMarkdown, KindIterationSeparator, KindAgentActivity, KindPhaseAmbiguous, KindIssueLifecycle,
 	}
 	seen := make(map[Kind]bool)
 	for _, k := range kinds {
@@ -47,6 +47,18 @@ func TestKindValues(t *testing.T) {
 	}
 }
 
+func TestAgentActivity(t *testing.T) {
+	e := AgentActivity("quality", "Read main.go")
+	assert.Equal(t, Event{Kind: KindAgentActivity, Text: "Read main.go", Agent: "quality"}, e)
+}
+
+func TestPhaseAmbiguous(t *testing.T) {
+	e := PhaseAmbiguous("Setup", []string{"Setup DB", "Setup CI"})
+	assert.Equal(t, KindPhaseAmbiguous, e.Kind)
+	assert.Equal(t, []string{"Setup DB", "Setup CI"}, e.Phases)
+	assert.Equal(t, "Phase 'Setup' is ambiguous: it matches 2 open phases: Setup DB; Setup CI", e.Text)
+}
+
 func TestHandler(t *testing.T) {
 	var received []Event
 	h := Handler(func(e Event) {
@@ -74,3 +86,13 @@ func TestEventConstructorsPreserveWhitespace(t *testing.T) {
 	e := ToolUse(text)
 	assert.Equal(t, text, e.Text)
 }
+
+func TestParseKind(t *testing.T) {
+	for k := KindProg; k <= KindAgentActivity; k++ {
+		got, ok := ParseKind(k.String())
+		assert.True(t, ok, k.String())
+		assert.Equal(t, k, got)
+	}
+	_, ok := ParseKind("unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,151 @@
+package forge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// PullRequest is the part of a pull or merge request a run works from.
+type PullRequest struct {
+	Number     int
+	Title      string
+	Body       string
+	HeadBranch string
+	BaseBranch string
+	URL        string
+}
+
+// Client reads and updates pull requests.
+type Client interface {
+	Get(ref Ref) (*PullRequest, error)
+	SetBody(ref Ref, body string) error
+	Comment(ref Ref, body string) error
+	// Checkout checks out the pull request's branch in dir.
+	Checkout(ref Ref, dir string) error
+	// UpsertComment replaces the body of the comment containing marker, or
+	// adds a comment when there is none. body must contain marker.
+	UpsertComment(ref Ref, marker, body string) error
+	// SetCommitStatus sets the status of commit sha, shown on the pull
+	// request while sha is its head.
+	SetCommitStatus(ref Ref, sha string, status CommitStatus) error
+}
+
+// runFunc runs a command in dir with stdin and returns its stdout.
+type runFunc func(dir, stdin, name string, args ...string) (string, error)
+
+// CLIClient implements Client with the gh (GitHub) and glab (GitLab) CLIs,
+// which must be installed and authenticated.
+type CLIClient struct {
+	run runFunc
+}
+
+var _ Client = (*CLIClient)(nil)
+
+// NewClient returns a client that runs gh and glab.
+func NewClient() *CLIClient {
+	return &CLIClient{run: runCommand}
+}
+
+func runCommand(dir, stdin, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s: %s: %w", name, args[0], strings.TrimSpace(stderr.String()), err)
+	}
+	return stdout.String(), nil
+}
+
+// command returns the CLI and the subcommand prefix for ref.
+func command(ref Ref) (string, []string) {
+	if ref.Kind == GitLab {
+		return "glab", []string{"mr"}
+	}
+	return "gh", []string{"pr"}
+}
+
+// exec runs a pull request subcommand for ref, adding the number and --repo.
+func (c *CLIClient) exec(ref Ref, dir, stdin, sub string, args ...string) (string, error) {
+	name, full := command(ref)
+	full = append(full, sub, strconv.Itoa(ref.Number))
+	if repo := ref.repoFlag(); repo != "" {
+		full = append(full, "--repo", repo)
+	}
+	return c.run(dir, stdin, name, append(full, args...)...)
+}
+
+// Get fetches the pull request.
+func (c *CLIClient) Get(ref Ref) (*PullRequest, error) {
+	if ref.Kind == GitLab {
+		out, err := c.exec(ref, "", "", "view", "--output", "json")
+		if err != nil {
+			return nil, err
+		}
+		var mr struct {
+			IID          int    `json:"iid"`
+			Title        string `json:"title"`
+			Description  string `json:"description"`
+			SourceBranch string `json:"source_branch"`
+			TargetBranch string `json:"target_branch"`
+			WebURL       string `json:"web_url"`
+		}
+		if err := json.Unmarshal([]byte(out), &mr); err != nil {
+			return nil, fmt.Errorf("parse merge request %s: %w", ref, err)
+		}
+		return &PullRequest{Number: mr.IID, Title: mr.Title, Body: mr.Description,
+			HeadBranch: mr.SourceBranch, BaseBranch: mr.TargetBranch, URL: mr.WebURL}, nil
+	}
+
+	out, err := c.exec(ref, "", "", "view", "--json", "number,title,body,headRefName,baseRefName,url")
+	if err != nil {
+		return nil, err
+	}
+	var pr struct {
+		Number      int    `json:"number"`
+		Title       string `json:"title"`
+		Body        string `json:"body"`
+		HeadRefName string `json:"headRefName"`
+		BaseRefName string `json:"baseRefName"`
+		URL         string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(out), &pr); err != nil {
+		return nil, fmt.Errorf("parse pull request %s: %w", ref, err)
+	}
+	return &PullRequest{Number: pr.Number, Title: pr.Title, Body: pr.Body,
+		HeadBranch: pr.HeadRefName, BaseBranch: pr.BaseRefName, URL: pr.URL}, nil
+}
+
+// SetBody replaces the pull request description.
+func (c *CLIClient) SetBody(ref Ref, body string) error {
+	if ref.Kind == GitLab {
+		_, err := c.exec(ref, "", "", "update", "--description", body)
+		return err
+	}
+	_, err := c.exec(ref, "", body, "edit", "--body-file", "-")
+	return err
+}
+
+// Comment adds a comment to the pull request.
+func (c *CLIClient) Comment(ref Ref, body string) error {
+	if ref.Kind == GitLab {
+		_, err := c.exec(ref, "", "", "note", "--message", body)
+		return err
+	}
+	_, err := c.exec(ref, "", body, "comment", "--body-file", "-")
+	return err
+}
+
+// Checkout checks out the pull request's branch in dir.
+func (c *CLIClient) Checkout(ref Ref, dir string) error {
+	_, err := c.exec(ref, dir, "", "checkout")
+	return err
+}
@@ -0,0 +1,80 @@
+package forge
+
+import (
+	"strings"
+	"sync"
+)
+
+// MockClient is an in-memory Client holding a single pull request.
+type MockClient struct {
+	mu sync.Mutex
+
+	PR          PullRequest
+	Comments    []string
+	CheckoutErr error
+	Checkouts   []string // directories Checkout was called with
+	Statuses    []CommitStatus
+	StatusSHAs  []string // commits SetCommitStatus was called with, matching Statuses
+}
+
+var _ Client = (*MockClient)(nil)
+
+// NewMockClient returns a MockClient serving pr.
+func NewMockClient(pr PullRequest) *MockClient {
+	return &MockClient{PR: pr}
+}
+
+func (m *MockClient) Get(_ Ref) (*PullRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pr := m.PR
+	return &pr, nil
+}
+
+func (m *MockClient) SetBody(_ Ref, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.PR.Body = body
+	return nil
+}
+
+func (m *MockClient) Comment(_ Ref, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Comments = append(m.Comments, body)
+	return nil
+}
+
+func (m *MockClient) Checkout(_ Ref, dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Checkouts = append(m.Checkouts, dir)
+	return m.CheckoutErr
+}
+
+func (m *MockClient) UpsertComment(_ Ref, marker, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, c := range m.Comments {
+		if strings.Contains(c, marker) {
+			m.Comments[i] = body
+			return nil
+		}
+	}
+	m.Comments = append(m.Comments, body)
+	return nil
+}
+
+func (m *MockClient) SetCommitStatus(_ Ref, sha string, status CommitStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Statuses = append(m.Statuses, status)
+	m.StatusSHAs = append(m.StatusSHAs, sha)
+	return nil
+}
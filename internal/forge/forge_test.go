@@ -0,0 +1,153 @@
+package forge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Ref
+		ok   bool
+	}{
+		{in: "pr:12", want: Ref{Kind: GitHub, Number: 12}, ok: true},
+		{in: "pr:owner/repo#7", want: Ref{Kind: GitHub, Repo: "owner/repo", Number: 7}, ok: true},
+		{in: "pr:ghe.corp.com/owner/repo#7", want: Ref{Kind: GitHub, Host: "ghe.corp.com", Repo: "owner/repo", Number: 7}, ok: true},
+		{in: "mr:group/sub/project#3", want: Ref{Kind: GitLab, Repo: "group/sub/project", Number: 3}, ok: true},
+		{in: "https://github.com/owner/repo/pull/42", want: Ref{Kind: GitHub, Repo: "owner/repo", Number: 42}, ok: true},
+		{in: "https://gitlab.example.com/group/project/-/merge_requests/5/", want: Ref{Kind: GitLab, Host: "gitlab.example.com", Repo: "group/project", Number: 5}, ok: true},
+		{in: "https://gitlab.com/a/b/c/-/merge_requests/9", want: Ref{Kind: GitLab, Repo: "a/b/c", Number: 9}, ok: true},
+		{in: "pr:0", ok: false},
+		{in: "pr-12", ok: false},
+		{in: "./plans/pr.md", ok: false},
+		{in: "https://github.com/owner/repo/issues/4", ok: false},
+		{in: "pro-1a2b", ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, ok := ParseRef(tc.in)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.want, got)
+			if ok {
+				again, _ := ParseRef(got.String())
+				assert.Equal(t, got, again, "String round-trips")
+			}
+		})
+	}
+}
+
+type call struct {
+	dir, stdin, name string
+	args             []string
+}
+
+func recordingClient(out string) (*CLIClient, *[]call) {
+	var calls []call
+	return &CLIClient{run: func(dir, stdin, name string, args ...string) (string, error) {
+		calls = append(calls, call{dir, stdin, name, args})
+		return out, nil
+	}}, &calls
+}
+
+func TestCLIClient_GitHub(t *testing.T) {
+	c, calls := recordingClient(`{"number":7,"title":"Add cache","body":"- [ ] Tests","headRefName":"feat","baseRefName":"main","url":"https://github.com/o/r/pull/7"}`)
+	ref := Ref{Kind: GitHub, Repo: "o/r", Number: 7}
+
+	pr, err := c.Get(ref)
+	require.NoError(t, err)
+	assert.Equal(t, &PullRequest{Number: 7, Title: "Add cache", Body: "- [ ] Tests", HeadBranch: "feat", BaseBranch: "main",
+		URL: "https://github.com/o/r/pull/7"}, pr)
+
+	require.NoError(t, c.SetBody(ref, "- [x] Tests"))
+	require.NoError(t, c.Comment(ref, "done"))
+	require.NoError(t, c.Checkout(ref, "/work"))
+
+	require.Len(t, *calls, 4)
+	assert.Equal(t, "gh", (*calls)[0].name)
+	assert.Equal(t, []string{"pr", "view", "7", "--repo", "o/r", "--json", "number,title,body,headRefName,baseRefName,url"}, (*calls)[0].args)
+	assert.Equal(t, []string{"pr", "edit", "7", "--repo", "o/r", "--body-file", "-"}, (*calls)[1].args)
+	assert.Equal(t, "- [x] Tests", (*calls)[1].stdin)
+	assert.Equal(t, "done", (*calls)[2].stdin)
+	assert.Equal(t, call{dir: "/work", name: "gh", args: []string{"pr", "checkout", "7", "--repo", "o/r"}}, (*calls)[3])
+}
+
+func TestCLIClient_GitLab(t *testing.T) {
+	c, calls := recordingClient(`{"iid":5,"title":"Fix","description":"body","source_branch":"fix","target_branch":"develop","web_url":"u"}`)
+	ref := Ref{Kind: GitLab, Host: "git.example.com", Repo: "g/p", Number: 5}
+
+	pr, err := c.Get(ref)
+	require.NoError(t, err)
+	assert.Equal(t, "fix", pr.HeadBranch)
+	assert.Equal(t, "develop", pr.BaseBranch)
+	assert.Equal(t, "body", pr.Body)
+
+	require.NoError(t, c.Comment(ref, "hi"))
+	assert.Equal(t, "glab", (*calls)[0].name)
+	assert.Equal(t, []string{"mr", "view", "5", "--repo", "https://git.example.com/g/p", "--output", "json"}, (*calls)[0].args)
+	assert.Equal(t, []string{"mr", "note", "5", "--repo", "https://git.example.com/g/p", "--message", "hi"}, (*calls)[1].args)
+}
+
+// scriptedClient answers each command with the next of outs.
+func scriptedClient(outs ...string) (*CLIClient, *[]call) {
+	var calls []call
+	return &CLIClient{run: func(dir, stdin, name string, args ...string) (string, error) {
+		calls = append(calls, call{dir, stdin, name, args})
+		out := ""
+		if len(outs) > 0 {
+			out, outs = outs[0], outs[1:]
+		}
+		return out, nil
+	}}, &calls
+}
+
+func TestCLIClient_UpsertComment(t *testing.T) {
+	t.Run("github edits the marked comment", func(t *testing.T) {
+		c, calls := scriptedClient(`[{"id":1,"body":"LGTM"}]`+"\n"+`[{"id":2,"body":"<!-- m -->\nold"}]`, "{}")
+		ref := Ref{Kind: GitHub, Repo: "o/r", Number: 7}
+
+		require.NoError(t, c.UpsertComment(ref, "<!-- m -->", "<!-- m -->\nnew"))
+		require.Len(t, *calls, 2)
+		assert.Equal(t, []string{"api", "--paginate", "repos/o/r/issues/7/comments"}, (*calls)[0].args)
+		assert.Equal(t, call{name: "gh", stdin: "<!-- m -->\nnew",
+			args: []string{"api", "--method", "PATCH", "repos/o/r/issues/comments/2", "--field", "body=@-"}}, (*calls)[1])
+	})
+
+	t.Run("github comments when no comment is marked", func(t *testing.T) {
+		c, calls := scriptedClient(`[]`, "")
+		ref := Ref{Kind: GitHub, Number: 7}
+
+		require.NoError(t, c.UpsertComment(ref, "<!-- m -->", "<!-- m -->\nnew"))
+		require.Len(t, *calls, 2)
+		assert.Equal(t, []string{"api", "--paginate", "repos/{owner}/{repo}/issues/7/comments"}, (*calls)[0].args)
+		assert.Equal(t, []string{"pr", "comment", "7", "--body-file", "-"}, (*calls)[1].args)
+	})
+
+	t.Run("gitlab edits the marked note", func(t *testing.T) {
+		c, calls := scriptedClient(`[{"id":9,"body":"<!-- m -->"}]`, "{}")
+		ref := Ref{Kind: GitLab, Host: "git.example.com", Repo: "g/p", Number: 5}
+
+		require.NoError(t, c.UpsertComment(ref, "<!-- m -->", "<!-- m -->\nnew"))
+		require.Len(t, *calls, 2)
+		assert.Equal(t, "glab", (*calls)[0].name)
+		assert.Equal(t, []string{"api", "--hostname", "git.example.com", "--paginate", "projects/g%2Fp/merge_requests/5/notes"}, (*calls)[0].args)
+		assert.Equal(t, []string{"api", "--hostname", "git.example.com", "--method", "PUT", "projects/g%2Fp/merge_requests/5/notes/9",
+			"--raw-field", "body=<!-- m -->\nnew"}, (*calls)[1].args)
+	})
+}
+
+func TestCLIClient_SetCommitStatus(t *testing.T) {
+	c, calls := scriptedClient()
+	status := CommitStatus{Name: "programmator", State: StatePending, Description: "Running"}
+
+	require.NoError(t, c.SetCommitStatus(Ref{Kind: GitHub, Repo: "o/r", Number: 7}, "abc", status))
+	require.NoError(t, c.SetCommitStatus(Ref{Kind: GitLab, Number: 5}, "abc", status))
+
+	assert.Equal(t, []string{"api", "--method", "POST", "repos/o/r/statuses/abc",
+		"--raw-field", "state=pending", "--raw-field", "context=programmator", "--raw-field", "description=Running"}, (*calls)[0].args)
+	assert.Equal(t, []string{"api", "--method", "POST", "projects/:id/statuses/abc",
+		"--raw-field", "state=running", "--raw-field", "name=programmator", "--raw-field", "description=Running"}, (*calls)[1].args)
+}
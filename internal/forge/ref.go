@@ -0,0 +1,113 @@
+// Package forge reads and updates pull requests on GitHub and merge requests
+// on GitLab through the gh and glab CLIs.
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind identifies the hosting service of a pull request.
+type Kind string
+
+const (
+	GitHub Kind = "github"
+	GitLab Kind = "gitlab"
+)
+
+// Ref identifies a pull request (GitHub) or merge request (GitLab).
+type Ref struct {
+	Kind   Kind
+	Host   string // empty for github.com/gitlab.com or the CLI's default host
+	Repo   string // owner/repo or group/project; empty for the repository in the working directory
+	Number int
+}
+
+var (
+	shortRefRegex  = regexp.MustCompile(`^(pr|mr):(?:([^#\s]+)#)?(\d+)$`)
+	githubURLRegex = regexp.MustCompile(`^/([^/]+/[^/]+)/pull/(\d+)/?$`)
+	gitlabURLRegex = regexp.MustCompile(`^/(.+?)/-/merge_requests/(\d+)/?$`)
+)
+
+// ParseRef recognizes a pull request reference:
+//
+//   - pr:123, pr:owner/repo#123 (GitHub)
+//   - mr:45, mr:group/project#45 (GitLab)
+//   - https://github.com/owner/repo/pull/123
+//   - https://gitlab.example.com/group/project/-/merge_requests/45
+//
+// A leading path segment with a dot in pr:/mr: references is a host, as in
+// pr:github.example.com/owner/repo#1.
+func ParseRef(s string) (Ref, bool) {
+	s = strings.TrimSpace(s)
+	if m := shortRefRegex.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[3])
+		if err != nil || n < 1 {
+			return Ref{}, false
+		}
+		ref := Ref{Kind: GitHub, Number: n}
+		if m[1] == "mr" {
+			ref.Kind = GitLab
+		}
+		ref.Repo = m[2]
+		if host, rest, ok := strings.Cut(ref.Repo, "/"); ok && strings.Contains(host, ".") {
+			ref.Host, ref.Repo = host, rest
+		}
+		return ref, true
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return Ref{}, false
+	}
+	kind, m := GitHub, githubURLRegex.FindStringSubmatch(u.Path)
+	if m == nil {
+		kind, m = GitLab, gitlabURLRegex.FindStringSubmatch(u.Path)
+	}
+	if m == nil {
+		return Ref{}, false
+	}
+	n, err := strconv.Atoi(m[2])
+	if err != nil || n < 1 {
+		return Ref{}, false
+	}
+	ref := Ref{Kind: kind, Host: u.Host, Repo: m[1], Number: n}
+	if ref.Host == "github.com" || ref.Host == "gitlab.com" {
+		ref.Host = ""
+	}
+	return ref, true
+}
+
+// String returns the short form of the reference, accepted by ParseRef.
+func (r Ref) String() string {
+	prefix := "pr:"
+	if r.Kind == GitLab {
+		prefix = "mr:"
+	}
+	repo := r.Repo
+	if r.Host != "" {
+		repo = r.Host + "/" + repo
+	}
+	if repo == "" {
+		return fmt.Sprintf("%s%d", prefix, r.Number)
+	}
+	return fmt.Sprintf("%s%s#%d", prefix, repo, r.Number)
+}
+
+// repoFlag returns the --repo value the CLI expects, or "" for the
+// repository in the working directory.
+func (r Ref) repoFlag() string {
+	switch {
+	case r.Repo == "":
+		return ""
+	case r.Host == "":
+		return r.Repo
+	case r.Kind == GitLab:
+		return "https://" + r.Host + "/" + r.Repo
+	default:
+		return r.Host + "/" + r.Repo
+	}
+}
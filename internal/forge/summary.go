@@ -0,0 +1,129 @@
+package forge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CommitState is the state of a commit status.
+type CommitState string
+
+// Commit status states, mapped to each forge's own names.
+const (
+	StatePending CommitState = "pending"
+	StateSuccess CommitState = "success"
+	StateFailure CommitState = "failure"
+)
+
+// CommitStatus is a status reported on a commit under a name, such as a CI
+// check.
+type CommitStatus struct {
+	Name        string // "context" on GitHub, "name" on GitLab
+	State       CommitState
+	Description string
+}
+
+// comment is a pull request comment or merge request note as the APIs
+// return them.
+type comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// api runs "gh api" or "glab api" for ref's host with args.
+func (c *CLIClient) api(ref Ref, stdin string, args ...string) (string, error) {
+	name := "gh"
+	if ref.Kind == GitLab {
+		name = "glab"
+	}
+	full := []string{"api"}
+	if ref.Host != "" {
+		full = append(full, "--hostname", ref.Host)
+	}
+	return c.run("", stdin, name, append(full, args...)...)
+}
+
+// apiRepo is ref's repository in API paths. Without one, the CLIs fill in
+// the repository of the working directory.
+func apiRepo(ref Ref) string {
+	if ref.Kind == GitLab {
+		if ref.Repo == "" {
+			return "projects/:id"
+		}
+		return "projects/" + url.PathEscape(ref.Repo)
+	}
+	if ref.Repo == "" {
+		return "repos/{owner}/{repo}"
+	}
+	return "repos/" + ref.Repo
+}
+
+// UpsertComment edits the first comment containing marker, or adds one.
+func (c *CLIClient) UpsertComment(ref Ref, marker, body string) error {
+	path := apiRepo(ref) + "/issues/" + strconv.Itoa(ref.Number) + "/comments"
+	if ref.Kind == GitLab {
+		path = apiRepo(ref) + "/merge_requests/" + strconv.Itoa(ref.Number) + "/notes"
+	}
+	out, err := c.api(ref, "", "--paginate", path)
+	if err != nil {
+		return err
+	}
+	comments, err := parseComments(out)
+	if err != nil {
+		return fmt.Errorf("parse comments of %s: %w", ref, err)
+	}
+
+	for _, cm := range comments {
+		if !strings.Contains(cm.Body, marker) {
+			continue
+		}
+		if ref.Kind == GitLab {
+			_, err = c.api(ref, "", "--method", "PUT", path+"/"+strconv.FormatInt(cm.ID, 10), "--raw-field", "body="+body)
+		} else {
+			_, err = c.api(ref, body, "--method", "PATCH", apiRepo(ref)+"/issues/comments/"+strconv.FormatInt(cm.ID, 10), "--field", "body=@-")
+		}
+		return err
+	}
+	return c.Comment(ref, body)
+}
+
+// parseComments decodes paginated API output: one JSON array per page.
+func parseComments(out string) ([]comment, error) {
+	var all []comment
+	dec := json.NewDecoder(strings.NewReader(out))
+	for {
+		var page []comment
+		err := dec.Decode(&page)
+		if errors.Is(err, io.EOF) {
+			return all, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+	}
+}
+
+// SetCommitStatus sets a commit status on sha in ref's repository.
+func (c *CLIClient) SetCommitStatus(ref Ref, sha string, status CommitStatus) error {
+	if ref.Kind == GitLab {
+		state := string(status.State)
+		switch status.State {
+		case StatePending:
+			state = "running"
+		case StateFailure:
+			state = "failed"
+		}
+		_, err := c.api(ref, "", "--method", "POST", apiRepo(ref)+"/statuses/"+sha,
+			"--raw-field", "state="+state, "--raw-field", "name="+status.Name, "--raw-field", "description="+status.Description)
+		return err
+	}
+	_, err := c.api(ref, "", "--method", "POST", apiRepo(ref)+"/statuses/"+sha,
+		"--raw-field", "state="+string(status.State), "--raw-field", "context="+status.Name, "--raw-field", "description="+status.Description)
+	return err
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// emptyTree is the hash of git's empty tree, the base of a root commit.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// CommitFilter selects commits on HEAD's first-parent history. Zero fields
+// select every commit.
+type CommitFilter struct {
+	Last   int    // At most the last N matching commits
+	Author string // Commits whose author matches this pattern, as git log --author
+	Since  string // Commits after this date, as git log --since
+}
+
+// ChangedFilesInCommits returns the repo-relative files touched by the
+// commits matching f, and the revision before the oldest of them, the base a
+// diff of their changes starts from. No matching commits returns no files
+// and HEAD.
+func (r *Repo) ChangedFilesInCommits(f CommitFilter) (files []string, base string, err error) {
+	args := []string{"log", "--first-parent", "--format=%x00%H", "--name-only"}
+	if f.Last > 0 {
+		args = append(args, "-n", strconv.Itoa(f.Last))
+	}
+	if f.Author != "" {
+		args = append(args, "--author="+f.Author)
+	}
+	if f.Since != "" {
+		args = append(args, "--since="+f.Since)
+	}
+	out, err := runGit(r.repoRoot, append(args, "HEAD", "--")...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list commits: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	oldest := ""
+	for line := range strings.SplitSeq(out, "\n") {
+		if sha, ok := strings.CutPrefix(line, "\x00"); ok {
+			oldest = sha
+			continue
+		}
+		if line != "" && !seen[line] {
+			seen[line] = true
+			files = append(files, line)
+		}
+	}
+	if oldest == "" {
+		return nil, "HEAD", nil
+	}
+
+	base, err = runGit(r.repoRoot, "rev-parse", "--verify", "--quiet", oldest+"^")
+	if err != nil {
+		base = emptyTree
+	}
+	return files, base, nil
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_ChangedFilesInCommits(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	root, err := HeadCommit(dir)
+	require.NoError(t, err)
+
+	commit := func(file, author string) string {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(file+"\n"), 0o644))
+		_, err := runGit(dir, "add", file)
+		require.NoError(t, err)
+		_, err = runGit(dir, "-c", "user.name="+author, "-c", "user.email="+author+"@example.com", "commit", "-q", "-m", file)
+		require.NoError(t, err)
+		sha, err := HeadCommit(dir)
+		require.NoError(t, err)
+		return sha
+	}
+	first := commit("a.go", "alice")
+	commit("b.go", "bob")
+	commit("c.go", "alice")
+
+	files, base, err := repo.ChangedFilesInCommits(CommitFilter{Last: 2})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"b.go", "c.go"}, files)
+	assert.Equal(t, first, base)
+
+	files, base, err = repo.ChangedFilesInCommits(CommitFilter{Author: "alice"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a.go", "c.go"}, files)
+	assert.Equal(t, root, base, "the diff starts before alice's oldest commit")
+
+	files, base, err = repo.ChangedFilesInCommits(CommitFilter{Since: "1 hour ago"})
+	require.NoError(t, err)
+	assert.Contains(t, files, "a.go")
+	assert.NotEmpty(t, base)
+
+	files, base, err = repo.ChangedFilesInCommits(CommitFilter{Author: "nobody"})
+	require.NoError(t, err)
+	assert.Empty(t, files)
+	assert.Equal(t, "HEAD", base)
+
+	files, base, err = repo.ChangedFilesInCommits(CommitFilter{})
+	require.NoError(t, err)
+	assert.Contains(t, files, "README.md")
+	assert.Equal(t, emptyTree, base, "the root commit diffs against the empty tree")
+}
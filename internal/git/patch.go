@@ -0,0 +1,55 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ApplyPatch applies a unified diff to the worktree at dir with git apply.
+// With checkOnly, nothing is changed; the error reports whether the patch
+// would apply cleanly.
+func ApplyPatch(dir, patch string, checkOnly bool) error {
+	args := []string{"apply", "--recount", "--whitespace=nowarn"}
+	if checkOnly {
+		args = append(args, "--check")
+	}
+	if _, err := runGitInput(dir, patch, args...); err != nil {
+		return fmt.Errorf("apply patch: %w", err)
+	}
+	return nil
+}
+
+// PatchFiles returns the paths a unified diff changes.
+func PatchFiles(dir, patch string) ([]string, error) {
+	out, err := runGitInput(dir, patch, "apply", "--recount", "--numstat")
+	if err != nil {
+		return nil, fmt.Errorf("read patch: %w", err)
+	}
+	var files []string
+	for line := range strings.Lines(out) {
+		fields := strings.SplitN(strings.TrimSpace(line), "\t", 3)
+		if len(fields) == 3 {
+			files = append(files, fields[2])
+		}
+	}
+	return files, nil
+}
+
+// runGitInput runs a git command in dir with input on stdin and returns its
+// trimmed stdout.
+func runGitInput(dir, input string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w (stderr: %s)", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,39 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatch(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	patch := `--- a/README.md
++++ b/README.md
+@@ -1 +1,2 @@
+ # Test
++Usage notes.
+`
+	files, err := PatchFiles(dir, patch)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md"}, files)
+
+	require.NoError(t, ApplyPatch(dir, patch, true))
+	data, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Test\n", string(data), "check does not modify the file")
+
+	require.NoError(t, ApplyPatch(dir, patch, false))
+	data, err = os.ReadFile(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Test\nUsage notes.\n", string(data))
+
+	// Applying again no longer matches the file.
+	require.Error(t, ApplyPatch(dir, patch, true))
+	require.Error(t, ApplyPatch(dir, "not a patch", true))
+}
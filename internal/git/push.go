@@ -0,0 +1,58 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Push failure classes, wrapped by errors returned from Push.
+var (
+	// ErrPushRejected means the remote refused the push because the branch
+	// has diverged (non-fast-forward).
+	ErrPushRejected = errors.New("push rejected")
+	// ErrPushAuth means the remote could not be reached with the available
+	// credentials.
+	ErrPushAuth = errors.New("push authentication failed")
+)
+
+// HasUpstream reports whether the current branch tracks a remote branch.
+func (r *Repo) HasUpstream() bool {
+	_, err := runGit(r.repoRoot, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	return err == nil
+}
+
+// Push pushes the current branch to remote. When the branch has no upstream
+// yet, it is pushed to a branch of the same name and set as upstream.
+// The git CLI is used so credential helpers and SSH agents apply.
+func (r *Repo) Push(remote string) error {
+	branch, err := r.CurrentBranch()
+	if err != nil {
+		return fmt.Errorf("push: %w", err)
+	}
+
+	args := []string{"push", remote, branch}
+	if !r.HasUpstream() {
+		args = []string{"push", "--set-upstream", remote, branch}
+	}
+	if _, err := runGit(r.repoRoot, args...); err != nil {
+		return classifyPushError(remote, branch, err)
+	}
+	return nil
+}
+
+// classifyPushError wraps err with ErrPushRejected or ErrPushAuth when git's
+// output identifies the cause.
+func classifyPushError(remote, branch string, err error) error {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "updates were rejected"), strings.Contains(msg, "non-fast-forward"),
+		strings.Contains(msg, "fetch first"):
+		return fmt.Errorf("%w: %s %s: %w", ErrPushRejected, remote, branch, err)
+	case strings.Contains(msg, "authentication failed"), strings.Contains(msg, "permission denied"),
+		strings.Contains(msg, "could not read username"), strings.Contains(msg, "403"):
+		return fmt.Errorf("%w: %s %s: %w", ErrPushAuth, remote, branch, err)
+	default:
+		return fmt.Errorf("push %s %s: %w", remote, branch, err)
+	}
+}
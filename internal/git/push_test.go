@@ -0,0 +1,60 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_Push(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	_, err := runGit(dir, "init", "-q", "--bare", remote)
+	require.NoError(t, err)
+	_, err = runGit(dir, "remote", "add", "origin", remote)
+	require.NoError(t, err)
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	assert.False(t, repo.HasUpstream())
+
+	require.NoError(t, repo.Push("origin"))
+	assert.True(t, repo.HasUpstream(), "first push sets upstream")
+
+	// Another clone pushes first, so the next local push is non-fast-forward.
+	other := filepath.Join(t.TempDir(), "other")
+	_, err = runGit(dir, "clone", "-q", remote, other)
+	require.NoError(t, err)
+	_, err = runGit(other, "config", "user.email", "other@example.com")
+	require.NoError(t, err)
+	_, err = runGit(other, "config", "user.name", "Other")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(other, "other.txt"), []byte("other\n"), 0644))
+	_, err = CommitWorktree(other, "Other change")
+	require.NoError(t, err)
+	_, err = runGit(other, "push", "-q")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "local.txt"), []byte("local\n"), 0644))
+	_, err = CommitWorktree(dir, "Local change")
+	require.NoError(t, err)
+
+	err = repo.Push("origin")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrPushRejected), "got %v", err)
+}
+
+func TestClassifyPushError(t *testing.T) {
+	authErr := classifyPushError("origin", "main", errors.New("fatal: Authentication failed for 'https://example.com/repo.git/'"))
+	assert.True(t, errors.Is(authErr, ErrPushAuth))
+
+	otherErr := classifyPushError("origin", "main", errors.New("fatal: 'origin' does not appear to be a git repository"))
+	assert.False(t, errors.Is(otherErr, ErrPushAuth))
+	assert.False(t, errors.Is(otherErr, ErrPushRejected))
+}
@@ -24,13 +24,21 @@ type Repo struct {
 	repoRoot string
 }
 
+// ErrUnavailable is returned by NewRepo when the directory is not inside a
+// git repository or the git binary is not installed.
+var ErrUnavailable = errors.New("git unavailable")
+
 // NewRepo creates a new Repo for the given working directory.
-// Returns an error if the directory is not a git repository.
+// Returns an error wrapping ErrUnavailable if the directory is not a git
+// repository or git is not installed.
 func NewRepo(workDir string) (*Repo, error) {
 	r, err := git.PlainOpenWithOptions(workDir, &git.PlainOpenOptions{
 		DetectDotGit:          true,
 		EnableDotGitCommonDir: true,
 	})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, fmt.Errorf("open git repo at %s: %w: not a git repository", workDir, ErrUnavailable)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("open git repo at %s: %w", workDir, err)
 	}
@@ -38,6 +46,9 @@ func NewRepo(workDir string) (*Repo, error) {
 	rootCmd := exec.Command("git", "rev-parse", "--show-toplevel")
 	rootCmd.Dir = workDir
 	rootOut, err := rootCmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("open git repo at %s: %w: git is not installed", workDir, ErrUnavailable)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("git rev-parse --show-toplevel at %s: %w", workDir, err)
 	}
@@ -68,8 +79,9 @@ func (r *Repo) BranchExists(branch string) (bool, error) {
 	return false, nil
 }
 
-// CreateBranch creates a new branch and switches to it.
-// If the branch already exists, it just checks it out.
+// CreateBranch creates a new branch and switches to it. Like git checkout
+// -b, a new branch keeps uncommitted changes: it starts at HEAD, so there is
+// nothing to reset. If the branch already exists, it just checks it out.
 func (r *Repo) CreateBranch(branch string) error {
 	exists, err := r.BranchExists(branch)
 	if err != nil {
@@ -89,6 +101,7 @@ func (r *Repo) CreateBranch(branch string) error {
 	err = wt.Checkout(&git.CheckoutOptions{
 		Branch: ref,
 		Create: true,
+		Keep:   true,
 	})
 	if err != nil {
 		return fmt.Errorf("create branch %s: %w", branch, err)
@@ -384,3 +397,23 @@ func sanitizeBranchName(s string) string {
 
 	return s
 }
+
+// InitRepo creates a repository at dir and commits the files already in
+// it, if any, with message. Used for scratch copies of a tree.
+func InitRepo(dir, message string) (*Repo, error) {
+	if _, err := runGit(dir, "init", "-q"); err != nil {
+		return nil, fmt.Errorf("init repo: %w", err)
+	}
+	r, err := NewRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+	env := r.signatureEnv()
+	if _, err := runGitEnv(dir, env, "add", "-A"); err != nil {
+		return nil, fmt.Errorf("init repo: %w", err)
+	}
+	if _, err := runGitEnv(dir, env, "commit", "-q", "--allow-empty", "--no-verify", "-m", message); err != nil {
+		return nil, fmt.Errorf("init repo: %w", err)
+	}
+	return r, nil
+}
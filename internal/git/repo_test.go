@@ -102,6 +102,26 @@ func TestRepo_CreateBranch(t *testing.T) {
 	assert.True(t, exists)
 }
 
+func TestRepo_CreateBranch_KeepsUncommittedChanges(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Changed\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0644))
+
+	require.NoError(t, repo.CreateBranch("feature/wip"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Changed\n", string(data))
+	files, err := repo.UncommittedFiles()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"README.md", "new.txt"}, files)
+}
+
 func TestRepo_CreateBranch_ExistingBranch(t *testing.T) {
 	dir, cleanup := setupTestRepo(t)
 	defer cleanup()
@@ -307,6 +327,7 @@ func TestNewRepo_NonGitDir(t *testing.T) {
 	_, err := NewRepo(tmpDir)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "open git repo")
+	assert.ErrorIs(t, err, ErrUnavailable)
 }
 
 func TestRepo_Add_PathTraversal(t *testing.T) {
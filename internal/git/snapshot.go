@@ -0,0 +1,98 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Snapshot commits the whole worktree, untracked files included, on top of
+// branch without touching HEAD, the index, or any file. The first snapshot
+// creates branch at HEAD. Returns the new commit, or "" when the worktree
+// matches the branch tip.
+func (r *Repo) Snapshot(branch, message string) (string, error) {
+	ref := "refs/heads/" + branch
+	parent, err := runGit(r.repoRoot, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		if parent, err = HeadCommit(r.repoRoot); err != nil {
+			return "", err
+		}
+		if _, err := runGit(r.repoRoot, "update-ref", ref, parent, ""); err != nil {
+			return "", fmt.Errorf("create snapshot branch %s: %w", branch, err)
+		}
+	}
+
+	// A scratch index keeps the user's staging area as it is.
+	indexDir, err := os.MkdirTemp("", "programmator-snapshot-")
+	if err != nil {
+		return "", fmt.Errorf("create snapshot index: %w", err)
+	}
+	defer os.RemoveAll(indexDir)
+
+	env := append(r.signatureEnv(), "GIT_INDEX_FILE="+filepath.Join(indexDir, "index"))
+	if _, err := runGitEnv(r.repoRoot, env, "read-tree", parent); err != nil {
+		return "", fmt.Errorf("snapshot: %w", err)
+	}
+	if _, err := runGitEnv(r.repoRoot, env, "add", "-A", "--", "."); err != nil {
+		return "", fmt.Errorf("snapshot: %w", err)
+	}
+	tree, err := runGitEnv(r.repoRoot, env, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("snapshot: %w", err)
+	}
+	parentTree, err := runGit(r.repoRoot, "rev-parse", parent+"^{tree}")
+	if err != nil {
+		return "", fmt.Errorf("snapshot: %w", err)
+	}
+	if tree == parentTree {
+		return "", nil
+	}
+
+	commit, err := runGitEnv(r.repoRoot, env, "commit-tree", tree, "-p", parent, "-m", message)
+	if err != nil {
+		return "", fmt.Errorf("snapshot: %w", err)
+	}
+	if _, err := runGit(r.repoRoot, "update-ref", ref, commit, parent); err != nil {
+		return "", fmt.Errorf("snapshot: %w", err)
+	}
+	return commit, nil
+}
+
+// signatureEnv sets the author and committer of commits made with the git
+// command to the ones go-git commits use.
+func (r *Repo) signatureEnv() []string {
+	sig := r.commitSignature()
+	return []string{
+		"GIT_AUTHOR_NAME=" + sig.Name,
+		"GIT_AUTHOR_EMAIL=" + sig.Email,
+		"GIT_COMMITTER_NAME=" + sig.Name,
+		"GIT_COMMITTER_EMAIL=" + sig.Email,
+	}
+}
+
+// DeleteBranch deletes a local branch that is not checked out, merged or not.
+func (r *Repo) DeleteBranch(branch string) error {
+	if _, err := runGit(r.repoRoot, "branch", "-D", branch); err != nil {
+		return fmt.Errorf("delete branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// runGitEnv is runGit with extra environment variables.
+func runGitEnv(dir string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w (stderr: %s)", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
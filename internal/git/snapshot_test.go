@@ -0,0 +1,60 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_Snapshot(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	head, err := HeadCommit(dir)
+	require.NoError(t, err)
+
+	sha, err := repo.Snapshot("snapshots/test", "iter 1: nothing yet")
+	require.NoError(t, err)
+	assert.Empty(t, sha, "a clean worktree needs no snapshot")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Changed\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0o644))
+	first, err := repo.Snapshot("snapshots/test", "iter 2: edit")
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	second, err := repo.Snapshot("snapshots/test", "iter 3: more")
+	require.NoError(t, err)
+	require.NotEmpty(t, second)
+
+	log, err := runGit(dir, "log", "--format=%s", "snapshots/test")
+	require.NoError(t, err)
+	assert.Equal(t, "iter 3: more\niter 2: edit\nInitial commit", log)
+	content, err := runGit(dir, "show", "snapshots/test:new.go")
+	require.NoError(t, err)
+	assert.Contains(t, content, "func main")
+
+	after, err := HeadCommit(dir)
+	require.NoError(t, err)
+	assert.Equal(t, head, after, "HEAD stays where it was")
+	branch, err := repo.CurrentBranch()
+	require.NoError(t, err)
+	assert.NotEqual(t, "snapshots/test", branch)
+	files, err := repo.UncommittedFiles()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"README.md", "new.go"}, files, "the worktree keeps its changes")
+	staged, err := runGit(dir, "diff", "--cached", "--name-only")
+	require.NoError(t, err)
+	assert.Empty(t, staged, "the index is untouched")
+
+	require.NoError(t, repo.DeleteBranch("snapshots/test"))
+	exists, err := repo.BranchExists("snapshots/test")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,62 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Commit is a commit's hash and subject line.
+type Commit struct {
+	SHA     string
+	Subject string
+}
+
+// SquashGroup is consecutive commits to squash into one commit with
+// Message. Last is the group's last commit, whose tree the new commit gets.
+type SquashGroup struct {
+	Last    string
+	Message string
+}
+
+// CommitsSince returns the commits after base up to HEAD, oldest first.
+func (r *Repo) CommitsSince(base string) ([]Commit, error) {
+	out, err := runGit(r.repoRoot, "log", "--reverse", "--first-parent", "--format=%H%x00%s", base+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("list commits since %s: %w", base, err)
+	}
+	var commits []Commit
+	for line := range strings.SplitSeq(out, "\n") {
+		sha, subject, ok := strings.Cut(line, "\x00")
+		if ok {
+			commits = append(commits, Commit{SHA: sha, Subject: subject})
+		}
+	}
+	return commits, nil
+}
+
+// Squash replaces the commits after base with one commit per group, in
+// order, and moves the current branch to the last one. The index and the
+// files are not touched. Returns the new HEAD.
+func (r *Repo) Squash(base string, groups []SquashGroup) (string, error) {
+	head, err := HeadCommit(r.repoRoot)
+	if err != nil {
+		return "", err
+	}
+	if len(groups) == 0 {
+		return head, nil
+	}
+
+	env := r.signatureEnv()
+	parent := base
+	for _, g := range groups {
+		commit, err := runGitEnv(r.repoRoot, env, "commit-tree", g.Last+"^{tree}", "-p", parent, "-m", g.Message)
+		if err != nil {
+			return "", fmt.Errorf("squash: %w", err)
+		}
+		parent = commit
+	}
+	if _, err := runGit(r.repoRoot, "update-ref", "-m", "programmator: squash", "HEAD", parent, head); err != nil {
+		return "", fmt.Errorf("squash: %w", err)
+	}
+	return parent, nil
+}
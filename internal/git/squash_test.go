@@ -0,0 +1,65 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_Squash(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	base, err := HeadCommit(dir)
+	require.NoError(t, err)
+
+	for _, c := range []struct{ file, message string }{
+		{"a.go", "Task 1"},
+		{"b.go", "Apply review patches"},
+		{"c.go", "Task 2"},
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, c.file), []byte("package main\n"), 0o644))
+		require.NoError(t, repo.AddAndCommit([]string{c.file}, c.message))
+	}
+	tree, err := runGit(dir, "rev-parse", "HEAD^{tree}")
+	require.NoError(t, err)
+
+	commits, err := repo.CommitsSince(base)
+	require.NoError(t, err)
+	require.Len(t, commits, 3)
+	assert.Equal(t, []string{"Task 1", "Apply review patches", "Task 2"},
+		[]string{commits[0].Subject, commits[1].Subject, commits[2].Subject})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "staged.go"), []byte("package main\n"), 0o644))
+	require.NoError(t, repo.Add("staged.go"))
+
+	head, err := repo.Squash(base, []SquashGroup{
+		{Last: commits[1].SHA, Message: "Task 1\n\n- Apply review patches"},
+		{Last: commits[2].SHA, Message: "Task 2"},
+	})
+	require.NoError(t, err)
+
+	after, err := HeadCommit(dir)
+	require.NoError(t, err)
+	assert.Equal(t, head, after)
+	log, err := runGit(dir, "log", "--format=%s", base+"..HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "Task 2\nTask 1", log)
+	body, err := runGit(dir, "log", "-1", "--format=%b", "HEAD~1")
+	require.NoError(t, err)
+	assert.Equal(t, "- Apply review patches", body)
+	newTree, err := runGit(dir, "rev-parse", "HEAD^{tree}")
+	require.NoError(t, err)
+	assert.Equal(t, tree, newTree, "the squashed history ends with the same files")
+	staged, err := runGit(dir, "diff", "--cached", "--name-only")
+	require.NoError(t, err)
+	assert.Equal(t, "staged.go", staged, "the index is untouched")
+	branch, err := repo.CurrentBranch()
+	require.NoError(t, err)
+	assert.NotEqual(t, "HEAD", branch)
+}
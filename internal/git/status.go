@@ -0,0 +1,114 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WorktreeState is HEAD and the contents of the uncommitted files at one
+// point in time. Comparing it with the worktree later gives the files
+// changed in between, even ones that were already uncommitted.
+type WorktreeState struct {
+	head  string
+	files map[string]string // repo-relative path -> content hash
+}
+
+// CaptureState records HEAD and the contents of the uncommitted,
+// non-ignored files.
+func (r *Repo) CaptureState() (*WorktreeState, error) {
+	head, err := HeadCommit(r.repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	files, err := r.UncommittedFiles()
+	if err != nil {
+		return nil, err
+	}
+	s := &WorktreeState{head: head, files: make(map[string]string, len(files))}
+	for _, f := range files {
+		s.files[f] = r.contentHash(f)
+	}
+	return s, nil
+}
+
+// ChangedSince returns the repo-relative paths changed since s: files of
+// commits made on top of s's HEAD, and files whose uncommitted contents
+// differ from s's, including ones committed or reverted since.
+func (r *Repo) ChangedSince(s *WorktreeState) (map[string]bool, error) {
+	changed := make(map[string]bool)
+
+	head, err := HeadCommit(r.repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if head != s.head {
+		out, err := runGit(r.repoRoot, "diff", "--name-only", "-z", s.head, head)
+		if err != nil {
+			return nil, fmt.Errorf("diff %s..%s: %w", s.head, head, err)
+		}
+		for f := range strings.SplitSeq(out, "\x00") {
+			if f != "" {
+				changed[f] = true
+			}
+		}
+	}
+
+	files, err := r.UncommittedFiles()
+	if err != nil {
+		return nil, err
+	}
+	dirty := make(map[string]bool, len(files))
+	for _, f := range files {
+		dirty[f] = true
+		if prev, ok := s.files[f]; !ok || prev != r.contentHash(f) {
+			changed[f] = true
+		}
+	}
+	for f := range s.files {
+		if !dirty[f] {
+			changed[f] = true
+		}
+	}
+	return changed, nil
+}
+
+// RepoPath converts p, absolute or relative to the working directory, to a
+// slash-separated path relative to the repository root. It returns false
+// for paths outside the repository.
+func (r *Repo) RepoPath(p string) (string, bool) {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(r.workDir, p)
+	}
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return "", false
+	}
+	root := r.repoRoot
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(p)); err == nil {
+		p = filepath.Join(resolved, filepath.Base(p))
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return path.Clean(filepath.ToSlash(rel)), true
+}
+
+// contentHash hashes the contents of the repo-relative file f; "" when it
+// does not exist or cannot be read.
+func (r *Repo) contentHash(f string) string {
+	data, err := os.ReadFile(filepath.Join(r.repoRoot, f)) //nolint:gosec // path listed by git
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,63 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_ChangedSince(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	write := func(name, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("dirty.txt", "v1\n")
+	write("same.txt", "same\n")
+	write("reverted.txt", "new\n")
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	before, err := repo.CaptureState()
+	require.NoError(t, err)
+
+	changed, err := repo.ChangedSince(before)
+	require.NoError(t, err)
+	assert.Empty(t, changed, "nothing happened yet")
+
+	write("dirty.txt", "v2\n")                                        // already uncommitted, changed again
+	write("created.txt", "x\n")                                       // new file
+	require.NoError(t, os.Remove(filepath.Join(dir, "reverted.txt"))) // uncommitted file removed
+	write("README.md", "# Changed\n")
+	require.NoError(t, repo.AddAndCommit([]string{"README.md"}, "Commit")) // committed since
+
+	changed, err = repo.ChangedSince(before)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"dirty.txt": true, "created.txt": true, "reverted.txt": true, "README.md": true}, changed)
+}
+
+func TestRepo_RepoPath(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	sub := filepath.Join(dir, "pkg")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	repo, err := NewRepo(sub)
+	require.NoError(t, err)
+
+	p, ok := repo.RepoPath("a.go")
+	assert.True(t, ok)
+	assert.Equal(t, "pkg/a.go", p)
+
+	p, ok = repo.RepoPath(filepath.Join(dir, "README.md"))
+	assert.True(t, ok)
+	assert.Equal(t, "README.md", p)
+
+	_, ok = repo.RepoPath("../../outside.go")
+	assert.False(t, ok)
+}
@@ -0,0 +1,112 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRebaseConflict is returned when the branch cannot be rebased cleanly.
+// The rebase is aborted, leaving the branch as it was.
+var ErrRebaseConflict = errors.New("rebase conflict")
+
+// fetchTimeout bounds FetchBranch so an unreachable remote cannot hold up a run.
+const fetchTimeout = time.Minute
+
+// BaseStatus describes how HEAD relates to its base branch.
+type BaseStatus struct {
+	Ref       string // ref compared against, e.g. "origin/main"
+	SHA       string // tip of Ref
+	MergeBase string // best common ancestor of HEAD and Ref
+	Ahead     int    // commits on HEAD missing from Ref
+	Behind    int    // commits on Ref missing from HEAD
+}
+
+// HasRemote reports whether a remote with the given name is configured.
+func (r *Repo) HasRemote(name string) bool {
+	_, err := runGit(r.repoRoot, "remote", "get-url", name)
+	return err == nil
+}
+
+// FetchBranch updates remote's tracking ref for branch. Credential prompts
+// are disabled so the fetch fails instead of waiting for input.
+func (r *Repo) FetchBranch(remote, branch string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "fetch", "-q", remote, branch)
+	cmd.Dir = r.repoRoot
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("fetch %s %s: %w (stderr: %s)", remote, branch, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// CompareWithBase reports how far HEAD is ahead of and behind ref.
+func (r *Repo) CompareWithBase(ref string) (*BaseStatus, error) {
+	sha, err := runGit(r.repoRoot, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	mergeBase, err := r.MergeBase(sha)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := runGit(r.repoRoot, "rev-list", "--left-right", "--count", "HEAD..."+sha)
+	if err != nil {
+		return nil, fmt.Errorf("compare with %s: %w", ref, err)
+	}
+	ahead, behind, ok := strings.Cut(counts, "\t")
+	if !ok {
+		return nil, fmt.Errorf("compare with %s: unexpected rev-list output %q", ref, counts)
+	}
+	status := &BaseStatus{Ref: ref, SHA: sha, MergeBase: mergeBase}
+	if status.Ahead, err = strconv.Atoi(ahead); err != nil {
+		return nil, fmt.Errorf("compare with %s: %w", ref, err)
+	}
+	if status.Behind, err = strconv.Atoi(behind); err != nil {
+		return nil, fmt.Errorf("compare with %s: %w", ref, err)
+	}
+	return status, nil
+}
+
+// HasTrackedChanges reports whether tracked files have staged or unstaged
+// changes. Untracked files, such as a new plan, are ignored.
+func (r *Repo) HasTrackedChanges() (bool, error) {
+	_, err := runGit(r.repoRoot, "diff", "--quiet", "HEAD", "--")
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	return false, err
+}
+
+// CanStash checks that the uncommitted changes could be stashed, without
+// stashing them. It fails, for example, while paths are unmerged.
+func (r *Repo) CanStash() error {
+	if _, err := runGit(r.repoRoot, "stash", "create"); err != nil {
+		return fmt.Errorf("changes cannot be stashed: %w", err)
+	}
+	return nil
+}
+
+// Rebase replays the current branch onto rev. On conflict the rebase is
+// aborted and ErrRebaseConflict is returned.
+func (r *Repo) Rebase(rev string) error {
+	if _, err := runGit(r.repoRoot, "rebase", "-q", rev); err != nil {
+		if _, abortErr := runGit(r.repoRoot, "rebase", "--abort"); abortErr != nil {
+			return fmt.Errorf("rebase onto %s: %w (abort failed: %w)", rev, err, abortErr)
+		}
+		return fmt.Errorf("%w: %s: %w", ErrRebaseConflict, rev, err)
+	}
+	return nil
+}
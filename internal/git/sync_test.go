@@ -0,0 +1,133 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_SyncWithBase(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	_, err := runGit(dir, "init", "-q", "--bare", remote)
+	require.NoError(t, err)
+	_, err = runGit(dir, "remote", "add", "origin", remote)
+	require.NoError(t, err)
+	_, err = runGit(dir, "push", "-q", "origin", "HEAD:refs/heads/main")
+	require.NoError(t, err)
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	assert.True(t, repo.HasRemote("origin"))
+	assert.False(t, repo.HasRemote("upstream"))
+
+	// main moves ahead in another clone while the local branch gets its own commit.
+	other := filepath.Join(t.TempDir(), "other")
+	_, err = runGit(dir, "clone", "-q", "-b", "main", remote, other)
+	require.NoError(t, err)
+	_, err = runGit(other, "config", "user.email", "other@example.com")
+	require.NoError(t, err)
+	_, err = runGit(other, "config", "user.name", "Other")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(other, "other.txt"), []byte("other\n"), 0644))
+	_, err = CommitWorktree(other, "Other change")
+	require.NoError(t, err)
+	_, err = runGit(other, "push", "-q")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "local.txt"), []byte("local\n"), 0644))
+	_, err = CommitWorktree(dir, "Local change")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "untracked.md"), []byte("plan\n"), 0644))
+	dirty, err := repo.HasTrackedChanges()
+	require.NoError(t, err)
+	assert.False(t, dirty, "untracked files do not count")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "local.txt"), []byte("edited\n"), 0644))
+	dirty, err = repo.HasTrackedChanges()
+	require.NoError(t, err)
+	assert.True(t, dirty)
+	_, err = runGit(dir, "checkout", "--", "local.txt")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.FetchBranch("origin", "main"))
+	status, err := repo.CompareWithBase("origin/main")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Ahead)
+	assert.Equal(t, 1, status.Behind)
+	assert.NotEqual(t, status.SHA, status.MergeBase)
+
+	require.NoError(t, repo.Rebase("origin/main"))
+	status, err = repo.CompareWithBase("origin/main")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Ahead)
+	assert.Equal(t, 0, status.Behind)
+	assert.Equal(t, status.SHA, status.MergeBase)
+
+	_, err = repo.CompareWithBase("origin/missing")
+	require.Error(t, err)
+}
+
+func TestRepo_RebaseConflictAborts(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	_, err := runGit(dir, "branch", "base")
+	require.NoError(t, err)
+	_, err = runGit(dir, "checkout", "-q", "base")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Base\n"), 0644))
+	_, err = CommitWorktree(dir, "Base change")
+	require.NoError(t, err)
+	_, err = runGit(dir, "checkout", "-q", "-")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Local\n"), 0644))
+	head, err := CommitWorktree(dir, "Local change")
+	require.NoError(t, err)
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	err = repo.Rebase("base")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRebaseConflict), "got %v", err)
+
+	after, err := HeadCommit(dir)
+	require.NoError(t, err)
+	assert.Equal(t, head, after, "aborted rebase leaves the branch as it was")
+}
+
+func TestRepo_CanStash(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Changed\n"), 0644))
+	require.NoError(t, repo.CanStash())
+	files, err := repo.UncommittedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md"}, files, "nothing is stashed")
+
+	// A merge conflict leaves unmerged paths, which cannot be stashed.
+	_, err = runGit(dir, "stash", "-q")
+	require.NoError(t, err)
+	_, err = runGit(dir, "checkout", "-q", "-b", "other")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Other\n"), 0644))
+	_, err = CommitWorktree(dir, "Other change")
+	require.NoError(t, err)
+	_, err = runGit(dir, "checkout", "-q", "-")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Local\n"), 0644))
+	_, err = CommitWorktree(dir, "Local change")
+	require.NoError(t, err)
+	_, err = runGit(dir, "merge", "-q", "other")
+	require.Error(t, err)
+	require.Error(t, repo.CanStash())
+}
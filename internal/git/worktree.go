@@ -0,0 +1,193 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrCherryPickConflict is returned when a commit cannot be applied cleanly.
+var ErrCherryPickConflict = errors.New("cherry-pick conflict")
+
+// AddWorktree creates a linked worktree at path on a new branch started from HEAD.
+func (r *Repo) AddWorktree(path, branch string) error {
+	if _, err := runGit(r.repoRoot, "worktree", "add", "-q", "-b", branch, path, "HEAD"); err != nil {
+		return fmt.Errorf("add worktree %s: %w", path, err)
+	}
+	return nil
+}
+
+// AddDetachedWorktree creates a linked worktree at path with rev checked
+// out and no branch. Remove it with RemoveWorktree(path, "").
+func (r *Repo) AddDetachedWorktree(path, rev string) error {
+	if _, err := runGit(r.repoRoot, "worktree", "add", "-q", "--detach", path, rev); err != nil {
+		return fmt.Errorf("add worktree %s: %w", path, err)
+	}
+	return nil
+}
+
+// RemoveWorktree removes a linked worktree and deletes its branch.
+func (r *Repo) RemoveWorktree(path, branch string) error {
+	var errs []error
+	if _, err := runGit(r.repoRoot, "worktree", "remove", "--force", path); err != nil {
+		errs = append(errs, fmt.Errorf("remove worktree %s: %w", path, err))
+	}
+	if branch != "" {
+		if _, err := runGit(r.repoRoot, "branch", "-D", branch); err != nil {
+			errs = append(errs, fmt.Errorf("delete branch %s: %w", branch, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CommitWorktree stages every change in the worktree at dir, except the
+// given repo-relative paths, and commits it when anything is staged.
+// Returns the worktree HEAD after the commit.
+func CommitWorktree(dir, message string, exclude ...string) (string, error) {
+	args := []string{"add", "-A", "--", "."}
+	for _, path := range exclude {
+		if path != "" {
+			args = append(args, ":(exclude)"+path)
+		}
+	}
+	if _, err := runGit(dir, args...); err != nil {
+		return "", fmt.Errorf("stage worktree changes: %w", err)
+	}
+
+	if _, err := runGit(dir, "diff", "--cached", "--quiet"); err != nil {
+		if _, err := runGit(dir, "commit", "-q", "-m", message); err != nil {
+			return "", fmt.Errorf("commit worktree changes: %w", err)
+		}
+	}
+
+	return HeadCommit(dir)
+}
+
+// HeadCommit returns the commit hash HEAD points to in the worktree at dir.
+func HeadCommit(dir string) (string, error) {
+	sha, err := runGit(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("resolve HEAD: %w", err)
+	}
+	return sha, nil
+}
+
+// UncommittedFiles returns repo-relative paths with staged, unstaged, or
+// untracked changes, excluding gitignored files.
+func (r *Repo) UncommittedFiles() ([]string, error) {
+	files, err := worktreeChanges(r.repo)
+	if err != nil {
+		return nil, err
+	}
+	files, err = filterGitIgnored(r.repoRoot, files)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// CherryPick applies a commit or commit range (e.g. "base..tip") onto the
+// current branch. On failure the cherry-pick is aborted, the working tree is
+// left as it was, and an error wrapping ErrCherryPickConflict is returned.
+func (r *Repo) CherryPick(rev string) error {
+	if _, err := runGit(r.repoRoot, "cherry-pick", rev); err != nil {
+		_, _ = runGit(r.repoRoot, "cherry-pick", "--abort")
+		return fmt.Errorf("%w: %s: %w", ErrCherryPickConflict, rev, err)
+	}
+	return nil
+}
+
+// Diff returns the unified diff of the working tree against HEAD, covering
+// staged and unstaged changes to tracked files.
+func (r *Repo) Diff() (string, error) {
+	diff, err := runGit(r.repoRoot, "diff", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("diff working tree: %w", err)
+	}
+	return diff, nil
+}
+
+// DiffWithUntracked returns Diff plus a new-file diff for each untracked,
+// non-ignored file, so freshly created files are covered too.
+func (r *Repo) DiffWithUntracked() (string, error) {
+	return r.DiffFrom("HEAD")
+}
+
+// DiffFrom returns the unified diff of the working tree against rev plus a
+// new-file diff for each untracked, non-ignored file. Paths, relative to the
+// working directory, restrict the diff to those files.
+func (r *Repo) DiffFrom(rev string, paths ...string) (string, error) {
+	if len(paths) == 0 {
+		paths = []string{":/"} // whole repository, even from a subdirectory
+	}
+	args := append([]string{"diff", rev, "--"}, paths...)
+	diff, err := runGit(r.workDir, args...)
+	if err != nil {
+		return "", fmt.Errorf("diff working tree: %w", err)
+	}
+
+	args = append([]string{"ls-files", "--others", "--exclude-standard", "--full-name", "-z", "--"}, paths...)
+	out, err := runGit(r.workDir, args...)
+	if err != nil {
+		return "", fmt.Errorf("list untracked files: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString(diff)
+	for path := range strings.SplitSeq(out, "\x00") {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.repoRoot, path)) //nolint:gosec // path listed by git
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			continue // unreadable or binary
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		lines := slices.Collect(strings.Lines(string(data)))
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n@@ -0,0 +1,%d @@\n",
+			path, path, path, len(lines))
+		for _, line := range lines {
+			b.WriteString("+")
+			b.WriteString(strings.TrimSuffix(line, "\n"))
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// MergeBase returns the best common ancestor of HEAD and rev.
+func (r *Repo) MergeBase(rev string) (string, error) {
+	base, err := runGit(r.repoRoot, "merge-base", "HEAD", rev)
+	if err != nil {
+		return "", fmt.Errorf("merge-base %s: %w", rev, err)
+	}
+	return base, nil
+}
+
+// Root returns the absolute path of the repository's top-level directory.
+func (r *Repo) Root() string {
+	return r.repoRoot
+}
+
+// runGit runs a git command in dir and returns its trimmed stdout.
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w (stderr: %s)", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,191 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_WorktreeCommitAndCherryPick(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+
+	wtPath := filepath.Join(t.TempDir(), "wt")
+	require.NoError(t, repo.AddWorktree(wtPath, "parallel/test"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(wtPath, "feature.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(wtPath, "plan.md"), []byte("- [x] done\n"), 0644))
+
+	sha, err := CommitWorktree(wtPath, "Add feature", "plan.md")
+	require.NoError(t, err)
+	require.NotEmpty(t, sha)
+
+	base, err := HeadCommit(dir)
+	require.NoError(t, err)
+	require.NoError(t, repo.CherryPick(base+".."+sha))
+	assert.FileExists(t, filepath.Join(dir, "feature.go"))
+	assert.NoFileExists(t, filepath.Join(dir, "plan.md"), "excluded paths must not be committed")
+
+	require.NoError(t, repo.RemoveWorktree(wtPath, "parallel/test"))
+	assert.NoDirExists(t, wtPath)
+	exists, err := repo.BranchExists("parallel/test")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestRepo_AddDetachedWorktree(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	base, err := HeadCommit(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "later.txt"), []byte("later\n"), 0644))
+	_, err = CommitWorktree(dir, "Add later.txt")
+	require.NoError(t, err)
+
+	wtPath := filepath.Join(t.TempDir(), "wt")
+	require.NoError(t, repo.AddDetachedWorktree(wtPath, base))
+	head, err := HeadCommit(wtPath)
+	require.NoError(t, err)
+	assert.Equal(t, base, head)
+	assert.NoFileExists(t, filepath.Join(wtPath, "later.txt"))
+
+	require.NoError(t, repo.RemoveWorktree(wtPath, ""))
+	assert.NoDirExists(t, wtPath)
+}
+
+func TestCommitWorktree_NothingToCommit(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	before, err := HeadCommit(dir)
+	require.NoError(t, err)
+
+	sha, err := CommitWorktree(dir, "empty")
+	require.NoError(t, err)
+	assert.Equal(t, before, sha)
+}
+
+func TestRepo_UncommittedFiles(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+
+	files, err := repo.UncommittedFiles()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "debug.log"), []byte("x"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed"), 0644))
+
+	files, err = repo.UncommittedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gitignore", "README.md"}, files)
+}
+
+func TestRepo_CherryPickConflict(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+
+	wtA := filepath.Join(t.TempDir(), "a")
+	wtB := filepath.Join(t.TempDir(), "b")
+	require.NoError(t, repo.AddWorktree(wtA, "parallel/a"))
+	require.NoError(t, repo.AddWorktree(wtB, "parallel/b"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(wtA, "README.md"), []byte("# A\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(wtB, "README.md"), []byte("# B\n"), 0644))
+
+	shaA, err := CommitWorktree(wtA, "A")
+	require.NoError(t, err)
+	shaB, err := CommitWorktree(wtB, "B")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.CherryPick(shaA))
+	err = repo.CherryPick(shaB)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrCherryPickConflict))
+
+	data, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# A\n", string(data), "aborted cherry-pick must leave the tree untouched")
+
+	dirty, err := repo.HasUncommittedChanges()
+	require.NoError(t, err)
+	assert.False(t, dirty)
+}
+
+func TestRepo_Diff(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+
+	diff, err := repo.Diff()
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Changed\n"), 0644))
+	diff, err = repo.Diff()
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+# Changed")
+}
+
+func TestRepo_DiffWithUntracked(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Changed\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.go"), []byte("package x\n\nfunc f() { panic(1) }\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blob.bin"), []byte{0, 1, 2}, 0644))
+
+	diff, err := repo.DiffWithUntracked()
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+# Changed")
+	assert.Contains(t, diff, "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1,3 @@\n+package x\n+\n+func f() { panic(1) }")
+	assert.NotContains(t, diff, "blob.bin")
+}
+
+func TestRepo_DiffFrom(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := NewRepo(dir)
+	require.NoError(t, err)
+	base, err := HeadCommit(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "committed.txt"), []byte("committed\n"), 0644))
+	_, err = CommitWorktree(dir, "Add committed")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte("other\n"), 0644))
+
+	diff, err := repo.DiffFrom(base, "committed.txt", "new.txt")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+committed")
+	assert.Contains(t, diff, "+++ b/new.txt")
+	assert.NotContains(t, diff, "other.txt")
+
+	mergeBase, err := repo.MergeBase(base)
+	require.NoError(t, err)
+	assert.Equal(t, base, mergeBase)
+}
@@ -0,0 +1,178 @@
+// Package guard checks and sets up dcg (destructive_command_guard), the
+// Claude Code hook that blocks destructive shell commands during autonomous
+// runs.
+package guard
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Binary is the dcg executable looked up on PATH.
+const Binary = "dcg"
+
+// Guard modes (config key guard.mode).
+const (
+	// ModeAuto fails when dcg is installed but its config is missing or
+	// broken, and warns when dcg is not installed.
+	ModeAuto = "auto"
+	// ModeRequire fails unless dcg and its config are both in place.
+	ModeRequire = "require"
+	// ModeOff skips the check.
+	ModeOff = "off"
+)
+
+//go:embed default_config.toml
+var defaultConfig string
+
+// DefaultConfig returns the config `programmator guard init` writes.
+func DefaultConfig() string {
+	return defaultConfig
+}
+
+var (
+	// ErrNotInstalled is returned when the dcg binary is not on PATH.
+	ErrNotInstalled = errors.New("dcg is not installed")
+	// ErrNoConfig is returned when the dcg config file does not exist.
+	ErrNoConfig = errors.New("dcg config not found")
+	// ErrInvalidConfig is returned when the dcg config cannot be parsed.
+	ErrInvalidConfig = errors.New("dcg config is invalid")
+)
+
+// ConfigPath returns where dcg reads its config:
+// XDG_CONFIG_HOME/dcg/config.toml > ~/.config/dcg/config.toml.
+func ConfigPath() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "dcg", "config.toml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", ".config", "dcg", "config.toml")
+	}
+	return filepath.Join(home, ".config", "dcg", "config.toml")
+}
+
+// Status describes the dcg installation.
+type Status struct {
+	BinaryPath string // empty when dcg is not on PATH
+	ConfigPath string
+	ConfigErr  error // nil when the config exists and parses
+}
+
+// Inspect looks up the dcg binary and checks its config.
+func Inspect() Status {
+	st := Status{ConfigPath: ConfigPath()}
+	st.BinaryPath, _ = exec.LookPath(Binary)
+	st.ConfigErr = checkConfig(st.ConfigPath)
+	return st
+}
+
+// Check applies mode to the installation. It returns an error, with
+// guidance on fixing it, when the run must not start, and a warning when the
+// run may start unguarded.
+func (st Status) Check(mode string) (warning string, err error) {
+	switch {
+	case mode == ModeOff:
+		return "", nil
+	case st.BinaryPath == "" && mode == ModeRequire:
+		return "", fmt.Errorf("%w; install it from https://github.com/Dicklesworthstone/destructive_command_guard, "+
+			"or set guard.mode: off to run without it", ErrNotInstalled)
+	case st.BinaryPath == "":
+		return "dcg is not installed, destructive shell commands will not be blocked (set guard.mode: require to fail instead)", nil
+	case errors.Is(st.ConfigErr, ErrNoConfig):
+		return "", fmt.Errorf("%w at %s; run `programmator guard init` to create one", ErrNoConfig, st.ConfigPath)
+	case st.ConfigErr != nil:
+		return "", fmt.Errorf("%w; fix it or regenerate it with `programmator guard init --force`", st.ConfigErr)
+	}
+	return "", nil
+}
+
+// WriteDefault writes DefaultConfig to path. An existing file is only
+// replaced with force.
+func WriteDefault(path string, force bool) error {
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	return os.WriteFile(path, []byte(defaultConfig), 0o644) //nolint:gosec // dcg config is not secret
+}
+
+func checkConfig(path string) error {
+	data, err := os.ReadFile(path) //nolint:gosec // user config path
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNoConfig
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := validateTOML(string(data)); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+	return nil
+}
+
+// validateTOML catches the mistakes that stop dcg from loading its config:
+// lines that are neither a [table] nor a key = value pair, and unterminated
+// multi-line arrays. It is not a full TOML parser.
+func validateTOML(data string) error {
+	arrayStart := 0 // line of the open multi-line array, 0 when none
+	for i, line := range strings.Split(data, "\n") {
+		t := strings.TrimSpace(line)
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		if arrayStart > 0 {
+			if strings.HasPrefix(t, "]") {
+				arrayStart = 0
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(t, "["):
+			if !strings.HasSuffix(t, "]") {
+				return fmt.Errorf("line %d: unterminated table header %q", i+1, t)
+			}
+		case strings.Contains(t, "="):
+			key, value, _ := strings.Cut(t, "=")
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if key == "" || value == "" {
+				return fmt.Errorf("line %d: expected key = value, got %q", i+1, t)
+			}
+			if strings.HasPrefix(value, "[") && !strings.HasSuffix(stripComment(value), "]") {
+				arrayStart = i + 1
+			}
+		default:
+			return fmt.Errorf("line %d: expected a [table] or key = value, got %q", i+1, t)
+		}
+	}
+	if arrayStart > 0 {
+		return fmt.Errorf("line %d: array is never closed", arrayStart)
+	}
+	return nil
+}
+
+// stripComment removes a trailing comment outside quoted strings.
+func stripComment(s string) string {
+	inQuote := rune(0)
+	for i, r := range s {
+		switch {
+		case inQuote != 0 && r == inQuote:
+			inQuote = 0
+		case inQuote == 0 && (r == '"' || r == '\''):
+			inQuote = r
+		case inQuote == 0 && r == '#':
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+package guard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	assert.Equal(t, "/xdg/dcg/config.toml", ConfigPath())
+}
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	require.NoError(t, validateTOML(DefaultConfig()))
+}
+
+func TestValidateTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "tables and keys", data: "[general]\ncolor = \"auto\" # comment\n\n[packs]\nenabled = [\"a\", \"b\"]\n"},
+		{name: "multi-line array", data: "block = [\n  \"rm -rf /\",\n] # done\n"},
+		{name: "array with comment", data: "block = [ # patterns\n  \"x\",\n]\n"},
+		{name: "stray text", data: "[general]\ncolor auto\n", wantErr: "line 2: expected a [table] or key = value"},
+		{name: "missing value", data: "color =\n", wantErr: "line 1: expected key = value"},
+		{name: "unterminated table", data: "[general\n", wantErr: "unterminated table header"},
+		{name: "unclosed array", data: "x = 1\nblock = [\n  \"a\",\n", wantErr: "line 2: array is never closed"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTOML(tc.data)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestWriteDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dcg", "config.toml")
+
+	require.NoError(t, WriteDefault(path, false))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), string(data))
+
+	require.NoError(t, os.WriteFile(path, []byte("# mine\n"), 0o644))
+	require.ErrorContains(t, WriteDefault(path, false), "already exists")
+	require.NoError(t, WriteDefault(path, true))
+}
+
+func TestInspect(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+
+	st := Inspect()
+	assert.Empty(t, st.BinaryPath)
+	assert.ErrorIs(t, st.ConfigErr, ErrNoConfig)
+
+	require.NoError(t, os.WriteFile(filepath.Join(bin, Binary), []byte("#!/bin/sh\n"), 0o755))
+	require.NoError(t, WriteDefault(ConfigPath(), false))
+	st = Inspect()
+	assert.Equal(t, filepath.Join(bin, Binary), st.BinaryPath)
+	assert.NoError(t, st.ConfigErr)
+}
+
+func TestStatus_Check(t *testing.T) {
+	installed := Status{BinaryPath: "/bin/dcg", ConfigPath: "/cfg/config.toml"}
+	missing := Status{ConfigPath: "/cfg/config.toml", ConfigErr: ErrNoConfig}
+	noConfig := Status{BinaryPath: "/bin/dcg", ConfigPath: "/cfg/config.toml", ConfigErr: ErrNoConfig}
+	broken := Status{BinaryPath: "/bin/dcg", ConfigErr: ErrInvalidConfig}
+
+	tests := []struct {
+		name        string
+		st          Status
+		mode        string
+		wantWarning string
+		wantErr     error
+	}{
+		{name: "installed", st: installed, mode: ModeAuto},
+		{name: "not installed warns", st: missing, mode: ModeAuto, wantWarning: "not installed"},
+		{name: "empty mode is auto", st: missing, mode: "", wantWarning: "not installed"},
+		{name: "not installed required", st: missing, mode: ModeRequire, wantErr: ErrNotInstalled},
+		{name: "config missing", st: noConfig, mode: ModeAuto, wantErr: ErrNoConfig},
+		{name: "config broken", st: broken, mode: ModeRequire, wantErr: ErrInvalidConfig},
+		{name: "off", st: broken, mode: ModeOff},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			warning, err := tc.st.Check(tc.mode)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tc.wantWarning == "" {
+				assert.Empty(t, warning)
+			} else {
+				assert.Contains(t, warning, tc.wantWarning)
+			}
+		})
+	}
+
+	_, err := noConfig.Check(ModeAuto)
+	assert.ErrorContains(t, err, "programmator guard init")
+}
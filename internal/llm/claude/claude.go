@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
@@ -18,11 +17,22 @@ import (
 type Config struct {
 	ClaudeConfigDir string
 	AnthropicAPIKey string
+	Model           string            // --model value (e.g. "opus", "haiku")
+	Effort          string            // low, medium, or high; sets the thinking budget
+	Vars            map[string]string // set in the environment last, overriding inherited and derived variables
+}
+
+// thinkingTokens is the MAX_THINKING_TOKENS budget for each effort level.
+var thinkingTokens = map[string]string{
+	llm.EffortLow:    "4096",
+	llm.EffortMedium: "16384",
+	llm.EffortHigh:   "31999",
 }
 
 // Invoker invokes the Claude CLI binary.
 type Invoker struct {
-	Env Config
+	Env    Config
+	Runner llm.CommandRunner // where the binary runs (nil = this machine)
 }
 
 // New returns an Invoker that shells out to the "claude" binary.
@@ -33,6 +43,7 @@ func New(env Config) *Invoker {
 // BuildEnv constructs the environment variable slice for a Claude subprocess.
 // It filters ANTHROPIC_API_KEY and CLAUDE_CONFIG_DIR from the inherited
 // environment and only sets them if explicitly configured via the Config.
+// An Effort replaces any inherited MAX_THINKING_TOKENS.
 func BuildEnv(cfg Config) []string {
 	env := llm.FilterEnv(os.Environ(), "ANTHROPIC_API_KEY=", "CLAUDE_CONFIG_DIR=")
 	if cfg.ClaudeConfigDir != "" {
@@ -41,17 +52,28 @@ func BuildEnv(cfg Config) []string {
 	if cfg.AnthropicAPIKey != "" {
 		env = append(env, "ANTHROPIC_API_KEY="+cfg.AnthropicAPIKey)
 	}
-	return env
+	if tokens, ok := thinkingTokens[cfg.Effort]; ok {
+		env = append(llm.FilterEnv(env, "MAX_THINKING_TOKENS="), "MAX_THINKING_TOKENS="+tokens)
+	}
+	return llm.SetEnv(env, cfg.Vars)
 }
 
 // Invoke runs claude --print with the given prompt and options.
 func (c *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
 	args := []string{"--print"}
 
+	if c.Env.Model != "" {
+		args = append(args, "--model", c.Env.Model)
+	}
+
 	if len(opts.ExtraFlags) > 0 {
 		args = append(args, opts.ExtraFlags...)
 	}
 
+	if opts.ResumeSessionID != "" {
+		args = append(args, "--resume", opts.ResumeSessionID)
+	}
+
 	if opts.Streaming {
 		args = append(args, "--output-format", "stream-json", "--verbose")
 	}
@@ -62,14 +84,14 @@ func (c *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 		invokeCtx, cancel = context.WithTimeout(ctx, time.Duration(opts.Timeout)*time.Second)
 		defer cancel()
 	}
+	invokeCtx, watch, stopWatch := llm.WithLiveness(invokeCtx, opts.LivenessIdle())
+	defer stopWatch()
 
-	cmd := exec.CommandContext(invokeCtx, "claude", args...)
-	if opts.WorkingDir != "" {
-		cmd.Dir = opts.WorkingDir
+	cmd, err := llm.RunnerOr(c.Runner).Command(invokeCtx, opts.WorkingDir, BuildEnv(c.Env), "claude", args...)
+	if err != nil {
+		return nil, err
 	}
 
-	cmd.Env = BuildEnv(c.Env)
-
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, err
@@ -100,7 +122,7 @@ func (c *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 
 	var output string
 	if opts.Streaming {
-		output = processStreamingOutput(stdout, opts)
+		output = processStreamingOutput(watch(stdout), opts)
 	} else {
 		output = llm.ProcessTextOutput(stdout, opts)
 	}
@@ -110,6 +132,9 @@ func (c *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 		opts.OnProcessEnd()
 	}
 	if err != nil {
+		if stall := llm.Stalled(invokeCtx); stall != nil {
+			return nil, stall
+		}
 		if invokeCtx.Err() == context.DeadlineExceeded {
 			return &llm.InvokeResult{Text: llm.TimeoutBlockedStatus()}, nil
 		}
@@ -6,9 +6,12 @@ import (
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/alexander-akhmetov/programmator/internal/domain"
 	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/protocol"
 )
@@ -34,6 +37,19 @@ func TestInvokerTextMode(t *testing.T) {
 	require.Len(t, collected, 1)
 }
 
+func TestInvokerModelFlag(t *testing.T) {
+	tmpDir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\"\n"
+	err := os.WriteFile(tmpDir+"/claude", []byte(script), 0o755)
+	require.NoError(t, err)
+	t.Setenv("PATH", tmpDir+":"+os.Getenv("PATH"))
+
+	inv := New(Config{Model: "haiku"})
+	res, err := inv.Invoke(context.Background(), "test", llm.InvokeOptions{})
+	require.NoError(t, err)
+	require.Contains(t, res.Text, "--print --model haiku")
+}
+
 func TestInvokerWorkingDir(t *testing.T) {
 	tmpDir := t.TempDir()
 	script := "#!/bin/sh\ncat >/dev/null\npwd\n"
@@ -48,6 +64,23 @@ func TestInvokerWorkingDir(t *testing.T) {
 	require.Contains(t, res.Text, workDir)
 }
 
+func TestInvokerResumeSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	script := "#!/bin/sh\ncat >/dev/null\necho \"$@\"\n"
+	err := os.WriteFile(tmpDir+"/claude", []byte(script), 0o755)
+	require.NoError(t, err)
+	t.Setenv("PATH", tmpDir+":"+os.Getenv("PATH"))
+
+	inv := New(Config{})
+	res, err := inv.Invoke(context.Background(), "test", llm.InvokeOptions{ResumeSessionID: "sess-1"})
+	require.NoError(t, err)
+	require.Contains(t, res.Text, "--resume sess-1")
+
+	res, err = inv.Invoke(context.Background(), "test", llm.InvokeOptions{})
+	require.NoError(t, err)
+	require.NotContains(t, res.Text, "--resume")
+}
+
 func TestInvokerStreamingMode(t *testing.T) {
 	tmpDir := t.TempDir()
 	script := `#!/bin/sh
@@ -134,6 +167,25 @@ func TestInvokerTimeout(t *testing.T) {
 	require.Contains(t, res.Text, string(protocol.StatusBlocked))
 }
 
+func TestInvokerLivenessTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	script := `#!/bin/sh
+cat >/dev/null
+echo '{"type":"assistant","message":{"content":[{"type":"text","text":"working"}],"usage":{}}}'
+exec sleep 30
+`
+	err := os.WriteFile(tmpDir+"/claude", []byte(script), 0o755)
+	require.NoError(t, err)
+	t.Setenv("PATH", tmpDir+":"+os.Getenv("PATH"))
+
+	inv := New(Config{})
+	start := time.Now()
+	_, err = inv.Invoke(context.Background(), "test", llm.InvokeOptions{Streaming: true, Timeout: 60, LivenessTimeout: 1})
+	var stall *llm.StallError
+	require.ErrorAs(t, err, &stall)
+	assert.Less(t, time.Since(start), 20*time.Second, "aborted well before the timeout")
+}
+
 func TestInvokerToolUseCallback(t *testing.T) {
 	tmpDir := t.TempDir()
 	script := `#!/bin/sh
@@ -204,6 +256,24 @@ func TestBuildEnv(t *testing.T) {
 			config:     Config{},
 			wantAbsent: []string{"CLAUDE_CONFIG_DIR="},
 		},
+		{
+			name:    "effort sets the thinking budget",
+			setEnv:  map[string]string{"MAX_THINKING_TOKENS": "1024"},
+			config:  Config{Effort: "high"},
+			wantSet: map[string]string{"MAX_THINKING_TOKENS": "31999"},
+		},
+		{
+			name:    "no effort keeps the inherited thinking budget",
+			setEnv:  map[string]string{"MAX_THINKING_TOKENS": "1024"},
+			config:  Config{},
+			wantSet: map[string]string{"MAX_THINKING_TOKENS": "1024"},
+		},
+		{
+			name:    "vars override inherited and derived variables",
+			setEnv:  map[string]string{"FEATURE_CACHE": "off"},
+			config:  Config{Effort: "high", Vars: map[string]string{"FEATURE_CACHE": "on", "MAX_THINKING_TOKENS": "8000"}},
+			wantSet: map[string]string{"FEATURE_CACHE": "on", "MAX_THINKING_TOKENS": "8000"},
+		},
 		{
 			name:   "empty config returns non-nil env",
 			config: Config{},
@@ -234,3 +304,24 @@ func TestBuildEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessFlags(t *testing.T) {
+	flags, err := AccessFlags("")
+	require.NoError(t, err)
+	assert.Nil(t, flags)
+
+	flags, err = AccessFlags(domain.AccessFull)
+	require.NoError(t, err)
+	assert.Nil(t, flags)
+
+	flags, err = AccessFlags(domain.AccessReadOnly)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--settings", `{"permissions":{"deny":["Bash","Edit","MultiEdit","Write","NotebookEdit"]}}`}, flags)
+
+	flags, err = AccessFlags(domain.AccessNoBash)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--settings", `{"permissions":{"deny":["Bash"]}}`}, flags)
+
+	_, err = AccessFlags("write-only")
+	require.ErrorContains(t, err, `unknown access level "write-only"`)
+}
@@ -0,0 +1,45 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+)
+
+// Tools denied at each restricted access level.
+var (
+	editTools      = []string{"Edit", "MultiEdit", "Write", "NotebookEdit"}
+	deniedByAccess = map[string][]string{
+		domain.AccessReadOnly: append([]string{"Bash"}, editTools...),
+		domain.AccessNoBash:   {"Bash"},
+	}
+)
+
+type permissionSettings struct {
+	Permissions struct {
+		Deny []string `json:"deny"`
+	} `json:"permissions"`
+}
+
+// AccessFlags returns the CLI flags that restrict a claude invocation to the
+// given access level: a --settings document denying the tools the level
+// rules out. Full access needs no flags.
+func AccessFlags(access string) ([]string, error) {
+	if access == "" || access == domain.AccessFull {
+		return nil, nil
+	}
+	deny, ok := deniedByAccess[access]
+	if !ok {
+		return nil, fmt.Errorf("unknown access level %q (supported: %s, %s, %s)",
+			access, domain.AccessFull, domain.AccessReadOnly, domain.AccessNoBash)
+	}
+
+	var s permissionSettings
+	s.Permissions.Deny = deny
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return []string{"--settings", string(data)}, nil
+}
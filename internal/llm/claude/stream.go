@@ -12,10 +12,11 @@ import (
 
 // streamEvent is the JSON structure emitted by `claude --output-format stream-json`.
 type streamEvent struct {
-	Type    string `json:"type"`
-	Subtype string `json:"subtype"`
-	Model   string `json:"model"`
-	Message struct {
+	Type      string `json:"type"`
+	Subtype   string `json:"subtype"`
+	Model     string `json:"model"`
+	SessionID string `json:"session_id"`
+	Message   struct {
 		Model   string `json:"model"`
 		Content []struct {
 			Type  string `json:"type"`
@@ -98,9 +99,15 @@ func processStreamingOutput(r io.Reader, opts llm.InvokeOptions) string {
 }
 
 func handleSystemEvent(event *streamEvent, opts llm.InvokeOptions) {
-	if event.Subtype == "init" && event.Model != "" && opts.OnSystemInit != nil {
+	if event.Subtype != "init" {
+		return
+	}
+	if event.Model != "" && opts.OnSystemInit != nil {
 		opts.OnSystemInit(event.Model)
 	}
+	if event.SessionID != "" && opts.OnSessionID != nil {
+		opts.OnSessionID(event.SessionID)
+	}
 }
 
 func handleAssistantEvent(event *streamEvent, fullOutput *strings.Builder, processedBlockIDs map[string]bool, opts llm.InvokeOptions) {
@@ -64,6 +64,21 @@ func TestProcessStreamingOutputSystemInit(t *testing.T) {
 	require.Equal(t, "claude-3-opus", model)
 }
 
+func TestProcessStreamingOutputSessionID(t *testing.T) {
+	var sessionID string
+	opts := llm.InvokeOptions{
+		OnSessionID: func(id string) {
+			sessionID = id
+		},
+	}
+
+	input := `{"type":"system","subtype":"init","model":"claude-3-opus","session_id":"abc-123"}
+{"type":"result","result":""}`
+
+	processStreamingOutput(strings.NewReader(input), opts)
+	require.Equal(t, "abc-123", sessionID)
+}
+
 func TestProcessStreamingOutputTokenTracking(t *testing.T) {
 	var lastInput, lastOutput int
 	var finalModel string
@@ -5,22 +5,45 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/alexander-akhmetov/programmator/internal/llm"
 )
 
+// Sandbox modes of codex exec (--sandbox).
+const (
+	SandboxReadOnly       = "read-only"
+	SandboxWorkspaceWrite = "workspace-write"
+	SandboxFullAccess     = "danger-full-access"
+)
+
+// ValidSandbox reports whether mode is a codex sandbox mode.
+func ValidSandbox(mode string) bool {
+	switch mode {
+	case SandboxReadOnly, SandboxWorkspaceWrite, SandboxFullAccess:
+		return true
+	}
+	return false
+}
+
 // Config holds environment configuration for codex subprocesses.
 type Config struct {
-	Model  string // -m value (e.g. "o3", "gpt-5-codex")
-	APIKey string // OPENAI_API_KEY
+	Model  string            // -m value (e.g. "o3", "gpt-5-codex")
+	Effort string            // model_reasoning_effort (low, medium, high)
+	APIKey string            // OPENAI_API_KEY
+	Vars   map[string]string // set in the environment last, overriding inherited and derived variables
+
+	// Sandbox is the --sandbox mode of main loop and planning invocations;
+	// review agents and validators then run read-only. Empty passes no
+	// --sandbox.
+	Sandbox string
 }
 
 // Invoker invokes the OpenAI Codex CLI binary.
 type Invoker struct {
-	Env Config
+	Env    Config
+	Runner llm.CommandRunner // where the binary runs (nil = this machine)
 }
 
 // New returns an Invoker that shells out to the "codex" binary.
@@ -36,7 +59,7 @@ func BuildEnv(cfg Config) []string {
 	if cfg.APIKey != "" {
 		env = append(env, "OPENAI_API_KEY="+cfg.APIKey)
 	}
-	return env
+	return llm.SetEnv(env, cfg.Vars)
 }
 
 // Invoke runs codex with the given prompt and options.
@@ -46,17 +69,24 @@ func (c *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 	if c.Env.Model != "" {
 		args = append(args, "-m", c.Env.Model)
 	}
+	if c.Env.Effort != "" {
+		args = append(args, "-c", "model_reasoning_effort="+c.Env.Effort)
+	}
 
 	if len(opts.ExtraFlags) > 0 {
 		args = append(args, opts.ExtraFlags...)
 	}
+	if sandbox := c.sandbox(opts.Purpose); sandbox != "" && !hasSandboxFlag(opts.ExtraFlags) {
+		args = append(args, "--sandbox", sandbox)
+	}
 
 	if opts.Streaming {
 		args = append(args, "--json")
 	}
 
+	runner := llm.RunnerOr(c.Runner)
 	if opts.WorkingDir != "" {
-		args = append(args, "--cd", opts.WorkingDir)
+		args = append(args, "--cd", runner.Dir(opts.WorkingDir))
 	}
 
 	// Prompt is the final positional argument.
@@ -68,9 +98,13 @@ func (c *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 		invokeCtx, cancel = context.WithTimeout(ctx, time.Duration(opts.Timeout)*time.Second)
 		defer cancel()
 	}
+	invokeCtx, watch, stopWatch := llm.WithLiveness(invokeCtx, opts.LivenessIdle())
+	defer stopWatch()
 
-	cmd := exec.CommandContext(invokeCtx, "codex", args...)
-	cmd.Env = BuildEnv(c.Env)
+	cmd, err := runner.Command(invokeCtx, "", BuildEnv(c.Env), "codex", args...)
+	if err != nil {
+		return nil, err
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -93,7 +127,7 @@ func (c *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 
 	var output string
 	if opts.Streaming {
-		output = processCodexStreamingOutput(stdout, c.Env.Model, opts)
+		output = processCodexStreamingOutput(watch(stdout), c.Env.Model, opts)
 	} else {
 		output = llm.ProcessTextOutput(stdout, opts)
 	}
@@ -103,6 +137,9 @@ func (c *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 		opts.OnProcessEnd()
 	}
 	if err != nil {
+		if stall := llm.Stalled(invokeCtx); stall != nil {
+			return nil, stall
+		}
 		if invokeCtx.Err() == context.DeadlineExceeded {
 			return &llm.InvokeResult{Text: llm.TimeoutBlockedStatus()}, nil
 		}
@@ -114,3 +151,44 @@ func (c *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 
 	return &llm.InvokeResult{Text: output}, nil
 }
+
+// sandbox returns the --sandbox mode for an invocation of purpose: read-only
+// for reviews, which only report, and Env.Sandbox otherwise.
+func (c *Invoker) sandbox(purpose llm.Purpose) string {
+	if c.Env.Sandbox == "" {
+		return ""
+	}
+	if purpose == llm.PurposeReview || purpose == llm.PurposeValidator {
+		return SandboxReadOnly
+	}
+	return c.Env.Sandbox
+}
+
+// ReadOnlyFlags returns flags with every sandbox choice replaced by
+// --sandbox read-only, so the invocation cannot change files.
+func ReadOnlyFlags(flags []string) []string {
+	out := make([]string, 0, len(flags)+2)
+	for i := 0; i < len(flags); i++ {
+		switch f := flags[i]; {
+		case f == "-s", f == "--sandbox":
+			i++ // and its value
+		case strings.HasPrefix(f, "--sandbox="), f == "--full-auto", f == "--dangerously-bypass-approvals-and-sandbox":
+		default:
+			out = append(out, f)
+		}
+	}
+	return append(out, "--sandbox", SandboxReadOnly)
+}
+
+// hasSandboxFlag reports whether flags already choose the sandbox, which
+// then wins over Config.Sandbox.
+func hasSandboxFlag(flags []string) bool {
+	for _, f := range flags {
+		switch {
+		case f == "-s", f == "--sandbox", strings.HasPrefix(f, "--sandbox="),
+			f == "--full-auto", f == "--dangerously-bypass-approvals-and-sandbox":
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package codex
 import (
 	"context"
 	"os"
+	"os/exec"
 	"slices"
 	"strings"
 	"testing"
@@ -178,6 +179,11 @@ func TestInvokerModelFlag(t *testing.T) {
 	require.NoError(t, err)
 	require.Contains(t, res.Text, "-m o3")
 	require.Contains(t, res.Text, "exec")
+
+	inv = New(Config{Model: "o3", Effort: "high"})
+	res, err = inv.Invoke(context.Background(), "test", llm.InvokeOptions{})
+	require.NoError(t, err)
+	require.Contains(t, res.Text, "-m o3 -c model_reasoning_effort=high")
 }
 
 func TestInvokerWorkingDir(t *testing.T) {
@@ -196,3 +202,53 @@ func TestInvokerWorkingDir(t *testing.T) {
 	require.NoError(t, err)
 	require.Contains(t, res.Text, "--cd "+workDir)
 }
+
+func TestInvokerSandbox(t *testing.T) {
+	tmpDir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\"\n"
+	require.NoError(t, os.WriteFile(tmpDir+"/codex", []byte(script), 0o755))
+	t.Setenv("PATH", tmpDir+":"+os.Getenv("PATH"))
+
+	inv := New(Config{})
+	res, err := inv.Invoke(context.Background(), "test", llm.InvokeOptions{Purpose: llm.PurposeMain})
+	require.NoError(t, err)
+	require.NotContains(t, res.Text, "--sandbox")
+
+	inv = New(Config{Sandbox: SandboxWorkspaceWrite})
+	res, err = inv.Invoke(context.Background(), "test", llm.InvokeOptions{Purpose: llm.PurposeMain})
+	require.NoError(t, err)
+	require.Contains(t, res.Text, "--sandbox workspace-write")
+
+	res, err = inv.Invoke(context.Background(), "test", llm.InvokeOptions{Purpose: llm.PurposeReview})
+	require.NoError(t, err)
+	require.Contains(t, res.Text, "--sandbox read-only")
+
+	res, err = inv.Invoke(context.Background(), "test", llm.InvokeOptions{Purpose: llm.PurposeMain, ExtraFlags: []string{"--full-auto"}})
+	require.NoError(t, err)
+	require.NotContains(t, res.Text, "--sandbox", "flags choosing the sandbox win")
+}
+
+func TestReadOnlyFlags(t *testing.T) {
+	require.Equal(t, []string{"--sandbox", "read-only"}, ReadOnlyFlags(nil))
+	require.Equal(t, []string{"-m", "o3", "--sandbox", "read-only"},
+		ReadOnlyFlags([]string{"--dangerously-bypass-approvals-and-sandbox", "-m", "o3", "--full-auto"}))
+	require.Equal(t, []string{"--sandbox", "read-only"},
+		ReadOnlyFlags([]string{"-s", "workspace-write", "--sandbox=danger-full-access"}))
+}
+
+// echoRunner prints the command it would run, in place of a remote host.
+type echoRunner struct{ dir string }
+
+func (r echoRunner) Command(ctx context.Context, _ string, _ []string, name string, args ...string) (*exec.Cmd, error) {
+	return exec.CommandContext(ctx, "echo", append([]string{name}, args...)...), nil
+}
+
+func (r echoRunner) Dir(string) string { return r.dir }
+
+func TestInvokerRunner(t *testing.T) {
+	inv := New(Config{})
+	inv.Runner = echoRunner{dir: "/srv/app"}
+	res, err := inv.Invoke(context.Background(), "test", llm.InvokeOptions{WorkingDir: t.TempDir()})
+	require.NoError(t, err)
+	require.Contains(t, res.Text, "codex exec --cd /srv/app test")
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/alexander-akhmetov/programmator/internal/debug"
 	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
 )
 
 // codexEvent is the top-level JSONL structure emitted by `codex exec --json`.
@@ -53,6 +54,7 @@ func processCodexStreamingOutput(r io.Reader, model string, opts llm.InvokeOptio
 
 	totalInput, totalOutput := 0, 0
 	hasTokens := false
+	var failure string
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -90,12 +92,17 @@ func processCodexStreamingOutput(r io.Reader, model string, opts llm.InvokeOptio
 			// No-op for these event types.
 
 		case "turn.failed":
+			failure = "codex turn failed"
 			if event.Error != nil {
 				debug.Logf("codex stream: turn failed: %s", event.Error.Message)
+				failure += ": " + event.Error.Message
 			}
 
 		case "error":
 			debug.Logf("codex stream: error event: %s", event.Message)
+			if failure == "" {
+				failure = "codex error: " + event.Message
+			}
 
 		default:
 			debug.Logf("codex stream: unhandled event type=%s", event.Type)
@@ -114,7 +121,26 @@ func processCodexStreamingOutput(r io.Reader, model string, opts llm.InvokeOptio
 		opts.OnFinalTokens(m, totalInput, totalOutput)
 	}
 
-	return fullOutput.String()
+	output := fullOutput.String()
+	if failure != "" && !strings.Contains(output, protocol.StatusBlockKey) {
+		// The turn ended before codex wrote its status; report it blocked
+		// rather than leave the loop to find no status at all.
+		blocked := llm.BlockedStatus("Codex failed", failure)
+		if opts.OnOutput != nil {
+			opts.OnOutput(blocked)
+		}
+		output = joinMessages(output, blocked)
+	}
+	return output
+}
+
+// joinMessages appends msg to the output so far on a new line, so a status
+// block in a later agent message starts a line of its own.
+func joinMessages(output, msg string) string {
+	if output == "" || strings.HasSuffix(output, "\n") {
+		return output + msg
+	}
+	return output + "\n" + msg
 }
 
 // processItemCompleted handles item.completed events by dispatching to the
@@ -133,6 +159,9 @@ func processItemCompleted(raw json.RawMessage, fullOutput *strings.Builder, opts
 	switch item.Type {
 	case "agent_message":
 		if item.Text != "" {
+			if fullOutput.Len() > 0 && !strings.HasSuffix(fullOutput.String(), "\n") {
+				fullOutput.WriteString("\n")
+			}
 			fullOutput.WriteString(item.Text)
 			if opts.OnOutput != nil {
 				opts.OnOutput(item.Text)
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
 )
 
 func TestProcessCodexStreamingOutput(t *testing.T) {
@@ -235,6 +236,35 @@ func TestProcessCodexStreamingOutput(t *testing.T) {
 	}
 }
 
+func TestProcessCodexStreamingOutput_StatusProtocol(t *testing.T) {
+	t.Run("messages on separate lines", func(t *testing.T) {
+		input := `{"type":"item.completed","item":{"id":"i1","type":"agent_message","text":"Done with the phase."}}
+{"type":"item.completed","item":{"id":"i2","type":"agent_message","text":"PROGRAMMATOR_STATUS:\n  status: DONE"}}`
+		output := processCodexStreamingOutput(strings.NewReader(input), "o3", llm.InvokeOptions{})
+		require.Equal(t, "Done with the phase.\nPROGRAMMATOR_STATUS:\n  status: DONE", output)
+	})
+
+	t.Run("failed turn reported blocked", func(t *testing.T) {
+		input := `{"type":"item.completed","item":{"id":"i1","type":"agent_message","text":"Working on it"}}
+{"type":"turn.failed","error":{"message":"rate limited"}}`
+		var outputs []string
+		output := processCodexStreamingOutput(strings.NewReader(input), "o3", llm.InvokeOptions{
+			OnOutput: func(text string) { outputs = append(outputs, text) },
+		})
+		require.True(t, strings.HasPrefix(output, "Working on it\n"+protocol.StatusBlockKey+":"))
+		require.Contains(t, output, "status: "+string(protocol.StatusBlocked))
+		require.Contains(t, output, `error: "codex turn failed: rate limited"`)
+		require.Len(t, outputs, 2)
+	})
+
+	t.Run("failure after a status keeps it", func(t *testing.T) {
+		input := `{"type":"item.completed","item":{"id":"i1","type":"agent_message","text":"PROGRAMMATOR_STATUS:\n  status: CONTINUE"}}
+{"type":"error","message":"stream closed"}`
+		output := processCodexStreamingOutput(strings.NewReader(input), "o3", llm.InvokeOptions{})
+		require.Equal(t, "PROGRAMMATOR_STATUS:\n  status: CONTINUE", output)
+	})
+}
+
 func TestProcessCodexStreamingOutputNilCallbacks(t *testing.T) {
 	input := `{"type":"thread.started","thread_id":"t1"}
 {"type":"item.completed","item":{"id":"i1","type":"agent_message","text":"hello"}}
@@ -1,6 +1,8 @@
 package llm
 
 import (
+	"maps"
+	"slices"
 	"strings"
 )
 
@@ -43,3 +45,48 @@ func FilterEnv(environ []string, excludePrefixes ...string) []string {
 	}
 	return result
 }
+
+// SetEnv returns a copy of environ with vars assigned, replacing earlier
+// assignments of the same names. Vars are appended in name order.
+func SetEnv(environ []string, vars map[string]string) []string {
+	if len(vars) == 0 {
+		return environ
+	}
+	names := slices.Sorted(maps.Keys(vars))
+	prefixes := make([]string, len(names))
+	for i, name := range names {
+		prefixes[i] = name + "="
+	}
+	env := FilterEnv(environ, prefixes...)
+	for _, name := range names {
+		env = append(env, name+"="+vars[name])
+	}
+	return env
+}
+
+// secretEnvWords mark variable names that likely hold credentials.
+var secretEnvWords = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "AUTH"}
+
+// IsSecretEnv reports whether the variable name looks like it holds a
+// credential, such as STAGING_API_KEY or GITHUB_TOKEN.
+func IsSecretEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, word := range secretEnvWords {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretEnvValues returns the non-empty values of the vars whose names look
+// like credentials, to be masked in output and logs.
+func SecretEnvValues(vars map[string]string) []string {
+	var secrets []string
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		if v := vars[name]; v != "" && IsSecretEnv(name) {
+			secrets = append(secrets, v)
+		}
+	}
+	return secrets
+}
@@ -79,3 +79,29 @@ func TestAllProviderAPIKeyPrefixes(t *testing.T) {
 	}
 	require.Equal(t, expected, prefixes)
 }
+
+func TestSetEnv(t *testing.T) {
+	environ := []string{"A=1", "HTTPS_PROXY=old", "B=2"}
+	assert.Equal(t, environ, SetEnv(environ, nil))
+
+	got := SetEnv(environ, map[string]string{"HTTPS_PROXY": "http://proxy:3128", "API_URL": "https://staging"})
+	assert.Equal(t, []string{"A=1", "B=2", "API_URL=https://staging", "HTTPS_PROXY=http://proxy:3128"}, got)
+	assert.Equal(t, []string{"A=1", "HTTPS_PROXY=old", "B=2"}, environ, "environ is not modified")
+}
+
+func TestSecretEnvValues(t *testing.T) {
+	assert.True(t, IsSecretEnv("STAGING_API_KEY"))
+	assert.True(t, IsSecretEnv("github_token"))
+	assert.True(t, IsSecretEnv("DB_PASSWORD"))
+	assert.False(t, IsSecretEnv("FEATURE_NEW_CHECKOUT"))
+	assert.False(t, IsSecretEnv("HTTPS_PROXY"))
+
+	vars := map[string]string{
+		"STAGING_TOKEN": "t0k",
+		"API_KEY":       "k3y",
+		"EMPTY_SECRET":  "",
+		"API_URL":       "https://staging",
+	}
+	assert.Equal(t, []string{"k3y", "t0k"}, SecretEnvValues(vars))
+	assert.Nil(t, SecretEnvValues(nil))
+}
@@ -4,8 +4,11 @@
 package executor
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 
+	"github.com/alexander-akhmetov/programmator/internal/domain"
 	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/llm/claude"
 	"github.com/alexander-akhmetov/programmator/internal/llm/codex"
@@ -21,21 +24,126 @@ type Config struct {
 	OpenCode   opencode.Config // passed to opencode.New when Name is "opencode"
 	Codex      codex.Config    // passed to codex.New when Name is "codex"
 	ExtraFlags []string        // additional CLI flags for the executor
+
+	// PurposeFlags are appended to ExtraFlags for invocations of one purpose,
+	// so that e.g. review agents can run with a different --model.
+	PurposeFlags map[llm.Purpose][]string
+
+	// Runner runs the claude or codex binary somewhere other than this
+	// machine, such as an llm.RemoteRunner. Nil runs it locally.
+	Runner llm.CommandRunner
+
+	// ContextWindow is the size of the model's context window in tokens,
+	// against which prompts are checked before they are sent (0 = no check).
+	ContextWindow int
+
+	// ContextOverflow is what happens to a prompt over its share of
+	// ContextWindow: OverflowWarn or OverflowTruncate ("" = OverflowWarn).
+	ContextOverflow string
+}
+
+// Ways of handling a prompt too large for the context window.
+const (
+	OverflowWarn     = "warn"     // send it as is and warn
+	OverflowTruncate = "truncate" // leave out context the executor can read itself, then warn if still too large
+)
+
+// FlagsFor returns the CLI flags for an invocation of the given purpose:
+// ExtraFlags followed by the purpose's own flags, which win for executors
+// that take the last occurrence of a repeated flag.
+func (c Config) FlagsFor(purpose llm.Purpose) []string {
+	extra := c.PurposeFlags[purpose]
+	if len(extra) == 0 {
+		return c.ExtraFlags
+	}
+	flags := make([]string, 0, len(c.ExtraFlags)+len(extra))
+	flags = append(flags, c.ExtraFlags...)
+	return append(flags, extra...)
+}
+
+// ReadOnlyFlags returns FlagsFor(purpose) restricted so that the invocation
+// cannot change files or run commands, for invocations that only read and
+// answer. Executors without a read-only mode are refused rather than run
+// with write access.
+func (c Config) ReadOnlyFlags(purpose llm.Purpose) ([]string, error) {
+	flags := c.FlagsFor(purpose)
+	switch c.Name {
+	case "claude", "":
+		readOnly, err := claude.AccessFlags(domain.AccessReadOnly)
+		if err != nil {
+			return nil, err
+		}
+		return append(slices.Clone(flags), readOnly...), nil
+	case "codex":
+		return codex.ReadOnlyFlags(flags), nil
+	default:
+		return nil, fmt.Errorf("executor %q has no read-only mode (supported: claude, codex)", c.Name)
+	}
+}
+
+// WithModel returns a copy of c with model and effort set on the selected
+// executor, leaving the current values where they are empty. opencode has no
+// effort setting and ignores it.
+func (c Config) WithModel(model, effort string) Config {
+	switch c.Name {
+	case "claude", "":
+		c.Claude.Model = cmp.Or(model, c.Claude.Model)
+		c.Claude.Effort = cmp.Or(effort, c.Claude.Effort)
+	case "pi":
+		c.Pi.Model = cmp.Or(model, c.Pi.Model)
+		c.Pi.Effort = cmp.Or(effort, c.Pi.Effort)
+	case "opencode":
+		c.OpenCode.Model = cmp.Or(model, c.OpenCode.Model)
+	case "codex":
+		c.Codex.Model = cmp.Or(model, c.Codex.Model)
+		c.Codex.Effort = cmp.Or(effort, c.Codex.Effort)
+	}
+	return c
+}
+
+// Model returns the model set on the selected executor, "" when it uses
+// its default.
+func (c Config) Model() string {
+	switch c.Name {
+	case "claude", "":
+		return c.Claude.Model
+	case "pi":
+		return c.Pi.Model
+	case "opencode":
+		return c.OpenCode.Model
+	case "codex":
+		return c.Codex.Model
+	}
+	return ""
 }
 
 // New creates an Invoker based on the executor name in cfg.
-// An empty Name defaults to "claude". Unknown names return an error.
+// An empty Name defaults to "claude". Unknown names return an error, as
+// does a Runner for an executor other than claude and codex.
 func New(cfg Config) (llm.Invoker, error) {
+	if cfg.Runner != nil && !SupportsRunner(cfg.Name) {
+		return nil, fmt.Errorf("executor %q cannot run remotely (supported: claude, codex)", cfg.Name)
+	}
 	switch cfg.Name {
 	case "claude", "":
-		return claude.New(cfg.Claude), nil
+		inv := claude.New(cfg.Claude)
+		inv.Runner = cfg.Runner
+		return inv, nil
 	case "pi":
 		return pi.New(cfg.Pi), nil
 	case "opencode":
 		return opencode.New(cfg.OpenCode), nil
 	case "codex":
-		return codex.New(cfg.Codex), nil
+		inv := codex.New(cfg.Codex)
+		inv.Runner = cfg.Runner
+		return inv, nil
 	default:
 		return nil, fmt.Errorf("unknown executor: %q (supported: claude, pi, opencode, codex)", cfg.Name)
 	}
 }
+
+// SupportsRunner reports whether the named executor can run through a
+// Config.Runner.
+func SupportsRunner(name string) bool {
+	return name == "claude" || name == "" || name == "codex"
+}
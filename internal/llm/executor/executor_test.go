@@ -3,6 +3,7 @@ package executor
 import (
 	"testing"
 
+	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/llm/claude"
 	"github.com/alexander-akhmetov/programmator/internal/llm/codex"
 	"github.com/alexander-akhmetov/programmator/internal/llm/opencode"
@@ -132,3 +133,58 @@ func TestNew_OpenCodeConfigPassthrough(t *testing.T) {
 	assert.Equal(t, "oc-test-key", oc.Env.APIKey)
 	assert.Equal(t, "/custom/opencode/config", oc.Env.ConfigDir)
 }
+
+func TestConfig_WithModel(t *testing.T) {
+	cfg := Config{Name: "claude"}.WithModel("opus", "high")
+	assert.Equal(t, claude.Config{Model: "opus", Effort: "high"}, cfg.Claude)
+
+	cfg = Config{Name: "codex", Codex: codex.Config{Model: "o3", APIKey: "key"}}.WithModel("", "low")
+	assert.Equal(t, codex.Config{Model: "o3", Effort: "low", APIKey: "key"}, cfg.Codex, "an empty model keeps the configured one")
+
+	cfg = Config{Name: "pi"}.WithModel("sonnet", "medium")
+	assert.Equal(t, pi.Config{Model: "sonnet", Effort: "medium"}, cfg.Pi)
+
+	cfg = Config{Name: "opencode"}.WithModel("anthropic/claude-haiku-4-5", "high")
+	assert.Equal(t, opencode.Config{Model: "anthropic/claude-haiku-4-5"}, cfg.OpenCode, "opencode ignores effort")
+
+	base := Config{Name: "claude"}
+	_ = base.WithModel("opus", "")
+	assert.Empty(t, base.Claude.Model, "the receiver is not modified")
+}
+
+func TestConfig_Model(t *testing.T) {
+	assert.Equal(t, "opus", Config{Claude: claude.Config{Model: "opus"}}.Model())
+	assert.Equal(t, "o3", Config{Name: "codex", Codex: codex.Config{Model: "o3"}}.Model())
+	assert.Equal(t, "sonnet", Config{Name: "pi"}.WithModel("sonnet", "").Model())
+	assert.Empty(t, Config{Name: "opencode", Claude: claude.Config{Model: "opus"}}.Model())
+}
+
+func TestConfig_ReadOnlyFlags(t *testing.T) {
+	flags, err := Config{ExtraFlags: []string{"--dangerously-skip-permissions"}}.ReadOnlyFlags(llm.PurposeReview)
+	require.NoError(t, err)
+	assert.Equal(t, "--dangerously-skip-permissions", flags[0])
+	assert.Contains(t, flags, "--settings")
+
+	flags, err = Config{Name: "codex", ExtraFlags: []string{"--dangerously-bypass-approvals-and-sandbox"}}.ReadOnlyFlags(llm.PurposeReview)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--sandbox", "read-only"}, flags)
+
+	_, err = Config{Name: "pi"}.ReadOnlyFlags(llm.PurposeReview)
+	require.ErrorContains(t, err, "no read-only mode")
+}
+
+func TestConfig_FlagsFor(t *testing.T) {
+	cfg := Config{
+		ExtraFlags: []string{"--dangerously-skip-permissions"},
+		PurposeFlags: map[llm.Purpose][]string{
+			llm.PurposeReview: {"--model", "opus"},
+		},
+	}
+
+	assert.Equal(t, []string{"--dangerously-skip-permissions", "--model", "opus"}, cfg.FlagsFor(llm.PurposeReview))
+	assert.Equal(t, []string{"--dangerously-skip-permissions"}, cfg.FlagsFor(llm.PurposeMain))
+
+	// The shared flags are not modified.
+	_ = append(cfg.FlagsFor(llm.PurposeReview), "--verbose")
+	assert.Equal(t, []string{"--dangerously-skip-permissions"}, cfg.ExtraFlags)
+}
@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	limitMu sync.Mutex
+	// slots bounds in-flight invocations process-wide; nil means unlimited.
+	slots chan struct{}
+)
+
+// SetMaxConcurrentInvocations caps how many invocations wrapped by
+// WithConcurrencyLimit may run at once across the whole process. Values
+// below 1 remove the cap. Invocations already in flight are unaffected.
+func SetMaxConcurrentInvocations(n int) {
+	limitMu.Lock()
+	defer limitMu.Unlock()
+	if n < 1 {
+		slots = nil
+		return
+	}
+	slots = make(chan struct{}, n)
+}
+
+// acquireSlot blocks until an invocation slot is free or ctx is done.
+// The returned release func must be called once the invocation finishes.
+func acquireSlot(ctx context.Context) (func(), error) {
+	limitMu.Lock()
+	ch := slots
+	limitMu.Unlock()
+	if ch == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case ch <- struct{}{}:
+		return func() { <-ch }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// limitedInvoker gates an Invoker behind the global invocation limit.
+type limitedInvoker struct {
+	inner Invoker
+}
+
+// WithConcurrencyLimit wraps inv so each call waits for a free slot under
+// the limit set by SetMaxConcurrentInvocations.
+func WithConcurrencyLimit(inv Invoker) Invoker {
+	return &limitedInvoker{inner: inv}
+}
+
+// Invoke waits for a slot, then delegates to the wrapped invoker.
+func (l *limitedInvoker) Invoke(ctx context.Context, prompt string, opts InvokeOptions) (*InvokeResult, error) {
+	release, err := acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return l.inner.Invoke(ctx, prompt, opts)
+}
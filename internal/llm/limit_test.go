@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingInvoker struct {
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (c *countingInvoker) Invoke(_ context.Context, _ string, _ InvokeOptions) (*InvokeResult, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return &InvokeResult{Text: "ok"}, nil
+}
+
+func TestWithConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		max      int
+		wantPeak int32
+	}{
+		{name: "caps in-flight invocations", max: 2, wantPeak: 2},
+		{name: "serializes with limit of one", max: 1, wantPeak: 1},
+		{name: "unlimited when zero", max: 0, wantPeak: 6},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetMaxConcurrentInvocations(tc.max)
+			t.Cleanup(func() { SetMaxConcurrentInvocations(0) })
+
+			inner := &countingInvoker{}
+			// Separate wrappers share the same global limit.
+			a, b := WithConcurrencyLimit(inner), WithConcurrencyLimit(inner)
+
+			var wg sync.WaitGroup
+			for i := range 6 {
+				inv := a
+				if i%2 == 1 {
+					inv = b
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := inv.Invoke(context.Background(), "p", InvokeOptions{})
+					assert.NoError(t, err)
+				}()
+			}
+			wg.Wait()
+
+			if tc.max > 0 {
+				assert.LessOrEqual(t, inner.peak.Load(), tc.wantPeak)
+			} else {
+				assert.Greater(t, inner.peak.Load(), int32(1))
+			}
+		})
+	}
+}
+
+func TestWithConcurrencyLimit_ContextCanceled(t *testing.T) {
+	SetMaxConcurrentInvocations(1)
+	t.Cleanup(func() { SetMaxConcurrentInvocations(0) })
+
+	release, err := acquireSlot(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = WithConcurrencyLimit(&countingInvoker{}).Invoke(ctx, "p", InvokeOptions{})
+	require.ErrorIs(t, err, context.Canceled)
+}
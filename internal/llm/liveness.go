@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// StallError is the error of an invocation aborted by the liveness
+// timeout: the executor wrote no output, not even a tool call, for Idle.
+type StallError struct {
+	Idle time.Duration
+}
+
+func (e *StallError) Error() string {
+	return fmt.Sprintf("executor stalled: no output for %s", e.Idle)
+}
+
+// WithLiveness returns a copy of ctx canceled with a *StallError once no
+// reader passed to watch has returned data for idle, so a wedged executor
+// is killed before the invocation timeout. stop releases the watchdog. A
+// non-positive idle returns ctx and readers unchanged.
+func WithLiveness(ctx context.Context, idle time.Duration) (live context.Context, watch func(io.Reader) io.Reader, stop func()) {
+	if idle <= 0 {
+		return ctx, func(r io.Reader) io.Reader { return r }, func() {}
+	}
+	live, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(idle, func() { cancel(&StallError{Idle: idle}) })
+	watch = func(r io.Reader) io.Reader {
+		return &livenessReader{r: r, timer: timer, idle: idle}
+	}
+	return live, watch, func() {
+		timer.Stop()
+		cancel(nil)
+	}
+}
+
+// Stalled returns the *StallError ctx was canceled with by WithLiveness,
+// or nil.
+func Stalled(ctx context.Context) error {
+	var stall *StallError
+	if errors.As(context.Cause(ctx), &stall) {
+		return stall
+	}
+	return nil
+}
+
+// livenessReader restarts the liveness timer whenever a read returns data.
+type livenessReader struct {
+	r     io.Reader
+	timer *time.Timer
+	idle  time.Duration
+}
+
+func (l *livenessReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if n > 0 {
+		l.timer.Reset(l.idle)
+	}
+	return n, err
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLiveness(t *testing.T) {
+	t.Run("stalls without output", func(t *testing.T) {
+		ctx, watch, stop := WithLiveness(context.Background(), 50*time.Millisecond)
+		defer stop()
+		pr, pw := io.Pipe()
+		defer pw.Close()
+		r := watch(pr)
+
+		go func() { _, _ = pw.Write([]byte("event\n")) }()
+		buf := make([]byte, 16)
+		_, err := r.Read(buf)
+		require.NoError(t, err)
+
+		<-ctx.Done()
+		var stall *StallError
+		require.ErrorAs(t, Stalled(ctx), &stall)
+		assert.Equal(t, 50*time.Millisecond, stall.Idle)
+		assert.Equal(t, "executor stalled: no output for 50ms", stall.Error())
+	})
+
+	t.Run("output keeps it alive", func(t *testing.T) {
+		ctx, watch, stop := WithLiveness(context.Background(), 100*time.Millisecond)
+		pr, pw := io.Pipe()
+		r := watch(pr)
+		go func() {
+			for range 6 {
+				time.Sleep(40 * time.Millisecond)
+				_, _ = pw.Write([]byte("x"))
+			}
+			pw.Close()
+		}()
+		_, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, ctx.Err(), "240ms of output 40ms apart never idles for 100ms")
+
+		stop()
+		assert.Nil(t, Stalled(ctx), "stopping is not a stall")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		parent := context.Background()
+		ctx, watch, stop := WithLiveness(parent, 0)
+		defer stop()
+		assert.Equal(t, parent, ctx)
+		pr, _ := io.Pipe()
+		assert.Equal(t, io.Reader(pr), watch(pr))
+	})
+}
+
+func TestInvokeOptions_LivenessIdle(t *testing.T) {
+	assert.Equal(t, 90*time.Second, InvokeOptions{Streaming: true, LivenessTimeout: 90}.LivenessIdle())
+	assert.Zero(t, InvokeOptions{LivenessTimeout: 90}.LivenessIdle(), "text output arrives only at the end")
+	assert.Zero(t, InvokeOptions{Streaming: true}.LivenessIdle())
+}
@@ -4,6 +4,7 @@ package llm
 
 import (
 	"context"
+	"time"
 )
 
 // Invoker runs a Claude CLI invocation and returns the text output plus
@@ -14,6 +15,33 @@ type Invoker interface {
 	Invoke(ctx context.Context, prompt string, opts InvokeOptions) (*InvokeResult, error)
 }
 
+// Purpose is the kind of work an invocation does.
+type Purpose string
+
+// Invocation purposes, each of which can have its own executor flags.
+const (
+	PurposeMain      Purpose = "main"      // main loop iterations
+	PurposeReview    Purpose = "review"    // review agents
+	PurposeValidator Purpose = "validator" // review issue and simplification validators
+	PurposePlanning  Purpose = "planning"  // phase planning, splitting, and supervision
+)
+
+// Reasoning effort levels accepted by executors that support them.
+const (
+	EffortLow    = "low"
+	EffortMedium = "medium"
+	EffortHigh   = "high"
+)
+
+// ValidEffort reports whether effort is one of the known levels.
+func ValidEffort(effort string) bool {
+	switch effort {
+	case EffortLow, EffortMedium, EffortHigh:
+		return true
+	}
+	return false
+}
+
 // InvokeOptions configures a single Claude invocation.
 type InvokeOptions struct {
 	// WorkingDir for the Claude subprocess.
@@ -25,10 +53,23 @@ type InvokeOptions struct {
 	// ExtraFlags are additional CLI flags appended to the command.
 	ExtraFlags []string
 
+	// Purpose says what the invocation is for. Callers pick ExtraFlags for
+	// it; wrapping invokers may use it to tell invocations apart.
+	Purpose Purpose
+
 	// Timeout overrides the default invocation timeout (seconds).
 	// Zero means no explicit timeout (caller's context is respected).
 	Timeout int
 
+	// LivenessTimeout aborts a streaming invocation with a *StallError when
+	// the executor writes no output for this many seconds. Non-streaming
+	// output arrives only at the end and is not watched. Zero disables it.
+	LivenessTimeout int
+
+	// ResumeSessionID continues an earlier executor session instead of
+	// starting a fresh one. Executors without session support ignore it.
+	ResumeSessionID string
+
 	// OnOutput is called with text fragments as they arrive.
 	OnOutput func(text string)
 
@@ -41,6 +82,9 @@ type InvokeOptions struct {
 	// OnSystemInit is called when a system init event provides the model name.
 	OnSystemInit func(model string)
 
+	// OnSessionID is called with the executor session ID once it is known.
+	OnSessionID func(sessionID string)
+
 	// OnTokens is called with live token counts during streaming.
 	OnTokens func(inputTokens, outputTokens int)
 
@@ -53,6 +97,15 @@ type InvokeOptions struct {
 	OnProcessEnd   func()
 }
 
+// LivenessIdle returns how long a streaming invocation may go without
+// output before it is aborted; zero when it is not watched.
+func (o InvokeOptions) LivenessIdle() time.Duration {
+	if !o.Streaming || o.LivenessTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(o.LivenessTimeout) * time.Second
+}
+
 // InvokeResult holds the output of a completed invocation.
 type InvokeResult struct {
 	// Text is the full text output from Claude.
@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FailureClass is why an invocation failed.
+type FailureClass string
+
+// Failure classes, in the order they are reported.
+const (
+	FailureTimeout   FailureClass = "timeout"    // the invocation timeout passed
+	FailureStalled   FailureClass = "stalled"    // the executor wrote nothing for the liveness timeout
+	FailureRateLimit FailureClass = "rate_limit" // the provider refused the request for rate or capacity
+	FailureExit      FailureClass = "exit"       // the executor exited with a nonzero status
+	FailureParse     FailureClass = "parse"      // the output had no usable status or review block
+	FailureOther     FailureClass = "other"
+)
+
+// FailureClasses lists the failure classes in a stable order.
+var FailureClasses = []FailureClass{FailureTimeout, FailureStalled, FailureRateLimit, FailureExit, FailureParse, FailureOther}
+
+// rateLimitMarkers are substrings of executor errors that mean the provider
+// throttled the request.
+var rateLimitMarkers = []string{"rate limit", "rate_limit", "ratelimit", "too many requests", "429", "overloaded", "quota"}
+
+// ClassifyError returns the class of an invocation error. Parse failures are
+// never returned: the invocation itself succeeded, so callers report them.
+func ClassifyError(err error) FailureClass {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range rateLimitMarkers {
+		if strings.Contains(msg, marker) {
+			return FailureRateLimit
+		}
+	}
+	var exitErr *exec.ExitError
+	var stall *StallError
+	switch {
+	case errors.As(err, &stall):
+		return FailureStalled
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureTimeout
+	case errors.As(err, &exitErr):
+		return FailureExit
+	default:
+		return FailureOther
+	}
+}
+
+// InvocationStats summarizes the invocations of a run.
+type InvocationStats struct {
+	Invocations  int
+	Retries      int // invocations repeating one that failed or returned unusable output
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+	Failures     map[FailureClass]int
+}
+
+// MeanLatency returns the average invocation latency.
+func (s InvocationStats) MeanLatency() time.Duration {
+	if s.Invocations == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Invocations)
+}
+
+// FailureCount returns the number of failures of every class.
+func (s InvocationStats) FailureCount() int {
+	n := 0
+	for _, count := range s.Failures {
+		n += count
+	}
+	return n
+}
+
+// FormatFailures returns the failures as "class count" pairs in
+// FailureClasses order, e.g. "timeout 1, rate_limit 2"; empty when there are
+// none.
+func (s InvocationStats) FormatFailures() string {
+	var parts []string
+	for _, class := range FailureClasses {
+		if n := s.Failures[class]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", class, n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Metrics records the latency and failures of invocations. It is safe for
+// concurrent use; a nil *Metrics records nothing.
+type Metrics struct {
+	mu    sync.Mutex
+	stats InvocationStats
+}
+
+// Record adds an invocation that took d and failed with class ("" = it
+// succeeded).
+func (m *Metrics) Record(d time.Duration, class FailureClass) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Invocations++
+	m.stats.TotalLatency += d
+	m.stats.MaxLatency = max(m.stats.MaxLatency, d)
+	if class != "" {
+		m.addFailure(class)
+	}
+}
+
+// RecordFailure adds a failure found after the invocation returned, such as
+// output that could not be parsed.
+func (m *Metrics) RecordFailure(class FailureClass) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.addFailure(class)
+}
+
+func (m *Metrics) addFailure(class FailureClass) {
+	if m.stats.Failures == nil {
+		m.stats.Failures = make(map[FailureClass]int)
+	}
+	m.stats.Failures[class]++
+}
+
+// RecordRetry counts an invocation made again because the previous one
+// failed.
+func (m *Metrics) RecordRetry() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Retries++
+}
+
+// Stats returns a copy of the recorded stats.
+func (m *Metrics) Stats() InvocationStats {
+	if m == nil {
+		return InvocationStats{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Failures = maps.Clone(m.stats.Failures)
+	return stats
+}
+
+// metricsInvoker times the invocations of an Invoker.
+type metricsInvoker struct {
+	inner   Invoker
+	metrics *Metrics
+}
+
+// WithMetrics wraps inv so each call is recorded in m. Executors report a
+// timeout as a TimeoutBlockedStatus result rather than an error; both count
+// as timeouts. Canceled invocations (a skipped phase, a stopped run) count
+// without a failure. A nil m returns inv unchanged.
+func WithMetrics(inv Invoker, m *Metrics) Invoker {
+	if m == nil {
+		return inv
+	}
+	return &metricsInvoker{inner: inv, metrics: m}
+}
+
+// Invoke delegates to the wrapped invoker and records how it went.
+func (i *metricsInvoker) Invoke(ctx context.Context, prompt string, opts InvokeOptions) (*InvokeResult, error) {
+	start := time.Now()
+	res, err := i.inner.Invoke(ctx, prompt, opts)
+	var class FailureClass
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+	case err != nil:
+		class = ClassifyError(err)
+	case res != nil && res.Text == TimeoutBlockedStatus():
+		class = FailureTimeout
+	}
+	i.metrics.Record(time.Since(start), class)
+	return res, err
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 2").Run()
+	require.Error(t, exitErr)
+
+	tests := []struct {
+		err  error
+		want FailureClass
+	}{
+		{err: fmt.Errorf("claude exited: %w", exitErr), want: FailureExit},
+		{err: fmt.Errorf("claude exited: %w\nstderr: API Error: 429 Too Many Requests", exitErr), want: FailureRateLimit},
+		{err: errors.New("codex: Rate limit reached for o3"), want: FailureRateLimit},
+		{err: errors.New("overloaded_error"), want: FailureRateLimit},
+		{err: fmt.Errorf("wait: %w", context.DeadlineExceeded), want: FailureTimeout},
+		{err: &StallError{Idle: 10 * time.Minute}, want: FailureStalled},
+		{err: errors.New("exec: \"claude\": executable file not found in $PATH"), want: FailureOther},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, ClassifyError(tc.err), tc.err.Error())
+	}
+}
+
+type scriptedInvoker struct {
+	results []*InvokeResult
+	errs    []error
+	calls   int
+}
+
+func (s *scriptedInvoker) Invoke(_ context.Context, _ string, _ InvokeOptions) (*InvokeResult, error) {
+	i := s.calls
+	s.calls++
+	return s.results[i], s.errs[i]
+}
+
+func TestWithMetrics(t *testing.T) {
+	inner := &scriptedInvoker{
+		results: []*InvokeResult{{Text: "ok"}, {Text: TimeoutBlockedStatus()}, nil, nil},
+		errs:    []error{nil, nil, errors.New("429 rate limited"), context.Canceled},
+	}
+	var m Metrics
+	inv := WithMetrics(inner, &m)
+	for range 4 {
+		_, _ = inv.Invoke(context.Background(), "prompt", InvokeOptions{})
+	}
+	m.RecordFailure(FailureParse)
+	m.RecordRetry()
+
+	stats := m.Stats()
+	assert.Equal(t, 4, stats.Invocations)
+	assert.Equal(t, 1, stats.Retries)
+	assert.Equal(t, map[FailureClass]int{FailureTimeout: 1, FailureRateLimit: 1, FailureParse: 1}, stats.Failures)
+	assert.Equal(t, 3, stats.FailureCount())
+	assert.Equal(t, "timeout 1, rate_limit 1, parse 1", stats.FormatFailures())
+	assert.LessOrEqual(t, stats.MaxLatency, stats.TotalLatency)
+	assert.Equal(t, stats.TotalLatency/4, stats.MeanLatency())
+
+	stats.Failures[FailureExit] = 5
+	assert.NotContains(t, m.Stats().Failures, FailureExit, "Stats returns a copy")
+
+	assert.Same(t, inner, WithMetrics(inner, nil))
+	var nilMetrics *Metrics
+	nilMetrics.Record(time.Second, FailureExit)
+	assert.Zero(t, nilMetrics.Stats().Invocations)
+}
@@ -14,9 +14,10 @@ import (
 
 // Config holds environment configuration for opencode subprocesses.
 type Config struct {
-	Model     string // --model value ("provider/model" format, e.g. "anthropic/claude-sonnet-4-5")
-	APIKey    string // provider API key, set based on model prefix
-	ConfigDir string // OPENCODE_CONFIG_DIR
+	Model     string            // --model value ("provider/model" format, e.g. "anthropic/claude-sonnet-4-5")
+	APIKey    string            // provider API key, set based on model prefix
+	ConfigDir string            // OPENCODE_CONFIG_DIR
+	Vars      map[string]string // set in the environment last, overriding inherited and derived variables
 }
 
 // Invoker invokes the opencode AI coding agent CLI binary.
@@ -55,7 +56,7 @@ func BuildEnv(cfg Config) []string {
 		}
 		env = append(env, envVar+"="+cfg.APIKey)
 	}
-	return env
+	return llm.SetEnv(env, cfg.Vars)
 }
 
 // Invoke runs opencode with the given prompt and options.
@@ -87,6 +88,8 @@ func (o *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 		invokeCtx, cancel = context.WithTimeout(ctx, time.Duration(opts.Timeout)*time.Second)
 		defer cancel()
 	}
+	invokeCtx, watch, stopWatch := llm.WithLiveness(invokeCtx, opts.LivenessIdle())
+	defer stopWatch()
 
 	cmd := exec.CommandContext(invokeCtx, "opencode", args...)
 	cmd.Env = BuildEnv(o.Env)
@@ -113,7 +116,7 @@ func (o *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 
 	var output string
 	if opts.Streaming {
-		output = processOpenCodeStreamingOutput(stdout, o.Env.Model, opts)
+		output = processOpenCodeStreamingOutput(watch(stdout), o.Env.Model, opts)
 	} else {
 		output = llm.ProcessTextOutput(stdout, opts)
 	}
@@ -123,6 +126,9 @@ func (o *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 		opts.OnProcessEnd()
 	}
 	if err != nil {
+		if stall := llm.Stalled(invokeCtx); stall != nil {
+			return nil, stall
+		}
 		if invokeCtx.Err() == context.DeadlineExceeded {
 			return &llm.InvokeResult{Text: llm.TimeoutBlockedStatus()}, nil
 		}
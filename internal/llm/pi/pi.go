@@ -16,10 +16,12 @@ import (
 
 // Config holds environment configuration for pi subprocesses.
 type Config struct {
-	ConfigDir string // PI_CODING_AGENT_DIR
-	Provider  string // --provider value (e.g. "anthropic", "openai")
-	Model     string // --model value (e.g. "sonnet", "gpt-4o")
-	APIKey    string // API key for the configured provider
+	ConfigDir string            // PI_CODING_AGENT_DIR
+	Provider  string            // --provider value (e.g. "anthropic", "openai")
+	Model     string            // --model value (e.g. "sonnet", "gpt-4o")
+	Effort    string            // --thinking level (low, medium, high)
+	APIKey    string            // API key for the configured provider
+	Vars      map[string]string // set in the environment last, overriding inherited and derived variables
 }
 
 // Invoker invokes the pi coding agent CLI binary.
@@ -49,7 +51,7 @@ func BuildEnv(cfg Config) []string {
 		}
 		env = append(env, envVar+"="+cfg.APIKey)
 	}
-	return env
+	return llm.SetEnv(env, cfg.Vars)
 }
 
 // Invoke runs pi with the given prompt and options.
@@ -62,6 +64,9 @@ func (p *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 	if p.Env.Model != "" {
 		args = append(args, "--model", p.Env.Model)
 	}
+	if p.Env.Effort != "" {
+		args = append(args, "--thinking", p.Env.Effort)
+	}
 
 	if len(opts.ExtraFlags) > 0 {
 		args = append(args, opts.ExtraFlags...)
@@ -79,6 +84,8 @@ func (p *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 		invokeCtx, cancel = context.WithTimeout(ctx, time.Duration(opts.Timeout)*time.Second)
 		defer cancel()
 	}
+	invokeCtx, watch, stopWatch := llm.WithLiveness(invokeCtx, opts.LivenessIdle())
+	defer stopWatch()
 
 	cmd := exec.CommandContext(invokeCtx, "pi", args...)
 	if opts.WorkingDir != "" {
@@ -116,7 +123,7 @@ func (p *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 
 	var output string
 	if opts.Streaming {
-		output = processPiStreamingOutput(stdout, opts)
+		output = processPiStreamingOutput(watch(stdout), opts)
 	} else {
 		output = llm.ProcessTextOutput(stdout, opts)
 	}
@@ -126,6 +133,9 @@ func (p *Invoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOpti
 		opts.OnProcessEnd()
 	}
 	if err != nil {
+		if stall := llm.Stalled(invokeCtx); stall != nil {
+			return nil, stall
+		}
 		if invokeCtx.Err() == context.DeadlineExceeded {
 			return &llm.InvokeResult{Text: llm.TimeoutBlockedStatus()}, nil
 		}
@@ -212,10 +212,12 @@ func TestInvokerProviderAndModelFlags(t *testing.T) {
 	inv := New(Config{
 		Provider: "anthropic",
 		Model:    "sonnet",
+		Effort:   "low",
 	})
 	res, err := inv.Invoke(context.Background(), "test", llm.InvokeOptions{})
 	require.NoError(t, err)
 	require.Contains(t, res.Text, "--provider anthropic")
 	require.Contains(t, res.Text, "--model sonnet")
+	require.Contains(t, res.Text, "--thinking low")
 	require.Contains(t, res.Text, "--print")
 }
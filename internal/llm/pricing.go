@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"maps"
+	"strings"
+)
+
+// Price is what a model charges, in USD per million tokens.
+type Price struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// Cost returns the USD cost of the given token counts.
+func (p Price) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1_000_000
+}
+
+// PriceTable maps a model name fragment ("sonnet", "gpt-5-mini") to its price.
+type PriceTable map[string]Price
+
+// DefaultPrices returns list prices for common models. They are estimates:
+// cached input is billed as regular input.
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		"opus":       {Input: 15, Output: 75},
+		"opus-4-5":   {Input: 5, Output: 25},
+		"sonnet":     {Input: 3, Output: 15},
+		"haiku":      {Input: 1, Output: 5},
+		"haiku-3-5":  {Input: 0.8, Output: 4},
+		"gpt-4o":     {Input: 2.5, Output: 10},
+		"gpt-4.1":    {Input: 2, Output: 8},
+		"gpt-5":      {Input: 1.25, Output: 10},
+		"gpt-5-mini": {Input: 0.25, Output: 2},
+		"gpt-5-nano": {Input: 0.05, Output: 0.4},
+		"o3":         {Input: 2, Output: 8},
+		"o4-mini":    {Input: 1.1, Output: 4.4},
+	}
+}
+
+// WithOverrides returns a copy of the table with overrides added or replacing
+// existing entries. Keys are matched case-insensitively.
+func (t PriceTable) WithOverrides(overrides map[string]Price) PriceTable {
+	merged := maps.Clone(t)
+	if merged == nil {
+		merged = make(PriceTable, len(overrides))
+	}
+	for name, price := range overrides {
+		merged[strings.ToLower(name)] = price
+	}
+	return merged
+}
+
+// Lookup returns the price of model: the entry with the longest key that
+// appears in the model name, so "claude-opus-4-5-20251101" matches
+// "opus-4-5" rather than "opus".
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	best := ""
+	for name := range t {
+		if len(name) > len(best) && strings.Contains(model, name) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t[best], true
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceTable_Lookup(t *testing.T) {
+	prices := DefaultPrices()
+
+	tests := []struct {
+		model string
+		want  Price
+		found bool
+	}{
+		{model: "claude-sonnet-4-5-20250929", want: Price{Input: 3, Output: 15}, found: true},
+		{model: "claude-opus-4-1-20250805", want: Price{Input: 15, Output: 75}, found: true},
+		{model: "claude-opus-4-5-20251101", want: Price{Input: 5, Output: 25}, found: true},
+		{model: "openai/gpt-5-mini", want: Price{Input: 0.25, Output: 2}, found: true},
+		{model: "Claude-Haiku-4-5", want: Price{Input: 1, Output: 5}, found: true},
+		{model: "local-llama", found: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.model, func(t *testing.T) {
+			got, ok := prices.Lookup(tc.model)
+			assert.Equal(t, tc.found, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPriceTable_WithOverrides(t *testing.T) {
+	base := DefaultPrices()
+	prices := base.WithOverrides(map[string]Price{"Sonnet": {Input: 1, Output: 2}, "llama": {Input: 0.1, Output: 0.1}})
+
+	got, ok := prices.Lookup("claude-sonnet-4-5")
+	assert.True(t, ok)
+	assert.Equal(t, Price{Input: 1, Output: 2}, got)
+	_, ok = prices.Lookup("local-llama")
+	assert.True(t, ok)
+
+	got, _ = base.Lookup("claude-sonnet-4-5")
+	assert.Equal(t, Price{Input: 3, Output: 15}, got, "the base table is not modified")
+}
+
+func TestPrice_Cost(t *testing.T) {
+	p := Price{Input: 3, Output: 15}
+	assert.InDelta(t, 0.0105, p.Cost(1000, 500), 1e-9)
+	assert.Zero(t, p.Cost(0, 0))
+}
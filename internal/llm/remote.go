@@ -0,0 +1,264 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Ways a RemoteRunner copies the local working tree to the remote host.
+const (
+	SyncRsync = "rsync" // rsync the tree, honoring .gitignore files
+	SyncGit   = "git"   // push HEAD and apply the uncommitted changes as a patch
+	SyncNone  = "none"  // the host already has the tree, e.g. on a shared filesystem
+)
+
+// RemoteConfig configures a RemoteRunner.
+type RemoteConfig struct {
+	Host     string   // ssh destination: user@host or a ~/.ssh/config alias
+	Dir      string   // repository path on the host; with git sync, a clone reserved for programmator
+	Sync     string   // SyncRsync (default), SyncGit, or SyncNone
+	SSHFlags []string // extra ssh options, such as -p 2222 or -i ~/.ssh/key
+	Forward  []string // names of further variables to pass on, such as the configured executor_env
+}
+
+// forwardedEnv lists the variables passed on to remote commands: executor
+// settings derived from the config. Credentials and config directories are
+// not forwarded; the remote CLI uses the host's own login.
+var forwardedEnv = []string{"MAX_THINKING_TOKENS="}
+
+// RemoteRunner runs executor commands on another host over SSH, so a
+// laptop can drive invocations on a bigger machine or where credentials
+// live. Before a command runs, the local working tree is synced to
+// RemoteConfig.Dir when it changed since the last sync; output streams back
+// over the SSH connection.
+type RemoteRunner struct {
+	cfg RemoteConfig
+
+	mu     sync.Mutex // serializes syncs of concurrent invocations
+	synced string     // fingerprint of the tree last synced
+}
+
+var _ CommandRunner = (*RemoteRunner)(nil)
+
+// NewRemoteRunner returns a RemoteRunner for cfg.
+func NewRemoteRunner(cfg RemoteConfig) *RemoteRunner {
+	if cfg.Sync == "" {
+		cfg.Sync = SyncRsync
+	}
+	return &RemoteRunner{cfg: cfg}
+}
+
+// Command syncs dir to the host and returns an ssh command running name
+// there, in RemoteConfig.Dir.
+func (r *RemoteRunner) Command(ctx context.Context, dir string, env []string, name string, args ...string) (*exec.Cmd, error) {
+	if err := r.sync(ctx, dir); err != nil {
+		return nil, fmt.Errorf("sync %s to %s: %w", dir, r.cfg.Host, err)
+	}
+
+	words := []string{"cd", shellQuote(r.cfg.Dir), "&&", "exec", "env"}
+	for _, e := range env {
+		if r.forwarded(e) {
+			words = append(words, shellQuote(e))
+		}
+	}
+	words = append(words, shellQuote(name))
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+	return exec.CommandContext(ctx, "ssh", r.sshArgs(strings.Join(words, " "))...), nil
+}
+
+// forwarded reports whether the env assignment e is passed on to remote
+// commands.
+func (r *RemoteRunner) forwarded(e string) bool {
+	for _, prefix := range forwardedEnv {
+		if strings.HasPrefix(e, prefix) {
+			return true
+		}
+	}
+	for _, name := range r.cfg.Forward {
+		if strings.HasPrefix(e, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// Dir returns RemoteConfig.Dir, where every local directory is synced to.
+func (r *RemoteRunner) Dir(string) string {
+	return r.cfg.Dir
+}
+
+func (r *RemoteRunner) sshArgs(command string) []string {
+	args := append([]string{"-o", "BatchMode=yes"}, r.cfg.SSHFlags...)
+	return append(args, r.cfg.Host, command)
+}
+
+// sshCommand is the ssh invocation, with its flags, that rsync and git use.
+func (r *RemoteRunner) sshCommand() string {
+	words := []string{"ssh", "-o", "BatchMode=yes"}
+	for _, flag := range r.cfg.SSHFlags {
+		words = append(words, shellQuote(flag))
+	}
+	return strings.Join(words, " ")
+}
+
+// sync copies dir to the host unless it is unchanged since the last sync.
+// Outside a git repository the tree cannot be fingerprinted and is synced
+// every time.
+func (r *RemoteRunner) sync(ctx context.Context, dir string) error {
+	if r.cfg.Sync == SyncNone {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fingerprint, err := treeFingerprint(ctx, dir)
+	if err == nil && fingerprint == r.synced {
+		return nil
+	}
+	r.synced = ""
+
+	switch r.cfg.Sync {
+	case SyncGit:
+		err = r.syncGit(ctx, dir)
+	default:
+		err = r.syncRsync(ctx, dir)
+	}
+	if err != nil {
+		return err
+	}
+	r.synced = fingerprint
+	return nil
+}
+
+func (r *RemoteRunner) syncRsync(ctx context.Context, dir string) error {
+	cmd := exec.CommandContext(ctx, "rsync", "-az", "--delete", "--filter=:- .gitignore",
+		"-e", r.sshCommand(), strings.TrimSuffix(dir, "/")+"/", r.cfg.Host+":"+strings.TrimSuffix(r.cfg.Dir, "/")+"/")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("rsync: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// syncGit pushes HEAD to the remote clone, checks it out there, and applies
+// the uncommitted changes, new files included, on top.
+func (r *RemoteRunner) syncGit(ctx context.Context, dir string) error {
+	head, err := gitOutput(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	head = strings.TrimSpace(head)
+
+	push := exec.CommandContext(ctx, "git", "push", "--force", "--quiet", r.cfg.Host+":"+r.cfg.Dir, "HEAD:refs/programmator/sync")
+	push.Dir = dir
+	push.Env = append(os.Environ(), "GIT_SSH_COMMAND="+r.sshCommand())
+	if out, err := push.CombinedOutput(); err != nil {
+		return fmt.Errorf("git push: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	patch, err := worktreePatch(ctx, dir)
+	if err != nil {
+		return err
+	}
+	script := fmt.Sprintf("cd %s && git checkout --quiet --force --detach %s && git clean -fdq", shellQuote(r.cfg.Dir), head)
+	if patch != "" {
+		script += " && git apply --whitespace=nowarn"
+	}
+	apply := exec.CommandContext(ctx, "ssh", r.sshArgs(script)...)
+	apply.Stdin = strings.NewReader(patch)
+	if out, err := apply.CombinedOutput(); err != nil {
+		return fmt.Errorf("check out %s on %s: %w: %s", head, r.cfg.Host, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// worktreePatch returns the uncommitted changes of dir as a binary patch,
+// with untracked files as new files.
+func worktreePatch(ctx context.Context, dir string) (string, error) {
+	patch, err := gitOutput(ctx, dir, "diff", "HEAD", "--binary")
+	if err != nil {
+		return "", err
+	}
+	untracked, err := untrackedFiles(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(patch)
+	for _, file := range untracked {
+		// --no-index exits 1 when the files differ, which they always do.
+		cmd := exec.CommandContext(ctx, "git", "diff", "--binary", "--no-index", "--", os.DevNull, file)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		var exitErr *exec.ExitError
+		if err != nil && (!errors.As(err, &exitErr) || exitErr.ExitCode() != 1) {
+			return "", fmt.Errorf("git diff %s: %w", file, err)
+		}
+		b.Write(out)
+	}
+	return b.String(), nil
+}
+
+// treeFingerprint identifies the state of the working tree in dir: HEAD,
+// the uncommitted changes, and the size and modification time of
+// untracked files.
+func treeFingerprint(ctx context.Context, dir string) (string, error) {
+	head, err := gitOutput(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	diff, err := gitOutput(ctx, dir, "diff", "HEAD", "--binary")
+	if err != nil {
+		return "", err
+	}
+	untracked, err := untrackedFiles(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", head, diff)
+	for _, file := range untracked {
+		if fi, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			fmt.Fprintf(h, "%s\x00%d\x00%d\x00", file, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func untrackedFiles(ctx context.Context, dir string) ([]string, error) {
+	out, err := gitOutput(ctx, dir, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return strings.FieldsFunc(out, func(r rune) bool { return r == 0 }), nil
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@,+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
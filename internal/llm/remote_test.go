@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRemote puts ssh and rsync on PATH that run on this machine: ssh runs
+// its command with sh, rsync copies into the path after "host:". Both log
+// their arguments to the returned file.
+func fakeRemote(t *testing.T) string {
+	t.Helper()
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+	scripts := map[string]string{
+		"ssh":   "#!/bin/sh\necho \"ssh $*\" >> " + log + "\nfor last; do :; done\nexec sh -c \"$last\"\n",
+		"rsync": "#!/bin/sh\necho \"rsync $*\" >> " + log + "\nfor last; do :; done\nfor arg; do [ \"$arg\" = \"$last\" ] && break; src=$arg; done\ncp -R \"$src\". \"${last#*:}\"\n",
+	}
+	for name, script := range scripts {
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755))
+	}
+	t.Setenv("PATH", bin+":"+os.Getenv("PATH"))
+	return log
+}
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+	} {
+		runGit(t, dir, args...)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-qm", "initial")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func syncCalls(t *testing.T, log, tool string) int {
+	t.Helper()
+	data, err := os.ReadFile(log)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(t, err)
+	n := 0
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if strings.HasPrefix(line, tool+" ") {
+			n++
+		}
+	}
+	return n
+}
+
+func runRemote(t *testing.T, r *RemoteRunner, dir string, env []string, script string) string {
+	t.Helper()
+	cmd, err := r.Command(context.Background(), dir, env, "sh", "-c", script)
+	require.NoError(t, err)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+func TestRemoteRunner_Rsync(t *testing.T) {
+	log := fakeRemote(t)
+	local := gitRepo(t)
+	remote := t.TempDir()
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("API_URL", "")
+	r := NewRemoteRunner(RemoteConfig{Host: "devbox", Dir: remote, SSHFlags: []string{"-p", "2222"}, Forward: []string{"API_URL"}})
+
+	env := []string{"MAX_THINKING_TOKENS=4096", "ANTHROPIC_API_KEY=secret", "API_URL=https://staging"}
+	out := runRemote(t, r, local, env, `pwd; cat main.go; echo "tokens=$MAX_THINKING_TOKENS key=${ANTHROPIC_API_KEY:-unset} url=$API_URL"`)
+	assert.Contains(t, out, remote)
+	assert.Contains(t, out, "package main")
+	assert.Contains(t, out, "tokens=4096 key=unset url=https://staging", "only executor settings and forwarded variables are passed on")
+	assert.Equal(t, 1, syncCalls(t, log, "rsync"))
+
+	data, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "ssh -o BatchMode=yes -p 2222 devbox cd ")
+	assert.Contains(t, string(data), "-e ssh -o BatchMode=yes -p 2222")
+
+	// An unchanged tree is not synced again; a changed one is.
+	runRemote(t, r, local, nil, "true")
+	assert.Equal(t, 1, syncCalls(t, log, "rsync"))
+	require.NoError(t, os.WriteFile(filepath.Join(local, "new.go"), []byte("package main // new\n"), 0o644))
+	out = runRemote(t, r, local, nil, "cat new.go")
+	assert.Contains(t, out, "// new")
+	assert.Equal(t, 2, syncCalls(t, log, "rsync"))
+}
+
+func TestRemoteRunner_Git(t *testing.T) {
+	fakeRemote(t)
+	local := gitRepo(t)
+	remote := filepath.Join(t.TempDir(), "clone")
+	runGit(t, t.TempDir(), "clone", "-q", local, remote)
+
+	runGit(t, local, "commit", "-q", "--allow-empty", "-m", "second")
+	require.NoError(t, os.WriteFile(filepath.Join(local, "main.go"), []byte("package main // changed\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(local, "added.go"), []byte("package main // added\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(remote, "stale.go"), []byte("stale\n"), 0o644))
+
+	r := NewRemoteRunner(RemoteConfig{Host: "devbox", Dir: remote, Sync: SyncGit})
+	out := runRemote(t, r, local, nil, "git log -1 --format=%s; cat main.go added.go; ls")
+	assert.Contains(t, out, "second\n")
+	assert.Contains(t, out, "package main // changed\npackage main // added\n")
+	assert.NotContains(t, out, "stale.go")
+	assert.Contains(t, runRemote(t, r, local, nil, "git diff --stat"), "main.go")
+}
+
+func TestRemoteRunner_SyncNone(t *testing.T) {
+	log := fakeRemote(t)
+	remote := t.TempDir()
+	r := NewRemoteRunner(RemoteConfig{Host: "devbox", Dir: remote, Sync: SyncNone})
+
+	assert.Contains(t, runRemote(t, r, "/nonexistent", nil, "pwd"), remote)
+	assert.Zero(t, syncCalls(t, log, "rsync"))
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "plain/path-1.go", shellQuote("plain/path-1.go"))
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, "'two words'", shellQuote("two words"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, "'$HOME'", shellQuote("$HOME"))
+}
@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"context"
+	"os/exec"
+)
+
+// CommandRunner starts executor CLI processes. Executors run their binary
+// through one, so that it can run somewhere other than this machine.
+type CommandRunner interface {
+	// Command returns the command running name with args for the local
+	// working directory dir, with env as its environment.
+	Command(ctx context.Context, dir string, env []string, name string, args ...string) (*exec.Cmd, error)
+
+	// Dir returns the path of the local directory dir where commands run,
+	// for executors that take their working directory as a flag.
+	Dir(dir string) string
+}
+
+// LocalRunner runs commands on this machine.
+var LocalRunner CommandRunner = localRunner{}
+
+type localRunner struct{}
+
+func (localRunner) Command(ctx context.Context, dir string, env []string, name string, args ...string) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	return cmd, nil
+}
+
+func (localRunner) Dir(dir string) string { return dir }
+
+// RunnerOr returns r, or LocalRunner when r is nil.
+func RunnerOr(r CommandRunner) CommandRunner {
+	if r == nil {
+		return LocalRunner
+	}
+	return r
+}
@@ -1,15 +1,26 @@
 package llm
 
-import "github.com/alexander-akhmetov/programmator/internal/protocol"
+import (
+	"strconv"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+)
 
 // TimeoutBlockedStatus returns a PROGRAMMATOR_STATUS block indicating the
 // executor invocation timed out. Used by all executors when the context
 // deadline is exceeded.
 func TimeoutBlockedStatus() string {
+	return BlockedStatus("Timeout", "Executor invocation timed out")
+}
+
+// BlockedStatus returns a PROGRAMMATOR_STATUS block reporting the
+// invocation BLOCKED with summary and reason, for executors that ended
+// without writing a status of their own.
+func BlockedStatus(summary, reason string) string {
 	return protocol.StatusBlockKey + `:
   phase_completed: ` + protocol.NullPhase + `
   status: ` + string(protocol.StatusBlocked) + `
   files_changed: []
-  summary: "Timeout"
-  error: "Executor invocation timed out"`
+  summary: ` + strconv.Quote(summary) + `
+  error: ` + strconv.Quote(reason)
 }
@@ -16,3 +16,9 @@ func TestTimeoutBlockedStatus(t *testing.T) {
 	assert.Contains(t, result, "Timeout")
 	assert.Contains(t, result, "timed out")
 }
+
+func TestBlockedStatus_QuotesText(t *testing.T) {
+	result := BlockedStatus("Failed", `turn failed: "rate limited"`)
+	assert.Contains(t, result, `summary: "Failed"`)
+	assert.Contains(t, result, `error: "turn failed: \"rate limited\""`)
+}
@@ -0,0 +1,41 @@
+package llm
+
+// FormatToolUse describes a tool_use block in one line: the tool name and,
+// for the common tools, its main argument, as in "Read /src/main.go".
+func FormatToolUse(name string, input any) string {
+	if inputMap, ok := input.(map[string]any); ok {
+		return name + FormatToolArg(name, inputMap)
+	}
+	return name
+}
+
+// FormatToolArg returns the main argument of a tool_use block, with a
+// leading space, or "" for tools without one.
+func FormatToolArg(toolName string, input map[string]any) string {
+	switch toolName {
+	case "Read", "Write", "Edit":
+		if path, ok := input["file_path"].(string); ok {
+			return " " + path
+		}
+	case "Bash":
+		if cmd, ok := input["command"].(string); ok {
+			if len(cmd) > 80 {
+				cmd = cmd[:80] + "..."
+			}
+			return " " + cmd
+		}
+	case "Glob":
+		if pattern, ok := input["pattern"].(string); ok {
+			return " " + pattern
+		}
+	case "Grep":
+		if pattern, ok := input["pattern"].(string); ok {
+			return " " + pattern
+		}
+	case "Task":
+		if desc, ok := input["description"].(string); ok {
+			return " " + desc
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatToolArg(t *testing.T) {
+	tests := []struct {
+		name     string
+		toolName string
+		input    map[string]any
+		expected string
+	}{
+		{name: "Read with path", toolName: "Read", input: map[string]any{"file_path": "/foo/bar.go"}, expected: " /foo/bar.go"},
+		{name: "Write with path", toolName: "Write", input: map[string]any{"file_path": "/a/b.go"}, expected: " /a/b.go"},
+		{name: "Edit with path", toolName: "Edit", input: map[string]any{"file_path": "/c.go"}, expected: " /c.go"},
+		{name: "Read missing path", toolName: "Read", input: map[string]any{}, expected: ""},
+		{name: "Bash short cmd", toolName: "Bash", input: map[string]any{"command": "ls -la"}, expected: " ls -la"},
+		{name: "Bash long cmd truncated", toolName: "Bash", input: map[string]any{"command": strings.Repeat("a", 100)}, expected: " " + strings.Repeat("a", 80) + "..."},
+		{name: "Glob pattern", toolName: "Glob", input: map[string]any{"pattern": "**/*.go"}, expected: " **/*.go"},
+		{name: "Grep pattern", toolName: "Grep", input: map[string]any{"pattern": "TODO"}, expected: " TODO"},
+		{name: "Task description", toolName: "Task", input: map[string]any{"description": "search files"}, expected: " search files"},
+		{name: "unknown tool", toolName: "Unknown", input: map[string]any{"foo": "bar"}, expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := FormatToolArg(tc.toolName, tc.input)
+			require.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestFormatToolUse(t *testing.T) {
+	require.Equal(t, "Read /foo/bar.go", FormatToolUse("Read", map[string]any{"file_path": "/foo/bar.go"}))
+	require.Equal(t, "Read", FormatToolUse("Read", "not a map"))
+}
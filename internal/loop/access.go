@@ -0,0 +1,53 @@
+package loop
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/llm/claude"
+)
+
+// SetMinimalPermissions makes phases without an access annotation run with
+// the access inferred from their name (read-only for investigation phases,
+// no shell for documentation phases).
+func (l *Loop) SetMinimalPermissions(enabled bool) {
+	l.minimalPermissions = enabled
+}
+
+// phaseAccess returns the tool access for phase, or "" for no restriction.
+func (l *Loop) phaseAccess(phase *domain.Phase) string {
+	switch {
+	case phase == nil:
+		return ""
+	case phase.Access != "":
+		return phase.Access
+	case l.minimalPermissions:
+		return domain.InferAccess(phase.Name)
+	default:
+		return ""
+	}
+}
+
+// invocationFlags returns the executor flags for an invocation working on
+// phase (nil for review fixes and the docs phase). Restricted phases get
+// claude settings that deny the tools their access level rules out.
+func (l *Loop) invocationFlags(phase *domain.Phase) []string {
+	flags := l.executorConfig.FlagsFor(llm.PurposeMain)
+	access := l.phaseAccess(phase)
+	if access == "" || access == domain.AccessFull {
+		return flags
+	}
+	if l.executorName() != "claude" {
+		l.log(fmt.Sprintf("Warning: phase access %q ignored: only the claude executor supports it", access))
+		return flags
+	}
+	accessFlags, err := claude.AccessFlags(access)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: %v; running phase '%s' without restrictions", err, phase.Name))
+		return flags
+	}
+	l.log(fmt.Sprintf("Phase access: %s", access))
+	return append(slices.Clone(flags), accessFlags...)
+}
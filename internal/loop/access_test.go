@@ -0,0 +1,68 @@
+package loop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_PhaseAccessFlags(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{
+		"Task 1: Look around <!-- access: read-only -->",
+		"Task 2: Fix the bug",
+		"Task 3: Update README",
+		"Task 4: Write docs <!-- access: full -->",
+	}})
+
+	invoker := newSequenceInvoker([]sequenceResponse{
+		{PhaseCompleted: "Task 1: Look around", Status: protocol.StatusContinue, Summary: "looked"},
+		{PhaseCompleted: "Task 2: Fix the bug", Status: protocol.StatusContinue, Summary: "fixed"},
+		{PhaseCompleted: "Task 3: Update README", Status: protocol.StatusContinue, Summary: "documented"},
+		{PhaseCompleted: "Task 4: Write docs", Status: protocol.StatusDone, Summary: "more docs"},
+	})
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 5, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetExecutorConfig(executor.Config{ExtraFlags: []string{"--model", "opus"}})
+	l.SetMinimalPermissions(true)
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+	require.Len(t, invoker.calls, 4)
+
+	readOnly := `{"permissions":{"deny":["Bash","Edit","MultiEdit","Write","NotebookEdit"]}}`
+	noBash := `{"permissions":{"deny":["Bash"]}}`
+	assert.Equal(t, []string{"--model", "opus", "--settings", readOnly}, invoker.calls[0].Opts.ExtraFlags)
+	assert.Equal(t, []string{"--model", "opus"}, invoker.calls[1].Opts.ExtraFlags)
+	assert.Equal(t, []string{"--model", "opus", "--settings", noBash}, invoker.calls[2].Opts.ExtraFlags)
+	assert.Equal(t, []string{"--model", "opus"}, invoker.calls[3].Opts.ExtraFlags)
+}
+
+func TestLoop_PhaseAccessIgnoredForOtherExecutors(t *testing.T) {
+	l := New(safety.Config{}, t.TempDir(), nil, false)
+	l.SetExecutorConfig(executor.Config{Name: "codex"})
+
+	var logs []string
+	l.SetEventCallback(func(ev event.Event) { logs = append(logs, ev.Text) })
+
+	flags := l.invocationFlags(&domain.Phase{Name: "Investigate", Access: domain.AccessReadOnly})
+	assert.Empty(t, flags)
+	require.Len(t, logs, 1)
+	assert.Contains(t, logs[0], "only the claude executor supports it")
+}
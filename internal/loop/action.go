@@ -56,6 +56,9 @@ type ReviewDecision struct {
 	Passed bool
 	// NeedsFix is true when Claude should be invoked to fix issues.
 	NeedsFix bool
+	// NotConverging is true when the review reported exactly the same issues
+	// as the previous iteration, so further fix rounds are not helping.
+	NotConverging bool
 }
 
 // SafetyCheckResult wraps the safety check outcome with exit details.
@@ -0,0 +1,363 @@
+package loop
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// What the run does when files it edited were changed by someone else
+// between invocations.
+const (
+	ConflictWarn   = "warn"   // log and note the files, and go on
+	ConflictPause  = "pause"  // warn, then wait until the files are left alone
+	ConflictIgnore = "ignore" // do not check
+)
+
+// ActiveFilesStatusPath is the status file listing the files a run is
+// editing, relative to the working directory.
+const ActiveFilesStatusPath = ".programmator/status/active-files.json"
+
+// DefaultQuietPeriod is how long files must go unchanged before a paused
+// run goes on, when ActiveFilesConfig.QuietPeriod is not set.
+const DefaultQuietPeriod = 30 * time.Second
+
+// quietPollInterval is how often a paused run checks the changed files.
+const quietPollInterval = 2 * time.Second
+
+// ActiveFilesConfig configures the hints about the files a run edits, for
+// humans working in the same checkout, and what happens when they edit
+// them too.
+type ActiveFilesConfig struct {
+	// StatusFile writes the edited files to ActiveFilesStatusPath while the
+	// run goes on.
+	StatusFile bool
+	// OnConflict is ConflictWarn (""), ConflictPause, or ConflictIgnore.
+	OnConflict string
+	// QuietPeriod is how long changed files must stay unchanged before a
+	// paused run goes on (default DefaultQuietPeriod).
+	QuietPeriod time.Duration
+}
+
+// ConcurrentEdit records files the run edited that changed outside it
+// before an invocation.
+type ConcurrentEdit struct {
+	Iteration int
+	Files     []string      // relative to the working directory, sorted
+	Paused    time.Duration // how long the run waited for them, with ConflictPause
+}
+
+// activeFile is a file the executor edited during the run.
+type activeFile struct {
+	lastEdit time.Time
+	editing  bool   // edited during the current invocation
+	sum      []byte // content hash when the run last wrote it; nil for a missing file
+}
+
+// activeFiles tracks the files the executor edits. Tool uses arrive on the
+// streaming goroutine, so it has a lock of its own.
+type activeFiles struct {
+	mu       sync.Mutex
+	files    map[string]*activeFile // by path relative to the working directory
+	workItem string                 // ID of the work item, for the status file
+	exclude  string                 // work item file: the loop writes notes to it
+}
+
+// SetActiveFiles configures the edited-files hints and conflict checks.
+func (l *Loop) SetActiveFiles(cfg ActiveFilesConfig) {
+	l.activeFilesConfig = cfg
+}
+
+// startActiveFiles resets the tracked files for the run of rc.
+func (l *Loop) startActiveFiles(rc *runContext) {
+	l.active.mu.Lock()
+	defer l.active.mu.Unlock()
+	l.active.files = make(map[string]*activeFile)
+	l.active.workItem = rc.workItemID
+	l.active.exclude = ""
+	if locator, ok := rc.source.(source.Locator); ok {
+		if path, err := locator.WorkItemFile(rc.workItemID); err == nil {
+			l.active.exclude = l.activeRelPath(path)
+		}
+	}
+}
+
+// editedFilePath returns the file a file-editing tool use writes, or "".
+func editedFilePath(name string, input any) string {
+	inputMap, ok := input.(map[string]any)
+	if !ok {
+		return ""
+	}
+	switch name {
+	case "Write", "Edit", "MultiEdit":
+		path, _ := inputMap["file_path"].(string)
+		return path
+	case "NotebookEdit":
+		path, _ := inputMap["notebook_path"].(string)
+		return path
+	}
+	return ""
+}
+
+// trackToolUse records the file a tool use edits as active, shows it in
+// the footer, and updates the status file. Files changed by shell commands
+// are not seen here.
+func (l *Loop) trackToolUse(name string, input any) {
+	path := editedFilePath(name, input)
+	if path == "" {
+		return
+	}
+	rel := l.activeRelPath(path)
+
+	l.active.mu.Lock()
+	if l.active.files == nil || rel == l.active.exclude {
+		l.active.mu.Unlock()
+		return
+	}
+	f := l.active.files[rel]
+	if f == nil {
+		f = &activeFile{}
+		l.active.files[rel] = f
+	}
+	f.lastEdit = time.Now()
+	f.editing = true
+	editing := l.active.editingLocked()
+	l.writeActiveFilesStatusLocked()
+	l.active.mu.Unlock()
+
+	if l.currentState != nil {
+		l.currentState.ActiveFiles = editing
+		l.notifyStateChange()
+	}
+}
+
+// endActiveInvocation clears the files being edited once an invocation
+// ends, and records the content of every edited file, so the next
+// invocation can tell whether someone else changed them.
+func (l *Loop) endActiveInvocation() {
+	l.active.mu.Lock()
+	if l.active.files == nil {
+		l.active.mu.Unlock()
+		return
+	}
+	for rel, f := range l.active.files {
+		f.editing = false
+		f.sum = l.activeFileSum(rel)
+	}
+	l.writeActiveFilesStatusLocked()
+	l.active.mu.Unlock()
+
+	if l.currentState != nil && l.currentState.ActiveFiles != nil {
+		l.currentState.ActiveFiles = nil
+		l.notifyStateChange()
+	}
+}
+
+// refreshActiveFiles records the content of the edited files again after
+// the run itself wrote to them between invocations: review patches,
+// commits (whose hooks may rewrite files), cherry-picked parallel phases,
+// or a rebase. Without it their changes would pass for someone else's.
+func (l *Loop) refreshActiveFiles() {
+	l.active.mu.Lock()
+	defer l.active.mu.Unlock()
+	for rel, f := range l.active.files {
+		if !f.editing {
+			f.sum = l.activeFileSum(rel)
+		}
+	}
+}
+
+// checkConcurrentEdits looks for files the run edited that changed since
+// the last invocation ended, or since the run last wrote to them itself
+// (see refreshActiveFiles): someone else's work. They are logged and
+// noted, and with ConflictPause the run waits until they have not changed
+// for the quiet period. Their new content is the executor's starting point from then on.
+// Returns false when the run was stopped while waiting.
+func (l *Loop) checkConcurrentEdits(rc *runContext) bool {
+	if l.activeFilesConfig.OnConflict == ConflictIgnore {
+		return true
+	}
+	changed := l.changedActiveFiles()
+	if len(changed) == 0 {
+		return true
+	}
+
+	edit := ConcurrentEdit{Iteration: rc.state.Iteration, Files: changed}
+	l.log(fmt.Sprintf("Warning: %s changed outside the run since the last invocation; the executor will work on the new content",
+		strings.Join(changed, ", ")))
+	l.addNote(rc, notes.Phase, fmt.Sprintf("warning: [iter %d] Files edited by the run changed outside it: %s",
+		rc.state.Iteration, strings.Join(changed, ", ")))
+
+	ok := true
+	if l.activeFilesConfig.OnConflict == ConflictPause {
+		quiet := cmp.Or(l.activeFilesConfig.QuietPeriod, DefaultQuietPeriod)
+		l.log(fmt.Sprintf("Pausing until they are left alone for %s", quiet.Round(time.Second)))
+		start := time.Now()
+		ok = l.waitQuiet(rc, changed, quiet)
+		edit.Paused = time.Since(start)
+	}
+	rc.result.ConcurrentEdits = append(rc.result.ConcurrentEdits, edit)
+	l.refreshActiveFiles()
+	return ok
+}
+
+// changedActiveFiles returns the edited files whose content differs from
+// when the run last wrote them, sorted.
+func (l *Loop) changedActiveFiles() []string {
+	l.active.mu.Lock()
+	defer l.active.mu.Unlock()
+	var changed []string
+	for rel, f := range l.active.files {
+		if f.editing {
+			continue
+		}
+		if !bytes.Equal(f.sum, l.activeFileSum(rel)) {
+			changed = append(changed, rel)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// waitQuiet waits until files have not changed for quiet. It returns false
+// when the run was stopped while waiting.
+func (l *Loop) waitQuiet(rc *runContext, files []string, quiet time.Duration) bool {
+	sums := func() map[string]string {
+		m := make(map[string]string, len(files))
+		for _, rel := range files {
+			m[rel] = string(l.activeFileSum(rel))
+		}
+		return m
+	}
+	last := sums()
+	quietSince := time.Now()
+	for time.Since(quietSince) < quiet {
+		if !sleepContext(rc.ctx, min(quietPollInterval, quiet)) {
+			return false
+		}
+		if now := sums(); !maps.Equal(now, last) {
+			last = now
+			quietSince = time.Now()
+		}
+	}
+	return true
+}
+
+// stopActiveFiles removes the status file when the run ends.
+func (l *Loop) stopActiveFiles() {
+	l.active.mu.Lock()
+	defer l.active.mu.Unlock()
+	l.active.files = nil
+	if l.activeFilesConfig.StatusFile {
+		_ = os.Remove(filepath.Join(cmp.Or(l.workingDir, "."), ActiveFilesStatusPath))
+	}
+}
+
+// editingLocked returns the files being edited in the current invocation,
+// sorted. l.active.mu must be held.
+func (a *activeFiles) editingLocked() []string {
+	var editing []string
+	for rel, f := range a.files {
+		if f.editing {
+			editing = append(editing, rel)
+		}
+	}
+	slices.Sort(editing)
+	return editing
+}
+
+// activeFilesStatus is the content of the status file.
+type activeFilesStatus struct {
+	PID      int                     `json:"pid"`
+	WorkItem string                  `json:"work_item"`
+	Updated  time.Time               `json:"updated"`
+	Files    []activeFilesStatusFile `json:"files"`
+}
+
+type activeFilesStatusFile struct {
+	Path     string    `json:"path"`
+	LastEdit time.Time `json:"last_edit"`
+	Editing  bool      `json:"editing"` // edited by the invocation running now
+}
+
+// writeActiveFilesStatusLocked writes the status file with StatusFile set.
+// Its directory ignores itself in git, so auto-commits leave it out.
+// l.active.mu must be held.
+func (l *Loop) writeActiveFilesStatusLocked() {
+	if !l.activeFilesConfig.StatusFile {
+		return
+	}
+	status := activeFilesStatus{
+		PID:      os.Getpid(),
+		WorkItem: l.active.workItem,
+		Updated:  time.Now(),
+		Files:    make([]activeFilesStatusFile, 0, len(l.active.files)),
+	}
+	for _, rel := range slices.Sorted(maps.Keys(l.active.files)) {
+		f := l.active.files[rel]
+		status.Files = append(status.Files, activeFilesStatusFile{Path: rel, LastEdit: f.lastEdit, Editing: f.editing})
+	}
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return
+	}
+
+	path := filepath.Join(cmp.Or(l.workingDir, "."), ActiveFilesStatusPath)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		l.log(fmt.Sprintf("Warning: active files status unavailable: %v", err))
+		return
+	}
+	_ = os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n"), 0o644) //nolint:gosec // not a secret
+	// Replace the file at once, so readers never see half of it.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil { //nolint:gosec // meant to be read by others
+		l.log(fmt.Sprintf("Warning: active files status unavailable: %v", err))
+		return
+	}
+	_ = os.Rename(tmp, path)
+}
+
+// activeRelPath returns path relative to the working directory, slash
+// separated; paths outside it stay absolute.
+func (l *Loop) activeRelPath(path string) string {
+	root, err := filepath.Abs(cmp.Or(l.workingDir, "."))
+	if err != nil {
+		return path
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	rel, err := filepath.Rel(root, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Clean(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// activeFileSum returns the content hash of an active file, or nil when it
+// cannot be read.
+func (l *Loop) activeFileSum(rel string) []byte {
+	path := filepath.FromSlash(rel)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cmp.Or(l.workingDir, "."), path)
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // a file the executor edited
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
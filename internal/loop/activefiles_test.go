@@ -0,0 +1,172 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestEditedFilePath(t *testing.T) {
+	assert.Equal(t, "a.go", editedFilePath("Edit", map[string]any{"file_path": "a.go"}))
+	assert.Equal(t, "a.go", editedFilePath("MultiEdit", map[string]any{"file_path": "a.go"}))
+	assert.Equal(t, "a.go", editedFilePath("Write", map[string]any{"file_path": "a.go"}))
+	assert.Equal(t, "n.ipynb", editedFilePath("NotebookEdit", map[string]any{"notebook_path": "n.ipynb"}))
+	assert.Empty(t, editedFilePath("Read", map[string]any{"file_path": "a.go"}))
+	assert.Empty(t, editedFilePath("Bash", map[string]any{"command": "touch a.go"}))
+	assert.Empty(t, editedFilePath("Edit", "a.go"))
+}
+
+// editingInvoker edits path through an Edit tool call, checking the status
+// file while it does, before answering like seq.
+type editingInvoker struct {
+	seq    *sequenceInvoker
+	path   string
+	status []activeFilesStatus // status file as seen during each invocation
+}
+
+func (i *editingInvoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
+	opts.OnToolUse("Edit", map[string]any{"file_path": i.path})
+	if err := os.WriteFile(i.path, fmt.Appendf(nil, "agent edit %d\n", len(i.status)), 0o644); err != nil {
+		return nil, err
+	}
+	var status activeFilesStatus
+	if data, err := os.ReadFile(filepath.Join(opts.WorkingDir, ActiveFilesStatusPath)); err == nil {
+		_ = json.Unmarshal(data, &status)
+	}
+	i.status = append(i.status, status)
+	return i.seq.Invoke(ctx, prompt, opts)
+}
+
+func TestLoopRun_ActiveFiles(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, workingFile := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+	invoker := &editingInvoker{
+		path: workingFile,
+		seq: newSequenceInvoker([]sequenceResponse{
+			{Status: protocol.StatusContinue, Summary: "Started", FilesChanged: []string{"working.txt"}},
+			{Status: protocol.StatusContinue, Summary: "Went on", FilesChanged: []string{"working.txt"}},
+		}),
+	}
+
+	var editing [][]string
+	l := New(safety.Config{MaxIterations: 2, StagnationLimit: 5, Timeout: 60}, dir, func(state *safety.State, _ *domain.WorkItem, _ []string) {
+		if len(state.ActiveFiles) > 0 {
+			editing = append(editing, state.ActiveFiles)
+		}
+	}, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetActiveFiles(ActiveFilesConfig{StatusFile: true})
+	// Someone edits the file between the two invocations.
+	l.SetIterationRecorder(func(rec IterationRecord) {
+		if rec.Iteration == 1 {
+			require.NoError(t, os.WriteFile(workingFile, []byte("human edit\n"), 0o644))
+		}
+	})
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonMaxIterations, result.ExitReason)
+
+	assert.Equal(t, []ConcurrentEdit{{Iteration: 2, Files: []string{"working.txt"}}}, result.ConcurrentEdits)
+
+	assert.Equal(t, [][]string{{"working.txt"}, {"working.txt"}}, editing)
+	require.Len(t, invoker.status, 2)
+	require.Len(t, invoker.status[0].Files, 1)
+	assert.Equal(t, "working.txt", invoker.status[0].Files[0].Path)
+	assert.True(t, invoker.status[0].Files[0].Editing)
+	assert.Equal(t, os.Getpid(), invoker.status[0].PID)
+	assert.NoFileExists(t, filepath.Join(dir, ActiveFilesStatusPath), "removed when the run ends")
+	assert.FileExists(t, filepath.Join(dir, ".programmator", "status", ".gitignore"))
+}
+
+func TestCheckConcurrentEdits(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	require.NoError(t, os.WriteFile(file, []byte("agent\n"), 0o644))
+	mock := source.NewMockSource()
+	var added []string
+	mock.AddNoteFunc = func(_, note string) error {
+		added = append(added, note)
+		return nil
+	}
+
+	newRun := func(ctx context.Context, cfg ActiveFilesConfig) (*Loop, *runContext) {
+		l := NewWithSource(safety.Config{MaxIterations: 10}, dir, nil, false, mock)
+		l.SetActiveFiles(cfg)
+		rc := &runContext{ctx: ctx, workItemID: "t-1", source: mock, state: safety.NewState(), result: &Result{}}
+		l.startActiveFiles(rc)
+		l.trackToolUse("Write", map[string]any{"file_path": file})
+		l.endActiveInvocation()
+		rc.state.Iteration = 2
+		return l, rc
+	}
+
+	l, rc := newRun(context.Background(), ActiveFilesConfig{})
+	require.True(t, l.checkConcurrentEdits(rc), "nothing changed")
+	require.Empty(t, rc.result.ConcurrentEdits)
+
+	require.NoError(t, os.WriteFile(file, []byte("human\n"), 0o644))
+	require.True(t, l.checkConcurrentEdits(rc))
+	require.Equal(t, []ConcurrentEdit{{Iteration: 2, Files: []string{"a.go"}}}, rc.result.ConcurrentEdits)
+	require.Len(t, added, 1)
+	assert.True(t, strings.HasPrefix(added[0], "warning: [iter 2]"), added[0])
+	require.True(t, l.checkConcurrentEdits(rc), "the new content is the baseline")
+	require.Len(t, rc.result.ConcurrentEdits, 1)
+
+	// The run's own writes between invocations (patches, commit hooks) are
+	// not someone else's.
+	require.NoError(t, os.WriteFile(file, []byte("patched\n"), 0o644))
+	l.refreshActiveFiles()
+	require.True(t, l.checkConcurrentEdits(rc))
+	require.Len(t, rc.result.ConcurrentEdits, 1)
+
+	l, rc = newRun(context.Background(), ActiveFilesConfig{OnConflict: ConflictIgnore})
+	require.NoError(t, os.WriteFile(file, []byte("human again\n"), 0o644))
+	require.True(t, l.checkConcurrentEdits(rc))
+	require.Empty(t, rc.result.ConcurrentEdits)
+
+	l, rc = newRun(context.Background(), ActiveFilesConfig{OnConflict: ConflictPause, QuietPeriod: 50 * time.Millisecond})
+	require.NoError(t, os.WriteFile(file, []byte("human once more\n"), 0o644))
+	require.True(t, l.checkConcurrentEdits(rc))
+	require.Len(t, rc.result.ConcurrentEdits, 1)
+	assert.GreaterOrEqual(t, rc.result.ConcurrentEdits[0].Paused, 50*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l, rc = newRun(ctx, ActiveFilesConfig{OnConflict: ConflictPause, QuietPeriod: time.Hour})
+	require.NoError(t, os.WriteFile(file, []byte("human, still\n"), 0o644))
+	require.False(t, l.checkConcurrentEdits(rc), "stopped while paused")
+}
+
+func TestTrackToolUse_SkipsWorkItemFile(t *testing.T) {
+	dir := t.TempDir()
+	planPath := filepath.Join(dir, "plan.md")
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan: Test\n\n## Tasks\n- [ ] Task\n"), 0o644))
+	src := source.NewPlanSource(planPath)
+	l := NewWithSource(safety.Config{MaxIterations: 10}, dir, nil, false, src)
+	rc := &runContext{ctx: context.Background(), workItemID: planPath, source: src, state: safety.NewState(), result: &Result{}}
+	l.startActiveFiles(rc)
+
+	l.trackToolUse("Edit", map[string]any{"file_path": planPath})
+	l.trackToolUse("Edit", map[string]any{"file_path": "src/main.go"})
+	l.active.mu.Lock()
+	defer l.active.mu.Unlock()
+	assert.Equal(t, []string{"src/main.go"}, l.active.editingLocked())
+}
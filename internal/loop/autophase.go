@@ -0,0 +1,129 @@
+package loop
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// maxPlannedPhases caps how many phases auto-phasing may add.
+const maxPlannedPhases = 12
+
+// phasePlanRegex matches PHASE_PLAN: blocks in executor output.
+var phasePlanRegex = regexp.MustCompile(`(?s)` + protocol.PhasePlanBlockKey + `:\s*\n(.*?)(?:\n\s*\x60{3}|$)`)
+
+// SetAutoPhasing enables planning phaseless work items: before the first
+// iteration, the executor proposes phases, which are written into the work
+// item as a checklist under a "Plan" heading, and the run proceeds phase by
+// phase. Only sources implementing source.PhaseAdder are planned.
+func (l *Loop) SetAutoPhasing(enabled bool) {
+	l.autoPhasing = enabled
+}
+
+// planPhases runs the planning invocation for a phaseless work item and
+// reloads it with the proposed phases. Failures are logged and leave the
+// work item phaseless.
+func (l *Loop) planPhases(rc *runContext) {
+	if !l.autoPhasing || rc.workItem.HasPhases() {
+		return
+	}
+	adder, ok := rc.source.(source.PhaseAdder)
+	if !ok {
+		l.log(fmt.Sprintf("Auto-phasing skipped: %s sources cannot add phases", rc.source.Type()))
+		return
+	}
+
+	l.log("Planning phases for phaseless work item")
+	phases, err := l.invokePhasePlan(rc)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: phase planning failed, continuing without phases: %v", err))
+		return
+	}
+	if err := adder.AddPhases(rc.workItemID, phases); err != nil {
+		l.log(fmt.Sprintf("Warning: failed to write planned phases: %v", err))
+		return
+	}
+
+	item, err := rc.source.Get(rc.workItemID)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: failed to reload work item after planning: %v", err))
+		return
+	}
+	rc.workItem = item
+	l.log(fmt.Sprintf("Planned %d phases", len(phases)))
+	l.addNote(rc, notes.Phase, fmt.Sprintf("progress: Planned %d phases: %s", len(phases), strings.Join(phases, "; ")))
+}
+
+// invokePhasePlan runs the planning prompt, in an invocation that may not
+// change files, and parses the phases.
+func (l *Loop) invokePhasePlan(rc *runContext) ([]string, error) {
+	inv, err := l.resolveInvoker()
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := l.executorConfig.ReadOnlyFlags(llm.PurposePlanning)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := inv.Invoke(rc.ctx, buildPhasePlanPrompt(rc), llm.InvokeOptions{
+		WorkingDir: l.workingDir,
+		ExtraFlags: flags,
+		Purpose:    llm.PurposePlanning,
+		Timeout:    l.config.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invoke: %w", err)
+	}
+	return parsePhasePlan(out.Text)
+}
+
+// parsePhasePlan extracts the phases of a PHASE_PLAN block from output.
+func parsePhasePlan(output string) ([]string, error) {
+	phases, err := parseTaskBlock(output, phasePlanRegex, protocol.PhasePlanBlockKey, "phases")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(phases) < 2:
+		return nil, fmt.Errorf("phase plan needs at least 2 phases, got %d", len(phases))
+	case len(phases) > maxPlannedPhases:
+		return nil, fmt.Errorf("phase plan has %d phases, at most %d allowed", len(phases), maxPlannedPhases)
+	}
+	return phases, nil
+}
+
+// buildPhasePlanPrompt asks the executor to break the work item into phases.
+func buildPhasePlanPrompt(rc *runContext) string {
+	var b strings.Builder
+
+	b.WriteString("You are planning work for an autonomous coding agent. Do not modify any files. ")
+	b.WriteString("Read the task and the code it touches, then break the task into phases that can each be completed in a single iteration.\n\n")
+
+	title := rc.workItem.Title
+	if title == "" {
+		title = rc.workItemID
+	}
+	fmt.Fprintf(&b, "## Task: %s\n\n%s\n\n", title, rc.workItem.RawContent)
+
+	fmt.Fprintf(&b, `## Phases
+
+List 2 to %d phases in the order they should be done. Each is one line that
+names a concrete, verifiable change. Together they must cover the whole task.
+
+Respond with exactly one block:
+`+"```yaml"+`
+`+protocol.PhasePlanBlockKey+`:
+  phases:
+    - 'First phase'
+    - 'Second phase'
+`+"```", maxPlannedPhases)
+
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package loop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/plan"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestParsePhasePlan(t *testing.T) {
+	phases, err := parsePhasePlan("```yaml\nPHASE_PLAN:\n  phases:\n    - 'Add the parser'\n    - 'Wire it   up'\n```")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Add the parser", "Wire it up"}, phases)
+
+	_, err = parsePhasePlan("PHASE_PLAN:\n  phases: ['Everything']\n")
+	require.ErrorContains(t, err, "at least 2 phases")
+	_, err = parsePhasePlan("PHASE_PLAN:\n  phases: [a, b, c, d, e, f, g, h, i, j, k, l, m]\n")
+	require.ErrorContains(t, err, "at most 12")
+	_, err = parsePhasePlan("no plan")
+	require.ErrorContains(t, err, "no PHASE_PLAN found")
+}
+
+func newAutoPhasingLoop(t *testing.T, dir, planPath string, inv *splittingInvoker) *Loop {
+	t.Helper()
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 5, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(inv)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetAutoPhasing(true)
+	return l
+}
+
+func TestLoopRun_PlansPhaselessWorkItem(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath := filepath.Join(dir, "plan.md")
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan: Parser\n\nWrite a parser for the config format.\n"), 0o644))
+	workingFile := filepath.Join(dir, "parser.go")
+
+	inv := &splittingInvoker{
+		sequenceInvoker: newSequenceInvoker([]sequenceResponse{
+			{PhaseCompleted: "Add the parser", Status: protocol.StatusContinue, FilesChanged: []string{"parser.go"},
+				Summary: "Parser", FileEdits: map[string]string{workingFile: "parser\n"}},
+			{PhaseCompleted: "Wire it up", Status: protocol.StatusDone, FilesChanged: []string{"parser.go"},
+				Summary: "Wired", FileEdits: map[string]string{workingFile: "wired\n"}},
+		}),
+		split: "PHASE_PLAN:\n  phases: ['Add the parser', 'Wire it up']\n",
+	}
+
+	result, err := newAutoPhasingLoop(t, dir, planPath, inv).Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	require.Len(t, inv.splitPrompts, 1)
+	assert.Contains(t, inv.splitPrompts[0], "Write a parser for the config format.")
+	assert.Contains(t, inv.splitFlags[0], "--settings", "claude plans read-only")
+	require.Len(t, inv.calls, 2)
+	assert.Contains(t, inv.calls[0].Prompt, "Add the parser")
+
+	updated, err := plan.ParseFile(planPath)
+	require.NoError(t, err)
+	assert.Contains(t, updated.RawContent, "## Plan\n\n- [x] Add the parser\n- [x] Wire it up\n")
+	assert.True(t, updated.AllTasksComplete())
+}
+
+func TestLoopRun_AutoPhasingFailureStaysPhaseless(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath := filepath.Join(dir, "plan.md")
+	content := "# Plan: Parser\n\nWrite a parser.\n"
+	require.NoError(t, os.WriteFile(planPath, []byte(content), 0o644))
+
+	inv := &splittingInvoker{
+		sequenceInvoker: newSequenceInvoker([]sequenceResponse{
+			{Status: protocol.StatusDone, Summary: "Done", FilesChanged: []string{"parser.go"},
+				FileEdits: map[string]string{filepath.Join(dir, "parser.go"): "parser\n"}},
+		}),
+		split: "PHASE_PLAN:\n  phases: ['Everything']\n",
+	}
+
+	result, err := newAutoPhasingLoop(t, dir, planPath, inv).Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+	require.Len(t, inv.calls, 1)
+
+	data, err := os.ReadFile(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func TestPlanPhases_SkipsWorkItemsWithPhases(t *testing.T) {
+	l := New(safety.Config{}, t.TempDir(), nil, false)
+	l.SetAutoPhasing(true)
+
+	src := source.NewMockSource()
+	rc := &runContext{
+		source:   src,
+		state:    safety.NewState(),
+		workItem: &domain.WorkItem{Phases: []domain.Phase{{Name: "Existing"}}},
+	}
+	l.planPhases(rc)
+	assert.Len(t, rc.workItem.Phases, 1)
+}
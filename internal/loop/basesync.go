@@ -0,0 +1,63 @@
+package loop
+
+import (
+	"fmt"
+)
+
+// Base-branch sync modes for GitWorkflowConfig.BaseSync.
+const (
+	BaseSyncOff    = "off"
+	BaseSyncWarn   = "warn"
+	BaseSyncRebase = "rebase"
+)
+
+// syncBaseBranch checks, before the first iteration, that the branch is up
+// to date with its base: the base is fetched from the remote, a branch
+// behind it is reported, and in rebase mode the branch is rebased onto it.
+// Returns the commit the branch now shares with the base, or "" when the
+// check is off or the base could not be resolved.
+func (l *Loop) syncBaseBranch() string {
+	cfg := l.gitConfig
+	if l.gitRepo == nil || cfg.BaseBranch == "" || cfg.BaseSync == "" || cfg.BaseSync == BaseSyncOff {
+		return ""
+	}
+
+	ref := cfg.BaseBranch
+	remote := cfg.PushRemote
+	if remote == "" {
+		remote = "origin"
+	}
+	if l.gitRepo.HasRemote(remote) {
+		if err := l.gitRepo.FetchBranch(remote, cfg.BaseBranch); err != nil {
+			l.log(fmt.Sprintf("Warning: base branch fetch failed, comparing with the last fetched state: %v", err))
+		}
+		ref = remote + "/" + cfg.BaseBranch
+	}
+
+	status, err := l.gitRepo.CompareWithBase(ref)
+	if err != nil {
+		l.log(fmt.Sprintf("Skipping base branch check: %v", err))
+		return ""
+	}
+	if status.Behind == 0 {
+		l.log(fmt.Sprintf("Branch is up to date with %s", ref))
+		return status.MergeBase
+	}
+
+	behind := fmt.Sprintf("Branch is %d commit(s) behind %s", status.Behind, ref)
+	if cfg.BaseSync != BaseSyncRebase {
+		l.log(fmt.Sprintf("Warning: %s; rebase before starting or set git.base_sync: rebase", behind))
+		return status.MergeBase
+	}
+	if dirty, err := l.gitRepo.HasTrackedChanges(); err != nil || dirty {
+		l.log(fmt.Sprintf("Warning: %s; not rebasing over uncommitted changes", behind))
+		return status.MergeBase
+	}
+	if err := l.gitRepo.Rebase(ref); err != nil {
+		l.log(fmt.Sprintf("Warning: %s; rebase failed, continuing without it: %v", behind, err))
+		return status.MergeBase
+	}
+	l.refreshActiveFiles()
+	l.log(fmt.Sprintf("%s; rebased onto %s", behind, ref))
+	return status.SHA
+}
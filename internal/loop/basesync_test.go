@@ -0,0 +1,93 @@
+package loop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// setupBehindRepo returns a repo whose branch is one commit behind
+// origin/main, and the SHA of origin/main.
+func setupBehindRepo(t *testing.T) (string, string) {
+	t.Helper()
+	dir, cleanup := setupTestRepo(t)
+	t.Cleanup(cleanup)
+
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	gitOutput(t, dir, "init", "-q", "--bare", remoteDir)
+	gitOutput(t, dir, "remote", "add", "origin", remoteDir)
+	gitOutput(t, dir, "push", "-q", "origin", "HEAD:refs/heads/main")
+
+	other := filepath.Join(t.TempDir(), "other")
+	gitOutput(t, dir, "clone", "-q", "-b", "main", remoteDir, other)
+	gitOutput(t, other, "config", "user.email", "other@example.com")
+	gitOutput(t, other, "config", "user.name", "Other")
+	require.NoError(t, os.WriteFile(filepath.Join(other, "upstream.txt"), []byte("upstream\n"), 0644))
+	gitOutput(t, other, "add", "upstream.txt")
+	gitOutput(t, other, "commit", "-q", "-m", "Upstream change")
+	gitOutput(t, other, "push", "-q")
+
+	return dir, gitOutput(t, other, "rev-parse", "HEAD")
+}
+
+func TestSyncBaseBranch(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		wantRebased bool
+		wantBase    bool // false: empty BaseSHA
+	}{
+		{name: "off", mode: BaseSyncOff},
+		{name: "warn leaves the branch alone", mode: BaseSyncWarn, wantBase: true},
+		{name: "rebase onto the base", mode: BaseSyncRebase, wantRebased: true, wantBase: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, upstream := setupBehindRepo(t)
+			start := gitOutput(t, dir, "rev-parse", "HEAD")
+
+			repo, err := gitutil.NewRepo(dir)
+			require.NoError(t, err)
+			l := New(safety.Config{MaxIterations: 10}, dir, nil, false)
+			l.gitRepo = repo
+			l.SetGitWorkflowConfig(GitWorkflowConfig{BaseBranch: "main", BaseSync: tc.mode})
+
+			base := l.syncBaseBranch()
+
+			head := gitOutput(t, dir, "rev-parse", "HEAD")
+			switch {
+			case tc.wantRebased:
+				assert.Equal(t, upstream, base)
+				assert.Equal(t, upstream, head)
+			case tc.wantBase:
+				assert.Equal(t, start, base)
+				assert.Equal(t, start, head)
+			default:
+				assert.Empty(t, base)
+				assert.Equal(t, start, head)
+			}
+		})
+	}
+}
+
+func TestSyncBaseBranch_SkipsRebaseWithTrackedChanges(t *testing.T) {
+	dir, _ := setupBehindRepo(t)
+	start := gitOutput(t, dir, "rev-parse", "HEAD")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("edited\n"), 0644))
+
+	repo, err := gitutil.NewRepo(dir)
+	require.NoError(t, err)
+	l := New(safety.Config{MaxIterations: 10}, dir, nil, false)
+	l.gitRepo = repo
+	l.SetGitWorkflowConfig(GitWorkflowConfig{BaseBranch: "main", BaseSync: BaseSyncRebase})
+
+	assert.Equal(t, start, l.syncBaseBranch())
+	assert.Equal(t, start, gitOutput(t, dir, "rev-parse", "HEAD"))
+}
@@ -0,0 +1,39 @@
+package loop
+
+import (
+	"fmt"
+
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// SetResumeCheckpoint makes the run continue the iteration and token counts
+// of earlier sessions of the same work item, so that MaxIterations limits
+// the work item's lifetime instead of each session.
+func (l *Loop) SetResumeCheckpoint(cp safety.Checkpoint) {
+	l.resumeCheckpoint = &cp
+}
+
+// SetCheckpointRecorder sets a callback receiving the cumulative counts of
+// the run before every iteration and when the run ends.
+func (l *Loop) SetCheckpointRecorder(fn func(safety.Checkpoint)) {
+	l.onCheckpoint = fn
+}
+
+// resumeCounts applies the resume checkpoint to a new run's state.
+func (l *Loop) resumeCounts(rc *runContext) {
+	rc.sessions = 1
+	cp := l.resumeCheckpoint
+	if cp == nil || cp.Iterations == 0 {
+		return
+	}
+	rc.state.Resume(*cp)
+	rc.sessions = cp.Sessions + 1
+	l.log(fmt.Sprintf("Continuing from %d iterations of earlier sessions", cp.Iterations))
+}
+
+// recordCheckpoint hands the run's cumulative counts to the recorder.
+func (l *Loop) recordCheckpoint(rc *runContext) {
+	if l.onCheckpoint != nil {
+		l.onCheckpoint(rc.state.Checkpoint(rc.sessions))
+	}
+}
@@ -0,0 +1,42 @@
+package loop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_ResumeCheckpoint(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, workingFile := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+	invoker := newSequenceInvoker([]sequenceResponse{
+		{Status: protocol.StatusContinue, Summary: "Progress", FilesChanged: []string{"working.txt"}, FileEdits: map[string]string{workingFile: "one\n"}},
+		{Status: protocol.StatusContinue, Summary: "More", FilesChanged: []string{"working.txt"}, FileEdits: map[string]string{workingFile: "two\n"}},
+	})
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 5, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetResumeCheckpoint(safety.Checkpoint{Iterations: 9, Sessions: 1})
+	var checkpoints []safety.Checkpoint
+	l.SetCheckpointRecorder(func(cp safety.Checkpoint) { checkpoints = append(checkpoints, cp) })
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+
+	// Nine iterations of the earlier session leave room for one.
+	assert.Equal(t, safety.ExitReasonMaxIterations, result.ExitReason)
+	assert.Len(t, invoker.Calls(), 1)
+	require.NotEmpty(t, checkpoints)
+	assert.Equal(t, 9, checkpoints[0].Iterations)
+	last := checkpoints[len(checkpoints)-1]
+	assert.Equal(t, 11, last.Iterations)
+	assert.Equal(t, 2, last.Sessions)
+}
@@ -0,0 +1,64 @@
+package loop
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+)
+
+// contextFileLimit caps how many bytes of a referenced context file are
+// inlined into the prompt. Larger files are cut to an excerpt and the
+// executor is told to read the rest itself.
+const contextFileLimit = 16000
+
+// loadContextFiles reads the files referenced by the work item's context
+// annotations, relative to the working directory. Missing files are reported
+// once per run and skipped.
+func (l *Loop) loadContextFiles(rc *runContext) {
+	refs := rc.workItem.ContextRefs()
+	if len(refs) == 0 {
+		return
+	}
+
+	files := make([]domain.ContextFile, 0, len(refs))
+	for _, ref := range refs {
+		path := ref
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(l.workingDir, path)
+		}
+		data, err := os.ReadFile(path) //nolint:gosec // path referenced by the work item
+		if err != nil {
+			if !rc.missingContext[ref] {
+				if rc.missingContext == nil {
+					rc.missingContext = make(map[string]bool)
+				}
+				rc.missingContext[ref] = true
+				l.log(fmt.Sprintf("Warning: context file %s: %v", ref, err))
+			}
+			continue
+		}
+
+		file := domain.ContextFile{Path: ref, Content: string(data), Size: len(data)}
+		if len(data) > contextFileLimit {
+			file.Content = string(data[:runeCut(data, contextFileLimit)])
+			file.Truncated = true
+		}
+		files = append(files, file)
+	}
+	rc.workItem.ContextFiles = files
+}
+
+// runeCut returns the largest length of at most limit bytes that does not
+// split a UTF-8 character of data.
+func runeCut(data []byte, limit int) int {
+	if limit >= len(data) {
+		return len(data)
+	}
+	for limit > 0 && !utf8.RuneStart(data[limit]) {
+		limit--
+	}
+	return limit
+}
@@ -0,0 +1,56 @@
+package loop
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestLoadContextFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "design.md"), []byte("design"), 0o600))
+	big := strings.Repeat("x", contextFileLimit+10)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "big.txt"), []byte(big), 0o600))
+
+	l := New(safety.Config{}, dir, nil, false)
+	rc := &runContext{workItem: &domain.WorkItem{
+		RawContent: "<!-- context: docs/design.md, missing.md, big.txt -->",
+	}}
+
+	l.loadContextFiles(rc)
+
+	files := rc.workItem.ContextFiles
+	require.Len(t, files, 2)
+	assert.Equal(t, domain.ContextFile{Path: "docs/design.md", Content: "design", Size: 6}, files[0])
+	assert.Equal(t, "big.txt", files[1].Path)
+	assert.True(t, files[1].Truncated)
+	assert.Len(t, files[1].Content, contextFileLimit)
+	assert.Equal(t, len(big), files[1].Size)
+	assert.True(t, rc.missingContext["missing.md"])
+}
+
+func TestLoadContextFiles_CutsWholeCharacters(t *testing.T) {
+	dir := t.TempDir()
+	// Two-byte characters from the second byte on, so the limit falls inside one.
+	content := "x" + strings.Repeat("é", contextFileLimit)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte(content), 0o600))
+
+	l := New(safety.Config{}, dir, nil, false)
+	rc := &runContext{workItem: &domain.WorkItem{RawContent: "<!-- context: notes.md -->"}}
+	l.loadContextFiles(rc)
+
+	require.Len(t, rc.workItem.ContextFiles, 1)
+	file := rc.workItem.ContextFiles[0]
+	assert.True(t, file.Truncated)
+	assert.True(t, utf8.ValidString(file.Content))
+	assert.Len(t, file.Content, contextFileLimit-1)
+}
@@ -0,0 +1,94 @@
+package loop
+
+import (
+	"fmt"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+)
+
+// ContextOverflow records an iteration whose prompt was estimated to exceed
+// its share of the executor's context window.
+type ContextOverflow struct {
+	Iteration  int
+	Tokens     int  // estimated tokens of the prompt as first built
+	SentTokens int  // estimated tokens of the prompt sent
+	Budget     int  // tokens the prompt may take (see prompt.Budget)
+	Truncated  bool // context the executor can read itself was left out
+}
+
+// buildPrompt builds the prompt of an iteration with builder: a
+// continuation for a resumed session, the review fix prompt while review
+// issues are pending, or the task prompt. With a nil builder, or when the
+// builder's templates fail, the embedded templates are used.
+func (l *Loop) buildPrompt(rc *runContext, builder *prompt.Builder, resumeID string) string {
+	if resumeID != "" {
+		return prompt.BuildContinuation(rc.workItem, l.statusBlockKey())
+	}
+	if builder == nil {
+		return prompt.Build(rc.workItem)
+	}
+	if l.engine.PendingReviewFix {
+		// Use review fix prompt with the stored issues so review templates apply
+		promptText, err := builder.BuildReviewFirst("", rc.result.TotalFilesChanged, l.lastReviewIssues, l.lastReviewExcerpts, l.engine.ReviewIterations, l.gitConfig.AutoCommit)
+		if err != nil {
+			l.log(fmt.Sprintf("Failed to build review fix prompt: %v, falling back to task prompt", err))
+			return prompt.Build(rc.workItem)
+		}
+		return promptText
+	}
+	promptText, err := builder.Build(rc.workItem)
+	if err != nil {
+		l.log(fmt.Sprintf("Failed to build prompt from templates: %v, falling back to defaults", err))
+		return prompt.Build(rc.workItem)
+	}
+	return promptText
+}
+
+// fitPrompt builds the prompt of an iteration and returns it with its
+// estimated tokens. A prompt over budget is rebuilt without what the
+// executor can read itself when context_overflow is "truncate".
+func (l *Loop) fitPrompt(rc *runContext, resumeID string) (string, int) {
+	promptText := l.buildPrompt(rc, l.promptBuilder, resumeID)
+	tokens := prompt.EstimateTokens(promptText)
+
+	budget := prompt.Budget(l.executorConfig.ContextWindow)
+	if budget == 0 || tokens <= budget || resumeID != "" || l.promptBuilder == nil ||
+		l.executorConfig.ContextOverflow != executor.OverflowTruncate {
+		return promptText, tokens
+	}
+	return l.buildPrompt(rc, l.promptBuilder.WithTokenBudget(budget), resumeID), tokens
+}
+
+// checkContextWindow warns about, and records in the result, a prompt
+// estimated to exceed its share of the executor's context window. tokens
+// is the estimate of the prompt as first built, before fitPrompt shrank it.
+func (l *Loop) checkContextWindow(rc *runContext, tokens int, promptText string) {
+	window := l.executorConfig.ContextWindow
+	budget := prompt.Budget(window)
+	sent := prompt.EstimateTokens(promptText)
+	if budget == 0 || max(tokens, sent) <= budget {
+		return
+	}
+
+	overflow := ContextOverflow{
+		Iteration:  rc.state.Iteration,
+		Tokens:     max(tokens, sent),
+		SentTokens: sent,
+		Budget:     budget,
+		Truncated:  sent < tokens,
+	}
+	rc.result.ContextOverflows = append(rc.result.ContextOverflows, overflow)
+
+	switch {
+	case sent <= budget:
+		l.log(fmt.Sprintf("Prompt of ~%d tokens is over half the %d-token context window; left out context files and excerpts to send ~%d",
+			overflow.Tokens, window, sent))
+	case overflow.Truncated:
+		l.log(fmt.Sprintf("Warning: prompt is ~%d tokens after leaving out context files and excerpts, over half the %d-token context window; the executor may lose track of it",
+			sent, window))
+	default:
+		l.log(fmt.Sprintf("Warning: prompt is ~%d tokens, over half the %d-token context window; the executor may lose track of it (context_overflow: truncate shrinks it)",
+			sent, window))
+	}
+}
@@ -0,0 +1,92 @@
+package loop
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestFitPrompt_ContextWindow(t *testing.T) {
+	design := strings.Repeat("Use a queue. ", 1000)
+	newRC := func() *runContext {
+		state := safety.NewState()
+		state.Iteration = 3
+		return &runContext{
+			state:  state,
+			result: &Result{},
+			workItem: &domain.WorkItem{
+				ID:           "plan.md",
+				Title:        "Queue",
+				Phases:       []domain.Phase{{Name: "Task 1"}},
+				ContextFiles: []domain.ContextFile{{Path: "docs/design.md", Content: design, Size: len(design)}},
+			},
+		}
+	}
+	builder, err := prompt.NewBuilder(nil)
+	require.NoError(t, err)
+
+	t.Run("within the window", func(t *testing.T) {
+		l := New(safety.Config{}, t.TempDir(), nil, false)
+		l.SetPromptBuilder(builder)
+		l.SetExecutorConfig(executor.Config{ContextWindow: 200000})
+		rc := newRC()
+
+		text, tokens := l.fitPrompt(rc, "")
+		l.checkContextWindow(rc, tokens, text)
+		assert.Contains(t, text, design)
+		assert.Empty(t, rc.result.ContextOverflows)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		l := New(safety.Config{}, t.TempDir(), nil, false)
+		l.SetPromptBuilder(builder)
+		l.SetExecutorConfig(executor.Config{ContextWindow: 4000})
+		rc := newRC()
+
+		text, tokens := l.fitPrompt(rc, "")
+		l.checkContextWindow(rc, tokens, text)
+		assert.Contains(t, text, design)
+		require.Len(t, rc.result.ContextOverflows, 1)
+		overflow := rc.result.ContextOverflows[0]
+		assert.Equal(t, 3, overflow.Iteration)
+		assert.Equal(t, 2000, overflow.Budget)
+		assert.Equal(t, prompt.EstimateTokens(text), overflow.SentTokens)
+		assert.False(t, overflow.Truncated)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		l := New(safety.Config{}, t.TempDir(), nil, false)
+		l.SetPromptBuilder(builder)
+		l.SetExecutorConfig(executor.Config{ContextWindow: 4000, ContextOverflow: executor.OverflowTruncate})
+		rc := newRC()
+
+		text, tokens := l.fitPrompt(rc, "")
+		l.checkContextWindow(rc, tokens, text)
+		assert.NotContains(t, text, design)
+		assert.Contains(t, text, "- docs/design.md (13000 bytes)")
+		require.Len(t, rc.result.ContextOverflows, 1)
+		overflow := rc.result.ContextOverflows[0]
+		assert.True(t, overflow.Truncated)
+		assert.Greater(t, overflow.Tokens, overflow.Budget)
+		assert.LessOrEqual(t, overflow.SentTokens, overflow.Budget)
+	})
+
+	t.Run("no window", func(t *testing.T) {
+		l := New(safety.Config{}, t.TempDir(), nil, false)
+		l.SetPromptBuilder(builder)
+		l.SetExecutorConfig(executor.Config{ContextOverflow: executor.OverflowTruncate})
+		rc := newRC()
+
+		text, tokens := l.fitPrompt(rc, "")
+		l.checkContextWindow(rc, tokens, text)
+		assert.Contains(t, text, design)
+		assert.Empty(t, rc.result.ContextOverflows)
+	})
+}
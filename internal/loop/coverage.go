@@ -0,0 +1,345 @@
+package loop
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// coverageCommitMessage is used when a test-writing iteration commits its
+// changes.
+const coverageCommitMessage = "test: cover changed code"
+
+// CoverageConfig is the gate on the Go test coverage of the packages a run
+// changed, checked before the run completes.
+type CoverageConfig struct {
+	Enabled bool
+	// Threshold is the coverage, in percent of statements, a changed
+	// package may not drop below. Packages already below it before the
+	// run only may not lose coverage.
+	Threshold float64
+	// MaxFixIterations is how many test-writing iterations packages short of
+	// coverage get before the run stops as blocked.
+	MaxFixIterations int
+}
+
+// coverageMeasurer returns the test coverage of the Go packages matching
+// patterns, relative to dir, keyed by import path.
+type coverageMeasurer func(ctx context.Context, dir string, patterns []string) (map[string]float64, error)
+
+// SetCoverageGate enables the coverage gate.
+func (l *Loop) SetCoverageGate(cfg CoverageConfig) {
+	l.coverage = cfg
+}
+
+// coverageGap is a changed package whose coverage is short of the gate.
+type coverageGap struct {
+	pkg       string
+	after     float64
+	before    float64
+	hadBefore bool // the package existed, with tests measured, before the run
+}
+
+func (g coverageGap) String() string {
+	if !g.hadBefore {
+		return fmt.Sprintf("%s: %.1f%% (new package)", g.pkg, g.after)
+	}
+	return fmt.Sprintf("%s: %.1f%% (was %.1f%%)", g.pkg, g.after, g.before)
+}
+
+// recordCoverageBase remembers HEAD before the first iteration, so the gate
+// can measure the coverage the changed packages had before the run.
+func (l *Loop) recordCoverageBase(rc *runContext) {
+	if !l.coverage.Enabled {
+		return
+	}
+	if l.gitRepo == nil {
+		l.log("Warning: coverage gate disabled: not a git repository")
+		return
+	}
+	head, err := gitutil.HeadCommit(l.gitRepo.WorkDir())
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: coverage gate disabled: %v", err))
+		return
+	}
+	rc.coverageBase = head
+}
+
+// runCoverageGate checks the coverage of the changed packages before the
+// run completes. Packages short of it get test-writing iterations, up to
+// MaxFixIterations. Returns false, with the run stopped as blocked, when
+// coverage is still short after them or the diff scan flags their changes.
+// When coverage cannot be measured the
+// gate logs why and lets the run complete.
+func (l *Loop) runCoverageGate(rc *runContext) bool {
+	if rc.coverageBase == "" {
+		return true
+	}
+	for attempt := 0; ; attempt++ {
+		gaps, measured, err := l.coverageGaps(rc)
+		if err != nil {
+			l.log(fmt.Sprintf("Warning: coverage gate skipped: %v", err))
+			return true
+		}
+		if len(gaps) == 0 {
+			if measured > 0 {
+				l.log(fmt.Sprintf("Coverage gate passed for %d changed package(s)", measured))
+			}
+			return true
+		}
+
+		lines := make([]string, 0, len(gaps))
+		for _, g := range gaps {
+			lines = append(lines, g.String())
+		}
+		l.log(fmt.Sprintf("Coverage gate: %d package(s) below %.1f%%:", len(gaps), l.coverage.Threshold))
+		for _, line := range lines {
+			l.log("  " + line)
+		}
+
+		if attempt >= l.coverage.MaxFixIterations {
+			msg := "coverage gate: " + strings.Join(lines, "; ")
+			l.addNote(rc, notes.Final, fmt.Sprintf("error: Coverage below %.1f%% after %d test iteration(s): %s",
+				l.coverage.Threshold, attempt, strings.Join(lines, "; ")))
+			rc.result.ExitReason = safety.ExitReasonBlocked
+			rc.result.ExitMessage = msg
+			rc.result.Iterations = rc.state.Iteration
+			return false
+		}
+		l.addNote(rc, notes.Phase, fmt.Sprintf("warning: Coverage below %.1f%%: %s",
+			l.coverage.Threshold, strings.Join(lines, "; ")))
+		if !l.writeTests(rc, lines) {
+			return false
+		}
+	}
+}
+
+// coverageGaps measures the coverage of the changed packages and returns
+// those short of the gate, sorted by package, with the number of packages
+// measured. Coverage before the run is measured once, in a worktree at the
+// commit the run started from.
+func (l *Loop) coverageGaps(rc *runContext) ([]coverageGap, int, error) {
+	patterns := changedPackages(l.workingDir, rc.result.TotalFilesChanged)
+	if len(patterns) == 0 {
+		return nil, 0, nil
+	}
+	measure := l.measureCoverage
+	if measure == nil {
+		measure = goCoverage
+	}
+
+	if rc.coverageBefore == nil {
+		before, err := l.baseCoverage(rc, measure, patterns)
+		if err != nil {
+			return nil, 0, fmt.Errorf("measure coverage before the run: %w", err)
+		}
+		rc.coverageBefore = before
+	}
+	after, err := measure(rc.ctx, l.workingDir, patterns)
+	if err != nil {
+		return nil, 0, fmt.Errorf("measure coverage: %w", err)
+	}
+
+	var gaps []coverageGap
+	for pkg, cov := range after {
+		before, had := rc.coverageBefore[pkg]
+		if cov >= l.coverage.Threshold || (had && cov >= before) {
+			continue
+		}
+		gaps = append(gaps, coverageGap{pkg: pkg, after: cov, before: before, hadBefore: had})
+	}
+	slices.SortFunc(gaps, func(a, b coverageGap) int { return strings.Compare(a.pkg, b.pkg) })
+	return gaps, len(after), nil
+}
+
+// baseCoverage measures the packages matching patterns, among those that
+// existed then, at the commit the run started from.
+func (l *Loop) baseCoverage(rc *runContext, measure coverageMeasurer, patterns []string) (map[string]float64, error) {
+	tmp, err := os.MkdirTemp("", "programmator-coverage-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	wt := filepath.Join(tmp, "base")
+	if err := l.gitRepo.AddDetachedWorktree(wt, rc.coverageBase); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := l.gitRepo.RemoveWorktree(wt, ""); err != nil {
+			l.log(fmt.Sprintf("Warning: %v", err))
+		}
+	}()
+
+	dir := filepath.Join(wt, repoRelative(l.gitRepo.Root(), l.workingDir))
+	existing := slices.DeleteFunc(slices.Clone(patterns), func(p string) bool {
+		return len(goFiles(filepath.Join(dir, p))) == 0
+	})
+	if len(existing) == 0 {
+		return map[string]float64{}, nil
+	}
+	return measure(rc.ctx, dir, existing)
+}
+
+// writeTests invokes the executor with the tests template for the packages
+// in gaps. With auto-commit enabled the tests get their own commit.
+// Failures are logged; the gate measures coverage again either way. Returns
+// false, with the run stopped as blocked, when the diff scan flags the
+// changes made.
+func (l *Loop) writeTests(rc *runContext, gaps []string) bool {
+	builder := l.promptBuilder
+	if builder == nil {
+		var err error
+		builder, err = prompt.NewBuilder(nil)
+		if err != nil {
+			l.log(fmt.Sprintf("Warning: test iteration skipped: %v", err))
+			return true
+		}
+	}
+	promptText, err := builder.BuildTests(rc.workItem, rc.result.TotalFilesChanged, gaps, l.coverage.Threshold)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: test iteration skipped: %v", err))
+		return true
+	}
+
+	l.log("Coverage gate: writing tests for the changed code")
+	scanBase := l.diffScanHead()
+	output, err := l.invokeClaudePrint(rc.ctx, promptText, "", l.executorConfig.FlagsFor(llm.PurposeMain))
+	if l.scanDiffSince(rc, scanBase) {
+		return false
+	}
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: test iteration failed: %v", err))
+		return true
+	}
+
+	status, err := parser.ParseKey(output, l.statusBlockKey())
+	if err != nil || status == nil {
+		l.log("Warning: test iteration returned no " + l.statusBlockKey())
+		return true
+	}
+	if status.Status == protocol.StatusBlocked {
+		l.log(fmt.Sprintf("Warning: test iteration blocked: %s", status.Error))
+		return true
+	}
+
+	l.log(fmt.Sprintf("Coverage gate: %s", status.Summary))
+	l.trackFilesChanged(rc, status)
+	if err := l.autoCommitPhase(rc, coverageCommitMessage, status.FilesChanged); err != nil {
+		l.log(fmt.Sprintf("Warning: test auto-commit failed: %v", err))
+	}
+	return true
+}
+
+// changedPackages returns the package patterns ("./pkg/cache", or "." for
+// workingDir itself) of the directories holding Go files among
+// filesChanged, sorted.
+func changedPackages(workingDir string, filesChanged []string) []string {
+	var patterns []string
+	for _, f := range filesChanged {
+		if !strings.HasSuffix(f, ".go") {
+			continue
+		}
+		if filepath.IsAbs(f) {
+			rel, err := filepath.Rel(workingDir, f)
+			if err != nil || !filepath.IsLocal(rel) {
+				continue
+			}
+			f = rel
+		}
+		dir := filepath.Dir(filepath.Clean(f))
+		if !filepath.IsLocal(dir) || len(goFiles(filepath.Join(workingDir, dir))) == 0 {
+			continue
+		}
+		pattern := "./" + filepath.ToSlash(dir)
+		if dir == "." {
+			pattern = "."
+		}
+		if !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	slices.Sort(patterns)
+	return patterns
+}
+
+// goFiles returns the Go files in dir.
+func goFiles(dir string) []string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	return files
+}
+
+// repoRelative returns dir relative to the repository root, or "." when it
+// is not under it.
+func repoRelative(root, dir string) string {
+	if r, err := filepath.EvalSymlinks(root); err == nil {
+		root = r
+	}
+	if d, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = d
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "."
+	}
+	return rel
+}
+
+var coverageLineRegex = regexp.MustCompile(`coverage: ([0-9.]+)% of statements`)
+
+// goCoverage runs go test -cover on the packages matching patterns in dir.
+// Packages whose tests fail are measured too; packages that do not build
+// are left out.
+func goCoverage(ctx context.Context, dir string, patterns []string) (map[string]float64, error) {
+	cmd := exec.CommandContext(ctx, "go", append([]string{"test", "-cover", "-json"}, patterns...)...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	coverage := parseCoverage(out)
+	if err != nil && len(coverage) == 0 {
+		return nil, fmt.Errorf("go test: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return coverage, nil
+}
+
+// parseCoverage reads the coverage of each package from go test -json
+// output.
+func parseCoverage(out []byte) map[string]float64 {
+	coverage := make(map[string]float64)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var ev struct {
+			Action  string
+			Package string
+			Output  string
+		}
+		if json.Unmarshal(scanner.Bytes(), &ev) != nil || ev.Action != "output" || ev.Package == "" {
+			continue
+		}
+		if m := coverageLineRegex.FindStringSubmatch(ev.Output); m != nil {
+			if pct, err := strconv.ParseFloat(m[1], 64); err == nil {
+				coverage[ev.Package] = pct
+			}
+		}
+	}
+	return coverage
+}
@@ -0,0 +1,184 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestParseCoverage(t *testing.T) {
+	out := []byte(`{"Action":"start","Package":"example.com/cache"}
+{"Action":"output","Package":"example.com/cache","Output":"ok  \texample.com/cache\t0.01s\tcoverage: 72.5% of statements\n"}
+{"Action":"output","Package":"example.com/store","Output":"coverage: 0.0% of statements\n"}
+{"Action":"output","Package":"example.com/store","Test":"TestGet","Output":"=== RUN   TestGet\n"}
+not json
+{"Action":"pass","Package":"example.com/cache"}
+`)
+	assert.Equal(t, map[string]float64{"example.com/cache": 72.5, "example.com/store": 0}, parseCoverage(out))
+}
+
+func TestChangedPackages(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"main.go", "cache/cache.go", "cache/lru/lru.go", "docs/notes.md"} {
+		path := filepath.Join(dir, f)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+
+	got := changedPackages(dir, []string{
+		"cache/cache.go",
+		filepath.Join(dir, "cache/lru/lru.go"),
+		"main.go",
+		"cache/cache.go",
+		"docs/notes.md",
+		"gone/gone.go",
+		"../other/other.go",
+	})
+	assert.Equal(t, []string{".", "./cache", "./cache/lru"}, got)
+}
+
+func TestCoverageGap_String(t *testing.T) {
+	assert.Equal(t, "example.com/cache: 40.0% (was 55.0%)",
+		coverageGap{pkg: "example.com/cache", after: 40, before: 55, hadBefore: true}.String())
+	assert.Equal(t, "example.com/cache: 12.5% (new package)",
+		coverageGap{pkg: "example.com/cache", after: 12.5}.String())
+}
+
+func TestLoopRun_CoverageGate(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxFix          int
+		wantExit        safety.ExitReason
+		wantCalls       int
+		wantCommits     []string
+		wantMeasureRuns int
+	}{
+		{
+			name:            "tests written until coverage is met",
+			maxFix:          2,
+			wantExit:        safety.ExitReasonComplete,
+			wantCalls:       2,
+			wantCommits:     []string{coverageCommitMessage, "Task 1: Feature"},
+			wantMeasureRuns: 2,
+		},
+		{
+			name:            "blocked without test iterations",
+			maxFix:          0,
+			wantExit:        safety.ExitReasonBlocked,
+			wantCalls:       1,
+			wantCommits:     []string{"Task 1: Feature"},
+			wantMeasureRuns: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			planPath, _ := writePlanFile(t, dir, planConfig{
+				Tasks:       []string{"Task 1: Feature"},
+				CommitFiles: true,
+			})
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "cache"), 0755))
+			code := filepath.Join(dir, "cache", "cache.go")
+			test := filepath.Join(dir, "cache", "cache_test.go")
+
+			invoker := newSequenceInvoker([]sequenceResponse{
+				{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, FilesChanged: []string{"cache/cache.go"},
+					Summary: "Feature done", FileEdits: map[string]string{code: "package cache\n"}},
+				{Status: protocol.StatusDone, FilesChanged: []string{"cache/cache_test.go"},
+					Summary: "Tested cache", FileEdits: map[string]string{test: "package cache\n"}},
+			})
+
+			// The new package is at 40% until its tests are written.
+			var measured []string
+			measure := func(_ context.Context, _ string, patterns []string) (map[string]float64, error) {
+				measured = append(measured, patterns...)
+				if _, err := os.Stat(test); err == nil {
+					return map[string]float64{"example.com/cache": 90}, nil
+				}
+				return map[string]float64{"example.com/cache": 40}, nil
+			}
+
+			l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+			l.SetInvoker(invoker)
+			l.SetSource(source.NewPlanSource(planPath))
+			l.SetReviewRunner(createNoIssueReviewRunner(t))
+			l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+			l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true})
+			l.SetCoverageGate(CoverageConfig{Enabled: true, Threshold: 80, MaxFixIterations: tc.maxFix})
+			l.measureCoverage = measure
+
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantExit, result.ExitReason)
+
+			require.Equal(t, tc.wantCalls, invoker.CallCount())
+			assert.Equal(t, tc.wantCommits, getCommitMessages(t, dir))
+			assert.Len(t, measured, tc.wantMeasureRuns, "the package did not exist before the run")
+			if tc.wantCalls == 2 {
+				assert.Contains(t, invoker.calls[1].Prompt, "example.com/cache: 40.0% (new package)")
+				assert.Contains(t, result.TotalFilesChanged, "cache/cache_test.go")
+			}
+			if tc.wantExit == safety.ExitReasonBlocked {
+				assert.Equal(t, "coverage gate: example.com/cache: 40.0% (new package)", result.ExitMessage)
+			}
+		})
+	}
+}
+
+func TestLoopRun_CoverageGateDiffScan(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, _ := writePlanFile(t, dir, planConfig{
+		Tasks:       []string{"Task 1: Feature"},
+		CommitFiles: true,
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cache"), 0755))
+	code := filepath.Join(dir, "cache", "cache.go")
+	test := filepath.Join(dir, "cache", "cache_test.go")
+
+	// The test iteration also makes the code panic.
+	invoker := newSequenceInvoker([]sequenceResponse{
+		{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, FilesChanged: []string{"cache/cache.go"},
+			Summary: "Feature done", FileEdits: map[string]string{code: "package cache\n"}},
+		{Status: protocol.StatusDone, FilesChanged: []string{"cache/cache.go", "cache/cache_test.go"},
+			Summary: "Tested cache", FileEdits: map[string]string{
+				code: "package cache\n\nfunc init() { panic(\"todo\") }\n",
+				test: "package cache\n",
+			}},
+	})
+	measure := func(context.Context, string, []string) (map[string]float64, error) {
+		return map[string]float64{"example.com/cache": 40}, nil
+	}
+	scanner, err := safety.NewScanner(safety.DefaultScanRules())
+	require.NoError(t, err)
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true})
+	l.SetCoverageGate(CoverageConfig{Enabled: true, Threshold: 80, MaxFixIterations: 2})
+	l.SetDiffScanner(scanner)
+	l.measureCoverage = measure
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonBlocked, result.ExitReason)
+	assert.Contains(t, result.ExitMessage, `panic: cache/cache.go`)
+	assert.Equal(t, 2, invoker.CallCount(), "no more test iterations")
+	assert.Equal(t, []string{"Task 1: Feature"}, getCommitMessages(t, dir), "flagged tests are not committed")
+}
@@ -0,0 +1,114 @@
+package loop
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// maxFindingPhaseRunes caps the description quoted in a follow-up phase
+// name; the full text is in the work item body.
+const maxFindingPhaseRunes = 100
+
+// fileDeferredFindings files the issues of the last review as a new work
+// item when the review iteration limit ends the run with them open, so they
+// are not lost. It needs review.Config.FileDeferred and a source that
+// implements source.Filer.
+func (l *Loop) fileDeferredFindings(rc *runContext) {
+	if !l.reviewConfig.FileDeferred {
+		return
+	}
+	count := 0
+	for _, res := range l.openFindings {
+		count += len(res.Issues)
+	}
+	if count == 0 {
+		return
+	}
+	filer, ok := rc.source.(source.Filer)
+	if !ok {
+		l.log(fmt.Sprintf("Warning: %d deferred finding(s) not filed: %s sources cannot create work items", count, rc.source.Type()))
+		return
+	}
+
+	title, body, phases := deferredWorkItem(rc, l.engine.ReviewIterations, l.openFindings)
+	id, err := filer.File(title, body, phases)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: failed to file deferred findings: %v", err))
+		if id == "" {
+			return
+		}
+	}
+	rc.result.FollowUp = id
+	l.log(fmt.Sprintf("Filed %d deferred finding(s) as %s", count, id))
+	l.addNote(rc, notes.Phase, fmt.Sprintf("warning: Filed %d deferred review finding(s) as %s", count, id))
+}
+
+// recordResidualIssues keeps the issues of a review that passed under
+// review.score_threshold in the run result and notes them, so what was left
+// unfixed is on record.
+func (l *Loop) recordResidualIssues(rc *runContext, res *review.RunResult) {
+	var residual []*review.Result
+	for _, r := range res.Results {
+		if len(r.Issues) > 0 {
+			residual = append(residual, r)
+		}
+	}
+	rc.result.ResidualIssues = residual
+	if len(residual) == 0 {
+		return
+	}
+	count := countIssues(residual)
+	l.log(fmt.Sprintf("Review passed below the score threshold with %d residual issue(s), score %d", count, res.Score))
+	l.addNote(rc, notes.Final, fmt.Sprintf("review: Passed with %d residual issue(s), score %d:\n%s",
+		count, res.Score, review.FormatIssuesMarkdown(residual)))
+}
+
+// deferredWorkItem returns the title, body, and phases of the work item for
+// findings left open: one phase per issue, with the details, the run they
+// came from, and the work item they were found in, in the body.
+func deferredWorkItem(rc *runContext, reviewIterations int, results []*review.Result) (string, string, []string) {
+	name := rc.workItemID
+	if rc.workItem != nil && rc.workItem.Title != "" {
+		name = rc.workItem.Title
+	}
+	title := "Follow up on deferred review findings: " + name
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Review findings left open when run %s on %s reached its review iteration limit (%d). ",
+		rc.result.RunID, rc.workItemID, reviewIterations)
+	b.WriteString("The last fix attempt was not reviewed again, so some may already be fixed.\n\n")
+	b.WriteString(review.FormatIssuesMarkdown(results))
+
+	var phases []string
+	for _, res := range results {
+		for _, issue := range res.Issues {
+			phases = append(phases, findingPhase(issue))
+		}
+	}
+	return title, b.String(), phases
+}
+
+// findingPhase names the phase fixing issue, as "Fix [severity] file:line:
+// description", with the description cut to its first line.
+func findingPhase(issue review.Issue) string {
+	var b strings.Builder
+	b.WriteString("Fix [" + string(issue.Severity) + "] ")
+	if issue.File != "" {
+		b.WriteString(issue.File)
+		if issue.Line > 0 {
+			b.WriteString(":" + strconv.Itoa(issue.Line))
+		}
+		b.WriteString(": ")
+	}
+	desc, _, _ := strings.Cut(strings.TrimSpace(issue.Description), "\n")
+	if runes := []rune(desc); len(runes) > maxFindingPhaseRunes {
+		desc = string(runes[:maxFindingPhaseRunes-3]) + "..."
+	}
+	b.WriteString(desc)
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package loop
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// filingSource is a mock source that records the work items it files.
+type filingSource struct {
+	*source.MockSource
+	titles, bodies []string
+	phases         [][]string
+}
+
+func (s *filingSource) File(title, body string, phases []string) (string, error) {
+	s.titles = append(s.titles, title)
+	s.bodies = append(s.bodies, body)
+	s.phases = append(s.phases, phases)
+	return "follow-1", nil
+}
+
+func TestFindingPhase(t *testing.T) {
+	require.Equal(t, "Fix [high] api.go:12: Nil check missing",
+		findingPhase(review.Issue{Severity: review.SeverityHigh, File: "api.go", Line: 12, Description: "Nil check missing\nmore detail"}))
+	require.Equal(t, "Fix [low] Naming", findingPhase(review.Issue{Severity: review.SeverityLow, Description: "Naming"}))
+
+	long := findingPhase(review.Issue{Severity: review.SeverityLow, Description: strings.Repeat("x", 200)})
+	require.Len(t, []rune(strings.TrimPrefix(long, "Fix [low] ")), maxFindingPhaseRunes)
+}
+
+func TestRun_FileDeferredFindings(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		src := &filingSource{MockSource: source.NewMockSource()}
+		src.GetFunc = func(_ string) (*domain.WorkItem, error) {
+			return &domain.WorkItem{ID: "t-1", Title: "Add cache", Phases: []domain.Phase{{Name: "Phase 1", Completed: true}}}, nil
+		}
+
+		config := safety.Config{MaxIterations: 100, StagnationLimit: 50, Timeout: 60, MaxReviewIterations: 100}
+		l := NewWithSource(config, "", nil, false, src)
+		l.SetReviewConfig(review.Config{
+			MaxIterations: 2,
+			Agents:        []review.AgentConfig{{Name: "test_agent"}},
+			FileDeferred:  enabled,
+		})
+		l.SetReviewRunner(createMockReviewRunner(t, true, 1))
+		l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, _ string) (string, error) {
+			return `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: CONTINUE
+  files_changed: ["fix.go"]
+  summary: "Attempted fix"
+`, nil
+		}})
+
+		result, err := l.Run("t-1")
+		require.NoError(t, err)
+		require.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+		require.NotEmpty(t, result.RunID)
+
+		if !enabled {
+			require.Empty(t, src.titles)
+			require.Empty(t, result.FollowUp)
+			continue
+		}
+		require.Equal(t, "follow-1", result.FollowUp)
+		require.Equal(t, []string{"Follow up on deferred review findings: Add cache"}, src.titles)
+		require.Contains(t, src.bodies[0], "run "+result.RunID+" on t-1")
+		require.Contains(t, src.bodies[0], "`file.go:2`")
+		require.Equal(t, [][]string{{"Fix [high] file.go:2: Issue 1"}}, src.phases)
+	}
+}
+
+func TestRun_ResidualIssuesBelowScoreThreshold(t *testing.T) {
+	src := source.NewMockSource()
+	src.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{ID: "t-1", Title: "Add cache", Phases: []domain.Phase{{Name: "Phase 1", Completed: true}}}, nil
+	}
+
+	runner := review.NewRunner(review.Config{
+		MaxIterations:  2,
+		ScoreThreshold: 6,
+		Agents:         []review.AgentConfig{{Name: "test_agent"}},
+	})
+	runner.SetAgentFactory(func(cfg review.AgentConfig, _ string) review.Agent {
+		mock := review.NewMockAgent(cfg.Name)
+		mock.SetReviewFunc(func(context.Context, string, []string) (*review.Result, error) {
+			return &review.Result{AgentName: cfg.Name, Issues: []review.Issue{
+				{File: "file.go", Line: 2, Severity: review.SeverityHigh, Description: "Nil check missing"},
+			}}, nil
+		})
+		return mock
+	})
+
+	config := safety.Config{MaxIterations: 100, StagnationLimit: 50, Timeout: 60, MaxReviewIterations: 100}
+	l := NewWithSource(config, "", nil, false, src)
+	l.SetReviewConfig(review.Config{MaxIterations: 2, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetReviewRunner(runner)
+	invoked := false
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, _ string) (string, error) {
+		invoked = true
+		return "", nil
+	}})
+
+	result, err := l.Run("t-1")
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+	require.False(t, invoked, "no fix iteration for a review under the threshold")
+	require.Len(t, result.ResidualIssues, 1)
+	require.Equal(t, "Nil check missing", result.ResidualIssues[0].Issues[0].Description)
+}
@@ -0,0 +1,80 @@
+package loop
+
+import (
+	"fmt"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+)
+
+// docsCommitMessage is used when the docs phase commits its changes.
+const docsCommitMessage = "docs: update documentation"
+
+// SetDocsPhase enables the post-completion docs phase, which asks the
+// executor to update documentation for the files changed during the run.
+func (l *Loop) SetDocsPhase(enabled bool) {
+	l.docsPhase = enabled
+}
+
+// runDocsPhase invokes the executor with the docs template once all phases
+// and review are complete. With auto-commit enabled the updates get their own
+// commit. Failures are logged and never fail the run; it returns false, with
+// the run stopped as blocked, only when the diff scan flags the changes made.
+func (l *Loop) runDocsPhase(rc *runContext) bool {
+	if !l.docsPhase || len(rc.result.TotalFilesChanged) == 0 {
+		return true
+	}
+
+	builder := l.promptBuilder
+	if builder == nil {
+		var err error
+		builder, err = prompt.NewBuilder(nil)
+		if err != nil {
+			l.log(fmt.Sprintf("Warning: docs phase skipped: %v", err))
+			return true
+		}
+	}
+	promptText, err := builder.BuildDocs(rc.workItem, rc.result.TotalFilesChanged)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: docs phase skipped: %v", err))
+		return true
+	}
+
+	l.log("Docs phase: updating documentation for changed files")
+	scanBase := l.diffScanHead()
+	output, err := l.invokeClaudePrint(rc.ctx, promptText, "", l.executorConfig.FlagsFor(llm.PurposeMain))
+	if l.scanDiffSince(rc, scanBase) {
+		return false
+	}
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: docs phase failed: %v", err))
+		l.addNote(rc, notes.Phase, fmt.Sprintf("warning: Docs phase failed: %v", err))
+		return true
+	}
+
+	status, err := parser.ParseKey(output, l.statusBlockKey())
+	if err != nil || status == nil {
+		l.log("Warning: docs phase returned no " + l.statusBlockKey())
+		return true
+	}
+	if status.Status == protocol.StatusBlocked {
+		l.log(fmt.Sprintf("Warning: docs phase blocked: %s", status.Error))
+		l.addNote(rc, notes.Phase, fmt.Sprintf("warning: Docs phase blocked: %s", status.Error))
+		return true
+	}
+
+	l.log(fmt.Sprintf("Docs phase: %s", status.Summary))
+	l.trackFilesChanged(rc, status)
+	if len(status.FilesChanged) == 0 {
+		return true
+	}
+	l.addNote(rc, notes.Phase, fmt.Sprintf("progress: Docs updated: %s", status.Summary))
+
+	if err := l.autoCommitPhase(rc, docsCommitMessage, status.FilesChanged); err != nil {
+		l.log(fmt.Sprintf("Warning: docs auto-commit failed: %v", err))
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package loop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_DocsPhase(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		wantCalls   int
+		wantCommits []string
+	}{
+		{
+			name:        "enabled commits docs separately",
+			enabled:     true,
+			wantCalls:   2,
+			wantCommits: []string{docsCommitMessage, "Task 1: Feature"},
+		},
+		{
+			name:        "disabled",
+			enabled:     false,
+			wantCalls:   1,
+			wantCommits: []string{"Task 1: Feature"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			planPath, workingFile := writePlanFile(t, dir, planConfig{
+				Tasks:       []string{"Task 1: Feature"},
+				CommitFiles: true,
+			})
+			readme := filepath.Join(dir, "README.md")
+
+			invoker := newSequenceInvoker([]sequenceResponse{
+				{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+					Summary: "Feature done", FileEdits: map[string]string{workingFile: "feature\n"}},
+				{Status: protocol.StatusDone, FilesChanged: []string{"README.md"},
+					Summary: "Documented feature", FileEdits: map[string]string{readme: "# Feature\n"}},
+			})
+
+			l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+			l.SetInvoker(invoker)
+			l.SetSource(source.NewPlanSource(planPath))
+			l.SetReviewRunner(createNoIssueReviewRunner(t))
+			l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+			l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true})
+			l.SetDocsPhase(tc.enabled)
+
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+			require.Equal(t, tc.wantCalls, invoker.CallCount())
+			assert.Equal(t, tc.wantCommits, getCommitMessages(t, dir))
+			if tc.enabled {
+				assert.Contains(t, invoker.calls[1].Prompt, "  - working.txt")
+				assert.Contains(t, result.TotalFilesChanged, "README.md")
+				content, err := os.ReadFile(readme)
+				require.NoError(t, err)
+				assert.Equal(t, "# Feature\n", string(content))
+			}
+		})
+	}
+}
+
+func TestLoopRun_DocsPhaseDiffScan(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, workingFile := writePlanFile(t, dir, planConfig{
+		Tasks:       []string{"Task 1: Feature"},
+		CommitFiles: true,
+	})
+	mainFile := filepath.Join(dir, "main.go")
+
+	// The docs invocation also edits code.
+	invoker := newSequenceInvoker([]sequenceResponse{
+		{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+			Summary: "Feature done", FileEdits: map[string]string{workingFile: "feature\n"}},
+		{Status: protocol.StatusDone, FilesChanged: []string{"main.go"},
+			Summary: "Documented feature", FileEdits: map[string]string{mainFile: "package main\n\nfunc main() {\n\tpanic(\"todo\")\n}\n"}},
+	})
+	scanner, err := safety.NewScanner(safety.DefaultScanRules())
+	require.NoError(t, err)
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true})
+	l.SetDocsPhase(true)
+	l.SetDiffScanner(scanner)
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonBlocked, result.ExitReason)
+	assert.Contains(t, result.ExitMessage, `panic: main.go: panic("todo")`)
+	assert.Equal(t, []string{"Task 1: Feature"}, getCommitMessages(t, dir), "flagged docs changes are not committed")
+}
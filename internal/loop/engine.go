@@ -2,6 +2,7 @@ package loop
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/alexander-akhmetov/programmator/internal/protocol"
@@ -10,6 +11,8 @@ import (
 
 // Engine makes pure decisions about what the loop should do next.
 // It holds no I/O references—only configuration and transient review state.
+// Every decision is reported to OnTransition with the inputs it was made
+// from, so a run can be replayed without invoking the executor.
 type Engine struct {
 	SafetyConfig safety.Config
 
@@ -18,6 +21,65 @@ type Engine struct {
 	PendingReviewFix bool // true when Claude should fix review issues
 	ReviewPassed     bool // true when review has passed
 	MaxReviewIter    int  // from review.max_iterations; 0 means unlimited
+
+	// OnTransition, when set, receives every decision the engine makes.
+	OnTransition func(Transition)
+
+	// Issue fingerprints from the previous failed review iteration.
+	lastReviewFingerprints []string
+	seq                    int
+}
+
+// Start records the beginning of a run with the engine's limits.
+func (e *Engine) Start() {
+	e.record(Transition{
+		Kind:   TransitionStart,
+		Config: &EngineConfig{Safety: e.SafetyConfig, MaxReviewIter: e.MaxReviewIter},
+	})
+}
+
+// Finish records how the run ended. The exit may come from an engine
+// decision or from the runner (errors, interrupts).
+func (e *Engine) Finish(reason safety.ExitReason, message string) {
+	e.record(Transition{Kind: TransitionExit, ExitReason: reason, Decision: message})
+}
+
+// CheckSafety checks the run's safety limits against state.
+func (e *Engine) CheckSafety(state *safety.State) safety.CheckResult {
+	snap := snapshotSafety(state)
+	result := safety.Check(e.SafetyConfig, snap.state())
+	t := Transition{Kind: TransitionSafety, Iteration: state.Iteration, Safety: &snap, Decision: DecisionContinue}
+	if result.ShouldExit {
+		t.Decision = DecisionExit
+		t.ExitReason = result.Reason
+		t.Message = result.Message
+	}
+	e.record(t)
+	return result
+}
+
+// StartReview counts a new review iteration, or reports false when the
+// review iteration limit has been reached.
+func (e *Engine) StartReview() bool {
+	t := Transition{Kind: TransitionReviewLimit, ReviewIterations: e.ReviewIterations}
+	if e.MaxReviewIter > 0 && e.ReviewIterations >= e.MaxReviewIter {
+		t.Decision = DecisionLimitReached
+		e.record(t)
+		return false
+	}
+	e.ReviewIterations++
+	t.Decision = DecisionContinue
+	e.record(t)
+	return true
+}
+
+func (e *Engine) record(t Transition) {
+	e.seq++
+	if e.OnTransition == nil {
+		return
+	}
+	t.Seq = e.seq
+	e.OnTransition(t)
 }
 
 // ProcessStatus analyses a parsed Claude status block and returns pure decisions.
@@ -36,29 +98,69 @@ func (e *Engine) ProcessStatus(input ProcessStatusInput) StatusProcessResult {
 		result.ResetPendingReviewFix = true
 	}
 
+	t := Transition{
+		Kind:             TransitionStatus,
+		Iteration:        input.Iteration,
+		Status:           status,
+		PendingReviewFix: input.PendingReviewFix,
+		Decision:         DecisionContinue,
+	}
 	switch status.Status {
 	case protocol.StatusDone:
 		result.TaskCompleted = true
+		t.Decision = DecisionTaskCompleted
 
 	case protocol.StatusBlocked:
 		result.Blocked = true
 		result.BlockedError = status.Error
 		result.ExitReason = safety.ExitReasonBlocked
 		result.ShouldExit = true
+		t.Decision = DecisionBlocked
+		t.ExitReason = result.ExitReason
+		t.Message = status.Error
 	}
+	e.record(t)
 
 	return result
 }
 
 // DecideReview evaluates the review result and decides what to do next.
-// The iteration limit is now checked before running the review (in handleReview),
-// so DecideReview only distinguishes between passed and needs-fix.
-func (e *Engine) DecideReview(passed bool) ReviewDecision {
+// The iteration limit is checked before running the review (in handleReview).
+// fingerprints identify the issues found; when a failed review reports exactly
+// the same non-empty set as the previous one, the fix loop is not converging.
+func (e *Engine) DecideReview(passed bool, fingerprints []string) ReviewDecision {
+	t := Transition{
+		Kind:             TransitionReview,
+		ReviewIterations: e.ReviewIterations,
+		ReviewPassed:     passed,
+		Fingerprints:     fingerprints,
+	}
+	decision := e.decideReview(passed, fingerprints)
+	switch {
+	case decision.Passed:
+		t.Decision = DecisionReviewPassed
+	case decision.NotConverging:
+		t.Decision = DecisionNotConverging
+		t.ExitReason = safety.ExitReasonReviewStuck
+	default:
+		t.Decision = DecisionNeedsFix
+	}
+	e.record(t)
+	return decision
+}
+
+func (e *Engine) decideReview(passed bool, fingerprints []string) ReviewDecision {
 	if passed {
 		e.ReviewPassed = true
+		e.lastReviewFingerprints = nil
 		return ReviewDecision{Passed: true}
 	}
 
+	if len(fingerprints) > 0 && slices.Equal(fingerprints, e.lastReviewFingerprints) {
+		return ReviewDecision{NotConverging: true}
+	}
+	e.lastReviewFingerprints = fingerprints
+
 	e.PendingReviewFix = true
 	return ReviewDecision{NeedsFix: true}
 }
@@ -79,4 +181,5 @@ func (e *Engine) ResetReviewState() {
 	e.ReviewIterations = 0
 	e.PendingReviewFix = false
 	e.ReviewPassed = false
+	e.lastReviewFingerprints = nil
 }
@@ -146,7 +146,7 @@ func TestDecideReview(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			e := newTestEngine()
 
-			decision := e.DecideReview(tc.passed)
+			decision := e.DecideReview(tc.passed, nil)
 
 			require.Equal(t, tc.wantPassed, decision.Passed)
 			require.Equal(t, tc.wantNeedsFix, decision.NeedsFix)
@@ -217,7 +217,7 @@ func TestDecideReview_FailureAlwaysReturnsNeedsFix(t *testing.T) {
 	e.MaxReviewIter = 3
 
 	for i := range 5 {
-		decision := e.DecideReview(false)
+		decision := e.DecideReview(false, nil)
 		require.True(t, decision.NeedsFix, "iteration %d should need fix", i+1)
 		require.False(t, decision.Passed)
 		e.PendingReviewFix = false
@@ -228,16 +228,37 @@ func TestDecideReview_PassStopsImmediately(t *testing.T) {
 	e := newTestEngine()
 
 	// Even after several failures, passing stops immediately
-	e.DecideReview(false)
+	e.DecideReview(false, nil)
 	e.PendingReviewFix = false
-	e.DecideReview(false)
+	e.DecideReview(false, nil)
 	e.PendingReviewFix = false
 
-	decision := e.DecideReview(true)
+	decision := e.DecideReview(true, nil)
 	require.True(t, decision.Passed)
 	require.True(t, e.ReviewPassed)
 }
 
+func TestDecideReview_IdenticalIssuesNotConverging(t *testing.T) {
+	e := newTestEngine()
+
+	decision := e.DecideReview(false, []string{"a", "b"})
+	require.True(t, decision.NeedsFix)
+	e.PendingReviewFix = false
+
+	decision = e.DecideReview(false, []string{"a", "c"})
+	require.True(t, decision.NeedsFix, "different issues should get another fix round")
+	e.PendingReviewFix = false
+
+	decision = e.DecideReview(false, []string{"a", "c"})
+	require.True(t, decision.NotConverging)
+	require.False(t, decision.NeedsFix)
+	require.False(t, e.PendingReviewFix)
+
+	e.ResetReviewState()
+	decision = e.DecideReview(false, []string{"a", "c"})
+	require.True(t, decision.NeedsFix, "reset should forget previous issues")
+}
+
 func TestResetReviewState(t *testing.T) {
 	e := newTestEngine()
 	e.ReviewIterations = 5
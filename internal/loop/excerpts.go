@@ -0,0 +1,102 @@
+package loop
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+// excerptContext is how many lines around a reported issue are included in
+// its excerpt.
+const excerptContext = 8
+
+// lineRange is an inclusive, 1-based range of lines in a file.
+type lineRange struct {
+	start, end int
+}
+
+// fixExcerpts renders the code around the issues of results, with line
+// numbers, for the review fix prompt. It returns "" when excerpts are
+// disabled, no issue has a location, or the excerpts would exceed the
+// configured number of lines, in which case the executor reads the files.
+func (l *Loop) fixExcerpts(results []*review.Result) string {
+	limit := l.reviewConfig.FixExcerptLines
+	if limit <= 0 {
+		return ""
+	}
+
+	ranges := make(map[string][]lineRange)
+	for _, r := range results {
+		for _, issue := range r.Issues {
+			if issue.File == "" || issue.Line <= 0 || !filepath.IsLocal(issue.File) {
+				continue
+			}
+			end := max(issue.LineEnd, issue.Line)
+			ranges[issue.File] = append(ranges[issue.File], lineRange{
+				start: max(issue.Line-excerptContext, 1),
+				end:   end + excerptContext,
+			})
+		}
+	}
+	if len(ranges) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	total := 0
+	for _, path := range slices.Sorted(maps.Keys(ranges)) {
+		lines, ok := l.readTextLines(path)
+		if !ok {
+			return "" // deleted, unreadable, or binary: leave it to the executor
+		}
+
+		for _, rg := range mergeLineRanges(ranges[path]) {
+			rg.end = min(rg.end, len(lines))
+			if rg.start > rg.end {
+				continue // the issue points past the end of the file
+			}
+			total += rg.end - rg.start + 1
+			if total > limit {
+				return ""
+			}
+			fmt.Fprintf(&b, "### %s (lines %d-%d)\n\n```\n", path, rg.start, rg.end)
+			for n := rg.start; n <= rg.end; n++ {
+				fmt.Fprintf(&b, "%5d  %s\n", n, lines[n-1])
+			}
+			b.WriteString("```\n\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// readTextLines returns the lines of path, a local path relative to the
+// working directory, or false when it cannot be read or is binary.
+func (l *Loop) readTextLines(path string) ([]string, bool) {
+	data, err := os.ReadFile(filepath.Join(l.workingDir, path)) //nolint:gosec // path reported by a review agent, checked to be local
+	if err != nil || bytes.IndexByte(data, 0) >= 0 {
+		return nil, false
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), true
+}
+
+// mergeLineRanges sorts ranges and joins those that overlap or touch.
+func mergeLineRanges(ranges []lineRange) []lineRange {
+	slices.SortFunc(ranges, func(a, b lineRange) int { return cmp.Compare(a.start, b.start) })
+	merged := ranges[:1]
+	for _, rg := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if rg.start <= last.end+1 {
+			last.end = max(last.end, rg.end)
+			continue
+		}
+		merged = append(merged, rg)
+	}
+	return merged
+}
@@ -0,0 +1,61 @@
+package loop
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func excerptLoop(t *testing.T, limit int) *Loop {
+	t.Helper()
+	dir := t.TempDir()
+	var content strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte(content.String()), 0o600))
+
+	l := New(safety.Config{}, dir, nil, false)
+	cfg := review.DefaultConfig()
+	cfg.FixExcerptLines = limit
+	l.SetReviewConfig(cfg)
+	return l
+}
+
+func TestFixExcerpts(t *testing.T) {
+	results := []*review.Result{{Issues: []review.Issue{
+		{File: "a.go", Line: 50},
+		{File: "a.go", Line: 55, LineEnd: 56}, // overlaps the first
+		{File: "a.go", Line: 2},
+		{File: "missing-line.go"},
+	}}}
+
+	got := excerptLoop(t, 120).fixExcerpts(results)
+
+	assert.Contains(t, got, "### a.go (lines 1-10)")
+	assert.Contains(t, got, "### a.go (lines 42-64)")
+	assert.Contains(t, got, "   50  line 50\n")
+	assert.NotContains(t, got, "line 41\n")
+	assert.NotContains(t, got, "missing-line.go")
+	assert.Less(t, strings.Index(got, "lines 1-10"), strings.Index(got, "lines 42-64"))
+}
+
+func TestFixExcerpts_FallsBack(t *testing.T) {
+	issue := func(file string, line int) []*review.Result {
+		return []*review.Result{{Issues: []review.Issue{{File: file, Line: line}}}}
+	}
+
+	assert.Empty(t, excerptLoop(t, 10).fixExcerpts(issue("a.go", 50)), "over the line limit")
+	assert.Empty(t, excerptLoop(t, 0).fixExcerpts(issue("a.go", 50)), "disabled")
+	assert.Empty(t, excerptLoop(t, 120).fixExcerpts(issue("gone.go", 5)), "unreadable file")
+	assert.Empty(t, excerptLoop(t, 120).fixExcerpts(issue("../a.go", 5)), "path outside the working dir")
+	assert.Empty(t, excerptLoop(t, 120).fixExcerpts(issue("a.go", 0)), "no line")
+}
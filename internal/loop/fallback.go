@@ -0,0 +1,101 @@
+package loop
+
+import (
+	"fmt"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+)
+
+// FallbackConfig is the chain of executors a run switches to when
+// invocations keep failing, e.g. with the provider overloaded or out of
+// quota.
+type FallbackConfig struct {
+	// After is how many consecutive invocations must fail before the run
+	// moves to the next executor of Chain.
+	After int
+	// Chain lists the fallback executors in the order they are tried.
+	Chain []executor.Config
+}
+
+// ExecutorSwitch records the run moving to a fallback executor.
+type ExecutorSwitch struct {
+	Iteration int    // first iteration on the new executor
+	From      string // executor label, as in "claude (opus)"
+	To        string
+	Reason    string // the last invocation error
+}
+
+// invokerFactory creates the invoker of an executor.
+type invokerFactory func(executor.Config) (llm.Invoker, error)
+
+// SetFallback configures the executors to fall back to.
+func (l *Loop) SetFallback(cfg FallbackConfig) {
+	l.fallback = cfg
+}
+
+// switchToFallback moves the run to the next executor of the fallback
+// chain once After consecutive invocations failed. The failures were the
+// executor's, not the work's, so the error and stagnation streaks are reset
+// and the new executor gets its own attempts. Sessions are not resumed
+// across executors. Returns false when no switch was made.
+func (l *Loop) switchToFallback(rc *runContext, err error) bool {
+	if l.fallback.After <= 0 || l.consecutiveInvokeErrors < l.fallback.After {
+		return false
+	}
+	newInvoker := l.newInvoker
+	if newInvoker == nil {
+		newInvoker = executor.New
+	}
+	for l.fallbackNext < len(l.fallback.Chain) {
+		next := l.fallback.Chain[l.fallbackNext]
+		l.fallbackNext++
+		inv, createErr := newInvoker(next)
+		if createErr != nil {
+			l.log(fmt.Sprintf("Warning: fallback executor %s unavailable: %v", executorLabel(next), createErr))
+			continue
+		}
+
+		sw := ExecutorSwitch{
+			Iteration: rc.state.Iteration + 1,
+			From:      executorLabel(l.executorConfig),
+			To:        executorLabel(next),
+			Reason:    err.Error(),
+		}
+		l.executorConfig = next
+		l.invoker = inv
+		l.sessionID = ""
+		l.consecutiveInvokeErrors = 0
+		rc.state.ConsecutiveNoChanges = 0
+		rc.state.ConsecutiveErrors = 0
+		rc.result.ExecutorSwitches = append(rc.result.ExecutorSwitches, sw)
+
+		l.log(fmt.Sprintf("Switching executor from %s to %s after %d failed invocations", sw.From, sw.To, l.fallback.After))
+		l.addNote(rc, notes.Phase, fmt.Sprintf("warning: Switched executor from %s to %s at iteration %d: %s",
+			sw.From, sw.To, sw.Iteration, sw.Reason))
+		return true
+	}
+	return false
+}
+
+// tagFallbackIteration records the current iteration in the result when it
+// runs on a fallback executor.
+func (l *Loop) tagFallbackIteration(rc *runContext) {
+	if len(rc.result.ExecutorSwitches) > 0 {
+		rc.result.FallbackIterations = append(rc.result.FallbackIterations, rc.state.Iteration)
+	}
+}
+
+// executorLabel names an executor and, when one is set, its model, as in
+// "claude (sonnet)".
+func executorLabel(cfg executor.Config) string {
+	name := cfg.Name
+	if name == "" {
+		name = "claude"
+	}
+	if model := cfg.Model(); model != "" {
+		return fmt.Sprintf("%s (%s)", name, model)
+	}
+	return name
+}
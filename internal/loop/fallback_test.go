@@ -0,0 +1,104 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/llm/claude"
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestExecutorLabel(t *testing.T) {
+	assert.Equal(t, "claude", executorLabel(executor.Config{}))
+	assert.Equal(t, "claude (sonnet)", executorLabel(executor.Config{Claude: claude.Config{Model: "sonnet"}}))
+	assert.Equal(t, "codex", executorLabel(executor.Config{Name: "codex"}))
+}
+
+func TestLoopRun_ExecutorFallback(t *testing.T) {
+	overloaded := func(context.Context, string) (string, error) {
+		return "", errors.New("API error: 529 overloaded")
+	}
+	sonnet := executor.Config{Claude: claude.Config{Model: "sonnet"}}
+	codex := executor.Config{Name: "codex"}
+
+	tests := []struct {
+		name         string
+		chain        []executor.Config
+		wantExit     safety.ExitReason
+		wantSwitches []ExecutorSwitch
+		wantTagged   []int
+	}{
+		{
+			name:     "falls back along the chain",
+			chain:    []executor.Config{sonnet, {Name: "unknown"}, codex},
+			wantExit: safety.ExitReasonComplete,
+			wantSwitches: []ExecutorSwitch{
+				{Iteration: 3, From: "claude", To: "claude (sonnet)", Reason: "API error: 529 overloaded"},
+				{Iteration: 5, From: "claude (sonnet)", To: "codex", Reason: "API error: 529 overloaded"},
+			},
+			wantTagged: []int{3, 4, 5},
+		},
+		{
+			name:     "stops once the chain is exhausted",
+			chain:    []executor.Config{sonnet},
+			wantExit: safety.ExitReasonError,
+			wantSwitches: []ExecutorSwitch{
+				{Iteration: 3, From: "claude", To: "claude (sonnet)", Reason: "API error: 529 overloaded"},
+			},
+			wantTagged: []int{3, 4, 5},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+			done := newSequenceInvoker([]sequenceResponse{
+				{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, FilesChanged: []string{"feature.go"},
+					Summary: "Feature done", FileEdits: map[string]string{filepath.Join(dir, "feature.go"): "package main\n"}},
+			})
+
+			l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+			l.SetInvoker(&fakeInvoker{fn: overloaded})
+			l.SetSource(source.NewPlanSource(planPath))
+			l.SetReviewRunner(createNoIssueReviewRunner(t))
+			l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+			l.SetFallback(FallbackConfig{After: 2, Chain: tc.chain})
+			var created []string
+			l.newInvoker = func(cfg executor.Config) (llm.Invoker, error) {
+				created = append(created, executorLabel(cfg))
+				switch cfg.Name {
+				case "codex":
+					return done, nil
+				case "unknown":
+					return nil, errors.New("unknown executor")
+				}
+				return &fakeInvoker{fn: overloaded}, nil
+			}
+
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantExit, result.ExitReason)
+			assert.Equal(t, tc.wantSwitches, result.ExecutorSwitches)
+			assert.Equal(t, tc.wantTagged, result.FallbackIterations)
+			if tc.wantExit == safety.ExitReasonComplete {
+				assert.Equal(t, []string{"claude (sonnet)", "unknown", "codex"}, created)
+				assert.Equal(t, 1, done.CallCount())
+			} else {
+				assert.Contains(t, result.ExitMessage, "3 consecutive invocation failures")
+			}
+		})
+	}
+}
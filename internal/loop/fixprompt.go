@@ -0,0 +1,66 @@
+package loop
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+// setFixIssues sets the issues of the next fix prompt from results. When
+// review.fix_max_issues caps them, the most severe go first and the rest
+// wait in fixBacklog for the fix prompts after it.
+func (l *Loop) setFixIssues(results []*review.Result) {
+	batch, rest := review.LimitIssues(results, l.reviewConfig.FixMaxIssues)
+	opts := review.MarkdownOptions{GroupBy: l.reviewConfig.FixGroupBy, Omitted: countIssues(rest)}
+	if l.reviewConfig.FixSnippetLines > 0 {
+		opts.Snippet = l.issueSnippet
+	}
+	l.lastReviewIssues = review.FormatIssuesMarkdownWith(batch, opts)
+	l.lastReviewExcerpts = l.fixExcerpts(batch)
+	l.fixBacklog = rest
+}
+
+// nextFixBatch moves on to the next batch of review issues after a fix
+// iteration, and reports whether there was one. The review runs again only
+// once every batch was sent.
+func (l *Loop) nextFixBatch() bool {
+	if len(l.fixBacklog) == 0 {
+		return false
+	}
+	l.fixBatch++
+	l.setFixIssues(l.fixBacklog)
+	l.log(fmt.Sprintf("Next batch of review issues to fix (%d more after it)", countIssues(l.fixBacklog)))
+	return true
+}
+
+// issueSnippet renders the lines of issue's location, with
+// review.fix_snippet_lines of context on each side, numbered; "" when the
+// issue has no location in a readable file.
+func (l *Loop) issueSnippet(issue review.Issue) string {
+	if issue.File == "" || issue.Line <= 0 || !filepath.IsLocal(issue.File) {
+		return ""
+	}
+	lines, ok := l.readTextLines(issue.File)
+	if !ok {
+		return ""
+	}
+	n := l.reviewConfig.FixSnippetLines
+	start := max(issue.Line-n, 1)
+	end := min(max(issue.LineEnd, issue.Line)+n, len(lines))
+
+	var b strings.Builder
+	for i := start; i <= end; i++ {
+		fmt.Fprintf(&b, "%5d  %s\n", i, lines[i-1])
+	}
+	return b.String()
+}
+
+func countIssues(results []*review.Result) int {
+	n := 0
+	for _, r := range results {
+		n += len(r.Issues)
+	}
+	return n
+}
@@ -0,0 +1,41 @@
+package loop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestFixIssueBatches(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n\tpanic(1)\n}\n"), 0o600))
+
+	l := New(safety.Config{}, dir, nil, false)
+	l.SetReviewConfig(review.Config{FixMaxIssues: 1, FixSnippetLines: 1, FixGroupBy: review.GroupByFile})
+	l.engine.PendingReviewFix = true
+	results := []*review.Result{{AgentName: "quality", Issues: []review.Issue{
+		{File: "main.go", Line: 1, Severity: review.SeverityLow, Description: "Missing package doc"},
+		{File: "main.go", Line: 4, Severity: review.SeverityHigh, Description: "Panics"},
+	}}}
+
+	l.setFixIssues(results)
+	assert.Contains(t, l.lastReviewIssues, "### `main.go` (1 issue)")
+	assert.Contains(t, l.lastReviewIssues, "Panics")
+	assert.Contains(t, l.lastReviewIssues, "    3  func main() {\n        4  \tpanic(1)\n        5  }\n")
+	assert.Contains(t, l.lastReviewIssues, "_1 more issue omitted")
+	assert.NotContains(t, l.lastReviewIssues, "Missing package doc")
+	scope := l.sessionScopeFor(nil)
+
+	require.True(t, l.nextFixBatch())
+	assert.Contains(t, l.lastReviewIssues, "Missing package doc")
+	assert.NotContains(t, l.lastReviewIssues, "omitted")
+	assert.NotEqual(t, scope, l.sessionScopeFor(nil), "each batch starts a fresh session")
+
+	require.False(t, l.nextFixBatch())
+}
@@ -0,0 +1,44 @@
+package loop
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// SetFresh makes the run start over: completed phases are reopened, and
+// notes and status changes an earlier run already wrote to the work item
+// are written again instead of skipped.
+func (l *Loop) SetFresh(fresh bool) {
+	l.fresh = fresh
+}
+
+// startFresh resets item for a fresh run and returns it as read again.
+func (l *Loop) startFresh(src source.Source, id string, item *domain.WorkItem) (*domain.WorkItem, error) {
+	if r, ok := src.(source.Refresher); ok {
+		r.SetFresh(true)
+	}
+
+	var indices []int
+	var names []string
+	for i, p := range item.Phases {
+		if p.Completed {
+			indices = append(indices, i)
+			names = append(names, p.Name)
+		}
+	}
+	if len(indices) == 0 {
+		return item, nil
+	}
+	reopener, ok := src.(source.PhaseReopener)
+	if !ok {
+		return nil, fmt.Errorf("fresh run: %s sources cannot reopen phases", src.Type())
+	}
+	if err := reopener.ReopenPhases(id, indices); err != nil {
+		return nil, fmt.Errorf("fresh run: reopen phases: %w", err)
+	}
+	l.log(fmt.Sprintf("Fresh run: reopened %d phase(s): %s", len(indices), strings.Join(names, ", ")))
+	return src.Get(id)
+}
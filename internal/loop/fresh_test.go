@@ -0,0 +1,45 @@
+package loop
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_FreshReopensCompletedPhases(t *testing.T) {
+	for _, fresh := range []bool{true, false} {
+		dir, cleanup := setupTestRepo(t)
+		planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+		content, err := os.ReadFile(planPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(planPath, []byte(strings.Replace(string(content), "- [ ]", "- [x]", 1)), 0o644))
+
+		invoker := newSequenceInvoker([]sequenceResponse{
+			{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, Summary: "Redone"},
+		})
+		l := New(safety.Config{MaxIterations: 5, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+		l.SetInvoker(invoker)
+		l.SetSource(source.NewPlanSource(planPath))
+		l.SetReviewRunner(createNoIssueReviewRunner(t))
+		l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+		l.SetFresh(fresh)
+
+		result, err := l.Run(planPath)
+		require.NoError(t, err)
+		assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+		if fresh {
+			assert.Equal(t, 1, result.Iterations, "the reopened phase is worked on again")
+		} else {
+			assert.Zero(t, result.Iterations)
+		}
+		cleanup()
+	}
+}
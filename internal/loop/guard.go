@@ -0,0 +1,125 @@
+package loop
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// guardCheckboxRegex matches a checkbox line of a plan or ticket file.
+var guardCheckboxRegex = regexp.MustCompile(`^([ \t]*-\s+\[)([ xX])(\]\s+.+)$`)
+
+// guardedFile is the work item file as it was before an invocation.
+type guardedFile struct {
+	path    string
+	content []byte
+}
+
+// guardWorkItemFile saves the work item file before an invocation, so
+// restoreWorkItemFile can undo what the executor changed in its phases.
+// Sources without a file (pull requests) and unreadable files are not
+// guarded.
+func (l *Loop) guardWorkItemFile(rc *runContext) {
+	rc.guarded = nil
+	locator, ok := rc.source.(source.Locator)
+	if !ok {
+		return
+	}
+	path, err := locator.WorkItemFile(rc.workItemID)
+	if err != nil {
+		return
+	}
+	content, err := os.ReadFile(path) //nolint:gosec // path of the work item being run
+	if err != nil {
+		return
+	}
+	rc.guarded = &guardedFile{path: path, content: content}
+}
+
+// restoreWorkItemFile undoes the executor's edits to the phases of the work
+// item file: the loop marks phases complete from phase_completed, and a
+// checkbox ticked by the executor would make that update fail or skip
+// ahead. Checkbox marks the executor changed are put back and its other
+// edits, such as notes, are kept; when it added, removed or renamed phases
+// the whole file is restored.
+func (l *Loop) restoreWorkItemFile(rc *runContext) {
+	g := rc.guarded
+	rc.guarded = nil
+	if g == nil {
+		return
+	}
+	current, err := os.ReadFile(g.path)
+	if err != nil || bytes.Equal(current, g.content) {
+		return
+	}
+
+	restored, toggled, ok := reconcileCheckboxes(string(g.content), string(current))
+	if ok && len(toggled) == 0 {
+		return
+	}
+	if !ok {
+		restored = string(g.content)
+	}
+	if err := os.WriteFile(g.path, []byte(restored), 0o600); err != nil {
+		l.log(fmt.Sprintf("Warning: executor edited the phases of %s and restoring them failed: %v", g.path, err))
+		return
+	}
+
+	var what string
+	if ok {
+		what = fmt.Sprintf("reverted its checkbox changes (%s)", strings.Join(toggled, ", "))
+	} else {
+		what = "restored the file, as it added, removed or renamed phases"
+	}
+	l.log(fmt.Sprintf("Warning: executor edited the phases of %s; %s. Phases are marked from phase_completed", g.path, what))
+	l.addNote(rc, notes.Iteration, fmt.Sprintf("warning: [iter %d] Executor edited the work item's phases; %s",
+		rc.state.Iteration, what))
+}
+
+// reconcileCheckboxes returns after with the marks of its checkboxes reset
+// to those in before, and the names of the checkboxes that were reset. ok is
+// false when the checkboxes of the two differ in more than their marks.
+func reconcileCheckboxes(before, after string) (string, []string, bool) {
+	beforeBoxes := checkboxLines(strings.Split(before, "\n"))
+	lines := strings.Split(after, "\n")
+	afterBoxes := checkboxLines(lines)
+	if !slices.EqualFunc(beforeBoxes, afterBoxes, func(a, b checkboxLine) bool { return a.key() == b.key() }) {
+		return "", nil, false
+	}
+
+	var toggled []string
+	for i, box := range afterBoxes {
+		want := beforeBoxes[i]
+		if box.done() == want.done() {
+			continue
+		}
+		lines[box.index] = box.prefix + want.mark + box.rest
+		toggled = append(toggled, strings.TrimSpace(strings.TrimPrefix(box.rest, "]")))
+	}
+	return strings.Join(lines, "\n"), toggled, true
+}
+
+// checkboxLine is a checkbox line split around its mark.
+type checkboxLine struct {
+	index              int
+	prefix, mark, rest string
+}
+
+func (c checkboxLine) key() string { return c.prefix + c.rest }
+func (c checkboxLine) done() bool  { return c.mark != " " }
+
+func checkboxLines(lines []string) []checkboxLine {
+	var boxes []checkboxLine
+	for i, line := range lines {
+		if m := guardCheckboxRegex.FindStringSubmatch(line); m != nil {
+			boxes = append(boxes, checkboxLine{index: i, prefix: m[1], mark: m[2], rest: m[3]})
+		}
+	}
+	return boxes
+}
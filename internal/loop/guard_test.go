@@ -0,0 +1,120 @@
+package loop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestReconcileCheckboxes(t *testing.T) {
+	before := "# Plan\n\n- [x] Task 1: Schema\n- [ ] Task 2: API\n  - [ ] Handlers\n\n## Notes\n"
+
+	tests := []struct {
+		name    string
+		after   string
+		want    string
+		toggled []string
+		ok      bool
+	}{
+		{
+			name:  "notes only",
+			after: before + "- picked sqlite\n",
+			want:  before + "- picked sqlite\n",
+			ok:    true,
+		},
+		{
+			name:    "checked and noted",
+			after:   "# Plan\n\n- [x] Task 1: Schema\n- [X] Task 2: API\n  - [x] Handlers\n\n## Notes\n- API done\n",
+			want:    before + "- API done\n",
+			toggled: []string{"Task 2: API", "Handlers"},
+			ok:      true,
+		},
+		{
+			name:    "unchecked",
+			after:   "# Plan\n\n- [ ] Task 1: Schema\n- [ ] Task 2: API\n  - [ ] Handlers\n\n## Notes\n",
+			want:    before,
+			toggled: []string{"Task 1: Schema"},
+			ok:      true,
+		},
+		{
+			name:  "renamed",
+			after: "# Plan\n\n- [x] Task 1: Schema\n- [ ] Task 2: REST API\n  - [ ] Handlers\n\n## Notes\n",
+		},
+		{
+			name:  "added",
+			after: before + "- [ ] Task 3: Docs\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, toggled, ok := reconcileCheckboxes(before, tt.after)
+			require.Equal(t, tt.ok, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.toggled, toggled)
+		})
+	}
+}
+
+func TestRestoreWorkItemFile(t *testing.T) {
+	original := "# Plan: Guard\n\n## Tasks\n- [ ] Task 1: Schema\n- [ ] Task 2: API\n"
+	setup := func(t *testing.T) (*Loop, *runContext, string, *[]string) {
+		t.Helper()
+		planPath := filepath.Join(t.TempDir(), "plan.md")
+		require.NoError(t, os.WriteFile(planPath, []byte(original), 0o600))
+		l := New(safety.Config{}, t.TempDir(), nil, false)
+		var logs []string
+		l.SetEventCallback(func(ev event.Event) { logs = append(logs, ev.Text) })
+		rc := &runContext{workItemID: planPath, source: source.NewPlanSource(planPath), state: safety.NewState(), result: &Result{}}
+		l.guardWorkItemFile(rc)
+		return l, rc, planPath, &logs
+	}
+
+	t.Run("checkbox reverted, notes kept", func(t *testing.T) {
+		l, rc, planPath, logs := setup(t)
+		edited := "# Plan: Guard\n\n## Tasks\n- [x] Task 1: Schema\n- [ ] Task 2: API\n\n## Notes\n- schema added\n"
+		require.NoError(t, os.WriteFile(planPath, []byte(edited), 0o600))
+
+		l.restoreWorkItemFile(rc)
+
+		data, err := os.ReadFile(planPath)
+		require.NoError(t, err)
+		assert.Equal(t, original+"\n## Notes\n- schema added\n", string(data))
+		require.Len(t, *logs, 1)
+		assert.Contains(t, (*logs)[0], "reverted its checkbox changes (Task 1: Schema)")
+		require.NoError(t, rc.source.UpdatePhase(rc.workItemID, "Task 1: Schema"), "the reported phase can still be completed")
+	})
+
+	t.Run("phases rewritten", func(t *testing.T) {
+		l, rc, planPath, logs := setup(t)
+		require.NoError(t, os.WriteFile(planPath, []byte("# Plan: Guard\n\n## Tasks\n- [x] Everything\n"), 0o600))
+
+		l.restoreWorkItemFile(rc)
+
+		data, err := os.ReadFile(planPath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(data))
+		require.Len(t, *logs, 1)
+		assert.Contains(t, (*logs)[0], "restored the file")
+	})
+
+	t.Run("untouched", func(t *testing.T) {
+		l, rc, planPath, logs := setup(t)
+		l.restoreWorkItemFile(rc)
+
+		data, err := os.ReadFile(planPath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(data))
+		assert.Empty(t, *logs)
+		assert.Nil(t, rc.guarded)
+	})
+}
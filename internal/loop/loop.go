@@ -2,10 +2,14 @@
 package loop
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,12 +24,16 @@ import (
 	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
 	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+	"github.com/alexander-akhmetov/programmator/internal/messages"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
 	"github.com/alexander-akhmetov/programmator/internal/parser"
+	"github.com/alexander-akhmetov/programmator/internal/plan"
 	"github.com/alexander-akhmetov/programmator/internal/prompt"
 	"github.com/alexander-akhmetov/programmator/internal/protocol"
 	"github.com/alexander-akhmetov/programmator/internal/review"
 	"github.com/alexander-akhmetov/programmator/internal/safety"
 	"github.com/alexander-akhmetov/programmator/internal/source"
+	"github.com/alexander-akhmetov/programmator/internal/ticket"
 	"github.com/alexander-akhmetov/programmator/internal/timing"
 )
 
@@ -37,6 +45,58 @@ type Result struct {
 	FinalStatus       *parser.ParsedStatus
 	Duration          time.Duration
 	RecentSummaries   []string // Summaries from recent iterations (for debugging stagnation)
+	Labels            []string // Labels the run was tagged with (see SetLabels)
+	BaseSHA           string   // Commit shared with the base branch when the run started (see GitWorkflowConfig.BaseBranch)
+
+	// Invocations are the latency, retries, and failures of every executor
+	// invocation of the run, review agents included.
+	Invocations llm.InvocationStats
+
+	// NoGit is set when the working directory is not a git repository or git
+	// is not installed. The run still works, with less: no branches, commits,
+	// snapshots, pushes, or diff scans; review agents read the changed files
+	// instead of a diff; and changed files, for stagnation and review, are
+	// the files whose modification time changed during an invocation, not
+	// only the ones the executor reported.
+	NoGit bool
+
+	// RunID identifies the run by its start time and process ID.
+	RunID string
+
+	// FollowUp is the ID of the work item filed for review findings left
+	// open by the review iteration limit (see review.Config.FileDeferred),
+	// or "" when none was filed.
+	FollowUp string
+
+	// ContextOverflows are the iterations whose prompt was estimated to
+	// exceed its share of the executor's context window.
+	ContextOverflows []ContextOverflow
+
+	// ResidualIssues are the issues of a final review that passed with a
+	// score below review.score_threshold; nil when it found none.
+	ResidualIssues []*review.Result
+
+	// ReviewLifecycle follows each review finding of the run across the
+	// review iterations: when it was introduced, fixed, or reappeared.
+	ReviewLifecycle []review.IssueLifecycle
+
+	// PhantomChanges are the iterations whose status block reported files
+	// that git shows no change to; those files were not counted as progress.
+	PhantomChanges []int
+
+	// SignOffFiles are the changed files under GitWorkflowConfig.SignOffPaths
+	// left out of the auto-commits; they are staged for a human to commit.
+	SignOffFiles []string
+
+	// ExecutorSwitches are the moves to fallback executors after repeated
+	// invocation failures (see SetFallback), and FallbackIterations the
+	// iterations that ran on a fallback executor.
+	ExecutorSwitches   []ExecutorSwitch
+	FallbackIterations []int
+
+	// ConcurrentEdits are the files the run edited that changed outside it
+	// between invocations (see SetActiveFiles).
+	ConcurrentEdits []ConcurrentEdit
 }
 
 type StateCallback func(state *safety.State, workItem *domain.WorkItem, filesChanged []string)
@@ -48,11 +108,22 @@ type EventCallback func(event.Event)
 
 // GitWorkflowConfig holds configuration for automatic git operations.
 type GitWorkflowConfig struct {
-	AutoCommit         bool   // Auto-commit after each phase completion
-	MoveCompletedPlans bool   // Move completed plans to completed/ directory
-	CompletedPlansDir  string // Directory for completed plans (default: plans/completed)
-	BranchPrefix       string // Prefix for auto-created branches (default: programmator/)
-	AutoBranch         bool   // Auto-create branch on start
+	AutoCommit         bool     // Auto-commit after each phase completion
+	MoveCompletedPlans bool     // Move completed plans to completed/ directory
+	CompletedPlansDir  string   // Directory for completed plans (default: plans/completed)
+	BranchPrefix       string   // Prefix for auto-created branches (default: programmator/)
+	AutoBranch         bool     // Auto-create branch on start
+	AutoPush           bool     // Push auto-commits to PushRemote
+	PushRemote         string   // Remote to push to (default: origin)
+	PushEvery          int      // Push after every N auto-commits (<2 = after each commit); leftovers are pushed when the run ends
+	BaseBranch         string   // Branch the work is based on, checked before the first iteration
+	BaseSync           string   // BaseSyncOff, BaseSyncWarn, or BaseSyncRebase
+	Snapshots          bool     // Commit every iteration to a scratch branch, see snapshotIteration
+	SquashSnapshots    bool     // Delete the scratch branch once the run completes
+	PRComment          bool     // Keep one summary comment on the pull request up to date during the run
+	PRStatus           bool     // Set a commit status on the pull request's head commit
+	Squash             string   // SquashOff, SquashPhase, or SquashRun: squash a completed run's commits
+	SignOffPaths       []string // Regexps on repo-relative paths held out of auto-commits for sign-off, see holdForSignOff
 }
 
 type Loop struct {
@@ -68,6 +139,11 @@ type Loop struct {
 
 	stopRequested atomic.Bool
 
+	// SkipPhase requests; invokeCancel aborts the in-flight invocation.
+	skipRequested atomic.Bool
+	invokeMu      sync.Mutex
+	invokeCancel  context.CancelFunc
+
 	currentState    *safety.State
 	currentWorkItem *domain.WorkItem
 
@@ -75,25 +151,140 @@ type Loop struct {
 	engine Engine
 
 	// Review configuration
-	reviewConfig     review.Config
-	reviewRunner     *review.Runner
-	lastReviewIssues string // formatted issues from last review for Claude to fix
+	reviewConfig       review.Config
+	reviewRunner       *review.Runner
+	reviewProgress     reviewTracker
+	lastReviewIssues   string           // formatted issues from last review for Claude to fix
+	lastReviewExcerpts string           // code around those issues, or "" when too large to embed
+	openFindings       []*review.Result // issues of the last review that found any, see fileDeferredFindings
+	fixBacklog         []*review.Result // issues left out of the fix prompt by review.fix_max_issues, see setFixIssues
+	fixBatch           int              // fix prompts sent for the last review, minus one
 
 	// Prompt builder (uses customizable templates)
 	promptBuilder *prompt.Builder
+	statusKey     string // see SetStatusKey
+
+	// Progress messages, possibly translated (nil = English)
+	messages *messages.Catalog
 
-	// Ticket CLI command name
-	ticketCommand string
+	// Ticket CLI command name, and named ticket stores
+	ticketCommand    string
+	ticketWorkspaces map[string]ticket.Workspace
 
 	// Git workflow configuration
 	gitConfig GitWorkflowConfig
 	gitRepo   *gitutil.Repo
+	baseSHA   string // see Result.BaseSHA
+	noGit     bool   // see Result.NoGit
+
+	snapshotBranch string // scratch branch for per-iteration snapshots
+
+	// Set when the source checked out an existing pull request branch
+	pullRequestBase string // branch the pull request merges into
+	pullRequestHead string // HEAD when the run started, to detect commits to push
+	prices          llm.PriceTable
 
 	// Executor configuration for the factory
 	executorConfig executor.Config
 
 	// Track consecutive invocation failures to exit early on persistent errors
 	consecutiveInvokeErrors int
+
+	// Executors to switch to when invocations keep failing; fallbackNext is
+	// the index of the next one in the chain.
+	fallback     FallbackConfig
+	fallbackNext int
+	newInvoker   invokerFactory // nil = executor.New
+
+	// Invocation latency and failures (see Result.Invocations); retryNext
+	// marks the next main invocation as a retry of a failed one.
+	metrics   *llm.Metrics
+	retryNext bool
+
+	// Pacing between iterations: the current cool-down, and the rate-limited
+	// invocations seen when it was last adjusted.
+	pacing     PacingConfig
+	cooldown   time.Duration
+	rateLimits int
+
+	// Maximum number of independent phases run concurrently in worktrees
+	parallelPhases int
+
+	// Executor session reuse: consecutive iterations with the same scope
+	// (phase, review fix round) resume sessionID instead of starting fresh.
+	reuseSessions bool
+	sessionID     string
+	sessionScope  string
+
+	// Maximum supervisor interventions per run (0 = supervisor disabled)
+	supervisorMax int
+
+	// Phase splitting: after this many attempts on one phase, or for phase
+	// descriptions longer than this many characters (0 = off)
+	splitAfterAttempts int
+	splitMaxLength     int
+
+	// Run the docs phase after all phases and review complete
+	docsPhase bool
+
+	coverage        CoverageConfig
+	measureCoverage coverageMeasurer // nil = go test -cover
+
+	// Check reported files_changed against git status (see dropPhantomChanges)
+	verifyFilesChanged bool
+
+	// Start over: reopen completed phases and repeat notes (see startFresh)
+	fresh bool
+
+	// Plan phases for phaseless work items before the first iteration
+	autoPhasing bool
+
+	// Validation commands for work items that do not list their own
+	validationCommands []string
+
+	// Files the executor edits, published for humans in the same checkout,
+	// and checked for their edits between invocations
+	activeFilesConfig ActiveFilesConfig
+	active            activeFiles
+
+	// Scans each invocation's diff for dangerous changes (nil = disabled)
+	diffScanner *safety.Scanner
+
+	// Infer tool access for phases without an access annotation
+	minimalPermissions bool
+
+	// Asks the user BLOCKED questions (nil = BLOCKED ends the run)
+	questionHandler QuestionHandler
+
+	// Asks the user which phase an ambiguous phase_completed meant (nil = none)
+	phasePicker PhasePicker
+
+	// Asks the user to approve committing high-risk paths (nil = never commit them)
+	signOffApprover SignOffApprover
+
+	// Labels copied into Result for history and reports
+	labels []string
+
+	// Receive a record of each iteration and each review result for run
+	// transcripts and artifacts (nil = off); iterTools collects the current
+	// iteration's tool calls.
+	onIteration      func(IterationRecord)
+	redact           func(string) string // masks secrets, see SetSecrets (nil = none)
+	onReview         func(*review.RunResult)
+	toolMu           sync.Mutex
+	iterTools        []string
+	iterToolsDropped int
+
+	// Counts of earlier sessions the run continues from (nil = start at
+	// zero), and the recorder of the run's cumulative counts (nil = off)
+	resumeCheckpoint *safety.Checkpoint
+	onCheckpoint     func(safety.Checkpoint)
+
+	// Files a review-only run reviews from the first review on
+	reviewOnlyFiles []string
+
+	// Which notes reach the work item and how they are written
+	notesConfig notes.Config
 }
 
 // SetSource sets the source for the loop (for testing).
@@ -113,6 +304,7 @@ func NewWithSource(config safety.Config, workingDir string, onStateChange StateC
 		streaming:     streaming,
 		source:        src,
 		reviewConfig:  review.DefaultConfig(),
+		metrics:       &llm.Metrics{},
 		engine: Engine{
 			SafetyConfig: config,
 		},
@@ -125,26 +317,112 @@ func (l *Loop) SetReviewConfig(cfg review.Config) {
 	l.engine.MaxReviewIter = cfg.MaxIterations
 }
 
+// SetTransitionRecorder sets a callback receiving every engine decision,
+// for recording a run so it can be replayed.
+func (l *Loop) SetTransitionRecorder(fn func(Transition)) {
+	l.engine.OnTransition = fn
+}
+
+// SetReviewRecorder sets a callback receiving the result of every review
+// iteration.
+func (l *Loop) SetReviewRecorder(fn func(*review.RunResult)) {
+	l.onReview = fn
+}
+
 // SetPromptBuilder sets a custom prompt builder (for customizable templates).
 func (l *Loop) SetPromptBuilder(builder *prompt.Builder) {
 	l.promptBuilder = builder
 }
 
+// SetMessages sets the catalog the loop's progress messages are taken
+// from, for a translation. Nil keeps them in English.
+func (l *Loop) SetMessages(catalog *messages.Catalog) {
+	l.messages = catalog
+}
+
+// SetStatusKey sets the key prompts ask the status block to start with,
+// such as a per-run key from protocol.NonceStatusKey. Output without a block
+// under this key is parsed for a plain PROGRAMMATOR_STATUS block instead.
+func (l *Loop) SetStatusKey(key string) {
+	l.statusKey = key
+}
+
+// statusBlockKey returns the key of the executor's status block.
+func (l *Loop) statusBlockKey() string {
+	return cmp.Or(l.statusKey, protocol.StatusBlockKey)
+}
+
 // SetTicketCommand sets the ticket CLI command name.
 func (l *Loop) SetTicketCommand(cmd string) {
 	l.ticketCommand = cmd
 }
 
+// SetTicketWorkspaces sets the named ticket stores that "name:id" work item
+// IDs are looked up in.
+func (l *Loop) SetTicketWorkspaces(workspaces map[string]ticket.Workspace) {
+	l.ticketWorkspaces = workspaces
+}
+
 // SetGitWorkflowConfig sets the git workflow configuration.
 func (l *Loop) SetGitWorkflowConfig(cfg GitWorkflowConfig) {
 	l.gitConfig = cfg
 }
 
+// SetPrices sets the per-model prices used to estimate the cost of the run
+// in pull request summaries. Nil leaves the cost out.
+func (l *Loop) SetPrices(prices llm.PriceTable) {
+	l.prices = prices
+}
+
 // SetExecutorConfig sets the executor configuration for the invoker factory.
 func (l *Loop) SetExecutorConfig(cfg executor.Config) {
 	l.executorConfig = cfg
 }
 
+// SetSessionReuse enables resuming the executor session across consecutive
+// iterations of the same phase. Executors without session support ignore it.
+func (l *Loop) SetSessionReuse(enabled bool) {
+	l.reuseSessions = enabled
+}
+
+// SetLabels tags the run with labels, reported in Result.Labels.
+func (l *Loop) SetLabels(labels []string) {
+	l.labels = labels
+}
+
+// SetValidationCommands sets the validation commands used for work items that
+// do not define any themselves.
+func (l *Loop) SetValidationCommands(cmds []string) {
+	l.validationCommands = cmds
+}
+
+// sessionScopeFor identifies the unit of work an executor session belongs to.
+// Sessions are only resumed while the scope stays the same, so phase
+// boundaries and review fix rounds always start a fresh session.
+func (l *Loop) sessionScopeFor(phase *domain.Phase) string {
+	switch {
+	case l.engine.PendingReviewFix:
+		return fmt.Sprintf("review-fix:%d.%d", l.engine.ReviewIterations, l.fixBatch)
+	case phase != nil:
+		return "phase:" + phase.Name
+	default:
+		return "task"
+	}
+}
+
+// resumableSession returns the session ID to resume for the given scope, or
+// "" when a fresh session should be started.
+func (l *Loop) resumableSession(scope string) string {
+	if !l.reuseSessions {
+		return ""
+	}
+	if scope != l.sessionScope {
+		l.sessionScope = scope
+		l.sessionID = ""
+	}
+	return l.sessionID
+}
+
 // executorName returns a display name for the configured executor.
 func (l *Loop) executorName() string {
 	if l.executorConfig.Name == "" {
@@ -153,34 +431,53 @@ func (l *Loop) executorName() string {
 	return l.executorConfig.Name
 }
 
-// setupGitWorkflow initializes the git repo and optionally creates a branch.
+// setupGitWorkflow initializes the git repo, optionally creates a branch, and
+// checks the branch against its base.
 func (l *Loop) setupGitWorkflow(sourceID string, isPlan bool) error {
 	// Initialize git repo
 	repo, err := gitutil.NewRepo(l.workingDir)
+	if errors.Is(err, gitutil.ErrUnavailable) {
+		l.noGit = true
+		l.reviewConfig.IncludeDiff = false
+		l.log(fmt.Sprintf("No git (%v): branches, commits, and diffs are off; changed files are detected by modification time", err))
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("open git repo: %w", err)
 	}
 	l.gitRepo = repo
 
-	// Only create branch if auto-branch is enabled
-	if !l.gitConfig.AutoBranch {
-		return nil
+	if l.gitConfig.AutoBranch {
+		if err := l.createRunBranch(sourceID, isPlan); err != nil {
+			return err
+		}
+	}
+	if l.gitConfig.Snapshots {
+		l.snapshotBranch = l.snapshotBranchName(sourceID, isPlan)
 	}
 
-	// Generate branch name
-	prefix := l.gitConfig.BranchPrefix
-	if prefix == "" {
-		prefix = "programmator/"
+	l.baseSHA = l.syncBaseBranch()
+	if l.reviewConfig.BaseBranch == "" {
+		l.reviewConfig.BaseBranch = l.gitConfig.BaseBranch
 	}
 
-	branchName := gitutil.BranchNameFromSource(sourceID, isPlan)
-	if !strings.HasPrefix(branchName, prefix) {
-		// BranchNameFromSource already adds "programmator/", replace with configured prefix
-		branchName = prefix + strings.TrimPrefix(branchName, "programmator/")
+	// The review diff covers everything the run changes from here on, so it
+	// is taken after any rebase onto the base branch. Size rules measure the
+	// same diff.
+	if (l.reviewConfig.IncludeDiff || l.reviewConfig.HasSizeRules()) && l.reviewConfig.DiffBase == "" {
+		if head, err := gitutil.HeadCommit(repo.Root()); err == nil {
+			l.reviewConfig.DiffBase = head
+		}
 	}
+	return nil
+}
+
+// createRunBranch creates or checks out the branch the run commits to.
+func (l *Loop) createRunBranch(sourceID string, isPlan bool) error {
+	branchName := l.runBranchName(sourceID, isPlan)
 
 	// Create or checkout the branch
-	l.log(fmt.Sprintf("Setting up branch: %s", branchName))
+	l.say(messages.SettingUpBranch, messages.Args{"Branch": branchName})
 
 	if err := l.gitRepo.CreateBranch(branchName); err != nil {
 		return fmt.Errorf("create branch: %w", err)
@@ -189,17 +486,41 @@ func (l *Loop) setupGitWorkflow(sourceID string, isPlan bool) error {
 	return nil
 }
 
+// runBranchName is the branch a run on sourceID commits to, under the
+// configured prefix.
+func (l *Loop) runBranchName(sourceID string, isPlan bool) string {
+	prefix := l.gitConfig.BranchPrefix
+	if prefix == "" {
+		prefix = "programmator/"
+	}
+
+	branchName := gitutil.BranchNameFromSource(sourceID, isPlan)
+	if !strings.HasPrefix(branchName, prefix) {
+		// BranchNameFromSource already adds "programmator/", replace with configured prefix
+		branchName = prefix + strings.TrimPrefix(branchName, "programmator/")
+	}
+	return branchName
+}
+
 // autoCommitPhase commits changes after a phase is completed.
-func (l *Loop) autoCommitPhase(phaseName string, filesChanged []string) error {
+func (l *Loop) autoCommitPhase(rc *runContext, phaseName string, filesChanged []string) error {
 	if !l.gitConfig.AutoCommit || l.gitRepo == nil || len(filesChanged) == 0 {
 		return nil
 	}
 
-	l.log(fmt.Sprintf("Auto-committing: %s", phaseName))
+	filesChanged = l.holdForSignOff(rc, phaseName, filesChanged)
+	if len(filesChanged) == 0 {
+		return nil
+	}
+
+	l.say(messages.AutoCommitting, messages.Args{"Phase": phaseName})
 
-	if err := l.gitRepo.AddAndCommit(filesChanged, phaseName); err != nil {
+	err := l.gitRepo.AddAndCommit(filesChanged, phaseName)
+	l.refreshActiveFiles() // commit hooks may have rewritten files
+	if err != nil {
 		return fmt.Errorf("auto-commit: %w", err)
 	}
+	l.recordCommit(rc)
 
 	return nil
 }
@@ -236,6 +557,7 @@ func (l *Loop) moveCompletedPlan(rc *runContext) error {
 	}
 
 	l.log(fmt.Sprintf("Moved completed plan to: %s", newPath))
+	indexed := l.indexCompletedPlan(rc, destDir, newPath)
 
 	// If auto-commit is enabled, commit the move
 	if l.gitConfig.AutoCommit && l.gitRepo != nil {
@@ -257,6 +579,11 @@ func (l *Loop) moveCompletedPlan(rc *runContext) error {
 				l.log(fmt.Sprintf("Warning: failed to stage plan deletion: %v", rmErr))
 				stagingOK = false
 			}
+			if relIndex, err := filepath.Rel(l.workingDir, filepath.Join(destDir, plan.IndexFile)); indexed && err == nil {
+				if addErr := l.gitRepo.Add(relIndex); addErr != nil {
+					l.log(fmt.Sprintf("Warning: failed to stage plan index: %v", addErr))
+				}
+			}
 		}
 
 		if !stagingOK {
@@ -267,6 +594,7 @@ func (l *Loop) moveCompletedPlan(rc *runContext) error {
 				l.log(fmt.Sprintf("Warning: failed to commit plan move: %v", err))
 			} else {
 				l.log("Committed plan move")
+				l.recordCommit(rc)
 			}
 		}
 	}
@@ -274,6 +602,30 @@ func (l *Loop) moveCompletedPlan(rc *runContext) error {
 	return nil
 }
 
+// indexCompletedPlan records the plan moved to planPath, and the run that
+// completed it, in the index of the completed plans directory. Returns
+// false when the index could not be written.
+func (l *Loop) indexCompletedPlan(rc *runContext, dir, planPath string) bool {
+	entry := plan.IndexEntry{
+		File:         filepath.Base(planPath),
+		Title:        rc.workItem.Title,
+		CompletedAt:  time.Now().UTC(),
+		Iterations:   rc.state.Iteration,
+		Duration:     time.Since(rc.state.StartTime).Round(time.Second).Seconds(),
+		FilesChanged: len(rc.result.TotalFilesChanged),
+		Labels:       l.labels,
+		RunID:        rc.result.RunID,
+	}
+	if l.gitRepo != nil {
+		entry.Branch, _ = l.gitRepo.CurrentBranch()
+	}
+	if err := plan.AddToIndex(dir, entry); err != nil {
+		l.log(fmt.Sprintf("Warning: failed to update the completed plans index: %v", err))
+		return false
+	}
+	return true
+}
+
 // loopAction indicates what the main loop should do next.
 type loopAction int
 
@@ -295,14 +647,43 @@ type runContext struct {
 	workItem           *domain.WorkItem
 	iterationSummaries []string // Track summaries for each iteration
 	taskCompleted      bool     // Claude reported DONE for the task
+	parallelDisabled   bool     // Parallel phases fell back to sequential execution
+
+	supervisorInterventions int    // Times the supervisor was consulted
+	supervisorGuidance      string // Supervisor instructions for the current phase
+
+	userAnswers    []string // User answers to BLOCKED questions for the current phase
+	answersPending bool     // An answer has not been sent to the executor yet
+
+	missingContext map[string]bool        // Context file references already reported missing
+	scanBaseline   map[string]bool        // Diff scan findings present before the run started
+	fileTimes      fileTimes              // Last scan of the working directory, outside a git repository
+	unpushed       int                    // Commits made since the last successful push
+	statusSet      bool                   // The pull request's pending commit status was set
+	snapshots      int                    // Iterations committed to the snapshot branch
+	guarded        *guardedFile           // Work item file as it was before the current invocation
+	worktree       *gitutil.WorktreeState // Git worktree as it was before the current invocation
+	signOff        []*regexp.Regexp       // Compiled GitWorkflowConfig.SignOffPaths
+	squashBase     string                 // HEAD before the first iteration, when commits are squashed
+	coverageBase   string                 // HEAD before the first iteration, when the coverage gate is on
+	coverageBefore map[string]float64     // Coverage of the changed packages at coverageBase, once measured
+	pushed         bool                   // Commits were pushed during the run
+
+	skippedPhases []string        // Phases skipped by the user, done for this run only
+	phaseAttempts map[string]int  // Iterations started per phase
+	splitPhases   map[string]bool // Phases already split, or created by a split
+
+	sessions int // Sessions the iteration and token counts span, this one included
+
+	notes *notes.Writer // Filters and renders notes for the run's source
 }
 
 // checkStopRequested checks if stop was requested and handles the response.
 // Returns loopReturn if we should exit, loopContinue otherwise.
 func (l *Loop) checkStopRequested(rc *runContext) loopAction {
 	if l.stopRequested.Load() {
-		l.log("Stop requested by user")
-		_ = rc.source.AddNote(rc.workItemID, fmt.Sprintf("progress: Stopped by user after %d iterations", rc.state.Iteration))
+		l.say(messages.StopRequested, nil)
+		l.addNote(rc, notes.Final, fmt.Sprintf("progress: Stopped by user after %d iterations", rc.state.Iteration))
 		rc.result.ExitReason = safety.ExitReasonUserInterrupt
 		rc.result.Iterations = rc.state.Iteration
 		return loopReturn
@@ -334,12 +715,15 @@ func (l *Loop) handleAllPhasesComplete(rc *runContext) loopAction {
 
 	// If we have pending review fixes, invoke Claude to fix them
 	if l.engine.PendingReviewFix {
-		l.log("Pending review fixes - invoking executor to fix issues")
+		l.say(messages.ReviewFixesPending, nil)
 		return loopBreakToClaudeInvocation
 	}
 
 	// Check if we should run review
-	if !l.engine.ReviewPassed {
+	switch {
+	case l.reviewConfig.Skip:
+		l.say(messages.ReviewSkipped, nil)
+	case !l.engine.ReviewPassed:
 		return l.handleReview(rc)
 	}
 
@@ -362,34 +746,37 @@ func (l *Loop) handleReview(rc *runContext) loopAction {
 	if len(l.reviewConfig.Agents) == 0 {
 		err := fmt.Errorf("review enabled but no review agents configured (review.agents)")
 		l.log(err.Error())
-		l.addNote(rc, fmt.Sprintf("error: %s", err.Error()))
+		l.addNote(rc, notes.Final, fmt.Sprintf("error: %s", err.Error()))
 		rc.result.ExitReason = safety.ExitReasonError
 		rc.result.ExitMessage = err.Error()
 		return loopReturn
 	}
 
 	// Check iteration limit before starting a new review+fix cycle.
-	if l.engine.MaxReviewIter > 0 && l.engine.ReviewIterations >= l.engine.MaxReviewIter {
+	if !l.engine.StartReview() {
 		l.log(fmt.Sprintf("Review iteration limit reached (%d/%d) - completing",
 			l.engine.ReviewIterations, l.engine.MaxReviewIter))
-		l.addNote(rc, fmt.Sprintf("warning: Review iteration limit reached (%d)",
+		l.addNote(rc, notes.Phase, fmt.Sprintf("warning: Review iteration limit reached (%d)",
 			l.engine.MaxReviewIter))
 		rc.state.ExitReviewPhase()
+		l.fileDeferredFindings(rc)
 		return l.completeAllPhases(rc)
 	}
-	l.engine.ReviewIterations++
 
-	l.log(fmt.Sprintf("Review iteration %d/%d",
-		l.engine.ReviewIterations, l.engine.MaxReviewIter))
+	l.say(messages.ReviewIteration, messages.Args{"Iteration": l.engine.ReviewIterations, "Max": l.engine.MaxReviewIter})
 
 	rc.state.EnterReviewPhase()
+	l.reviewProgress.reset()
+	rc.state.Review = l.reviewProgress.snapshot()
 
 	// Start a ticker to refresh the footer every second during review,
 	// since review agents bypass invokeClaudePrint and its pollProcessStats.
+	// The ticker alone updates rc.state.Review until it is stopped.
 	stopTicker := make(chan struct{})
+	var tickerDone sync.WaitGroup
 	if l.onStateChange != nil {
 		l.onStateChange(rc.state, rc.workItem, rc.result.TotalFilesChanged)
-		go func() {
+		tickerDone.Go(func() {
 			ticker := time.NewTicker(1 * time.Second)
 			defer ticker.Stop()
 			for {
@@ -397,26 +784,30 @@ func (l *Loop) handleReview(rc *runContext) loopAction {
 				case <-stopTicker:
 					return
 				case <-ticker.C:
+					rc.state.Review = l.reviewProgress.snapshot()
 					l.onStateChange(rc.state, rc.workItem, rc.result.TotalFilesChanged)
 				}
 			}
-		}()
+		})
 	}
-	defer close(stopTicker)
 
 	if l.reviewRunner == nil {
 		l.applySettingsToReviewConfig()
 		l.applyReviewContext(rc.workItem)
 		l.reviewRunner = review.NewRunner(l.reviewConfig)
 		if l.onEvent != nil {
-			l.reviewRunner.SetEventCallback(event.Handler(l.onEvent))
+			l.reviewRunner.SetEventCallback(event.Handler(l.emit))
 		}
 	}
+	l.reviewRunner.SetProgressCallback(l.reviewProgress.record)
 
 	reviewResult, err := l.reviewRunner.RunIteration(rc.ctx, l.workingDir, rc.result.TotalFilesChanged)
+	close(stopTicker)
+	tickerDone.Wait()
+	rc.state.Review = nil
 	if err != nil {
 		l.log(fmt.Sprintf("Review error: %v", err))
-		l.addNote(rc, fmt.Sprintf("error: Review failed: %v", err))
+		l.addNote(rc, notes.Final, fmt.Sprintf("error: Review failed: %v", err))
 		rc.result.ExitReason = safety.ExitReasonError
 		rc.result.ExitMessage = err.Error()
 		rc.result.Iterations = rc.state.Iteration
@@ -424,6 +815,9 @@ func (l *Loop) handleReview(rc *runContext) loopAction {
 	}
 
 	rc.state.RecordReviewIteration()
+	if l.onReview != nil {
+		l.onReview(reviewResult)
+	}
 
 	errorCount := countReviewErrors(reviewResult.Results)
 	if errorCount > 0 {
@@ -431,10 +825,10 @@ func (l *Loop) handleReview(rc *runContext) loopAction {
 		rc.state.ConsecutiveNoChanges++
 
 		// Check if stagnation limit exceeded
-		checkResult := safety.Check(l.config, rc.state)
+		checkResult := l.engine.CheckSafety(rc.state)
 		if checkResult.ShouldExit {
 			l.log(fmt.Sprintf("Review agent errors (%d) - %s", errorCount, checkResult.Message))
-			l.addNote(rc, fmt.Sprintf("error: Review agent errors - %s", checkResult.Message))
+			l.addNote(rc, notes.Final, fmt.Sprintf("error: Review agent errors - %s", checkResult.Message))
 			rc.result.ExitReason = checkResult.Reason
 			rc.result.ExitMessage = checkResult.Message
 			rc.result.Iterations = rc.state.Iteration
@@ -443,7 +837,10 @@ func (l *Loop) handleReview(rc *runContext) loopAction {
 		}
 
 		l.log(fmt.Sprintf("Review agent errors (%d) - retrying review without invoking Claude", errorCount))
-		l.addNote(rc, fmt.Sprintf("warning: Review agent errors (%d) - retrying review", errorCount))
+		for range errorCount {
+			l.metrics.RecordRetry()
+		}
+		l.addNote(rc, notes.Iteration, fmt.Sprintf("warning: Review agent errors (%d) - retrying review", errorCount))
 
 		l.engine.ReviewIterations--
 		l.engine.PendingReviewFix = false
@@ -454,31 +851,83 @@ func (l *Loop) handleReview(rc *runContext) loopAction {
 	// Reset stagnation counter on successful review run
 	rc.state.ConsecutiveNoChanges = 0
 
-	decision := l.engine.DecideReview(reviewResult.Passed)
+	decision := l.engine.DecideReview(reviewResult.Passed, reviewResult.IssueFingerprints())
 
 	if decision.Passed {
-		l.log("Review passed - no issues found")
-		l.addNote(rc, "progress: Review passed")
+		l.say(messages.ReviewPassed, nil)
+		l.addNote(rc, notes.Phase, "progress: Review passed")
+		l.recordResidualIssues(rc, reviewResult)
 		rc.state.ExitReviewPhase()
 		return l.completeAllPhases(rc)
 	}
 
 	issueNote := review.FormatIssuesMarkdown(reviewResult.Results)
-	l.lastReviewIssues = issueNote
+
+	if decision.NotConverging {
+		msg := fmt.Sprintf("review reported the same %d issues in consecutive iterations",
+			reviewResult.TotalIssues)
+		l.log(fmt.Sprintf("Review not converging: %s - stopping", msg))
+		l.log(fmt.Sprintf("Stuck issues:\n%s", issueNote))
+		l.addNote(rc, notes.Final, fmt.Sprintf("error: [iter %d] Review not converging, stuck issues:\n%s",
+			l.engine.ReviewIterations, issueNote))
+		rc.result.ExitReason = safety.ExitReasonReviewStuck
+		rc.result.ExitMessage = msg
+		rc.result.Iterations = rc.state.Iteration
+		return loopReturn
+	}
+
+	if l.reviewConfig.AutoApplyPatches {
+		scanBase := l.diffScanHead()
+		if remaining, applied := l.applyReviewPatches(rc, reviewResult.Results); applied > 0 {
+			if l.scanDiffSince(rc, scanBase) {
+				return loopReturn
+			}
+			l.addNote(rc, notes.Iteration, fmt.Sprintf("progress: [iter %d] Applied %d suggested review patch(es)",
+				l.engine.ReviewIterations, applied))
+			reviewResult.Results = remaining
+			reviewResult.TotalIssues = len(reviewResult.AllIssues())
+			if reviewResult.TotalIssues == 0 {
+				// Every issue was fixed by a patch; review again instead of invoking the executor.
+				l.engine.PendingReviewFix = false
+				return loopRetryReview
+			}
+			issueNote = review.FormatIssuesMarkdown(remaining)
+		}
+	}
+	l.fixBatch = 0
+	l.setFixIssues(reviewResult.Results)
+	l.openFindings = reviewResult.Results
 
 	// NeedsFix: invoke Claude to fix issues
-	l.log(fmt.Sprintf("Review found %d issues", reviewResult.TotalIssues))
-	l.addNote(rc, fmt.Sprintf("review: [iter %d] Found %d issues:\n%s",
+	l.say(messages.ReviewFound, messages.Args{"Count": reviewResult.TotalIssues})
+	l.addNote(rc, notes.Iteration, fmt.Sprintf("review: [iter %d] Found %d issues:\n%s",
 		l.engine.ReviewIterations, reviewResult.TotalIssues, issueNote))
 
 	return loopBreakToClaudeInvocation
 }
 
-// completeAllPhases marks the work item as complete and returns.
+// completeAllPhases runs the coverage gate, then marks the work item as
+// complete and returns.
 func (l *Loop) completeAllPhases(rc *runContext) loopAction {
-	l.log("All phases complete!")
+	if !l.runCoverageGate(rc) {
+		return loopReturn
+	}
+	if !l.runDocsPhase(rc) {
+		return loopReturn
+	}
+
+	l.say(messages.AllPhasesComplete, nil)
+	if len(rc.skippedPhases) > 0 {
+		// Skipped phases are still open: the work item is not done.
+		l.log(fmt.Sprintf("Work item left open for the skipped phases: %s", strings.Join(rc.skippedPhases, ", ")))
+		l.addNote(rc, notes.Final, fmt.Sprintf("progress: Completed all phases in %d iterations except the skipped %s",
+			rc.state.Iteration, strings.Join(rc.skippedPhases, ", ")))
+		rc.result.ExitReason = safety.ExitReasonComplete
+		rc.result.Iterations = rc.state.Iteration
+		return loopReturn
+	}
 	_ = rc.source.SetStatus(rc.workItemID, protocol.WorkItemClosed)
-	_ = rc.source.AddNote(rc.workItemID, fmt.Sprintf("progress: Completed all phases in %d iterations", rc.state.Iteration))
+	l.addNote(rc, notes.Final, fmt.Sprintf("progress: Completed all phases in %d iterations", rc.state.Iteration))
 
 	// Move completed plan if configured
 	if err := l.moveCompletedPlan(rc); err != nil {
@@ -493,9 +942,10 @@ func (l *Loop) completeAllPhases(rc *runContext) loopAction {
 // processClaudeStatus processes the status returned by Claude.
 // Returns loopReturn if we should exit, loopContinue otherwise.
 func (l *Loop) processClaudeStatus(rc *runContext, status *parser.ParsedStatus) loopAction {
-	l.log(fmt.Sprintf("Status: %s", status.Status))
-	l.log(fmt.Sprintf("Summary: %s", status.Summary))
+	l.say(messages.Status, messages.Args{"Status": status.Status})
+	l.emit(event.IterationSummary(l.messages.Format(messages.Summary, messages.Args{"Summary": status.Summary}), status.Summary))
 
+	l.dropPhantomChanges(rc, status)
 	rc.result.FinalStatus = status
 	phaseProgressed := l.recordPhaseProgress(rc, status)
 	l.trackFilesChanged(rc, status)
@@ -503,12 +953,20 @@ func (l *Loop) processClaudeStatus(rc *runContext, status *parser.ParsedStatus)
 	// Track iteration summary for stagnation debugging
 	rc.iterationSummaries = append(rc.iterationSummaries,
 		FormatIterationSummary(rc.state.Iteration, status.Summary, status.FilesChanged))
+	l.snapshotIteration(rc, status.Summary)
 
-	rc.state.RecordIteration(status.FilesChanged, status.Error)
+	filesChanged := status.FilesChanged
+	if rc.fileTimes != nil {
+		filesChanged = l.touchedFiles(rc)
+		rc.addFilesChanged(filesChanged)
+	}
+	rc.state.RecordIteration(filesChanged, status.Error)
 	if phaseProgressed {
 		// A successfully completed phase is meaningful progress even when no files
 		// changed in this iteration (e.g. validation-only or pre-completed work).
 		rc.state.ConsecutiveNoChanges = 0
+		rc.supervisorGuidance = ""
+		rc.userAnswers = nil
 	}
 
 	// Use engine to process status
@@ -518,9 +976,10 @@ func (l *Loop) processClaudeStatus(rc *runContext, status *parser.ParsedStatus)
 		PendingReviewFix: l.engine.PendingReviewFix,
 	})
 
-	if result.ResetPendingReviewFix {
+	if result.ResetPendingReviewFix && !l.nextFixBatch() {
 		l.engine.PendingReviewFix = false
 		l.lastReviewIssues = ""
+		l.lastReviewExcerpts = ""
 	}
 
 	if l.onStateChange != nil {
@@ -528,17 +987,23 @@ func (l *Loop) processClaudeStatus(rc *runContext, status *parser.ParsedStatus)
 	}
 
 	if result.TaskCompleted {
-		l.log("Executor reported DONE")
+		l.say(messages.Done, nil)
 		rc.taskCompleted = true
 		if !rc.state.InReviewPhase {
-			l.addNote(rc, fmt.Sprintf("progress: Task marked complete in %d iterations", rc.state.Iteration))
+			l.addNote(rc, notes.Final, fmt.Sprintf("progress: Task marked complete in %d iterations", rc.state.Iteration))
 		}
 		return loopContinue
 	}
 
 	if result.ShouldExit {
-		l.log(fmt.Sprintf("Executor reported BLOCKED: %s", result.BlockedError))
-		l.addNote(rc, fmt.Sprintf("error: [iter %d] BLOCKED: %s", rc.state.Iteration, result.BlockedError))
+		l.say(messages.Blocked, messages.Args{"Error": result.BlockedError})
+		l.addNote(rc, notes.Final, fmt.Sprintf("error: [iter %d] BLOCKED: %s", rc.state.Iteration, result.BlockedError))
+		if l.askBlockedQuestions(rc, result.BlockedError, status.Questions) {
+			return loopContinue
+		}
+		if l.superviseStuckRun(rc, result.ExitReason, result.BlockedError) {
+			return loopContinue
+		}
 		rc.result.ExitReason = result.ExitReason
 		rc.result.Iterations = rc.state.Iteration
 		return loopReturn
@@ -550,7 +1015,10 @@ func (l *Loop) processClaudeStatus(rc *runContext, status *parser.ParsedStatus)
 // recordPhaseProgress records phase completion or progress notes.
 func (l *Loop) recordPhaseProgress(rc *runContext, status *parser.ParsedStatus) bool {
 	if status.PhaseCompleted != "" {
-		l.log(fmt.Sprintf("Phase completed: %s", status.PhaseCompleted))
+		l.say(messages.PhaseCompleted, messages.Args{"Phase": status.PhaseCompleted})
+		if candidates := ambiguousPhaseMatches(rc.workItem, status.PhaseCompleted); candidates != nil {
+			return l.completeAmbiguousPhase(rc, status, candidates)
+		}
 		if err := rc.source.UpdatePhase(rc.workItemID, status.PhaseCompleted); err != nil {
 			l.log(fmt.Sprintf("Warning: failed to update phase '%s': %v", status.PhaseCompleted, err))
 
@@ -560,9 +1028,9 @@ func (l *Loop) recordPhaseProgress(rc *runContext, status *parser.ParsedStatus)
 				if fallbackErr == nil {
 					l.log(fmt.Sprintf("Phase fallback succeeded: mapped '%s' to '%s'",
 						status.PhaseCompleted, fallbackName))
-					l.addNote(rc, fmt.Sprintf("progress: [iter %d] Completed %s (reported as %s)",
+					l.addNote(rc, notes.Phase, fmt.Sprintf("progress: [iter %d] Completed %s (reported as %s)",
 						rc.state.Iteration, fallbackName, status.PhaseCompleted))
-					if autoCommitErr := l.autoCommitPhase(fallbackName, status.FilesChanged); autoCommitErr != nil {
+					if autoCommitErr := l.autoCommitPhase(rc, fallbackName, status.FilesChanged); autoCommitErr != nil {
 						l.log(fmt.Sprintf("Warning: auto-commit failed: %v", autoCommitErr))
 					}
 					return true
@@ -571,19 +1039,19 @@ func (l *Loop) recordPhaseProgress(rc *runContext, status *parser.ParsedStatus)
 					fallbackName, fallbackErr))
 			}
 
-			l.addNote(rc, fmt.Sprintf("warning: [iter %d] Failed to update phase '%s': %v",
+			l.addNote(rc, notes.Phase, fmt.Sprintf("warning: [iter %d] Failed to update phase '%s': %v",
 				rc.state.Iteration, status.PhaseCompleted, err))
 			return false
 		}
-		l.addNote(rc, fmt.Sprintf("progress: [iter %d] Completed %s", rc.state.Iteration, status.PhaseCompleted))
+		l.addNote(rc, notes.Phase, fmt.Sprintf("progress: [iter %d] Completed %s", rc.state.Iteration, status.PhaseCompleted))
 
 		// Auto-commit after phase completion if enabled
-		if err := l.autoCommitPhase(status.PhaseCompleted, status.FilesChanged); err != nil {
+		if err := l.autoCommitPhase(rc, status.PhaseCompleted, status.FilesChanged); err != nil {
 			l.log(fmt.Sprintf("Warning: auto-commit failed: %v", err))
 		}
 		return true
 	}
-	l.addNote(rc, fmt.Sprintf("progress: [iter %d] %s", rc.state.Iteration, status.Summary))
+	l.addNote(rc, notes.Iteration, fmt.Sprintf("progress: [iter %d] %s", rc.state.Iteration, status.Summary))
 	return false
 }
 
@@ -687,12 +1155,18 @@ func normalizePhaseForLooseCompare(s string) string {
 // trackFilesChanged records which files were changed.
 func (l *Loop) trackFilesChanged(rc *runContext, status *parser.ParsedStatus) {
 	if len(status.FilesChanged) > 0 {
-		l.log(fmt.Sprintf("Files changed: %s", strings.Join(status.FilesChanged, ", ")))
-		for _, f := range status.FilesChanged {
-			if _, exists := rc.filesChangedSet[f]; !exists {
-				rc.filesChangedSet[f] = struct{}{}
-				rc.result.TotalFilesChanged = append(rc.result.TotalFilesChanged, f)
-			}
+		l.say(messages.FilesChanged, messages.Args{"Files": strings.Join(status.FilesChanged, ", ")})
+		rc.addFilesChanged(status.FilesChanged)
+	}
+}
+
+// addFilesChanged adds files to the run's changed files, which the review
+// covers.
+func (rc *runContext) addFilesChanged(files []string) {
+	for _, f := range files {
+		if _, exists := rc.filesChangedSet[f]; !exists {
+			rc.filesChangedSet[f] = struct{}{}
+			rc.result.TotalFilesChanged = append(rc.result.TotalFilesChanged, f)
 		}
 	}
 }
@@ -708,16 +1182,27 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 	src := l.source
 	if src == nil {
 		// Auto-detect source type based on workItemID
-		src, workItemID = source.Detect(workItemID, l.ticketCommand)
+		src, workItemID = source.Detect(workItemID, l.ticketCommand, l.ticketWorkspaces)
 	}
 	timing.Log("Loop.Run: source created")
+	if l.statusKey != "" && l.promptBuilder != nil {
+		l.promptBuilder = l.promptBuilder.WithStatusKey(l.statusKey)
+	}
 
 	result := &Result{
 		ExitReason:        safety.ExitReasonComplete,
 		TotalFilesChanged: make([]string, 0),
+		Labels:            l.labels,
+		RunID:             fmt.Sprintf("%s-%d", startTime.Format("20060102-150405"), os.Getpid()),
 	}
+	l.engine.Start()
 	defer func() {
 		result.Duration = time.Since(startTime)
+		result.Invocations = l.metrics.Stats()
+		if l.reviewRunner != nil {
+			result.ReviewLifecycle = l.reviewRunner.Lifecycle()
+		}
+		l.engine.Finish(result.ExitReason, result.ExitMessage)
 	}()
 
 	timing.Log("Loop.Run: fetching work item")
@@ -731,7 +1216,7 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 	l.logStartBanner(src.Type(), workItemID, workItem)
 
 	// Validate review config before changing ticket state
-	if len(l.reviewConfig.Agents) == 0 {
+	if len(l.reviewConfig.Agents) == 0 && !l.reviewConfig.Skip {
 		err := fmt.Errorf("review enabled but no review agents configured (review.agents)")
 		l.log(err.Error())
 		result.ExitReason = safety.ExitReasonError
@@ -739,12 +1224,50 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 		return result, err
 	}
 
+	// Checkboxes under the acceptance criteria heading are not phases; with
+	// the acceptance check off nothing would ever look at them.
+	if n := workItem.AcceptanceCheckboxes(); n > 0 && (!l.reviewConfig.AcceptanceCheck || l.reviewConfig.Skip) {
+		err := fmt.Errorf("%d checkbox(es) under the Acceptance Criteria heading are criteria, not phases, and are only verified with review.acceptance_check on: turn it on or move them out of the section", n)
+		l.log(err.Error())
+		result.ExitReason = safety.ExitReasonError
+		result.ExitMessage = err.Error()
+		return result, err
+	}
+
+	signOff, err := compileSignOffPaths(l.gitConfig.SignOffPaths)
+	if err != nil {
+		l.log(err.Error())
+		result.ExitReason = safety.ExitReasonError
+		result.ExitMessage = err.Error()
+		return result, err
+	}
+
+	if l.fresh {
+		if workItem, err = l.startFresh(src, workItemID, workItem); err != nil {
+			l.log(err.Error())
+			result.ExitReason = safety.ExitReasonError
+			result.ExitMessage = err.Error()
+			return result, err
+		}
+	}
+
 	_ = src.SetStatus(workItemID, protocol.WorkItemInProgress)
 
+	if co, ok := src.(source.Checkouter); ok {
+		if err := l.checkoutWorkItem(co, workItemID); err != nil {
+			l.log(err.Error())
+			result.ExitReason = safety.ExitReasonError
+			result.ExitMessage = err.Error()
+			return result, err
+		}
+	}
+
 	// Set up git repo and optionally create branch
 	if err := l.setupGitWorkflow(workItemID, src.Type() == protocol.SourceTypePlan); err != nil {
 		l.log(fmt.Sprintf("Warning: git workflow setup failed: %v", err))
 	}
+	result.BaseSHA = l.baseSHA
+	result.NoGit = l.noGit
 
 	rc := &runContext{
 		ctx:             ctx,
@@ -754,7 +1277,29 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 		result:          result,
 		filesChangedSet: make(map[string]struct{}),
 		workItem:        workItem,
-	}
+		signOff:         signOff,
+		phaseAttempts:   make(map[string]int),
+		splitPhases:     make(map[string]bool),
+	}
+	if l.noGit {
+		rc.fileTimes = scanFileTimes(cmp.Or(l.workingDir, "."))
+	}
+	l.initNotes(rc)
+	l.startActiveFiles(rc)
+	defer l.stopActiveFiles()
+	l.resumeCounts(rc)
+	l.initDiffScanBaseline(rc)
+	l.recordSquashBase(rc)
+	l.recordCoverageBase(rc)
+	l.seedPullRequestChanges(rc)
+	rc.addFilesChanged(l.reviewOnlyFiles)
+	l.planPhases(rc)
+	defer l.pushCommits(rc)
+	defer l.stageSignOffFiles(rc)
+	defer l.finishSnapshots(rc)
+	defer l.reportResult(rc)
+	defer l.squashCommits(rc)
+	defer l.recordCheckpoint(rc)
 
 	if l.onStateChange != nil {
 		l.onStateChange(rc.state, rc.workItem, nil)
@@ -774,6 +1319,15 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 			rc.result.ExitReason = safety.ExitReasonError
 			return rc.result, err
 		}
+		if len(rc.workItem.ValidationCommands) == 0 {
+			rc.workItem.ValidationCommands = l.validationCommands
+		}
+		l.applySkippedPhases(rc)
+		l.loadContextFiles(rc)
+
+		if l.handleSkipRequest(rc) {
+			continue
+		}
 
 		action := l.handleAllPhasesComplete(rc)
 		if action == loopReturn {
@@ -784,12 +1338,18 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 		}
 		// If action == loopBreakToClaudeInvocation, we fall through to invoke Claude
 
+		l.recordCheckpoint(rc)
+		l.updatePullRequestSummary(rc)
 		rc.state.Iteration++
 
-		checkResult := safety.Check(l.config, rc.state)
+		checkResult := l.engine.CheckSafety(rc.state)
 		if checkResult.ShouldExit {
-			l.log(fmt.Sprintf("Safety exit: %s", checkResult.Reason))
-			l.addNote(rc, fmt.Sprintf("error: Safety exit after %d iters: %s", rc.state.Iteration, checkResult.Reason))
+			if l.superviseStuckRun(rc, checkResult.Reason, checkResult.Message) {
+				rc.state.Iteration--
+				continue
+			}
+			l.say(messages.SafetyExit, messages.Args{"Reason": checkResult.Reason})
+			l.addNote(rc, notes.Final, fmt.Sprintf("error: Safety exit after %d iters: %s", rc.state.Iteration, checkResult.Reason))
 			rc.result.ExitReason = checkResult.Reason
 			rc.result.ExitMessage = checkResult.Message
 			rc.result.Iterations = rc.state.Iteration
@@ -797,33 +1357,49 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 			return rc.result, nil
 		}
 
+		if !l.pace(rc) || !l.checkConcurrentEdits(rc) {
+			// Stopped while waiting; the checks above end the run.
+			rc.state.Iteration--
+			continue
+		}
+
 		currentPhase := rc.workItem.CurrentPhase()
 		l.logIterationSeparator(rc.state.Iteration, l.config.MaxIterations)
-		l.log(fmt.Sprintf("Iteration %d/%d", rc.state.Iteration, l.config.MaxIterations))
+		l.say(messages.Iteration, messages.Args{"Iteration": rc.state.Iteration, "Max": l.config.MaxIterations})
+
+		if batch := l.parallelBatch(rc); batch != nil {
+			scanBase := l.diffScanHead()
+			l.runParallelPhases(rc, batch)
+			if l.scanDiffSince(rc, scanBase) {
+				return rc.result, nil
+			}
+			continue
+		}
+
 		if currentPhase != nil {
-			l.log(fmt.Sprintf("Current phase: %s", currentPhase.Name))
+			l.say(messages.CurrentPhase, messages.Args{"Phase": currentPhase.Name})
 		}
 
-		var promptText string
-		if l.engine.PendingReviewFix && l.promptBuilder != nil {
-			// Use review fix prompt with the stored issues so review templates apply
-			var promptErr error
-			promptText, promptErr = l.promptBuilder.BuildReviewFirst("", rc.result.TotalFilesChanged, l.lastReviewIssues, l.engine.ReviewIterations, l.gitConfig.AutoCommit)
-			if promptErr != nil {
-				l.log(fmt.Sprintf("Failed to build review fix prompt: %v, falling back to task prompt", promptErr))
-				promptText = prompt.Build(rc.workItem)
-			}
-		} else if l.promptBuilder != nil {
-			var promptErr error
-			promptText, promptErr = l.promptBuilder.Build(rc.workItem)
-			if promptErr != nil {
-				l.log(fmt.Sprintf("Failed to build prompt from templates: %v, falling back to defaults", promptErr))
-				promptText = prompt.Build(rc.workItem)
-			}
-		} else {
-			promptText = prompt.Build(rc.workItem)
+		if reason := l.splitReason(rc, currentPhase); reason != "" && l.splitPhase(rc, currentPhase, reason) {
+			rc.state.Iteration--
+			continue
+		}
+		if currentPhase != nil && !l.engine.PendingReviewFix {
+			rc.phaseAttempts[currentPhase.Name]++
 		}
 
+		resumeID := l.resumableSession(l.sessionScopeFor(currentPhase))
+
+		if resumeID != "" {
+			l.say(messages.Resuming, nil)
+		}
+		promptText, builtTokens := l.fitPrompt(rc, resumeID)
+		if resumeID == "" {
+			promptText = withSupervisorGuidance(promptText, rc.supervisorGuidance)
+		}
+		promptText = rc.withUserAnswers(promptText, resumeID != "")
+		l.checkContextWindow(rc, builtTokens, promptText)
+
 		l.currentState = rc.state
 		l.currentWorkItem = rc.workItem
 
@@ -831,16 +1407,45 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 			l.onStateChange(rc.state, rc.workItem, rc.result.TotalFilesChanged)
 		}
 
-		l.log(fmt.Sprintf("Invoking %s...", l.executorName()))
+		l.say(messages.Invoking, messages.Args{"Executor": l.executorName()})
 
-		output, err := l.invokeClaudePrint(ctx, promptText)
+		accessPhase := currentPhase
+		if l.engine.PendingReviewFix {
+			accessPhase = nil
+		}
+		phaseName := ""
+		if currentPhase != nil {
+			phaseName = currentPhase.Name
+		}
+		capture := l.beginIterationRecord(rc, phaseName, promptText, resumeID != "")
+		l.tagFallbackIteration(rc)
+		if l.retryNext {
+			l.metrics.RecordRetry()
+		}
+		l.guardWorkItemFile(rc)
+		l.captureWorktree(rc)
+		scanBase := l.diffScanHead()
+		output, err := l.invokeClaudePrint(ctx, promptText, resumeID, l.invocationFlags(accessPhase))
+		l.restoreWorkItemFile(rc)
+		if l.handleSkipRequest(rc) {
+			if l.onStateChange != nil {
+				l.onStateChange(rc.state, rc.workItem, rc.result.TotalFilesChanged)
+			}
+			continue
+		}
 		if err != nil {
+			l.finishIterationRecord(capture, nil, fmt.Sprintf("invocation failed: %v", err))
 			l.log(fmt.Sprintf("Invocation failed: %v", err))
+			l.sessionID = ""
+			l.retryNext = true
 			rc.state.RecordIteration(nil, "invocation_error")
 			if l.onStateChange != nil {
 				l.onStateChange(rc.state, rc.workItem, rc.result.TotalFilesChanged)
 			}
 			l.consecutiveInvokeErrors++
+			if l.switchToFallback(rc, err) {
+				continue
+			}
 			if l.consecutiveInvokeErrors >= 3 {
 				l.log("3 consecutive invocation failures — exiting")
 				rc.result.ExitReason = safety.ExitReasonError
@@ -851,15 +1456,25 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 			continue
 		}
 		l.consecutiveInvokeErrors = 0
+		l.retryNext = false
 
-		status, err := parser.Parse(output)
+		if l.scanDiffSince(rc, scanBase) {
+			return rc.result, nil
+		}
+
+		status, err := parser.ParseKey(output, l.statusBlockKey())
 		if err != nil {
+			l.metrics.RecordFailure(llm.FailureParse)
+			l.finishIterationRecord(capture, nil, err.Error())
 			rc.result.ExitReason = safety.ExitReasonError
 			return rc.result, err
 		}
 
 		if status == nil {
-			l.log("Warning: No " + protocol.StatusBlockKey + " found in output")
+			l.metrics.RecordFailure(llm.FailureParse)
+			l.retryNext = true
+			l.finishIterationRecord(capture, nil, "no "+l.statusBlockKey()+" found in output")
+			l.log("Warning: No " + l.statusBlockKey() + " found in output")
 			rc.state.RecordIteration(nil, "no_status_block")
 			if l.onStateChange != nil {
 				l.onStateChange(rc.state, rc.workItem, rc.result.TotalFilesChanged)
@@ -867,6 +1482,7 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 			continue
 		}
 
+		l.finishIterationRecord(capture, status, "")
 		if action := l.processClaudeStatus(rc, status); action == loopReturn {
 			return rc.result, nil
 		}
@@ -876,27 +1492,26 @@ func (l *Loop) Run(workItemID string) (*Result, error) {
 // invokeClaudePrint invokes Claude via the llm.Invoker interface.
 // It wires loop-specific callbacks (output formatting, token tracking,
 // process stats) into InvokeOptions.
-func (l *Loop) invokeClaudePrint(ctx context.Context, promptText string) (string, error) {
-	inv := l.invoker
-	if inv == nil {
-		var err error
-		inv, err = executor.New(l.executorConfig)
-		if err != nil {
-			return "", fmt.Errorf("create invoker: %w", err)
-		}
-		l.invoker = inv
+func (l *Loop) invokeClaudePrint(ctx context.Context, promptText, resumeSessionID string, flags []string) (string, error) {
+	inv, err := l.resolveInvoker()
+	if err != nil {
+		return "", err
 	}
 
 	opts := llm.InvokeOptions{
-		WorkingDir: l.workingDir,
-		Streaming:  l.streaming,
-		ExtraFlags: l.executorConfig.ExtraFlags,
-		Timeout:    l.config.Timeout,
+		WorkingDir:      l.workingDir,
+		Streaming:       l.streaming,
+		ExtraFlags:      flags,
+		Purpose:         llm.PurposeMain,
+		Timeout:         l.config.Timeout,
+		LivenessTimeout: l.config.LivenessTimeout,
+		ResumeSessionID: resumeSessionID,
 		OnOutput: func(text string) {
 			l.emit(event.StreamingText(text))
 		},
 		OnToolUse: func(name string, input any) {
 			l.outputToolUse(name, input)
+			l.trackToolUse(name, input)
 		},
 		OnToolResult: func(toolName, result string) {
 			l.handleToolResult(toolName, result)
@@ -907,6 +1522,11 @@ func (l *Loop) invokeClaudePrint(ctx context.Context, promptText string) (string
 				l.notifyStateChange()
 			}
 		},
+		OnSessionID: func(sessionID string) {
+			if l.reuseSessions {
+				l.sessionID = sessionID
+			}
+		},
 		OnTokens: func(inputTokens, outputTokens int) {
 			if l.currentState != nil {
 				l.currentState.SetCurrentIterTokens(inputTokens, outputTokens)
@@ -939,6 +1559,13 @@ func (l *Loop) invokeClaudePrint(ctx context.Context, promptText string) (string
 		defer closeStats() // ensure goroutine stops even if Invoke errors before OnProcessEnd
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	l.setInvokeCancel(cancel)
+	defer l.setInvokeCancel(nil)
+
+	defer l.endActiveInvocation()
+
 	res, err := inv.Invoke(ctx, promptText, opts)
 	if err != nil {
 		return "", err
@@ -1018,18 +1645,18 @@ func formatToolResultSummary(toolName, result string) string {
 }
 
 func (l *Loop) outputToolUse(name string, input any) {
-	if l.onEvent == nil {
+	if l.onEvent == nil && l.onIteration == nil {
 		return
 	}
-	toolLine := name
-	inputMap, hasInput := input.(map[string]any)
-	if hasInput {
-		toolLine += formatToolArg(name, inputMap)
+	toolLine := llm.FormatToolUse(name, input)
+	l.recordToolUse(toolLine)
+	if l.onEvent == nil {
+		return
 	}
 	l.emit(event.ToolUse(toolLine))
 
 	// Show diff for Edit operations
-	if name == "Edit" && hasInput {
+	if inputMap, ok := input.(map[string]any); ok && name == "Edit" {
 		l.outputEditDiff(inputMap)
 	}
 }
@@ -1111,35 +1738,6 @@ func (l *Loop) notifyStateChange() {
 	}
 }
 
-func formatToolArg(toolName string, input map[string]any) string {
-	switch toolName {
-	case "Read", "Write", "Edit":
-		if path, ok := input["file_path"].(string); ok {
-			return " " + path
-		}
-	case "Bash":
-		if cmd, ok := input["command"].(string); ok {
-			if len(cmd) > 80 {
-				cmd = cmd[:80] + "..."
-			}
-			return " " + cmd
-		}
-	case "Glob":
-		if pattern, ok := input["pattern"].(string); ok {
-			return " " + pattern
-		}
-	case "Grep":
-		if pattern, ok := input["pattern"].(string); ok {
-			return " " + pattern
-		}
-	case "Task":
-		if desc, ok := input["description"].(string); ok {
-			return " " + desc
-		}
-	}
-	return ""
-}
-
 func (l *Loop) Stop() {
 	l.stopRequested.Store(true)
 	if l.cancelFunc != nil {
@@ -1151,6 +1749,11 @@ func (l *Loop) log(message string) {
 	l.emit(event.Prog(message))
 }
 
+// say logs message id of the loop's catalog.
+func (l *Loop) say(id messages.ID, args messages.Args) {
+	l.log(l.messages.Format(id, args))
+}
+
 func (l *Loop) logIterationSeparator(iteration, maxIterations int) {
 	l.emit(event.IterationSeparator(fmt.Sprintf("ITER\t%d\t%d", iteration, maxIterations)))
 }
@@ -1168,17 +1771,16 @@ func (l *Loop) logStartBanner(srcType, workItemID string, workItem *domain.WorkI
 			label = "Tasks"
 		}
 		fmt.Fprintf(&b, " %s (%d):\n", label, len(workItem.Phases))
-		seenCurrent := false
-		for _, p := range workItem.Phases {
+		current := workItem.CurrentPhaseIndex()
+		for i, p := range workItem.Phases {
 			indicator := "○"
 			switch {
 			case p.Completed:
 				indicator = "✓"
-			case !seenCurrent:
+			case i == current:
 				indicator = "→"
-				seenCurrent = true
 			}
-			fmt.Fprintf(&b, "   %s %s\n", indicator, p.Name)
+			fmt.Fprintf(&b, "   %s%s %s\n", strings.Repeat("  ", p.Depth), indicator, p.Name)
 		}
 	}
 
@@ -1198,11 +1800,6 @@ func (l *Loop) getRecentSummaries(rc *runContext, n int) []string {
 	return rc.iterationSummaries[len(rc.iterationSummaries)-n:]
 }
 
-// addNote adds a note to the work item, ignoring errors.
-func (l *Loop) addNote(rc *runContext, note string) {
-	_ = rc.source.AddNote(rc.workItemID, note)
-}
-
 func (l *Loop) pollProcessStats(pid int, stop <-chan struct{}) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
@@ -1233,6 +1830,20 @@ func getProcessMemory(pid int) int64 {
 	return rss
 }
 
+// resolveInvoker returns the configured invoker, creating it from the
+// executor config on first use. Calls through it count against the global
+// invocation limit.
+func (l *Loop) resolveInvoker() (llm.Invoker, error) {
+	if l.invoker == nil {
+		inv, err := executor.New(l.executorConfig)
+		if err != nil {
+			return nil, fmt.Errorf("create invoker: %w", err)
+		}
+		l.invoker = inv
+	}
+	return llm.WithConcurrencyLimit(llm.WithMetrics(l.invoker, l.metrics)), nil
+}
+
 // SetInvoker sets the llm.Invoker used for Claude invocations.
 func (l *Loop) SetInvoker(inv llm.Invoker) {
 	l.invoker = inv
@@ -1247,10 +1858,11 @@ func (l *Loop) SetEventCallback(cb EventCallback) {
 	l.onEvent = cb
 }
 
-// emit sends a typed event to the event callback, if set.
+// emit sends a typed event to the event callback, if set, with the
+// secrets in it masked.
 func (l *Loop) emit(e event.Event) {
 	if l.onEvent != nil {
-		l.onEvent(e)
+		l.onEvent(l.redactEvent(e))
 	}
 }
 
@@ -1258,6 +1870,7 @@ func (l *Loop) emit(e event.Event) {
 // ExecutorConfig is already set by ToReviewConfig() via toReviewExecutorConfig(),
 // which handles review-specific executor overrides. Do not overwrite it.
 func (l *Loop) applySettingsToReviewConfig() {
+	l.reviewConfig.Metrics = l.metrics
 }
 
 func (l *Loop) applyReviewContext(workItem *domain.WorkItem) {
@@ -1265,6 +1878,10 @@ func (l *Loop) applyReviewContext(workItem *domain.WorkItem) {
 		return
 	}
 	l.reviewConfig.TicketContext = workItem.RawContent
+	l.reviewConfig.AcceptanceCriteria = workItem.AcceptanceCriteria()
+	if len(workItem.ValidationCommands) > 0 {
+		l.reviewConfig.ValidationCommands = workItem.ValidationCommands
+	}
 }
 
 // SetReviewRunner sets a custom review runner (useful for testing).
@@ -1279,6 +1279,19 @@ func TestLoopRunMoveCompletedPlanWithAutoCommit(t *testing.T) {
 		"most recent commit should be the plan move")
 	assert.Equal(t, "Implement feature", messages[1],
 		"first commit should be for the phase")
+
+	// Assert: the move commit records the plan in the completed plans index
+	assert.Contains(t, gitOutput(t, dir, "show", "--name-only", "--format=", "HEAD"), "plans/completed/index.json")
+	index, err := plan.LoadIndex(filepath.Join(dir, "plans", "completed"))
+	require.NoError(t, err)
+	require.Len(t, index.Plans, 1)
+	entry := index.Plans[0]
+	assert.Equal(t, "plan.md", entry.File)
+	assert.Equal(t, "Integration Test", entry.Title)
+	assert.Equal(t, 1, entry.Iterations)
+	assert.Equal(t, 1, entry.FilesChanged)
+	assert.Equal(t, result.RunID, entry.RunID)
+	assert.False(t, entry.CompletedAt.IsZero())
 }
 
 // TestLoopRunMoveCompletedPlanDisabled verifies that plan files are NOT moved
@@ -1338,3 +1351,71 @@ func TestLoopRunMoveCompletedPlanDisabled(t *testing.T) {
 	_, err = os.Stat(completedDir)
 	assert.True(t, os.IsNotExist(err), "completed directory should not exist")
 }
+
+// sessionInvoker wraps sequenceInvoker and reports a new session ID per call.
+type sessionInvoker struct {
+	*sequenceInvoker
+	sessions int
+}
+
+func (s *sessionInvoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
+	s.sessions++
+	if opts.OnSessionID != nil {
+		opts.OnSessionID(fmt.Sprintf("session-%d", s.sessions))
+	}
+	return s.sequenceInvoker.Invoke(ctx, prompt, opts)
+}
+
+// TestLoopRunSessionReuse verifies that iterations within one phase resume the
+// previous executor session and that a phase boundary starts a fresh one.
+func TestLoopRunSessionReuse(t *testing.T) {
+	tests := []struct {
+		name        string
+		reuse       bool
+		wantResumes []string
+	}{
+		{name: "enabled", reuse: true, wantResumes: []string{"", "session-1", ""}},
+		{name: "disabled", reuse: false, wantResumes: []string{"", "", ""}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			planPath, workingFile := writePlanFile(t, dir, planConfig{
+				Tasks: []string{"Task 1: Setup", "Task 2: Feature"},
+			})
+
+			invoker := &sessionInvoker{sequenceInvoker: newSequenceInvoker([]sequenceResponse{
+				{Status: protocol.StatusContinue, FilesChanged: []string{"working.txt"}, Summary: "Half done",
+					FileEdits: map[string]string{workingFile: "half\n"}},
+				{PhaseCompleted: "Task 1: Setup", Status: protocol.StatusContinue, FilesChanged: []string{"working.txt"}, Summary: "Setup done",
+					FileEdits: map[string]string{workingFile: "setup\n"}},
+				{PhaseCompleted: "Task 2: Feature", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"}, Summary: "Feature done",
+					FileEdits: map[string]string{workingFile: "feature\n"}},
+			})}
+
+			l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+			l.SetInvoker(invoker)
+			l.SetSource(source.NewPlanSource(planPath))
+			l.SetReviewRunner(createNoIssueReviewRunner(t))
+			l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+			l.SetSessionReuse(tc.reuse)
+
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+			require.Len(t, invoker.calls, 3)
+			for i, want := range tc.wantResumes {
+				assert.Equal(t, want, invoker.calls[i].Opts.ResumeSessionID, "call %d", i)
+			}
+			if tc.reuse {
+				assert.Contains(t, invoker.calls[1].Prompt, "Continue working on the current phase: **Task 1: Setup**")
+				assert.Contains(t, invoker.calls[2].Prompt, "Task 2: Feature")
+				assert.NotContains(t, invoker.calls[2].Prompt, "Continue working on")
+			}
+		})
+	}
+}
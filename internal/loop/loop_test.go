@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/alexander-akhmetov/programmator/internal/domain"
 	"github.com/alexander-akhmetov/programmator/internal/event"
 	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/messages"
 	"github.com/alexander-akhmetov/programmator/internal/parser"
 	"github.com/alexander-akhmetov/programmator/internal/prompt"
 	"github.com/alexander-akhmetov/programmator/internal/protocol"
@@ -83,7 +86,7 @@ func TestInvokeClaudePrintCapturesStderr(t *testing.T) {
 	t.Setenv("PATH", tmpDir+":"+origPath)
 
 	ctx := context.Background()
-	_, err = l.invokeClaudePrint(ctx, "test prompt")
+	_, err = l.invokeClaudePrint(ctx, "test prompt", "", nil)
 
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "claude exited")
@@ -102,7 +105,7 @@ func TestInvokeClaudePrintErrorWithoutStderr(t *testing.T) {
 	t.Setenv("PATH", tmpDir+":"+origPath)
 
 	ctx := context.Background()
-	_, err = l.invokeClaudePrint(ctx, "test prompt")
+	_, err = l.invokeClaudePrint(ctx, "test prompt", "", nil)
 
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "claude exited")
@@ -173,6 +176,43 @@ func TestLoopLogEvent(t *testing.T) {
 	require.Equal(t, "test event message", received[0].Text)
 }
 
+func TestLoopRun_SummaryEvent(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, workingFile := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+	msgDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(msgDir, "messages"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(msgDir, "messages", "de.yaml"),
+		[]byte("loop.summary: \"Zusammenfassung: {{.Summary}}\"\n"), 0o644))
+	catalog, err := messages.Load("de", msgDir)
+	require.NoError(t, err)
+
+	invoker := newSequenceInvoker([]sequenceResponse{
+		{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+			Summary: "Feature done", FileEdits: map[string]string{workingFile: "feature\n"}},
+	})
+	var summaries []event.Event
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetMessages(catalog)
+	l.SetEventCallback(func(e event.Event) {
+		if e.Summary != "" {
+			summaries = append(summaries, e)
+		}
+	})
+
+	_, err = l.Run(planPath)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	require.Equal(t, event.KindProg, summaries[0].Kind)
+	require.Equal(t, "Zusammenfassung: Feature done", summaries[0].Text)
+	require.Equal(t, "Feature done", summaries[0].Summary)
+}
+
 func TestLogStartBanner(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -343,6 +383,26 @@ func TestResultWithFinalStatus(t *testing.T) {
 	}
 }
 
+func TestRunAcceptanceCheckboxesNeedTheCheck(t *testing.T) {
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{
+			ID:         "test-123",
+			Title:      "Test Ticket",
+			Phases:     []domain.Phase{{Name: "Phase 1"}},
+			RawContent: "# Test Ticket\n- [ ] Phase 1\n\n## Acceptance Criteria\n- [ ] Works\n",
+		}, nil
+	}
+
+	l := NewWithSource(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, "", nil, false, mock)
+	l.SetReviewConfig(singleAgentReviewConfig())
+
+	result, err := l.Run("test-123")
+	require.ErrorContains(t, err, "review.acceptance_check")
+	require.Equal(t, safety.ExitReasonError, result.ExitReason)
+	require.Empty(t, mock.SetStatusCalls, "fails before the work item is touched")
+}
+
 func TestRunAllPhasesCompleteAtStart(t *testing.T) {
 	mock := source.NewMockSource()
 	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
@@ -510,6 +570,54 @@ func TestRunWithMockInvokerBlocked(t *testing.T) {
 	require.Equal(t, safety.ExitReasonBlocked, result.ExitReason)
 }
 
+func TestRunWithStatusKey(t *testing.T) {
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{
+			ID:     "test-123",
+			Title:  "Test Ticket",
+			Phases: []domain.Phase{{Name: "Phase 1", Completed: false}},
+		}, nil
+	}
+
+	config := safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}
+	l := NewWithSource(config, "", nil, false, mock)
+	builder, err := prompt.NewBuilder(nil)
+	require.NoError(t, err)
+	l.SetPromptBuilder(builder)
+	l.SetStatusKey("PROGRAMMATOR_STATUS_3F9A1C")
+
+	var promptText string
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, p string) (string, error) {
+		promptText = p
+		// A quoted fixture with the plain key comes after the real block.
+		return `PROGRAMMATOR_STATUS_3F9A1C:
+  protocol: 2
+  phase_completed: null
+  status: BLOCKED
+  files_changed: []
+  summary: "Stuck"
+  error: "Cannot proceed"
+` + "```" + `
+
+The failing test's fixture was:
+` + "```" + `
+PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: DONE
+  files_changed: []
+  summary: "fixture"
+` + "```", nil
+	}})
+
+	result, err := l.Run("test-123")
+
+	require.NoError(t, err)
+	require.Contains(t, promptText, "PROGRAMMATOR_STATUS_3F9A1C:\n  protocol: 2\n")
+	require.Equal(t, safety.ExitReasonBlocked, result.ExitReason)
+	require.Equal(t, "Stuck", result.FinalStatus.Summary)
+}
+
 func TestRunWithMockInvokerNoStatus(t *testing.T) {
 	mock := source.NewMockSource()
 	callCount := 0
@@ -549,6 +657,9 @@ func TestRunWithMockInvokerNoStatus(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, safety.ExitReasonComplete, result.ExitReason)
 	require.GreaterOrEqual(t, invokeCount, 2)
+	require.Equal(t, invokeCount, result.Invocations.Invocations)
+	require.Equal(t, invokeCount, result.Invocations.Failures[llm.FailureParse])
+	require.Equal(t, invokeCount-1, result.Invocations.Retries)
 }
 
 func TestRunWithMockInvokerError(t *testing.T) {
@@ -577,6 +688,47 @@ func TestRunWithMockInvokerError(t *testing.T) {
 	// 3 consecutive invocation failures triggers early exit
 	require.Equal(t, safety.ExitReasonError, result.ExitReason)
 	require.Contains(t, result.ExitMessage, "3 consecutive invocation failures")
+
+	require.Equal(t, 3, result.Invocations.Invocations)
+	require.Equal(t, 2, result.Invocations.Retries)
+	require.Equal(t, map[llm.FailureClass]int{llm.FailureOther: 3}, result.Invocations.Failures)
+}
+
+// stallingInvoker fails every invocation as stalled, recording the
+// liveness timeout it was given.
+type stallingInvoker struct {
+	liveness []int
+}
+
+func (s *stallingInvoker) Invoke(_ context.Context, _ string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
+	s.liveness = append(s.liveness, opts.LivenessTimeout)
+	return nil, &llm.StallError{Idle: time.Duration(opts.LivenessTimeout) * time.Second}
+}
+
+func TestRunWithStalledInvocations(t *testing.T) {
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{
+			ID:     "test-123",
+			Title:  "Test Ticket",
+			Phases: []domain.Phase{{Name: "Phase 1"}},
+		}, nil
+	}
+
+	config := safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 3600, LivenessTimeout: 600}
+	l := NewWithSource(config, "", nil, false, mock)
+	l.SetReviewConfig(singleAgentReviewConfig())
+	inv := &stallingInvoker{}
+	l.SetInvoker(inv)
+
+	result, err := l.Run("test-123")
+
+	require.NoError(t, err)
+	require.Equal(t, []int{600, 600, 600}, inv.liveness)
+	require.Equal(t, safety.ExitReasonError, result.ExitReason)
+	require.Contains(t, result.ExitMessage, "executor stalled: no output for 10m0s")
+	require.Equal(t, 2, result.Invocations.Retries, "stalled invocations are retried")
+	require.Equal(t, map[llm.FailureClass]int{llm.FailureStalled: 3}, result.Invocations.Failures)
 }
 
 func TestRunMaxIterations(t *testing.T) {
@@ -949,6 +1101,8 @@ func singleAgentReviewConfig() review.Config {
 func createMockReviewRunner(t *testing.T, hasIssues bool, issueCount int) *review.Runner {
 	t.Helper()
 
+	var reviewCalls atomic.Int32
+
 	cfg := review.Config{
 		MaxIterations: 3,
 		Parallel:      true,
@@ -968,11 +1122,15 @@ func createMockReviewRunner(t *testing.T, hasIssues bool, issueCount int) *revie
 			return mock
 		}
 		mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*review.Result, error) {
+			// Report each issue at a new line so consecutive reviews are not
+			// treated as identical (not converging).
+			line := int(reviewCalls.Add(1))
 			var issues []review.Issue
 			if hasIssues {
 				for i := range issueCount {
 					issues = append(issues, review.Issue{
 						File:        "file.go",
+						Line:        line,
 						Severity:    review.SeverityHigh,
 						Description: fmt.Sprintf("Issue %d", i+1),
 					})
@@ -993,6 +1151,8 @@ func createMockReviewRunner(t *testing.T, hasIssues bool, issueCount int) *revie
 func createMockReviewRunnerFunc(t *testing.T, resultFunc func() (hasIssues bool, issueCount int)) *review.Runner {
 	t.Helper()
 
+	var reviewCalls atomic.Int32
+
 	cfg := review.Config{
 		MaxIterations: 3,
 		Agents: []review.AgentConfig{
@@ -1012,11 +1172,13 @@ func createMockReviewRunnerFunc(t *testing.T, resultFunc func() (hasIssues bool,
 		}
 		mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*review.Result, error) {
 			hasIssues, issueCount := resultFunc()
+			line := int(reviewCalls.Add(1))
 			var issues []review.Issue
 			if hasIssues {
 				for i := range issueCount {
 					issues = append(issues, review.Issue{
 						File:        "file.go",
+						Line:        line,
 						Severity:    review.SeverityHigh,
 						Description: fmt.Sprintf("Issue %d", i+1),
 					})
@@ -1045,6 +1207,23 @@ func TestSetReviewConfig(t *testing.T) {
 	require.Equal(t, 5, l.reviewConfig.MaxIterations)
 }
 
+func TestApplyReviewContext_ValidationCommands(t *testing.T) {
+	l := New(safety.Config{}, "", nil, false)
+	l.SetReviewConfig(review.Config{FinalCheck: true, ValidationCommands: []string{"make check"}})
+
+	l.applyReviewContext(&domain.WorkItem{RawContent: "plan"})
+	require.Equal(t, []string{"make check"}, l.reviewConfig.ValidationCommands)
+
+	l.applyReviewContext(&domain.WorkItem{ValidationCommands: []string{"go test ./..."}})
+	require.Equal(t, []string{"go test ./..."}, l.reviewConfig.ValidationCommands)
+}
+
+func TestApplyReviewContext_AcceptanceCriteria(t *testing.T) {
+	l := New(safety.Config{}, "", nil, false)
+	l.applyReviewContext(&domain.WorkItem{RawContent: "# Plan\n- [ ] Task 1\n## Acceptance Criteria\n- [ ] Works offline\n"})
+	require.Equal(t, []string{"Works offline"}, l.reviewConfig.AcceptanceCriteria)
+}
+
 func TestRunWithPlanSource_UpdatesCheckboxes(t *testing.T) {
 	// Integration test: verifies that completing a phase updates the plan file on disk
 	tmpDir := t.TempDir()
@@ -1144,6 +1323,35 @@ func TestRunPhaselessTicket_CompletesOnDone(t *testing.T) {
 	require.Equal(t, protocol.WorkItemClosed, mock.SetStatusCalls[1].Status)
 }
 
+func TestRun_ReviewSkip(t *testing.T) {
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{ID: "skip-1", Title: "Skip review", RawContent: "# Skip review\n"}, nil
+	}
+
+	l := NewWithSource(safety.Config{MaxIterations: 10, StagnationLimit: 5, Timeout: 60}, "", nil, false, mock)
+	l.SetReviewConfig(review.Config{Skip: true}) // no agents needed
+	reviewCalls := 0
+	l.SetReviewRunner(createMockReviewRunnerFunc(t, func() (bool, int) {
+		reviewCalls++
+		return true, 1
+	}))
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, _ string) (string, error) {
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: DONE
+  files_changed: ["main.go"]
+  summary: "Done"
+`, nil
+	}})
+
+	result, err := l.Run("skip-1")
+
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+	require.Equal(t, 0, reviewCalls)
+}
+
 func TestRunPhaselessTicket_ContinuesUntilDone(t *testing.T) {
 	// Test: A phaseless ticket continues looping until Claude signals DONE
 	mock := source.NewMockSource()
@@ -2101,33 +2309,6 @@ func TestFormatToolResultSummary(t *testing.T) {
 	}
 }
 
-func TestFormatToolArg(t *testing.T) {
-	tests := []struct {
-		name     string
-		toolName string
-		input    map[string]any
-		expected string
-	}{
-		{name: "Read with path", toolName: "Read", input: map[string]any{"file_path": "/foo/bar.go"}, expected: " /foo/bar.go"},
-		{name: "Write with path", toolName: "Write", input: map[string]any{"file_path": "/a/b.go"}, expected: " /a/b.go"},
-		{name: "Edit with path", toolName: "Edit", input: map[string]any{"file_path": "/c.go"}, expected: " /c.go"},
-		{name: "Read missing path", toolName: "Read", input: map[string]any{}, expected: ""},
-		{name: "Bash short cmd", toolName: "Bash", input: map[string]any{"command": "ls -la"}, expected: " ls -la"},
-		{name: "Bash long cmd truncated", toolName: "Bash", input: map[string]any{"command": strings.Repeat("a", 100)}, expected: " " + strings.Repeat("a", 80) + "..."},
-		{name: "Glob pattern", toolName: "Glob", input: map[string]any{"pattern": "**/*.go"}, expected: " **/*.go"},
-		{name: "Grep pattern", toolName: "Grep", input: map[string]any{"pattern": "TODO"}, expected: " TODO"},
-		{name: "Task description", toolName: "Task", input: map[string]any{"description": "search files"}, expected: " search files"},
-		{name: "unknown tool", toolName: "Unknown", input: map[string]any{"foo": "bar"}, expected: ""},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			got := formatToolArg(tc.toolName, tc.input)
-			require.Equal(t, tc.expected, got)
-		})
-	}
-}
-
 func TestHandleToolResult(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -2418,3 +2599,90 @@ func TestOutputToolUseTriggersEditDiff(t *testing.T) {
 	require.True(t, toolUseFound, "should emit ToolUse event")
 	require.True(t, diffHunkFound, "should emit DiffHunk event for Edit tool")
 }
+
+func TestRunReview_IdenticalIssuesNotConverging(t *testing.T) {
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{
+			ID:     "test-stuck",
+			Title:  "Test Stuck Review",
+			Phases: []domain.Phase{{Name: "Phase 1", Completed: true}},
+		}, nil
+	}
+
+	config := safety.Config{MaxIterations: 100, StagnationLimit: 50, Timeout: 60, MaxReviewIterations: 100}
+	l := NewWithSource(config, "", nil, false, mock)
+	l.SetReviewConfig(review.Config{
+		MaxIterations: 10,
+		Agents:        []review.AgentConfig{{Name: "test_agent"}},
+	})
+
+	// Every review reports exactly the same finding.
+	runner := review.NewRunner(review.Config{Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	runner.SetAgentFactory(func(agentCfg review.AgentConfig, _ string) review.Agent {
+		agent := review.NewMockAgent(agentCfg.Name)
+		agent.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*review.Result, error) {
+			return &review.Result{
+				AgentName: agentCfg.Name,
+				Issues:    []review.Issue{{File: "file.go", Line: 3, Severity: review.SeverityHigh, Description: "Stuck issue"}},
+			}, nil
+		})
+		return agent
+	})
+	l.SetReviewRunner(runner)
+
+	var notes []string
+	mock.AddNoteFunc = func(_, note string) error {
+		notes = append(notes, note)
+		return nil
+	}
+
+	claudeCallCount := 0
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, _ string) (string, error) {
+		claudeCallCount++
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: CONTINUE
+  files_changed: ["fix.go"]
+  summary: "Attempted fix"
+`, nil
+	}})
+
+	result, err := l.Run("test-stuck")
+
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonReviewStuck, result.ExitReason)
+	require.Equal(t, 1, claudeCallCount, "should stop after the second identical review")
+	require.Contains(t, strings.Join(notes, "\n"), "Stuck issue")
+}
+
+func TestRun_DefaultValidationCommands(t *testing.T) {
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{
+			ID:     "test-validate",
+			Title:  "Test Validation Commands",
+			Phases: []domain.Phase{{Name: "Phase 1"}},
+		}, nil
+	}
+
+	config := safety.Config{MaxIterations: 1, StagnationLimit: 3, Timeout: 60}
+	l := NewWithSource(config, "", nil, false, mock)
+	l.SetValidationCommands([]string{"make check"})
+
+	var prompts []string
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: CONTINUE
+  files_changed: ["a.go"]
+  summary: "Working"
+`, nil
+	}})
+
+	_, err := l.Run("test-validate")
+	require.NoError(t, err)
+	require.NotEmpty(t, prompts)
+	require.Contains(t, prompts[0], "`make check`")
+}
@@ -0,0 +1,97 @@
+package loop
+
+import (
+	"cmp"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// maxTimedFiles caps the files scanFileTimes records, so a run in a huge
+// directory does not stall between iterations.
+const maxTimedFiles = 20000
+
+// fileTimes maps the files under a directory, by slash-separated relative
+// path, to their modification times. Outside a git repository it stands in
+// for git status: comparing two scans gives the files an invocation touched.
+type fileTimes map[string]time.Time
+
+// scanFileTimes records the modification time of every file under root,
+// skipping hidden directories (.git, .programmator, ...) and node_modules.
+func scanFileTimes(root string) fileTimes {
+	times := make(fileTimes)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // unreadable entries are skipped
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name[0] == '.' || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(times) >= maxTimedFiles {
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil //nolint:nilerr // the file went away during the walk
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil //nolint:nilerr // cannot happen for paths under root
+		}
+		times[filepath.ToSlash(rel)] = info.ModTime()
+		return nil
+	})
+	return times
+}
+
+// changedSince returns the files created, modified, or deleted between the
+// before and after scans, sorted.
+func changedSince(before, after fileTimes) []string {
+	var changed []string
+	for path, mtime := range after {
+		if prev, ok := before[path]; !ok || !prev.Equal(mtime) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// touchedFiles returns the files changed in the working directory since the
+// last call, outside a git repository; nil inside one. The work item file is
+// left out: notes are appended to it every iteration.
+func (l *Loop) touchedFiles(rc *runContext) []string {
+	if rc.fileTimes == nil {
+		return nil
+	}
+	root := cmp.Or(l.workingDir, ".")
+	scan := scanFileTimes(root)
+	changed := changedSince(rc.fileTimes, scan)
+	rc.fileTimes = scan
+
+	mover, ok := rc.source.(source.Mover)
+	if !ok {
+		return changed
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return changed
+	}
+	rel, err := filepath.Rel(absRoot, mover.FilePath())
+	if err != nil {
+		return changed
+	}
+	return slices.DeleteFunc(changed, func(f string) bool { return f == filepath.ToSlash(rel) })
+}
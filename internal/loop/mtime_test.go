@@ -0,0 +1,80 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestChangedSince(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	t1 := time.Unix(2000, 0)
+	before := fileTimes{"a.go": t0, "b.go": t0, "gone.go": t0}
+	after := fileTimes{"a.go": t0, "b.go": t1, "new.go": t1}
+	require.Equal(t, []string{"b.go", "gone.go", "new.go"}, changedSince(before, after))
+	require.Empty(t, changedSince(after, after))
+}
+
+func TestScanFileTimes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "pkg/a.go", ".git/HEAD", "node_modules/x/index.js"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0o600))
+	}
+	times := scanFileTimes(dir)
+	require.Len(t, times, 2)
+	require.Contains(t, times, "main.go")
+	require.Contains(t, times, "pkg/a.go")
+}
+
+func TestRun_NoGit(t *testing.T) {
+	dir := t.TempDir()
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{ID: "test-123", Title: "Test Ticket"}, nil
+	}
+
+	config := safety.Config{MaxIterations: 10, StagnationLimit: 2, Timeout: 60}
+	l := NewWithSource(config, dir, nil, false, mock)
+	builder, err := prompt.NewBuilder(nil)
+	require.NoError(t, err)
+	l.SetPromptBuilder(builder)
+	l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true, AutoBranch: true})
+
+	calls := 0
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, _ string) (string, error) {
+		calls++
+		files := "[]"
+		if calls == 1 {
+			// The first invocation writes a file without reporting it.
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o600))
+		} else {
+			// Later ones report a file they never touched.
+			files = "[fake.go]"
+		}
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: CONTINUE
+  files_changed: ` + files + `
+  summary: "Working"
+`, nil
+	}})
+
+	result, err := l.Run("test-123")
+
+	require.NoError(t, err)
+	require.True(t, result.NoGit)
+	require.Equal(t, safety.ExitReasonStagnation, result.ExitReason)
+	require.Equal(t, 3, calls, "reported but untouched files are not progress")
+	require.Contains(t, result.TotalFilesChanged, "main.go")
+}
@@ -0,0 +1,52 @@
+package loop
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+)
+
+// SetNotesConfig sets which notes are added to the work item and how their
+// content is written, by source type. The zero value adds every note as
+// "kind: message".
+func (l *Loop) SetNotesConfig(cfg notes.Config) {
+	l.notesConfig = cfg
+}
+
+// initNotes sets up the note writer for the run's source. An invalid style
+// is logged and replaced by the default one.
+func (l *Loop) initNotes(rc *runContext) {
+	w, err := notes.NewWriter(l.notesConfig.For(rc.source.Type()))
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: %v; adding every note as is", err))
+		w, _ = notes.NewWriter(notes.Style{})
+	}
+	rc.notes = w
+}
+
+// addNote adds note, a "kind: message" line, to the work item unless the
+// note level drops notes of importance imp.
+func (l *Loop) addNote(rc *runContext, imp notes.Importance, note string) {
+	if rc.notes == nil {
+		l.initNotes(rc)
+	}
+	kind, message := notes.Split(note)
+	content, ok, err := rc.notes.Render(imp, notes.Data{
+		Kind:      kind,
+		Message:   message,
+		Note:      note,
+		Iteration: rc.state.Iteration,
+		WorkItem:  rc.workItemID,
+		Source:    rc.source.Type(),
+		Time:      time.Now(),
+	})
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: %v", err))
+		content, ok = note, true
+	}
+	if !ok {
+		return
+	}
+	_ = rc.source.AddNote(rc.workItemID, l.redactText(content))
+}
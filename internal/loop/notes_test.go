@@ -0,0 +1,110 @@
+package loop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// runNotesTicket runs a one-phase ticket to completion in two iterations with cfg and returns
+// the notes added to it.
+func runNotesTicket(t *testing.T, cfg notes.Config) []string {
+	t.Helper()
+
+	completed := false
+	mock := source.NewMockSource()
+	mock.TypeFunc = func() string { return protocol.SourceTypeTicket }
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{
+			ID:     "t-1",
+			Title:  "Ticket",
+			Phases: []domain.Phase{{Name: "Phase 1", Completed: completed}},
+		}, nil
+	}
+	mock.UpdatePhaseFunc = func(_, _ string) error {
+		completed = true
+		return nil
+	}
+
+	l := NewWithSource(safety.Config{MaxIterations: 5, StagnationLimit: 3, MaxReviewIterations: 3, Timeout: 60}, "", nil, false, mock)
+	l.SetReviewConfig(singleAgentReviewConfig())
+	l.SetReviewRunner(createMockReviewRunner(t, false, 0))
+	l.SetNotesConfig(cfg)
+	calls := 0
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, _ string) (string, error) {
+		calls++
+		if calls == 1 {
+			return `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: CONTINUE
+  files_changed: ["main.go"]
+  summary: "Started phase 1"
+`, nil
+		}
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: "Phase 1"
+  status: CONTINUE
+  files_changed: ["main.go"]
+  summary: "Implemented phase 1"
+`, nil
+	}})
+
+	result, err := l.Run("t-1")
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	var added []string
+	for _, call := range mock.AddNoteCalls {
+		added = append(added, call.Note)
+	}
+	return added
+}
+
+func TestAddNote_Levels(t *testing.T) {
+	t.Run("all", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"progress: [iter 1] Started phase 1",
+			"progress: [iter 2] Completed Phase 1",
+			"progress: Review passed",
+			"progress: Completed all phases in 2 iterations",
+		}, runNotesTicket(t, notes.Config{}))
+	})
+
+	t.Run("phase", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"progress: [iter 2] Completed Phase 1",
+			"progress: Review passed",
+			"progress: Completed all phases in 2 iterations",
+		}, runNotesTicket(t, notes.Config{Style: notes.Style{Level: notes.LevelPhase}}))
+	})
+
+	t.Run("final for tickets only", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"progress: Completed all phases in 2 iterations",
+		}, runNotesTicket(t, notes.Config{
+			Style:   notes.Style{Level: notes.LevelAll},
+			Sources: map[string]notes.Style{protocol.SourceTypeTicket: {Level: notes.LevelFinal}},
+		}))
+	})
+}
+
+func TestAddNote_Template(t *testing.T) {
+	added := runNotesTicket(t, notes.Config{Style: notes.Style{
+		Level:    notes.LevelPhase,
+		Template: "[{{.Source}} {{.WorkItem}}] {{.Kind}}: {{.Message}}",
+	}})
+
+	assert.Equal(t, []string{
+		"[ticket t-1] progress: [iter 2] Completed Phase 1",
+		"[ticket t-1] progress: Review passed",
+		"[ticket t-1] progress: Completed all phases in 2 iterations",
+	}, added)
+}
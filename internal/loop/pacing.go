@@ -0,0 +1,90 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/cron"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+)
+
+// DefaultMaxCooldown caps the adaptive cool-down when PacingConfig.MaxCooldown
+// is not set.
+const DefaultMaxCooldown = 10 * time.Minute
+
+// backoffStart is the first adaptive cool-down after a rate-limited
+// invocation when the fixed cool-down is shorter.
+const backoffStart = 30 * time.Second
+
+// PacingConfig spaces out iterations, to stay under provider rate limits or
+// leave quota for others.
+type PacingConfig struct {
+	Cooldown    time.Duration // sleep between iterations
+	Adaptive    bool          // back off after rate-limited invocations, see nextCooldown
+	MaxCooldown time.Duration // cap for the adaptive cool-down (default DefaultMaxCooldown)
+	WorkHours   cron.Window   // iterations start only inside this daily window (zero = any time)
+}
+
+// SetPacing configures the cool-down between iterations and the work hours.
+func (l *Loop) SetPacing(cfg PacingConfig) {
+	l.pacing = cfg
+	l.cooldown = cfg.Cooldown
+}
+
+// nextCooldown returns the adaptive cool-down after an iteration: doubled
+// (from at least backoffStart) when an invocation was rate limited, halved
+// back toward the fixed cool-down otherwise, and never above MaxCooldown.
+func (p PacingConfig) nextCooldown(cur time.Duration, rateLimited bool) time.Duration {
+	if !p.Adaptive {
+		return p.Cooldown
+	}
+	limit := p.MaxCooldown
+	if limit <= 0 {
+		limit = DefaultMaxCooldown
+	}
+	next := max(cur/2, p.Cooldown)
+	if rateLimited {
+		next = max(cur*2, backoffStart, p.Cooldown)
+	}
+	return min(next, max(limit, p.Cooldown))
+}
+
+// pace waits before an iteration: the cool-down after the previous one, then
+// until the work hours window opens. It returns false when the run was
+// stopped while waiting.
+func (l *Loop) pace(rc *runContext) bool {
+	if rc.state.Iteration > 1 {
+		rateLimits := l.metrics.Stats().Failures[llm.FailureRateLimit]
+		l.cooldown = l.pacing.nextCooldown(l.cooldown, rateLimits > l.rateLimits)
+		l.rateLimits = rateLimits
+		if l.cooldown > 0 {
+			l.log(fmt.Sprintf("Cooling down for %s", l.cooldown.Round(time.Second)))
+			if !sleepContext(rc.ctx, l.cooldown) {
+				return false
+			}
+		}
+	}
+
+	now := time.Now()
+	if open := l.pacing.WorkHours.NextOpen(now); open.After(now) {
+		l.log(fmt.Sprintf("Outside work hours (%s), waiting until %s", l.pacing.WorkHours, open.Format("Mon 15:04")))
+		if !sleepContext(rc.ctx, open.Sub(now)) {
+			return false
+		}
+	}
+	return true
+}
+
+// sleepContext sleeps for d and reports whether it did so without ctx
+// being canceled.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,73 @@
+package loop
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestPacingConfig_NextCooldown(t *testing.T) {
+	fixed := PacingConfig{Cooldown: 5 * time.Second}
+	require.Equal(t, 5*time.Second, fixed.nextCooldown(0, true), "fixed pacing ignores rate limits")
+
+	p := PacingConfig{Cooldown: 5 * time.Second, Adaptive: true, MaxCooldown: 100 * time.Second}
+	cur := p.nextCooldown(5*time.Second, true)
+	require.Equal(t, 30*time.Second, cur, "backs off from at least 30s")
+	cur = p.nextCooldown(cur, true)
+	require.Equal(t, 60*time.Second, cur)
+	cur = p.nextCooldown(cur, true)
+	require.Equal(t, 100*time.Second, cur, "capped at MaxCooldown")
+	cur = p.nextCooldown(cur, false)
+	require.Equal(t, 50*time.Second, cur, "recovers after a clean iteration")
+	for range 5 {
+		cur = p.nextCooldown(cur, false)
+	}
+	require.Equal(t, 5*time.Second, cur, "never below the fixed cool-down")
+
+	require.Equal(t, 30*time.Second, PacingConfig{Adaptive: true}.nextCooldown(0, true), "default cap")
+}
+
+func TestRunWithPacing(t *testing.T) {
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return &domain.WorkItem{ID: "test-123", Title: "Test Ticket"}, nil
+	}
+
+	config := safety.Config{MaxIterations: 3, StagnationLimit: 10, Timeout: 60}
+	l := NewWithSource(config, "", nil, false, mock)
+	builder, err := prompt.NewBuilder(nil)
+	require.NoError(t, err)
+	l.SetPromptBuilder(builder)
+	l.SetPacing(PacingConfig{Cooldown: time.Millisecond})
+	var logs []string
+	l.SetEventCallback(func(ev event.Event) { logs = append(logs, ev.Text) })
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, _ string) (string, error) {
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: CONTINUE
+  files_changed: [main.go]
+  summary: "Working"
+`, nil
+	}})
+
+	result, err := l.Run("test-123")
+
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonMaxIterations, result.ExitReason)
+	cooldowns := 0
+	for _, line := range logs {
+		if strings.HasPrefix(line, "Cooling down for") {
+			cooldowns++
+		}
+	}
+	require.Equal(t, 2, cooldowns, "between iterations only")
+}
@@ -0,0 +1,313 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// parallelPhaseResult holds the outcome of one phase executed in its own worktree.
+type parallelPhaseResult struct {
+	phaseIdx     int
+	phaseName    string
+	worktreePath string
+	branch       string
+	status       *parser.ParsedStatus
+	err          error
+}
+
+// SetParallelPhases sets how many independent phases may run concurrently.
+// Values below 2 keep strictly sequential execution.
+func (l *Loop) SetParallelPhases(n int) {
+	l.parallelPhases = n
+}
+
+// parallelBatch returns the indices of independent phases to run concurrently
+// in this iteration, or nil when the iteration should run sequentially.
+func (l *Loop) parallelBatch(rc *runContext) []int {
+	if l.parallelPhases < 2 || rc.parallelDisabled || l.gitRepo == nil {
+		return nil
+	}
+	// Parallel phases come back as commits, which only auto-commit allows.
+	if !l.gitConfig.AutoCommit {
+		l.log("Running phases sequentially: parallel phases are committed, and git.auto_commit is off")
+		rc.parallelDisabled = true
+		return nil
+	}
+	if l.engine.PendingReviewFix || rc.state.InReviewPhase || rc.taskCompleted {
+		return nil
+	}
+
+	ready := rc.workItem.ReadyPhases()
+	if len(ready) < 2 {
+		return nil
+	}
+
+	// Worktrees start from HEAD, so uncommitted work in the main tree would be
+	// invisible to them. The source file itself is expected to be dirty.
+	dirty, err := l.uncommittedFiles(rc)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: parallel phases disabled: %v", err))
+		rc.parallelDisabled = true
+		return nil
+	}
+	if len(dirty) > 0 {
+		l.log(fmt.Sprintf("Running phases sequentially: uncommitted changes in %s", strings.Join(dirty, ", ")))
+		return nil
+	}
+
+	return ready[:min(len(ready), l.parallelPhases)]
+}
+
+// uncommittedFiles lists uncommitted paths in the main worktree, ignoring the
+// work item's own file.
+func (l *Loop) uncommittedFiles(rc *runContext) ([]string, error) {
+	files, err := l.gitRepo.UncommittedFiles()
+	if err != nil {
+		return nil, err
+	}
+	sourcePath := l.sourceRelPath(rc)
+	dirty := make([]string, 0, len(files))
+	for _, f := range files {
+		if f != sourcePath {
+			dirty = append(dirty, f)
+		}
+	}
+	return dirty, nil
+}
+
+// sourceRelPath returns the work item file path relative to the repo root,
+// or "" when the source has no file inside the repository.
+func (l *Loop) sourceRelPath(rc *runContext) string {
+	mover, ok := rc.source.(source.Mover)
+	if !ok {
+		return ""
+	}
+	rel, err := filepath.Rel(l.gitRepo.Root(), mover.FilePath())
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// runParallelPhases executes the given phases concurrently, each in its own
+// git worktree, then cherry-picks their commits back in plan order. A phase
+// that fails or conflicts stays incomplete and parallel execution is turned
+// off for the rest of the run, so it is retried sequentially.
+func (l *Loop) runParallelPhases(rc *runContext, indices []int) {
+	names := make([]string, len(indices))
+	for i, idx := range indices {
+		names[i] = rc.workItem.Phases[idx].Name
+	}
+	l.log(fmt.Sprintf("Running %d phases in parallel: %s", len(indices), strings.Join(names, ", ")))
+
+	inv, err := l.resolveInvoker()
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: parallel phases disabled: %v", err))
+		rc.parallelDisabled = true
+		return
+	}
+
+	l.currentState = rc.state
+	l.currentWorkItem = rc.workItem
+	if l.onStateChange != nil {
+		l.onStateChange(rc.state, rc.workItem, rc.result.TotalFilesChanged)
+	}
+
+	baseSHA, err := gitutil.HeadCommit(l.gitRepo.Root())
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: parallel phases disabled: %v", err))
+		rc.parallelDisabled = true
+		return
+	}
+
+	tmpRoot, err := os.MkdirTemp("", "programmator-parallel-*")
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: parallel phases disabled: %v", err))
+		rc.parallelDisabled = true
+		return
+	}
+	defer os.RemoveAll(tmpRoot)
+
+	results := make([]*parallelPhaseResult, len(indices))
+	var tokensMu sync.Mutex
+	var wg sync.WaitGroup
+	for i, idx := range indices {
+		res := &parallelPhaseResult{
+			phaseIdx:     idx,
+			phaseName:    rc.workItem.Phases[idx].Name,
+			worktreePath: filepath.Join(tmpRoot, fmt.Sprintf("phase-%d", idx+1)),
+			branch:       fmt.Sprintf("programmator-parallel/%s-phase-%d", filepath.Base(tmpRoot), idx+1),
+		}
+		results[i] = res
+
+		if err := l.gitRepo.AddWorktree(res.worktreePath, res.branch); err != nil {
+			res.err = err
+			res.branch = ""
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res.status, res.err = l.runPhaseInWorktree(rc.ctx, inv, rc.workItem, res, &tokensMu)
+		}()
+	}
+	wg.Wait()
+
+	l.mergeParallelResults(rc, baseSHA, results)
+}
+
+// runPhaseInWorktree invokes the executor for a single phase inside its worktree.
+func (l *Loop) runPhaseInWorktree(ctx context.Context, inv llm.Invoker, workItem *domain.WorkItem, res *parallelPhaseResult, tokensMu *sync.Mutex) (*parser.ParsedStatus, error) {
+	builder := l.promptBuilder
+	if builder == nil {
+		var err error
+		builder, err = prompt.NewBuilder(nil)
+		if err != nil {
+			return nil, fmt.Errorf("build prompt: %w", err)
+		}
+	}
+	promptText, err := builder.BuildForPhase(workItem, res.phaseIdx)
+	if err != nil {
+		return nil, fmt.Errorf("build prompt: %w", err)
+	}
+
+	l.log(fmt.Sprintf("[%s] Invoking %s in %s", res.phaseName, l.executorName(), res.worktreePath))
+
+	// Output from concurrent invocations would interleave, so only token
+	// totals are collected here; progress is reported per phase.
+	opts := llm.InvokeOptions{
+		WorkingDir:      res.worktreePath,
+		Streaming:       l.streaming,
+		ExtraFlags:      l.invocationFlags(&workItem.Phases[res.phaseIdx]),
+		Purpose:         llm.PurposeMain,
+		Timeout:         l.config.Timeout,
+		LivenessTimeout: l.config.LivenessTimeout,
+		OnFinalTokens: func(model string, inputTokens, outputTokens int) {
+			tokensMu.Lock()
+			defer tokensMu.Unlock()
+			if l.currentState != nil {
+				l.currentState.FinalizeIterTokens(model, inputTokens, outputTokens)
+			}
+		},
+	}
+
+	out, err := inv.Invoke(ctx, promptText, opts)
+	if err != nil {
+		return nil, fmt.Errorf("invoke: %w", err)
+	}
+
+	status, err := parser.ParseKey(out.Text, l.statusBlockKey())
+	if err != nil {
+		return nil, fmt.Errorf("parse status: %w", err)
+	}
+	if status == nil {
+		return nil, errors.New("no " + l.statusBlockKey() + " found in output")
+	}
+	return status, nil
+}
+
+// mergeParallelResults commits each worktree, cherry-picks the commits onto
+// the current branch in plan order, and marks merged phases complete.
+func (l *Loop) mergeParallelResults(rc *runContext, baseSHA string, results []*parallelPhaseResult) {
+	sourcePath := l.sourceRelPath(rc)
+	var filesChanged []string
+	var failures []string
+	merged := 0
+
+	for _, res := range results {
+		if res.branch != "" {
+			defer func() {
+				if err := l.gitRepo.RemoveWorktree(res.worktreePath, res.branch); err != nil {
+					l.log(fmt.Sprintf("Warning: failed to clean up worktree: %v", err))
+				}
+			}()
+		}
+
+		if failure := l.mergeParallelPhase(rc, res, baseSHA, sourcePath); failure != "" {
+			l.log(fmt.Sprintf("[%s] %s", res.phaseName, failure))
+			l.addNote(rc, notes.Phase, fmt.Sprintf("warning: [iter %d] Parallel %s: %s", rc.state.Iteration, res.phaseName, failure))
+			failures = append(failures, res.phaseName)
+			continue
+		}
+
+		merged++
+		filesChanged = append(filesChanged, res.status.FilesChanged...)
+		l.trackFilesChanged(rc, res.status)
+		rc.iterationSummaries = append(rc.iterationSummaries,
+			FormatIterationSummary(rc.state.Iteration, res.status.Summary, res.status.FilesChanged))
+	}
+
+	if len(failures) > 0 {
+		l.log(fmt.Sprintf("Falling back to sequential execution for: %s", strings.Join(failures, ", ")))
+		rc.parallelDisabled = true
+	}
+
+	errMsg := ""
+	if merged == 0 {
+		errMsg = "parallel_phases_failed"
+	}
+	rc.state.RecordIteration(filesChanged, errMsg)
+	if merged > 0 {
+		rc.state.ConsecutiveNoChanges = 0
+	}
+
+	if l.onStateChange != nil {
+		l.onStateChange(rc.state, rc.workItem, rc.result.TotalFilesChanged)
+	}
+}
+
+// mergeParallelPhase applies one phase's worktree changes to the main branch.
+// Returns a human-readable failure reason, or "" on success.
+func (l *Loop) mergeParallelPhase(rc *runContext, res *parallelPhaseResult, baseSHA, sourcePath string) string {
+	if res.err != nil {
+		return res.err.Error()
+	}
+	if res.status.Status == protocol.StatusBlocked {
+		return "blocked: " + res.status.Error
+	}
+	if res.status.PhaseCompleted == "" {
+		return "phase not reported complete"
+	}
+	// Worktree phases are committed as a whole, so changes needing
+	// sign-off are redone sequentially, where they can be held back.
+	if risky := l.needsSignOff(rc, res.status.FilesChanged); len(risky) > 0 {
+		return "changes need sign-off: " + strings.Join(risky, ", ")
+	}
+
+	tip, err := gitutil.CommitWorktree(res.worktreePath, res.phaseName, sourcePath)
+	if err != nil {
+		return err.Error()
+	}
+	if tip != baseSHA {
+		if err := l.gitRepo.CherryPick(baseSHA + ".." + tip); err != nil {
+			if errors.Is(err, gitutil.ErrCherryPickConflict) {
+				return "merge conflict"
+			}
+			return err.Error()
+		}
+		l.refreshActiveFiles()
+		l.recordCommit(rc)
+	}
+
+	if err := rc.source.UpdatePhase(rc.workItemID, res.phaseName); err != nil {
+		return fmt.Sprintf("failed to update phase: %v", err)
+	}
+	l.log(fmt.Sprintf("[%s] Phase completed", res.phaseName))
+	l.addNote(rc, notes.Phase, fmt.Sprintf("progress: [iter %d] Completed %s (parallel)", rc.state.Iteration, res.phaseName))
+	return ""
+}
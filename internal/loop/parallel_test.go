@@ -0,0 +1,185 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/plan"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+var promptPhaseRegex = regexp.MustCompile(`phase_completed: "([^"]+)"`)
+
+// phaseCall records which phase was requested and where it ran.
+type phaseCall struct {
+	phase string
+	dir   string
+}
+
+// phaseWritingInvoker completes whichever phase the prompt asks for by
+// writing a file into the invocation's working directory.
+type phaseWritingInvoker struct {
+	mu    sync.Mutex
+	calls []phaseCall
+	// write returns the file name and content to write for a phase.
+	write func(phase string) (string, string)
+}
+
+func (f *phaseWritingInvoker) Invoke(_ context.Context, prompt string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
+	match := promptPhaseRegex.FindStringSubmatch(prompt)
+	if match == nil {
+		return nil, fmt.Errorf("no phase in prompt")
+	}
+	phase := match[1]
+
+	f.mu.Lock()
+	f.calls = append(f.calls, phaseCall{phase: phase, dir: opts.WorkingDir})
+	f.mu.Unlock()
+
+	name, content := f.write(phase)
+	if err := os.WriteFile(filepath.Join(opts.WorkingDir, name), []byte(content), 0644); err != nil {
+		return nil, err
+	}
+
+	return &llm.InvokeResult{Text: fmt.Sprintf(`PROGRAMMATOR_STATUS:
+  phase_completed: "%s"
+  status: CONTINUE
+  files_changed: ["%s"]
+  summary: "Did %s"
+`, phase, name, phase)}, nil
+}
+
+func newParallelTestLoop(t *testing.T, dir, planPath string, inv llm.Invoker) *Loop {
+	t.Helper()
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(inv)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true})
+	l.SetParallelPhases(2)
+	return l
+}
+
+func TestLoopRun_ParallelIndependentPhases(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, _ := writePlanFile(t, dir, planConfig{
+		Tasks: []string{
+			"Task 1: Alpha <!-- depends: none -->",
+			"Task 2: Beta <!-- depends: none -->",
+			"Task 3: Gamma",
+		},
+		CommitFiles: true,
+	})
+
+	inv := &phaseWritingInvoker{write: func(phase string) (string, string) {
+		slug := strings.ToLower(strings.Fields(strings.SplitN(phase, ": ", 2)[1])[0])
+		return slug + ".txt", phase + "\n"
+	}}
+
+	l := newParallelTestLoop(t, dir, planPath, inv)
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	updated, err := plan.ParseFile(planPath)
+	require.NoError(t, err)
+	for _, task := range updated.Tasks {
+		assert.True(t, task.Completed, task.Name)
+	}
+
+	for _, name := range []string{"alpha.txt", "beta.txt", "gamma.txt"} {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+	assert.ElementsMatch(t, []string{"alpha.txt", "beta.txt", "gamma.txt"}, result.TotalFilesChanged)
+
+	require.Len(t, inv.calls, 3)
+	for _, call := range inv.calls[:2] {
+		assert.NotEqual(t, dir, call.dir, "independent phases should run in worktrees")
+	}
+	assert.Equal(t, "Task 3: Gamma", inv.calls[2].phase)
+	assert.Equal(t, dir, inv.calls[2].dir, "dependent phase should run in the main tree")
+
+	assert.Equal(t, []string{"Task 3: Gamma", "Task 2: Beta", "Task 1: Alpha"}, getCommitMessages(t, dir))
+}
+
+func TestLoopRun_ParallelNeedsAutoCommit(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, _ := writePlanFile(t, dir, planConfig{
+		Tasks: []string{
+			"Task 1: Alpha <!-- depends: none -->",
+			"Task 2: Beta <!-- depends: none -->",
+		},
+		CommitFiles: true,
+	})
+
+	inv := &phaseWritingInvoker{write: func(phase string) (string, string) {
+		slug := strings.ToLower(strings.Fields(strings.SplitN(phase, ": ", 2)[1])[0])
+		return slug + ".txt", phase + "\n"
+	}}
+
+	l := newParallelTestLoop(t, dir, planPath, inv)
+	l.SetGitWorkflowConfig(GitWorkflowConfig{})
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	require.Len(t, inv.calls, 2)
+	for _, call := range inv.calls {
+		assert.Equal(t, dir, call.dir, "phases run sequentially in the main tree")
+	}
+	assert.Empty(t, getCommitMessages(t, dir), "nothing is committed")
+}
+
+func TestLoopRun_ParallelConflictFallsBackToSequential(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, workingFile := writePlanFile(t, dir, planConfig{
+		Tasks: []string{
+			"Task 1: Alpha <!-- depends: none -->",
+			"Task 2: Beta <!-- depends: none -->",
+		},
+		CommitFiles: true,
+	})
+
+	inv := &phaseWritingInvoker{write: func(phase string) (string, string) {
+		return "working.txt", phase + "\n"
+	}}
+
+	l := newParallelTestLoop(t, dir, planPath, inv)
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	updated, err := plan.ParseFile(planPath)
+	require.NoError(t, err)
+	assert.True(t, updated.Tasks[0].Completed)
+	assert.True(t, updated.Tasks[1].Completed)
+
+	require.Len(t, inv.calls, 3)
+	assert.Equal(t, "Task 2: Beta", inv.calls[2].phase)
+	assert.Equal(t, dir, inv.calls[2].dir, "conflicting phase should be retried sequentially")
+
+	content, err := os.ReadFile(workingFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Task 2: Beta\n", string(content))
+	assert.Equal(t, []string{"Task 2: Beta", "Task 1: Alpha"}, getCommitMessages(t, dir))
+}
@@ -0,0 +1,58 @@
+package loop
+
+import (
+	"fmt"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+// reviewPatchesCommitMessage is the auto-commit message for patches applied
+// straight from review findings.
+const reviewPatchesCommitMessage = "Apply review patches"
+
+// applyReviewPatches applies the high-confidence patches review agents
+// suggested and returns the results without the issues they fixed, plus how
+// many were applied. A patch that no longer applies (an earlier patch in the
+// batch touched the same lines) leaves its issue for the executor.
+func (l *Loop) applyReviewPatches(rc *runContext, results []*review.Result) ([]*review.Result, int) {
+	applied := 0
+	var changed []string
+	remaining := make([]*review.Result, 0, len(results))
+	for _, res := range results {
+		kept := make([]review.Issue, 0, len(res.Issues))
+		for _, issue := range res.Issues {
+			if !issue.AutoApplicable() {
+				kept = append(kept, issue)
+				continue
+			}
+			files, err := gitutil.PatchFiles(l.workingDir, issue.Patch)
+			if err == nil {
+				err = gitutil.ApplyPatch(l.workingDir, issue.Patch, false)
+			}
+			if err != nil {
+				l.log(fmt.Sprintf("Warning: review patch for %s not applied: %v", issue.File, err))
+				issue.PatchApplies = false
+				kept = append(kept, issue)
+				continue
+			}
+			applied++
+			changed = append(changed, files...)
+		}
+		filtered := *res
+		filtered.Issues = kept
+		remaining = append(remaining, &filtered)
+	}
+	if applied == 0 {
+		return results, 0
+	}
+
+	l.log(fmt.Sprintf("Applied %d review patch(es) without invoking %s", applied, l.executorName()))
+	l.trackFilesChanged(rc, &parser.ParsedStatus{FilesChanged: changed})
+	if err := l.autoCommitPhase(rc, reviewPatchesCommitMessage, changed); err != nil {
+		l.log(fmt.Sprintf("Warning: review patch auto-commit failed: %v", err))
+	}
+	l.refreshActiveFiles()
+	return remaining, applied
+}
@@ -0,0 +1,108 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// createPatchReviewRunner returns a runner whose first review reports one
+// issue with a high-confidence patch, and whose later reviews pass.
+func createPatchReviewRunner(t *testing.T, patch string) *review.Runner {
+	t.Helper()
+
+	var calls atomic.Int32
+	runner := review.NewRunner(review.Config{Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	runner.SetAgentFactory(func(agentCfg review.AgentConfig, _ string) review.Agent {
+		mock := review.NewMockAgent(agentCfg.Name)
+		mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*review.Result, error) {
+			if calls.Add(1) > 1 {
+				return &review.Result{AgentName: agentCfg.Name, Summary: "No issues"}, nil
+			}
+			return &review.Result{
+				AgentName: agentCfg.Name,
+				Issues: []review.Issue{{
+					File: "working.txt", Line: 1, Severity: review.SeverityLow, Description: "Wrong content",
+					Patch: patch, PatchConfidence: "high",
+				}},
+			}, nil
+		})
+		return mock
+	})
+	return runner
+}
+
+func TestLoopRun_AppliesReviewPatches(t *testing.T) {
+	patch := "--- a/working.txt\n+++ b/working.txt\n@@ -1 +1 @@\n-feature\n+feature, fixed\n"
+
+	tests := []struct {
+		name        string
+		autoApply   bool
+		wantCalls   int
+		wantCommits []string
+		wantContent string
+	}{
+		{
+			name:        "applied without an executor fix",
+			autoApply:   true,
+			wantCalls:   1,
+			wantCommits: []string{reviewPatchesCommitMessage, "Task 1: Feature"},
+			wantContent: "feature, fixed\n",
+		},
+		{
+			name:        "disabled leaves the fix to the executor",
+			autoApply:   false,
+			wantCalls:   2,
+			wantCommits: []string{"Task 1: Feature"},
+			wantContent: "feature\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			planPath, workingFile := writePlanFile(t, dir, planConfig{
+				Tasks:       []string{"Task 1: Feature"},
+				CommitFiles: true,
+			})
+
+			invoker := newSequenceInvoker([]sequenceResponse{
+				{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+					Summary: "Feature done", FileEdits: map[string]string{workingFile: "feature\n"}},
+				{Status: protocol.StatusDone, Summary: "Review fix"},
+			})
+
+			l := New(safety.Config{MaxIterations: 10, MaxReviewIterations: 3, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+			l.SetInvoker(invoker)
+			l.SetSource(source.NewPlanSource(planPath))
+			l.SetReviewRunner(createPatchReviewRunner(t, patch))
+			l.SetReviewConfig(review.Config{
+				MaxIterations:    3,
+				Agents:           []review.AgentConfig{{Name: "test_agent"}},
+				AutoApplyPatches: tc.autoApply,
+			})
+			l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true})
+
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+			assert.Equal(t, tc.wantCalls, invoker.CallCount())
+			assert.Equal(t, tc.wantCommits, getCommitMessages(t, dir))
+			content, err := os.ReadFile(workingFile)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantContent, string(content))
+		})
+	}
+}
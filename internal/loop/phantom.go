@@ -0,0 +1,83 @@
+package loop
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+)
+
+// SetVerifyFilesChanged enables checking the files_changed of each status
+// block against git status, so files the executor only claims to have
+// changed do not count as progress.
+func (l *Loop) SetVerifyFilesChanged(enabled bool) {
+	l.verifyFilesChanged = enabled
+}
+
+// captureWorktree records the git worktree before an invocation, so
+// dropPhantomChanges can check the files the executor reports against it.
+func (l *Loop) captureWorktree(rc *runContext) {
+	rc.worktree = nil
+	if !l.verifyFilesChanged || l.gitRepo == nil {
+		return
+	}
+	state, err := l.gitRepo.CaptureState()
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: cannot record git status, reported changes go unchecked: %v", err))
+		return
+	}
+	rc.worktree = state
+}
+
+// dropPhantomChanges removes from status.FilesChanged the files git shows
+// no change to since the invocation started, and logs them. Files the
+// executor only claims to have changed are not progress: an iteration
+// reporting nothing but phantom changes counts toward stagnation.
+func (l *Loop) dropPhantomChanges(rc *runContext, status *parser.ParsedStatus) {
+	before := rc.worktree
+	rc.worktree = nil
+	if before == nil || len(status.FilesChanged) == 0 {
+		return
+	}
+	changed, err := l.gitRepo.ChangedSince(before)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: cannot check reported changes against git status: %v", err))
+		return
+	}
+
+	var real, phantom []string
+	for _, f := range status.FilesChanged {
+		if p, ok := l.gitRepo.RepoPath(f); ok && changedUnder(changed, p) {
+			real = append(real, f)
+		} else {
+			phantom = append(phantom, f)
+		}
+	}
+	if len(phantom) == 0 {
+		return
+	}
+	status.FilesChanged = real
+	rc.result.PhantomChanges = append(rc.result.PhantomChanges, rc.state.Iteration)
+
+	files := strings.Join(phantom, ", ")
+	l.log(fmt.Sprintf("Warning: executor reported changes git does not show: %s. They do not count as progress", files))
+	l.addNote(rc, notes.Iteration, fmt.Sprintf("warning: [iter %d] Reported changes git does not show: %s", rc.state.Iteration, files))
+}
+
+// changedUnder reports whether p, or a file under p when it is a
+// directory, is among the changed paths.
+func changedUnder(changed map[string]bool, p string) bool {
+	if changed[p] {
+		return true
+	}
+	if p == "." {
+		return len(changed) > 0
+	}
+	for f := range changed {
+		if strings.HasPrefix(f, p+"/") {
+			return true
+		}
+	}
+	return false
+}
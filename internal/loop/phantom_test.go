@@ -0,0 +1,73 @@
+package loop
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_PhantomChangesCountTowardStagnation(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+	mainFile := filepath.Join(dir, "main.go")
+
+	invoker := newSequenceInvoker([]sequenceResponse{
+		{Status: protocol.StatusContinue, FilesChanged: []string{"main.go", "ghost.go"}, Summary: "Started",
+			FileEdits: map[string]string{mainFile: "package main\n"}},
+		{Status: protocol.StatusContinue, FilesChanged: []string{"main.go"}, Summary: "Nothing really"},
+		{Status: protocol.StatusContinue, FilesChanged: []string{"ghost.go"}, Summary: "Nothing again"},
+	})
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 2, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetVerifyFilesChanged(true)
+	var logs []string
+	l.SetEventCallback(func(ev event.Event) { logs = append(logs, ev.Text) })
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, safety.ExitReasonStagnation, result.ExitReason)
+	assert.Equal(t, []int{1, 2, 3}, result.PhantomChanges)
+	assert.Equal(t, []string{"main.go"}, result.TotalFilesChanged)
+	assert.Contains(t, strings.Join(logs, "\n"), "Warning: executor reported changes git does not show: ghost.go")
+}
+
+func TestLoopRun_VerifyFilesChangedOff(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+	invoker := newSequenceInvoker([]sequenceResponse{
+		{Status: protocol.StatusContinue, FilesChanged: []string{"ghost.go"}, Summary: "Claimed"},
+	})
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 2, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Empty(t, result.PhantomChanges)
+	assert.Equal(t, []string{"ghost.go"}, result.TotalFilesChanged)
+}
+
+func TestChangedUnder(t *testing.T) {
+	changed := map[string]bool{"internal/a.go": true}
+	assert.True(t, changedUnder(changed, "internal/a.go"))
+	assert.True(t, changedUnder(changed, "internal"))
+	assert.True(t, changedUnder(changed, "."))
+	assert.False(t, changedUnder(changed, "intern"))
+	assert.False(t, changedUnder(map[string]bool{}, "."))
+}
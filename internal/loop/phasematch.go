@@ -0,0 +1,91 @@
+package loop
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+)
+
+// PhasePicker asks the user which of several open phases a reported
+// phase_completed name meant. An empty choice marks none of them.
+type PhasePicker func(reported string, candidates []string) (string, error)
+
+// SetPhasePicker makes ambiguous phase_completed names ask the user which
+// phase to mark. Nil marks none and notes it.
+func (l *Loop) SetPhasePicker(p PhasePicker) {
+	l.phasePicker = p
+}
+
+// ambiguousPhaseMatches returns the open phases of workItem that reported
+// matches loosely, one containing the other, when it matches more than one
+// and none exactly. Sources would silently mark the first of them.
+func ambiguousPhaseMatches(workItem *domain.WorkItem, reported string) []string {
+	if workItem == nil {
+		return nil
+	}
+	name := domain.NormalizePhaseName(reported)
+	if name == "" {
+		return nil
+	}
+
+	var candidates []string
+	for _, phase := range workItem.Phases {
+		if phase.Completed {
+			continue
+		}
+		existing := domain.NormalizePhaseName(phase.Name)
+		if existing == "" {
+			continue
+		}
+		if existing == name {
+			return nil
+		}
+		if strings.Contains(existing, name) || strings.Contains(name, existing) {
+			candidates = append(candidates, phase.Name)
+		}
+	}
+	if len(candidates) < 2 {
+		return nil
+	}
+	return candidates
+}
+
+// completeAmbiguousPhase marks the phase the user picks from candidates, or
+// none when there is no picker or the user picks none. Returns true when a
+// phase was marked.
+func (l *Loop) completeAmbiguousPhase(rc *runContext, status *parser.ParsedStatus, candidates []string) bool {
+	l.emit(event.PhaseAmbiguous(status.PhaseCompleted, candidates))
+
+	chosen := ""
+	if l.phasePicker != nil {
+		picked, err := l.phasePicker(status.PhaseCompleted, candidates)
+		if err != nil {
+			l.log(fmt.Sprintf("Warning: failed to read phase choice: %v", err))
+		}
+		chosen = strings.TrimSpace(picked)
+	}
+	if chosen == "" {
+		l.log("No phase marked")
+		l.addNote(rc, notes.Phase, fmt.Sprintf("warning: [iter %d] Reported phase '%s' matches several phases (%s); none marked",
+			rc.state.Iteration, status.PhaseCompleted, strings.Join(candidates, "; ")))
+		return false
+	}
+
+	if err := rc.source.UpdatePhase(rc.workItemID, chosen); err != nil {
+		l.log(fmt.Sprintf("Warning: failed to update phase '%s': %v", chosen, err))
+		l.addNote(rc, notes.Phase, fmt.Sprintf("warning: [iter %d] Failed to update phase '%s': %v",
+			rc.state.Iteration, chosen, err))
+		return false
+	}
+	l.log(fmt.Sprintf("Marked '%s' as chosen for '%s'", chosen, status.PhaseCompleted))
+	l.addNote(rc, notes.Phase, fmt.Sprintf("progress: [iter %d] Completed %s (reported as %s, chosen by the user)",
+		rc.state.Iteration, chosen, status.PhaseCompleted))
+	if err := l.autoCommitPhase(rc, chosen, status.FilesChanged); err != nil {
+		l.log(fmt.Sprintf("Warning: auto-commit failed: %v", err))
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestAmbiguousPhaseMatches(t *testing.T) {
+	item := &domain.WorkItem{Phases: []domain.Phase{
+		{Name: "Add tests for parser"},
+		{Name: "Add tests for lexer"},
+		{Name: "Add tests for printer", Completed: true},
+		{Name: "Write docs"},
+	}}
+
+	require.Equal(t, []string{"Add tests for parser", "Add tests for lexer"}, ambiguousPhaseMatches(item, "add tests"))
+	require.Nil(t, ambiguousPhaseMatches(item, "Phase 1: Add tests for parser"), "exact match")
+	require.Nil(t, ambiguousPhaseMatches(item, "Write docs and examples"), "single loose match")
+	require.Nil(t, ambiguousPhaseMatches(item, "Deploy"))
+	require.Nil(t, ambiguousPhaseMatches(nil, "add tests"))
+}
+
+func newPhaseMatchTestLoop(t *testing.T) (*Loop, *domain.WorkItem, *[]string) {
+	t.Helper()
+	item := &domain.WorkItem{
+		ID:     "test-phasematch",
+		Title:  "Phase match",
+		Phases: []domain.Phase{{Name: "Add tests for parser"}, {Name: "Add tests for lexer"}},
+	}
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return item, nil
+	}
+	mock.UpdatePhaseFunc = func(_, phaseName string) error {
+		for i := range item.Phases {
+			if item.Phases[i].Name == phaseName {
+				item.Phases[i].Completed = true
+				return nil
+			}
+		}
+		// Like the real sources, a loose match marks the first phase.
+		for i := range item.Phases {
+			if strings.Contains(strings.ToLower(item.Phases[i].Name), strings.ToLower(phaseName)) {
+				item.Phases[i].Completed = true
+				return nil
+			}
+		}
+		return fmt.Errorf("phase %q not found", phaseName)
+	}
+	notes := &[]string{}
+	mock.AddNoteFunc = func(_, note string) error {
+		*notes = append(*notes, note)
+		return nil
+	}
+
+	config := safety.Config{MaxIterations: 5, StagnationLimit: 3, Timeout: 60, MaxReviewIterations: 3}
+	l := NewWithSource(config, "", nil, false, mock)
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	calls := 0
+	l.SetInvoker(&fakeInvoker{fn: func(context.Context, string) (string, error) {
+		calls++
+		phase := "Add tests"
+		if calls > 1 {
+			phase = "Add tests for parser"
+		}
+		return fmt.Sprintf(`PROGRAMMATOR_STATUS:
+  phase_completed: %q
+  status: CONTINUE
+  files_changed: ["lexer_test.go"]
+  summary: "Added tests"
+`, phase), nil
+	}})
+	return l, item, notes
+}
+
+func TestLoopRun_AmbiguousPhasePicked(t *testing.T) {
+	l, item, notes := newPhaseMatchTestLoop(t)
+	var offered []string
+	l.SetPhasePicker(func(reported string, candidates []string) (string, error) {
+		require.Equal(t, "Add tests", reported)
+		offered = candidates
+		return "Add tests for lexer", nil
+	})
+
+	result, err := l.Run("test-phasematch")
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+	require.Equal(t, []string{"Add tests for parser", "Add tests for lexer"}, offered)
+	require.True(t, item.Phases[1].Completed)
+	require.Contains(t, strings.Join(*notes, "\n"), "Completed Add tests for lexer (reported as Add tests, chosen by the user)")
+}
+
+func TestLoopRun_AmbiguousPhaseHeadless(t *testing.T) {
+	l, item, notes := newPhaseMatchTestLoop(t)
+	var ambiguous []event.Event
+	l.SetEventCallback(func(e event.Event) {
+		if e.Kind == event.KindPhaseAmbiguous {
+			ambiguous = append(ambiguous, e)
+		}
+	})
+
+	result, err := l.Run("test-phasematch")
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonMaxIterations, result.ExitReason)
+	require.NotEmpty(t, ambiguous)
+	require.Equal(t, []string{"Add tests for parser", "Add tests for lexer"}, ambiguous[0].Phases)
+	require.True(t, item.Phases[0].Completed, "marked when reported exactly")
+	require.False(t, item.Phases[1].Completed, "never marked by the ambiguous report")
+	require.Contains(t, strings.Join(*notes, "\n"),
+		"Reported phase 'Add tests' matches several phases (Add tests for parser; Add tests for lexer); none marked")
+}
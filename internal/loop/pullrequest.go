@@ -0,0 +1,223 @@
+package loop
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/forge"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// checkoutWorkItem checks out the branch of a source that works on an
+// existing branch, such as a pull request. The run commits and pushes to that
+// branch instead of creating its own, and never rebases it: the branch is
+// already published.
+func (l *Loop) checkoutWorkItem(co source.Checkouter, workItemID string) error {
+	l.log(fmt.Sprintf("Checking out %s", workItemID))
+	base, err := co.Checkout(workItemID, l.workingDir)
+	if err != nil {
+		return fmt.Errorf("check out %s: %w", workItemID, err)
+	}
+
+	l.gitConfig.AutoBranch = false
+	l.gitConfig.AutoCommit = true
+	l.gitConfig.AutoPush = true
+	if l.gitConfig.BaseSync == BaseSyncRebase {
+		l.gitConfig.BaseSync = BaseSyncWarn
+	}
+	if base != "" {
+		l.gitConfig.BaseBranch = base
+		l.reviewConfig.BaseBranch = base
+	}
+	l.pullRequestBase = base
+	if head, err := gitutil.HeadCommit(l.workingDir); err == nil {
+		l.pullRequestHead = head
+	}
+	return nil
+}
+
+// seedPullRequestChanges makes the review cover the whole pull request: the
+// files it changes are reviewed from the first review on, and the diff shown
+// to reviewers starts at the merge-base with the base branch.
+func (l *Loop) seedPullRequestChanges(rc *runContext) {
+	if l.pullRequestBase == "" || l.gitRepo == nil {
+		return
+	}
+
+	ref := l.pullRequestBase
+	remote := l.gitConfig.PushRemote
+	if remote == "" {
+		remote = defaultPushRemote
+	}
+	if l.gitRepo.HasRemote(remote) {
+		ref = remote + "/" + l.pullRequestBase
+	}
+	if mergeBase, err := l.gitRepo.MergeBase(ref); err == nil {
+		if l.reviewConfig.IncludeDiff || l.reviewConfig.HasSizeRules() {
+			l.reviewConfig.DiffBase = mergeBase
+		}
+		if rc.result.BaseSHA == "" {
+			rc.result.BaseSHA = mergeBase
+		}
+	} else {
+		l.log(fmt.Sprintf("Warning: no merge-base with %s, the review diff covers this run only: %v", ref, err))
+	}
+
+	files, err := l.gitRepo.ChangedFilesFromBase(l.pullRequestBase)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: failed to list pull request changes: %v", err))
+		return
+	}
+	rc.addFilesChanged(files)
+}
+
+// reportResult pushes the run's commits and posts a summary on sources that
+// support it. Commits the executor made itself while fixing review issues
+// are not counted as unpushed, so they are detected by comparing HEAD with
+// where the run started.
+func (l *Loop) reportResult(rc *runContext) {
+	reporter, ok := rc.source.(source.Reporter)
+	if !ok {
+		return
+	}
+
+	if l.gitRepo != nil && l.pullRequestHead != "" {
+		if head, err := gitutil.HeadCommit(l.workingDir); err == nil && head != l.pullRequestHead {
+			rc.unpushed = max(rc.unpushed, 1)
+		}
+	}
+	l.pushCommits(rc)
+
+	if err := reporter.Report(rc.workItemID, l.runSummary(rc)); err != nil {
+		l.log(fmt.Sprintf("Warning: failed to report result: %v", err))
+	}
+
+	if updater, ok := rc.source.(source.SummaryUpdater); ok && rc.statusSet {
+		state, description := forge.StateSuccess, "Completed"
+		if rc.result.ExitReason != safety.ExitReasonComplete {
+			state, description = forge.StateFailure, fmt.Sprintf("Stopped: %s", rc.result.ExitReason)
+		}
+		l.setCommitStatus(rc, updater, state, description)
+	}
+}
+
+// updatePullRequestSummary refreshes the summary comment before an
+// iteration, and sets the pending commit status before the first one.
+func (l *Loop) updatePullRequestSummary(rc *runContext) {
+	updater, ok := rc.source.(source.SummaryUpdater)
+	if !ok {
+		return
+	}
+	if l.gitConfig.PRComment {
+		if err := updater.UpdateSummary(rc.workItemID, l.progressSummary(rc)); err != nil {
+			l.log(fmt.Sprintf("Warning: failed to update pull request summary: %v", err))
+		}
+	}
+	if !rc.statusSet {
+		rc.statusSet = l.setCommitStatus(rc, updater, forge.StatePending, "Running")
+	}
+}
+
+// setCommitStatus sets the commit status of HEAD when enabled and reports
+// whether it was set.
+func (l *Loop) setCommitStatus(rc *runContext, updater source.SummaryUpdater, state forge.CommitState, description string) bool {
+	if !l.gitConfig.PRStatus {
+		return false
+	}
+	head, err := gitutil.HeadCommit(l.workingDir)
+	if err != nil {
+		return false
+	}
+	if err := updater.SetCommitStatus(rc.workItemID, head, state, description); err != nil {
+		l.log(fmt.Sprintf("Warning: failed to set commit status: %v", err))
+		return false
+	}
+	return true
+}
+
+// progressSummary describes the running run in Markdown.
+func (l *Loop) progressSummary(rc *runContext) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**programmator** is running: iteration %d", rc.state.Iteration+1)
+	if l.config.MaxIterations > 0 {
+		fmt.Fprintf(&b, " of at most %d", l.config.MaxIterations)
+	}
+	b.WriteString("\n\n")
+	if rc.workItem != nil && len(rc.workItem.Phases) > 0 {
+		done := 0
+		for _, phase := range rc.workItem.Phases {
+			if phase.Completed {
+				done++
+			}
+		}
+		fmt.Fprintf(&b, "- Phases: %d/%d done", done, len(rc.workItem.Phases))
+		if current := rc.workItem.CurrentPhase(); current != nil {
+			fmt.Fprintf(&b, ", working on %s", current.Name)
+		}
+		b.WriteString("\n")
+	}
+	switch {
+	case l.reviewConfig.Skip:
+		b.WriteString("- Review: skipped\n")
+	case l.engine.ReviewPassed:
+		b.WriteString("- Review: passed\n")
+	case l.engine.ReviewIterations > 0:
+		fmt.Fprintf(&b, "- Review: %d iteration(s), fixing issues\n", l.engine.ReviewIterations)
+	default:
+		b.WriteString("- Review: pending\n")
+	}
+	l.writeCost(&b, rc)
+	return b.String()
+}
+
+// writeCost adds the estimated cost of the run so far, when it can be
+// priced.
+func (l *Loop) writeCost(b *strings.Builder, rc *runContext) {
+	if len(l.prices) == 0 {
+		return
+	}
+	total, priced := 0.0, false
+	for model, tokens := range rc.state.TokensByModel {
+		if price, ok := l.prices.Lookup(model); ok {
+			total += price.Cost(tokens.InputTokens, tokens.OutputTokens)
+			priced = true
+		}
+	}
+	if priced {
+		fmt.Fprintf(b, "- Estimated cost: ~$%.2f\n", total)
+	}
+}
+
+// runSummary describes how the run ended in Markdown.
+func (l *Loop) runSummary(rc *runContext) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**programmator** finished: `%s` after %d iteration(s)", rc.result.ExitReason, rc.state.Iteration)
+	if rc.result.ExitMessage != "" {
+		fmt.Fprintf(&b, " (%s)", rc.result.ExitMessage)
+	}
+	b.WriteString("\n\n")
+
+	switch {
+	case l.reviewConfig.Skip:
+		b.WriteString("- Review: skipped\n")
+	case l.engine.ReviewPassed:
+		b.WriteString("- Review: passed\n")
+	case l.engine.ReviewIterations > 0:
+		fmt.Fprintf(&b, "- Review: not passed after %d iteration(s)\n", l.engine.ReviewIterations)
+	default:
+		b.WriteString("- Review: not run\n")
+	}
+	if rc.unpushed > 0 {
+		fmt.Fprintf(&b, "- %d commit(s) could not be pushed\n", rc.unpushed)
+	} else if head, err := gitutil.HeadCommit(l.workingDir); err == nil && l.pullRequestHead != "" && head != l.pullRequestHead {
+		fmt.Fprintf(&b, "- Pushed fixes up to %s\n", shortSHA(head))
+	}
+	l.writeCost(&b, rc)
+	return b.String()
+}
+
+func shortSHA(sha string) string {
+	return sha[:min(len(sha), 12)]
+}
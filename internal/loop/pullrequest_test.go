@@ -0,0 +1,135 @@
+package loop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/forge"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_PullRequest(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// The pull request branch already changes pr.txt relative to its base.
+	repo, err := gitutil.NewRepo(dir)
+	require.NoError(t, err)
+	base, err := repo.CurrentBranch()
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateBranch("feature"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pr.txt"), []byte("pr\n"), 0o644))
+	require.NoError(t, repo.AddAndCommit([]string{"pr.txt"}, "PR work"))
+
+	client := forge.NewMockClient(forge.PullRequest{
+		Title:      "Add feature",
+		Body:       "- [x] Write code\n- [ ] Add tests\n",
+		HeadBranch: "feature",
+		BaseBranch: base,
+	})
+	workingFile := filepath.Join(dir, "working.txt")
+	inv := newSequenceInvoker([]sequenceResponse{
+		{PhaseCompleted: "Add tests", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+			Summary: "Tests added", FileEdits: map[string]string{workingFile: "tests\n"}},
+	})
+
+	l := New(safety.Config{MaxIterations: 5, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(inv)
+	l.SetSource(source.NewPullRequestSource(client, forge.Ref{Kind: forge.GitHub, Number: 1}))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+
+	result, err := l.Run("pr:1")
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	assert.Equal(t, []string{dir}, client.Checkouts)
+	assert.Equal(t, "- [x] Write code\n- [x] Add tests\n", client.PR.Body)
+	assert.ElementsMatch(t, []string{"pr.txt", "working.txt"}, result.TotalFilesChanged)
+	assert.NotEmpty(t, result.BaseSHA)
+
+	branch, err := repo.CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "feature", branch, "works on the pull request branch")
+	dirty, err := repo.HasUncommittedChanges()
+	require.NoError(t, err)
+	assert.False(t, dirty, "phase is committed")
+
+	require.Len(t, client.Comments, 1)
+	assert.Contains(t, client.Comments[0], "finished: `complete` after 1 iteration(s)")
+	assert.Contains(t, client.Comments[0], "- Review: passed")
+	assert.Contains(t, client.Comments[0], "could not be pushed", "the test repo has no remote")
+	assert.Contains(t, client.Comments[0], "Completed Add tests")
+}
+
+func TestLoopRun_PullRequestCheckoutFails(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	client := forge.NewMockClient(forge.PullRequest{Body: "- [ ] Task"})
+	client.CheckoutErr = assert.AnError
+
+	l := New(safety.Config{MaxIterations: 5, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(newSequenceInvoker(nil))
+	l.SetSource(source.NewPullRequestSource(client, forge.Ref{Kind: forge.GitHub, Number: 1}))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+
+	result, err := l.Run("pr:1")
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, safety.ExitReasonError, result.ExitReason)
+	assert.Empty(t, client.Comments)
+}
+
+func TestLoopRun_PullRequestSummaryComment(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	client := forge.NewMockClient(forge.PullRequest{Body: "- [ ] Write code\n- [ ] Add tests\n"})
+	inv := newSequenceInvoker([]sequenceResponse{
+		{PhaseCompleted: "Write code", Status: protocol.StatusContinue, Summary: "Code written"},
+		{PhaseCompleted: "Add tests", Status: protocol.StatusDone, Summary: "Tests added"},
+	})
+
+	var progress []string
+	onState := func(*safety.State, *domain.WorkItem, []string) {
+		if len(client.Comments) > 0 {
+			progress = append(progress, client.Comments[0])
+		}
+	}
+	l := New(safety.Config{MaxIterations: 5, StagnationLimit: 3, Timeout: 60}, dir, onState, false)
+	l.SetInvoker(inv)
+	l.SetSource(source.NewPullRequestSource(client, forge.Ref{Kind: forge.GitHub, Number: 1}))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetGitWorkflowConfig(GitWorkflowConfig{PRComment: true, PRStatus: true})
+	l.SetPrices(llm.DefaultPrices())
+
+	result, err := l.Run("pr:1")
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	assert.Contains(t, progress, "<!-- programmator:summary -->\n**programmator** is running: iteration 2 of at most 5\n\n"+
+		"- Phases: 1/2 done, working on Add tests\n- Review: pending\n\n<details>\n<summary>Run notes</summary>\n\n"+
+		"progress: [iter 1] Completed Write code\n\n</details>\n")
+
+	require.Len(t, client.Comments, 1, "one comment, edited in place")
+	assert.Contains(t, client.Comments[0], "finished: `complete` after 2 iteration(s)")
+	assert.Contains(t, client.Comments[0], "- Review: passed")
+
+	require.Len(t, client.Statuses, 2)
+	assert.Equal(t, forge.StatePending, client.Statuses[0].State)
+	assert.Equal(t, forge.StateSuccess, client.Statuses[1].State)
+	head, err := gitutil.HeadCommit(dir)
+	require.NoError(t, err)
+	assert.Equal(t, head, client.StatusSHAs[1])
+}
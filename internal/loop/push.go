@@ -0,0 +1,53 @@
+package loop
+
+import (
+	"errors"
+	"fmt"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+)
+
+// defaultPushRemote is used when AutoPush is on and no remote is configured.
+const defaultPushRemote = "origin"
+
+// recordCommit counts a commit made by the loop and pushes once PushEvery
+// commits have accumulated.
+func (l *Loop) recordCommit(rc *runContext) {
+	if !l.gitConfig.AutoPush {
+		return
+	}
+	rc.unpushed++
+	if rc.unpushed >= max(l.gitConfig.PushEvery, 1) {
+		l.pushCommits(rc)
+	}
+}
+
+// pushCommits pushes any commits not yet pushed. Failures are reported as
+// warnings and the commits stay pending, so the next push retries them.
+func (l *Loop) pushCommits(rc *runContext) {
+	if !l.gitConfig.AutoPush || l.gitRepo == nil || rc.unpushed == 0 {
+		return
+	}
+
+	remote := l.gitConfig.PushRemote
+	if remote == "" {
+		remote = defaultPushRemote
+	}
+
+	l.log(fmt.Sprintf("Pushing %d commit(s) to %s", rc.unpushed, remote))
+	if err := l.gitRepo.Push(remote); err != nil {
+		hint := ""
+		switch {
+		case errors.Is(err, gitutil.ErrPushRejected):
+			hint = " (remote branch has diverged; pull and push manually)"
+		case errors.Is(err, gitutil.ErrPushAuth):
+			hint = " (check git credentials for the remote)"
+		}
+		l.log(fmt.Sprintf("Warning: push failed%s: %v", hint, err))
+		l.addNote(rc, notes.Phase, fmt.Sprintf("warning: [iter %d] Push to %s failed%s: %v", rc.state.Iteration, remote, hint, err))
+		return
+	}
+	rc.unpushed = 0
+	rc.pushed = true
+}
@@ -0,0 +1,105 @@
+package loop
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func TestLoopRun_AutoPush(t *testing.T) {
+	tests := []struct {
+		name       string
+		remote     string // "" creates a bare remote
+		pushEvery  int
+		wantPushed bool
+	}{
+		{name: "pushes commits and sets upstream", pushEvery: 1, wantPushed: true},
+		{name: "pushes leftover commits at the end", pushEvery: 5, wantPushed: true},
+		{name: "push failure becomes a warning", remote: "missing", pushEvery: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			remoteDir := filepath.Join(t.TempDir(), "remote.git")
+			gitOutput(t, dir, "init", "-q", "--bare", remoteDir)
+			gitOutput(t, dir, "remote", "add", "origin", remoteDir)
+
+			planPath, workingFile := writePlanFile(t, dir, planConfig{
+				Tasks:       []string{"Task 1: Feature", "Task 2: Tests"},
+				CommitFiles: true,
+			})
+
+			invoker := newSequenceInvoker([]sequenceResponse{
+				{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusContinue, FilesChanged: []string{"working.txt"},
+					Summary: "Feature", FileEdits: map[string]string{workingFile: "feature\n"}},
+				{PhaseCompleted: "Task 2: Tests", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+					Summary: "Tests", FileEdits: map[string]string{workingFile: "feature\ntests\n"}},
+			})
+
+			var notes []string
+			l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+			l.SetInvoker(invoker)
+			l.SetSource(&notingSource{Source: source.NewPlanSource(planPath), notes: &notes})
+			l.SetReviewRunner(createNoIssueReviewRunner(t))
+			l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+			l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true, AutoPush: true, PushRemote: tc.remote, PushEvery: tc.pushEvery})
+
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+			if tc.wantPushed {
+				head := gitOutput(t, dir, "rev-parse", "HEAD")
+				assert.Equal(t, head, gitOutput(t, remoteDir, "rev-parse", "HEAD"))
+				assert.NotEmpty(t, gitOutput(t, dir, "rev-parse", "--abbrev-ref", "@{upstream}"))
+				return
+			}
+			assert.True(t, containsNote(notes, "warning:", "Push to missing failed"), "notes: %v", notes)
+		})
+	}
+}
+
+// notingSource records notes added through a wrapped source.
+type notingSource struct {
+	source.Source
+	notes *[]string
+}
+
+func (s *notingSource) AddNote(id, note string) error {
+	*s.notes = append(*s.notes, note)
+	return s.Source.AddNote(id, note)
+}
+
+func containsNote(notes []string, parts ...string) bool {
+	for _, n := range notes {
+		ok := true
+		for _, p := range parts {
+			ok = ok && strings.Contains(n, p)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package loop
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+)
+
+// QuestionHandler asks the user the questions from a BLOCKED status and
+// returns their answer. An empty answer leaves the run blocked.
+type QuestionHandler func(blockedError string, questions []string) (string, error)
+
+// SetQuestionHandler makes BLOCKED statuses ask the user for an answer and
+// resume with it instead of ending the run. Nil disables asking.
+func (l *Loop) SetQuestionHandler(h QuestionHandler) {
+	l.questionHandler = h
+}
+
+// askBlockedQuestions asks the user to unblock the executor. When the status
+// has no questions, its error is asked instead. Returns true when the user
+// answered and the loop should keep going.
+func (l *Loop) askBlockedQuestions(rc *runContext, blockedError string, questions []string) bool {
+	if l.questionHandler == nil {
+		return false
+	}
+	if len(questions) == 0 {
+		if blockedError == "" {
+			return false
+		}
+		questions = []string{blockedError}
+	}
+
+	l.log(fmt.Sprintf("Asking user %d question(s)", len(questions)))
+	answer, err := l.questionHandler(blockedError, questions)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: failed to read answer: %v", err))
+		return false
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		l.log("No answer given")
+		return false
+	}
+
+	var b strings.Builder
+	for _, q := range questions {
+		fmt.Fprintf(&b, "Q: %s\n", q)
+	}
+	fmt.Fprintf(&b, "A: %s", answer)
+	rc.userAnswers = append(rc.userAnswers, b.String())
+	rc.answersPending = true
+
+	l.log("Resuming with the user's answer")
+	l.addNote(rc, notes.Iteration, fmt.Sprintf("progress: [iter %d] User answered: %s", rc.state.Iteration, answer))
+
+	rc.state.ConsecutiveNoChanges = 0
+	rc.state.ConsecutiveErrors = 0
+	return true
+}
+
+// withUserAnswers appends the user's answers to blocking questions to a
+// prompt. A resumed session already saw earlier answers, so only new ones
+// are sent to it.
+func (rc *runContext) withUserAnswers(promptText string, resumed bool) string {
+	if len(rc.userAnswers) == 0 || (resumed && !rc.answersPending) {
+		return promptText
+	}
+	answers := rc.userAnswers
+	if resumed {
+		answers = answers[len(answers)-1:]
+	}
+	rc.answersPending = false
+	return promptText + "\n\n## Answers from the user\n\nYou reported BLOCKED earlier. The user answered your questions:\n\n" +
+		strings.Join(answers, "\n\n") + "\n"
+}
@@ -0,0 +1,132 @@
+package loop
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+const blockedWithQuestions = `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: BLOCKED
+  files_changed: []
+  summary: "Need a decision"
+  error: "Storage backend is unspecified"
+  questions:
+    - "Redis or Postgres?"
+`
+
+func newQuestionsTestLoop(t *testing.T) (*Loop, *domain.WorkItem, *[]string) {
+	t.Helper()
+	item := &domain.WorkItem{
+		ID:     "test-questions",
+		Title:  "Questions",
+		Phases: []domain.Phase{{Name: "Store sessions"}},
+	}
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		return item, nil
+	}
+	mock.UpdatePhaseFunc = func(_, phaseName string) error {
+		for i := range item.Phases {
+			if item.Phases[i].Name == phaseName {
+				item.Phases[i].Completed = true
+			}
+		}
+		return nil
+	}
+	notes := &[]string{}
+	mock.AddNoteFunc = func(_, note string) error {
+		*notes = append(*notes, note)
+		return nil
+	}
+
+	config := safety.Config{MaxIterations: 5, StagnationLimit: 3, Timeout: 60, MaxReviewIterations: 3}
+	l := NewWithSource(config, "", nil, false, mock)
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	return l, item, notes
+}
+
+func TestLoopRun_BlockedQuestionAnswered(t *testing.T) {
+	l, _, notes := newQuestionsTestLoop(t)
+
+	var asked []string
+	var blockedErr string
+	l.SetQuestionHandler(func(errText string, questions []string) (string, error) {
+		blockedErr = errText
+		asked = questions
+		return "Postgres", nil
+	})
+
+	var prompts []string
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if len(prompts) == 1 {
+			return blockedWithQuestions, nil
+		}
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: "Store sessions"
+  status: DONE
+  files_changed: ["store.go"]
+  summary: "Stored sessions in Postgres"
+`, nil
+	}})
+
+	result, err := l.Run("test-questions")
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	require.Equal(t, "Storage backend is unspecified", blockedErr)
+	require.Equal(t, []string{"Redis or Postgres?"}, asked)
+	require.Len(t, prompts, 2)
+	require.NotContains(t, prompts[0], "## Answers from the user")
+	require.Contains(t, prompts[1], "## Answers from the user")
+	require.Contains(t, prompts[1], "Q: Redis or Postgres?\nA: Postgres")
+	require.Contains(t, strings.Join(*notes, "\n"), "User answered: Postgres")
+}
+
+func TestLoopRun_BlockedQuestionUnanswered(t *testing.T) {
+	l, _, _ := newQuestionsTestLoop(t)
+	l.SetQuestionHandler(func(string, []string) (string, error) {
+		return "  ", nil
+	})
+	calls := 0
+	l.SetInvoker(&fakeInvoker{fn: func(context.Context, string) (string, error) {
+		calls++
+		return blockedWithQuestions, nil
+	}})
+
+	result, err := l.Run("test-questions")
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonBlocked, result.ExitReason)
+	require.Equal(t, 1, calls)
+}
+
+func TestLoopRun_BlockedErrorAskedWithoutQuestions(t *testing.T) {
+	l, _, _ := newQuestionsTestLoop(t)
+	var asked []string
+	l.SetQuestionHandler(func(_ string, questions []string) (string, error) {
+		asked = questions
+		return "", nil
+	})
+	l.SetInvoker(&fakeInvoker{fn: func(context.Context, string) (string, error) {
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: BLOCKED
+  files_changed: []
+  summary: "Stuck"
+  error: "Database credentials not configured"
+`, nil
+	}})
+
+	result, err := l.Run("test-questions")
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonBlocked, result.ExitReason)
+	require.Equal(t, []string{"Database credentials not configured"}, asked)
+}
@@ -0,0 +1,217 @@
+package loop
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// TransitionKind names the engine decision a Transition records.
+type TransitionKind string
+
+const (
+	TransitionStart       TransitionKind = "start"        // run started (Start)
+	TransitionStatus      TransitionKind = "status"       // executor status processed (ProcessStatus)
+	TransitionSafety      TransitionKind = "safety"       // safety limits checked (CheckSafety)
+	TransitionReviewLimit TransitionKind = "review_limit" // review iteration limit checked (StartReview)
+	TransitionReview      TransitionKind = "review"       // review result judged (DecideReview)
+	TransitionExit        TransitionKind = "exit"         // run ended (Finish)
+)
+
+// Decisions recorded in Transition.Decision.
+const (
+	DecisionContinue      = "continue"
+	DecisionExit          = "exit"
+	DecisionTaskCompleted = "task_completed"
+	DecisionBlocked       = "blocked"
+	DecisionLimitReached  = "limit_reached"
+	DecisionReviewPassed  = "passed"
+	DecisionNeedsFix      = "needs_fix"
+	DecisionNotConverging = "not_converging"
+)
+
+// EngineConfig is the engine configuration a run started with.
+type EngineConfig struct {
+	Safety        safety.Config `json:"safety"`
+	MaxReviewIter int           `json:"max_review_iterations"`
+}
+
+// SafetySnapshot holds the counters a safety check looks at.
+type SafetySnapshot struct {
+	Iteration            int  `json:"iteration"`
+	ConsecutiveNoChanges int  `json:"consecutive_no_changes"`
+	ConsecutiveErrors    int  `json:"consecutive_errors"`
+	ReviewIterations     int  `json:"review_iterations"`
+	InReviewPhase        bool `json:"in_review_phase,omitempty"`
+}
+
+func snapshotSafety(state *safety.State) SafetySnapshot {
+	return SafetySnapshot{
+		Iteration:            state.Iteration,
+		ConsecutiveNoChanges: state.ConsecutiveNoChanges,
+		ConsecutiveErrors:    state.ConsecutiveErrors,
+		ReviewIterations:     state.ReviewIterations,
+		InReviewPhase:        state.InReviewPhase,
+	}
+}
+
+func (s SafetySnapshot) state() *safety.State {
+	return &safety.State{
+		Iteration:            s.Iteration,
+		ConsecutiveNoChanges: s.ConsecutiveNoChanges,
+		ConsecutiveErrors:    s.ConsecutiveErrors,
+		ReviewIterations:     s.ReviewIterations,
+		InReviewPhase:        s.InReviewPhase,
+	}
+}
+
+// Transition is one engine decision together with the inputs it was made
+// from. Only the fields of its Kind are set.
+type Transition struct {
+	Seq       int            `json:"seq"`
+	Time      time.Time      `json:"time,omitzero"` // stamped by the recorder
+	Kind      TransitionKind `json:"kind"`
+	Iteration int            `json:"iteration,omitempty"`
+
+	// Inputs.
+	Config           *EngineConfig        `json:"config,omitempty"`
+	Status           *parser.ParsedStatus `json:"status,omitempty"`
+	PendingReviewFix bool                 `json:"pending_review_fix,omitempty"`
+	Safety           *SafetySnapshot      `json:"safety,omitempty"`
+	ReviewIterations int                  `json:"review_iterations,omitempty"`
+	ReviewPassed     bool                 `json:"review_passed,omitempty"`
+	Fingerprints     []string             `json:"fingerprints,omitempty"`
+
+	// Decision.
+	Decision   string            `json:"decision,omitempty"`
+	ExitReason safety.ExitReason `json:"exit_reason,omitempty"`
+	Message    string            `json:"message,omitempty"`
+}
+
+// Replay re-executes the decision recorded in t against the engine's
+// current state and returns the transition the engine makes now. Start
+// transitions reconfigure the engine; exit transitions are returned as is.
+func (e *Engine) Replay(t Transition) Transition {
+	var replayed Transition
+	onTransition := e.OnTransition
+	e.OnTransition = func(r Transition) { replayed = r }
+	defer func() { e.OnTransition = onTransition }()
+
+	switch t.Kind {
+	case TransitionStart:
+		if t.Config != nil {
+			e.SafetyConfig = t.Config.Safety
+			e.MaxReviewIter = t.Config.MaxReviewIter
+		}
+		e.ResetReviewState()
+		e.Start()
+	case TransitionStatus:
+		e.ProcessStatus(ProcessStatusInput{Status: t.Status, Iteration: t.Iteration, PendingReviewFix: t.PendingReviewFix})
+	case TransitionSafety:
+		snap := SafetySnapshot{}
+		if t.Safety != nil {
+			snap = *t.Safety
+		}
+		e.CheckSafety(snap.state())
+	case TransitionReviewLimit:
+		e.ReviewIterations = t.ReviewIterations
+		e.StartReview()
+	case TransitionReview:
+		e.ReviewIterations = t.ReviewIterations
+		e.DecideReview(t.ReviewPassed, t.Fingerprints)
+	default:
+		return t
+	}
+	replayed.Seq = t.Seq
+	replayed.Time = t.Time
+	return replayed
+}
+
+// ReplayStep pairs a recorded transition with its replayed counterpart.
+type ReplayStep struct {
+	Recorded Transition
+	Replayed Transition
+}
+
+// Diverged reports whether the engine decides differently today than it
+// did when the run was recorded.
+func (s ReplayStep) Diverged() bool {
+	return s.Recorded.Decision != s.Replayed.Decision || s.Recorded.ExitReason != s.Replayed.ExitReason
+}
+
+// Replay feeds a run's recorded transitions, in order, through a fresh
+// engine. Nothing is invoked: statuses and review results come from the
+// recording.
+func Replay(transitions []Transition) []ReplayStep {
+	var e Engine
+	steps := make([]ReplayStep, 0, len(transitions))
+	for _, t := range transitions {
+		steps = append(steps, ReplayStep{Recorded: t, Replayed: e.Replay(t)})
+	}
+	return steps
+}
+
+// ExitCause returns the step whose decision ended the run: the last replayed
+// decision with the run's exit reason. ok is false when the exit was not an
+// engine decision (runner errors, interrupts) or the run has no exit record.
+func ExitCause(steps []ReplayStep) (ReplayStep, bool) {
+	if len(steps) == 0 || steps[len(steps)-1].Recorded.Kind != TransitionExit {
+		return ReplayStep{}, false
+	}
+	exit := steps[len(steps)-1].Recorded
+	if exit.ExitReason == safety.ExitReasonComplete {
+		for _, s := range slices.Backward(steps[:len(steps)-1]) {
+			switch s.Replayed.Decision {
+			case DecisionReviewPassed, DecisionLimitReached:
+				return s, true
+			}
+		}
+		return ReplayStep{}, false
+	}
+	for _, s := range slices.Backward(steps[:len(steps)-1]) {
+		if s.Replayed.ExitReason == exit.ExitReason {
+			return s, true
+		}
+	}
+	return ReplayStep{}, false
+}
+
+// Describe summarizes a transition on one line.
+func (t Transition) Describe() string {
+	switch t.Kind {
+	case TransitionStart:
+		if t.Config == nil {
+			return "run started"
+		}
+		return fmt.Sprintf("run started: max_iterations=%d stagnation_limit=%d max_review_iterations=%d",
+			t.Config.Safety.MaxIterations, t.Config.Safety.StagnationLimit, t.Config.MaxReviewIter)
+	case TransitionStatus:
+		if t.Status == nil {
+			return "status: none"
+		}
+		s := fmt.Sprintf("status %s, %d file(s) changed", t.Status.Status, len(t.Status.FilesChanged))
+		if t.Status.PhaseCompleted != "" {
+			s += fmt.Sprintf(", completed %q", t.Status.PhaseCompleted)
+		}
+		return s
+	case TransitionSafety:
+		if t.Safety == nil {
+			return "safety check"
+		}
+		return fmt.Sprintf("safety check: no-change streak %d, error streak %d, review iterations %d",
+			t.Safety.ConsecutiveNoChanges, t.Safety.ConsecutiveErrors, t.Safety.ReviewIterations)
+	case TransitionReviewLimit:
+		return fmt.Sprintf("review limit check: %d review iteration(s) done", t.ReviewIterations)
+	case TransitionReview:
+		if t.ReviewPassed {
+			return fmt.Sprintf("review %d: passed", t.ReviewIterations)
+		}
+		return fmt.Sprintf("review %d: %d issue(s)", t.ReviewIterations, len(t.Fingerprints))
+	case TransitionExit:
+		return fmt.Sprintf("run ended: %s", t.ExitReason)
+	}
+	return string(t.Kind)
+}
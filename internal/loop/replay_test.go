@@ -0,0 +1,126 @@
+package loop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestEngine_RecordsTransitions(t *testing.T) {
+	e := newTestEngine()
+	e.MaxReviewIter = 1
+	var got []Transition
+	e.OnTransition = func(tr Transition) { got = append(got, tr) }
+
+	e.Start()
+	e.ProcessStatus(ProcessStatusInput{Status: &parser.ParsedStatus{Status: protocol.StatusDone}, Iteration: 1})
+	e.CheckSafety(&safety.State{Iteration: 2, ConsecutiveNoChanges: 3})
+	assert.True(t, e.StartReview())
+	e.DecideReview(false, []string{"a"})
+	assert.False(t, e.StartReview())
+	e.Finish(safety.ExitReasonComplete, "")
+
+	require.Len(t, got, 7)
+	for i, tr := range got {
+		assert.Equal(t, i+1, tr.Seq)
+	}
+	assert.Equal(t, TransitionStart, got[0].Kind)
+	assert.Equal(t, 1, got[0].Config.MaxReviewIter)
+	assert.Equal(t, DecisionTaskCompleted, got[1].Decision)
+	assert.Equal(t, DecisionExit, got[2].Decision)
+	assert.Equal(t, safety.ExitReasonStagnation, got[2].ExitReason)
+	assert.Equal(t, DecisionContinue, got[3].Decision)
+	assert.Equal(t, DecisionNeedsFix, got[4].Decision)
+	assert.Equal(t, DecisionLimitReached, got[5].Decision)
+	assert.Equal(t, TransitionExit, got[6].Kind)
+}
+
+func TestReplay_ReproducesDecisions(t *testing.T) {
+	var recorded []Transition
+	e := newTestEngine()
+	e.OnTransition = func(tr Transition) { recorded = append(recorded, tr) }
+
+	e.Start()
+	e.DecideReview(false, []string{"a", "b"})
+	e.DecideReview(false, []string{"a", "b"})
+	e.Finish(safety.ExitReasonReviewStuck, "")
+
+	steps := Replay(recorded)
+	require.Len(t, steps, 4)
+	for _, s := range steps {
+		assert.False(t, s.Diverged(), "step %d", s.Recorded.Seq)
+	}
+	assert.Equal(t, DecisionNotConverging, steps[2].Replayed.Decision, "fingerprint memory is rebuilt from earlier reviews")
+
+	cause, ok := ExitCause(steps)
+	require.True(t, ok)
+	assert.Equal(t, 3, cause.Recorded.Seq)
+}
+
+func TestReplay_FlagsDivergence(t *testing.T) {
+	recorded := []Transition{
+		{Seq: 1, Kind: TransitionStart, Config: &EngineConfig{Safety: safety.Config{MaxIterations: 10, StagnationLimit: 5}}},
+		// Recorded under an older stagnation limit of 2.
+		{Seq: 2, Kind: TransitionSafety, Iteration: 3, Safety: &SafetySnapshot{Iteration: 3, ConsecutiveNoChanges: 2},
+			Decision: DecisionExit, ExitReason: safety.ExitReasonStagnation},
+		{Seq: 3, Kind: TransitionExit, ExitReason: safety.ExitReasonStagnation},
+	}
+
+	steps := Replay(recorded)
+	assert.True(t, steps[1].Diverged())
+	assert.Equal(t, DecisionContinue, steps[1].Replayed.Decision)
+	_, ok := ExitCause(steps)
+	assert.False(t, ok, "the current engine would not have exited")
+}
+
+func TestExitCause_RunnerExit(t *testing.T) {
+	steps := Replay([]Transition{
+		{Seq: 1, Kind: TransitionStart},
+		{Seq: 2, Kind: TransitionExit, ExitReason: safety.ExitReasonError, Decision: "invoke failed"},
+	})
+	_, ok := ExitCause(steps)
+	assert.False(t, ok)
+
+	_, ok = ExitCause(Replay([]Transition{{Seq: 1, Kind: TransitionStart}}))
+	assert.False(t, ok, "no exit record")
+}
+
+func TestLoopRun_RecordsReplayableTransitions(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+	invoker := newSequenceInvoker([]sequenceResponse{
+		{Status: protocol.StatusContinue, Summary: "Thinking"},
+		{Status: protocol.StatusContinue, Summary: "Still thinking"},
+		{Status: protocol.StatusContinue, Summary: "More thinking"},
+	})
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 2, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	var recorded []Transition
+	l.SetTransitionRecorder(func(tr Transition) { recorded = append(recorded, tr) })
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonStagnation, result.ExitReason)
+
+	steps := Replay(recorded)
+	require.NotEmpty(t, steps)
+	for _, s := range steps {
+		assert.False(t, s.Diverged(), "step %d: %s", s.Recorded.Seq, s.Recorded.Describe())
+	}
+	cause, ok := ExitCause(steps)
+	require.True(t, ok)
+	assert.Equal(t, TransitionSafety, cause.Recorded.Kind)
+	assert.Equal(t, safety.ExitReasonStagnation, cause.Replayed.ExitReason)
+}
@@ -0,0 +1,47 @@
+package loop
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// reviewOnlyPhase is the single, completed phase of a review-only run.
+const reviewOnlyPhase = "Review changes"
+
+// RunReviewOnly reviews filesChanged and has the executor fix the issues
+// found, without a plan or ticket: the run starts at the review phase and
+// ends when a review passes or the review limits are reached. Fixes are
+// committed when auto-commit is on, on a branch named after the run when
+// auto-branch is on too, and left in the working tree otherwise.
+func (l *Loop) RunReviewOnly(filesChanged []string) (*Result, error) {
+	if len(filesChanged) == 0 {
+		return nil, fmt.Errorf("no files to review")
+	}
+	l.source = reviewOnlySource{}
+	l.reviewOnlyFiles = filesChanged
+	return l.Run("review-" + time.Now().Format("20060102-150405"))
+}
+
+// reviewOnlySource stands in for the work item of a review-only run: one
+// completed phase, so the run goes straight to review, and nothing to update.
+type reviewOnlySource struct{}
+
+var _ source.Source = reviewOnlySource{}
+
+func (reviewOnlySource) Get(id string) (*domain.WorkItem, error) {
+	return &domain.WorkItem{
+		ID:         id,
+		Title:      reviewOnlyPhase,
+		RawContent: "# " + reviewOnlyPhase + "\n\nFix the issues code review finds in the changed files.\n",
+		Phases:     []domain.Phase{{Name: reviewOnlyPhase, Completed: true}},
+	}, nil
+}
+
+func (reviewOnlySource) UpdatePhase(string, string) error { return nil }
+func (reviewOnlySource) SetStatus(string, string) error   { return nil }
+func (reviewOnlySource) AddNote(string, string) error     { return nil }
+func (reviewOnlySource) Type() string                     { return protocol.SourceTypeReview }
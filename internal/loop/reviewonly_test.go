@@ -0,0 +1,65 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+func TestRunReviewOnly_FixesUntilReviewPasses(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// Uncommitted work to review.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wip.go"), []byte("package wip\n"), 0o644))
+
+	reviewCalls := 0
+	var prompts []string
+	l := New(safety.Config{MaxIterations: 5, StagnationLimit: 3, MaxReviewIterations: 3, Timeout: 60}, dir, nil, false)
+	builder, err := prompt.NewBuilder(nil)
+	require.NoError(t, err)
+	l.SetPromptBuilder(builder)
+	l.SetReviewConfig(singleAgentReviewConfig())
+	l.SetReviewRunner(createMockReviewRunnerFunc(t, func() (bool, int) {
+		reviewCalls++
+		return reviewCalls == 1, 1
+	}))
+	l.SetInvoker(&fakeInvoker{fn: func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: CONTINUE
+  files_changed: ["wip.go"]
+  summary: "Fixed the review issue"
+`, nil
+	}})
+
+	result, err := l.RunReviewOnly([]string{"wip.go"})
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+	assert.Equal(t, 2, reviewCalls)
+	require.Len(t, prompts, 1, "one fix between the failing and the passing review")
+	assert.Contains(t, prompts[0], "Issue 1", "the fix prompt lists the issue")
+	assert.Contains(t, result.TotalFilesChanged, "wip.go")
+
+	repo, err := gitutil.NewRepo(dir)
+	require.NoError(t, err)
+	dirty, err := repo.HasUncommittedChanges()
+	require.NoError(t, err)
+	assert.True(t, dirty, "without auto-commit, changes stay uncommitted")
+}
+
+func TestRunReviewOnly_NoFiles(t *testing.T) {
+	l := New(safety.Config{MaxIterations: 5, StagnationLimit: 3, Timeout: 60}, "", nil, false)
+
+	_, err := l.RunReviewOnly(nil)
+	require.Error(t, err)
+}
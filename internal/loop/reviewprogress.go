@@ -0,0 +1,71 @@
+package loop
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// reviewTracker follows the progress a review runner reports, so the state
+// callbacks can show which phase and agents are running and for how long.
+type reviewTracker struct {
+	mu     sync.Mutex
+	phase  string
+	agents []trackedAgent
+}
+
+type trackedAgent struct {
+	name    string
+	started time.Time
+	elapsed time.Duration
+	done    bool
+}
+
+// record applies one progress report. A new phase forgets the agents of
+// the previous one.
+func (t *reviewTracker) record(p review.Progress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case p.Agent == "":
+		t.phase = p.Phase
+		t.agents = nil
+	case !p.Done:
+		t.agents = append(t.agents, trackedAgent{name: p.Agent, started: time.Now()})
+	default:
+		for i := range t.agents {
+			if a := &t.agents[i]; a.name == p.Agent && !a.done {
+				a.done = true
+				a.elapsed = time.Since(a.started)
+				break
+			}
+		}
+	}
+}
+
+// snapshot returns the progress so far, with running agents timed up to now.
+func (t *reviewTracker) snapshot() *safety.ReviewProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := &safety.ReviewProgress{Phase: t.phase}
+	for _, a := range t.agents {
+		elapsed := a.elapsed
+		if !a.done {
+			elapsed = time.Since(a.started)
+		}
+		p.Agents = append(p.Agents, safety.AgentProgress{Name: a.name, Elapsed: elapsed, Done: a.done})
+	}
+	return p
+}
+
+// reset clears the tracker before a review pass.
+func (t *reviewTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phase = ""
+	t.agents = nil
+}
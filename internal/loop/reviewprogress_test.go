@@ -0,0 +1,35 @@
+package loop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/review"
+)
+
+func TestReviewTracker(t *testing.T) {
+	var tr reviewTracker
+	require.Equal(t, "", tr.snapshot().Phase)
+
+	tr.record(review.Progress{Phase: review.PhaseAgents})
+	tr.record(review.Progress{Phase: review.PhaseAgents, Agent: "bugs"})
+	tr.record(review.Progress{Phase: review.PhaseAgents, Agent: "style"})
+	tr.record(review.Progress{Phase: review.PhaseAgents, Agent: "bugs", Done: true})
+
+	snap := tr.snapshot()
+	require.Equal(t, review.PhaseAgents, snap.Phase)
+	require.Len(t, snap.Agents, 2)
+	require.Equal(t, "bugs", snap.Agents[0].Name)
+	require.True(t, snap.Agents[0].Done)
+	require.False(t, snap.Agents[1].Done)
+	require.Equal(t, []string{"style"}, snap.Running())
+
+	tr.record(review.Progress{Phase: review.PhaseValidation})
+	snap = tr.snapshot()
+	require.Equal(t, review.PhaseValidation, snap.Phase)
+	require.Empty(t, snap.Agents, "a new phase starts without agents")
+
+	tr.reset()
+	require.Equal(t, "", tr.snapshot().Phase)
+}
@@ -0,0 +1,94 @@
+package loop
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// SetDiffScanner enables the post-invocation diff scan. When the scanner
+// flags a change, the run stops as BLOCKED so a human can review it.
+func (l *Loop) SetDiffScanner(s *safety.Scanner) {
+	l.diffScanner = s
+}
+
+// diffScanHead returns the commit a diff scan after the next change compares
+// against, or "" when nothing is scanned.
+func (l *Loop) diffScanHead() string {
+	if l.diffScanner == nil || l.gitRepo == nil {
+		return ""
+	}
+	head, err := gitutil.HeadCommit(l.gitRepo.Root())
+	if err != nil {
+		return ""
+	}
+	return head
+}
+
+// diffScanFindings scans the changes since base (HEAD when empty): the
+// commits made since, uncommitted changes, and new files.
+func (l *Loop) diffScanFindings(base string) ([]safety.ScanFinding, error) {
+	diff, err := l.gitRepo.DiffFrom(cmp.Or(base, "HEAD"))
+	if err != nil {
+		return nil, err
+	}
+	return l.diffScanner.Scan(diff), nil
+}
+
+// initDiffScanBaseline records findings already present in the working tree
+// before the run starts, so pre-existing changes do not block it.
+func (l *Loop) initDiffScanBaseline(rc *runContext) {
+	if l.diffScanner == nil || l.gitRepo == nil {
+		return
+	}
+	findings, err := l.diffScanFindings("")
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: diff scan: %v", err))
+		return
+	}
+	rc.scanBaseline = make(map[string]bool, len(findings))
+	for _, f := range findings {
+		rc.scanBaseline[f.String()] = true
+	}
+}
+
+// scanDiffSince checks what changed since base, as returned by diffScanHead
+// before an invocation, parallel phases, or review patches changed the tree,
+// commits included. Returns true when it found dangerous changes and the run
+// must stop.
+func (l *Loop) scanDiffSince(rc *runContext, base string) bool {
+	if l.diffScanner == nil || l.gitRepo == nil {
+		return false
+	}
+	findings, err := l.diffScanFindings(base)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: diff scan: %v", err))
+		return false
+	}
+
+	var flagged []string
+	for _, f := range findings {
+		if !rc.scanBaseline[f.String()] {
+			flagged = append(flagged, f.String())
+		}
+	}
+	if len(flagged) == 0 {
+		return false
+	}
+
+	l.log(fmt.Sprintf("Diff scan flagged %d dangerous change(s):", len(flagged)))
+	for _, f := range flagged {
+		l.log("  " + f)
+	}
+	l.addNote(rc, notes.Final, fmt.Sprintf("error: [iter %d] BLOCKED by diff scan, needs human review: %s",
+		rc.state.Iteration, strings.Join(flagged, "; ")))
+
+	rc.result.ExitReason = safety.ExitReasonBlocked
+	rc.result.ExitMessage = "diff scan: " + strings.Join(flagged, "; ")
+	rc.result.Iterations = rc.state.Iteration
+	return true
+}
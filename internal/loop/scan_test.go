@@ -0,0 +1,94 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_DiffScanBlocksDangerousChange(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+	mainFile := filepath.Join(dir, "main.go")
+
+	invoker := newSequenceInvoker([]sequenceResponse{
+		{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, FilesChanged: []string{"main.go"},
+			Summary: "Feature done", FileEdits: map[string]string{mainFile: "package main\n\nfunc main() {\n\tpanic(\"todo\")\n}\n"}},
+	})
+
+	scanner, err := safety.NewScanner(safety.DefaultScanRules())
+	require.NoError(t, err)
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetDiffScanner(scanner)
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonBlocked, result.ExitReason)
+	assert.Contains(t, result.ExitMessage, `panic: main.go: panic("todo")`)
+	assert.Equal(t, 1, invoker.CallCount())
+}
+
+func TestLoopRun_DiffScanSeesExecutorCommits(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+	require.NoError(t, exec.Command("git", "-C", dir, "add", "-A").Run())
+	require.NoError(t, exec.Command("git", "-C", dir, "commit", "-qm", "plan").Run())
+
+	calls := 0
+	invoker := &fakeInvoker{fn: func(context.Context, string) (string, error) {
+		calls++
+		mainFile := filepath.Join(dir, "main.go")
+		if err := os.WriteFile(mainFile, []byte("package main\n\nfunc main() {\n\tpanic(\"todo\")\n}\n"), 0o644); err != nil {
+			return "", err
+		}
+		// The executor commits its change itself, leaving a clean tree.
+		if out, err := exec.Command("git", "-C", dir, "add", "main.go").CombinedOutput(); err != nil {
+			return "", fmt.Errorf("%s: %w", out, err)
+		}
+		if out, err := exec.Command("git", "-C", dir, "-c", "user.name=t", "-c", "user.email=t@t", "commit", "-qm", "feature").CombinedOutput(); err != nil {
+			return "", fmt.Errorf("%s: %w", out, err)
+		}
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: "Task 1: Feature"
+  status: DONE
+  files_changed: ["main.go"]
+  summary: "Feature done"
+`, nil
+	}}
+
+	scanner, err := safety.NewScanner(safety.DefaultScanRules())
+	require.NoError(t, err)
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetDiffScanner(scanner)
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonBlocked, result.ExitReason)
+	assert.Contains(t, result.ExitMessage, `panic: main.go: panic("todo")`)
+	assert.Equal(t, 1, calls)
+}
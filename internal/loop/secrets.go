@@ -0,0 +1,72 @@
+package loop
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+)
+
+// redactedSecret replaces secret values in events, notes, and iteration
+// records.
+const redactedSecret = "[redacted]"
+
+// SetSecrets sets values, such as secret executor_env values, masked in
+// everything the run emits: events, review events, work item notes, and
+// iteration records.
+func (l *Loop) SetSecrets(secrets []string) {
+	l.redact = newRedactor(secrets)
+}
+
+// newRedactor returns a function replacing the secrets in a text with
+// redactedSecret, longer secrets first so one containing another is masked
+// whole.
+func newRedactor(secrets []string) func(string) string {
+	secrets = slices.DeleteFunc(slices.Clone(secrets), func(s string) bool { return s == "" })
+	if len(secrets) == 0 {
+		return func(s string) string { return s }
+	}
+	slices.SortStableFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, redactedSecret)
+	}
+	return strings.NewReplacer(pairs...).Replace
+}
+
+// redactText masks the secrets set with SetSecrets in s.
+func (l *Loop) redactText(s string) string {
+	if l.redact == nil {
+		return s
+	}
+	return l.redact(s)
+}
+
+// redactEvent masks the secrets in the text of an event.
+func (l *Loop) redactEvent(e event.Event) event.Event {
+	if l.redact == nil {
+		return e
+	}
+	e.Text = l.redact(e.Text)
+	e.Summary = l.redact(e.Summary)
+	if e.Issue != nil {
+		issue := *e.Issue
+		issue.Description = l.redact(issue.Description)
+		e.Issue = &issue
+	}
+	return e
+}
+
+// redactRecord masks the secrets in the text of an iteration record.
+func (l *Loop) redactRecord(rec IterationRecord) IterationRecord {
+	if l.redact == nil {
+		return rec
+	}
+	rec.Prompt = l.redact(rec.Prompt)
+	rec.Tools = slices.Clone(rec.Tools)
+	for i, tool := range rec.Tools {
+		rec.Tools[i] = l.redact(tool)
+	}
+	rec.Error = l.redact(rec.Error)
+	return rec
+}
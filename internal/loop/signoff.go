@@ -0,0 +1,105 @@
+package loop
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+)
+
+// SignOffApprover asks the user whether to commit a phase's changes to
+// high-risk paths (see GitWorkflowConfig.SignOffPaths). Files it does not
+// approve are left for a human to commit.
+type SignOffApprover func(phase string, files []string) (bool, error)
+
+// SetSignOffApprover makes auto-commits touching high-risk paths ask for
+// approval. Without one, those files are never committed by the loop.
+func (l *Loop) SetSignOffApprover(a SignOffApprover) {
+	l.signOffApprover = a
+}
+
+// compileSignOffPaths compiles GitWorkflowConfig.SignOffPaths.
+func compileSignOffPaths(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("git sign-off path: %w", err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// needsSignOff returns the repo-relative paths of files that match a
+// sign-off path.
+func (l *Loop) needsSignOff(rc *runContext, files []string) []string {
+	if len(rc.signOff) == 0 || l.gitRepo == nil {
+		return nil
+	}
+	var risky []string
+	for _, f := range files {
+		p, ok := l.gitRepo.RepoPath(f)
+		if !ok {
+			continue
+		}
+		if slices.ContainsFunc(rc.signOff, func(re *regexp.Regexp) bool { return re.MatchString(p) }) {
+			risky = append(risky, p)
+		}
+	}
+	return risky
+}
+
+// holdForSignOff returns filesChanged without the files under sign-off
+// paths, unless the approver approves committing them. Held files are
+// recorded in the result and staged when the run ends.
+func (l *Loop) holdForSignOff(rc *runContext, phaseName string, filesChanged []string) []string {
+	risky := l.needsSignOff(rc, filesChanged)
+	if len(risky) == 0 {
+		return filesChanged
+	}
+	if l.signOffApprover != nil {
+		approved, err := l.signOffApprover(phaseName, risky)
+		if err != nil {
+			l.log(fmt.Sprintf("Warning: failed to read sign-off: %v", err))
+		}
+		if approved {
+			l.log(fmt.Sprintf("Sign-off given for: %s", strings.Join(risky, ", ")))
+			return filesChanged
+		}
+	}
+
+	kept := make([]string, 0, len(filesChanged))
+	for _, f := range filesChanged {
+		if p, ok := l.gitRepo.RepoPath(f); !ok || !slices.Contains(risky, p) {
+			kept = append(kept, f)
+		}
+	}
+	for _, p := range risky {
+		if !slices.Contains(rc.result.SignOffFiles, p) {
+			rc.result.SignOffFiles = append(rc.result.SignOffFiles, p)
+		}
+	}
+	slices.Sort(rc.result.SignOffFiles)
+
+	files := strings.Join(risky, ", ")
+	l.log(fmt.Sprintf("Not committing files that need sign-off: %s", files))
+	l.addNote(rc, notes.Phase, fmt.Sprintf("warning: [iter %d] %s: files needing sign-off left for a human to commit: %s",
+		rc.state.Iteration, phaseName, files))
+	return kept
+}
+
+// stageSignOffFiles stages the files held for sign-off when the run ends,
+// so they wait in the index for a human commit.
+func (l *Loop) stageSignOffFiles(rc *runContext) {
+	if len(rc.result.SignOffFiles) == 0 || l.gitRepo == nil {
+		return
+	}
+	if err := l.gitRepo.Add(rc.result.SignOffFiles...); err != nil {
+		l.log(fmt.Sprintf("Warning: failed to stage files needing sign-off: %v", err))
+		return
+	}
+	l.log(fmt.Sprintf("Staged for sign-off, not committed: %s", strings.Join(rc.result.SignOffFiles, ", ")))
+}
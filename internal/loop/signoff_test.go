@@ -0,0 +1,97 @@
+package loop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_SignOffPaths(t *testing.T) {
+	tests := []struct {
+		name       string
+		approver   bool
+		approve    bool
+		wantHeld   []string
+		wantStaged string
+	}{
+		{name: "no approver holds risky files", wantHeld: []string{"migrations/001_users.sql"}, wantStaged: "migrations/001_users.sql"},
+		{name: "declined", approver: true, wantHeld: []string{"migrations/001_users.sql"}, wantStaged: "migrations/001_users.sql"},
+		{name: "approved commits everything", approver: true, approve: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			planPath, workingFile := writePlanFile(t, dir, planConfig{
+				Tasks:       []string{"Task 1: Users table"},
+				CommitFiles: true,
+			})
+			migration := filepath.Join(dir, "migrations", "001_users.sql")
+			require.NoError(t, os.MkdirAll(filepath.Dir(migration), 0o755))
+
+			invoker := newSequenceInvoker([]sequenceResponse{
+				{PhaseCompleted: "Task 1: Users table", Status: protocol.StatusDone,
+					FilesChanged: []string{"working.txt", "migrations/001_users.sql"}, Summary: "Added users",
+					FileEdits: map[string]string{workingFile: "users\n", migration: "CREATE TABLE users ();\n"}},
+			})
+
+			l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+			l.SetInvoker(invoker)
+			l.SetSource(source.NewPlanSource(planPath))
+			l.SetReviewRunner(createNoIssueReviewRunner(t))
+			l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+			l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true, SignOffPaths: []string{`^migrations/`}})
+			var asked []string
+			if tc.approver {
+				l.SetSignOffApprover(func(phase string, files []string) (bool, error) {
+					asked = files
+					return tc.approve, nil
+				})
+			}
+
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+			assert.Equal(t, []string{"Task 1: Users table"}, getCommitMessages(t, dir))
+			assert.Equal(t, tc.wantHeld, result.SignOffFiles)
+			assert.Equal(t, tc.wantStaged, gitOutput(t, dir, "diff", "--cached", "--name-only"))
+			committed := gitOutput(t, dir, "show", "--name-only", "--format=", "HEAD")
+			assert.Contains(t, committed, "working.txt")
+			if tc.approver {
+				assert.Equal(t, []string{"migrations/001_users.sql"}, asked)
+			}
+			if tc.wantHeld != nil {
+				assert.NotContains(t, committed, "migrations/")
+			} else {
+				assert.Contains(t, committed, "migrations/001_users.sql")
+			}
+		})
+	}
+}
+
+func TestLoopRun_SignOffPathsInvalid(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}})
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(newSequenceInvoker(nil))
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewConfig(review.Config{Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true, SignOffPaths: []string{`migrations/(`}})
+
+	result, err := l.Run(planPath)
+	require.ErrorContains(t, err, "git sign-off path")
+	assert.Equal(t, safety.ExitReasonError, result.ExitReason)
+}
@@ -0,0 +1,69 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+)
+
+// SkipPhase asks the loop to give up on the current phase: the running
+// invocation is canceled, the phase is left unchecked with a deferred note,
+// and the run continues with the next phase as if it were done. Safe to call
+// from any goroutine.
+func (l *Loop) SkipPhase() {
+	l.skipRequested.Store(true)
+
+	l.invokeMu.Lock()
+	defer l.invokeMu.Unlock()
+	if l.invokeCancel != nil {
+		l.invokeCancel()
+	}
+}
+
+// setInvokeCancel records the cancel func of the in-flight invocation.
+func (l *Loop) setInvokeCancel(cancel context.CancelFunc) {
+	l.invokeMu.Lock()
+	defer l.invokeMu.Unlock()
+	l.invokeCancel = cancel
+}
+
+// handleSkipRequest applies a pending SkipPhase request. Returns true when
+// the current phase was skipped.
+func (l *Loop) handleSkipRequest(rc *runContext) bool {
+	if !l.skipRequested.Swap(false) {
+		return false
+	}
+
+	phase := rc.workItem.CurrentPhase()
+	if phase == nil || rc.state.InReviewPhase || l.engine.PendingReviewFix {
+		l.log("Skip ignored: no task phase in progress")
+		return false
+	}
+
+	rc.skippedPhases = append(rc.skippedPhases, phase.Name)
+	l.applySkippedPhases(rc)
+
+	l.log(fmt.Sprintf("Skipped phase: %s", phase.Name))
+	l.addNote(rc, notes.Phase, fmt.Sprintf("warning: [iter %d] Deferred %s: skipped by user, needs follow-up",
+		rc.state.Iteration, phase.Name))
+
+	rc.supervisorGuidance = ""
+	rc.userAnswers = nil
+	rc.state.ConsecutiveNoChanges = 0
+	rc.state.ConsecutiveErrors = 0
+	l.sessionID = ""
+	return true
+}
+
+// applySkippedPhases treats the phases skipped during the run as done in
+// rc.workItem, as read from the source, so the run moves past them. The
+// source keeps them unchecked for a later run.
+func (l *Loop) applySkippedPhases(rc *runContext) {
+	for i, phase := range rc.workItem.Phases {
+		if !phase.Completed && slices.Contains(rc.skippedPhases, phase.Name) {
+			rc.workItem.CompletePhase(i)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package loop
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_SkipPhaseCancelsInvocation(t *testing.T) {
+	item := &domain.WorkItem{
+		ID:     "test-skip",
+		Title:  "Skip",
+		Phases: []domain.Phase{{Name: "Optional task"}, {Name: "Required task"}},
+	}
+	mock := source.NewMockSource()
+	mock.GetFunc = func(_ string) (*domain.WorkItem, error) {
+		c := *item
+		c.Phases = slices.Clone(item.Phases)
+		return &c, nil
+	}
+	mock.UpdatePhaseFunc = func(_, phaseName string) error {
+		for i := range item.Phases {
+			if item.Phases[i].Name == phaseName {
+				item.Phases[i].Completed = true
+			}
+		}
+		return nil
+	}
+	var notes []string
+	mock.AddNoteFunc = func(_, note string) error {
+		notes = append(notes, note)
+		return nil
+	}
+
+	config := safety.Config{MaxIterations: 5, StagnationLimit: 3, Timeout: 60, MaxReviewIterations: 3}
+	l := NewWithSource(config, "", nil, false, mock)
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+
+	var prompts []string
+	l.SetInvoker(&fakeInvoker{fn: func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if len(prompts) == 1 {
+			// Stuck on the optional task until the user skips it.
+			l.SkipPhase()
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return `PROGRAMMATOR_STATUS:
+  phase_completed: "Required task"
+  status: DONE
+  files_changed: ["a.go"]
+  summary: "Done"
+`, nil
+	}})
+
+	result, err := l.Run("test-skip")
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	require.Len(t, prompts, 2)
+	require.Contains(t, prompts[1], "**Required task**")
+	require.False(t, item.Phases[0].Completed, "left unchecked for a later run")
+	require.True(t, item.Phases[1].Completed)
+	require.Contains(t, strings.Join(notes, "\n"), "Deferred Optional task: skipped by user")
+	require.Contains(t, strings.Join(notes, "\n"), "except the skipped Optional task")
+	for _, call := range mock.SetStatusCalls {
+		require.NotEqual(t, protocol.WorkItemClosed, call.Status, "not closed with a phase open")
+	}
+}
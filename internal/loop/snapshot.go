@@ -0,0 +1,58 @@
+package loop
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// snapshotBranchName is the scratch branch for snapshots of a run on
+// sourceID: the run branch name with "snapshots/" after the prefix.
+func (l *Loop) snapshotBranchName(sourceID string, isPlan bool) string {
+	prefix := cmp.Or(l.gitConfig.BranchPrefix, "programmator/")
+	return prefix + "snapshots/" + strings.TrimPrefix(l.runBranchName(sourceID, isPlan), prefix)
+}
+
+// snapshotIteration commits the worktree to the snapshot branch after every
+// iteration, whether or not a phase completed, so each step of the executor
+// can be recovered. HEAD, the index and the files are left alone; phase
+// commits are made on the run branch as usual. A failed snapshot is a
+// warning.
+func (l *Loop) snapshotIteration(rc *runContext, summary string) {
+	if l.snapshotBranch == "" || l.gitRepo == nil {
+		return
+	}
+
+	message := fmt.Sprintf("iter %d", rc.state.Iteration)
+	if line, _, _ := strings.Cut(strings.TrimSpace(summary), "\n"); line != "" {
+		message += ": " + line
+	}
+	sha, err := l.gitRepo.Snapshot(l.snapshotBranch, message)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: snapshot failed: %v", err))
+		return
+	}
+	if sha == "" {
+		return // nothing changed since the last snapshot
+	}
+	rc.snapshots++
+	l.log(fmt.Sprintf("Snapshot: %s (%s)", l.snapshotBranch, sha[:min(len(sha), 12)]))
+}
+
+// finishSnapshots deletes the snapshot branch of a completed run when
+// SquashSnapshots is set: the phase commits then hold the same changes. Runs
+// that end any other way keep it for recovery.
+func (l *Loop) finishSnapshots(rc *runContext) {
+	if rc.snapshots == 0 {
+		return
+	}
+	if !l.gitConfig.SquashSnapshots || rc.result.ExitReason != safety.ExitReasonComplete {
+		l.log(fmt.Sprintf("%d iteration snapshot(s) on branch %s", rc.snapshots, l.snapshotBranch))
+		return
+	}
+	if err := l.gitRepo.DeleteBranch(l.snapshotBranch); err != nil {
+		l.log(fmt.Sprintf("Warning: could not delete snapshot branch: %v", err))
+	}
+}
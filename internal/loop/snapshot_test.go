@@ -0,0 +1,67 @@
+package loop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_Snapshots(t *testing.T) {
+	tests := []struct {
+		name       string
+		squash     bool
+		wantBranch bool
+	}{
+		{name: "keeps a commit per iteration", wantBranch: true},
+		{name: "squash deletes the branch of a completed run", squash: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			planPath, workingFile := writePlanFile(t, dir, planConfig{
+				Tasks:       []string{"Task 1: Feature"},
+				CommitFiles: true,
+			})
+
+			invoker := newSequenceInvoker([]sequenceResponse{
+				{Status: protocol.StatusContinue, FilesChanged: []string{"working.txt"},
+					Summary: "Started the feature", FileEdits: map[string]string{workingFile: "draft\n"}},
+				{Status: protocol.StatusContinue, Summary: "Read the docs"},
+				{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+					Summary: "Finished the feature", FileEdits: map[string]string{workingFile: "feature\n"}},
+			})
+
+			l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+			l.SetInvoker(invoker)
+			l.SetSource(source.NewPlanSource(planPath))
+			l.SetReviewRunner(createNoIssueReviewRunner(t))
+			l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+			l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true, Snapshots: true, SquashSnapshots: tc.squash})
+
+			head := gitOutput(t, dir, "rev-parse", "HEAD")
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+			branches := gitOutput(t, dir, "for-each-ref", "--format=%(refname:short)", "refs/heads/programmator/snapshots/")
+			if !tc.wantBranch {
+				assert.Empty(t, branches)
+				return
+			}
+			require.NotEmpty(t, branches)
+			log := gitOutput(t, dir, "log", "--format=%s", head+".."+branches)
+			assert.Equal(t, "iter 3: Finished the feature\niter 1: Started the feature", log,
+				"iterations without changes add no snapshot")
+			assert.Equal(t, "feature", gitOutput(t, dir, "show", branches+":working.txt"))
+		})
+	}
+}
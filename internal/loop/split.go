@@ -0,0 +1,189 @@
+package loop
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// maxSplitSubtasks caps how many subtasks one split may add to the plan.
+const maxSplitSubtasks = 8
+
+// phaseSplitRegex matches PHASE_SPLIT: blocks in executor output.
+var phaseSplitRegex = regexp.MustCompile(`(?s)` + protocol.PhaseSplitBlockKey + `:\s*\n(.*?)(?:\n\s*\x60{3}|$)`)
+
+// SetPhaseSplitting enables automatic phase decomposition: a phase still
+// open after afterAttempts iterations, or whose description is longer than
+// maxLength characters, is split into subtasks nested under it in the plan.
+// Zero disables the respective trigger. Only sources implementing
+// source.Splitter (plan files) are split.
+func (l *Loop) SetPhaseSplitting(afterAttempts, maxLength int) {
+	l.splitAfterAttempts = afterAttempts
+	l.splitMaxLength = maxLength
+}
+
+// splitReason returns why phase should be split before the next attempt,
+// or "" when it should not.
+func (l *Loop) splitReason(rc *runContext, phase *domain.Phase) string {
+	if phase == nil || rc.state.InReviewPhase || l.engine.PendingReviewFix || rc.splitPhases[phase.Name] {
+		return ""
+	}
+	if _, ok := rc.source.(source.Splitter); !ok {
+		return ""
+	}
+	if n := rc.phaseAttempts[phase.Name]; l.splitAfterAttempts > 0 && n >= l.splitAfterAttempts {
+		return fmt.Sprintf("still open after %d attempts", n)
+	}
+	if n := utf8.RuneCountInString(phase.Name); l.splitMaxLength > 0 && n > l.splitMaxLength {
+		return fmt.Sprintf("description is %d characters long", n)
+	}
+	return ""
+}
+
+// splitPhase asks the executor to decompose the current phase and writes
+// the subtasks into the plan under it. Returns true when the plan changed
+// and the loop should continue with the first subtask.
+func (l *Loop) splitPhase(rc *runContext, phase *domain.Phase, reason string) bool {
+	splitter := rc.source.(source.Splitter)
+	// Whatever happens, try each phase once per run.
+	rc.splitPhases[phase.Name] = true
+
+	l.log(fmt.Sprintf("Splitting phase %s: %s", phase.Name, reason))
+	subtasks, err := l.invokeSplit(rc, phase, reason)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: phase split failed: %v", err))
+		return false
+	}
+	if err := splitter.SplitPhase(rc.workItemID, phase.Name, subtasks); err != nil {
+		l.log(fmt.Sprintf("Warning: failed to write subtasks of '%s': %v", phase.Name, err))
+		return false
+	}
+
+	for _, st := range subtasks {
+		rc.splitPhases[st] = true // no splitting of subtasks
+	}
+	l.addNote(rc, notes.Phase, fmt.Sprintf("progress: [iter %d] Split %s into %d subtasks (%s)",
+		rc.state.Iteration, phase.Name, len(subtasks), reason))
+
+	rc.supervisorGuidance = ""
+	rc.userAnswers = nil
+	rc.state.ConsecutiveNoChanges = 0
+	rc.state.ConsecutiveErrors = 0
+	l.sessionID = ""
+	return true
+}
+
+// invokeSplit runs the split prompt, in an invocation that may not change
+// files, and parses the subtasks.
+func (l *Loop) invokeSplit(rc *runContext, phase *domain.Phase, reason string) ([]string, error) {
+	inv, err := l.resolveInvoker()
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := l.executorConfig.ReadOnlyFlags(llm.PurposePlanning)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := inv.Invoke(rc.ctx, buildSplitPrompt(rc, phase, reason), llm.InvokeOptions{
+		WorkingDir: l.workingDir,
+		ExtraFlags: flags,
+		Purpose:    llm.PurposePlanning,
+		Timeout:    l.config.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invoke: %w", err)
+	}
+	return parsePhaseSplit(out.Text)
+}
+
+// parsePhaseSplit extracts the subtasks of a PHASE_SPLIT block from output.
+func parsePhaseSplit(output string) ([]string, error) {
+	subtasks, err := parseTaskBlock(output, phaseSplitRegex, protocol.PhaseSplitBlockKey, "subtasks")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(subtasks) < 2:
+		return nil, fmt.Errorf("phase split needs at least 2 subtasks, got %d", len(subtasks))
+	case len(subtasks) > maxSplitSubtasks:
+		return nil, fmt.Errorf("phase split has %d subtasks, at most %d allowed", len(subtasks), maxSplitSubtasks)
+	}
+	return subtasks, nil
+}
+
+// parseTaskBlock extracts the list under field of the key block matched by
+// re in output, with whitespace in each entry collapsed and empty entries
+// dropped.
+func parseTaskBlock(output string, re *regexp.Regexp, key, field string) ([]string, error) {
+	match := re.FindStringSubmatch(output)
+	if match == nil {
+		return nil, fmt.Errorf("no %s found in output", key)
+	}
+
+	yamlContent := key + ":\n" + match[1]
+	yamlContent = strings.TrimRight(yamlContent, "`\n ")
+
+	var wrapper map[string]map[string][]string
+	if err := yaml.Unmarshal([]byte(yamlContent), &wrapper); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", key, err)
+	}
+
+	var tasks []string
+	for _, t := range wrapper[key][field] {
+		if t = strings.Join(strings.Fields(t), " "); t != "" {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+// buildSplitPrompt asks the executor to break phase into subtasks.
+func buildSplitPrompt(rc *runContext, phase *domain.Phase, reason string) string {
+	var b strings.Builder
+
+	b.WriteString("You are planning work for an autonomous coding agent. Do not modify any files. ")
+	b.WriteString("Split the task below into smaller subtasks that can each be completed in a single iteration.\n\n")
+
+	fmt.Fprintf(&b, "## Task to split\n\n%s\n\nWhy: %s\n\n", phase.Name, reason)
+
+	b.WriteString("## Recent iterations\n\n")
+	summaries := rc.iterationSummaries
+	if len(summaries) > 5 {
+		summaries = summaries[len(summaries)-5:]
+	}
+	if len(summaries) == 0 {
+		b.WriteString("(none)\n")
+	}
+	for _, s := range summaries {
+		fmt.Fprintf(&b, "- %s\n", s)
+	}
+
+	fmt.Fprintf(&b, "\n## Plan\n\n%s\n\n", rc.workItem.RawContent)
+
+	fmt.Fprintf(&b, `## Subtasks
+
+List 2 to %d subtasks in the order they should be done. Each is one line that
+names a concrete, verifiable change. Together they must cover the whole task;
+leave out work that is already done.
+
+Respond with exactly one block:
+`+"```yaml"+`
+`+protocol.PhaseSplitBlockKey+`:
+  subtasks:
+    - 'First subtask'
+    - 'Second subtask'
+`+"```", maxSplitSubtasks)
+
+	return b.String()
+}
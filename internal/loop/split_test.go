@@ -0,0 +1,176 @@
+package loop
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/plan"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestParsePhaseSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "fenced block",
+			output: "Plan:\n```yaml\nPHASE_SPLIT:\n  subtasks:\n    - 'Add the type'\n    - 'Wire it   into\n      the CLI'\n```",
+			want:   []string{"Add the type", "Wire it into the CLI"},
+		},
+		{
+			name:   "blank subtasks are dropped",
+			output: "PHASE_SPLIT:\n  subtasks: ['One', '', 'Two']\n",
+			want:   []string{"One", "Two"},
+		},
+		{
+			name:    "single subtask",
+			output:  "PHASE_SPLIT:\n  subtasks: ['Everything']\n",
+			wantErr: "at least 2 subtasks",
+		},
+		{
+			name:    "too many subtasks",
+			output:  "PHASE_SPLIT:\n  subtasks: [a, b, c, d, e, f, g, h, i]\n",
+			wantErr: "at most 8",
+		},
+		{
+			name:    "missing block",
+			output:  "nothing to see",
+			wantErr: "no PHASE_SPLIT found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parsePhaseSplit(tc.output)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+// splittingInvoker answers split prompts with a fixed block and delegates
+// everything else to a sequence invoker.
+type splittingInvoker struct {
+	*sequenceInvoker
+	split        string
+	splitPrompts []string
+	splitFlags   [][]string
+}
+
+func (s *splittingInvoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
+	if strings.HasPrefix(prompt, "You are planning work") {
+		s.splitPrompts = append(s.splitPrompts, prompt)
+		s.splitFlags = append(s.splitFlags, opts.ExtraFlags)
+		return &llm.InvokeResult{Text: s.split}, nil
+	}
+	return s.sequenceInvoker.Invoke(ctx, prompt, opts)
+}
+
+func newSplittingLoop(t *testing.T, dir, planPath string, inv llm.Invoker, afterAttempts, maxLength int) *Loop {
+	t.Helper()
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 5, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(inv)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetPhaseSplitting(afterAttempts, maxLength)
+	return l
+}
+
+func TestLoopRun_SplitsPhaseAfterFailedAttempts(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, workingFile := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature", "Task 2: Docs"}})
+
+	inv := &splittingInvoker{
+		sequenceInvoker: newSequenceInvoker([]sequenceResponse{
+			{Status: protocol.StatusContinue, Summary: "Started"},
+			{Status: protocol.StatusContinue, Summary: "Still going"},
+			{PhaseCompleted: "Add the type", Status: protocol.StatusContinue, FilesChanged: []string{"working.txt"},
+				Summary: "Type added", FileEdits: map[string]string{workingFile: "type\n"}},
+			{PhaseCompleted: "Wire it", Status: protocol.StatusContinue, FilesChanged: []string{"working.txt"},
+				Summary: "Wired", FileEdits: map[string]string{workingFile: "wired\n"}},
+			{PhaseCompleted: "Task 2: Docs", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+				Summary: "Docs", FileEdits: map[string]string{workingFile: "docs\n"}},
+		}),
+		split: "PHASE_SPLIT:\n  subtasks: ['Add the type', 'Wire it']\n",
+	}
+
+	l := newSplittingLoop(t, dir, planPath, inv, 2, 0)
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	require.Len(t, inv.splitPrompts, 1)
+	assert.Contains(t, inv.splitPrompts[0], "Task 1: Feature")
+	assert.Contains(t, inv.splitPrompts[0], "still open after 2 attempts")
+	assert.Contains(t, inv.splitPrompts[0], "[iter 2] Still going")
+	assert.Contains(t, inv.splitFlags[0], "--settings", "claude splits read-only")
+
+	require.Len(t, inv.calls, 5)
+	assert.Contains(t, inv.calls[2].Prompt, "Add the type")
+	assert.Equal(t, 5, result.Iterations)
+
+	updated, err := plan.ParseFile(planPath)
+	require.NoError(t, err)
+	require.Len(t, updated.Tasks, 4)
+	assert.Equal(t, "Add the type", updated.Tasks[1].Name)
+	assert.Equal(t, 1, updated.Tasks[1].Depth)
+	assert.True(t, updated.AllTasksComplete())
+}
+
+func TestLoopRun_SplitsLongPhaseOnce(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	long := "Task 1: " + strings.Repeat("do a lot of things ", 10)
+	planPath, workingFile := writePlanFile(t, dir, planConfig{Tasks: []string{long}})
+
+	inv := &splittingInvoker{
+		sequenceInvoker: newSequenceInvoker([]sequenceResponse{
+			{PhaseCompleted: strings.TrimSpace(long), Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+				Summary: "Done", FileEdits: map[string]string{workingFile: "done\n"}},
+		}),
+		split: "no block, sorry",
+	}
+
+	l := newSplittingLoop(t, dir, planPath, inv, 0, 100)
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	require.Len(t, inv.splitPrompts, 1, "a failed split is not retried")
+	assert.Contains(t, inv.splitPrompts[0], "description is 197 characters long")
+	require.Len(t, inv.calls, 1)
+}
+
+func TestLoopRun_NoSplitWithoutSplitterSource(t *testing.T) {
+	l := New(safety.Config{}, t.TempDir(), nil, false)
+	l.SetPhaseSplitting(1, 0)
+
+	rc := &runContext{
+		source:        source.NewMockSource(),
+		state:         safety.NewState(),
+		phaseAttempts: map[string]int{"Task": 3},
+		splitPhases:   map[string]bool{},
+	}
+	assert.Empty(t, l.splitReason(rc, &domain.Phase{Name: "Task"}))
+}
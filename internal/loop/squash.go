@@ -0,0 +1,125 @@
+package loop
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// Commit squash modes for GitWorkflowConfig.Squash.
+const (
+	SquashOff   = "off"
+	SquashPhase = "phase"
+	SquashRun   = "run"
+)
+
+// recordSquashBase remembers HEAD before the first iteration, so
+// squashCommits knows which commits the run made.
+func (l *Loop) recordSquashBase(rc *runContext) {
+	if l.gitRepo == nil || !l.gitConfig.AutoCommit || l.gitConfig.Squash == "" || l.gitConfig.Squash == SquashOff {
+		return
+	}
+	head, err := gitutil.HeadCommit(l.gitRepo.WorkDir())
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: commits will not be squashed: %v", err))
+		return
+	}
+	rc.squashBase = head
+}
+
+// squashCommits rewrites the commits of a completed run as one commit per
+// phase, or one for the whole run, each listing the commits it replaces.
+// Runs that end any other way, and runs that already pushed, keep their
+// commits as they are.
+func (l *Loop) squashCommits(rc *runContext) {
+	if rc.squashBase == "" || rc.result.ExitReason != safety.ExitReasonComplete {
+		return
+	}
+	if rc.pushed {
+		l.log("Not squashing commits: some were already pushed")
+		return
+	}
+	commits, err := l.gitRepo.CommitsSince(rc.squashBase)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: commits not squashed: %v", err))
+		return
+	}
+
+	var groups []gitutil.SquashGroup
+	if l.gitConfig.Squash == SquashRun {
+		groups = squashRun(rc.workItem.Title, commits)
+	} else {
+		groups = squashPhases(rc.workItem.Phases, commits)
+	}
+	if len(groups) == len(commits) {
+		return
+	}
+	if _, err := l.gitRepo.Squash(rc.squashBase, groups); err != nil {
+		l.log(fmt.Sprintf("Warning: commits not squashed: %v", err))
+		return
+	}
+	l.log(fmt.Sprintf("Squashed %d commit(s) into %d", len(commits), len(groups)))
+	if rc.unpushed > 0 {
+		rc.unpushed = len(groups)
+	}
+}
+
+// squashPhases groups commits by phase: a commit named after a phase starts
+// a group, unless the group before is the same phase's; other commits
+// (review patches, documentation, the plan move) join the group before
+// them. Each group keeps its first commit's message, followed by a list of
+// the others.
+func squashPhases(phases []domain.Phase, commits []gitutil.Commit) []gitutil.SquashGroup {
+	isPhase := make(map[string]bool, len(phases))
+	for _, p := range phases {
+		isPhase[p.Name] = true
+	}
+
+	var groups [][]gitutil.Commit
+	for _, c := range commits {
+		n := len(groups)
+		if n > 0 && (!isPhase[c.Subject] || groups[n-1][0].Subject == c.Subject) {
+			groups[n-1] = append(groups[n-1], c)
+			continue
+		}
+		groups = append(groups, []gitutil.Commit{c})
+	}
+
+	res := make([]gitutil.SquashGroup, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, squashGroup(g[0].Subject, g[1:], g))
+	}
+	return res
+}
+
+// squashRun groups all commits into one titled after the work item.
+func squashRun(title string, commits []gitutil.Commit) []gitutil.SquashGroup {
+	if len(commits) == 0 {
+		return nil
+	}
+	if title == "" {
+		title = commits[0].Subject
+	}
+	return []gitutil.SquashGroup{squashGroup(title, commits, commits)}
+}
+
+// squashGroup composes the squashed commit of group: title, then a list of
+// the listed commits' subjects, repeats left out.
+func squashGroup(title string, listed, group []gitutil.Commit) gitutil.SquashGroup {
+	seen := map[string]bool{title: true}
+	var subjects []string
+	for _, c := range listed {
+		if !seen[c.Subject] {
+			seen[c.Subject] = true
+			subjects = append(subjects, c.Subject)
+		}
+	}
+	message := title
+	if len(subjects) > 0 {
+		message += "\n\n- " + strings.Join(subjects, "\n- ")
+	}
+	return gitutil.SquashGroup{Last: group[len(group)-1].SHA, Message: message}
+}
@@ -0,0 +1,94 @@
+package loop
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestLoopRun_SquashCommits(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		wantCommits []string
+	}{
+		{
+			name:        "off",
+			mode:        SquashOff,
+			wantCommits: []string{docsCommitMessage, "Task 2: Tests", "Task 1: Feature"},
+		},
+		{
+			name:        "per phase",
+			mode:        SquashPhase,
+			wantCommits: []string{"Task 2: Tests\n\n- " + docsCommitMessage, "Task 1: Feature"},
+		},
+		{
+			name:        "per run",
+			mode:        SquashRun,
+			wantCommits: []string{"Integration Test\n\n- Task 1: Feature\n- Task 2: Tests\n- " + docsCommitMessage},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			planPath, workingFile := writePlanFile(t, dir, planConfig{
+				Tasks:       []string{"Task 1: Feature", "Task 2: Tests"},
+				CommitFiles: true,
+			})
+			readme := filepath.Join(dir, "README.md")
+
+			invoker := newSequenceInvoker([]sequenceResponse{
+				{PhaseCompleted: "Task 1: Feature", Status: protocol.StatusContinue, FilesChanged: []string{"working.txt"},
+					Summary: "Feature done", FileEdits: map[string]string{workingFile: "feature\n"}},
+				{PhaseCompleted: "Task 2: Tests", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+					Summary: "Tests done", FileEdits: map[string]string{workingFile: "feature\ntests\n"}},
+				{Status: protocol.StatusDone, FilesChanged: []string{"README.md"},
+					Summary: "Documented feature", FileEdits: map[string]string{readme: "# Feature\n"}},
+			})
+
+			l := New(safety.Config{MaxIterations: 10, StagnationLimit: 3, Timeout: 60}, dir, nil, false)
+			l.SetInvoker(invoker)
+			l.SetSource(source.NewPlanSource(planPath))
+			l.SetReviewRunner(createNoIssueReviewRunner(t))
+			l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+			l.SetGitWorkflowConfig(GitWorkflowConfig{AutoCommit: true, Squash: tc.mode})
+			l.SetDocsPhase(true)
+
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+			assert.Equal(t, tc.wantCommits, getCommitMessages(t, dir))
+			assert.Equal(t, "feature\ntests", gitOutput(t, dir, "show", "HEAD:working.txt"))
+			assert.Equal(t, "# Feature", gitOutput(t, dir, "show", "HEAD:README.md"))
+		})
+	}
+}
+
+func TestSquashPhases(t *testing.T) {
+	phases := []domain.Phase{{Name: "Task 1"}, {Name: "Task 2"}}
+	commits := []gitutil.Commit{
+		{SHA: "a", Subject: "chore: setup"},
+		{SHA: "b", Subject: "Task 1"},
+		{SHA: "c", Subject: "Apply review patches"},
+		{SHA: "d", Subject: "Task 1"},
+		{SHA: "e", Subject: "Task 2"},
+	}
+
+	assert.Equal(t, []gitutil.SquashGroup{
+		{Last: "a", Message: "chore: setup"},
+		{Last: "d", Message: "Task 1\n\n- Apply review patches"},
+		{Last: "e", Message: "Task 2"},
+	}, squashPhases(phases, commits))
+}
@@ -0,0 +1,236 @@
+package loop
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/notes"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+)
+
+// Supervisor actions.
+const (
+	supervisorRetry = "retry" // retry the phase with modified instructions
+	supervisorSkip  = "skip"  // leave the phase open and move on
+	supervisorSplit = "split" // retry the phase as a sequence of smaller steps
+	supervisorAbort = "abort" // stop the run
+)
+
+// supervisorDiffLimit caps how much of the working tree diff goes into the
+// supervisor prompt.
+const supervisorDiffLimit = 20000
+
+// supervisorDecisionRegex matches SUPERVISOR_DECISION: blocks in executor output.
+var supervisorDecisionRegex = regexp.MustCompile(`(?s)` + protocol.SupervisorDecisionBlockKey + `:\s*\n(.*?)(?:\n\s*\x60{3}|$)`)
+
+// supervisorDecision is the structured verdict returned by the supervisor.
+type supervisorDecision struct {
+	Action       string   `yaml:"action"`
+	Reason       string   `yaml:"reason"`
+	Instructions string   `yaml:"instructions"`
+	Steps        []string `yaml:"steps"`
+}
+
+// SetSupervisor enables the supervisor agent, which is consulted when the run
+// stagnates or the executor reports BLOCKED, at most maxInterventions times
+// per run. Zero disables it.
+func (l *Loop) SetSupervisor(maxInterventions int) {
+	l.supervisorMax = maxInterventions
+}
+
+// parseSupervisorDecision extracts a SUPERVISOR_DECISION block from output.
+func parseSupervisorDecision(output string) (*supervisorDecision, error) {
+	match := supervisorDecisionRegex.FindStringSubmatch(output)
+	if match == nil {
+		return nil, fmt.Errorf("no %s found in output", protocol.SupervisorDecisionBlockKey)
+	}
+
+	yamlContent := protocol.SupervisorDecisionBlockKey + ":\n" + match[1]
+	yamlContent = strings.TrimRight(yamlContent, "`\n ")
+
+	var wrapper struct {
+		Decision supervisorDecision `yaml:"SUPERVISOR_DECISION"`
+	}
+	if err := yaml.Unmarshal([]byte(yamlContent), &wrapper); err != nil {
+		return nil, fmt.Errorf("parse supervisor decision: %w", err)
+	}
+
+	d := &wrapper.Decision
+	d.Action = strings.ToLower(strings.TrimSpace(d.Action))
+	switch d.Action {
+	case supervisorRetry, supervisorSkip, supervisorAbort:
+	case supervisorSplit:
+		if len(d.Steps) == 0 {
+			return nil, fmt.Errorf("supervisor chose split without steps")
+		}
+	default:
+		return nil, fmt.Errorf("unknown supervisor action %q", d.Action)
+	}
+	return d, nil
+}
+
+// superviseStuckRun consults the supervisor before the loop exits on
+// stagnation or BLOCKED, and applies its decision. Returns true when the loop
+// should keep going instead of exiting.
+func (l *Loop) superviseStuckRun(rc *runContext, reason safety.ExitReason, detail string) bool {
+	if l.supervisorMax <= 0 || rc.supervisorInterventions >= l.supervisorMax {
+		return false
+	}
+	if reason != safety.ExitReasonStagnation && reason != safety.ExitReasonBlocked {
+		return false
+	}
+	phase := rc.workItem.CurrentPhase()
+	if phase == nil || rc.state.InReviewPhase || l.engine.PendingReviewFix {
+		return false
+	}
+	rc.supervisorInterventions++
+
+	l.log(fmt.Sprintf("Consulting supervisor (%d/%d): %s", rc.supervisorInterventions, l.supervisorMax, reason))
+	decision, err := l.invokeSupervisor(rc, reason, detail)
+	if err != nil {
+		l.log(fmt.Sprintf("Warning: supervisor failed: %v", err))
+		return false
+	}
+
+	l.log(fmt.Sprintf("Supervisor decided to %s: %s", decision.Action, decision.Reason))
+	l.addNote(rc, notes.Iteration, fmt.Sprintf("progress: [iter %d] Supervisor: %s %s — %s",
+		rc.state.Iteration, decision.Action, phase.Name, decision.Reason))
+
+	switch decision.Action {
+	case supervisorAbort:
+		return false
+	case supervisorSkip:
+		// Like a skip by the user: the phase stays open in the source and
+		// the work item is not closed.
+		rc.skippedPhases = append(rc.skippedPhases, phase.Name)
+		l.applySkippedPhases(rc)
+		rc.supervisorGuidance = ""
+	case supervisorSplit:
+		rc.supervisorGuidance = formatSplitGuidance(decision)
+	default:
+		rc.supervisorGuidance = decision.Instructions
+	}
+
+	rc.state.ConsecutiveNoChanges = 0
+	rc.state.ConsecutiveErrors = 0
+	l.sessionID = ""
+	return true
+}
+
+// invokeSupervisor runs the supervisor prompt, in an invocation that may not
+// change files, and parses its decision.
+func (l *Loop) invokeSupervisor(rc *runContext, reason safety.ExitReason, detail string) (*supervisorDecision, error) {
+	inv, err := l.resolveInvoker()
+	if err != nil {
+		return nil, err
+	}
+
+	diff := ""
+	if l.gitRepo != nil {
+		if d, err := l.gitRepo.Diff(); err == nil {
+			diff = d
+		}
+	}
+
+	flags, err := l.executorConfig.ReadOnlyFlags(llm.PurposePlanning)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := inv.Invoke(rc.ctx, buildSupervisorPrompt(rc, reason, detail, diff), llm.InvokeOptions{
+		WorkingDir: l.workingDir,
+		ExtraFlags: flags,
+		Purpose:    llm.PurposePlanning,
+		Timeout:    l.config.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invoke: %w", err)
+	}
+	return parseSupervisorDecision(out.Text)
+}
+
+// buildSupervisorPrompt describes the stuck run and asks for a decision.
+func buildSupervisorPrompt(rc *runContext, reason safety.ExitReason, detail, diff string) string {
+	var b strings.Builder
+
+	b.WriteString("You are supervising an autonomous coding agent that is stuck. Do not modify any files. ")
+	b.WriteString("Analyze the situation and decide how the run should proceed.\n\n")
+
+	fmt.Fprintf(&b, "## Problem\n\n%s", reason)
+	if detail != "" {
+		fmt.Fprintf(&b, ": %s", detail)
+	}
+	fmt.Fprintf(&b, "\n\n## Current phase\n\n%s\n\n", rc.workItem.CurrentPhase().Name)
+
+	b.WriteString("## Recent iterations\n\n")
+	summaries := rc.iterationSummaries
+	if len(summaries) > 5 {
+		summaries = summaries[len(summaries)-5:]
+	}
+	if len(summaries) == 0 {
+		b.WriteString("(none)\n")
+	}
+	for _, s := range summaries {
+		fmt.Fprintf(&b, "- %s\n", s)
+	}
+
+	b.WriteString("\n## Uncommitted diff\n\n")
+	switch {
+	case diff == "":
+		b.WriteString("(no changes)\n")
+	case len(diff) > supervisorDiffLimit:
+		fmt.Fprintf(&b, "```diff\n%s\n```\n(diff truncated)\n", diff[:supervisorDiffLimit])
+	default:
+		fmt.Fprintf(&b, "```diff\n%s\n```\n", diff)
+	}
+
+	fmt.Fprintf(&b, "\n## Plan\n\n%s\n\n", rc.workItem.RawContent)
+
+	b.WriteString(`## Decision
+
+Choose one action:
+- retry: the phase is achievable; give new, concrete instructions that avoid the previous failure.
+- skip: the phase is already done, unnecessary, or impossible and can safely be left behind.
+- split: the phase is too large; list smaller steps to complete in order.
+- abort: the run cannot make progress without a human.
+
+Respond with exactly one block:
+` + "```yaml" + `
+` + protocol.SupervisorDecisionBlockKey + `:
+  action: retry
+  reason: 'Why this action'
+  instructions: 'Instructions for the next attempt (retry only)'
+  steps: ['First step', 'Second step'] # split only
+` + "```")
+
+	return b.String()
+}
+
+// formatSplitGuidance turns a split decision into instructions for the
+// executor, which works through the steps within the current phase.
+func formatSplitGuidance(d *supervisorDecision) string {
+	var b strings.Builder
+	b.WriteString("This phase was split into smaller steps. Complete them in order, one per iteration, ")
+	b.WriteString("and only report the phase completed after the last step:\n")
+	for i, step := range d.Steps {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+	}
+	if d.Instructions != "" {
+		b.WriteString("\n")
+		b.WriteString(d.Instructions)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// withSupervisorGuidance appends pending supervisor instructions to a prompt.
+func withSupervisorGuidance(promptText, guidance string) string {
+	if guidance == "" {
+		return promptText
+	}
+	return promptText + "\n\n## Supervisor guidance\n\nA previous attempt at this phase got stuck. Follow these instructions:\n\n" + guidance + "\n"
+}
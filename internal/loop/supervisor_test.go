@@ -0,0 +1,207 @@
+package loop
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/plan"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+func TestParseSupervisorDecision(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    *supervisorDecision
+		wantErr string
+	}{
+		{
+			name: "retry with instructions",
+			output: "Thinking...\n```yaml\nSUPERVISOR_DECISION:\n  action: retry\n  reason: 'Wrong file'\n" +
+				"  instructions: 'Edit main.go instead'\n```",
+			want: &supervisorDecision{Action: supervisorRetry, Reason: "Wrong file", Instructions: "Edit main.go instead"},
+		},
+		{
+			name:   "action is case-insensitive",
+			output: "SUPERVISOR_DECISION:\n  action: SKIP\n  reason: done already\n",
+			want:   &supervisorDecision{Action: supervisorSkip, Reason: "done already"},
+		},
+		{
+			name:   "split with steps",
+			output: "SUPERVISOR_DECISION:\n  action: split\n  reason: too big\n  steps: ['Add type', 'Wire it']\n",
+			want:   &supervisorDecision{Action: supervisorSplit, Reason: "too big", Steps: []string{"Add type", "Wire it"}},
+		},
+		{
+			name:    "split without steps",
+			output:  "SUPERVISOR_DECISION:\n  action: split\n  reason: too big\n",
+			wantErr: "without steps",
+		},
+		{
+			name:    "unknown action",
+			output:  "SUPERVISOR_DECISION:\n  action: panic\n",
+			wantErr: `unknown supervisor action "panic"`,
+		},
+		{
+			name:    "missing block",
+			output:  "no decision here",
+			wantErr: "no SUPERVISOR_DECISION found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseSupervisorDecision(tc.output)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+// supervisedInvoker answers supervisor prompts with a fixed decision and
+// delegates everything else to a sequence invoker.
+type supervisedInvoker struct {
+	*sequenceInvoker
+	decision          string
+	supervisorPrompts []string
+	supervisorFlags   [][]string
+}
+
+func (s *supervisedInvoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
+	if strings.HasPrefix(prompt, "You are supervising") {
+		s.supervisorPrompts = append(s.supervisorPrompts, prompt)
+		s.supervisorFlags = append(s.supervisorFlags, opts.ExtraFlags)
+		return &llm.InvokeResult{Text: s.decision}, nil
+	}
+	return s.sequenceInvoker.Invoke(ctx, prompt, opts)
+}
+
+func newSupervisedLoop(t *testing.T, dir, planPath string, inv llm.Invoker, maxInterventions int) *Loop {
+	t.Helper()
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 2, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(inv)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	l.SetSupervisor(maxInterventions)
+	return l
+}
+
+func TestLoopRun_SupervisorRetriesBlockedPhase(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, workingFile := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Setup"}})
+
+	inv := &supervisedInvoker{
+		sequenceInvoker: newSequenceInvoker([]sequenceResponse{
+			{Status: protocol.StatusBlocked, Summary: "Stuck", Error: "cannot find config"},
+			{PhaseCompleted: "Task 1: Setup", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+				Summary: "Done", FileEdits: map[string]string{workingFile: "done\n"}},
+		}),
+		decision: "SUPERVISOR_DECISION:\n  action: retry\n  reason: config lives elsewhere\n  instructions: 'Look in configs/app.yaml'\n",
+	}
+
+	l := newSupervisedLoop(t, dir, planPath, inv, 2)
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+
+	require.Len(t, inv.supervisorPrompts, 1)
+	assert.Contains(t, inv.supervisorPrompts[0], "blocked: cannot find config")
+	assert.Contains(t, inv.supervisorFlags[0], "--settings", "claude supervises read-only")
+	assert.Contains(t, inv.supervisorPrompts[0], "Task 1: Setup")
+
+	require.Len(t, inv.calls, 2)
+	assert.NotContains(t, inv.calls[0].Prompt, "Supervisor guidance")
+	assert.Contains(t, inv.calls[1].Prompt, "## Supervisor guidance")
+	assert.Contains(t, inv.calls[1].Prompt, "Look in configs/app.yaml")
+}
+
+func TestLoopRun_SupervisorSkipsStagnantPhase(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, workingFile := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Setup", "Task 2: Feature"}})
+
+	inv := &supervisedInvoker{
+		sequenceInvoker: newSequenceInvoker([]sequenceResponse{
+			{Status: protocol.StatusContinue, Summary: "Thinking"},
+			{Status: protocol.StatusContinue, Summary: "Still thinking"},
+			{PhaseCompleted: "Task 2: Feature", Status: protocol.StatusDone, FilesChanged: []string{"working.txt"},
+				Summary: "Feature done", FileEdits: map[string]string{workingFile: "feature\n"}},
+		}),
+		decision: "SUPERVISOR_DECISION:\n  action: skip\n  reason: setup already exists\n",
+	}
+
+	l := newSupervisedLoop(t, dir, planPath, inv, 2)
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+	require.Len(t, inv.supervisorPrompts, 1)
+	assert.Contains(t, inv.supervisorPrompts[0], "[iter 2] Still thinking")
+
+	updated, err := plan.ParseFile(planPath)
+	require.NoError(t, err)
+	assert.False(t, updated.Tasks[0].Completed, "a skipped phase stays open")
+	assert.True(t, updated.Tasks[1].Completed)
+	assert.Contains(t, inv.calls[2].Prompt, "Task 2: Feature")
+}
+
+func TestLoopRun_SupervisorAbortAndLimit(t *testing.T) {
+	tests := []struct {
+		name             string
+		decision         string
+		maxInterventions int
+		wantConsulted    int
+	}{
+		{
+			name:             "abort exits",
+			decision:         "SUPERVISOR_DECISION:\n  action: abort\n  reason: needs a human\n",
+			maxInterventions: 2,
+			wantConsulted:    1,
+		},
+		{
+			name:             "retries stop at the limit",
+			decision:         "SUPERVISOR_DECISION:\n  action: retry\n  reason: try again\n  instructions: 'Try harder'\n",
+			maxInterventions: 2,
+			wantConsulted:    2,
+		},
+		{
+			name:             "disabled",
+			decision:         "SUPERVISOR_DECISION:\n  action: retry\n  reason: try again\n",
+			maxInterventions: 0,
+			wantConsulted:    0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			planPath, _ := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Setup"}})
+
+			// No responses: every executor call reports BLOCKED.
+			inv := &supervisedInvoker{sequenceInvoker: newSequenceInvoker(nil), decision: tc.decision}
+
+			l := newSupervisedLoop(t, dir, planPath, inv, tc.maxInterventions)
+			result, err := l.Run(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, safety.ExitReasonBlocked, result.ExitReason)
+			assert.Len(t, inv.supervisorPrompts, tc.wantConsulted)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package loop
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+	"github.com/alexander-akhmetov/programmator/internal/parser"
+)
+
+// maxTranscriptTools caps the tool calls kept per iteration record.
+const maxTranscriptTools = 500
+
+// IterationRecord is what the executor was asked and did in one iteration,
+// collected for run transcripts.
+type IterationRecord struct {
+	Iteration int       `json:"iteration"`
+	Time      time.Time `json:"time,omitzero"`
+	Duration  string    `json:"duration,omitempty"`
+	Phase     string    `json:"phase,omitempty"`
+	ReviewFix bool      `json:"review_fix,omitempty"`
+	Resumed   bool      `json:"resumed,omitempty"` // prompt continued an executor session
+
+	Prompt       string               `json:"prompt"`
+	Tools        []string             `json:"tools,omitempty"` // one line per tool call, as shown in the TUI
+	ToolsDropped int                  `json:"tools_dropped,omitempty"`
+	Status       *parser.ParsedStatus `json:"status,omitempty"`
+	DiffStat     []FileStat           `json:"diff_stat,omitempty"`
+	Error        string               `json:"error,omitempty"` // invocation failure or missing status block
+}
+
+// FileStat counts the lines an iteration added and removed in one file.
+type FileStat struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// SetIterationRecorder sets a callback receiving a record of every
+// sequential iteration after its invocation.
+func (l *Loop) SetIterationRecorder(fn func(IterationRecord)) {
+	l.onIteration = fn
+}
+
+// iterationCapture collects an IterationRecord while the executor runs.
+type iterationCapture struct {
+	rec   IterationRecord
+	start time.Time
+	head  string // HEAD before the invocation; the diff stat is taken from here
+}
+
+// beginIterationRecord starts recording the current iteration. It returns
+// nil when no recorder is set.
+func (l *Loop) beginIterationRecord(rc *runContext, phase, promptText string, resumed bool) *iterationCapture {
+	if l.onIteration == nil {
+		return nil
+	}
+	c := &iterationCapture{
+		rec: IterationRecord{
+			Iteration: rc.state.Iteration,
+			Phase:     phase,
+			ReviewFix: l.engine.PendingReviewFix,
+			Resumed:   resumed,
+			Prompt:    promptText,
+		},
+		start: time.Now(),
+	}
+	if l.gitRepo != nil {
+		c.head, _ = gitutil.HeadCommit(l.workingDir)
+	}
+
+	l.toolMu.Lock()
+	l.iterTools = nil
+	l.iterToolsDropped = 0
+	l.toolMu.Unlock()
+	return c
+}
+
+// finishIterationRecord completes c with the invocation's outcome and hands
+// it to the recorder.
+func (l *Loop) finishIterationRecord(c *iterationCapture, status *parser.ParsedStatus, failure string) {
+	if c == nil {
+		return
+	}
+	c.rec.Time = c.start
+	c.rec.Duration = time.Since(c.start).Round(time.Second).String()
+	c.rec.Status = status
+	c.rec.Error = failure
+
+	l.toolMu.Lock()
+	c.rec.Tools = l.iterTools
+	c.rec.ToolsDropped = l.iterToolsDropped
+	l.iterTools = nil
+	l.toolMu.Unlock()
+
+	if l.gitRepo != nil && c.head != "" {
+		if diff, err := l.gitRepo.DiffFrom(c.head); err == nil {
+			c.rec.DiffStat = diffStat(diff)
+		} else {
+			l.log(fmt.Sprintf("Warning: transcript diff stat failed: %v", err))
+		}
+	}
+	l.onIteration(l.redactRecord(c.rec))
+}
+
+// recordToolUse keeps a tool call line for the iteration record.
+func (l *Loop) recordToolUse(line string) {
+	if l.onIteration == nil {
+		return
+	}
+	l.toolMu.Lock()
+	defer l.toolMu.Unlock()
+
+	if len(l.iterTools) >= maxTranscriptTools {
+		l.iterToolsDropped++
+		return
+	}
+	l.iterTools = append(l.iterTools, line)
+}
+
+// diffStat counts added and removed lines per file in a unified diff.
+func diffStat(diff string) []FileStat {
+	var stats []FileStat
+	var cur *FileStat
+	for line := range strings.Lines(diff) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			path := strings.TrimPrefix(line, "diff --git ")
+			if i := strings.LastIndex(path, " b/"); i >= 0 {
+				path = path[i+len(" b/"):]
+			}
+			stats = append(stats, FileStat{Path: path})
+			cur = &stats[len(stats)-1]
+		case cur == nil, strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+		case strings.HasPrefix(line, "+"):
+			cur.Added++
+		case strings.HasPrefix(line, "-"):
+			cur.Removed++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,104 @@
+package loop
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// toolCallingInvoker reports a tool call before answering like seq.
+type toolCallingInvoker struct {
+	seq  *sequenceInvoker
+	path string
+}
+
+func (i *toolCallingInvoker) Invoke(ctx context.Context, prompt string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
+	if opts.OnToolUse != nil {
+		opts.OnToolUse("Edit", map[string]any{"file_path": i.path, "old_string": "initial", "new_string": "changed"})
+	}
+	return i.seq.Invoke(ctx, prompt, opts)
+}
+
+func TestLoopRun_RecordsIterations(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	planPath, workingFile := writePlanFile(t, dir, planConfig{Tasks: []string{"Task 1: Feature"}, CommitFiles: true})
+	invoker := &toolCallingInvoker{
+		path: workingFile,
+		seq: newSequenceInvoker([]sequenceResponse{
+			{Status: protocol.StatusContinue, Summary: "Looked around"},
+			{
+				PhaseCompleted: "Task 1: Feature",
+				Status:         protocol.StatusDone,
+				Summary:        "Implemented",
+				FilesChanged:   []string{"working.txt"},
+				FileEdits:      map[string]string{workingFile: "changed\nmore\n"},
+			},
+		}),
+	}
+
+	l := New(safety.Config{MaxIterations: 10, StagnationLimit: 5, Timeout: 60}, dir, nil, false)
+	l.SetInvoker(invoker)
+	l.SetSource(source.NewPlanSource(planPath))
+	l.SetReviewRunner(createNoIssueReviewRunner(t))
+	l.SetReviewConfig(review.Config{MaxIterations: 3, Agents: []review.AgentConfig{{Name: "test_agent"}}})
+	var records []IterationRecord
+	l.SetIterationRecorder(func(rec IterationRecord) { records = append(records, rec) })
+	var reviews []*review.RunResult
+	l.SetReviewRecorder(func(res *review.RunResult) { reviews = append(reviews, res) })
+
+	result, err := l.Run(planPath)
+	require.NoError(t, err)
+	require.Equal(t, safety.ExitReasonComplete, result.ExitReason)
+	require.Len(t, records, 2)
+	require.Len(t, reviews, 1)
+	assert.True(t, reviews[0].Passed)
+
+	first := records[0]
+	assert.Equal(t, 1, first.Iteration)
+	assert.Equal(t, "Task 1: Feature", first.Phase)
+	assert.Equal(t, invoker.seq.Calls()[0].Prompt, first.Prompt)
+	assert.Equal(t, []string{"Edit " + workingFile}, first.Tools)
+	require.NotNil(t, first.Status)
+	assert.Equal(t, "Looked around", first.Status.Summary)
+	assert.Empty(t, first.DiffStat)
+	assert.False(t, first.Time.IsZero())
+
+	second := records[1]
+	assert.Equal(t, protocol.StatusDone, second.Status.Status)
+	assert.Equal(t, []FileStat{{Path: filepath.Base(workingFile), Added: 2, Removed: 1}}, second.DiffStat)
+}
+
+func TestDiffStat(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+index 1..2 100644
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+-old
++new
++added
+ same
+diff --git a/dir/new.txt b/dir/new.txt
+new file mode 100644
+--- /dev/null
++++ b/dir/new.txt
+@@ -0,0 +1,1 @@
++hello`
+
+	assert.Equal(t, []FileStat{
+		{Path: "a.go", Added: 2, Removed: 1},
+		{Path: "dir/new.txt", Added: 1},
+	}, diffStat(diff))
+	assert.Empty(t, diffStat(""))
+}
@@ -0,0 +1,170 @@
+// Package messages holds the main loop progress and notification texts as
+// templates, so they can be translated. Only the messages with an ID here
+// are; other log lines, notes, and errors stay in English. English is built
+// in; a translation is a messages/<language>.yaml file in a config directory
+// that overrides any of the English entries.
+package messages
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed en.yaml
+var english []byte
+
+// DefaultLanguage is the language of the built-in messages.
+const DefaultLanguage = "en"
+
+// ID names a message.
+type ID string
+
+// Loop progress messages.
+const (
+	Iteration          ID = "loop.iteration"            // Iteration, Max
+	CurrentPhase       ID = "loop.current_phase"        // Phase
+	Resuming           ID = "loop.resuming"             //
+	Invoking           ID = "loop.invoking"             // Executor
+	Status             ID = "loop.status"               // Status
+	Summary            ID = "loop.summary"              // Summary
+	FilesChanged       ID = "loop.files_changed"        // Files
+	PhaseCompleted     ID = "loop.phase_completed"      // Phase
+	Done               ID = "loop.done"                 //
+	Blocked            ID = "loop.blocked"              // Error
+	AllPhasesComplete  ID = "loop.all_phases_complete"  //
+	SettingUpBranch    ID = "loop.setting_up_branch"    // Branch
+	AutoCommitting     ID = "loop.auto_committing"      // Phase
+	StopRequested      ID = "loop.stop_requested"       //
+	SafetyExit         ID = "loop.safety_exit"          // Reason
+	ReviewFixesPending ID = "loop.review_fixes_pending" //
+	ReviewSkipped      ID = "loop.review_skipped"       //
+	ReviewIteration    ID = "loop.review_iteration"     // Iteration, Max
+	ReviewPassed       ID = "loop.review_passed"        //
+	ReviewFound        ID = "loop.review_found"         // Count
+)
+
+// Notification messages.
+const (
+	RunEndSubject ID = "notify.run_end.subject" // ID, Reason
+	RunEndBody    ID = "notify.run_end.body"    // ID, Reason, ExitMessage, Dir, Result, Iterations, FilesChanged, Duration, Labels, Error
+	OwnerSubject  ID = "notify.owner.subject"   // ID, Owner
+	OwnerBody     ID = "notify.owner.body"      // ID, Owner, Count, Issues
+)
+
+// Args are the values a message template refers to, by name.
+type Args map[string]any
+
+// Catalog is the set of messages of one language.
+type Catalog struct {
+	lang      string
+	templates map[ID]*template.Template
+}
+
+var defaultCatalog = mustParse(DefaultLanguage, english)
+
+// Default returns the built-in English catalog.
+func Default() *Catalog {
+	return defaultCatalog
+}
+
+// Load returns the catalog of lang: the English messages overridden by the
+// entries of messages/<lang>.yaml in dirs, the first directory winning. A
+// language other than English without any such file is an error.
+func Load(lang string, dirs ...string) (*Catalog, error) {
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+	if strings.ContainsAny(lang, `/\`) || strings.HasPrefix(lang, ".") {
+		return nil, fmt.Errorf("invalid language %q", lang)
+	}
+
+	c := &Catalog{lang: lang, templates: maps.Clone(defaultCatalog.templates)}
+
+	found := lang == DefaultLanguage
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] == "" {
+			continue
+		}
+		path := filepath.Join(dirs[i], "messages", lang+".yaml")
+		data, err := os.ReadFile(path) //nolint:gosec // path under a config directory
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read messages: %w", err)
+		}
+		if err := c.merge(data, defaultCatalog.templates); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("language %q: no messages/%s.yaml in the config directories", lang, lang)
+	}
+	return c, nil
+}
+
+// Lang returns the catalog's language.
+func (c *Catalog) Lang() string {
+	if c == nil {
+		return DefaultLanguage
+	}
+	return c.lang
+}
+
+// Format renders message id with args. A nil catalog formats in English; a
+// translation that fails to render falls back to English too.
+func (c *Catalog) Format(id ID, args Args) string {
+	if c == nil {
+		c = defaultCatalog
+	}
+	tmpl, ok := c.templates[id]
+	if !ok {
+		return string(id)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, args); err != nil {
+		if c != defaultCatalog {
+			return defaultCatalog.Format(id, args)
+		}
+		return string(id)
+	}
+	return b.String()
+}
+
+// merge parses a YAML map of message IDs to templates into c. Unless known
+// is nil, keys it lacks are rejected, to catch misspelled ones.
+func (c *Catalog) merge(data []byte, known map[ID]*template.Template) error {
+	var entries map[string]string
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	for key, text := range entries {
+		id := ID(key)
+		if _, ok := known[id]; known != nil && !ok {
+			return fmt.Errorf("unknown message %q", key)
+		}
+		tmpl, err := template.New(key).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return err
+		}
+		c.templates[id] = tmpl
+	}
+	return nil
+}
+
+func mustParse(lang string, data []byte) *Catalog {
+	c := &Catalog{lang: lang, templates: make(map[ID]*template.Template)}
+	if err := c.merge(data, nil); err != nil {
+		panic(fmt.Sprintf("messages: built-in %s catalog: %v", lang, err))
+	}
+	return c
+}
@@ -0,0 +1,80 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMessages(t *testing.T, dir, lang, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "messages"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "messages", lang+".yaml"), []byte(content), 0o644))
+}
+
+func TestDefault(t *testing.T) {
+	ids := []ID{
+		Iteration, CurrentPhase, Resuming, Invoking, Status, Summary, FilesChanged, PhaseCompleted,
+		Done, Blocked, AllPhasesComplete, SettingUpBranch, AutoCommitting, StopRequested, SafetyExit,
+		ReviewFixesPending, ReviewSkipped, ReviewIteration, ReviewPassed, ReviewFound,
+		RunEndSubject, RunEndBody, OwnerSubject, OwnerBody,
+	}
+	for _, id := range ids {
+		assert.Contains(t, Default().templates, id)
+	}
+	assert.Len(t, Default().templates, len(ids), "every message has an ID constant")
+
+	assert.Equal(t, "Iteration 2/10", Default().Format(Iteration, Args{"Iteration": 2, "Max": 10}))
+	var nilCatalog *Catalog
+	assert.Equal(t, "Current phase: Setup", nilCatalog.Format(CurrentPhase, Args{"Phase": "Setup"}))
+	assert.Equal(t, "en", nilCatalog.Lang())
+	assert.Equal(t, "loop.unknown", Default().Format("loop.unknown", nil))
+}
+
+func TestLoad(t *testing.T) {
+	local, global := t.TempDir(), t.TempDir()
+	writeMessages(t, global, "de", "loop.iteration: \"Iteration {{.Iteration}} von {{.Max}}\"\nloop.review_passed: \"Review bestanden\"\n")
+	writeMessages(t, local, "de", "loop.review_passed: \"Review ohne Befunde\"\n")
+
+	c, err := Load("de", local, global)
+	require.NoError(t, err)
+	assert.Equal(t, "de", c.Lang())
+	assert.Equal(t, "Iteration 2 von 10", c.Format(Iteration, Args{"Iteration": 2, "Max": 10}))
+	assert.Equal(t, "Review ohne Befunde", c.Format(ReviewPassed, nil), "the local directory wins")
+	assert.Equal(t, "Review skipped", c.Format(ReviewSkipped, nil), "missing entries stay in English")
+	assert.Equal(t, "Review passed - no issues found", Default().Format(ReviewPassed, nil))
+
+	c, err = Load("", local, global)
+	require.NoError(t, err)
+	assert.Equal(t, "en", c.Lang())
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Load("fr", dir, "")
+	require.ErrorContains(t, err, "no messages/fr.yaml")
+
+	_, err = Load("../de", dir)
+	require.ErrorContains(t, err, "invalid language")
+
+	writeMessages(t, dir, "es", "loop.iteracion: \"Iteración {{.Iteration}}\"\n")
+	_, err = Load("es", dir)
+	require.ErrorContains(t, err, `unknown message "loop.iteracion"`)
+
+	writeMessages(t, dir, "it", "loop.iteration: \"Iterazione {{.Iteration\"\n")
+	_, err = Load("it", dir)
+	require.Error(t, err)
+}
+
+func TestFormat_FallsBackToEnglish(t *testing.T) {
+	dir := t.TempDir()
+	writeMessages(t, dir, "pt", "loop.review_found: \"{{.Count.Missing}} problemas\"\n")
+
+	c, err := Load("pt", dir)
+	require.NoError(t, err)
+	assert.Equal(t, "Review found 3 issues", c.Format(ReviewFound, Args{"Count": 3}))
+}
@@ -0,0 +1,144 @@
+// Package notes decides which progress notes a run adds to its work item and
+// renders their content.
+package notes
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Levels: which notes reach the source.
+const (
+	LevelAll   = "all"   // every note, including per-iteration progress
+	LevelPhase = "phase" // phase milestones and the outcome of the run
+	LevelFinal = "final" // only the outcome of the run
+)
+
+// Importance ranks a note. A level keeps the notes at or above its threshold.
+type Importance int
+
+const (
+	Iteration Importance = iota // per-iteration progress, review rounds, warnings
+	Phase                       // phase completions, splits, planning, review passed
+	Final                       // how the run ended: completed, blocked, stopped
+)
+
+// Style is how notes are written for one source type.
+type Style struct {
+	Level    string // Level*; "" is LevelAll
+	Template string // text/template for the note content, given Data; "" keeps "kind: message"
+}
+
+// Validate checks the level and parses the template.
+func (s Style) Validate() error {
+	if _, err := threshold(s.Level); err != nil {
+		return err
+	}
+	if _, err := parseTemplate(s.Template); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Config is the default note style, with overrides by source type.
+type Config struct {
+	Style
+	Sources map[string]Style // by source type; empty fields inherit the default
+}
+
+// For returns the style for sourceType.
+func (c Config) For(sourceType string) Style {
+	s := c.Style
+	if o, ok := c.Sources[sourceType]; ok {
+		if o.Level != "" {
+			s.Level = o.Level
+		}
+		if o.Template != "" {
+			s.Template = o.Template
+		}
+	}
+	return s
+}
+
+// Data is what a note template can reference.
+type Data struct {
+	Kind      string    // progress, review, warning, or error
+	Message   string    // the note without its kind
+	Note      string    // the default content, "kind: message"
+	Iteration int       // current iteration, 0 before the first
+	WorkItem  string    // work item ID
+	Source    string    // source type
+	Time      time.Time // when the note was written
+}
+
+// Writer filters and renders the notes of one run.
+type Writer struct {
+	threshold Importance
+	tmpl      *template.Template
+}
+
+// NewWriter returns a writer for style s.
+func NewWriter(s Style) (*Writer, error) {
+	t, err := threshold(s.Level)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := parseTemplate(s.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{threshold: t, tmpl: tmpl}, nil
+}
+
+// Render returns the content of a note of importance imp, or false when the
+// level drops it. A template that renders to nothing drops the note too.
+func (w *Writer) Render(imp Importance, d Data) (string, bool, error) {
+	if imp < w.threshold {
+		return "", false, nil
+	}
+	if w.tmpl == nil {
+		return d.Note, true, nil
+	}
+	var b bytes.Buffer
+	if err := w.tmpl.Execute(&b, d); err != nil {
+		return "", false, fmt.Errorf("render note template: %w", err)
+	}
+	out := strings.TrimSpace(b.String())
+	return out, out != "", nil
+}
+
+// Split separates a "kind: message" note into its kind and message. Notes
+// without a kind are progress notes.
+func Split(note string) (kind, message string) {
+	kind, message, ok := strings.Cut(note, ": ")
+	if !ok || kind == "" || strings.ContainsAny(kind, " \n") {
+		return "progress", note
+	}
+	return kind, message
+}
+
+func threshold(level string) (Importance, error) {
+	switch level {
+	case "", LevelAll:
+		return Iteration, nil
+	case LevelPhase:
+		return Phase, nil
+	case LevelFinal:
+		return Final, nil
+	}
+	return 0, fmt.Errorf("unknown note level %q (supported: %s, %s, %s)", level, LevelAll, LevelPhase, LevelFinal)
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("note").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse note template: %w", err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,91 @@
+package notes
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_Levels(t *testing.T) {
+	tests := []struct {
+		level string
+		keep  []Importance
+	}{
+		{"", []Importance{Iteration, Phase, Final}},
+		{LevelAll, []Importance{Iteration, Phase, Final}},
+		{LevelPhase, []Importance{Phase, Final}},
+		{LevelFinal, []Importance{Final}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.level, func(t *testing.T) {
+			w, err := NewWriter(Style{Level: tc.level})
+			require.NoError(t, err)
+			for _, imp := range []Importance{Iteration, Phase, Final} {
+				content, ok, err := w.Render(imp, Data{Note: "progress: done"})
+				require.NoError(t, err)
+				assert.Equal(t, slices.Contains(tc.keep, imp), ok, "importance %d", imp)
+				if ok {
+					assert.Equal(t, "progress: done", content)
+				}
+			}
+		})
+	}
+}
+
+func TestWriter_Template(t *testing.T) {
+	w, err := NewWriter(Style{Template: `{{if eq .Kind "progress"}}#{{.Iteration}} {{.Message}}{{end}}`})
+	require.NoError(t, err)
+
+	content, ok, err := w.Render(Iteration, Data{Kind: "progress", Message: "Completed Task 1", Iteration: 3})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "#3 Completed Task 1", content)
+
+	_, ok, err = w.Render(Iteration, Data{Kind: "review", Message: "Found 2 issues"})
+	require.NoError(t, err)
+	assert.False(t, ok, "a note that renders empty is dropped")
+}
+
+func TestWriter_TemplateExecutionError(t *testing.T) {
+	w, err := NewWriter(Style{Template: "{{.Missing}}"})
+	require.NoError(t, err)
+
+	_, _, err = w.Render(Final, Data{})
+	require.ErrorContains(t, err, "render note template")
+}
+
+func TestStyle_Validate(t *testing.T) {
+	require.NoError(t, Style{}.Validate())
+	require.NoError(t, Style{Level: LevelPhase, Template: "{{.Note}}"}.Validate())
+	require.ErrorContains(t, Style{Level: "verbose"}.Validate(), `unknown note level "verbose"`)
+	require.ErrorContains(t, Style{Template: "{{"}.Validate(), "parse note template")
+}
+
+func TestConfig_For(t *testing.T) {
+	cfg := Config{
+		Style:   Style{Level: LevelAll, Template: "{{.Note}}"},
+		Sources: map[string]Style{"ticket": {Level: LevelFinal}},
+	}
+
+	assert.Equal(t, Style{Level: LevelFinal, Template: "{{.Note}}"}, cfg.For("ticket"))
+	assert.Equal(t, Style{Level: LevelAll, Template: "{{.Note}}"}, cfg.For("plan"))
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		note, kind, message string
+	}{
+		{"progress: [iter 2] Completed Task 1", "progress", "[iter 2] Completed Task 1"},
+		{"error: Review failed: timeout", "error", "Review failed: timeout"},
+		{"review: [iter 1] Found 2 issues:\n- a: b", "review", "[iter 1] Found 2 issues:\n- a: b"},
+		{"Plain note", "progress", "Plain note"},
+		{"[iter 1] Fixed: the bug", "progress", "[iter 1] Fixed: the bug"},
+	}
+	for _, tc := range tests {
+		kind, message := Split(tc.note)
+		assert.Equal(t, tc.kind, kind, tc.note)
+		assert.Equal(t, tc.message, message, tc.note)
+	}
+}
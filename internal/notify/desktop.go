@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// commandRunner runs an external command; replaced in tests.
+type commandRunner func(ctx context.Context, name string, args ...string) error
+
+func runCommand(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput() //nolint:gosec // fixed notifier commands
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Desktop shows a local desktop notification: notify-send on Linux and
+// BSDs, osascript on macOS, and a PowerShell toast on Windows.
+type Desktop struct {
+	goos string
+	run  commandRunner
+}
+
+// NewDesktop returns a desktop sender for the current platform.
+func NewDesktop() *Desktop {
+	return &Desktop{goos: runtime.GOOS, run: runCommand}
+}
+
+// Name returns "desktop".
+func (d *Desktop) Name() string { return "desktop" }
+
+// Send shows msg as a desktop notification.
+func (d *Desktop) Send(ctx context.Context, msg Message) error {
+	name, args := d.command(msg)
+	return d.run(ctx, name, args...)
+}
+
+func (d *Desktop) command(msg Message) (string, []string) {
+	switch d.goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(msg.Body), appleScriptString(msg.Subject))
+		return "osascript", []string{"-e", script}
+	case "windows":
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", windowsToastScript(msg)}
+	default:
+		return "notify-send", []string{"--app-name=programmator", msg.Subject, msg.Body}
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// powerShellString quotes s as a single-quoted PowerShell string literal.
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// windowsToastScript builds a PowerShell script that shows msg as a toast.
+func windowsToastScript(msg Message) string {
+	return `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode(` + powerShellString(msg.Subject) + `)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode(` + powerShellString(msg.Body) + `)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('programmator').Show($toast)`
+}
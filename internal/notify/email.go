@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailConfig holds the SMTP settings of an email sender.
+type EmailConfig struct {
+	Host     string
+	Port     int // default 587
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// sendMailFunc matches smtp.SendMail; replaced in tests.
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// Email sends notifications over SMTP. smtp.SendMail upgrades the
+// connection with STARTTLS when the server supports it.
+type Email struct {
+	cfg      EmailConfig
+	sendMail sendMailFunc
+	now      func() time.Time
+}
+
+// NewEmail returns an email sender.
+func NewEmail(cfg EmailConfig) (*Email, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("email notifications need a host")
+	}
+	if cfg.From == "" || len(cfg.To) == 0 {
+		return nil, fmt.Errorf("email notifications need from and to addresses")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &Email{cfg: cfg, sendMail: smtp.SendMail, now: time.Now}, nil
+}
+
+// Name returns "email".
+func (e *Email) Name() string { return "email" }
+
+// Send emails msg to the configured recipients. smtp.SendMail takes no
+// context, so ctx only stops a send that has not started.
+func (e *Email) Send(ctx context.Context, msg Message) error {
+	return e.SendTo(ctx, e.cfg.To, msg)
+}
+
+// SendTo emails msg to the given recipients instead of the configured ones.
+func (e *Email) SendTo(ctx context.Context, to []string, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	return e.sendMail(addr, auth, e.cfg.From, to, e.message(to, msg))
+}
+
+// message renders msg to the recipients to as a plain-text RFC 5322
+// message.
+func (e *Email) message(to []string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", headerValue(msg.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// headerValue keeps a header on one line.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,95 @@
+// Package notify sends notifications when a run ends, by desktop
+// notification or email.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Message is a notification about a finished run.
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// Sender delivers a message over one channel.
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Router is a Sender that can also deliver to recipients other than its
+// configured ones, such as the owners of the files a review flagged.
+type Router interface {
+	Sender
+	SendTo(ctx context.Context, to []string, msg Message) error
+}
+
+// Notifier sends messages through its senders for the exit reasons it is
+// configured for.
+type Notifier struct {
+	senders []Sender
+	on      []string
+}
+
+// New returns a notifier sending through senders when a run ends with one of
+// the exit reasons in on, or with any exit reason when on is empty.
+func New(on []string, senders ...Sender) *Notifier {
+	return &Notifier{senders: senders, on: on}
+}
+
+// Enabled reports whether n has any senders.
+func (n *Notifier) Enabled() bool {
+	return n != nil && len(n.senders) > 0
+}
+
+// Wants reports whether a run ending with exitReason is notified.
+func (n *Notifier) Wants(exitReason string) bool {
+	return n.Enabled() && (len(n.on) == 0 || slices.Contains(n.on, exitReason))
+}
+
+// Notify sends msg through every sender when exitReason is wanted. A failing
+// sender does not stop the others; their errors are joined.
+func (n *Notifier) Notify(ctx context.Context, exitReason string, msg Message) error {
+	if !n.Wants(exitReason) {
+		return nil
+	}
+	var errs []error
+	for _, s := range n.senders {
+		if err := s.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s notification: %w", s.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CanRoute reports whether n has a sender that implements Router.
+func (n *Notifier) CanRoute() bool {
+	if n == nil {
+		return false
+	}
+	return slices.ContainsFunc(n.senders, func(s Sender) bool {
+		_, ok := s.(Router)
+		return ok
+	})
+}
+
+// Route sends msg to the recipients to through every sender that implements
+// Router, whatever exit reasons n is configured for.
+func (n *Notifier) Route(ctx context.Context, to []string, msg Message) error {
+	if n == nil {
+		return nil
+	}
+	var errs []error
+	for _, s := range n.senders {
+		if r, ok := s.(Router); ok {
+			if err := r.SendTo(ctx, to, msg); err != nil {
+				errs = append(errs, fmt.Errorf("%s notification: %w", s.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
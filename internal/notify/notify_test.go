@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSender struct {
+	name string
+	err  error
+	sent []Message
+}
+
+func (f *fakeSender) Name() string { return f.name }
+
+func (f *fakeSender) Send(_ context.Context, msg Message) error {
+	f.sent = append(f.sent, msg)
+	return f.err
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	msg := Message{Subject: "done", Body: "all phases complete"}
+
+	t.Run("every exit reason by default", func(t *testing.T) {
+		s := &fakeSender{name: "fake"}
+		n := New(nil, s)
+		require.NoError(t, n.Notify(context.Background(), "complete", msg))
+		require.NoError(t, n.Notify(context.Background(), "blocked", msg))
+		assert.Len(t, s.sent, 2)
+	})
+
+	t.Run("only the configured exit reasons", func(t *testing.T) {
+		s := &fakeSender{name: "fake"}
+		n := New([]string{"blocked", "error"}, s)
+		require.NoError(t, n.Notify(context.Background(), "complete", msg))
+		require.NoError(t, n.Notify(context.Background(), "blocked", msg))
+		assert.Equal(t, []Message{msg}, s.sent)
+		assert.False(t, n.Wants("complete"))
+		assert.True(t, n.Wants("error"))
+	})
+
+	t.Run("a failing sender does not stop the others", func(t *testing.T) {
+		failing := &fakeSender{name: "email", err: errors.New("connection refused")}
+		ok := &fakeSender{name: "desktop"}
+		err := New(nil, failing, ok).Notify(context.Background(), "complete", msg)
+		require.ErrorContains(t, err, "email notification: connection refused")
+		assert.Len(t, ok.sent, 1)
+	})
+
+	t.Run("no senders", func(t *testing.T) {
+		var nilNotifier *Notifier
+		assert.False(t, nilNotifier.Wants("complete"))
+		assert.False(t, New(nil).Enabled())
+		require.NoError(t, nilNotifier.Notify(context.Background(), "complete", msg))
+	})
+}
+
+func TestDesktop_Send(t *testing.T) {
+	msg := Message{Subject: `plan "a" complete`, Body: "It's done"}
+	tests := []struct {
+		goos     string
+		wantName string
+		wantArgs []string
+	}{
+		{"linux", "notify-send", []string{"--app-name=programmator", `plan "a" complete`, "It's done"}},
+		{"darwin", "osascript", []string{"-e", `display notification "It's done" with title "plan \"a\" complete"`}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.goos, func(t *testing.T) {
+			var gotName string
+			var gotArgs []string
+			d := &Desktop{goos: tc.goos, run: func(_ context.Context, name string, args ...string) error {
+				gotName, gotArgs = name, args
+				return nil
+			}}
+			require.NoError(t, d.Send(context.Background(), msg))
+			assert.Equal(t, tc.wantName, gotName)
+			assert.Equal(t, tc.wantArgs, gotArgs)
+		})
+	}
+
+	t.Run("windows", func(t *testing.T) {
+		var gotName string
+		var gotArgs []string
+		d := &Desktop{goos: "windows", run: func(_ context.Context, name string, args ...string) error {
+			gotName, gotArgs = name, args
+			return nil
+		}}
+		require.NoError(t, d.Send(context.Background(), msg))
+		assert.Equal(t, "powershell", gotName)
+		script := gotArgs[len(gotArgs)-1]
+		assert.Contains(t, script, `CreateTextNode('plan "a" complete')`)
+		assert.Contains(t, script, `CreateTextNode('It''s done')`)
+	})
+}
+
+func TestNewEmail(t *testing.T) {
+	_, err := NewEmail(EmailConfig{From: "a@example.com", To: []string{"b@example.com"}})
+	require.ErrorContains(t, err, "need a host")
+	_, err = NewEmail(EmailConfig{Host: "smtp.example.com", From: "a@example.com"})
+	require.ErrorContains(t, err, "need from and to")
+
+	e, err := NewEmail(EmailConfig{Host: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, 587, e.cfg.Port)
+}
+
+func TestEmail_Send(t *testing.T) {
+	e, err := NewEmail(EmailConfig{
+		Host:     "smtp.example.com",
+		Port:     2525,
+		Username: "bot",
+		Password: "secret",
+		From:     "programmator@example.com",
+		To:       []string{"me@example.com", "team@example.com"},
+	})
+	require.NoError(t, err)
+	e.now = func() time.Time { return time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC) }
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotAuth smtp.Auth
+	var gotMsg []byte
+	e.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo, gotMsg = addr, a, from, to, msg
+		return nil
+	}
+
+	require.NoError(t, e.Send(context.Background(), Message{Subject: "plan complete\nBcc: x", Body: "Exit: complete\nIterations: 3"}))
+	assert.Equal(t, "smtp.example.com:2525", gotAddr)
+	assert.NotNil(t, gotAuth)
+	assert.Equal(t, "programmator@example.com", gotFrom)
+	assert.Equal(t, []string{"me@example.com", "team@example.com"}, gotTo)
+
+	msg := string(gotMsg)
+	assert.Contains(t, msg, "To: me@example.com, team@example.com\r\n")
+	assert.Contains(t, msg, "Subject: plan complete Bcc: x\r\n", "the subject stays on one line")
+	assert.Contains(t, msg, "Date: Sun, 01 Mar 2026 08:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(msg, "\r\n\r\nExit: complete\r\nIterations: 3\r\n"))
+}
+
+func TestEmail_SendWithoutAuth(t *testing.T) {
+	e, err := NewEmail(EmailConfig{Host: "localhost", Port: 25, From: "a@example.com", To: []string{"b@example.com"}})
+	require.NoError(t, err)
+	var gotAuth smtp.Auth = smtp.PlainAuth("", "", "", "")
+	e.sendMail = func(_ string, a smtp.Auth, _ string, _ []string, _ []byte) error {
+		gotAuth = a
+		return nil
+	}
+	require.NoError(t, e.Send(context.Background(), Message{Subject: "s", Body: "b"}))
+	assert.Nil(t, gotAuth)
+}
+
+func TestNotifier_Route(t *testing.T) {
+	e, err := NewEmail(EmailConfig{Host: "localhost", From: "a@example.com", To: []string{"me@example.com"}})
+	require.NoError(t, err)
+	var gotTo []string
+	e.sendMail = func(_ string, _ smtp.Auth, _ string, to []string, _ []byte) error {
+		gotTo = to
+		return nil
+	}
+	desktop := &fakeSender{name: "desktop"}
+
+	n := New([]string{"blocked"}, desktop, e)
+	assert.True(t, n.CanRoute())
+	require.NoError(t, n.Route(context.Background(), []string{"billing@example.com"}, Message{Subject: "s", Body: "b"}))
+	assert.Equal(t, []string{"billing@example.com"}, gotTo, "routed whatever on_exit says")
+	assert.Empty(t, desktop.sent, "senders that cannot route are skipped")
+
+	assert.False(t, New(nil, desktop).CanRoute())
+	var nilNotifier *Notifier
+	assert.False(t, nilNotifier.CanRoute())
+}
@@ -2,8 +2,10 @@
 package parser
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 
@@ -19,7 +21,9 @@ type ParsedStatus struct {
 	FilesChanged   []string `yaml:"files_changed"`
 	Summary        string   `yaml:"summary"`
 	Error          string   `yaml:"error,omitempty"`
+	Questions      []string `yaml:"questions,omitempty"` // questions for the user when BLOCKED
 	CommitMade     bool     `yaml:"commit_made,omitempty"`
+	Protocol       int      `yaml:"protocol,omitempty"` // protocol.Version the block was written for; 0 for version 1 blocks
 }
 
 // IsValid checks if the parsed status has valid values.
@@ -34,31 +38,74 @@ func (p *ParsedStatus) IsValid() bool {
 // It handles both cases:
 // 1. Status block followed by closing backticks (```)
 // 2. Status block at end of output with no closing backticks
-var statusBlockRegex = regexp.MustCompile(`(?s)` + protocol.StatusBlockKey + `:\s*\n(.*?)(?:\n\s*\x60{3}|$)`)
+var statusBlockRegex = statusBlockPattern(protocol.StatusBlockKey)
+
+var (
+	keyRegexMu sync.Mutex
+	keyRegexes = map[string]*regexp.Regexp{protocol.StatusBlockKey: statusBlockRegex}
+)
+
+func statusBlockPattern(key string) *regexp.Regexp {
+	return regexp.MustCompile(`(?s)\b` + regexp.QuoteMeta(key) + `:\s*\n(.*?)(?:\n\s*\x60{3}|$)`)
+}
 
 // Parse extracts and parses a PROGRAMMATOR_STATUS block from Claude output.
 // Returns nil, nil if no status block is found.
 // Returns nil, error if the status block is malformed.
 func Parse(output string) (*ParsedStatus, error) {
-	match := statusBlockRegex.FindStringSubmatch(output)
-	if match == nil {
+	return ParseKey(output, protocol.StatusBlockKey)
+}
+
+// ParseKey parses the last status block starting with key, such as a
+// per-run key from protocol.NonceStatusKey. When output has none, the last
+// block with the plain PROGRAMMATOR_STATUS key is parsed instead, so
+// executor timeouts and prompt templates written for the plain key keep
+// working. A block whose protocol field is newer than protocol.Version is an
+// error.
+func ParseKey(output, key string) (*ParsedStatus, error) {
+	body, ok := lastBlock(output, key)
+	if !ok && key != protocol.StatusBlockKey {
+		body, ok = lastBlock(output, protocol.StatusBlockKey)
+	}
+	if !ok {
 		return nil, nil
 	}
 
-	yamlContent := protocol.StatusBlockKey + ":\n" + match[1]
+	yamlContent := "status:\n" + body
 	yamlContent = strings.TrimRight(yamlContent, "`\n ")
 
 	var wrapper struct {
-		Status ParsedStatus `yaml:"PROGRAMMATOR_STATUS"`
+		Status ParsedStatus `yaml:"status"`
 	}
 
 	if err := yaml.Unmarshal([]byte(yamlContent), &wrapper); err != nil {
 		return nil, err
 	}
+	if wrapper.Status.Protocol > protocol.Version {
+		return nil, fmt.Errorf("status block uses protocol version %d, newer than the supported %d", wrapper.Status.Protocol, protocol.Version)
+	}
 
 	return &wrapper.Status, nil
 }
 
+// lastBlock returns the body of the last block starting with key. Earlier
+// matches are usually the key quoted from a file or command output.
+func lastBlock(output, key string) (string, bool) {
+	keyRegexMu.Lock()
+	re, ok := keyRegexes[key]
+	if !ok {
+		re = statusBlockPattern(key)
+		keyRegexes[key] = re
+	}
+	keyRegexMu.Unlock()
+
+	matches := re.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[len(matches)-1][1], true
+}
+
 // ParseDirect parses YAML content directly into a ParsedStatus struct.
 // This is useful for testing or when the YAML is already extracted.
 func ParseDirect(output string) (*ParsedStatus, error) {
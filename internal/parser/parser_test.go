@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/alexander-akhmetov/programmator/internal/protocol"
@@ -274,6 +275,35 @@ func TestParseCommitMade(t *testing.T) {
 	}
 }
 
+func TestParse_Questions(t *testing.T) {
+	output := `PROGRAMMATOR_STATUS:
+  phase_completed: null
+  status: BLOCKED
+  files_changed: []
+  summary: "Need a decision"
+  error: "Two incompatible ways to store sessions"
+  questions:
+    - "Should sessions be stored in Redis or Postgres?"
+    - "Is it OK to drop the legacy cookie format?"
+`
+	got, err := Parse(output)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	want := []string{
+		"Should sessions be stored in Redis or Postgres?",
+		"Is it OK to drop the legacy cookie format?",
+	}
+	if len(got.Questions) != len(want) {
+		t.Fatalf("Questions = %q, want %q", got.Questions, want)
+	}
+	for i := range want {
+		if got.Questions[i] != want[i] {
+			t.Errorf("Questions[%d] = %q, want %q", i, got.Questions[i], want[i])
+		}
+	}
+}
+
 func TestParseDirect(t *testing.T) {
 	yaml := `phase_completed: "Phase 1"
 status: CONTINUE
@@ -367,3 +397,61 @@ func TestStatusString(t *testing.T) {
 		t.Errorf("protocol.StatusBlocked.String() = %q, want BLOCKED", protocol.StatusBlocked.String())
 	}
 }
+
+func TestParseKey(t *testing.T) {
+	output := "Test output quoted from a fixture:\n\n" +
+		"PROGRAMMATOR_STATUS:\n  phase_completed: null\n  status: BLOCKED\n  files_changed: []\n  summary: \"fixture\"\n" +
+		"```\n\nDone.\n\n```\n" +
+		"PROGRAMMATOR_STATUS_3F9A1C:\n  protocol: 2\n  phase_completed: \"Phase 1\"\n  status: CONTINUE\n  files_changed:\n    - main.go\n  summary: \"real\"\n```"
+
+	status, err := ParseKey(output, "PROGRAMMATOR_STATUS_3F9A1C")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Summary != "real" || status.Protocol != 2 || status.Status != protocol.StatusContinue {
+		t.Errorf("got %+v, want the nonce-keyed block", status)
+	}
+
+	// The plain key still parses; the nonce-keyed block does not match it.
+	status, err = Parse(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Summary != "fixture" || status.Protocol != 0 {
+		t.Errorf("got %+v, want the plain block", status)
+	}
+
+	// Without a block under the run's key, a plain block is used (executor
+	// timeouts, templates written for the plain key).
+	status, err = ParseKey("PROGRAMMATOR_STATUS:\n  status: DONE\n  summary: \"old template\"", "PROGRAMMATOR_STATUS_3F9A1C")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status == nil || status.Status != protocol.StatusDone {
+		t.Errorf("got %+v, want the plain block as fallback", status)
+	}
+
+	status, err = ParseKey("no block here", "PROGRAMMATOR_STATUS_3F9A1C")
+	if err != nil || status != nil {
+		t.Errorf("got %+v, %v; want nil, nil", status, err)
+	}
+}
+
+func TestParse_LastBlockWins(t *testing.T) {
+	output := "PROGRAMMATOR_STATUS:\n  status: BLOCKED\n  summary: \"quoted\"\n```\n\n" +
+		"PROGRAMMATOR_STATUS:\n  status: CONTINUE\n  summary: \"final\"\n```"
+	status, err := Parse(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Summary != "final" {
+		t.Errorf("Summary = %q, want the last block", status.Summary)
+	}
+}
+
+func TestParse_NewerProtocol(t *testing.T) {
+	_, err := Parse("PROGRAMMATOR_STATUS:\n  protocol: 3\n  status: DONE\n")
+	if err == nil || !strings.Contains(err.Error(), "protocol version 3, newer than the supported 2") {
+		t.Errorf("err = %v, want a protocol version error", err)
+	}
+}
@@ -0,0 +1,115 @@
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// IndexFile is the index of the completed plans directory.
+const IndexFile = "index.json"
+
+// IndexEntry records a completed plan and the run that completed it.
+type IndexEntry struct {
+	File         string    `json:"file"` // plan file name in the completed directory
+	Title        string    `json:"title"`
+	CompletedAt  time.Time `json:"completed_at"`
+	Iterations   int       `json:"iterations"`
+	Duration     float64   `json:"duration_seconds"`
+	FilesChanged int       `json:"files_changed"`
+	Branch       string    `json:"branch,omitempty"`
+	Labels       []string  `json:"labels,omitempty"`
+	RunID        string    `json:"run_id,omitempty"`
+}
+
+// Index lists the plans in a completed plans directory, oldest first.
+type Index struct {
+	Plans []IndexEntry `json:"plans"`
+}
+
+// LoadIndex reads the index of the completed plans directory dir. A
+// directory without one has an empty index.
+func LoadIndex(dir string) (*Index, error) {
+	ix := &Index{}
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile)) //nolint:gosec // completed plans directory from config
+	if errors.Is(err, os.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read plan index: %w", err)
+	}
+	if err := json.Unmarshal(data, ix); err != nil {
+		return nil, fmt.Errorf("parse plan index: %w", err)
+	}
+	return ix, nil
+}
+
+// AddToIndex records entry in the index of dir, replacing an earlier entry
+// for the same file.
+func AddToIndex(dir string, entry IndexEntry) error {
+	ix, err := LoadIndex(dir)
+	if err != nil {
+		return err
+	}
+	ix.Plans = slices.DeleteFunc(ix.Plans, func(e IndexEntry) bool { return e.File == entry.File })
+	ix.Plans = append(ix.Plans, entry)
+
+	data, err := json.MarshalIndent(ix, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode plan index: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".index-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("write plan index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("write plan index: %w", err)
+	}
+	return os.Rename(tmpName, filepath.Join(dir, IndexFile))
+}
+
+// Search returns the entries of the index of dir whose title, file name,
+// labels, or plan content contain every word of query, ignoring case,
+// newest first. An empty query returns every entry.
+func Search(dir, query string) ([]IndexEntry, error) {
+	ix, err := LoadIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	words := strings.Fields(strings.ToLower(query))
+
+	var matches []IndexEntry
+	for _, e := range ix.Plans {
+		text := strings.ToLower(e.Title + "\n" + e.File + "\n" + strings.Join(e.Labels, "\n"))
+		if !containsAll(text, words) {
+			content, err := os.ReadFile(filepath.Join(dir, e.File)) //nolint:gosec // file listed in the index
+			if err != nil || !containsAll(strings.ToLower(string(content)), words) {
+				continue
+			}
+		}
+		matches = append(matches, e)
+	}
+	slices.SortStableFunc(matches, func(a, b IndexEntry) int { return b.CompletedAt.Compare(a.CompletedAt) })
+	return matches, nil
+}
+
+func containsAll(text string, words []string) bool {
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return true
+}
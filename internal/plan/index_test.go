@@ -0,0 +1,57 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_AddAndSearch(t *testing.T) {
+	dir := t.TempDir()
+
+	ix, err := LoadIndex(dir)
+	require.NoError(t, err)
+	assert.Empty(t, ix.Plans, "no index yet")
+
+	day := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cache.md"), []byte("# Plan: Add caching\n\nUse an LRU for parsed configs.\n"), 0o644))
+	require.NoError(t, AddToIndex(dir, IndexEntry{File: "cache.md", Title: "Add caching", CompletedAt: day, Iterations: 3}))
+	require.NoError(t, AddToIndex(dir, IndexEntry{File: "auth.md", Title: "Rotate auth tokens", CompletedAt: day.Add(24 * time.Hour), Labels: []string{"security"}}))
+	require.NoError(t, AddToIndex(dir, IndexEntry{File: "cache.md", Title: "Add caching", CompletedAt: day.Add(48 * time.Hour), Iterations: 5}))
+
+	ix, err = LoadIndex(dir)
+	require.NoError(t, err)
+	require.Len(t, ix.Plans, 2, "a plan completed again replaces its entry")
+
+	all, err := Search(dir, "")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "cache.md", all[0].File, "newest first")
+	assert.Equal(t, 5, all[0].Iterations)
+
+	for query, want := range map[string][]string{
+		"CACHING":         {"cache.md"},
+		"security tokens": {"auth.md"},
+		"lru configs":     {"cache.md"}, // plan content
+		"caching tokens":  nil,
+	} {
+		matches, err := Search(dir, query)
+		require.NoError(t, err)
+		var files []string
+		for _, m := range matches {
+			files = append(files, m.File)
+		}
+		assert.Equal(t, want, files, query)
+	}
+}
+
+func TestLoadIndex_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, IndexFile), []byte("{"), 0o644))
+	_, err := LoadIndex(dir)
+	require.ErrorContains(t, err, "parse plan index")
+}
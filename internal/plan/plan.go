@@ -8,7 +8,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
 )
 
 // Sentinel errors for plan operations.
@@ -19,12 +23,25 @@ var (
 	ErrNoFilePath = errors.New("plan has no file path")
 	// ErrDestinationExists is returned when the move destination already exists.
 	ErrDestinationExists = errors.New("destination file already exists")
+	// ErrHasTasks is returned when tasks are added to a plan that already has some.
+	ErrHasTasks = errors.New("plan already has tasks")
+	// ErrTaskIndex is returned when a task index is out of range.
+	ErrTaskIndex = errors.New("task index out of range")
 )
 
 // Task represents a single task within a plan.
 type Task struct {
 	Name      string
 	Completed bool
+	// Depth is the task's nesting level: 0 for top-level checkboxes, 1 for
+	// indented sub-items, and so on. Sub-tasks follow their parent in Tasks.
+	Depth int
+	// DependsOn holds 0-based indices of tasks that must complete first.
+	// Nil means the task follows every task before it.
+	DependsOn []int
+	// Access is the tool access requested with "<!-- access: read-only -->",
+	// lowercased; empty when the task has no access annotation.
+	Access string
 }
 
 // Plan represents a parsed plan file.
@@ -42,19 +59,12 @@ type Plan struct {
 }
 
 var (
-	titleRegex                  = regexp.MustCompile(`(?m)^#\s+(?:Plan:\s*)?(.+)$`)
-	taskRegex                   = regexp.MustCompile(`(?m)^-\s+\[([ xX])\]\s+(.+)$`)
-	validationRegex             = regexp.MustCompile("(?m)^-\\s+`([^`]+)`\\s*$")
-	normalizePrefixRegex        = regexp.MustCompile(`^(task|step|phase)\s*\d+[:.]\s*`)
-	escapeSequenceCanonicalizer = strings.NewReplacer(
-		`\\n`, `\n`,
-		`\\r`, `\r`,
-		`\\t`, `\t`,
-		"\r\n", "\n",
-		"\r", "\n",
-		"\n", `\n`,
-		"\t", `\t`,
-	)
+	titleRegex      = regexp.MustCompile(`(?m)^#\s+(?:Plan:\s*)?(.+)$`)
+	taskRegex       = regexp.MustCompile(`(?m)^([ \t]*)-\s+\[([ xX])\]\s+(.+)$`)
+	validationRegex = regexp.MustCompile("(?m)^-\\s+`([^`]+)`\\s*$")
+	dependsRegex    = regexp.MustCompile(`\s*<!--\s*depends:\s*([^>]*?)\s*-->\s*$`)
+	accessRegex     = regexp.MustCompile(`\s*<!--\s*access:\s*([^>]*?)\s*-->`)
+	taskNumberRegex = regexp.MustCompile(`\d+`)
 )
 
 // ParseFile reads and parses a plan file from disk.
@@ -132,33 +142,102 @@ func parseValidationCommands(content string) []string {
 	return commands
 }
 
-// parseTasks extracts all checkbox tasks from the plan.
+// parseTasks extracts all checkbox tasks from the plan. Checkboxes of the
+// acceptance criteria section are criteria, not tasks.
 func parseTasks(content string) []Task {
-	matches := taskRegex.FindAllStringSubmatch(content, -1)
-	tasks := make([]Task, 0, len(matches))
+	lines := strings.Split(content, "\n")
+	criteria := domain.AcceptanceLines(lines)
+	tasks := make([]Task, 0)
+	var indents []string
 
-	for _, match := range matches {
-		if len(match) > 2 {
+	for n, line := range lines {
+		if criteria[n] {
+			continue
+		}
+		if match := taskRegex.FindStringSubmatch(line); match != nil {
+			raw, access := parseTaskAccess(match[3])
+			name, deps := parseTaskDependencies(raw)
 			tasks = append(tasks, Task{
-				Name:      strings.TrimSpace(match[2]),
-				Completed: match[1] != " ",
+				Name:      name,
+				Completed: match[2] != " ",
+				DependsOn: deps,
+				Access:    access,
 			})
+			indents = append(indents, match[1])
 		}
 	}
 
+	for i, depth := range domain.NestingDepths(indents) {
+		tasks[i].Depth = depth
+	}
 	return tasks
 }
 
-// CurrentTask returns the first incomplete task, or nil if all are done.
-func (p *Plan) CurrentTask() *Task {
-	for i := range p.Tasks {
-		if !p.Tasks[i].Completed {
-			return &p.Tasks[i]
+// parseTaskAccess strips an "<!-- access: read-only -->" annotation from a
+// task line and returns the lowercased access level.
+func parseTaskAccess(raw string) (string, string) {
+	match := accessRegex.FindStringSubmatchIndex(raw)
+	if match == nil {
+		return raw, ""
+	}
+	access := strings.ToLower(strings.TrimSpace(raw[match[2]:match[3]]))
+	return raw[:match[0]] + raw[match[1]:], access
+}
+
+// parseTaskDependencies strips a trailing "<!-- depends: 1, 3 -->" annotation
+// from a task line. Numbers are 1-based task positions; "none" marks a task
+// with no dependencies. Unparseable entries are ignored.
+func parseTaskDependencies(raw string) (string, []int) {
+	match := dependsRegex.FindStringSubmatchIndex(raw)
+	if match == nil {
+		return strings.TrimSpace(raw), nil
+	}
+
+	name := strings.TrimSpace(raw[:match[0]])
+	spec := strings.TrimSpace(raw[match[2]:match[3]])
+
+	deps := []int{}
+	if strings.EqualFold(spec, "none") {
+		return name, deps
+	}
+	for field := range strings.FieldsFuncSeq(spec, func(r rune) bool { return r == ',' || r == ' ' }) {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 {
+			continue
 		}
+		deps = append(deps, n-1)
+	}
+	return name, deps
+}
+
+// CurrentTask returns the first incomplete task without incomplete
+// sub-tasks, or nil if all are done.
+func (p *Plan) CurrentTask() *Task {
+	if i := p.tree().CurrentPhaseIndex(); i >= 0 {
+		return &p.Tasks[i]
 	}
 	return nil
 }
 
+// tree returns the task hierarchy as a work item, for the nesting helpers.
+func (p *Plan) tree() *domain.WorkItem {
+	phases := make([]domain.Phase, len(p.Tasks))
+	for i, t := range p.Tasks {
+		phases[i] = domain.Phase{Name: t.Name, Completed: t.Completed, Depth: t.Depth}
+	}
+	return &domain.WorkItem{Phases: phases}
+}
+
+// completeTask checks task i and its sub-tasks, then every enclosing task
+// whose sub-tasks are now all checked.
+func (p *Plan) completeTask(i int) {
+	w := p.tree()
+	w.CompletePhase(i)
+	for j := range p.Tasks {
+		p.Tasks[j].Completed = w.Phases[j].Completed
+	}
+}
+
 // AllTasksComplete returns true if all tasks are completed.
 func (p *Plan) AllTasksComplete() bool {
 	for _, t := range p.Tasks {
@@ -169,18 +248,39 @@ func (p *Plan) AllTasksComplete() bool {
 	return len(p.Tasks) > 0
 }
 
-// MarkTaskComplete marks a task as completed by name.
+// MarkTaskComplete marks a task as completed by name, along with its
+// sub-tasks and any parent task left with no open sub-tasks.
 // Returns an error if the task is not found or already completed.
 func (p *Plan) MarkTaskComplete(taskName string) error {
-	normalizedName := normalizeTaskName(taskName)
+	i := p.findOpenTask(taskName)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskName)
+	}
+	p.completeTask(i)
+	return nil
+}
+
+// ReopenTasks marks the tasks at indices, into Tasks, as not completed.
+// Call SaveFile to write the result.
+func (p *Plan) ReopenTasks(indices []int) {
+	for _, i := range indices {
+		if i >= 0 && i < len(p.Tasks) {
+			p.Tasks[i].Completed = false
+		}
+	}
+}
+
+// findOpenTask returns the index of the incomplete task matching taskName,
+// or -1 if there is none.
+func (p *Plan) findOpenTask(taskName string) int {
+	normalizedName := domain.NormalizePhaseName(taskName)
 
 	// First pass: exact match
 	for i := range p.Tasks {
 		if !p.Tasks[i].Completed {
-			existingName := normalizeTaskName(p.Tasks[i].Name)
+			existingName := domain.NormalizePhaseName(p.Tasks[i].Name)
 			if existingName == normalizedName {
-				p.Tasks[i].Completed = true
-				return nil
+				return i
 			}
 		}
 	}
@@ -188,10 +288,9 @@ func (p *Plan) MarkTaskComplete(taskName string) error {
 	// Second pass: existing task name contains the query (not vice versa)
 	for i := range p.Tasks {
 		if !p.Tasks[i].Completed {
-			existingName := normalizeTaskName(p.Tasks[i].Name)
+			existingName := domain.NormalizePhaseName(p.Tasks[i].Name)
 			if strings.Contains(existingName, normalizedName) {
-				p.Tasks[i].Completed = true
-				return nil
+				return i
 			}
 		}
 	}
@@ -199,50 +298,228 @@ func (p *Plan) MarkTaskComplete(taskName string) error {
 	// Third pass: query contains existing task name (Claude elaborated)
 	for i := range p.Tasks {
 		if !p.Tasks[i].Completed {
-			existingName := normalizeTaskName(p.Tasks[i].Name)
+			existingName := domain.NormalizePhaseName(p.Tasks[i].Name)
 			if strings.Contains(normalizedName, existingName) {
-				p.Tasks[i].Completed = true
-				return nil
+				return i
 			}
 		}
 	}
 
-	return fmt.Errorf("%w: %s", ErrTaskNotFound, taskName)
+	return -1
+}
+
+// SplitTask adds subtasks as unchecked sub-items of an incomplete task,
+// after any sub-items it already has, so the first new subtask becomes the
+// current task. Dependency annotations of later tasks are renumbered.
+// Call SaveFile to write the result.
+func (p *Plan) SplitTask(taskName string, subtasks []string) error {
+	if len(subtasks) == 0 {
+		return errors.New("no subtasks")
+	}
+	i := p.findOpenTask(taskName)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskName)
+	}
+	last := i
+	for last+1 < len(p.Tasks) && p.Tasks[last+1].Depth > p.Tasks[i].Depth {
+		last++
+	}
+
+	lines, taskLines, indents, err := p.taskLines()
+	if err != nil {
+		return err
+	}
+
+	indent := indents[i] + "  "
+	if last > i {
+		indent = indents[i+1]
+	}
+	added := make([]string, 0, len(subtasks))
+	for _, st := range subtasks {
+		if st = cleanTaskName(st); st != "" {
+			added = append(added, indent+"- [ ] "+st)
+		}
+	}
+	if len(added) == 0 {
+		return errors.New("no subtasks")
+	}
+
+	for _, n := range taskLines[last+1:] {
+		lines[n] = shiftDependencies(lines[n], last+1, len(added))
+	}
+	at := taskLines[last] + 1
+	lines = append(lines[:at], append(added, lines[at:]...)...)
+
+	p.RawContent = strings.Join(lines, "\n")
+	p.Tasks = parseTasks(p.RawContent)
+	return nil
 }
 
-// normalizeTaskName strips common prefixes and normalizes for comparison.
-func normalizeTaskName(s string) string {
-	s = strings.ToLower(s)
-	s = strings.TrimSpace(s)
-	// Remove common prefixes like "Task 1:", "Step 2:", "Phase 1:", etc.
-	s = normalizePrefixRegex.ReplaceAllString(s, "")
-	s = escapeSequenceCanonicalizer.Replace(s)
-	s = strings.Join(strings.Fields(s), " ")
-	return s
+// AddTasks appends tasks under a "## Plan" heading to a plan that has none.
+// Call SaveFile to write the result.
+func (p *Plan) AddTasks(tasks []string) error {
+	if len(p.Tasks) > 0 {
+		return ErrHasTasks
+	}
+	content := domain.AppendPhaseList(p.RawContent, tasks)
+	parsed := parseTasks(content)
+	if len(parsed) == 0 {
+		return errors.New("no tasks")
+	}
+	p.RawContent = content
+	p.Tasks = parsed
+	return nil
 }
 
-// SaveFile writes the plan back to its file, updating checkbox states.
-func (p *Plan) SaveFile() error {
-	if p.FilePath == "" {
-		return ErrNoFilePath
+// AppendTask adds an unchecked top-level task after the last task, or under
+// a "## Plan" heading when the plan has none. Call SaveFile to write the
+// result.
+func (p *Plan) AppendTask(name string) error {
+	return p.InsertTask(len(p.Tasks), name)
+}
+
+// InsertTask adds an unchecked task before the task at index, into Tasks,
+// at the same nesting depth; index len(Tasks) appends a top-level task.
+// Dependency annotations are renumbered. Call SaveFile to write the result.
+func (p *Plan) InsertTask(index int, name string) error {
+	if name = cleanTaskName(name); name == "" {
+		return errors.New("empty task name")
+	}
+	if index < 0 || index > len(p.Tasks) {
+		return fmt.Errorf("%w: %d", ErrTaskIndex, index)
+	}
+	if len(p.Tasks) == 0 {
+		return p.AddTasks([]string{name})
+	}
+	lines, taskLines, indents, err := p.taskLines()
+	if err != nil {
+		return err
 	}
 
+	at, indent := taskLines[len(taskLines)-1]+1, indents[0]
+	if index < len(p.Tasks) {
+		at, indent = taskLines[index], indents[index]
+	}
+	for _, n := range taskLines {
+		lines[n] = shiftDependencies(lines[n], index, 1)
+	}
+	lines = slices.Insert(lines, at, indent+"- [ ] "+name)
+
+	p.RawContent = strings.Join(lines, "\n")
+	p.Tasks = parseTasks(p.RawContent)
+	return nil
+}
+
+// RemoveTask deletes the task at index, into Tasks, with its sub-tasks.
+// Dependency annotations are renumbered, dropping references to the removed
+// tasks. Call SaveFile to write the result.
+func (p *Plan) RemoveTask(index int) error {
+	if index < 0 || index >= len(p.Tasks) {
+		return fmt.Errorf("%w: %d", ErrTaskIndex, index)
+	}
+	last := index
+	for last+1 < len(p.Tasks) && p.Tasks[last+1].Depth > p.Tasks[index].Depth {
+		last++
+	}
+	lines, taskLines, _, err := p.taskLines()
+	if err != nil {
+		return err
+	}
+
+	for _, n := range taskLines {
+		lines[n] = dropDependencies(lines[n], index+1, last+1)
+	}
+	for j := last; j >= index; j-- {
+		lines = slices.Delete(lines, taskLines[j], taskLines[j]+1)
+	}
+
+	p.RawContent = strings.Join(lines, "\n")
+	p.Tasks = parseTasks(p.RawContent)
+	return nil
+}
+
+// taskLines splits RawContent into lines and returns them with the line
+// numbers and indentation of the task checkboxes, in task order.
+func (p *Plan) taskLines() ([]string, []int, []string, error) {
 	lines := strings.Split(p.RawContent, "\n")
+	criteria := domain.AcceptanceLines(lines)
+	var taskLines []int
+	var indents []string
+	for n, line := range lines {
+		if criteria[n] {
+			continue
+		}
+		if match := taskRegex.FindStringSubmatchIndex(line); match != nil {
+			taskLines = append(taskLines, n)
+			indents = append(indents, line[:match[3]])
+		}
+	}
+	if len(taskLines) != len(p.Tasks) {
+		return nil, nil, nil, fmt.Errorf("plan content does not match its tasks")
+	}
+	return lines, taskLines, indents, nil
+}
 
-	// Track which task index we're matching
-	taskIdx := 0
+// cleanTaskName collapses whitespace in a task name to single spaces, so it
+// fits on one checkbox line.
+func cleanTaskName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
 
-	for i, line := range lines {
-		if match := taskRegex.FindStringSubmatch(line); match != nil {
-			if taskIdx < len(p.Tasks) {
-				task := p.Tasks[taskIdx]
-				if task.Completed && match[1] == " " {
-					lines[i] = strings.Replace(line, "- [ ]", "- [x]", 1)
-				}
-				taskIdx++
-			}
+// dropDependencies removes the 1-based task positions from through to from
+// a task line's "<!-- depends: ... -->" annotation and moves the positions
+// above them down. A task left with no dependencies gets "none", so it does
+// not start waiting on every task before it.
+func dropDependencies(line string, from, to int) string {
+	match := dependsRegex.FindStringSubmatchIndex(line)
+	if match == nil {
+		return line
+	}
+	numbers := taskNumberRegex.FindAllString(line[match[2]:match[3]], -1)
+	if len(numbers) == 0 {
+		return line
+	}
+	kept := make([]string, 0, len(numbers))
+	for _, s := range numbers {
+		n, err := strconv.Atoi(s)
+		switch {
+		case err != nil || n < from:
+			kept = append(kept, s)
+		case n > to:
+			kept = append(kept, strconv.Itoa(n-(to-from+1)))
 		}
 	}
+	spec := strings.Join(kept, ", ")
+	if len(kept) == 0 {
+		spec = "none"
+	}
+	return line[:match[2]] + spec + line[match[3]:]
+}
+
+// shiftDependencies adds k to the 1-based task positions above after in a
+// task line's "<!-- depends: ... -->" annotation.
+func shiftDependencies(line string, after, k int) string {
+	match := dependsRegex.FindStringSubmatchIndex(line)
+	if match == nil {
+		return line
+	}
+	spec := taskNumberRegex.ReplaceAllStringFunc(line[match[2]:match[3]], func(s string) string {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= after {
+			return s
+		}
+		return strconv.Itoa(n + k)
+	})
+	return line[:match[2]] + spec + line[match[3]:]
+}
+
+// SaveFile writes the plan back to its file, updating checkbox states.
+func (p *Plan) SaveFile() error {
+	if p.FilePath == "" {
+		return ErrNoFilePath
+	}
+
+	content := p.Content()
 
 	// Write atomically: temp file + rename to avoid data loss on partial write.
 	// Preserve original file permissions on the temp file.
@@ -258,7 +535,7 @@ func (p *Plan) SaveFile() error {
 	}
 	tmpName := tmp.Name()
 
-	if _, err := tmp.Write([]byte(strings.Join(lines, "\n"))); err != nil {
+	if _, err := tmp.Write([]byte(content)); err != nil {
 		tmp.Close()
 		os.Remove(tmpName)
 		return fmt.Errorf("write temp file: %w", err)
@@ -275,6 +552,36 @@ func (p *Plan) SaveFile() error {
 	return os.Rename(tmpName, p.FilePath)
 }
 
+// Content returns RawContent with the checkboxes of completed tasks ticked
+// and those of reopened tasks cleared.
+func (p *Plan) Content() string {
+	lines := strings.Split(p.RawContent, "\n")
+	criteria := domain.AcceptanceLines(lines)
+
+	// Track which task index we're matching
+	taskIdx := 0
+
+	for i, line := range lines {
+		if criteria[i] {
+			continue
+		}
+		if match := taskRegex.FindStringSubmatch(line); match != nil {
+			if taskIdx < len(p.Tasks) {
+				task := p.Tasks[taskIdx]
+				switch {
+				case task.Completed && match[2] == " ":
+					lines[i] = strings.Replace(line, "- [ ]", "- [x]", 1)
+				case !task.Completed && match[2] != " ":
+					lines[i] = strings.Replace(line, "- ["+match[2]+"]", "- [ ]", 1)
+				}
+				taskIdx++
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 // ID returns the plan's identifier (base filename without extension).
 func (p *Plan) ID() string {
 	if p.FilePath == "" {
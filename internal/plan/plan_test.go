@@ -516,3 +516,254 @@ func TestMoveTo_NoFilePath(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, ErrNoFilePath))
 }
+
+func TestParse_TaskDependencies(t *testing.T) {
+	content := `# Plan
+
+- [ ] Task 1: Shared setup
+- [ ] Task 2: API handler <!-- depends: 1 -->
+- [ ] Task 3: CLI flag <!-- depends: 1 -->
+- [ ] Task 4: Docs <!-- depends: none -->
+- [ ] Task 5: Wire up <!-- depends: 2, 3 -->
+- [ ] Task 6: Sequential
+`
+	p, err := Parse("test.md", content)
+	require.NoError(t, err)
+	require.Len(t, p.Tasks, 6)
+
+	tests := []struct {
+		name string
+		deps []int
+	}{
+		{"Task 1: Shared setup", nil},
+		{"Task 2: API handler", []int{0}},
+		{"Task 3: CLI flag", []int{0}},
+		{"Task 4: Docs", []int{}},
+		{"Task 5: Wire up", []int{1, 2}},
+		{"Task 6: Sequential", nil},
+	}
+	for i, tc := range tests {
+		assert.Equal(t, tc.name, p.Tasks[i].Name)
+		assert.Equal(t, tc.deps, p.Tasks[i].DependsOn, tc.name)
+	}
+}
+
+func TestParse_TaskAccess(t *testing.T) {
+	content := `# Plan
+
+- [ ] Task 1: Investigate the crash <!-- access: read-only -->
+- [ ] Task 2: Fix it <!-- access: Full --> <!-- depends: 1 -->
+- [ ] Task 3: Docs <!-- depends: 2 --> <!-- access: no-bash -->
+- [ ] Task 4: Plain
+`
+	p, err := Parse("test.md", content)
+	require.NoError(t, err)
+	require.Len(t, p.Tasks, 4)
+
+	tests := []struct {
+		name   string
+		access string
+		deps   []int
+	}{
+		{"Task 1: Investigate the crash", "read-only", nil},
+		{"Task 2: Fix it", "full", []int{0}},
+		{"Task 3: Docs", "no-bash", []int{1}},
+		{"Task 4: Plain", "", nil},
+	}
+	for i, tc := range tests {
+		assert.Equal(t, tc.name, p.Tasks[i].Name)
+		assert.Equal(t, tc.access, p.Tasks[i].Access, tc.name)
+		assert.Equal(t, tc.deps, p.Tasks[i].DependsOn, tc.name)
+	}
+}
+
+func TestSaveFile_PreservesDependencyAnnotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.md")
+	content := "# Plan\n\n- [ ] Task 1: A\n- [ ] Task 2: B <!-- depends: none -->\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	p, err := ParseFile(path)
+	require.NoError(t, err)
+	require.NoError(t, p.MarkTaskComplete("Task 2: B"))
+	require.NoError(t, p.SaveFile())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "- [x] Task 2: B <!-- depends: none -->")
+}
+
+func TestParse_IndentedSubtasks(t *testing.T) {
+	content := `# Plan
+
+- [ ] Task 1: Backend
+  - [x] Schema
+  - [ ] API
+    - [ ] Handlers
+- [ ] Task 2: Frontend
+`
+	p, err := Parse("test.md", content)
+	require.NoError(t, err)
+	require.Len(t, p.Tasks, 5)
+
+	depths := make([]int, len(p.Tasks))
+	for i, task := range p.Tasks {
+		depths[i] = task.Depth
+	}
+	assert.Equal(t, []int{0, 1, 1, 2, 0}, depths)
+	assert.Equal(t, "Handlers", p.CurrentTask().Name)
+}
+
+func TestParse_AcceptanceCriteria(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.md")
+	content := "# Plan\n\n- [ ] Task 1: Cache\n\n## Acceptance Criteria\n- [ ] Hits skip the database\n\n## Tasks\n- [ ] Task 2: Docs\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	p, err := ParseFile(path)
+	require.NoError(t, err)
+	require.Len(t, p.Tasks, 2)
+	assert.Equal(t, "Task 2: Docs", p.Tasks[1].Name)
+
+	require.NoError(t, p.AppendTask("Task 3: Release"))
+	require.NoError(t, p.MarkTaskComplete("Task 1: Cache"))
+	require.NoError(t, p.SaveFile())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "- [x] Task 1: Cache\n\n## Acceptance Criteria\n- [ ] Hits skip the database\n")
+	assert.Contains(t, string(data), "- [ ] Task 2: Docs\n- [ ] Task 3: Release")
+}
+
+func TestMarkTaskComplete_CompletesParent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.md")
+	content := "# Plan\n\n- [ ] Task 1: Backend\n  - [x] Schema\n  - [ ] API\n- [ ] Task 2: Frontend\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	p, err := ParseFile(path)
+	require.NoError(t, err)
+	require.NoError(t, p.MarkTaskComplete("API"))
+	assert.True(t, p.Tasks[0].Completed)
+	assert.False(t, p.Tasks[3].Completed)
+	require.NoError(t, p.SaveFile())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan\n\n- [x] Task 1: Backend\n  - [x] Schema\n  - [x] API\n- [ ] Task 2: Frontend\n", string(data))
+}
+
+func TestReopenTasks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.md")
+	content := "# Plan\n\n- [x] Task 1: Backend\n  - [X] Schema\n- [x] Task 2: Frontend\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	p, err := ParseFile(path)
+	require.NoError(t, err)
+	p.ReopenTasks([]int{0, 1, 7})
+	assert.Equal(t, "Schema", p.CurrentTask().Name)
+	require.NoError(t, p.SaveFile())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan\n\n- [ ] Task 1: Backend\n  - [ ] Schema\n- [x] Task 2: Frontend\n", string(data))
+}
+
+func TestSplitTask(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.md")
+	content := "# Plan\n\n- [ ] Task 1: Backend\n- [ ] Task 2: Frontend <!-- depends: 1 -->\n- [ ] Task 3: Docs <!-- depends: 1, 2 -->\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	p, err := ParseFile(path)
+	require.NoError(t, err)
+	require.NoError(t, p.SplitTask("Task 1: Backend", []string{"Schema", "  API\n  and handlers "}))
+	require.NoError(t, p.SaveFile())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan\n\n- [ ] Task 1: Backend\n  - [ ] Schema\n  - [ ] API and handlers\n"+
+		"- [ ] Task 2: Frontend <!-- depends: 1 -->\n- [ ] Task 3: Docs <!-- depends: 1, 4 -->\n", string(data))
+
+	require.Len(t, p.Tasks, 5)
+	assert.Equal(t, 1, p.Tasks[1].Depth)
+	assert.Equal(t, []int{0, 3}, p.Tasks[4].DependsOn)
+	assert.Equal(t, "Schema", p.CurrentTask().Name)
+}
+
+func TestSplitTask_AfterExistingSubtasks(t *testing.T) {
+	content := "# Plan\n\n- [ ] Backend\n    - [x] Schema\n- [ ] Frontend\n"
+	p, err := Parse("test.md", content)
+	require.NoError(t, err)
+
+	require.NoError(t, p.SplitTask("Backend", []string{"API", "Handlers"}))
+	assert.Equal(t, "# Plan\n\n- [ ] Backend\n    - [x] Schema\n    - [ ] API\n    - [ ] Handlers\n- [ ] Frontend\n", p.RawContent)
+	assert.Equal(t, "API", p.CurrentTask().Name)
+}
+
+func TestSplitTask_Errors(t *testing.T) {
+	p, err := Parse("test.md", "# Plan\n\n- [x] Done\n- [ ] Open\n")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.SplitTask("Done", []string{"a"}), ErrTaskNotFound)
+	assert.Error(t, p.SplitTask("Open", nil))
+	assert.Error(t, p.SplitTask("Open", []string{" ", ""}))
+}
+
+func TestInsertTask(t *testing.T) {
+	content := "# Plan\n\n- [x] Backend\n  - [x] Schema\n- [ ] Frontend <!-- depends: 1 -->\n- [ ] Docs <!-- depends: 2, 3 -->\n"
+	p, err := Parse("test.md", content)
+	require.NoError(t, err)
+
+	require.NoError(t, p.InsertTask(1, " Migrations\n"))
+	require.NoError(t, p.AppendTask("Release"))
+	assert.Equal(t, "# Plan\n\n- [x] Backend\n  - [ ] Migrations\n  - [x] Schema\n- [ ] Frontend <!-- depends: 1 -->\n"+
+		"- [ ] Docs <!-- depends: 3, 4 -->\n- [ ] Release\n", p.RawContent)
+	require.Len(t, p.Tasks, 6)
+	assert.Equal(t, 1, p.Tasks[1].Depth)
+	assert.Equal(t, 0, p.Tasks[5].Depth)
+	assert.Equal(t, []int{2, 3}, p.Tasks[4].DependsOn)
+	assert.Equal(t, "Migrations", p.CurrentTask().Name)
+
+	assert.ErrorIs(t, p.InsertTask(7, "Late"), ErrTaskIndex)
+	assert.ErrorIs(t, p.InsertTask(-1, "Early"), ErrTaskIndex)
+	assert.Error(t, p.AppendTask("  "))
+
+	empty, err := Parse("test.md", "# Plan\n")
+	require.NoError(t, err)
+	require.NoError(t, empty.AppendTask("First"))
+	assert.Equal(t, "# Plan\n\n## Plan\n\n- [ ] First\n", empty.RawContent)
+}
+
+func TestRemoveTask(t *testing.T) {
+	content := "# Plan\n\n- [ ] Backend\n  - [ ] Schema\n- [ ] Frontend <!-- depends: 2 -->\n- [ ] Docs <!-- depends: 1, 3 -->\n"
+	p, err := Parse("test.md", content)
+	require.NoError(t, err)
+
+	require.NoError(t, p.RemoveTask(0))
+	assert.Equal(t, "# Plan\n\n- [ ] Frontend <!-- depends: none -->\n- [ ] Docs <!-- depends: 1 -->\n", p.RawContent)
+	require.Len(t, p.Tasks, 2)
+	assert.Equal(t, []int{}, p.Tasks[0].DependsOn)
+	assert.Equal(t, []int{0}, p.Tasks[1].DependsOn)
+
+	require.NoError(t, p.RemoveTask(1))
+	assert.Equal(t, "# Plan\n\n- [ ] Frontend <!-- depends: none -->\n", p.RawContent)
+	assert.ErrorIs(t, p.RemoveTask(1), ErrTaskIndex)
+}
+
+func TestAddTasks(t *testing.T) {
+	p, err := Parse("test.md", "# Plan: Parser\n\nWrite a parser.\n")
+	require.NoError(t, err)
+
+	require.NoError(t, p.AddTasks([]string{"Lexer", "Parser"}))
+	assert.Equal(t, "# Plan: Parser\n\nWrite a parser.\n\n## Plan\n\n- [ ] Lexer\n- [ ] Parser\n", p.RawContent)
+	require.Len(t, p.Tasks, 2)
+	assert.Equal(t, "Lexer", p.CurrentTask().Name)
+
+	assert.ErrorIs(t, p.AddTasks([]string{"More"}), ErrHasTasks)
+
+	empty, err := Parse("test.md", "# Plan\n")
+	require.NoError(t, err)
+	assert.Error(t, empty.AddTasks([]string{"  "}))
+}
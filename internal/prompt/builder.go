@@ -3,6 +3,7 @@ package prompt
 
 import (
 	"bytes"
+	"cmp"
 	"fmt"
 	"strings"
 	"sync"
@@ -18,6 +19,10 @@ type Builder struct {
 	phasedTmpl      *template.Template
 	phaselessTmpl   *template.Template
 	reviewFirstTmpl *template.Template
+	docsTmpl        *template.Template
+	testsTmpl       *template.Template
+	statusKey       string // "" = protocol.StatusBlockKey
+	tokenBudget     int    // 0 = prompts are not shrunk
 }
 
 // NewBuilder creates a prompt builder from loaded prompts.
@@ -47,20 +52,64 @@ func NewBuilder(prompts *config.Prompts) (*Builder, error) {
 		return nil, fmt.Errorf("parse review_first template: %w", err)
 	}
 
+	docsTmpl, err := template.New("docs").Parse(prompts.Docs)
+	if err != nil {
+		return nil, fmt.Errorf("parse docs template: %w", err)
+	}
+
+	testsTmpl, err := template.New("tests").Parse(prompts.Tests)
+	if err != nil {
+		return nil, fmt.Errorf("parse tests template: %w", err)
+	}
+
 	return &Builder{
 		phasedTmpl:      phasedTmpl,
 		phaselessTmpl:   phaselessTmpl,
 		reviewFirstTmpl: reviewFirstTmpl,
+		docsTmpl:        docsTmpl,
+		testsTmpl:       testsTmpl,
 	}, nil
 }
 
+// WithStatusKey returns a copy of b whose prompts ask for a status block
+// starting with key, such as a per-run key from protocol.NonceStatusKey.
+func (b *Builder) WithStatusKey(key string) *Builder {
+	c := *b
+	c.statusKey = key
+	return &c
+}
+
+// WithTokenBudget returns a copy of b whose prompts leave out what the
+// executor can read itself when they would exceed budget tokens (see
+// EstimateTokens): task prompts list their context files by path instead of
+// inlining them, and review fix prompts drop the code excerpts. 0 builds
+// prompts in full.
+func (b *Builder) WithTokenBudget(budget int) *Builder {
+	c := *b
+	c.tokenBudget = budget
+	return &c
+}
+
+// StatusKey returns the key prompts ask the executor's status block to
+// start with.
+func (b *Builder) StatusKey() string {
+	return cmp.Or(b.statusKey, protocol.StatusBlockKey)
+}
+
 // Data contains the data for rendering prompt templates.
 type Data struct {
 	ID               string
 	Title            string
 	RawContent       string
-	CurrentPhase     string // Formatted phase name (e.g., "**Phase 1**" or "All phases complete")
+	CurrentPhase     string // Formatted phase name, with its parents for a nested phase (e.g., "Phase 1 › Step 2" or "All phases complete")
 	CurrentPhaseName string // Raw phase name for status block (e.g., "Phase 1" or "null")
+
+	ValidationCommands []string
+	ContextFiles       string            // Formatted context files referenced by the work item (or "")
+	Metadata           map[string]string // Custom ticket frontmatter fields, e.g. {{.Metadata.component}}
+
+	StatusKey       string // Key the status block starts with (PROGRAMMATOR_STATUS, or a per-run key)
+	ProtocolVersion int    // protocol.Version, for the status block's protocol field
 }
 
 // ReviewFixData contains the data for rendering review fix prompts.
@@ -69,47 +118,154 @@ type ReviewFixData struct {
 	Iteration      int
 	FilesList      string
 	IssuesMarkdown string
+	FileExcerpts   string // code around the issues, with line numbers (or "")
 	AutoCommit     bool
+
+	StatusKey       string
+	ProtocolVersion int
+}
+
+// DocsData contains the data for rendering the docs phase prompt.
+type DocsData struct {
+	ID                 string
+	Title              string
+	FilesList          string
+	ValidationCommands []string
+
+	StatusKey       string
+	ProtocolVersion int
+}
+
+// TestsData contains the data for rendering the coverage gate's prompt.
+type TestsData struct {
+	ID                 string
+	Title              string
+	Threshold          float64
+	CoverageGaps       string
+	FilesList          string
+	ValidationCommands []string
+
+	StatusKey       string
+	ProtocolVersion int
 }
 
 // Build creates a prompt from a work item.
 func (b *Builder) Build(w *domain.WorkItem) (string, error) {
 	data := Data{
-		ID:         w.ID,
-		Title:      w.Title,
-		RawContent: w.RawContent,
+		ID:                 w.ID,
+		Title:              w.Title,
+		RawContent:         w.RawContent,
+		ValidationCommands: w.ValidationCommands,
+		ContextFiles:       formatContextFiles(w.ContextFiles),
+		Metadata:           w.Metadata,
+		StatusKey:          b.StatusKey(),
+		ProtocolVersion:    protocol.Version,
 	}
 
 	// Use phaseless template when there are no phases
 	if !w.HasPhases() {
-		return b.render(b.phaselessTmpl, data)
+		return b.renderTask(b.phaselessTmpl, data, w.ContextFiles)
 	}
 
 	// Use phased template when phases exist
-	currentPhase := w.CurrentPhase()
-	if currentPhase != nil {
-		data.CurrentPhase = currentPhase.Name
-		data.CurrentPhaseName = currentPhase.Name
+	if idx := w.CurrentPhaseIndex(); idx >= 0 {
+		data.CurrentPhase = FormatPhasePath(w.PhasePath(idx))
+		data.CurrentPhaseName = w.Phases[idx].Name
 	} else {
 		data.CurrentPhase = "All phases complete"
 		data.CurrentPhaseName = protocol.NullPhase
 	}
 
-	return b.render(b.phasedTmpl, data)
+	return b.renderTask(b.phasedTmpl, data, w.ContextFiles)
+}
+
+// BuildForPhase creates a phased prompt targeting a specific phase rather than
+// the first incomplete one. Used when independent phases run concurrently.
+func (b *Builder) BuildForPhase(w *domain.WorkItem, phaseIdx int) (string, error) {
+	if phaseIdx < 0 || phaseIdx >= len(w.Phases) {
+		return "", fmt.Errorf("phase index %d out of range (%d phases)", phaseIdx, len(w.Phases))
+	}
+	name := w.Phases[phaseIdx].Name
+	return b.renderTask(b.phasedTmpl, Data{
+		ID:                 w.ID,
+		Title:              w.Title,
+		RawContent:         w.RawContent,
+		CurrentPhase:       FormatPhasePath(w.PhasePath(phaseIdx)),
+		CurrentPhaseName:   name,
+		ValidationCommands: w.ValidationCommands,
+		ContextFiles:       formatContextFiles(w.ContextFiles),
+		Metadata:           w.Metadata,
+		StatusKey:          b.StatusKey(),
+		ProtocolVersion:    protocol.Version,
+	}, w.ContextFiles)
 }
 
 // BuildReviewFirst creates a prompt for comprehensive review phase.
-func (b *Builder) BuildReviewFirst(baseBranch string, filesChanged []string, issuesMarkdown string, iteration int, autoCommit bool) (string, error) {
+// fileExcerpts, when set, is the code around the issues, which the executor
+// can then fix without reading the files.
+func (b *Builder) BuildReviewFirst(baseBranch string, filesChanged []string, issuesMarkdown, fileExcerpts string, iteration int, autoCommit bool) (string, error) {
 	data := ReviewFixData{
 		BaseBranch:     baseBranch,
 		Iteration:      iteration,
 		FilesList:      formatFilesList(filesChanged),
 		IssuesMarkdown: issuesMarkdown,
+		FileExcerpts:   fileExcerpts,
 		AutoCommit:     autoCommit,
+
+		StatusKey:       b.StatusKey(),
+		ProtocolVersion: protocol.Version,
 	}
+	out, err := b.render(b.reviewFirstTmpl, data)
+	if err != nil || !b.overBudget(out) || data.FileExcerpts == "" {
+		return out, err
+	}
+	data.FileExcerpts = ""
 	return b.render(b.reviewFirstTmpl, data)
 }
 
+// BuildDocs creates a prompt for the post-completion docs phase, which
+// updates documentation for the files changed during the run.
+func (b *Builder) BuildDocs(w *domain.WorkItem, filesChanged []string) (string, error) {
+	return b.render(b.docsTmpl, DocsData{
+		ID:                 w.ID,
+		Title:              w.Title,
+		FilesList:          formatFilesList(filesChanged),
+		ValidationCommands: w.ValidationCommands,
+		StatusKey:          b.StatusKey(),
+		ProtocolVersion:    protocol.Version,
+	})
+}
+
+// BuildTests creates a prompt for a test-writing iteration of the coverage
+// gate, listing the packages whose coverage fell below threshold.
+func (b *Builder) BuildTests(w *domain.WorkItem, filesChanged, gaps []string, threshold float64) (string, error) {
+	return b.render(b.testsTmpl, TestsData{
+		ID:                 w.ID,
+		Title:              w.Title,
+		Threshold:          threshold,
+		CoverageGaps:       formatFilesList(gaps),
+		FilesList:          formatFilesList(filesChanged),
+		ValidationCommands: w.ValidationCommands,
+		StatusKey:          b.StatusKey(),
+		ProtocolVersion:    protocol.Version,
+	})
+}
+
+// renderTask renders a task prompt, listing files instead of inlining them
+// when the prompt is over the token budget.
+func (b *Builder) renderTask(tmpl *template.Template, data Data, files []domain.ContextFile) (string, error) {
+	out, err := b.render(tmpl, data)
+	if err != nil || !b.overBudget(out) || data.ContextFiles == "" {
+		return out, err
+	}
+	data.ContextFiles = formatContextPaths(files)
+	return b.render(tmpl, data)
+}
+
+func (b *Builder) overBudget(promptText string) bool {
+	return b.tokenBudget > 0 && EstimateTokens(promptText) > b.tokenBudget
+}
+
 func (b *Builder) render(tmpl *template.Template, data any) (string, error) {
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
@@ -125,7 +281,53 @@ func formatFilesList(files []string) string {
 	return "  - " + strings.Join(files, "\n  - ")
 }
 
-// BuildPhaseList creates a formatted list of phases with checkboxes.
+// formatContextFiles renders context files as markdown sections. Excerpts of
+// large files say so, so the executor knows to read the rest.
+func formatContextFiles(files []domain.ContextFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&b, "### %s\n\n```\n%s\n```\n", f.Path, strings.TrimRight(f.Content, "\n"))
+		if f.Truncated {
+			fmt.Fprintf(&b, "(excerpt: first %d of %d bytes, read %s for the rest)\n", len(f.Content), f.Size, f.Path)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatContextPaths lists context files left out of a prompt for size, for
+// the executor to read when it needs them.
+func formatContextPaths(files []domain.ContextFile) string {
+	var b strings.Builder
+	b.WriteString("(not inlined, to fit the context window; read them as needed)\n")
+	for _, f := range files {
+		fmt.Fprintf(&b, "\n- %s (%d bytes)", f.Path, f.Size)
+	}
+	return b.String()
+}
+
+// BuildContinuation creates a short prompt for a resumed executor session
+// that already holds the full task instructions from an earlier iteration.
+// statusKey is the key the earlier prompt asked the status block to start
+// with.
+func BuildContinuation(w *domain.WorkItem, statusKey string) string {
+	target := "the task"
+	if phase := w.CurrentPhase(); phase != nil {
+		target = fmt.Sprintf("the current phase: **%s**", phase.Name)
+	}
+	return fmt.Sprintf(`Continue working on %s (%s: %s).
+
+Your previous iteration ended before this work was finished. Pick up where you left off; re-read files if anything may have changed.
+
+Follow the same instructions as before and end with the %s block exactly as specified earlier.
+`, target, w.ID, w.Title, statusKey)
+}
+
+// BuildPhaseList creates a formatted list of phases with checkboxes,
+// indenting sub-phases under their parent.
 func BuildPhaseList(phases []domain.Phase) string {
 	lines := make([]string, 0, len(phases))
 	for _, p := range phases {
@@ -133,11 +335,17 @@ func BuildPhaseList(phases []domain.Phase) string {
 		if p.Completed {
 			checkbox = "[x]"
 		}
-		lines = append(lines, fmt.Sprintf("- %s %s", checkbox, p.Name))
+		lines = append(lines, fmt.Sprintf("%s- %s %s", strings.Repeat("  ", p.Depth), checkbox, p.Name))
 	}
 	return strings.Join(lines, "\n")
 }
 
+// FormatPhasePath joins a phase's name with its parents' (see
+// domain.WorkItem.PhasePath) for display.
+func FormatPhasePath(path []string) string {
+	return strings.Join(path, " › ")
+}
+
 // defaultBuilder is a package-level builder using embedded defaults.
 // It is lazily initialized on first use via defaultBuilderOnce.
 var (
@@ -155,11 +363,21 @@ func Build(w *domain.WorkItem) string {
 		}
 	})
 	if defaultBuilder == nil {
-		return fmt.Sprintf("Work item %s: %s\n\n%s", w.ID, w.Title, w.RawContent)
+		return buildPlain(w)
 	}
 	result, err := defaultBuilder.Build(w)
 	if err != nil {
-		return fmt.Sprintf("Work item %s: %s\n\n%s", w.ID, w.Title, w.RawContent)
+		return buildPlain(w)
 	}
 	return result
 }
+
+// buildPlain creates a prompt without templates, for when the embedded ones
+// cannot be used: the work item and its context files.
+func buildPlain(w *domain.WorkItem) string {
+	text := fmt.Sprintf("Work item %s: %s\n\n%s", w.ID, w.Title, w.RawContent)
+	if files := formatContextFiles(w.ContextFiles); files != "" {
+		text += "\n\nContext files referenced by the work item:\n\n" + files
+	}
+	return text
+}
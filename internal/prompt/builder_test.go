@@ -35,6 +35,18 @@ func TestBuild(t *testing.T) {
 				`phase_completed: "Phase 2"`,
 			},
 		},
+		{
+			name: "validation commands listed",
+			workItem: &domain.WorkItem{
+				ID:                 "t-124",
+				Title:              "Validated",
+				Phases:             []domain.Phase{{Name: "Phase 1"}},
+				ValidationCommands: []string{"go test ./...", "make lint"},
+			},
+			wantSubs: []string{
+				"  - `go test ./...`\n  - `make lint`",
+			},
+		},
 		{
 			name: "all phases complete",
 			workItem: &domain.WorkItem{
@@ -131,6 +143,15 @@ func TestBuildPhaseList(t *testing.T) {
 			},
 			want: "- [ ] First\n- [ ] Second",
 		},
+		{
+			name: "nested phases",
+			phases: []domain.Phase{
+				{Name: "Backend"},
+				{Name: "API", Depth: 1, Completed: true},
+				{Name: "Frontend"},
+			},
+			want: "- [ ] Backend\n  - [x] API\n- [ ] Frontend",
+		},
 	}
 
 	for _, tt := range tests {
@@ -198,11 +219,31 @@ func TestNewBuilder_WithCustomPrompts(t *testing.T) {
 	assert.Equal(t, "Custom phaseless: custom-2", result)
 }
 
+func TestBuilder_Metadata(t *testing.T) {
+	builder, err := NewBuilder(&config.Prompts{
+		Phased:    "{{.ID}} {{with .Metadata.component}}component: {{.}}{{end}}",
+		Phaseless: "{{.ID}} priority {{.Metadata.priority}}",
+	})
+	require.NoError(t, err)
+
+	result, err := builder.Build(&domain.WorkItem{
+		ID:       "t-1",
+		Phases:   []domain.Phase{{Name: "Phase"}},
+		Metadata: map[string]string{"component": "billing"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "t-1 component: billing", result)
+
+	result, err = builder.Build(&domain.WorkItem{ID: "t-2", Metadata: map[string]string{"priority": "2"}})
+	require.NoError(t, err)
+	assert.Equal(t, "t-2 priority 2", result)
+}
+
 func TestBuilder_BuildReviewFirst(t *testing.T) {
 	builder, err := NewBuilder(nil)
 	require.NoError(t, err)
 
-	result, err := builder.BuildReviewFirst("main", []string{"file1.go"}, "Critical issue found", 1, false)
+	result, err := builder.BuildReviewFirst("main", []string{"file1.go"}, "Critical issue found", "", 1, false)
 	require.NoError(t, err)
 
 	assert.Contains(t, result, "main")
@@ -217,10 +258,155 @@ func TestBuilder_BuildReviewFirst(t *testing.T) {
 	assert.NotContains(t, result, "git commit")
 
 	// With auto-commit enabled
-	resultAC, err := builder.BuildReviewFirst("main", []string{"file1.go"}, "Critical issue found", 1, true)
+	resultAC, err := builder.BuildReviewFirst("main", []string{"file1.go"}, "Critical issue found", "", 1, true)
 	require.NoError(t, err)
 	assert.Contains(t, resultAC, "commit_made")
 	assert.Contains(t, resultAC, "git commit")
+	assert.NotContains(t, resultAC, "Code at the reported lines")
+
+	// With excerpts of the code around the issues
+	resultEx, err := builder.BuildReviewFirst("main", []string{"file1.go"}, "Critical issue found", "### file1.go (lines 1-3)", 1, false)
+	require.NoError(t, err)
+	assert.Contains(t, resultEx, "Code at the reported lines")
+	assert.Contains(t, resultEx, "### file1.go (lines 1-3)")
+}
+
+func TestBuilder_ContextFiles(t *testing.T) {
+	builder, err := NewBuilder(nil)
+	require.NoError(t, err)
+
+	item := &domain.WorkItem{
+		ID:     "plan.md",
+		Title:  "Use design",
+		Phases: []domain.Phase{{Name: "Task 1"}},
+		ContextFiles: []domain.ContextFile{
+			{Path: "docs/design.md", Content: "Use a queue.\n", Size: 13},
+			{Path: "big.txt", Content: "head", Truncated: true, Size: 100},
+		},
+	}
+	result, err := builder.Build(item)
+	require.NoError(t, err)
+	assert.Contains(t, result, "Context files referenced by the work item:")
+	assert.Contains(t, result, "### docs/design.md\n\n```\nUse a queue.\n```")
+	assert.Contains(t, result, "(excerpt: first 4 of 100 bytes, read big.txt for the rest)")
+
+	result = buildPlain(item)
+	assert.Contains(t, result, "Context files referenced by the work item:", "without templates too")
+	assert.Contains(t, result, "### docs/design.md\n\n```\nUse a queue.\n```")
+
+	item.ContextFiles = nil
+	result, err = builder.Build(item)
+	require.NoError(t, err)
+	assert.NotContains(t, result, "Context files referenced")
+	assert.NotContains(t, buildPlain(item), "Context files referenced")
+}
+
+func TestBuilder_WithTokenBudget(t *testing.T) {
+	builder, err := NewBuilder(nil)
+	require.NoError(t, err)
+
+	big := strings.Repeat("x", 40000)
+	item := &domain.WorkItem{
+		ID:           "plan.md",
+		Title:        "Use design",
+		Phases:       []domain.Phase{{Name: "Task 1"}},
+		ContextFiles: []domain.ContextFile{{Path: "docs/design.md", Content: big, Size: len(big)}},
+	}
+	full, err := builder.Build(item)
+	require.NoError(t, err)
+	assert.Greater(t, EstimateTokens(full), 10000)
+
+	fitted, err := builder.WithTokenBudget(10000).Build(item)
+	require.NoError(t, err)
+	assert.NotContains(t, fitted, big)
+	assert.Contains(t, fitted, "- docs/design.md (40000 bytes)")
+	assert.Less(t, EstimateTokens(fitted), 10000)
+
+	roomy, err := builder.WithTokenBudget(20000).Build(item)
+	require.NoError(t, err)
+	assert.Equal(t, full, roomy, "prompts within budget are unchanged")
+
+	review, err := builder.WithTokenBudget(1000).BuildReviewFirst("main", []string{"a.go"}, "Issue", big, 1, false)
+	require.NoError(t, err)
+	assert.NotContains(t, review, "Code at the reported lines")
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(""))
+	assert.Equal(t, 1, EstimateTokens("abc"))
+	assert.Equal(t, 2, EstimateTokens("abcde"))
+	assert.Equal(t, 100000, Budget(200000))
+	assert.Equal(t, 0, Budget(0))
+}
+
+func TestBuilder_BuildDocs(t *testing.T) {
+	builder, err := NewBuilder(nil)
+	require.NoError(t, err)
+
+	item := &domain.WorkItem{ID: "plan.md", Title: "Add caching", ValidationCommands: []string{"go test ./..."}}
+	result, err := builder.BuildDocs(item, []string{"cache.go", "README.md"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "plan.md: Add caching")
+	assert.Contains(t, result, "  - cache.go\n  - README.md")
+	assert.Contains(t, result, "- `go test ./...`")
+	assert.Contains(t, result, "phase_completed: null")
+
+	item.ValidationCommands = nil
+	result, err = builder.BuildDocs(item, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result, "(no files)")
+	assert.Contains(t, result, "Run the project's build and linters")
+}
+
+func TestBuilder_BuildTests(t *testing.T) {
+	builder, err := NewBuilder(nil)
+	require.NoError(t, err)
+
+	item := &domain.WorkItem{ID: "plan.md", Title: "Add caching"}
+	result, err := builder.BuildTests(item, []string{"cache/cache.go"}, []string{"example.com/cache: 40.0% (was 85.0%)"}, 80)
+	require.NoError(t, err)
+	assert.Contains(t, result, "plan.md: Add caching")
+	assert.Contains(t, result, "These packages fell below 80.0% coverage:\n\n  - example.com/cache: 40.0% (was 85.0%)")
+	assert.Contains(t, result, "  - cache/cache.go")
+	assert.Contains(t, result, "Run the tests of the packages above")
+	assert.Contains(t, result, "phase_completed: null")
+}
+
+func TestBuilder_BuildForPhase(t *testing.T) {
+	builder, err := NewBuilder(nil)
+	require.NoError(t, err)
+
+	item := &domain.WorkItem{
+		ID:    "plan",
+		Title: "Plan",
+		Phases: []domain.Phase{
+			{Name: "Phase 1"},
+			{Name: "Phase 2", DependsOn: []int{}},
+		},
+	}
+
+	result, err := builder.BuildForPhase(item, 1)
+	require.NoError(t, err)
+	assert.Contains(t, result, "**Phase 2**")
+	assert.Contains(t, result, `phase_completed: "Phase 2"`)
+
+	_, err = builder.BuildForPhase(item, 2)
+	require.Error(t, err)
+}
+
+func TestBuildContinuation(t *testing.T) {
+	phased := &domain.WorkItem{
+		ID:     "t-1",
+		Title:  "Feature",
+		Phases: []domain.Phase{{Name: "Phase 1", Completed: true}, {Name: "Phase 2"}},
+	}
+	result := BuildContinuation(phased, "PROGRAMMATOR_STATUS_3F9A1C")
+	assert.Contains(t, result, "**Phase 2**")
+	assert.Contains(t, result, "t-1: Feature")
+	assert.Contains(t, result, "end with the PROGRAMMATOR_STATUS_3F9A1C block")
+
+	phaseless := &domain.WorkItem{ID: "t-2", Title: "Chore"}
+	assert.Contains(t, BuildContinuation(phaseless, "PROGRAMMATOR_STATUS"), "Continue working on the task")
 }
 
 func TestNewBuilder_InvalidTemplate(t *testing.T) {
@@ -0,0 +1,19 @@
+package prompt
+
+// bytesPerToken is the rough number of bytes of English text or code per
+// token for the tokenizers of current models.
+const bytesPerToken = 4
+
+// EstimateTokens returns a rough token count of text, for checking prompts
+// against a context window before they are sent. It is not exact: it counts
+// high for plain prose and low for dense code or non-Latin scripts.
+func EstimateTokens(text string) int {
+	return (len(text) + bytesPerToken - 1) / bytesPerToken
+}
+
+// Budget returns the tokens a prompt may take of a context window of window
+// tokens: half, leaving the rest for the files the executor reads, tool
+// output, and its answer. A window of 0 is no limit and returns 0.
+func Budget(window int) int {
+	return window / 2
+}
@@ -3,6 +3,12 @@
 // identifiers, and the null-phase sentinel.
 package protocol
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
 // Status represents the status reported by Claude in a PROGRAMMATOR_STATUS block.
 type Status string
 
@@ -29,13 +35,37 @@ func (s Status) IsValid() bool {
 // Block marker: the key that begins a PROGRAMMATOR_STATUS YAML block.
 const StatusBlockKey = "PROGRAMMATOR_STATUS"
 
+// Version is the status block protocol version, written in the block's
+// protocol field. Blocks without the field are version 1.
+const Version = 2
+
+// NonceStatusKey returns key with a random suffix (e.g.
+// PROGRAMMATOR_STATUS_3F9A1C), so a run's status block cannot be confused
+// with a literal key in files or command output the executor quotes.
+func NonceStatusKey(key string) string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return key + "_" + strings.ToUpper(hex.EncodeToString(b))
+}
+
 // Review result block key.
 const ReviewResultBlockKey = "REVIEW_RESULT"
 
+// Supervisor decision block key.
+const SupervisorDecisionBlockKey = "SUPERVISOR_DECISION"
+
+// Phase split block key.
+const PhaseSplitBlockKey = "PHASE_SPLIT"
+
+// Phase plan block key, for phases proposed for a phaseless work item.
+const PhasePlanBlockKey = "PHASE_PLAN"
+
 // Source type identifiers returned by Source.Type().
 const (
-	SourceTypePlan   = "plan"
-	SourceTypeTicket = "ticket"
+	SourceTypePlan        = "plan"
+	SourceTypeTicket      = "ticket"
+	SourceTypePullRequest = "pull_request"
+	SourceTypeReview      = "review" // review-only runs, which have no work item of their own
 )
 
 // NullPhase is the sentinel value used in the status block when there is no
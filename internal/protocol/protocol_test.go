@@ -35,9 +35,11 @@ func TestStatusIsValid(t *testing.T) {
 func TestConstants(t *testing.T) {
 	assert.Equal(t, "PROGRAMMATOR_STATUS", StatusBlockKey)
 	assert.Equal(t, "REVIEW_RESULT", ReviewResultBlockKey)
+	assert.Equal(t, "SUPERVISOR_DECISION", SupervisorDecisionBlockKey)
 	assert.Equal(t, "null", NullPhase)
 	assert.Equal(t, "plan", SourceTypePlan)
 	assert.Equal(t, "ticket", SourceTypeTicket)
+	assert.Equal(t, "review", SourceTypeReview)
 	assert.Equal(t, "open", WorkItemOpen)
 	assert.Equal(t, "in_progress", WorkItemInProgress)
 	assert.Equal(t, "closed", WorkItemClosed)
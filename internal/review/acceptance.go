@@ -0,0 +1,48 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AcceptanceAgentName is the name of the built-in agent verifying the work
+// item's acceptance criteria.
+const AcceptanceAgentName = "acceptance"
+
+// acceptancePrompt is the acceptance agent's prompt, with criteria numbered
+// so its issues can name them.
+func acceptancePrompt(criteria []string) string {
+	var b strings.Builder
+	b.WriteString(GetDefaultPrompt(AcceptanceAgentName))
+	b.WriteString("\n## Acceptance Criteria\n")
+	for i, c := range criteria {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, c)
+	}
+	return b.String()
+}
+
+// runAcceptanceCheck verifies the acceptance criteria against the change as
+// the acceptance agent when Config.AcceptanceCheck is set and the work item
+// lists criteria. Each unmet criterion is an issue. A failed invocation is
+// reported as the agent's error, so the pass is retried like any other.
+func (r *Runner) runAcceptanceCheck(ctx context.Context, workingDir string, filesChanged, diffChunks []string) (*Result, error) {
+	if !r.config.AcceptanceCheck || len(r.config.AcceptanceCriteria) == 0 {
+		return nil, nil
+	}
+	agent := r.getOrCreateAgent(AgentConfig{Name: AcceptanceAgentName, Prompt: acceptancePrompt(r.config.AcceptanceCriteria)})
+	r.giveContext(agent)
+	r.log(fmt.Sprintf("  Running agent: %s (%d criteria)", agent.Name(), len(r.config.AcceptanceCriteria)))
+	r.agentProgress(AcceptanceAgentName, false)
+	result, err := reviewWithDiff(ctx, agent, workingDir, filesChanged, diffChunks)
+	r.agentProgress(AcceptanceAgentName, true)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		r.log(fmt.Sprintf("  Agent %s failed: %v", AcceptanceAgentName, err))
+		return &Result{AgentName: AcceptanceAgentName, Error: err}, nil
+	}
+	r.log(fmt.Sprintf("  Agent %s: %d unmet criteria", agent.Name(), len(result.Issues)))
+	return result, nil
+}
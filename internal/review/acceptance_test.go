@@ -0,0 +1,78 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptancePrompt(t *testing.T) {
+	prompt := acceptancePrompt([]string{"Cache hits skip the database", "Entries expire"})
+	assert.Contains(t, prompt, "# Acceptance Criteria Check")
+	assert.Contains(t, prompt, "## Acceptance Criteria\n1. Cache hits skip the database\n2. Entries expire\n")
+}
+
+func TestRunner_AcceptanceCheck(t *testing.T) {
+	var acceptanceErr error
+	var prompts []string
+	validated := false
+	runner := NewRunner(Config{
+		Agents:             []AgentConfig{{Name: "quality"}},
+		ValidateIssues:     true,
+		AcceptanceCheck:    true,
+		AcceptanceCriteria: []string{"Cache hits skip the database"},
+		Schedule:           []StrictnessStep{{Agents: []string{"quality"}}},
+	})
+	runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+		mock := NewMockAgent(agentCfg.Name)
+		mock.SetReviewFunc(func(context.Context, string, []string) (*Result, error) {
+			switch agentCfg.Name {
+			case issueValidatorName:
+				validated = true
+			case AcceptanceAgentName:
+				prompts = append(prompts, agentCfg.Prompt)
+				if acceptanceErr != nil {
+					return nil, acceptanceErr
+				}
+				return &Result{AgentName: agentCfg.Name, Issues: []Issue{
+					{Severity: SeverityHigh, Category: "acceptance", Description: "1. 'Cache hits skip the database': every Get queries the store"},
+				}}, nil
+			}
+			return &Result{AgentName: agentCfg.Name, Issues: []Issue{}}, nil
+		})
+		return mock
+	})
+
+	result, err := runner.RunIteration(context.Background(), t.TempDir(), []string{"cache.go"})
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.False(t, validated, "unmet criteria are not validated")
+	require.Len(t, result.Results, 2, "the schedule does not narrow the acceptance check")
+	check := result.Results[1]
+	assert.Equal(t, AcceptanceAgentName, check.AgentName)
+	require.Len(t, check.Issues, 1)
+	assert.NotEmpty(t, check.Issues[0].ID)
+	require.Len(t, prompts, 1)
+	assert.Contains(t, prompts[0], "1. Cache hits skip the database")
+
+	acceptanceErr = errors.New("invocation failed")
+	result, err = runner.RunIteration(context.Background(), t.TempDir(), []string{"cache.go"})
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Results, 2)
+	assert.ErrorIs(t, result.Results[1].Error, acceptanceErr)
+
+	for _, cfg := range []Config{
+		{Agents: []AgentConfig{{Name: "quality"}}, AcceptanceCheck: false, AcceptanceCriteria: []string{"Works"}},
+		{Agents: []AgentConfig{{Name: "quality"}}, AcceptanceCheck: true},
+	} {
+		runner.config = cfg
+		result, err = runner.RunIteration(context.Background(), t.TempDir(), []string{"cache.go"})
+		require.NoError(t, err)
+		assert.True(t, result.Passed)
+		assert.Len(t, result.Results, 1)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alexander-akhmetov/programmator/internal/event"
 	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
 	"github.com/alexander-akhmetov/programmator/internal/safety"
@@ -34,6 +35,28 @@ type Issue struct {
 	Description string   `yaml:"description"`
 	Suggestion  string   `yaml:"suggestion,omitempty"`
 	Verdict     string   `yaml:"verdict,omitempty" json:"verdict,omitempty"`
+
+	// Patch is an optional unified diff that fixes the issue, and
+	// PatchConfidence how sure the agent is of it (high, medium, low).
+	Patch           string `yaml:"patch,omitempty"`
+	PatchConfidence string `yaml:"patch_confidence,omitempty"`
+	// PatchApplies is set by the runner when Patch applies cleanly.
+	PatchApplies bool `yaml:"-"`
+
+	// PreviousRuns is how many earlier runs reported the same finding.
+	PreviousRuns int `yaml:"-"`
+
+	// ReportedBy lists the executors that reported the issue in a
+	// consensus review.
+	ReportedBy []string `yaml:"-"`
+
+	// Path is File resolved against the working directory, set by the
+	// runner so the issue can be opened from anywhere.
+	Path string `yaml:"-"`
+
+	// Owners are the CODEOWNERS owners of File, set by the runner when
+	// Config.CodeOwners is on.
+	Owners []string `yaml:"-"`
 }
 
 // UnmarshalYAML handles line values that are either integers (42) or ranges ("82-94").
@@ -49,6 +72,8 @@ func (issue *Issue) UnmarshalYAML(value *yaml.Node) error {
 		Description string    `yaml:"description"`
 		Suggestion  string    `yaml:"suggestion,omitempty"`
 		Verdict     string    `yaml:"verdict,omitempty"`
+		Patch       string    `yaml:"patch,omitempty"`
+		Confidence  string    `yaml:"patch_confidence,omitempty"`
 	}
 	if err := value.Decode(&raw); err != nil {
 		return err
@@ -61,6 +86,8 @@ func (issue *Issue) UnmarshalYAML(value *yaml.Node) error {
 	issue.Description = raw.Description
 	issue.Suggestion = raw.Suggestion
 	issue.Verdict = raw.Verdict
+	issue.Patch = raw.Patch
+	issue.PatchConfidence = raw.Confidence
 
 	if raw.Line.Tag != "" {
 		lineStr := raw.Line.Value
@@ -103,6 +130,30 @@ const (
 	SeverityInfo     Severity = "info"
 )
 
+// severityRank orders severities from info (1) to critical (5). Unknown
+// values rank 0.
+func severityRank(s Severity) int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityLow:
+		return 2
+	case SeverityMedium:
+		return 3
+	case SeverityHigh:
+		return 4
+	case SeverityCritical:
+		return 5
+	default:
+		return 0
+	}
+}
+
+// ValidSeverity reports whether s is one of the known severity levels.
+func ValidSeverity(s Severity) bool {
+	return severityRank(s) > 0
+}
+
 // Agent defines the interface for code review agents.
 type Agent interface {
 	// Name returns the agent's name.
@@ -123,6 +174,10 @@ type ClaudeAgent struct {
 	timeout        time.Duration
 	executorConfig executor.Config
 	invoker        llm.Invoker
+	metrics        *llm.Metrics
+	patches        bool
+	contextFiles   []string
+	onEvent        event.Handler
 }
 
 // ClaudeAgentOption is a functional option for ClaudeAgent.
@@ -142,6 +197,36 @@ func WithExecutorConfig(cfg executor.Config) ClaudeAgentOption {
 	}
 }
 
+// WithInvoker makes the agent use inv instead of building one from its
+// executor configuration.
+func WithInvoker(inv llm.Invoker) ClaudeAgentOption {
+	return func(a *ClaudeAgent) {
+		a.invoker = inv
+	}
+}
+
+// WithMetrics records the agent's invocations in m.
+func WithMetrics(m *llm.Metrics) ClaudeAgentOption {
+	return func(a *ClaudeAgent) {
+		a.metrics = m
+	}
+}
+
+// WithPatches asks the agent for a suggested patch per issue.
+func WithPatches() ClaudeAgentOption {
+	return func(a *ClaudeAgent) {
+		a.patches = true
+	}
+}
+
+// WithEventHandler streams the agent's tool uses and output lines to h as
+// KindAgentActivity events while it reviews.
+func WithEventHandler(h event.Handler) ClaudeAgentOption {
+	return func(a *ClaudeAgent) {
+		a.onEvent = h
+	}
+}
+
 // NewClaudeAgent creates a new ClaudeAgent.
 func NewClaudeAgent(name string, focus []string, prompt string, opts ...ClaudeAgentOption) *ClaudeAgent {
 	agent := &ClaudeAgent{
@@ -163,16 +248,28 @@ func (a *ClaudeAgent) Name() string {
 	return a.name
 }
 
+// SetContextFiles sets the related files listed in the following reviews.
+func (a *ClaudeAgent) SetContextFiles(files []string) {
+	a.contextFiles = files
+}
+
 // Review runs the code review using Claude.
 func (a *ClaudeAgent) Review(ctx context.Context, workingDir string, filesChanged []string) (*Result, error) {
+	return a.review(ctx, workingDir, a.buildPrompt(filesChanged, ""))
+}
+
+// ReviewDiff runs the code review on a diff embedded in the prompt.
+func (a *ClaudeAgent) ReviewDiff(ctx context.Context, workingDir string, filesChanged []string, diff string, part, parts int) (*Result, error) {
+	return a.review(ctx, workingDir, a.buildPrompt(filesChanged, formatDiffSection(diff, part, parts)))
+}
+
+func (a *ClaudeAgent) review(ctx context.Context, workingDir, prompt string) (*Result, error) {
 	start := time.Now()
 	result := &Result{
 		AgentName: a.name,
 		Issues:    make([]Issue, 0),
 	}
 
-	prompt := a.buildPrompt(filesChanged)
-
 	output, err := a.invokeClaude(ctx, workingDir, prompt)
 	if err != nil {
 		result.Error = err
@@ -182,6 +279,7 @@ func (a *ClaudeAgent) Review(ctx context.Context, workingDir string, filesChange
 
 	issues, summary, err := parseReviewOutput(output)
 	if err != nil {
+		a.metrics.RecordFailure(llm.FailureParse)
 		result.Error = fmt.Errorf("failed to parse review output: %w", err)
 		result.Duration = time.Since(start)
 		return result, result.Error
@@ -194,8 +292,9 @@ func (a *ClaudeAgent) Review(ctx context.Context, workingDir string, filesChange
 	return result, nil
 }
 
-// buildPrompt constructs the review prompt for Claude.
-func (a *ClaudeAgent) buildPrompt(filesChanged []string) string {
+// buildPrompt constructs the review prompt for Claude. diffSection, when
+// set, is inserted after the file list.
+func (a *ClaudeAgent) buildPrompt(filesChanged []string, diffSection string) string {
 	var b strings.Builder
 
 	b.WriteString(a.prompt)
@@ -221,6 +320,20 @@ func (a *ClaudeAgent) buildPrompt(filesChanged []string) string {
 		b.WriteString("\n")
 	}
 
+	if len(a.contextFiles) > 0 {
+		b.WriteString("## Related Files\n")
+		b.WriteString("These files are not part of the change but are coupled to it: they share a package with a changed file or import one. ")
+		b.WriteString("Read them as needed for the interfaces and call sites the change relies on. Report issues in them only when the change breaks them.\n")
+		for _, f := range a.contextFiles {
+			b.WriteString("- ")
+			b.WriteString(f)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(diffSection)
+
 	b.WriteString(`## Output Format
 
 Respond with a YAML block containing your findings.
@@ -238,6 +351,34 @@ REVIEW_RESULT:
       suggestion: 'Add error logging or return the error'
   summary: 'Brief summary of findings'
 ` + "```" + `
+`)
+
+	if a.patches {
+		b.WriteString(`
+When the fix is small and you are sure of it, add a ` + "`patch`" + ` with a unified diff against the current files (paths relative to the repository root, ` + "`--- a/`/`+++ b/`" + ` headers, enough context lines to apply with ` + "`git apply`" + `) and a ` + "`patch_confidence`" + ` of 'high', 'medium', or 'low'. Use 'high' only when applying the patch fully fixes the issue with no other changes. Leave both out otherwise.
+
+` + "```yaml" + `
+    - file: 'path/to/file.go'
+      line: 42
+      severity: 'high'
+      category: 'error handling'
+      description: 'Error is ignored'
+      suggestion: 'Return the error'
+      patch_confidence: 'high'
+      patch: |
+        --- a/path/to/file.go
+        +++ b/path/to/file.go
+        @@ -41,3 +41,5 @@
+         	data, err := load()
+        -	_ = err
+        +	if err != nil {
+        +		return err
+        +	}
+` + "```" + `
+`)
+	}
+
+	b.WriteString(`
 
 If no issues found:
 ` + "```yaml" + `
@@ -259,12 +400,30 @@ func (a *ClaudeAgent) invokeClaude(ctx context.Context, workingDir, promptText s
 			return "", fmt.Errorf("create invoker: %w", err)
 		}
 	}
+	inv = llm.WithConcurrencyLimit(llm.WithMetrics(inv, a.metrics))
 
+	purpose := llm.PurposeReview
+	if isValidatorAgent(a.name) {
+		purpose = llm.PurposeValidator
+	}
 	opts := llm.InvokeOptions{
 		WorkingDir: workingDir,
-		ExtraFlags: a.executorConfig.ExtraFlags,
+		ExtraFlags: a.executorConfig.FlagsFor(purpose),
+		Purpose:    purpose,
 		Timeout:    int(a.timeout.Seconds()),
 	}
+	if a.onEvent != nil {
+		lines := &activityLines{emit: func(line string) {
+			a.onEvent(event.AgentActivity(a.name, line))
+		}}
+		defer lines.flush()
+		opts.Streaming = true
+		opts.OnOutput = lines.write
+		opts.OnToolUse = func(name string, input any) {
+			lines.flush()
+			lines.emit(llm.FormatToolUse(name, input))
+		}
+	}
 
 	res, err := inv.Invoke(ctx, promptText, opts)
 	if err != nil {
@@ -273,6 +432,31 @@ func (a *ClaudeAgent) invokeClaude(ctx context.Context, workingDir, promptText s
 	return res.Text, nil
 }
 
+// activityLines splits an agent's output fragments into lines, emitting
+// each complete, non-blank one.
+type activityLines struct {
+	emit    func(line string)
+	pending string
+}
+
+func (l *activityLines) write(text string) {
+	lines := strings.Split(l.pending+text, "\n")
+	l.pending = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		if line = strings.TrimSpace(line); line != "" {
+			l.emit(line)
+		}
+	}
+}
+
+// flush emits the line still being written, if any.
+func (l *activityLines) flush() {
+	if line := strings.TrimSpace(l.pending); line != "" {
+		l.emit(line)
+	}
+	l.pending = ""
+}
+
 // MockAgent is a mock implementation for testing.
 type MockAgent struct {
 	name       string
@@ -0,0 +1,207 @@
+package review
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Files of a calibration case directory.
+const (
+	CalibrationDiffFile     = "change.diff"
+	CalibrationExpectedFile = "expected.yaml"
+	CalibrationBaseDir      = "base"
+)
+
+// calibrationLineSlack is how many lines apart a finding may be from the
+// expected issue's line and still match it.
+const calibrationLineSlack = 3
+
+// CalibrationCase is a historical change with the issues a review should
+// find in it: a directory holding change.diff, expected.yaml, and
+// optionally base/, the files the diff applies to.
+type CalibrationCase struct {
+	Name     string
+	Dir      string
+	Diff     string
+	Expected []ExpectedIssue
+}
+
+// ExpectedIssue is an issue a calibration case expects a review to find.
+// Line 0 matches any line of File; an empty Agent expects it from any agent.
+type ExpectedIssue struct {
+	File        string `yaml:"file"`
+	Line        int    `yaml:"line,omitempty"`
+	Agent       string `yaml:"agent,omitempty"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// LoadCalibrationCases reads the case directories under dir, in name order.
+// A case with no expected issues measures false positives only.
+func LoadCalibrationCases(dir string) ([]CalibrationCase, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read calibration cases: %w", err)
+	}
+	var cases []CalibrationCase
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		caseDir := filepath.Join(dir, e.Name())
+		diff, err := os.ReadFile(filepath.Join(caseDir, CalibrationDiffFile)) //nolint:gosec // user-provided dataset
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("case %s: %w", e.Name(), err)
+		}
+		c := CalibrationCase{Name: e.Name(), Dir: caseDir, Diff: string(diff)}
+
+		data, err := os.ReadFile(filepath.Join(caseDir, CalibrationExpectedFile)) //nolint:gosec // user-provided dataset
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("case %s: %w", e.Name(), err)
+		}
+		var expected struct {
+			Issues []ExpectedIssue `yaml:"issues"`
+		}
+		if err := yaml.Unmarshal(data, &expected); err != nil {
+			return nil, fmt.Errorf("case %s: parse %s: %w", e.Name(), CalibrationExpectedFile, err)
+		}
+		for _, issue := range expected.Issues {
+			if issue.File == "" {
+				return nil, fmt.Errorf("case %s: expected issue without a file", e.Name())
+			}
+		}
+		c.Expected = expected.Issues
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no calibration cases in %s (each is a directory with %s)", dir, CalibrationDiffFile)
+	}
+	return cases, nil
+}
+
+// AgentCalibration is one agent's score over calibration cases. Precision
+// is the share of its findings that match an expected issue; recall the
+// share of the expected issues meant for it (its own and those without an
+// agent) that it found.
+type AgentCalibration struct {
+	Agent    string
+	Findings int // issues reported
+	Correct  int // reported issues matching an expected issue
+	Expected int // expected issues meant for the agent
+	Found    int // expected issues meant for the agent that it reported
+	Errors   int // cases the agent failed on
+}
+
+// Precision returns Correct/Findings, or 0 without findings.
+func (a *AgentCalibration) Precision() float64 {
+	if a.Findings == 0 {
+		return 0
+	}
+	return float64(a.Correct) / float64(a.Findings)
+}
+
+// Recall returns Found/Expected, or 0 without expected issues.
+func (a *AgentCalibration) Recall() float64 {
+	if a.Expected == 0 {
+		return 0
+	}
+	return float64(a.Found) / float64(a.Expected)
+}
+
+// Calibration accumulates agent scores over calibration cases.
+type Calibration struct {
+	Agents map[string]*AgentCalibration
+	Total  AgentCalibration // all agents together: every expected issue, found by any agent
+}
+
+// NewCalibration returns an empty calibration for agents.
+func NewCalibration(agents []AgentConfig) *Calibration {
+	c := &Calibration{Agents: make(map[string]*AgentCalibration, len(agents)), Total: AgentCalibration{Agent: "all"}}
+	for _, a := range agents {
+		c.Agents[a.Name] = &AgentCalibration{Agent: a.Name}
+	}
+	return c
+}
+
+// Add scores the results of a review of c, run in workingDir.
+func (cal *Calibration) Add(c CalibrationCase, workingDir string, results []*Result) {
+	foundAny := make([]bool, len(c.Expected))
+	for name, score := range cal.Agents {
+		var issues []Issue
+		for _, res := range results {
+			if res.AgentName != name {
+				continue
+			}
+			issues = res.Issues
+			if res.Error != nil {
+				score.Errors++
+				cal.Total.Errors++
+			}
+		}
+
+		for i, exp := range c.Expected {
+			found := slices.ContainsFunc(issues, func(issue Issue) bool { return exp.matches(workingDir, issue) })
+			foundAny[i] = foundAny[i] || found
+			if exp.Agent == "" || exp.Agent == name {
+				score.Expected++
+				if found {
+					score.Found++
+				}
+			}
+		}
+		for _, issue := range issues {
+			score.Findings++
+			cal.Total.Findings++
+			if slices.ContainsFunc(c.Expected, func(exp ExpectedIssue) bool { return exp.matches(workingDir, issue) }) {
+				score.Correct++
+				cal.Total.Correct++
+			}
+		}
+	}
+
+	cal.Total.Expected += len(c.Expected)
+	for _, found := range foundAny {
+		if found {
+			cal.Total.Found++
+		}
+	}
+}
+
+// Sorted returns the agent scores in name order.
+func (cal *Calibration) Sorted() []*AgentCalibration {
+	scores := make([]*AgentCalibration, 0, len(cal.Agents))
+	for _, s := range cal.Agents {
+		scores = append(scores, s)
+	}
+	slices.SortFunc(scores, func(a, b *AgentCalibration) int { return strings.Compare(a.Agent, b.Agent) })
+	return scores
+}
+
+// matches reports whether issue, found in a review run in workingDir, is
+// the expected one: the same file and, when the expected line is set, a
+// line range within calibrationLineSlack of it.
+func (exp ExpectedIssue) matches(workingDir string, issue Issue) bool {
+	file := issue.Path
+	if file == "" {
+		file = issue.File
+	}
+	if rel, err := filepath.Rel(workingDir, file); err == nil && filepath.IsLocal(rel) {
+		file = rel
+	}
+	if filepath.ToSlash(filepath.Clean(file)) != filepath.ToSlash(filepath.Clean(exp.File)) {
+		return false
+	}
+	if exp.Line == 0 || issue.Line == 0 {
+		return true
+	}
+	end := max(issue.LineEnd, issue.Line)
+	return exp.Line >= issue.Line-calibrationLineSlack && exp.Line <= end+calibrationLineSlack
+}
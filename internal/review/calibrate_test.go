@@ -0,0 +1,70 @@
+package review
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCalibrationCases(t *testing.T) {
+	dir := t.TempDir()
+	writeCase := func(name, expected string) {
+		t.Helper()
+		caseDir := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(caseDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(caseDir, CalibrationDiffFile), []byte("diff"), 0o644))
+		if expected != "" {
+			require.NoError(t, os.WriteFile(filepath.Join(caseDir, CalibrationExpectedFile), []byte(expected), 0o644))
+		}
+	}
+	writeCase("b-clean", "")
+	writeCase("a-token", "issues:\n  - file: auth/token.go\n    line: 42\n    agent: bug-deep\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0o755))
+
+	cases, err := LoadCalibrationCases(dir)
+	require.NoError(t, err)
+	require.Len(t, cases, 2)
+	assert.Equal(t, "a-token", cases[0].Name)
+	assert.Equal(t, []ExpectedIssue{{File: "auth/token.go", Line: 42, Agent: "bug-deep"}}, cases[0].Expected)
+	assert.Equal(t, "b-clean", cases[1].Name)
+	assert.Empty(t, cases[1].Expected)
+
+	writeCase("c-bad", "issues:\n  - line: 3\n")
+	_, err = LoadCalibrationCases(dir)
+	require.ErrorContains(t, err, "expected issue without a file")
+
+	_, err = LoadCalibrationCases(t.TempDir())
+	require.ErrorContains(t, err, "no calibration cases")
+}
+
+func TestCalibration_Add(t *testing.T) {
+	wd := "/tmp/case"
+	c := CalibrationCase{Name: "token", Expected: []ExpectedIssue{
+		{File: "auth/token.go", Line: 42},
+		{File: "auth/token_test.go", Agent: "tests"},
+	}}
+	cal := NewCalibration([]AgentConfig{{Name: "bugs"}, {Name: "tests"}})
+
+	cal.Add(c, wd, []*Result{
+		{AgentName: "bugs", Issues: []Issue{
+			{File: "auth/token.go", Line: 40, Path: "/tmp/case/auth/token.go"}, // within the line slack
+			{File: "auth/token.go", Line: 90, Path: "/tmp/case/auth/token.go"}, // too far away
+		}},
+		{AgentName: "tests", Issues: []Issue{{File: "auth/token_test.go"}}},
+		{AgentName: "final-check", Issues: []Issue{{File: "auth/token.go", Line: 42}}},
+	})
+
+	bugs, tests := cal.Agents["bugs"], cal.Agents["tests"]
+	assert.Equal(t, AgentCalibration{Agent: "bugs", Findings: 2, Correct: 1, Expected: 1, Found: 1}, *bugs)
+	assert.InDelta(t, 0.5, bugs.Precision(), 0.001)
+	assert.InDelta(t, 1.0, bugs.Recall(), 0.001)
+	assert.Equal(t, AgentCalibration{Agent: "tests", Findings: 1, Correct: 1, Expected: 2, Found: 1}, *tests)
+	assert.Equal(t, AgentCalibration{Agent: "all", Findings: 3, Correct: 2, Expected: 2, Found: 2}, cal.Total)
+	assert.Equal(t, []*AgentCalibration{bugs, tests}, cal.Sorted())
+
+	assert.Zero(t, (&AgentCalibration{}).Precision())
+	assert.Zero(t, (&AgentCalibration{}).Recall())
+}
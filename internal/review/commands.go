@@ -0,0 +1,203 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FinalCheckAgentName is the name of the built-in agent running the
+// validation commands.
+const FinalCheckAgentName = "final-check"
+
+// Limits keeping a failing command's report readable in fix prompts.
+const (
+	maxCheckIssues      = 20 // located issues per command
+	maxCheckOutputLines = 30 // output lines quoted when no location is found
+)
+
+// checkLocation matches the "file:line[:col]: message" lines compilers,
+// vets, linters, and test runners print.
+var checkLocation = regexp.MustCompile(`^\s*([^\s:][^:]*?):(\d+)(?::\d+)?:\s*(.+)$`)
+
+// CommandAgent is a review agent that runs the project's validation
+// commands (build, tests, lint) instead of invoking a model. Every failing
+// command is reported as issues: one per "file:line: message" line of its
+// output naming a file of the repository, or a single issue quoting the
+// output tail when none does.
+type CommandAgent struct {
+	commands []string
+	timeout  time.Duration
+}
+
+var _ Agent = (*CommandAgent)(nil)
+
+// NewCommandAgent returns a CommandAgent running commands in order, each
+// limited to timeout (0 = no limit).
+func NewCommandAgent(commands []string, timeout time.Duration) *CommandAgent {
+	return &CommandAgent{commands: commands, timeout: timeout}
+}
+
+// Name returns FinalCheckAgentName.
+func (a *CommandAgent) Name() string {
+	return FinalCheckAgentName
+}
+
+// Review runs every command in workingDir and reports the failures.
+func (a *CommandAgent) Review(ctx context.Context, workingDir string, filesChanged []string) (*Result, error) {
+	start := time.Now()
+	result := &Result{AgentName: FinalCheckAgentName, Issues: make([]Issue, 0)}
+
+	failed := 0
+	for _, command := range a.commands {
+		out, err := a.run(ctx, workingDir, command)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err == nil {
+			continue
+		}
+		failed++
+		result.Issues = append(result.Issues, commandIssues(workingDir, filesChanged, command, out, err)...)
+	}
+
+	result.Duration = time.Since(start)
+	if failed == 0 {
+		result.Summary = fmt.Sprintf("All %d validation commands passed", len(a.commands))
+	} else {
+		result.Summary = fmt.Sprintf("%d of %d validation commands failed", failed, len(a.commands))
+	}
+	return result, nil
+}
+
+func (a *CommandAgent) run(ctx context.Context, workingDir, command string) ([]byte, error) {
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	cmd.Dir = workingDir
+	// Children the shell started may hold the output open past the kill.
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", a.timeout)
+	}
+	return out, err
+}
+
+// commandIssues converts the output of a failed command into issues.
+func commandIssues(workingDir string, filesChanged []string, command string, out []byte, runErr error) []Issue {
+	var issues []Issue
+	seen := make(map[string]bool)
+	for line := range strings.SplitSeq(string(out), "\n") {
+		m := checkLocation.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		file := checkFile(workingDir, filesChanged, m[1])
+		lineNo, _ := strconv.Atoi(m[2])
+		if file == "" || lineNo <= 0 {
+			continue
+		}
+		key := file + ":" + m[2] + ":" + m[3]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		issues = append(issues, Issue{
+			File:        file,
+			Line:        lineNo,
+			Severity:    SeverityHigh,
+			Category:    "validation",
+			Description: fmt.Sprintf("%s (from `%s`)", strings.TrimSpace(m[3]), command),
+		})
+		if len(issues) == maxCheckIssues {
+			break
+		}
+	}
+	if len(issues) > 0 {
+		return issues
+	}
+
+	description := fmt.Sprintf("`%s` failed: %v", command, runErr)
+	if tail := checkOutputTail(out); tail != "" {
+		description += "\n" + tail
+	}
+	return []Issue{{
+		Severity:    SeverityHigh,
+		Category:    "validation",
+		Description: description,
+	}}
+}
+
+// checkFile returns name, as reported by a tool, as a path relative to
+// workingDir, or "" when it is not a file there. Test runners print names
+// relative to the package directory, so a bare name also matches a changed
+// file ending in it.
+func checkFile(workingDir string, filesChanged []string, name string) string {
+	name = filepath.Clean(name)
+	if filepath.IsAbs(name) {
+		rel, err := filepath.Rel(workingDir, name)
+		if err != nil || !filepath.IsLocal(rel) {
+			return ""
+		}
+		name = rel
+	}
+	if !filepath.IsLocal(name) {
+		return ""
+	}
+	if fi, err := os.Stat(filepath.Join(workingDir, name)); err == nil && !fi.IsDir() {
+		return filepath.ToSlash(name)
+	}
+	suffix := "/" + filepath.ToSlash(name)
+	for _, f := range filesChanged {
+		if strings.HasSuffix("/"+filepath.ToSlash(f), suffix) {
+			return filepath.ToSlash(f)
+		}
+	}
+	return ""
+}
+
+// checkOutputTail returns the last lines of a command's output.
+func checkOutputTail(out []byte) string {
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > maxCheckOutputLines {
+		lines = append([]string{"..."}, lines[len(lines)-maxCheckOutputLines:]...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runFinalCheck runs the validation commands as the final-check agent when
+// Config.FinalCheck is set and there are commands to run.
+func (r *Runner) runFinalCheck(ctx context.Context, workingDir string, filesChanged []string) (*Result, error) {
+	if !r.config.FinalCheck || len(r.config.ValidationCommands) == 0 {
+		return nil, nil
+	}
+	agent := NewCommandAgent(r.config.ValidationCommands, time.Duration(r.config.Timeout)*time.Second)
+	r.log(fmt.Sprintf("  Running agent: %s", agent.Name()))
+	r.agentProgress(agent.Name(), false)
+	result, err := agent.Review(ctx, workingDir, filesChanged)
+	r.agentProgress(agent.Name(), true)
+	if err != nil {
+		return nil, err
+	}
+	r.log(fmt.Sprintf("  Agent %s: %d issues found", agent.Name(), len(result.Issues)))
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package review
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandAgent_Review(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg", "calc"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "calc", "calc_test.go"), []byte("package calc\n"), 0o644))
+
+	t.Run("passing commands report no issues", func(t *testing.T) {
+		agent := NewCommandAgent([]string{"true", "echo ok"}, 0)
+		result, err := agent.Review(context.Background(), dir, nil)
+		require.NoError(t, err)
+		assert.Equal(t, FinalCheckAgentName, result.AgentName)
+		assert.Empty(t, result.Issues)
+		assert.Equal(t, "All 2 validation commands passed", result.Summary)
+	})
+
+	t.Run("locations in the output become issues", func(t *testing.T) {
+		script := `printf '%s\n' './main.go:3:5: undefined: foo' 'main.go:3:5: undefined: foo' '    calc_test.go:12: got 2, want 3' 'other.go:1: not in the repo' 'FAIL'; exit 1`
+		agent := NewCommandAgent([]string{script}, 0)
+		result, err := agent.Review(context.Background(), dir, []string{"pkg/calc/calc_test.go"})
+		require.NoError(t, err)
+		require.Len(t, result.Issues, 2)
+
+		assert.Equal(t, "main.go", result.Issues[0].File)
+		assert.Equal(t, 3, result.Issues[0].Line)
+		assert.Equal(t, SeverityHigh, result.Issues[0].Severity)
+		assert.Equal(t, "validation", result.Issues[0].Category)
+		assert.Contains(t, result.Issues[0].Description, "undefined: foo")
+
+		assert.Equal(t, "pkg/calc/calc_test.go", result.Issues[1].File)
+		assert.Equal(t, 12, result.Issues[1].Line)
+		assert.Contains(t, result.Issues[1].Description, "got 2, want 3")
+		assert.Equal(t, "1 of 1 validation commands failed", result.Summary)
+	})
+
+	t.Run("output without locations becomes one issue", func(t *testing.T) {
+		agent := NewCommandAgent([]string{"echo 'lint: 3 problems'; exit 2"}, 0)
+		result, err := agent.Review(context.Background(), dir, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Issues, 1)
+		assert.Empty(t, result.Issues[0].File)
+		assert.Contains(t, result.Issues[0].Description, "exit status 2")
+		assert.Contains(t, result.Issues[0].Description, "lint: 3 problems")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		agent := NewCommandAgent([]string{"sleep 5"}, 50*time.Millisecond)
+		result, err := agent.Review(context.Background(), dir, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Issues, 1)
+		assert.Contains(t, result.Issues[0].Description, "timed out")
+	})
+}
+
+func TestRunner_FinalCheck(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+
+	validated := false
+	cfg := Config{
+		Agents:             []AgentConfig{{Name: "bug-shallow"}},
+		ValidateIssues:     true,
+		FinalCheck:         true,
+		ValidationCommands: []string{"echo 'main.go:1:1: build failed'; exit 1"},
+	}
+	runner := NewRunner(cfg)
+	runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+		mock := NewMockAgent(agentCfg.Name)
+		mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*Result, error) {
+			if agentCfg.Name == issueValidatorName {
+				validated = true
+			}
+			return &Result{AgentName: agentCfg.Name, Issues: []Issue{}}, nil
+		})
+		return mock
+	})
+
+	result, err := runner.RunIteration(context.Background(), dir, []string{"main.go"})
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.False(t, validated, "the final check's issues are not validated")
+	require.Len(t, result.Results, 2)
+	check := result.Results[1]
+	assert.Equal(t, FinalCheckAgentName, check.AgentName)
+	require.Len(t, check.Issues, 1)
+	assert.NotEmpty(t, check.Issues[0].ID)
+	assert.Equal(t, filepath.Join(dir, "main.go"), check.Issues[0].Path)
+
+	runner.config.FinalCheck = false
+	result, err = runner.RunIteration(context.Background(), dir, []string{"main.go"})
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+	assert.Len(t, result.Results, 1)
+}
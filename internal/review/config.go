@@ -2,6 +2,11 @@
 package review
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
 )
 
@@ -16,17 +21,123 @@ type Config struct {
 	Timeout                 int             `yaml:"-"` // seconds per agent invocation, inherited from main config
 	Agents                  []AgentConfig   `yaml:"agents,omitempty"`
 	ExecutorConfig          executor.Config `yaml:"-"` // executor configuration, inherited from main config
+	Invoker                 llm.Invoker     `yaml:"-"` // overrides ExecutorConfig for agent invocations (embedding)
+	Metrics                 *llm.Metrics    `yaml:"-"` // records the latency and failures of agent invocations (nil = off)
 	TicketContext           string          `yaml:"-"` // full ticket/plan content for reviewer context
+	BaseBranch              string          `yaml:"-"` // branch the changes are compared against, exposed to prompt_file templates
+	IncludeDiff             bool            `yaml:"-"` // embed the unified diff in agent prompts
+	DiffBase                string          `yaml:"-"` // revision the embedded diff is computed against (default: HEAD)
+	DiffLimit               int             `yaml:"-"` // max diff bytes per agent prompt; larger diffs are split (default: DefaultDiffLimit)
 	ValidateIssues          bool            `yaml:"-"`
 	ValidateSimplifications bool            `yaml:"-"`
+	ValidatorTimeout        int             `yaml:"-"` // seconds per validator invocation; a validator running longer is skipped (0 = Timeout only)
+	HistoryDir              string          `yaml:"-"` // where finding fingerprints persist across runs (empty = disabled)
+	SummaryDir              string          `yaml:"-"` // where cached repository summaries for reviewer prompts live (empty = disabled)
+	ContextFiles            int             `yaml:"-"` // max files coupled to the changed Go files (same package, importers) listed for agents (0 = none)
+	EscalateRecurringAfter  int             `yaml:"-"` // raise severity once a finding was seen in N previous runs (0 = never)
+	SuggestPatches          bool            `yaml:"-"` // ask agents for a unified-diff patch per issue
+	AutoApplyPatches        bool            `yaml:"-"` // let the fix loop apply high-confidence patches without an executor fix
+	SanitizeInputs          bool            `yaml:"-"` // fence the ticket context in agent prompts and strip lines addressing the reviewer
+	InjectionDetector       string          `yaml:"-"` // scan the ticket context for prompt injection: InjectionOff, InjectionWarn, or InjectionBlock
+	FixExcerptLines         int             `yaml:"-"` // max lines of code around issues embedded in fix prompts (0 = none)
+	FixGroupBy              string          `yaml:"-"` // grouping of issues in fix prompts: GroupByAgent, GroupByFile, or GroupBySeverity
+	FixMaxIssues            int             `yaml:"-"` // issues per fix prompt, most severe first; the rest follow in later ones (0 = all)
+	FixSnippetLines         int             `yaml:"-"` // lines of code shown around each issue in fix prompts (0 = none)
+	ScoreThreshold          int             `yaml:"-"` // pass a review whose Score is below this, leaving its issues as residual (0 = only with no issues)
+	Skip                    bool            `yaml:"-"` // skip review: the loop completes once all phases are done
+	Severities              []Severity      `yaml:"-"` // keep only issues of these severities (empty = all)
+	CodeOwners              bool            `yaml:"-"` // annotate issues with their owners from the repository's CODEOWNERS file
+	FileDeferred            bool            `yaml:"-"` // file issues left open by the iteration limit as a new work item
+	FinalCheck              bool            `yaml:"-"` // run ValidationCommands as the final-check agent in every pass
+	ValidationCommands      []string        `yaml:"-"` // the work item's validation commands (build, tests, lint)
+	AcceptanceCheck         bool            `yaml:"-"` // verify AcceptanceCriteria as the acceptance agent in every pass
+	AcceptanceCriteria      []string        `yaml:"-"` // the work item's acceptance criteria
+
+	// Schedule narrows early review iterations: iteration N uses step N, and
+	// iterations past the end use the last step. Empty runs every agent at
+	// every severity each time.
+	Schedule []StrictnessStep `yaml:"schedule,omitempty"`
+
+	// IssueValidatorWhen and SimplificationValidatorWhen limit the validator
+	// passes to changes of a given size (see SizeRule).
+	IssueValidatorWhen          SizeRule `yaml:"-"`
+	SimplificationValidatorWhen SizeRule `yaml:"-"`
+
+	// ConsensusExecutors, when two or more, run every agent on each of these
+	// executors instead of ExecutorConfig; ConsensusMode (ConsensusIntersect
+	// or ConsensusUnion) decides which of their issues are kept.
+	ConsensusExecutors []executor.Config `yaml:"-"`
+	ConsensusMode      string            `yaml:"-"`
 }
 
 // AgentConfig defines a single review agent configuration.
 type AgentConfig struct {
-	Name       string   `yaml:"name"`
-	Focus      []string `yaml:"focus"`
-	Prompt     string   `yaml:"prompt,omitempty"`      // inline prompt text
-	PromptFile string   `yaml:"prompt_file,omitempty"` // prompt file path (absolute or relative to working dir)
+	Name        string   `yaml:"name"`
+	Focus       []string `yaml:"focus"`
+	Prompt      string   `yaml:"prompt,omitempty"`       // inline prompt text
+	PromptFile  string   `yaml:"prompt_file,omitempty"`  // Go template file path (absolute or relative to working dir)
+	MinSeverity Severity `yaml:"min_severity,omitempty"` // drop findings below this severity
+	When        SizeRule `yaml:"when,omitempty"`         // run only for changes of this size
+	Model       string   `yaml:"model,omitempty"`        // executor model for this agent (empty = the review executor's)
+	Effort      string   `yaml:"effort,omitempty"`       // reasoning effort: low, medium, or high (empty = executor default)
+
+	ticketInPrompt bool             // the rendered prompt_file already includes the ticket context
+	executor       *executor.Config // consensus executor this agent runs on (nil = Config.ExecutorConfig)
+}
+
+// StrictnessStep is the scope of one review iteration in a Config.Schedule.
+type StrictnessStep struct {
+	MinSeverity Severity `yaml:"min_severity,omitempty"` // drop findings below this severity
+	Agents      []string `yaml:"agents,omitempty"`       // run only these agents (empty = all)
+}
+
+// Validate checks the severity and that every agent is one of agents.
+func (s StrictnessStep) Validate(agents []AgentConfig) error {
+	if s.MinSeverity != "" && !ValidSeverity(s.MinSeverity) {
+		return fmt.Errorf("unknown min_severity %q", s.MinSeverity)
+	}
+	for _, name := range s.Agents {
+		if !slices.ContainsFunc(agents, func(a AgentConfig) bool { return a.Name == name }) {
+			return fmt.Errorf("unknown review agent %q", name)
+		}
+	}
+	return nil
+}
+
+func (s StrictnessStep) String() string {
+	scope := "all agents"
+	if len(s.Agents) > 0 {
+		scope = strings.Join(s.Agents, ", ")
+	}
+	if s.MinSeverity != "" {
+		scope += ", " + string(s.MinSeverity) + " and above"
+	}
+	return scope
+}
+
+// SelectAgents keeps only the agents named in names, in their configured
+// order. A name that matches no agent is an error.
+func (c *Config) SelectAgents(names []string) error {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	selected := make([]AgentConfig, 0, len(names))
+	available := make([]string, 0, len(c.Agents))
+	for _, agent := range c.Agents {
+		available = append(available, agent.Name)
+		if wanted[agent.Name] {
+			selected = append(selected, agent)
+			delete(wanted, agent.Name)
+		}
+	}
+	for _, name := range names {
+		if wanted[name] {
+			return fmt.Errorf("unknown review agent %q (configured: %s)", name, strings.Join(available, ", "))
+		}
+	}
+	c.Agents = selected
+	return nil
 }
 
 // DefaultConfig returns the default review configuration.
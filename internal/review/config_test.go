@@ -42,3 +42,22 @@ func TestDefaultAgents(t *testing.T) {
 		require.NotEmpty(t, a.Focus)
 	}
 }
+
+func TestConfig_SelectAgents(t *testing.T) {
+	cfg := Config{Agents: DefaultAgents()}
+	require.NoError(t, cfg.SelectAgents([]string{"comments", "bug-deep"}))
+	require.Len(t, cfg.Agents, 2)
+	require.Equal(t, "bug-deep", cfg.Agents[0].Name, "keeps the configured order")
+	require.Equal(t, "comments", cfg.Agents[1].Name)
+
+	err := cfg.SelectAgents([]string{"bug-deep", "architect"})
+	require.ErrorContains(t, err, `unknown review agent "architect" (configured: bug-deep, comments)`)
+}
+
+func TestStrictnessStep_Validate(t *testing.T) {
+	agents := []AgentConfig{{Name: "bug-deep"}, {Name: "comments"}}
+	require.NoError(t, StrictnessStep{}.Validate(agents))
+	require.NoError(t, StrictnessStep{MinSeverity: SeverityHigh, Agents: []string{"bug-deep"}}.Validate(agents))
+	require.ErrorContains(t, StrictnessStep{MinSeverity: "blocker"}.Validate(agents), `unknown min_severity "blocker"`)
+	require.ErrorContains(t, StrictnessStep{Agents: []string{"architect"}}.Validate(agents), `unknown review agent "architect"`)
+}
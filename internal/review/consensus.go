@@ -0,0 +1,159 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Consensus modes: how the issues of executors reviewing the same changes
+// are combined.
+const (
+	ConsensusIntersect = "intersect" // keep issues reported by every executor
+	ConsensusUnion     = "union"     // keep every issue, merging those reported by several
+)
+
+// consensusLineSlack is how many lines apart two executors may place the
+// same finding.
+const consensusLineSlack = 3
+
+// consensusEnabled reports whether each agent runs on several executors.
+func (r *Runner) consensusEnabled() bool {
+	return len(r.config.ConsensusExecutors) >= 2
+}
+
+// runAgents runs every agent once, or once per consensus executor with the
+// results merged per agent. Progress is reported under phase, per executor
+// with consensus.
+func (r *Runner) runAgents(ctx context.Context, agents []AgentConfig, workingDir string, filesChanged, diffChunks []string, phase string) ([]*Result, error) {
+	run := r.runAgentsSequential
+	if r.config.Parallel {
+		run = r.runAgentsParallel
+	}
+	if !r.consensusEnabled() {
+		r.startPhase(phase)
+		return run(ctx, agents, workingDir, filesChanged, diffChunks)
+	}
+
+	names := make([]string, len(r.config.ConsensusExecutors))
+	perExecutor := make([][]*Result, len(r.config.ConsensusExecutors))
+	for i := range r.config.ConsensusExecutors {
+		execCfg := &r.config.ConsensusExecutors[i]
+		names[i] = executorLabel(execCfg.Name)
+		r.log(fmt.Sprintf("  Consensus review on %s", names[i]))
+		r.startPhase(fmt.Sprintf("%s on %s", phase, names[i]))
+
+		bound := make([]AgentConfig, len(agents))
+		for j, a := range agents {
+			a.executor = execCfg
+			bound[j] = a
+		}
+		results, err := run(ctx, bound, workingDir, filesChanged, diffChunks)
+		if err != nil {
+			return nil, err
+		}
+		perExecutor[i] = results
+	}
+
+	merged := make([]*Result, len(agents))
+	for j := range agents {
+		byExecutor := make([]*Result, len(perExecutor))
+		for i := range perExecutor {
+			byExecutor[i] = perExecutor[i][j]
+		}
+		merged[j] = mergeConsensus(r.config.ConsensusMode, names, byExecutor)
+
+		found := 0
+		for _, res := range byExecutor {
+			found += len(res.Issues)
+		}
+		r.log(fmt.Sprintf("  Agent %s: %d issues after %s consensus (%d reported)",
+			agents[j].Name, len(merged[j].Issues), consensusMode(r.config.ConsensusMode), found))
+	}
+	return merged, nil
+}
+
+// mergeConsensus combines one agent's results from several executors.
+// Issues are matched by file and nearby lines, at most one per executor;
+// matched issues keep the text of the first executor that reported them and
+// the highest severity any executor gave.
+// In intersect mode an executor error fails the agent, since no consensus
+// is possible; in union mode only an error on every executor does.
+func mergeConsensus(mode string, executors []string, results []*Result) *Result {
+	merged := &Result{AgentName: results[0].AgentName}
+	var groups []Issue
+	var errs []string
+	succeeded := 0
+	for i, res := range results {
+		merged.Duration = max(merged.Duration, res.Duration)
+		merged.TokensUsed += res.TokensUsed
+		if res.Error != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", executors[i], res.Error))
+			continue
+		}
+		succeeded++
+		if merged.Summary == "" {
+			merged.Summary = res.Summary
+		}
+
+		for _, issue := range res.Issues {
+			g := slices.IndexFunc(groups, func(g Issue) bool {
+				return !slices.Contains(g.ReportedBy, executors[i]) && issuesMatch(g, issue)
+			})
+			if g < 0 {
+				issue.ReportedBy = []string{executors[i]}
+				groups = append(groups, issue)
+				continue
+			}
+			groups[g].ReportedBy = append(groups[g].ReportedBy, executors[i])
+			if severityRank(issue.Severity) > severityRank(groups[g].Severity) {
+				groups[g].Severity = issue.Severity
+			}
+		}
+	}
+
+	if len(errs) > 0 && (consensusMode(mode) == ConsensusIntersect || succeeded == 0) {
+		merged.Error = fmt.Errorf("consensus review failed: %s", strings.Join(errs, "; "))
+		return merged
+	}
+
+	merged.Issues = make([]Issue, 0, len(groups))
+	for _, g := range groups {
+		if consensusMode(mode) == ConsensusIntersect && len(g.ReportedBy) < len(executors) {
+			continue
+		}
+		merged.Issues = append(merged.Issues, g)
+	}
+	return merged
+}
+
+// issuesMatch reports whether two executors' issues describe the same
+// finding: the same file and overlapping or nearby lines.
+func issuesMatch(a, b Issue) bool {
+	if filepath.Clean(a.File) != filepath.Clean(b.File) {
+		return false
+	}
+	if a.Line == 0 || b.Line == 0 {
+		return a.Line == b.Line
+	}
+	aEnd, bEnd := max(a.Line, a.LineEnd), max(b.Line, b.LineEnd)
+	return a.Line <= bEnd+consensusLineSlack && b.Line <= aEnd+consensusLineSlack
+}
+
+// consensusMode returns mode, defaulting to ConsensusIntersect.
+func consensusMode(mode string) string {
+	if mode == ConsensusUnion {
+		return ConsensusUnion
+	}
+	return ConsensusIntersect
+}
+
+// executorLabel names an executor in logs and ReportedBy.
+func executorLabel(name string) string {
+	if name == "" {
+		return "claude"
+	}
+	return name
+}
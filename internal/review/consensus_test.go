@@ -0,0 +1,122 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
+)
+
+func TestIssuesMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Issue
+		want bool
+	}{
+		{name: "same line", a: Issue{File: "a.go", Line: 10}, b: Issue{File: "./a.go", Line: 10}, want: true},
+		{name: "nearby line", a: Issue{File: "a.go", Line: 10}, b: Issue{File: "a.go", Line: 13}, want: true},
+		{name: "inside range", a: Issue{File: "a.go", Line: 10, LineEnd: 40}, b: Issue{File: "a.go", Line: 30}, want: true},
+		{name: "far apart", a: Issue{File: "a.go", Line: 10}, b: Issue{File: "a.go", Line: 20}, want: false},
+		{name: "other file", a: Issue{File: "a.go", Line: 10}, b: Issue{File: "b.go", Line: 10}, want: false},
+		{name: "both without line", a: Issue{File: "a.go"}, b: Issue{File: "a.go"}, want: true},
+		{name: "one without line", a: Issue{File: "a.go"}, b: Issue{File: "a.go", Line: 1}, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, issuesMatch(tc.a, tc.b))
+			assert.Equal(t, tc.want, issuesMatch(tc.b, tc.a))
+		})
+	}
+}
+
+func TestMergeConsensus(t *testing.T) {
+	executors := []string{"claude", "codex"}
+	results := func() []*Result {
+		return []*Result{
+			{AgentName: "bug", Summary: "claude summary", TokensUsed: 10, Issues: []Issue{
+				{File: "a.go", Line: 10, Severity: SeverityMedium, Description: "nil deref"},
+				{File: "b.go", Line: 5, Severity: SeverityLow, Description: "hallucinated"},
+			}},
+			{AgentName: "bug", TokensUsed: 20, Issues: []Issue{
+				{File: "a.go", Line: 11, Severity: SeverityHigh, Description: "pointer may be nil"},
+				{File: "c.go", Line: 1, Severity: SeverityLow, Description: "only codex"},
+			}},
+		}
+	}
+
+	t.Run("intersect keeps agreed issues", func(t *testing.T) {
+		merged := mergeConsensus(ConsensusIntersect, executors, results())
+		require.NoError(t, merged.Error)
+		require.Len(t, merged.Issues, 1)
+		assert.Equal(t, "nil deref", merged.Issues[0].Description)
+		assert.Equal(t, SeverityHigh, merged.Issues[0].Severity)
+		assert.Equal(t, []string{"claude", "codex"}, merged.Issues[0].ReportedBy)
+		assert.Equal(t, "claude summary", merged.Summary)
+		assert.Equal(t, 30, merged.TokensUsed)
+	})
+
+	t.Run("empty mode defaults to intersect", func(t *testing.T) {
+		assert.Len(t, mergeConsensus("", executors, results()).Issues, 1)
+	})
+
+	t.Run("union merges duplicates", func(t *testing.T) {
+		merged := mergeConsensus(ConsensusUnion, executors, results())
+		require.Len(t, merged.Issues, 3)
+		assert.Equal(t, []string{"claude", "codex"}, merged.Issues[0].ReportedBy)
+		assert.Equal(t, []string{"claude"}, merged.Issues[1].ReportedBy)
+		assert.Equal(t, []string{"codex"}, merged.Issues[2].ReportedBy)
+	})
+
+	t.Run("one issue per executor in a match", func(t *testing.T) {
+		res := results()
+		res[0].Issues = []Issue{{File: "a.go", Line: 10}, {File: "a.go", Line: 11}}
+		res[1].Issues = []Issue{{File: "a.go", Line: 10}}
+		assert.Len(t, mergeConsensus(ConsensusIntersect, executors, res).Issues, 1)
+	})
+
+	t.Run("executor error", func(t *testing.T) {
+		res := results()
+		res[1] = &Result{AgentName: "bug", Error: errors.New("timeout")}
+		assert.ErrorContains(t, mergeConsensus(ConsensusIntersect, executors, res).Error, "codex: timeout")
+
+		merged := mergeConsensus(ConsensusUnion, executors, res)
+		require.NoError(t, merged.Error)
+		assert.Len(t, merged.Issues, 2)
+	})
+}
+
+func TestRunner_ConsensusReview(t *testing.T) {
+	runner := NewRunner(Config{
+		Agents:             []AgentConfig{{Name: "bug"}},
+		ConsensusExecutors: []executor.Config{{Name: "claude"}, {Name: "codex"}},
+		Parallel:           true,
+	})
+	var built []string
+	runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+		execName := agentCfg.executor.Name
+		built = append(built, agentCfg.Name+"@"+execName)
+		mock := NewMockAgent(agentCfg.Name)
+		mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*Result, error) {
+			issues := []Issue{{File: "a.go", Line: 3, Severity: SeverityHigh, Description: "bug from " + execName}}
+			if execName == "codex" {
+				issues = append(issues, Issue{File: "z.go", Line: 1, Severity: SeverityLow, Description: "noise"})
+			}
+			return &Result{AgentName: agentCfg.Name, Issues: issues}, nil
+		})
+		return mock
+	})
+
+	result, err := runner.RunIteration(context.Background(), t.TempDir(), []string{"a.go"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"bug@claude", "bug@codex"}, built)
+	assert.False(t, result.Passed)
+	issues := result.AllIssues()
+	require.Len(t, issues, 1)
+	assert.Equal(t, "bug from claude", issues[0].Description)
+	assert.Contains(t, FormatIssuesMarkdown(result.Results), "_(reported by claude, codex)_")
+}
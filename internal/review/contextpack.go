@@ -0,0 +1,200 @@
+package review
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ContextReviewer is implemented by agents that can be pointed at files
+// related to the change, besides the files to review.
+type ContextReviewer interface {
+	// SetContextFiles sets the related files listed in the following
+	// reviews, relative to the working directory.
+	SetContextFiles(files []string)
+}
+
+// refreshContextFiles computes the context pack of filesChanged for the
+// agents of the coming pass, when Config.ContextFiles allows any.
+func (r *Runner) refreshContextFiles(ctx context.Context, workingDir string, filesChanged []string) {
+	r.contextFiles = nil
+	if r.config.ContextFiles <= 0 || len(filesChanged) == 0 {
+		return
+	}
+	files, err := relatedFiles(ctx, workingDir, filesChanged, r.config.ContextFiles)
+	if err != nil {
+		r.log(fmt.Sprintf("Warning: context pack incomplete: %v", err))
+	}
+	if len(files) > 0 {
+		r.log(fmt.Sprintf("Context pack: %d related files", len(files)))
+	}
+	r.contextFiles = files
+}
+
+// giveContext passes the context pack to agent when it can take one.
+func (r *Runner) giveContext(agent Agent) {
+	if cr, ok := agent.(ContextReviewer); ok {
+		cr.SetContextFiles(r.contextFiles)
+	}
+}
+
+// relatedFiles returns up to limit files tightly coupled to the changed Go
+// files: first the other files of their packages, then the files of
+// packages in the same module that import them, found with go list. Paths
+// are relative to workingDir; changed files and tests are left out. Files
+// found before go list fails are returned with its error.
+func relatedFiles(ctx context.Context, workingDir string, filesChanged []string, limit int) ([]string, error) {
+	changed := make(map[string]bool, len(filesChanged))
+	var dirs []string
+	for _, f := range filesChanged {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(workingDir, f)
+		}
+		changed[f] = true
+		if isGoSource(f) && !slices.Contains(dirs, filepath.Dir(f)) {
+			dirs = append(dirs, filepath.Dir(f))
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+	slices.Sort(dirs)
+
+	var related []string
+	add := func(f string) bool {
+		if changed[f] || slices.Contains(related, f) {
+			return len(related) < limit
+		}
+		related = append(related, f)
+		return len(related) < limit
+	}
+	relative := func() []string {
+		out := make([]string, 0, len(related))
+		for _, f := range related {
+			if rel, err := filepath.Rel(workingDir, f); err == nil {
+				f = rel
+			}
+			out = append(out, filepath.ToSlash(f))
+		}
+		return out
+	}
+
+	for _, dir := range dirs {
+		for _, f := range goSources(dir) {
+			if !add(f) {
+				return relative(), nil
+			}
+		}
+	}
+
+	pkgs, err := listPackages(ctx, moduleRoot(dirs[0]))
+	if err != nil {
+		return relative(), err
+	}
+	targets := make(map[string]bool)
+	for _, p := range pkgs {
+		if slices.Contains(dirs, p.dir) {
+			targets[p.importPath] = true
+		}
+	}
+	for _, p := range pkgs {
+		if slices.Contains(dirs, p.dir) || !slices.ContainsFunc(p.imports, func(i string) bool { return targets[i] }) {
+			continue
+		}
+		for _, f := range goSources(p.dir) {
+			if importsAny(f, targets) && !add(f) {
+				return relative(), nil
+			}
+		}
+	}
+	return relative(), nil
+}
+
+// goPackage is one package reported by go list.
+type goPackage struct {
+	dir        string
+	importPath string
+	imports    []string
+}
+
+// listPackages lists the packages of the module at root, sorted by import
+// path.
+func listPackages(ctx context.Context, root string) ([]goPackage, error) {
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-f", "{{.Dir}}\t{{.ImportPath}}\t{{join .Imports \" \"}}", "./...")
+	cmd.Dir = root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var pkgs []goPackage
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 || fields[0] == "" {
+			continue
+		}
+		pkgs = append(pkgs, goPackage{dir: fields[0], importPath: fields[1], imports: strings.Fields(fields[2])})
+	}
+	slices.SortFunc(pkgs, func(a, b goPackage) int { return strings.Compare(a.importPath, b.importPath) })
+	return pkgs, scanner.Err()
+}
+
+// moduleRoot returns the closest directory at or above dir holding a go.mod,
+// or dir when there is none.
+func moduleRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// goSources returns the non-test Go files in dir, sorted.
+func goSources(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		if f := filepath.Join(dir, e.Name()); !e.IsDir() && isGoSource(f) {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+func isGoSource(f string) bool {
+	return strings.HasSuffix(f, ".go") && !strings.HasSuffix(f, "_test.go")
+}
+
+// importsAny reports whether the Go file f imports one of the packages.
+func importsAny(f string, importPaths map[string]bool) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), f, nil, parser.ImportsOnly)
+	if err != nil {
+		return false
+	}
+	for _, spec := range file.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil && importPaths[p] {
+			return true
+		}
+	}
+	return false
+}
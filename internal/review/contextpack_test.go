@@ -0,0 +1,62 @@
+package review
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeModule writes files, given relative to dir, to a Go module at dir.
+func writeModule(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	files["go.mod"] = "module example.com/m\n\ngo 1.22\n"
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestRelatedFiles(t *testing.T) {
+	t.Setenv("GOFLAGS", "-mod=mod")
+	dir := t.TempDir()
+	writeModule(t, dir, map[string]string{
+		"store/store.go":      "package store\n\ntype Store interface{ Get(string) string }\n",
+		"store/cache.go":      "package store\n\ntype Cache struct{}\n",
+		"store/store_test.go": "package store\n",
+		"api/api.go":          "package api\n\nimport \"example.com/m/store\"\n\nvar S store.Store\n",
+		"api/util.go":         "package api\n\nfunc util() {}\n",
+		"cmd/main.go":         "package main\n\nimport _ \"example.com/m/api\"\n\nfunc main() {}\n",
+	})
+	ctx := context.Background()
+
+	files, err := relatedFiles(ctx, dir, []string{"store/store.go"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"store/cache.go", "api/api.go"}, files, "same package first, then files importing it")
+
+	files, err = relatedFiles(ctx, dir, []string{"store/store.go"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"store/cache.go"}, files, "capped")
+
+	files, err = relatedFiles(ctx, dir, []string{filepath.Join(dir, "store", "store.go"), "store/cache.go"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api/api.go"}, files, "changed files are not repeated")
+
+	files, err = relatedFiles(ctx, dir, []string{"README.md"}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, files, "no Go files changed")
+}
+
+func TestClaudeAgent_BuildPromptWithContextFiles(t *testing.T) {
+	agent := NewClaudeAgent("quality", nil, "Review.")
+	assert.NotContains(t, agent.buildPrompt([]string{"store/store.go"}, ""), "## Related Files")
+
+	agent.SetContextFiles([]string{"store/cache.go", "api/api.go"})
+	prompt := agent.buildPrompt([]string{"store/store.go"}, "")
+	assert.Contains(t, prompt, "## Files to Review\n- store/store.go\n\n## Related Files\n")
+	assert.Contains(t, prompt, "- store/cache.go\n- api/api.go\n")
+}
@@ -0,0 +1,83 @@
+package review
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDiff(t *testing.T) {
+	fileA := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-old\n+new\n"
+	fileB := "diff --git a/b.go b/b.go\n--- a/b.go\n+++ b/b.go\n@@ -1 +1 @@\n-x\n+y\n"
+	big := "diff --git a/big.go b/big.go\n" + strings.Repeat("+line\n", 50)
+
+	assert.Nil(t, splitDiff("", 100))
+	assert.Equal(t, []string{fileA + fileB}, splitDiff(fileA+fileB, 1000))
+	assert.Equal(t, []string{fileA, fileB}, splitDiff(fileA+fileB, len(fileA)+10))
+
+	chunks := splitDiff(fileA+big, 100)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, fileA, chunks[0])
+	assert.Contains(t, chunks[1], "diff truncated")
+}
+
+// diffAgent records the diff parts it was given.
+type diffAgent struct {
+	*MockAgent
+	parts []string
+}
+
+func (a *diffAgent) ReviewDiff(_ context.Context, _ string, _ []string, diff string, part, parts int) (*Result, error) {
+	a.parts = append(a.parts, diff)
+	return &Result{AgentName: a.Name(), Issues: []Issue{{File: "a.go", Severity: SeverityLow, Description: diff[:10]}}, Summary: "part"}, nil
+}
+
+func TestRunner_RunIteration_IncludeDiff(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0o644))
+	cmd := exec.Command("git", "commit", "--allow-empty", "-q", "-m", "init")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.go"), []byte("package b\n"), 0o644))
+
+	agent := &diffAgent{MockAgent: NewMockAgent("diff")}
+	runner := NewRunner(Config{Agents: []AgentConfig{{Name: "diff"}}, IncludeDiff: true, DiffLimit: 80})
+	runner.RegisterAgent(agent)
+
+	result, err := runner.RunIteration(context.Background(), dir, []string{"a.go", "b.go"})
+	require.NoError(t, err)
+
+	require.Len(t, agent.parts, 2, "each new file fits in its own part")
+	assert.Contains(t, agent.parts[0], "+++ b/a.go")
+	assert.Contains(t, agent.parts[1], "+++ b/b.go")
+	require.Len(t, result.Results, 1)
+	assert.Len(t, result.Results[0].Issues, 2)
+	assert.Equal(t, "part part", result.Results[0].Summary)
+}
+
+func TestClaudeAgent_BuildPromptWithDiff(t *testing.T) {
+	agent := NewClaudeAgent("test", nil, "Review.")
+	prompt := agent.buildPrompt([]string{"a.go"}, formatDiffSection("diff --git a/a.go b/a.go\n+x\n", 2, 3))
+
+	assert.Contains(t, prompt, "## Diff")
+	assert.Contains(t, prompt, "part 2 of 3")
+	assert.Contains(t, prompt, "```diff\ndiff --git a/a.go b/a.go\n+x\n```")
+	assert.Less(t, strings.Index(prompt, "## Files to Review"), strings.Index(prompt, "## Diff"))
+}
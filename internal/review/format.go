@@ -0,0 +1,271 @@
+package review
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Groupings of issues in FormatIssuesMarkdownWith.
+const (
+	GroupByAgent    = "agent"
+	GroupByFile     = "file"
+	GroupBySeverity = "severity"
+)
+
+// ValidGroupBy reports whether g is a grouping of FormatIssuesMarkdownWith.
+func ValidGroupBy(g string) bool {
+	switch g {
+	case "", GroupByAgent, GroupByFile, GroupBySeverity:
+		return true
+	}
+	return false
+}
+
+// MarkdownOptions control FormatIssuesMarkdownWith.
+type MarkdownOptions struct {
+	GroupBy string             // GroupByAgent (default), GroupByFile, or GroupBySeverity
+	Snippet func(Issue) string // code shown under an issue ("" or nil = none)
+	Omitted int                // issues left out by LimitIssues, noted at the end
+}
+
+// FormatIssuesMarkdownWith formats issues as markdown under a heading per
+// agent, file, or severity. Agent errors are listed first whatever the
+// grouping.
+func FormatIssuesMarkdownWith(results []*Result, opts MarkdownOptions) string {
+	var b strings.Builder
+
+	for _, result := range results {
+		if result.Error == nil {
+			continue
+		}
+		b.WriteString("### ")
+		b.WriteString(result.AgentName)
+		b.WriteString(" (error)\n")
+		b.WriteString("Error: ")
+		b.WriteString(result.Error.Error())
+		b.WriteString("\n\n")
+	}
+
+	for _, g := range groupIssues(results, opts.GroupBy) {
+		b.WriteString("### ")
+		b.WriteString(g.title)
+		b.WriteString(" (")
+		b.WriteString(pluralize(len(g.issues), "issue", "issues"))
+		b.WriteString(")\n\n")
+		for _, gi := range g.issues {
+			agent := gi.agent
+			if opts.GroupBy == "" || opts.GroupBy == GroupByAgent {
+				agent = ""
+			}
+			writeIssue(&b, gi.issue, agent)
+			if opts.Snippet != nil {
+				writeSnippet(&b, opts.Snippet(gi.issue))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if opts.Omitted > 0 {
+		b.WriteString("_")
+		b.WriteString(pluralize(opts.Omitted, "more issue", "more issues"))
+		b.WriteString(" omitted; they follow once the ones above are fixed._\n")
+	}
+	return b.String()
+}
+
+// LimitIssues keeps the n most severe issues of results, and returns them
+// and the others, each in results' order. Agent errors are kept. n <= 0
+// keeps every issue.
+func LimitIssues(results []*Result, n int) (kept, rest []*Result) {
+	type ref struct{ result, issue int }
+	var refs []ref
+	for i, result := range results {
+		if result.Error != nil {
+			continue
+		}
+		for j := range result.Issues {
+			refs = append(refs, ref{i, j})
+		}
+	}
+	if n <= 0 || len(refs) <= n {
+		return results, nil
+	}
+
+	slices.SortStableFunc(refs, func(a, b ref) int {
+		return cmp.Compare(severityRank(results[b.result].Issues[b.issue].Severity),
+			severityRank(results[a.result].Issues[a.issue].Severity))
+	})
+	keep := make(map[ref]bool, n)
+	for _, r := range refs[:n] {
+		keep[r] = true
+	}
+
+	for i, result := range results {
+		if result.Error != nil {
+			kept = append(kept, result)
+			continue
+		}
+		in, out := *result, *result
+		in.Issues, out.Issues = nil, nil
+		for j, issue := range result.Issues {
+			if keep[ref{i, j}] {
+				in.Issues = append(in.Issues, issue)
+			} else {
+				out.Issues = append(out.Issues, issue)
+			}
+		}
+		if len(in.Issues) > 0 {
+			kept = append(kept, &in)
+		}
+		if len(out.Issues) > 0 {
+			rest = append(rest, &out)
+		}
+	}
+	return kept, rest
+}
+
+// groupedIssue is an issue with the agent that reported it.
+type groupedIssue struct {
+	issue Issue
+	agent string
+}
+
+// issueGroup is the issues under one heading.
+type issueGroup struct {
+	title  string
+	issues []groupedIssue
+}
+
+// groupIssues groups the issues of results by agent, in results' order; by
+// file, sorted by path with issues by line and issues without a file last;
+// or by severity, most severe first.
+func groupIssues(results []*Result, by string) []issueGroup {
+	var groups []issueGroup
+	index := make(map[string]int)
+	add := func(key, title string, gi groupedIssue) {
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, issueGroup{title: title})
+		}
+		groups[i].issues = append(groups[i].issues, gi)
+	}
+
+	for _, result := range results {
+		if result.Error != nil {
+			continue
+		}
+		for _, issue := range result.Issues {
+			gi := groupedIssue{issue: issue, agent: result.AgentName}
+			switch by {
+			case GroupByFile:
+				if issue.File == "" {
+					add("", "Other", gi)
+				} else {
+					add(issue.File, "`"+issue.File+"`", gi)
+				}
+			case GroupBySeverity:
+				if severityRank(issue.Severity) == 0 {
+					add("", "Other", gi)
+				} else {
+					add(string(issue.Severity), string(issue.Severity), gi)
+				}
+			default:
+				add(result.AgentName, result.AgentName, gi)
+			}
+		}
+	}
+
+	switch by {
+	case GroupByFile:
+		slices.SortStableFunc(groups, func(a, b issueGroup) int {
+			fa, fb := a.issues[0].issue.File, b.issues[0].issue.File
+			switch {
+			case fa == "" || fb == "":
+				return cmp.Compare(fb, fa) // the "Other" group last
+			default:
+				return cmp.Compare(fa, fb)
+			}
+		})
+		for _, g := range groups {
+			slices.SortStableFunc(g.issues, func(a, b groupedIssue) int { return cmp.Compare(a.issue.Line, b.issue.Line) })
+		}
+	case GroupBySeverity:
+		slices.SortStableFunc(groups, func(a, b issueGroup) int {
+			return cmp.Compare(severityRank(b.issues[0].issue.Severity), severityRank(a.issues[0].issue.Severity))
+		})
+	}
+	return groups
+}
+
+// writeIssue writes issue as a list item, without the trailing newline,
+// naming agent when it is not "".
+func writeIssue(b *strings.Builder, issue Issue, agent string) {
+	b.WriteString("- **[")
+	b.WriteString(string(issue.Severity))
+	b.WriteString("]** ")
+	if issue.File != "" {
+		b.WriteString("`")
+		b.WriteString(issue.File)
+		if issue.Line > 0 {
+			b.WriteString(":")
+			b.WriteString(strconv.Itoa(issue.Line))
+			if issue.LineEnd > 0 {
+				b.WriteString("-")
+				b.WriteString(strconv.Itoa(issue.LineEnd))
+			}
+		}
+		b.WriteString("` - ")
+	}
+	b.WriteString(issue.Description)
+	if agent != "" {
+		b.WriteString(" _(agent: ")
+		b.WriteString(agent)
+		b.WriteString(")_")
+	}
+	if issue.PreviousRuns > 0 {
+		b.WriteString(" _(recurring: seen in ")
+		b.WriteString(pluralize(issue.PreviousRuns, "previous run", "previous runs"))
+		b.WriteString(")_")
+	}
+	if len(issue.ReportedBy) > 0 {
+		b.WriteString(" _(reported by ")
+		b.WriteString(strings.Join(issue.ReportedBy, ", "))
+		b.WriteString(")_")
+	}
+	if len(issue.Owners) > 0 {
+		b.WriteString(" _(owners: ")
+		b.WriteString(strings.Join(issue.Owners, ", "))
+		b.WriteString(")_")
+	}
+	if issue.Suggestion != "" {
+		b.WriteString("\n  - _Suggestion: ")
+		b.WriteString(issue.Suggestion)
+		b.WriteString("_")
+	}
+	if issue.PatchApplies {
+		b.WriteString("\n  - _Suggested patch (applies cleanly):_\n\n    ```diff\n")
+		for line := range strings.Lines(strings.TrimRight(issue.Patch, "\n") + "\n") {
+			b.WriteString("    ")
+			b.WriteString(line)
+		}
+		b.WriteString("    ```")
+	}
+}
+
+// writeSnippet writes code under an issue as an indented code block.
+func writeSnippet(b *strings.Builder, code string) {
+	if code == "" {
+		return
+	}
+	b.WriteString("\n\n    ```\n")
+	for line := range strings.Lines(strings.TrimRight(code, "\n") + "\n") {
+		b.WriteString("    ")
+		b.WriteString(line)
+	}
+	b.WriteString("    ```")
+}
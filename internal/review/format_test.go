@@ -0,0 +1,105 @@
+package review
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func formatTestResults() []*Result {
+	return []*Result{
+		{
+			AgentName: "quality",
+			Issues: []Issue{
+				{File: "b.go", Line: 20, Severity: SeverityLow, Description: "Long function"},
+				{File: "a.go", Line: 7, Severity: SeverityHigh, Description: "Error ignored"},
+				{Severity: SeverityMedium, Description: "No changelog entry"},
+			},
+		},
+		{AgentName: "flaky", Error: errors.New("timed out")},
+		{
+			AgentName: "security",
+			Issues: []Issue{
+				{File: "b.go", Line: 3, Severity: SeverityCritical, Description: "SQL injection"},
+			},
+		},
+	}
+}
+
+func TestFormatIssuesMarkdownWith_GroupBy(t *testing.T) {
+	t.Run("agent", func(t *testing.T) {
+		output := FormatIssuesMarkdownWith(formatTestResults(), MarkdownOptions{GroupBy: GroupByAgent})
+		assert.Equal(t, FormatIssuesMarkdown(formatTestResults()), output)
+		assert.NotContains(t, output, "_(agent:")
+	})
+
+	t.Run("file", func(t *testing.T) {
+		output := FormatIssuesMarkdownWith(formatTestResults(), MarkdownOptions{GroupBy: GroupByFile})
+		assertInOrder(t, output,
+			"### flaky (error)",
+			"### `a.go` (1 issue)", "Error ignored _(agent: quality)_",
+			"### `b.go` (2 issues)", "`b.go:3` - SQL injection _(agent: security)_", "`b.go:20` - Long function",
+			"### Other (1 issue)", "No changelog entry")
+	})
+
+	t.Run("severity", func(t *testing.T) {
+		output := FormatIssuesMarkdownWith(formatTestResults(), MarkdownOptions{GroupBy: GroupBySeverity})
+		assertInOrder(t, output,
+			"### critical (1 issue)", "SQL injection",
+			"### high (1 issue)", "Error ignored",
+			"### medium (1 issue)", "No changelog entry",
+			"### low (1 issue)", "Long function")
+	})
+}
+
+func TestFormatIssuesMarkdownWith_SnippetAndOmitted(t *testing.T) {
+	results := []*Result{{AgentName: "quality", Issues: []Issue{
+		{File: "a.go", Line: 2, Severity: SeverityHigh, Description: "Error ignored"},
+		{Severity: SeverityLow, Description: "No location"},
+	}}}
+	output := FormatIssuesMarkdownWith(results, MarkdownOptions{
+		Snippet: func(issue Issue) string {
+			if issue.File == "" {
+				return ""
+			}
+			return "    2  _ = f()\n"
+		},
+		Omitted: 3,
+	})
+
+	assert.Contains(t, output, "Error ignored\n\n    ```\n        2  _ = f()\n    ```\n")
+	assert.Contains(t, output, "No location\n\n_3 more issues omitted; they follow once the ones above are fixed._\n")
+}
+
+func TestLimitIssues(t *testing.T) {
+	results := formatTestResults()
+
+	kept, rest := LimitIssues(results, 0)
+	assert.Equal(t, results, kept)
+	assert.Nil(t, rest)
+
+	kept, rest = LimitIssues(results, 2)
+	require.Len(t, kept, 3)
+	assert.Equal(t, "quality", kept[0].AgentName)
+	assert.Equal(t, []Issue{results[0].Issues[1]}, kept[0].Issues)
+	assert.Equal(t, "flaky", kept[1].AgentName)
+	assert.Equal(t, "security", kept[2].AgentName)
+
+	require.Len(t, rest, 1)
+	assert.Equal(t, "quality", rest[0].AgentName)
+	assert.Equal(t, []Issue{results[0].Issues[0], results[0].Issues[2]}, rest[0].Issues)
+	assert.Len(t, results[0].Issues, 3, "results are not modified")
+}
+
+func assertInOrder(t *testing.T, s string, parts ...string) {
+	t.Helper()
+	rest := s
+	for _, part := range parts {
+		i := strings.Index(rest, part)
+		require.GreaterOrEqual(t, i, 0, "%q not found in order in:\n%s", part, s)
+		rest = rest[i+len(part):]
+	}
+}
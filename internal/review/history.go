@@ -0,0 +1,195 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Findings are forgotten once not reported for historyMaxAge, and beyond
+// historyMaxEntries the least recently reported ones go first, so the
+// history of a long-lived repository stays small.
+const (
+	historyMaxAge     = 90 * 24 * time.Hour
+	historyMaxEntries = 5000
+)
+
+// historyEntry records how often a finding has been reported across runs.
+type historyEntry struct {
+	Runs      int       `json:"runs"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	LastRunID string    `json:"last_run_id"`
+}
+
+// issueHistory is the persisted set of finding fingerprints for one repository.
+type issueHistory struct {
+	path    string
+	Entries map[string]*historyEntry `json:"entries"`
+}
+
+// historyPath returns the history file for a working directory. Each
+// repository gets its own file, keyed by a hash of its absolute path.
+func historyPath(historyDir, workingDir string) string {
+	abs, err := filepath.Abs(workingDir)
+	if err != nil {
+		abs = workingDir
+	}
+	hash := sha256.Sum256([]byte(abs))
+	return filepath.Join(historyDir, fmt.Sprintf("%x.json", hash[:8]))
+}
+
+// loadIssueHistory reads the history file, returning an empty history when
+// it does not exist yet.
+func loadIssueHistory(path string) (*issueHistory, error) {
+	h := &issueHistory{path: path, Entries: make(map[string]*historyEntry)}
+
+	data, err := os.ReadFile(path) //nolint:gosec // path is derived from the state dir
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read review history: %w", err)
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("parse review history: %w", err)
+	}
+	if h.Entries == nil {
+		h.Entries = make(map[string]*historyEntry)
+	}
+	return h, nil
+}
+
+// record marks a fingerprint as seen in runID and returns the number of
+// earlier runs that reported it.
+func (h *issueHistory) record(fingerprint, runID string, now time.Time) int {
+	entry, ok := h.Entries[fingerprint]
+	if !ok {
+		entry = &historyEntry{FirstSeen: now}
+		h.Entries[fingerprint] = entry
+	}
+	if entry.LastRunID != runID {
+		entry.Runs++
+		entry.LastRunID = runID
+	}
+	entry.LastSeen = now
+	return entry.Runs - 1
+}
+
+// prune forgets the findings not reported since historyMaxAge before now,
+// and the least recently reported ones beyond historyMaxEntries.
+func (h *issueHistory) prune(now time.Time) {
+	for fingerprint, entry := range h.Entries {
+		if now.Sub(entry.LastSeen) > historyMaxAge {
+			delete(h.Entries, fingerprint)
+		}
+	}
+	if len(h.Entries) <= historyMaxEntries {
+		return
+	}
+	fingerprints := slices.SortedFunc(maps.Keys(h.Entries), func(a, b string) int {
+		return h.Entries[b].LastSeen.Compare(h.Entries[a].LastSeen)
+	})
+	for _, fingerprint := range fingerprints[historyMaxEntries:] {
+		delete(h.Entries, fingerprint)
+	}
+}
+
+// save writes the history atomically.
+func (h *issueHistory) save() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return fmt.Errorf("create review history dir: %w", err)
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal review history: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), ".history-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	return os.Rename(tmpName, h.path)
+}
+
+// recurrenceFingerprint identifies a finding across runs. Unlike
+// issueFingerprint it ignores line numbers, which shift as code changes.
+func recurrenceFingerprint(agent string, issue Issue) string {
+	desc := strings.Join(strings.Fields(strings.ToLower(issue.Description)), " ")
+	data := fmt.Sprintf("%s|%s|%s|%s", agent, issue.File, strings.ToLower(issue.Category), desc)
+	hash := sha256.Sum256([]byte(data))
+	return fmt.Sprintf("%x", hash[:8])
+}
+
+// escalateSeverity raises a severity by one level, capped at critical.
+func escalateSeverity(s Severity) Severity {
+	switch s {
+	case SeverityInfo:
+		return SeverityLow
+	case SeverityLow:
+		return SeverityMedium
+	case SeverityMedium:
+		return SeverityHigh
+	case SeverityHigh, SeverityCritical:
+		return SeverityCritical
+	default:
+		return s
+	}
+}
+
+// trackRecurringIssues tags issues reported in earlier runs with their
+// recurrence count, escalates long-standing ones, and persists the history.
+// Failures are logged and never block the review.
+func (r *Runner) trackRecurringIssues(workingDir string, results []*Result) {
+	if r.config.HistoryDir == "" {
+		return
+	}
+
+	history, err := loadIssueHistory(historyPath(r.config.HistoryDir, workingDir))
+	if err != nil {
+		r.log(fmt.Sprintf("Warning: %v", err))
+		return
+	}
+
+	now := time.Now()
+	recurring := 0
+	for _, res := range results {
+		for i := range res.Issues {
+			issue := &res.Issues[i]
+			issue.PreviousRuns = history.record(recurrenceFingerprint(res.AgentName, *issue), r.runID, now)
+			if issue.PreviousRuns == 0 {
+				continue
+			}
+			recurring++
+			if r.config.EscalateRecurringAfter > 0 && issue.PreviousRuns >= r.config.EscalateRecurringAfter {
+				issue.Severity = escalateSeverity(issue.Severity)
+			}
+		}
+	}
+
+	if recurring > 0 {
+		r.log(fmt.Sprintf("%s seen in previous runs", pluralize(recurring, "issue was", "issues were")))
+	}
+
+	history.prune(now)
+	if err := history.save(); err != nil {
+		r.log(fmt.Sprintf("Warning: failed to save review history: %v", err))
+	}
+}
@@ -0,0 +1,123 @@
+package review
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueHistory_RecordCountsRuns(t *testing.T) {
+	h, err := loadIssueHistory(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	require.Empty(t, h.Entries)
+
+	now := time.Now()
+	assert.Equal(t, 0, h.record("fp", "run-1", now))
+	assert.Equal(t, 0, h.record("fp", "run-1", now), "same run must not count twice")
+	assert.Equal(t, 1, h.record("fp", "run-2", now))
+	assert.Equal(t, 2, h.record("fp", "run-3", now))
+}
+
+func TestIssueHistory_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.json")
+	h, err := loadIssueHistory(path)
+	require.NoError(t, err)
+
+	h.record("fp", "run-1", time.Now())
+	require.NoError(t, h.save())
+
+	loaded, err := loadIssueHistory(path)
+	require.NoError(t, err)
+	require.Contains(t, loaded.Entries, "fp")
+	assert.Equal(t, 1, loaded.Entries["fp"].Runs)
+	assert.Equal(t, 1, loaded.record("fp", "run-2", time.Now()))
+}
+
+func TestIssueHistory_Prune(t *testing.T) {
+	h, err := loadIssueHistory(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+
+	now := time.Now()
+	h.record("old", "run-1", now.Add(-historyMaxAge-time.Hour))
+	h.record("recent", "run-1", now.Add(-time.Hour))
+	h.prune(now)
+	assert.NotContains(t, h.Entries, "old")
+	assert.Contains(t, h.Entries, "recent")
+
+	for i := range historyMaxEntries {
+		h.record(fmt.Sprintf("fp-%d", i), "run-2", now)
+	}
+	h.prune(now)
+	assert.Len(t, h.Entries, historyMaxEntries)
+	assert.NotContains(t, h.Entries, "recent", "the least recently reported go first")
+}
+
+func TestRecurrenceFingerprint_IgnoresLineAndWhitespace(t *testing.T) {
+	a := Issue{File: "a.go", Line: 10, Category: "Bug", Description: "Nil  pointer dereference"}
+	b := Issue{File: "a.go", Line: 42, Category: "bug", Description: "nil pointer dereference"}
+	c := Issue{File: "b.go", Line: 10, Category: "bug", Description: "nil pointer dereference"}
+
+	assert.Equal(t, recurrenceFingerprint("quality", a), recurrenceFingerprint("quality", b))
+	assert.NotEqual(t, recurrenceFingerprint("quality", a), recurrenceFingerprint("quality", c))
+	assert.NotEqual(t, recurrenceFingerprint("quality", a), recurrenceFingerprint("security", a))
+}
+
+func TestEscalateSeverity(t *testing.T) {
+	tests := []struct {
+		in   Severity
+		want Severity
+	}{
+		{SeverityInfo, SeverityLow},
+		{SeverityLow, SeverityMedium},
+		{SeverityMedium, SeverityHigh},
+		{SeverityHigh, SeverityCritical},
+		{SeverityCritical, SeverityCritical},
+		{Severity("unknown"), Severity("unknown")},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.in), func(t *testing.T) {
+			assert.Equal(t, tc.want, escalateSeverity(tc.in))
+		})
+	}
+}
+
+func TestRunner_TrackRecurringIssues(t *testing.T) {
+	historyDir := t.TempDir()
+	workingDir := t.TempDir()
+	newResults := func() []*Result {
+		return []*Result{{
+			AgentName: "quality",
+			Issues:    []Issue{{File: "a.go", Line: 1, Severity: SeverityMedium, Description: "Same problem"}},
+		}}
+	}
+
+	first := NewRunner(Config{HistoryDir: historyDir, EscalateRecurringAfter: 1})
+	results := newResults()
+	first.trackRecurringIssues(workingDir, results)
+	assert.Equal(t, 0, results[0].Issues[0].PreviousRuns)
+	assert.Equal(t, SeverityMedium, results[0].Issues[0].Severity)
+
+	// Later iterations of the same run do not count as recurrences.
+	results = newResults()
+	first.trackRecurringIssues(workingDir, results)
+	assert.Equal(t, 0, results[0].Issues[0].PreviousRuns)
+
+	second := NewRunner(Config{HistoryDir: historyDir, EscalateRecurringAfter: 1})
+	second.runID = "second"
+	results = newResults()
+	second.trackRecurringIssues(workingDir, results)
+	assert.Equal(t, 1, results[0].Issues[0].PreviousRuns)
+	assert.Equal(t, SeverityHigh, results[0].Issues[0].Severity)
+}
+
+func TestRunner_TrackRecurringIssuesDisabled(t *testing.T) {
+	r := NewRunner(Config{})
+	results := []*Result{{AgentName: "quality", Issues: []Issue{{File: "a.go", Description: "x"}}}}
+	r.trackRecurringIssues(t.TempDir(), results)
+	assert.Equal(t, 0, results[0].Issues[0].PreviousRuns)
+}
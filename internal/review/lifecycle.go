@@ -0,0 +1,210 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+)
+
+// IssueState is where a finding stands after a review iteration of a run.
+type IssueState string
+
+const (
+	// IssueIntroduced is a finding reported for the first time in the run.
+	IssueIntroduced IssueState = "introduced"
+	// IssuePersisted is a finding reported again by the next iteration.
+	IssuePersisted IssueState = "persisted"
+	// IssueFixed is a finding its agent no longer reports.
+	IssueFixed IssueState = "fixed"
+	// IssueReappeared is a finding reported again after it was fixed.
+	IssueReappeared IssueState = "reappeared"
+)
+
+// IssueTransition is the state a finding was in after a review iteration.
+type IssueTransition struct {
+	Iteration int
+	State     IssueState
+}
+
+// IssueLifecycle follows one finding across the review iterations of a run.
+// Findings are matched by recurrenceFingerprint, so an issue whose line
+// moved is the same issue.
+type IssueLifecycle struct {
+	Agent       string
+	Issue       Issue // as last reported
+	Transitions []IssueTransition
+}
+
+// State returns the state the finding was left in.
+func (l *IssueLifecycle) State() IssueState {
+	return l.Transitions[len(l.Transitions)-1].State
+}
+
+// Open reports whether the finding was still reported by the last review
+// iteration that ran its agent.
+func (l *IssueLifecycle) Open() bool {
+	return l.State() != IssueFixed
+}
+
+// Reappeared reports whether the finding came back after it was fixed.
+func (l *IssueLifecycle) Reappeared() bool {
+	for _, t := range l.Transitions {
+		if t.State == IssueReappeared {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats l as the issue followed by its transitions, as in
+// "[quality] a.go:10 nil dereference: introduced 1, fixed 2".
+func (l *IssueLifecycle) String() string {
+	steps := make([]string, 0, len(l.Transitions))
+	for _, t := range l.Transitions {
+		steps = append(steps, fmt.Sprintf("%s %d", t.State, t.Iteration))
+	}
+	return describeIssue(l.Agent, l.Issue) + ": " + strings.Join(steps, ", ")
+}
+
+// LifecycleSummary counts the findings of a run by how they ended.
+type LifecycleSummary struct {
+	Found      int // findings reported at least once
+	Fixed      int // no longer reported
+	Open       int // still reported
+	Reappeared int // came back after they were fixed, at least once
+}
+
+// SummarizeLifecycles counts lifecycles by how they ended.
+func SummarizeLifecycles(lifecycles []IssueLifecycle) LifecycleSummary {
+	s := LifecycleSummary{Found: len(lifecycles)}
+	for i := range lifecycles {
+		if lifecycles[i].Open() {
+			s.Open++
+		} else {
+			s.Fixed++
+		}
+		if lifecycles[i].Reappeared() {
+			s.Reappeared++
+		}
+	}
+	return s
+}
+
+// String formats s as "5 found, 3 fixed, 2 open, 1 reappeared".
+func (s LifecycleSummary) String() string {
+	text := fmt.Sprintf("%d found, %d fixed, %d open", s.Found, s.Fixed, s.Open)
+	if s.Reappeared > 0 {
+		text += fmt.Sprintf(", %d reappeared", s.Reappeared)
+	}
+	return text
+}
+
+// Lifecycle returns the lifecycle of every finding of the run so far, in the
+// order they were first reported.
+func (r *Runner) Lifecycle() []IssueLifecycle {
+	out := make([]IssueLifecycle, 0, len(r.lifecycleOrder))
+	for _, fp := range r.lifecycleOrder {
+		l := *r.lifecycles[fp]
+		l.Transitions = append([]IssueTransition(nil), l.Transitions...)
+		out = append(out, l)
+	}
+	return out
+}
+
+// trackLifecycle records the state of each finding after iteration, given
+// the results of its final pass, emits each transition as an event, and logs
+// a summary of them. Findings of agents that did not run in the pass keep
+// their state. Iterations with agent errors are retried and left out.
+func (r *Runner) trackLifecycle(iteration int, results []*Result) {
+	ran := make(map[string]bool, len(results))
+	for _, res := range results {
+		if res.Error != nil {
+			return
+		}
+		ran[res.AgentName] = true
+	}
+	if r.lifecycles == nil {
+		r.lifecycles = make(map[string]*IssueLifecycle)
+	}
+
+	counts := make(map[IssueState]int)
+	var reappeared []string
+	reported := make(map[string]bool)
+	for _, res := range results {
+		for _, issue := range res.Issues {
+			fp := recurrenceFingerprint(res.AgentName, issue)
+			if reported[fp] {
+				continue
+			}
+			reported[fp] = true
+
+			l, ok := r.lifecycles[fp]
+			state := IssuePersisted
+			switch {
+			case !ok:
+				l = &IssueLifecycle{Agent: res.AgentName}
+				r.lifecycles[fp] = l
+				r.lifecycleOrder = append(r.lifecycleOrder, fp)
+				state = IssueIntroduced
+			case !l.Open():
+				state = IssueReappeared
+				reappeared = append(reappeared, fmt.Sprintf("  Reappeared: %s (fixed in iteration %d)",
+					describeIssue(res.AgentName, issue), l.Transitions[len(l.Transitions)-1].Iteration))
+			}
+			l.Issue = issue
+			l.Transitions = append(l.Transitions, IssueTransition{Iteration: iteration, State: state})
+			r.emitTransition(l)
+			counts[state]++
+		}
+	}
+	for _, fp := range r.lifecycleOrder {
+		l := r.lifecycles[fp]
+		if !reported[fp] && ran[l.Agent] && l.Open() {
+			l.Transitions = append(l.Transitions, IssueTransition{Iteration: iteration, State: IssueFixed})
+			r.emitTransition(l)
+			counts[IssueFixed]++
+		}
+	}
+
+	if iteration == 1 || len(counts) == 0 {
+		return
+	}
+	r.log(fmt.Sprintf("Issue lifecycle: %d introduced, %d persisted, %d fixed, %d reappeared",
+		counts[IssueIntroduced], counts[IssuePersisted], counts[IssueFixed], counts[IssueReappeared]))
+	for _, line := range reappeared {
+		r.log(line)
+	}
+}
+
+// emitTransition emits the last transition of l as an event.
+func (r *Runner) emitTransition(l *IssueLifecycle) {
+	if r.onEvent == nil {
+		return
+	}
+	t := l.Transitions[len(l.Transitions)-1]
+	r.onEvent(event.IssueLifecycle(fmt.Sprintf("%s: %s %d", describeIssue(l.Agent, l.Issue), t.State, t.Iteration),
+		event.IssueTransition{
+			Agent:       l.Agent,
+			File:        l.Issue.File,
+			Line:        l.Issue.Line,
+			Description: strings.TrimSpace(l.Issue.Description),
+			Iteration:   t.Iteration,
+			State:       string(t.State),
+		}))
+}
+
+// describeIssue names an issue in one line for the log.
+func describeIssue(agent string, issue Issue) string {
+	var b strings.Builder
+	b.WriteString("[" + agent + "] ")
+	if issue.File != "" {
+		b.WriteString(issue.File)
+		if issue.Line > 0 {
+			fmt.Fprintf(&b, ":%d", issue.Line)
+		}
+		b.WriteString(" ")
+	}
+	b.WriteString(strings.TrimSpace(issue.Description))
+	return b.String()
+}
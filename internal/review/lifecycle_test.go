@@ -0,0 +1,100 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+)
+
+func TestRunner_TracksIssueLifecycle(t *testing.T) {
+	nilDeref := Issue{File: "a.go", Line: 10, Severity: SeverityHigh, Description: "nil dereference"}
+	leak := Issue{File: "b.go", Line: 3, Severity: SeverityMedium, Description: "leaked file"}
+	moved := nilDeref
+	moved.Line = 14
+
+	// The issues each review iteration reports; nil makes the agent fail.
+	passes := [][]Issue{
+		{nilDeref, leak},
+		{moved},
+		nil,
+		{},
+		{leak},
+	}
+	pass := 0
+	runner := NewRunner(Config{Agents: []AgentConfig{{Name: "quality"}}})
+	runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+		mock := NewMockAgent(agentCfg.Name)
+		mock.SetReviewFunc(func(context.Context, string, []string) (*Result, error) {
+			issues := passes[pass]
+			pass++
+			if issues == nil {
+				return nil, errors.New("agent crashed")
+			}
+			return &Result{AgentName: agentCfg.Name, Issues: issues}, nil
+		})
+		return mock
+	})
+	var logs []string
+	var transitions []event.IssueTransition
+	runner.SetEventCallback(func(ev event.Event) {
+		if ev.Kind == event.KindIssueLifecycle {
+			transitions = append(transitions, *ev.Issue)
+			return
+		}
+		logs = append(logs, ev.Text)
+	})
+
+	for range passes {
+		_, err := runner.RunIteration(context.Background(), t.TempDir(), []string{"a.go"})
+		require.NoError(t, err)
+	}
+
+	lifecycles := runner.Lifecycle()
+	require.Len(t, lifecycles, 2)
+	assert.Equal(t, 14, lifecycles[0].Issue.Line, "a moved issue is the same issue")
+	assert.Equal(t, []IssueTransition{
+		{Iteration: 1, State: IssueIntroduced},
+		{Iteration: 2, State: IssuePersisted},
+		{Iteration: 3, State: IssueFixed},
+	}, lifecycles[0].Transitions, "the failed pass is retried as iteration 3")
+	assert.Equal(t, []IssueTransition{
+		{Iteration: 1, State: IssueIntroduced},
+		{Iteration: 2, State: IssueFixed},
+		{Iteration: 4, State: IssueReappeared},
+	}, lifecycles[1].Transitions)
+	assert.Equal(t, "[quality] b.go:3 leaked file: introduced 1, fixed 2, reappeared 4", lifecycles[1].String())
+
+	summary := SummarizeLifecycles(lifecycles)
+	assert.Equal(t, LifecycleSummary{Found: 2, Fixed: 1, Open: 1, Reappeared: 1}, summary)
+	assert.Equal(t, "2 found, 1 fixed, 1 open, 1 reappeared", summary.String())
+
+	assert.Contains(t, logs, "Issue lifecycle: 0 introduced, 1 persisted, 1 fixed, 0 reappeared")
+	assert.Contains(t, logs, "  Reappeared: [quality] b.go:3 leaked file (fixed in iteration 2)")
+
+	assert.Equal(t, []event.IssueTransition{
+		{Agent: "quality", File: "a.go", Line: 10, Description: "nil dereference", Iteration: 1, State: "introduced"},
+		{Agent: "quality", File: "b.go", Line: 3, Description: "leaked file", Iteration: 1, State: "introduced"},
+		{Agent: "quality", File: "a.go", Line: 14, Description: "nil dereference", Iteration: 2, State: "persisted"},
+		{Agent: "quality", File: "b.go", Line: 3, Description: "leaked file", Iteration: 2, State: "fixed"},
+		{Agent: "quality", File: "a.go", Line: 14, Description: "nil dereference", Iteration: 3, State: "fixed"},
+		{Agent: "quality", File: "b.go", Line: 3, Description: "leaked file", Iteration: 4, State: "reappeared"},
+	}, transitions, "every transition is an event, the first iteration's too")
+}
+
+func TestRunner_LifecycleKeepsIssuesOfAgentsNotRun(t *testing.T) {
+	runner := NewRunner(Config{})
+	runner.trackLifecycle(1, []*Result{
+		{AgentName: "security", Issues: []Issue{{File: "a.go", Description: "injection"}}},
+	})
+	runner.trackLifecycle(2, []*Result{{AgentName: "quality"}})
+
+	lifecycles := runner.Lifecycle()
+	require.Len(t, lifecycles, 1)
+	assert.Equal(t, IssueIntroduced, lifecycles[0].State())
+	assert.True(t, lifecycles[0].Open())
+}
@@ -0,0 +1,44 @@
+package review
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resolvePaths sets Path on every issue to its file resolved against
+// workingDir. Agents sometimes report the line in the file name
+// ("main.go:42"); it is moved to Line when Line is not set.
+func resolvePaths(workingDir string, results []*Result) {
+	for _, res := range results {
+		for i := range res.Issues {
+			issue := &res.Issues[i]
+			file := strings.TrimSpace(issue.File)
+			if file == "" {
+				continue
+			}
+			if name, line, ok := strings.Cut(file, ":"); ok && issue.Line == 0 {
+				if n, err := strconv.Atoi(line); err == nil && n > 0 {
+					file, issue.Line = name, n
+				}
+			}
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(workingDir, file)
+			}
+			issue.Path = filepath.Clean(file)
+		}
+	}
+}
+
+// Location returns the issue's resolved path with its line, as
+// "path:line", or the path alone when the line is unknown.
+func (issue *Issue) Location() string {
+	path := issue.Path
+	if path == "" {
+		path = issue.File
+	}
+	if issue.Line > 0 {
+		return path + ":" + strconv.Itoa(issue.Line)
+	}
+	return path
+}
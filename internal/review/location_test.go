@@ -0,0 +1,32 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvePaths(t *testing.T) {
+	results := []*Result{{
+		AgentName: "quality",
+		Issues: []Issue{
+			{File: "internal/app.go", Line: 12},
+			{File: "cmd/main.go:42"},
+			{File: "cmd/main.go:7", Line: 3},
+			{File: "/abs/other.go"},
+			{File: ""},
+		},
+	}}
+
+	resolvePaths("/repo", results)
+
+	issues := results[0].Issues
+	assert.Equal(t, "/repo/internal/app.go", issues[0].Path)
+	assert.Equal(t, "/repo/internal/app.go:12", issues[0].Location())
+	assert.Equal(t, "/repo/cmd/main.go", issues[1].Path)
+	assert.Equal(t, 42, issues[1].Line, "a line in the file name is moved to Line")
+	assert.Equal(t, "/repo/cmd/main.go:7", issues[2].Path, "an explicit Line wins")
+	assert.Equal(t, 3, issues[2].Line)
+	assert.Equal(t, "/abs/other.go", issues[3].Location())
+	assert.Empty(t, issues[4].Path)
+}
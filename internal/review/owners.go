@@ -0,0 +1,167 @@
+package review
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+)
+
+// codeOwnersPaths are where a CODEOWNERS file is looked for, relative to the
+// repository root, in the order GitHub uses.
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Owners maps files to their owners, as listed in a CODEOWNERS file.
+type Owners struct {
+	rules []ownerRule
+}
+
+type ownerRule struct {
+	pattern *regexp.Regexp
+	owners  []string // empty: the files have no owner
+}
+
+// LoadOwners reads the CODEOWNERS file of the repository containing dir, or
+// of dir itself outside a repository. It returns the root that the file's
+// patterns are relative to, and nil Owners when there is no file.
+func LoadOwners(dir string) (*Owners, string, error) {
+	root := dir
+	if repo, err := gitutil.NewRepo(dir); err == nil {
+		root = repo.Root()
+	}
+	for _, rel := range codeOwnersPaths {
+		data, err := os.ReadFile(filepath.Join(root, rel)) //nolint:gosec // fixed name under the repository root
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, root, err
+		}
+		return ParseOwners(string(data)), root, nil
+	}
+	return nil, root, nil
+}
+
+// ParseOwners parses the contents of a CODEOWNERS file. Lines with a pattern
+// that cannot be matched are skipped.
+func ParseOwners(data string) *Owners {
+	o := &Owners{}
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		re, err := ownerPattern(fields[0])
+		if err != nil {
+			continue
+		}
+		rule := ownerRule{pattern: re}
+		if len(fields) > 1 {
+			rule.owners = fields[1:]
+		}
+		o.rules = append(o.rules, rule)
+	}
+	return o
+}
+
+// ownerPattern compiles a CODEOWNERS pattern. As in gitignore, a pattern
+// with a slash before its end is anchored to the root and one without
+// matches at any depth; a pattern naming a directory matches everything in
+// it; * and ? stop at slashes and ** does not.
+func ownerPattern(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.Trim(pattern, "/")
+	var b strings.Builder
+	if strings.Contains(trimmed, "/") || strings.HasPrefix(pattern, "/") {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case trimmed[i] == '*':
+			b.WriteString("[^/]*")
+		case trimmed[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(trimmed[i : i+1]))
+		}
+	}
+	if dirOnly {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(b.String())
+}
+
+// Of returns the owners of file, a slash-separated path relative to the
+// CODEOWNERS root. The last matching rule wins, as on GitHub.
+func (o *Owners) Of(file string) []string {
+	if o == nil {
+		return nil
+	}
+	file = strings.TrimPrefix(filepath.ToSlash(file), "./")
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if o.rules[i].pattern.MatchString(file) {
+			return o.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// annotateOwners sets Owners on every issue with a resolved path, from the
+// CODEOWNERS file of workingDir's repository. Ownership is informational: a
+// missing or unreadable file leaves the issues as they are.
+func annotateOwners(workingDir string, results []*Result) {
+	owners, root, err := LoadOwners(workingDir)
+	if err != nil || owners == nil {
+		return
+	}
+	// Issue paths are under workingDir as given; git reports the root with
+	// symlinks resolved, so the prefix is taken between resolved paths.
+	prefix, err := filepath.Rel(evalSymlinks(root), evalSymlinks(workingDir))
+	if err != nil {
+		return
+	}
+	for _, res := range results {
+		for i := range res.Issues {
+			issue := &res.Issues[i]
+			if issue.Path == "" {
+				continue
+			}
+			rel, err := filepath.Rel(workingDir, issue.Path)
+			if err != nil {
+				continue
+			}
+			rel = filepath.Join(prefix, rel)
+			if strings.HasPrefix(rel, "..") {
+				continue
+			}
+			issue.Owners = owners.Of(rel)
+		}
+	}
+}
+
+// evalSymlinks returns path with symlinks resolved, or path itself when it
+// cannot be resolved.
+func evalSymlinks(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
@@ -0,0 +1,70 @@
+package review
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwners_Of(t *testing.T) {
+	owners := ParseOwners(`# Default owners
+*       @org/core
+
+*.md    @org/docs # docs anywhere
+/build/ @org/infra
+docs/**/api.md @org/api
+billing @org/billing alice@example.com
+/vendor/
+`)
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"main.go", []string{"@org/core"}},
+		{"README.md", []string{"@org/docs"}},
+		{"pkg/guide.md", []string{"@org/docs"}},
+		{"build/ci.yaml", []string{"@org/infra"}},
+		{"src/build/ci.yaml", []string{"@org/core"}},
+		{"docs/api.md", []string{"@org/api"}},
+		{"docs/v2/http/api.md", []string{"@org/api"}},
+		{"billing/charge.go", []string{"@org/billing", "alice@example.com"}},
+		{"internal/billing/charge.go", []string{"@org/billing", "alice@example.com"}},
+		{"./billing/charge.go", []string{"@org/billing", "alice@example.com"}},
+		{"vendor/lib/a.go", nil},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, owners.Of(tc.file), tc.file)
+	}
+
+	var none *Owners
+	assert.Nil(t, none.Of("main.go"))
+}
+
+func TestAnnotateOwners(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".github"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("*.go @org/go\n"), 0o600))
+
+	results := []*Result{{AgentName: "quality", Issues: []Issue{
+		{File: "main.go", Description: "a"},
+		{File: "notes.txt", Description: "b"},
+		{Description: "no file"},
+	}}}
+	resolvePaths(dir, results)
+	annotateOwners(dir, results)
+
+	assert.Equal(t, []string{"@org/go"}, results[0].Issues[0].Owners)
+	assert.Nil(t, results[0].Issues[1].Owners)
+	assert.Nil(t, results[0].Issues[2].Owners)
+	assert.Contains(t, FormatIssuesMarkdown(results), "`main.go` - a _(owners: @org/go)_")
+}
+
+func TestLoadOwners_Missing(t *testing.T) {
+	owners, _, err := LoadOwners(t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, owners)
+}
@@ -44,60 +44,10 @@ func parseReviewOutput(output string) ([]Issue, string, error) {
 	return wrapper.Result.Issues, wrapper.Result.Summary, nil
 }
 
-// FormatIssuesMarkdown formats issues as markdown for ticket notes.
+// FormatIssuesMarkdown formats issues as markdown for ticket notes, grouped
+// by agent.
 func FormatIssuesMarkdown(results []*Result) string {
-	var b strings.Builder
-
-	for _, result := range results {
-		if result.Error != nil {
-			b.WriteString("### ")
-			b.WriteString(result.AgentName)
-			b.WriteString(" (error)\n")
-			b.WriteString("Error: ")
-			b.WriteString(result.Error.Error())
-			b.WriteString("\n\n")
-			continue
-		}
-
-		if len(result.Issues) == 0 {
-			continue
-		}
-
-		b.WriteString("### ")
-		b.WriteString(result.AgentName)
-		b.WriteString(" (")
-		b.WriteString(pluralize(len(result.Issues), "issue", "issues"))
-		b.WriteString(")\n\n")
-
-		for _, issue := range result.Issues {
-			b.WriteString("- **[")
-			b.WriteString(string(issue.Severity))
-			b.WriteString("]** ")
-			if issue.File != "" {
-				b.WriteString("`")
-				b.WriteString(issue.File)
-				if issue.Line > 0 {
-					b.WriteString(":")
-					b.WriteString(strconv.Itoa(issue.Line))
-					if issue.LineEnd > 0 {
-						b.WriteString("-")
-						b.WriteString(strconv.Itoa(issue.LineEnd))
-					}
-				}
-				b.WriteString("` - ")
-			}
-			b.WriteString(issue.Description)
-			if issue.Suggestion != "" {
-				b.WriteString("\n  - _Suggestion: ")
-				b.WriteString(issue.Suggestion)
-				b.WriteString("_")
-			}
-			b.WriteString("\n")
-		}
-		b.WriteString("\n")
-	}
-
-	return b.String()
+	return FormatIssuesMarkdownWith(results, MarkdownOptions{})
 }
 
 // FormatIssuesYAML formats issues as structured YAML with IDs for validator input.
@@ -324,6 +324,22 @@ func TestFormatIssuesMarkdown(t *testing.T) {
 		require.Contains(t, output, "_Suggestion: Handle it_")
 	})
 
+	t.Run("tags recurring issues", func(t *testing.T) {
+		results := []*Result{
+			{
+				AgentName: "quality",
+				Issues: []Issue{
+					{File: "a.go", Severity: SeverityLow, Description: "Seen before", PreviousRuns: 2},
+					{File: "b.go", Severity: SeverityLow, Description: "Brand new"},
+				},
+			},
+		}
+
+		output := FormatIssuesMarkdown(results)
+		require.Contains(t, output, "Seen before _(recurring: seen in 2 previous runs)_")
+		require.NotContains(t, output, "Brand new _(recurring")
+	})
+
 	t.Run("handles multiple agents", func(t *testing.T) {
 		results := []*Result{
 			{
@@ -0,0 +1,37 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+)
+
+// AutoApplicable reports whether the issue's patch can be applied without
+// an executor fix: it applies cleanly and the agent is highly confident.
+func (issue *Issue) AutoApplicable() bool {
+	return issue.PatchApplies && strings.EqualFold(strings.TrimSpace(issue.PatchConfidence), "high")
+}
+
+// checkPatches marks the suggested patches that apply cleanly to the
+// worktree. Patches that do not apply stay on the issue for the executor to
+// read, but are never applied directly.
+func (r *Runner) checkPatches(workingDir string, results []*Result) {
+	suggested, applies := 0, 0
+	for _, res := range results {
+		for i := range res.Issues {
+			issue := &res.Issues[i]
+			if strings.TrimSpace(issue.Patch) == "" {
+				continue
+			}
+			suggested++
+			issue.PatchApplies = gitutil.ApplyPatch(workingDir, issue.Patch, true) == nil
+			if issue.PatchApplies {
+				applies++
+			}
+		}
+	}
+	if suggested > 0 {
+		r.log(fmt.Sprintf("%d of %s apply cleanly", applies, pluralize(suggested, "suggested patch", "suggested patches")))
+	}
+}
@@ -0,0 +1,111 @@
+package review
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPatch = `--- a/main.go
++++ b/main.go
+@@ -1,2 +1,2 @@
+ package main
+-var x = 1
++var x = 2
+`
+
+func TestParseReviewOutput_Patch(t *testing.T) {
+	output := `REVIEW_RESULT:
+  issues:
+    - file: 'main.go'
+      line: 2
+      severity: 'low'
+      category: 'style'
+      description: 'Wrong value'
+      patch_confidence: 'high'
+      patch: |
+        --- a/main.go
+        +++ b/main.go
+        @@ -1,2 +1,2 @@
+         package main
+        -var x = 1
+        +var x = 2
+  summary: 'One issue'
+`
+	issues, _, err := parseReviewOutput(output)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, testPatch, issues[0].Patch)
+	assert.Equal(t, "high", issues[0].PatchConfidence)
+}
+
+func TestRunner_CheckPatches(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\nvar x = 1\n"), 0644))
+
+	results := []*Result{{
+		AgentName: "bug-shallow",
+		Issues: []Issue{
+			{File: "main.go", Description: "applies", Patch: testPatch, PatchConfidence: "high"},
+			{File: "main.go", Description: "stale", Patch: "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-package other\n+package main\n", PatchConfidence: "high"},
+			{File: "main.go", Description: "no patch"},
+		},
+	}}
+
+	NewRunner(Config{}).checkPatches(dir, results)
+
+	issues := results[0].Issues
+	assert.True(t, issues[0].PatchApplies)
+	assert.False(t, issues[1].PatchApplies)
+	assert.False(t, issues[2].PatchApplies)
+
+	data, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\nvar x = 1\n", string(data), "checking must not modify files")
+}
+
+func TestIssue_AutoApplicable(t *testing.T) {
+	tests := []struct {
+		name  string
+		issue Issue
+		want  bool
+	}{
+		{name: "clean and confident", issue: Issue{PatchApplies: true, PatchConfidence: "High"}, want: true},
+		{name: "clean but unsure", issue: Issue{PatchApplies: true, PatchConfidence: "medium"}},
+		{name: "confident but does not apply", issue: Issue{PatchConfidence: "high"}},
+		{name: "no patch", issue: Issue{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.issue.AutoApplicable())
+		})
+	}
+}
+
+func TestFormatIssuesMarkdown_Patch(t *testing.T) {
+	results := []*Result{{
+		AgentName: "bug-shallow",
+		Issues: []Issue{
+			{File: "main.go", Severity: SeverityLow, Description: "Wrong value", Patch: testPatch, PatchApplies: true},
+			{File: "main.go", Severity: SeverityLow, Description: "Stale patch", Patch: "--- a/x\n"},
+		},
+	}}
+
+	out := FormatIssuesMarkdown(results)
+	assert.Contains(t, out, "_Suggested patch (applies cleanly):_\n\n    ```diff\n    --- a/main.go\n")
+	assert.Contains(t, out, "    +var x = 2\n    ```\n")
+	assert.NotContains(t, out, "--- a/x")
+}
+
+func TestClaudeAgent_PatchInstructions(t *testing.T) {
+	plain := NewClaudeAgent("test", []string{"bugs"}, "").buildPrompt([]string{"a.go"}, "")
+	assert.NotContains(t, plain, "patch_confidence")
+
+	withPatches := NewClaudeAgent("test", []string{"bugs"}, "", WithPatches()).buildPrompt([]string{"a.go"}, "")
+	assert.Contains(t, withPatches, "patch_confidence")
+	assert.Contains(t, withPatches, "git apply")
+}
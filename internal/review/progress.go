@@ -0,0 +1,38 @@
+package review
+
+// Review phases reported through Progress. A schedule step or consensus
+// executor is appended to PhaseAgents, as in "agents, step 2/3".
+const (
+	PhaseAgents     = "agents"     // review agents looking for issues
+	PhaseValidation = "validation" // validators filtering the findings
+)
+
+// Progress reports a change in a running review: a phase starting (Agent
+// empty), or an agent starting or finishing within the current phase.
+type Progress struct {
+	Phase string
+	Agent string
+	Done  bool
+}
+
+// ProgressCallback receives review progress. Agents running in parallel
+// report from their own goroutines.
+type ProgressCallback func(Progress)
+
+// SetProgressCallback sets the handler for review progress.
+func (r *Runner) SetProgressCallback(cb ProgressCallback) {
+	r.onProgress = cb
+}
+
+func (r *Runner) startPhase(phase string) {
+	r.phase = phase
+	if r.onProgress != nil {
+		r.onProgress(Progress{Phase: phase})
+	}
+}
+
+func (r *Runner) agentProgress(name string, done bool) {
+	if r.onProgress != nil {
+		r.onProgress(Progress{Phase: r.phase, Agent: name, Done: done})
+	}
+}
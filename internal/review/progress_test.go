@@ -0,0 +1,46 @@
+package review
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_ProgressCallback(t *testing.T) {
+	runner := NewRunner(Config{
+		Parallel:       false,
+		ValidateIssues: true,
+		Agents:         []AgentConfig{{Name: "bugs"}, {Name: "style"}},
+		Schedule:       []StrictnessStep{{Agents: []string{"bugs"}}, {}},
+	})
+	runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+		mock := NewMockAgent(agentCfg.Name)
+		mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*Result, error) {
+			if agentCfg.Name == "style" {
+				return &Result{AgentName: "style", Issues: []Issue{{Severity: SeverityLow, Description: "naming"}}}, nil
+			}
+			return &Result{AgentName: agentCfg.Name}, nil
+		})
+		return mock
+	})
+
+	var got []Progress
+	runner.SetProgressCallback(func(p Progress) { got = append(got, p) })
+
+	_, err := runner.RunIteration(context.Background(), "/tmp", []string{"file.go"})
+	require.NoError(t, err)
+	require.Equal(t, []Progress{
+		{Phase: "agents, step 1/2"},
+		{Phase: "agents, step 1/2", Agent: "bugs"},
+		{Phase: "agents, step 1/2", Agent: "bugs", Done: true},
+		{Phase: "agents, step 2/2"},
+		{Phase: "agents, step 2/2", Agent: "bugs"},
+		{Phase: "agents, step 2/2", Agent: "bugs", Done: true},
+		{Phase: "agents, step 2/2", Agent: "style"},
+		{Phase: "agents, step 2/2", Agent: "style", Done: true},
+		{Phase: PhaseValidation},
+		{Phase: PhaseValidation, Agent: issueValidatorName},
+		{Phase: PhaseValidation, Agent: issueValidatorName, Done: true},
+	}, got)
+}
@@ -26,10 +26,12 @@ func GetDefaultPrompt(agentName string) string {
 		return prompts.CommentsPrompt
 	case "tests-and-linters":
 		return prompts.LinterPrompt
-	case "simplification-validator":
+	case simplificationValidatorName:
 		return prompts.SimplificationValidatorPrompt
-	case "issue-validator":
+	case issueValidatorName:
 		return prompts.IssueValidatorPrompt
+	case AcceptanceAgentName:
+		return prompts.AcceptancePrompt
 	default:
 		return defaultGenericPrompt
 	}
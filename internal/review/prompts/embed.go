@@ -37,3 +37,6 @@ var SimplificationValidatorPrompt string
 
 //go:embed issue_validator.md
 var IssueValidatorPrompt string
+
+//go:embed acceptance.md
+var AcceptancePrompt string
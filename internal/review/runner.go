@@ -3,9 +3,10 @@ package review
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ type RunResult struct {
 	Passed      bool
 	Iteration   int
 	TotalIssues int
+	Score       int // severity-weighted sum of the issues, see SeverityWeight
 	Results     []*Result
 	Duration    time.Duration
 }
@@ -47,13 +49,53 @@ func (r *RunResult) AllIssues() []Issue {
 	return issues
 }
 
+// IssueFingerprints returns the sorted, de-duplicated fingerprints of all
+// issues. Two iterations with equal fingerprints reported the same findings.
+func (r *RunResult) IssueFingerprints() []string {
+	seen := make(map[string]struct{})
+	for _, result := range r.Results {
+		for _, issue := range result.Issues {
+			seen[issueFingerprint(result.AgentName, issue)] = struct{}{}
+		}
+	}
+	fingerprints := make([]string, 0, len(seen))
+	for fp := range seen {
+		fingerprints = append(fingerprints, fp)
+	}
+	slices.Sort(fingerprints)
+	return fingerprints
+}
+
 // Runner orchestrates the review process.
 type Runner struct {
 	config       Config
 	agents       map[string]Agent
+	agentPrompts map[string]string // prompt each factory-built agent was created with
 	agentsMu     sync.Mutex
 	onEvent      event.Handler
+	onProgress   ProgressCallback
+	phase        string // phase last reported through onProgress
 	agentFactory AgentFactory
+	runID        string   // identifies this run in the recurring-issue history
+	summary      string   // repository overview prepended to reviewer prompts
+	contextFiles []string // files related to the change, listed for the agents of the current pass
+
+	injectionChecked bool // the ticket context went through the injection detector
+
+	// Review iterations completed without agent errors, and the step of
+	// Config.Schedule reached so far.
+	iterations   int
+	scheduleStep int
+
+	// Lifecycles of the findings of the run, keyed by recurrenceFingerprint,
+	// and their fingerprints in the order they were first reported.
+	lifecycles     map[string]*IssueLifecycle
+	lifecycleOrder []string
+
+	// Validator results keyed by validator name and input hash, so a review
+	// retried with the same findings does not pay for validation again.
+	validatorCache   map[string]*Result
+	validatorCacheMu sync.Mutex
 }
 
 // AgentFactory creates review agents from config.
@@ -62,8 +104,11 @@ type AgentFactory func(agentCfg AgentConfig, defaultPrompt string) Agent
 // NewRunner creates a new review runner.
 func NewRunner(config Config) *Runner {
 	r := &Runner{
-		config: config,
-		agents: make(map[string]Agent),
+		config:         config,
+		agents:         make(map[string]Agent),
+		agentPrompts:   make(map[string]string),
+		validatorCache: make(map[string]*Result),
+		runID:          fmt.Sprintf("%d-%d", time.Now().UnixNano(), os.Getpid()),
 	}
 	r.agentFactory = r.defaultAgentFactory
 	return r
@@ -80,12 +125,33 @@ func (r *Runner) defaultAgentFactory(agentCfg AgentConfig, defaultPrompt string)
 	if agentCfg.Prompt != "" {
 		prompt = agentCfg.Prompt
 	}
-	prompt = addTicketContext(prompt, r.config.TicketContext)
+	if !agentCfg.ticketInPrompt {
+		prompt = addTicketContext(prompt, r.untrustedTicketContext())
+	}
+	if getsRepoSummary(agentCfg.Name) {
+		prompt = addRepoSummary(prompt, r.summary)
+	}
 	var opts []ClaudeAgentOption
 	if r.config.Timeout > 0 {
 		opts = append(opts, WithTimeout(time.Duration(r.config.Timeout)*time.Second))
 	}
-	opts = append(opts, WithExecutorConfig(r.config.ExecutorConfig))
+	execCfg := r.config.ExecutorConfig
+	if agentCfg.executor != nil {
+		execCfg = *agentCfg.executor
+	}
+	opts = append(opts, WithExecutorConfig(execCfg.WithModel(agentCfg.Model, agentCfg.Effort)))
+	if r.config.Invoker != nil {
+		opts = append(opts, WithInvoker(r.config.Invoker))
+	}
+	if r.config.Metrics != nil {
+		opts = append(opts, WithMetrics(r.config.Metrics))
+	}
+	if r.config.SuggestPatches {
+		opts = append(opts, WithPatches())
+	}
+	if r.onEvent != nil {
+		opts = append(opts, WithEventHandler(r.onEvent))
+	}
 	return NewClaudeAgent(agentCfg.Name, agentCfg.Focus, prompt, opts...)
 }
 
@@ -106,7 +172,7 @@ func addTicketContext(prompt, ticketContext string) string {
 }
 
 // runAgentsParallel runs all agents in parallel.
-func (r *Runner) runAgentsParallel(ctx context.Context, agents []AgentConfig, workingDir string, filesChanged []string) ([]*Result, error) {
+func (r *Runner) runAgentsParallel(ctx context.Context, agents []AgentConfig, workingDir string, filesChanged, diffChunks []string) ([]*Result, error) {
 	var wg sync.WaitGroup
 	results := make([]*Result, len(agents))
 	errs := make([]error, len(agents))
@@ -117,9 +183,12 @@ func (r *Runner) runAgentsParallel(ctx context.Context, agents []AgentConfig, wo
 			defer wg.Done()
 
 			agent := r.getOrCreateAgent(cfg)
+			r.giveContext(agent)
 			r.log(fmt.Sprintf("  Running agent: %s", agent.Name()))
 
-			result, err := agent.Review(ctx, workingDir, filesChanged)
+			r.agentProgress(cfg.Name, false)
+			result, err := reviewWithDiff(ctx, agent, workingDir, filesChanged, diffChunks)
+			r.agentProgress(cfg.Name, true)
 			if err != nil {
 				errs[idx] = fmt.Errorf("agent %s: %w", cfg.Name, err)
 				results[idx] = &Result{
@@ -129,7 +198,7 @@ func (r *Runner) runAgentsParallel(ctx context.Context, agents []AgentConfig, wo
 				return
 			}
 
-			results[idx] = result
+			results[idx] = keepSeverities(filterBySeverity(result, cfg.MinSeverity), r.config.Severities)
 			r.log(fmt.Sprintf("  Agent %s: %d issues found", agent.Name(), len(result.Issues)))
 		}(i, agentCfg)
 	}
@@ -150,7 +219,7 @@ func (r *Runner) runAgentsParallel(ctx context.Context, agents []AgentConfig, wo
 }
 
 // runAgentsSequential runs all agents sequentially.
-func (r *Runner) runAgentsSequential(ctx context.Context, agents []AgentConfig, workingDir string, filesChanged []string) ([]*Result, error) {
+func (r *Runner) runAgentsSequential(ctx context.Context, agents []AgentConfig, workingDir string, filesChanged, diffChunks []string) ([]*Result, error) {
 	results := make([]*Result, 0, len(agents))
 
 	for _, agentCfg := range agents {
@@ -161,9 +230,12 @@ func (r *Runner) runAgentsSequential(ctx context.Context, agents []AgentConfig,
 		}
 
 		agent := r.getOrCreateAgent(agentCfg)
+		r.giveContext(agent)
 		r.log(fmt.Sprintf("  Running agent: %s", agent.Name()))
 
-		result, err := agent.Review(ctx, workingDir, filesChanged)
+		r.agentProgress(agentCfg.Name, false)
+		result, err := reviewWithDiff(ctx, agent, workingDir, filesChanged, diffChunks)
+		r.agentProgress(agentCfg.Name, true)
 		if err != nil {
 			result = &Result{
 				AgentName: agentCfg.Name,
@@ -171,6 +243,7 @@ func (r *Runner) runAgentsSequential(ctx context.Context, agents []AgentConfig,
 			}
 		}
 
+		result = keepSeverities(filterBySeverity(result, agentCfg.MinSeverity), r.config.Severities)
 		results = append(results, result)
 		r.log(fmt.Sprintf("  Agent %s: %d issues found", agent.Name(), len(result.Issues)))
 	}
@@ -178,7 +251,40 @@ func (r *Runner) runAgentsSequential(ctx context.Context, agents []AgentConfig,
 	return results, nil
 }
 
-func (r *Runner) resolveAgentConfigs(agents []AgentConfig, workingDir string) ([]AgentConfig, error) {
+// filterBySeverity drops issues ranked below minSeverity. Issues with an
+// unknown severity are kept.
+func filterBySeverity(result *Result, minSeverity Severity) *Result {
+	if minSeverity == "" || result == nil {
+		return result
+	}
+	minRank := severityRank(minSeverity)
+	kept := make([]Issue, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		if rank := severityRank(issue.Severity); rank == 0 || rank >= minRank {
+			kept = append(kept, issue)
+		}
+	}
+	result.Issues = kept
+	return result
+}
+
+// keepSeverities drops issues whose severity is not in severities. Issues
+// with an unknown severity are kept, as in filterBySeverity.
+func keepSeverities(result *Result, severities []Severity) *Result {
+	if len(severities) == 0 || result == nil {
+		return result
+	}
+	kept := make([]Issue, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		if severityRank(issue.Severity) == 0 || slices.Contains(severities, issue.Severity) {
+			kept = append(kept, issue)
+		}
+	}
+	result.Issues = kept
+	return result
+}
+
+func (r *Runner) resolveAgentConfigs(agents []AgentConfig, workingDir string, filesChanged []string) ([]AgentConfig, error) {
 	resolved := make([]AgentConfig, 0, len(agents))
 
 	for _, cfg := range agents {
@@ -186,17 +292,13 @@ func (r *Runner) resolveAgentConfigs(agents []AgentConfig, workingDir string) ([
 			return nil, fmt.Errorf("agent %s: prompt and prompt_file are mutually exclusive", cfg.Name)
 		}
 		if cfg.PromptFile != "" {
-			path := cfg.PromptFile
-			if !filepath.IsAbs(path) {
-				path = filepath.Join(workingDir, path)
-			}
-
-			data, err := os.ReadFile(path) //nolint:gosec // prompt file path is user-configured
+			prompt, inlinesTicket, err := r.loadPromptFile(cfg, workingDir, filesChanged)
 			if err != nil {
-				return nil, fmt.Errorf("agent %s: read prompt_file %q: %w", cfg.Name, cfg.PromptFile, err)
+				return nil, fmt.Errorf("agent %s: %w", cfg.Name, err)
 			}
-			cfg.Prompt = string(data)
+			cfg.Prompt = prompt
 			cfg.PromptFile = ""
+			cfg.ticketInPrompt = inlinesTicket
 		}
 
 		resolved = append(resolved, cfg)
@@ -210,13 +312,29 @@ func (r *Runner) getOrCreateAgent(cfg AgentConfig) Agent {
 	r.agentsMu.Lock()
 	defer r.agentsMu.Unlock()
 
-	if agent, ok := r.agents[cfg.Name]; ok {
-		return agent
+	// Consensus agents are cached per executor.
+	key := cfg.Name
+	if cfg.executor != nil {
+		key += "@" + executorLabel(cfg.executor.Name)
+	}
+
+	// Agents registered directly have no recorded prompt and are always reused;
+	// factory-built agents are rebuilt when their prompt or the repository
+	// summary changes.
+	builtWith := cfg.Prompt
+	if getsRepoSummary(cfg.Name) {
+		builtWith += "\x00" + r.summary
+	}
+	if agent, ok := r.agents[key]; ok {
+		if prompt, built := r.agentPrompts[key]; !built || prompt == builtWith {
+			return agent
+		}
 	}
 
 	defaultPrompt := GetDefaultPromptForAgent(cfg)
 	agent := r.agentFactory(cfg, defaultPrompt)
-	r.agents[cfg.Name] = agent
+	r.agents[key] = agent
+	r.agentPrompts[key] = builtWith
 
 	return agent
 }
@@ -246,22 +364,28 @@ func (r *Runner) ValidateSimplifications(ctx context.Context, workingDir string,
 	if len(simplificationResult.Issues) == 0 {
 		return simplificationResult, nil
 	}
+	r.startPhase(PhaseValidation)
+	return r.validateSimplifications(ctx, workingDir, simplificationResult), nil
+}
 
+// validateSimplifications is ValidateSimplifications for a result with
+// issues, without starting the validation phase.
+func (r *Runner) validateSimplifications(ctx context.Context, workingDir string, simplificationResult *Result) *Result {
 	r.log("Validating simplification suggestions...")
 
 	input := FormatIssuesMarkdown([]*Result{simplificationResult})
 
 	validatorCfg := AgentConfig{
-		Name:  "simplification-validator",
+		Name:  simplificationValidatorName,
 		Focus: []string{"filter low-value simplification suggestions"},
 	}
 
 	agent := r.getOrCreateAgent(validatorCfg)
 
-	result, err := agent.Review(ctx, workingDir, []string{"SIMPLIFICATION_INPUT:\n" + input})
+	result, err := r.runValidator(ctx, agent, workingDir, "SIMPLIFICATION_INPUT:\n"+input)
 	if err != nil {
 		r.log(fmt.Sprintf("Simplification validation failed, using original results: %v", err))
-		return simplificationResult, nil
+		return simplificationResult
 	}
 
 	if result == nil {
@@ -270,7 +394,7 @@ func (r *Runner) ValidateSimplifications(ctx context.Context, workingDir string,
 			AgentName: "simplification",
 			Issues:    []Issue{},
 			Summary:   "All simplification suggestions filtered by validator",
-		}, nil
+		}
 	}
 
 	if len(result.Issues) == 0 {
@@ -280,17 +404,28 @@ func (r *Runner) ValidateSimplifications(ctx context.Context, workingDir string,
 			Issues:    []Issue{},
 			Summary:   "All simplification suggestions filtered by validator",
 			Duration:  result.Duration,
-		}, nil
+		}
 	}
 
 	result.AgentName = "simplification"
 	r.log(fmt.Sprintf("Simplification validator kept %d of %d suggestions", len(result.Issues), len(simplificationResult.Issues)))
-	return result, nil
+	return result
 }
 
 // ValidateIssues runs a validation agent to filter false positives from all review results
 // (excluding simplification, which has its own validator).
 func (r *Runner) ValidateIssues(ctx context.Context, workingDir string, results []*Result) ([]*Result, error) {
+	if countValidatable(results) == 0 {
+		return results, nil
+	}
+	r.startPhase(PhaseValidation)
+	return r.validateIssues(ctx, workingDir, results), nil
+}
+
+// validateIssues is ValidateIssues for results with issues to validate,
+// without starting the validation phase. The result at each index is the
+// one at that index of results, filtered.
+func (r *Runner) validateIssues(ctx context.Context, workingDir string, results []*Result) []*Result {
 	var toValidate []*Result
 	for _, res := range results {
 		if res.AgentName == "simplification" {
@@ -301,34 +436,30 @@ func (r *Runner) ValidateIssues(ctx context.Context, workingDir string, results
 		}
 	}
 
-	if len(toValidate) == 0 {
-		return results, nil
-	}
-
 	r.log("Validating issues across agents...")
 
 	input := FormatIssuesYAML(toValidate)
 
 	validatorCfg := AgentConfig{
-		Name:  "issue-validator",
+		Name:  issueValidatorName,
 		Focus: []string{"filter false positive review findings"},
 	}
 
 	agent := r.getOrCreateAgent(validatorCfg)
 
-	validatorResult, err := agent.Review(ctx, workingDir, []string{"VALIDATION_INPUT:\n" + input})
+	validatorResult, err := r.runValidator(ctx, agent, workingDir, "VALIDATION_INPUT:\n"+input)
 	if err != nil {
 		r.log(fmt.Sprintf("Issue validation failed, using original results: %v", err))
-		return results, nil
+		return results
 	}
 
 	if validatorResult == nil {
 		r.log("Issue validator returned no result, using original results")
-		return results, nil
+		return results
 	}
 	if strings.TrimSpace(validatorResult.Summary) == noStructuredReviewOutputSummary {
 		r.log("Issue validator returned no structured output, using original results")
-		return results, nil
+		return results
 	}
 
 	verdicts := make(map[string]string)
@@ -340,7 +471,7 @@ func (r *Runner) ValidateIssues(ctx context.Context, workingDir string, results
 
 	if len(validatorResult.Issues) > 0 && len(verdicts) == 0 {
 		r.log("Issue validator returned issues without IDs, using original results")
-		return results, nil
+		return results
 	}
 
 	totalBefore := 0
@@ -374,7 +505,64 @@ func (r *Runner) ValidateIssues(ctx context.Context, workingDir string, results
 	}
 
 	r.log(fmt.Sprintf("Issue validator kept %d of %d issues", totalAfter, totalBefore))
-	return filtered, nil
+	return filtered
+}
+
+// Names of the validator agents. Their invocations use the executor's
+// validator flags instead of the review flags.
+const (
+	simplificationValidatorName = "simplification-validator"
+	issueValidatorName          = "issue-validator"
+)
+
+func isValidatorAgent(name string) bool {
+	return name == simplificationValidatorName || name == issueValidatorName
+}
+
+// runValidator runs a validator agent on input, reusing the result of an
+// earlier call with the same input in this run. Failed calls are not cached.
+// Callers get a copy they may modify. A call running past
+// Config.ValidatorTimeout fails.
+func (r *Runner) runValidator(ctx context.Context, agent Agent, workingDir, input string) (*Result, error) {
+	hash := sha256.Sum256([]byte(agent.Name() + "\x00" + workingDir + "\x00" + input))
+	key := fmt.Sprintf("%x", hash)
+
+	r.validatorCacheMu.Lock()
+	cached, ok := r.validatorCache[key]
+	r.validatorCacheMu.Unlock()
+	if ok {
+		r.log(fmt.Sprintf("Reusing %s result for unchanged findings", agent.Name()))
+		return cloneResult(cached), nil
+	}
+
+	if r.config.ValidatorTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(r.config.ValidatorTimeout)*time.Second)
+		defer cancel()
+	}
+	r.agentProgress(agent.Name(), false)
+	result, err := agent.Review(ctx, workingDir, []string{input})
+	r.agentProgress(agent.Name(), true)
+	if r.config.ValidatorTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%s timed out after %ds", agent.Name(), r.config.ValidatorTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.validatorCacheMu.Lock()
+	r.validatorCache[key] = cloneResult(result)
+	r.validatorCacheMu.Unlock()
+	return result, nil
+}
+
+func cloneResult(res *Result) *Result {
+	if res == nil {
+		return nil
+	}
+	c := *res
+	c.Issues = slices.Clone(res.Issues)
+	return &c
 }
 
 // assignIssueIDs assigns stable IDs to issues that don't already have one.
@@ -402,64 +590,144 @@ func issueFingerprint(agent string, issue Issue) string {
 }
 
 // RunIteration runs all configured agents and validators, returning the result.
+//
+// With a Config.Schedule, the iteration is limited to its step. A step that
+// finds nothing is not the end of the review: the next, broader step runs
+// right away, so the review passes only once the last step passes.
 func (r *Runner) RunIteration(ctx context.Context, workingDir string, filesChanged []string) (*RunResult, error) {
 	start := time.Now()
+	iteration := r.iterations + 1
+	if err := r.checkTicketContext(); err != nil {
+		return &RunResult{Iteration: iteration, Duration: time.Since(start)}, err
+	}
+	r.refreshRepoSummary(workingDir)
+
+	schedule := r.config.Schedule
+	step := min(max(r.scheduleStep, r.iterations), len(schedule)-1)
+	for {
+		var scope *StrictnessStep
+		phase := PhaseAgents
+		if step >= 0 {
+			scope = &schedule[step]
+			phase = fmt.Sprintf("%s, step %d/%d", PhaseAgents, step+1, len(schedule))
+			r.log(fmt.Sprintf("Review step %d/%d: %s", step+1, len(schedule), scope))
+		}
+		result, err := r.runStep(ctx, workingDir, filesChanged, scope, phase)
+		result.Iteration = iteration
+		result.Duration = time.Since(start)
+		if err != nil {
+			return result, err
+		}
+		if !slices.ContainsFunc(result.Results, func(res *Result) bool { return res.Error != nil }) {
+			r.iterations = iteration // an iteration lost to agent errors is retried at the same step
+		}
+		if !result.Passed || step >= len(schedule)-1 {
+			r.scheduleStep = max(step, 0)
+			r.trackLifecycle(iteration, result.Results)
+			return result, nil
+		}
+		step++
+	}
+}
 
+// runStep runs one review pass, limited to scope when it is not nil, and
+// reports its agents under phase.
+func (r *Runner) runStep(ctx context.Context, workingDir string, filesChanged []string, scope *StrictnessStep, phase string) (*RunResult, error) {
 	result := &RunResult{
-		Passed:    true,
-		Iteration: 1,
-		Results:   make([]*Result, 0),
+		Passed:  true,
+		Results: make([]*Result, 0),
 	}
 
 	r.log("Running review iteration")
 
-	resolvedAgents, err := r.resolveAgentConfigs(r.config.Agents, workingDir)
+	agents := r.config.Agents
+	if scope != nil && len(scope.Agents) > 0 {
+		agents = slices.DeleteFunc(slices.Clone(agents), func(a AgentConfig) bool {
+			return !slices.Contains(scope.Agents, a.Name)
+		})
+	}
+	resolvedAgents, err := r.resolveAgentConfigs(agents, workingDir, filesChanged)
 	if err != nil {
-		result.Duration = time.Since(start)
 		return result, err
 	}
 
-	var passResults []*Result
+	sizeRules := r.config.HasSizeRules()
+	var diff string
+	var diffErr error
+	if len(filesChanged) > 0 && (r.config.IncludeDiff || sizeRules) {
+		diff, diffErr = r.diffFrom(workingDir, filesChanged)
+	}
+
+	// A change whose size is unknown runs every agent and validator.
+	var size *ChangeSize
+	if sizeRules {
+		if diffErr != nil {
+			r.log(fmt.Sprintf("Warning: could not measure the change, running all agents: %v", diffErr))
+		} else {
+			size = &ChangeSize{Files: len(filesChanged), Lines: countChangedLines(diff)}
+			resolvedAgents = r.selectBySize(resolvedAgents, *size)
+			if len(resolvedAgents) == 0 {
+				r.log("No review agents apply to a change of this size")
+			}
+		}
+	}
 
-	if r.config.Parallel {
-		passResults, err = r.runAgentsParallel(ctx, resolvedAgents, workingDir, filesChanged)
-	} else {
-		passResults, err = r.runAgentsSequential(ctx, resolvedAgents, workingDir, filesChanged)
+	var diffChunks []string
+	if r.config.IncludeDiff && len(filesChanged) > 0 {
+		if diffErr != nil {
+			r.log(fmt.Sprintf("Warning: could not render diff, agents will read files instead: %v", diffErr))
+		} else if diff != "" {
+			limit := r.config.DiffLimit
+			if limit <= 0 {
+				limit = DefaultDiffLimit
+			}
+			diffChunks = splitDiff(diff, limit)
+			if len(diffChunks) > 1 {
+				r.log(fmt.Sprintf("Diff split into %d parts for review", len(diffChunks)))
+			}
+		}
 	}
 
+	r.refreshContextFiles(ctx, workingDir, filesChanged)
+	passResults, err := r.runAgents(ctx, resolvedAgents, workingDir, filesChanged, diffChunks, phase)
+	if err != nil {
+		return result, err
+	}
+	if scope != nil {
+		for _, res := range passResults {
+			filterBySeverity(res, scope.MinSeverity)
+		}
+	}
+
+	// The final check is not narrowed by the schedule and its failures are
+	// not up to the validators: a failing build blocks at every step.
+	finalCheck, err := r.runFinalCheck(ctx, workingDir, filesChanged)
+	if err != nil {
+		return result, err
+	}
+	// Neither are unmet acceptance criteria: they are the ticket's own terms.
+	acceptance, err := r.runAcceptanceCheck(ctx, workingDir, filesChanged, diffChunks)
 	if err != nil {
-		result.Duration = time.Since(start)
 		return result, err
 	}
 
 	// Assign stable IDs to issues for tracking across iterations
 	assignIssueIDs(passResults)
 
-	if r.config.ValidateSimplifications {
-		for i, res := range passResults {
-			if res.AgentName == "simplification" && len(res.Issues) > 0 {
-				validated, validateErr := r.ValidateSimplifications(ctx, workingDir, res)
-				if validateErr == nil {
-					passResults[i] = validated
-					assignIssueIDs([]*Result{passResults[i]})
-				}
-			}
+	passResults = r.runValidators(ctx, workingDir, passResults, size)
+
+	for _, builtin := range []*Result{finalCheck, acceptance} {
+		if builtin != nil {
+			assignIssueIDs([]*Result{builtin})
+			passResults = append(passResults, builtin)
 		}
 	}
 
-	if r.config.ValidateIssues {
-		totalNonSimp := 0
-		for _, res := range passResults {
-			if res.AgentName != "simplification" {
-				totalNonSimp += len(res.Issues)
-			}
-		}
-		if totalNonSimp > 0 {
-			validated, validateErr := r.ValidateIssues(ctx, workingDir, passResults)
-			if validateErr == nil {
-				passResults = validated
-			}
-		}
+	r.trackRecurringIssues(workingDir, passResults)
+	r.checkPatches(workingDir, passResults)
+	resolvePaths(workingDir, passResults)
+	if r.config.CodeOwners {
+		annotateOwners(workingDir, passResults)
 	}
 
 	result.Results = passResults
@@ -473,8 +741,8 @@ func (r *Runner) RunIteration(ctx context.Context, workingDir string, filesChang
 		}
 	}
 	result.TotalIssues = issueCount + errorCount
-	result.Passed = issueCount == 0 && errorCount == 0
-	result.Duration = time.Since(start)
+	result.Score = Score(passResults)
+	result.Passed = r.config.passes(issueCount, result.Score, errorCount)
 
 	return result, nil
 }
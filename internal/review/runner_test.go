@@ -2,7 +2,10 @@ package review
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -10,6 +13,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
 	"github.com/alexander-akhmetov/programmator/internal/llm/claude"
 	"github.com/alexander-akhmetov/programmator/internal/llm/executor"
 	"github.com/alexander-akhmetov/programmator/internal/protocol"
@@ -83,7 +88,7 @@ func TestClaudeAgent(t *testing.T) {
 		require.Equal(t, "test", agent.Name())
 
 		// Test buildPrompt
-		prompt := agent.buildPrompt([]string{"file1.go", "file2.go"})
+		prompt := agent.buildPrompt([]string{"file1.go", "file2.go"}, "")
 		require.Contains(t, prompt, "Base prompt")
 		require.Contains(t, prompt, "focus1")
 		require.Contains(t, prompt, "focus2")
@@ -113,6 +118,69 @@ func TestClaudeAgent(t *testing.T) {
 		require.Equal(t, "/custom/config", agent.executorConfig.Claude.ClaudeConfigDir)
 		require.Equal(t, "test-key", agent.executorConfig.Claude.AnthropicAPIKey)
 	})
+
+	t.Run("uses flags for its purpose", func(t *testing.T) {
+		cfg := executor.Config{
+			ExtraFlags: []string{"--verbose"},
+			PurposeFlags: map[llm.Purpose][]string{
+				llm.PurposeReview:    {"--model", "opus"},
+				llm.PurposeValidator: {"--model", "haiku"},
+			},
+		}
+		for name, want := range map[string]llm.Purpose{
+			"quality":                   llm.PurposeReview,
+			issueValidatorName:          llm.PurposeValidator,
+			simplificationValidatorName: llm.PurposeValidator,
+		} {
+			inv := &optsRecorder{}
+			agent := NewClaudeAgent(name, nil, "prompt", WithExecutorConfig(cfg), WithInvoker(inv))
+			_, err := agent.invokeClaude(context.Background(), t.TempDir(), "prompt")
+			require.NoError(t, err)
+			require.Equal(t, want, inv.opts.Purpose, name)
+			require.Equal(t, cfg.FlagsFor(want), inv.opts.ExtraFlags, name)
+		}
+	})
+}
+
+type optsRecorder struct {
+	opts llm.InvokeOptions
+}
+
+func (r *optsRecorder) Invoke(_ context.Context, _ string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
+	r.opts = opts
+	return &llm.InvokeResult{}, nil
+}
+
+// activityInvoker streams output and a tool use, as an executor would.
+type activityInvoker struct {
+	streaming bool
+}
+
+func (a *activityInvoker) Invoke(_ context.Context, _ string, opts llm.InvokeOptions) (*llm.InvokeResult, error) {
+	a.streaming = opts.Streaming
+	opts.OnOutput("Checking the ")
+	opts.OnOutput("handlers\n\n")
+	opts.OnOutput("Looking at")
+	opts.OnToolUse("Read", map[string]any{"file_path": "a.go"})
+	opts.OnOutput("Done")
+	return &llm.InvokeResult{Text: "REVIEW_RESULT:\n  issues: []\n"}, nil
+}
+
+func TestClaudeAgent_StreamsActivity(t *testing.T) {
+	inv := &activityInvoker{}
+	var events []event.Event
+	agent := NewClaudeAgent("quality", nil, "prompt", WithInvoker(inv),
+		WithEventHandler(func(ev event.Event) { events = append(events, ev) }))
+
+	_, err := agent.invokeClaude(context.Background(), t.TempDir(), "prompt")
+	require.NoError(t, err)
+	require.True(t, inv.streaming)
+	require.Equal(t, []event.Event{
+		event.AgentActivity("quality", "Checking the handlers"),
+		event.AgentActivity("quality", "Looking at"),
+		event.AgentActivity("quality", "Read a.go"),
+		event.AgentActivity("quality", "Done"),
+	}, events)
 }
 
 func TestDefaultAgentFactory_PassesExecutorConfig(t *testing.T) {
@@ -434,6 +502,40 @@ func TestRunner_RunIteration(t *testing.T) {
 		require.Len(t, result.Results, 2)
 	})
 
+	t.Run("keeps only the configured severities", func(t *testing.T) {
+		for _, parallel := range []bool{true, false} {
+			cfg := Config{
+				MaxIterations: 3,
+				Parallel:      parallel,
+				Agents:        []AgentConfig{{Name: "agent1"}},
+				Severities:    []Severity{SeverityCritical, SeverityHigh},
+			}
+
+			runner := NewRunner(cfg)
+			runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+				mock := NewMockAgent(agentCfg.Name)
+				mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*Result, error) {
+					return &Result{AgentName: agentCfg.Name, Issues: []Issue{
+						{Severity: SeverityCritical, Description: "crash"},
+						{Severity: SeverityMedium, Description: "naming"},
+						{Severity: SeverityHigh, Description: "leak"},
+						{Severity: "unknown", Description: "unranked"},
+					}}, nil
+				})
+				return mock
+			})
+
+			result, err := runner.RunIteration(context.Background(), "/tmp", []string{"file.go"})
+			require.NoError(t, err)
+			require.Equal(t, 3, result.TotalIssues, "parallel=%v", parallel)
+			var kept []string
+			for _, issue := range result.Results[0].Issues {
+				kept = append(kept, issue.Description)
+			}
+			require.Equal(t, []string{"crash", "leak", "unranked"}, kept)
+		}
+	})
+
 	t.Run("runs agents sequentially when not parallel", func(t *testing.T) {
 		callOrder := []string{}
 		cfg := Config{
@@ -790,6 +892,105 @@ func TestRunner_RunIteration(t *testing.T) {
 	})
 }
 
+func TestRunner_RunIteration_Schedule(t *testing.T) {
+	newRunner := func(calls *[]string, issues func(agent string) []Issue) *Runner {
+		runner := NewRunner(Config{
+			Parallel: false,
+			Agents:   []AgentConfig{{Name: "bugs"}, {Name: "style"}},
+			Schedule: []StrictnessStep{
+				{MinSeverity: SeverityCritical, Agents: []string{"bugs"}},
+				{MinSeverity: SeverityHigh},
+				{},
+			},
+		})
+		runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+			mock := NewMockAgent(agentCfg.Name)
+			mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*Result, error) {
+				*calls = append(*calls, agentCfg.Name)
+				return &Result{AgentName: agentCfg.Name, Issues: issues(agentCfg.Name)}, nil
+			})
+			return mock
+		})
+		return runner
+	}
+
+	t.Run("early iterations are limited to their step", func(t *testing.T) {
+		var calls []string
+		runner := newRunner(&calls, func(agent string) []Issue {
+			return []Issue{
+				{Severity: SeverityCritical, Description: agent + " crash"},
+				{Severity: SeverityLow, Description: agent + " naming"},
+			}
+		})
+
+		result, err := runner.RunIteration(context.Background(), "/tmp", []string{"file.go"})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Iteration)
+		require.Equal(t, []string{"bugs"}, calls)
+		require.Len(t, result.Results, 1)
+		require.Len(t, result.Results[0].Issues, 1)
+		require.Equal(t, "bugs crash", result.Results[0].Issues[0].Description)
+
+		calls = nil
+		result, err = runner.RunIteration(context.Background(), "/tmp", []string{"file.go"})
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Iteration)
+		require.Equal(t, []string{"bugs", "style"}, calls)
+		require.Equal(t, 2, result.TotalIssues, "low issues are still dropped")
+
+		_, err = runner.RunIteration(context.Background(), "/tmp", []string{"file.go"})
+		require.NoError(t, err)
+		result, err = runner.RunIteration(context.Background(), "/tmp", []string{"file.go"})
+		require.NoError(t, err)
+		require.Equal(t, 4, result.TotalIssues, "iterations past the schedule use its last step")
+	})
+
+	t.Run("a clean step broadens in the same iteration", func(t *testing.T) {
+		var calls []string
+		runner := newRunner(&calls, func(agent string) []Issue {
+			if agent == "style" {
+				return []Issue{{Severity: SeverityLow, Description: "naming"}}
+			}
+			return nil
+		})
+
+		result, err := runner.RunIteration(context.Background(), "/tmp", []string{"file.go"})
+		require.NoError(t, err)
+		require.False(t, result.Passed, "the review passes only at the last step")
+		require.Equal(t, 1, result.Iteration)
+		require.Equal(t, []string{"bugs", "bugs", "style", "bugs", "style"}, calls)
+		require.Equal(t, 1, result.TotalIssues)
+	})
+
+	t.Run("agent errors retry the same step", func(t *testing.T) {
+		var calls []string
+		fail := true
+		runner := newRunner(&calls, func(string) []Issue {
+			return []Issue{{Severity: SeverityCritical, Description: "crash"}}
+		})
+		runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+			mock := NewMockAgent(agentCfg.Name)
+			mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*Result, error) {
+				calls = append(calls, agentCfg.Name)
+				if fail {
+					return &Result{AgentName: agentCfg.Name, Error: errors.New("timeout")}, nil
+				}
+				return &Result{AgentName: agentCfg.Name, Issues: []Issue{{Severity: SeverityCritical, Description: "crash"}}}, nil
+			})
+			return mock
+		})
+
+		_, err := runner.RunIteration(context.Background(), "/tmp", []string{"file.go"})
+		require.NoError(t, err)
+		fail = false
+		calls = nil
+		result, err := runner.RunIteration(context.Background(), "/tmp", []string{"file.go"})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Iteration)
+		require.Equal(t, []string{"bugs"}, calls)
+	})
+}
+
 func TestRunner_RunIteration_ValidatorsAlwaysRun(t *testing.T) {
 	t.Run("validators run on every iteration call", func(t *testing.T) {
 		cfg := Config{
@@ -1493,3 +1694,121 @@ func TestRunner_ValidateIssues(t *testing.T) {
 		require.Len(t, validated[0].Issues, 2)
 	})
 }
+
+func TestRunner_RunIteration_PromptFileTemplate(t *testing.T) {
+	dir := t.TempDir()
+	promptPath := filepath.Join(dir, "review.md.tmpl")
+	require.NoError(t, os.WriteFile(promptPath, []byte(
+		"Review {{.Agent}} vs {{.BaseBranch}} at {{.MinSeverity}}+:\n{{.FilesList}}\n{{.TicketContext}}"), 0o644))
+
+	runner := NewRunner(Config{
+		Agents:        []AgentConfig{{Name: "custom", PromptFile: "review.md.tmpl", MinSeverity: SeverityMedium}},
+		TicketContext: "Ticket body",
+		BaseBranch:    "develop",
+	})
+	var prompts []string
+	runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+		prompts = append(prompts, agentCfg.Prompt)
+		mock := NewMockAgent(agentCfg.Name)
+		mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*Result, error) {
+			return &Result{AgentName: agentCfg.Name, Issues: []Issue{
+				{File: "a.go", Severity: SeverityHigh, Description: "kept"},
+				{File: "a.go", Severity: SeverityLow, Description: "dropped"},
+			}}, nil
+		})
+		return mock
+	})
+
+	result, err := runner.RunIteration(context.Background(), dir, []string{"a.go", "b.go"})
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1)
+	require.Len(t, result.Results[0].Issues, 1)
+	require.Equal(t, "kept", result.Results[0].Issues[0].Description)
+	require.Equal(t, []string{"Review custom vs develop at medium+:\n- a.go\n- b.go\nTicket body"}, prompts)
+
+	// Edits to the prompt file apply on the next iteration.
+	require.NoError(t, os.WriteFile(promptPath, []byte("Updated {{len .Files}}"), 0o644))
+	_, err = runner.RunIteration(context.Background(), dir, []string{"a.go"})
+	require.NoError(t, err)
+	require.Equal(t, "Updated 1", prompts[len(prompts)-1])
+
+	// An unchanged prompt reuses the agent.
+	_, err = runner.RunIteration(context.Background(), dir, []string{"b.go"})
+	require.NoError(t, err)
+	require.Len(t, prompts, 2)
+
+	require.NoError(t, os.WriteFile(promptPath, []byte("{{.Missing}}"), 0o644))
+	_, err = runner.RunIteration(context.Background(), dir, []string{"a.go"})
+	require.ErrorContains(t, err, "render prompt_file")
+}
+
+func TestRunner_LoadPromptFile(t *testing.T) {
+	dir := t.TempDir()
+	runner := NewRunner(Config{TicketContext: "Ticket body", SanitizeInputs: false})
+
+	// Without the .tmpl suffix a prompt file is used as written.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "raw.md"), []byte("Check {{ braces }} and .TicketContext"), 0o644))
+	prompt, placed, err := runner.loadPromptFile(AgentConfig{Name: "raw", PromptFile: "raw.md"}, dir, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Check {{ braces }} and .TicketContext", prompt)
+	require.False(t, placed, "the ticket context is appended")
+
+	// A template mentioning the ticket context without rendering it still
+	// gets it appended.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "t.md.tmpl"), []byte("{{/* no .TicketContext here */}}Review"), 0o644))
+	prompt, placed, err = runner.loadPromptFile(AgentConfig{Name: "t", PromptFile: "t.md.tmpl"}, dir, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Review", prompt)
+	require.False(t, placed)
+}
+
+func TestRunner_ValidatorResultsCached(t *testing.T) {
+	cfg := Config{
+		MaxIterations:  3,
+		Agents:         []AgentConfig{{Name: "quality"}},
+		ValidateIssues: true,
+	}
+	runner := NewRunner(cfg)
+
+	description := "Real bug"
+	validatorCalls := 0
+	runner.SetAgentFactory(func(agentCfg AgentConfig, _ string) Agent {
+		mock := NewMockAgent(agentCfg.Name)
+		switch agentCfg.Name {
+		case "quality":
+			mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*Result, error) {
+				return &Result{
+					AgentName: "quality",
+					Issues: []Issue{
+						{ID: "id-keep", File: "a.go", Severity: SeverityHigh, Category: "bugs", Description: description},
+						{ID: "id-drop", File: "b.go", Severity: SeverityLow, Category: "style", Description: "False positive"},
+					},
+				}, nil
+			})
+		case "issue-validator":
+			mock.SetReviewFunc(func(_ context.Context, _ string, _ []string) (*Result, error) {
+				validatorCalls++
+				return &Result{
+					AgentName: "issue-validator",
+					Issues: []Issue{
+						{ID: "id-keep", Verdict: "valid"},
+						{ID: "id-drop", Verdict: "false_positive"},
+					},
+				}, nil
+			})
+		}
+		return mock
+	})
+
+	for range 2 {
+		result, err := runner.RunIteration(context.Background(), "/tmp", []string{"a.go"})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.TotalIssues)
+	}
+	require.Equal(t, 1, validatorCalls, "same findings reuse the validator result")
+
+	description = "Another bug"
+	_, err := runner.RunIteration(context.Background(), "/tmp", []string{"a.go"})
+	require.NoError(t, err)
+	require.Equal(t, 2, validatorCalls, "changed findings are validated again")
+}
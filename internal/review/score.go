@@ -0,0 +1,39 @@
+package review
+
+// SeverityWeight is what an issue of severity s adds to a review's Score:
+// critical 10, high 5, medium 2, low 1, info and unknown 0.
+func SeverityWeight(s Severity) int {
+	switch s {
+	case SeverityCritical:
+		return 10
+	case SeverityHigh:
+		return 5
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Score returns the severity-weighted sum of the issues in results.
+func Score(results []*Result) int {
+	score := 0
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			score += SeverityWeight(issue.Severity)
+		}
+	}
+	return score
+}
+
+// passes reports whether a review pass with issueCount issues scoring score
+// and errorCount agent errors passes: with no issues, or, when
+// Config.ScoreThreshold is set, with a score below it.
+func (c Config) passes(issueCount, score, errorCount int) bool {
+	if errorCount > 0 {
+		return false
+	}
+	return issueCount == 0 || (c.ScoreThreshold > 0 && score < c.ScoreThreshold)
+}
@@ -0,0 +1,50 @@
+package review
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScore(t *testing.T) {
+	assert.Equal(t, 0, Score(nil))
+	assert.Equal(t, 18, Score([]*Result{
+		{Issues: []Issue{{Severity: SeverityCritical}, {Severity: SeverityHigh}}},
+		{Issues: []Issue{{Severity: SeverityMedium}, {Severity: SeverityLow}, {Severity: SeverityInfo}}},
+	}))
+}
+
+func TestRunner_RunIteration_ScoreThreshold(t *testing.T) {
+	issues := []Issue{
+		{File: "a.go", Severity: SeverityMedium, Description: "Unclear name"},
+		{File: "a.go", Severity: SeverityLow, Description: "Typo"},
+	}
+	for _, tc := range []struct {
+		threshold int
+		passed    bool
+	}{
+		{threshold: 0, passed: false},
+		{threshold: 3, passed: false},
+		{threshold: 4, passed: true},
+	} {
+		runner := NewRunner(Config{
+			Agents:         []AgentConfig{{Name: "quality"}},
+			ScoreThreshold: tc.threshold,
+		})
+		runner.SetAgentFactory(func(cfg AgentConfig, _ string) Agent {
+			mock := NewMockAgent(cfg.Name)
+			mock.SetReviewFunc(func(context.Context, string, []string) (*Result, error) {
+				return &Result{AgentName: cfg.Name, Issues: issues}, nil
+			})
+			return mock
+		})
+
+		result, err := runner.RunIteration(context.Background(), t.TempDir(), []string{"a.go"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Score)
+		assert.Equal(t, tc.passed, result.Passed, "threshold %d", tc.threshold)
+		assert.Equal(t, 2, result.TotalIssues)
+	}
+}
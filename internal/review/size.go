@@ -0,0 +1,116 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SizeRule restricts a review agent or validator pass to changes of a given
+// size, so large diffs can get extra agents and tiny ones skip expensive
+// ones. Bounds are inclusive; zero means unbounded.
+type SizeRule struct {
+	MinLines int `yaml:"min_lines,omitempty"` // changed (added + removed) lines
+	MaxLines int `yaml:"max_lines,omitempty"`
+	MinFiles int `yaml:"min_files,omitempty"` // changed files
+	MaxFiles int `yaml:"max_files,omitempty"`
+}
+
+// ChangeSize measures the change under review.
+type ChangeSize struct {
+	Files int
+	Lines int
+}
+
+// IsZero reports whether the rule allows every change.
+func (s SizeRule) IsZero() bool {
+	return s == SizeRule{}
+}
+
+// Allows reports whether a change of the given size satisfies the rule.
+func (s SizeRule) Allows(size ChangeSize) bool {
+	return within(size.Lines, s.MinLines, s.MaxLines) && within(size.Files, s.MinFiles, s.MaxFiles)
+}
+
+func within(n, lo, hi int) bool {
+	return n >= lo && (hi == 0 || n <= hi)
+}
+
+// Validate rejects negative bounds and minimums above maximums.
+func (s SizeRule) Validate() error {
+	if s.MinLines < 0 || s.MaxLines < 0 || s.MinFiles < 0 || s.MaxFiles < 0 {
+		return fmt.Errorf("size bounds must not be negative")
+	}
+	if s.MaxLines > 0 && s.MinLines > s.MaxLines {
+		return fmt.Errorf("min_lines %d is above max_lines %d", s.MinLines, s.MaxLines)
+	}
+	if s.MaxFiles > 0 && s.MinFiles > s.MaxFiles {
+		return fmt.Errorf("min_files %d is above max_files %d", s.MinFiles, s.MaxFiles)
+	}
+	return nil
+}
+
+// String describes the rule for log messages.
+func (s SizeRule) String() string {
+	var parts []string
+	if s.MinLines > 0 || s.MaxLines > 0 {
+		parts = append(parts, "lines "+boundString(s.MinLines, s.MaxLines))
+	}
+	if s.MinFiles > 0 || s.MaxFiles > 0 {
+		parts = append(parts, "files "+boundString(s.MinFiles, s.MaxFiles))
+	}
+	if len(parts) == 0 {
+		return "any size"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func boundString(lo, hi int) string {
+	switch {
+	case hi == 0:
+		return fmt.Sprintf(">= %d", lo)
+	case lo == 0:
+		return fmt.Sprintf("<= %d", hi)
+	default:
+		return fmt.Sprintf("%d-%d", lo, hi)
+	}
+}
+
+// HasSizeRules reports whether any agent or validator depends on the size
+// of the change.
+func (c Config) HasSizeRules() bool {
+	if !c.IssueValidatorWhen.IsZero() || !c.SimplificationValidatorWhen.IsZero() {
+		return true
+	}
+	for _, a := range c.Agents {
+		if !a.When.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// countChangedLines counts added and removed lines in a unified diff.
+func countChangedLines(diff string) int {
+	n := 0
+	for line := range strings.Lines(diff) {
+		switch {
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, "-"):
+			n++
+		}
+	}
+	return n
+}
+
+// selectBySize drops agents whose When rule rejects the change.
+func (r *Runner) selectBySize(agents []AgentConfig, size ChangeSize) []AgentConfig {
+	selected := make([]AgentConfig, 0, len(agents))
+	for _, a := range agents {
+		if !a.When.Allows(size) {
+			r.log(fmt.Sprintf("Skipping %s: change of %d line(s) in %d file(s) is outside %s", a.Name, size.Lines, size.Files, a.When))
+			continue
+		}
+		selected = append(selected, a)
+	}
+	return selected
+}
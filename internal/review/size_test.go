@@ -0,0 +1,101 @@
+package review
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+)
+
+func TestSizeRule_Allows(t *testing.T) {
+	tests := []struct {
+		name string
+		rule SizeRule
+		size ChangeSize
+		want bool
+	}{
+		{name: "zero rule", rule: SizeRule{}, size: ChangeSize{Files: 100, Lines: 10000}, want: true},
+		{name: "below min lines", rule: SizeRule{MinLines: 50}, size: ChangeSize{Files: 1, Lines: 49}, want: false},
+		{name: "at min lines", rule: SizeRule{MinLines: 50}, size: ChangeSize{Files: 1, Lines: 50}, want: true},
+		{name: "above max lines", rule: SizeRule{MaxLines: 10}, size: ChangeSize{Files: 1, Lines: 11}, want: false},
+		{name: "too many files", rule: SizeRule{MaxFiles: 3}, size: ChangeSize{Files: 4, Lines: 1}, want: false},
+		{name: "lines and files", rule: SizeRule{MinLines: 10, MinFiles: 2}, size: ChangeSize{Files: 2, Lines: 10}, want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.rule.Allows(tc.size))
+		})
+	}
+}
+
+func TestSizeRule_Validate(t *testing.T) {
+	require.NoError(t, SizeRule{MinLines: 10, MaxLines: 10}.Validate())
+	require.NoError(t, SizeRule{MinFiles: 5}.Validate())
+	require.ErrorContains(t, SizeRule{MaxFiles: -1}.Validate(), "negative")
+	require.ErrorContains(t, SizeRule{MinFiles: 4, MaxFiles: 2}.Validate(), "min_files 4 is above max_files 2")
+}
+
+func TestSizeRule_String(t *testing.T) {
+	assert.Equal(t, "any size", SizeRule{}.String())
+	assert.Equal(t, "lines >= 200", SizeRule{MinLines: 200}.String())
+	assert.Equal(t, "lines 10-50, files <= 3", SizeRule{MinLines: 10, MaxLines: 50, MaxFiles: 3}.String())
+}
+
+func TestCountChangedLines(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n-old\n+new\n context\n+added"
+	assert.Equal(t, 3, countChangedLines(diff))
+	assert.Equal(t, 0, countChangedLines(""))
+}
+
+func TestRunner_RunIteration_SizeRules(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"commit", "--allow-empty", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte(strings.Repeat("x\n", 20)), 0o644))
+
+	var ran []string
+	runner := NewRunner(Config{
+		Agents: []AgentConfig{
+			{Name: "always"},
+			{Name: "small", When: SizeRule{MaxLines: 50}},
+			{Name: "large", When: SizeRule{MinLines: 100}},
+			{Name: "multi-file", When: SizeRule{MinFiles: 2}},
+		},
+		ValidateIssues:     true,
+		IssueValidatorWhen: SizeRule{MinLines: 1000},
+	})
+	runner.SetAgentFactory(func(cfg AgentConfig, _ string) Agent {
+		mock := NewMockAgent(cfg.Name)
+		mock.SetReviewFunc(func(context.Context, string, []string) (*Result, error) {
+			ran = append(ran, cfg.Name)
+			return &Result{AgentName: cfg.Name, Issues: []Issue{{File: "a.go", Severity: SeverityLow, Description: "x"}}}, nil
+		})
+		return mock
+	})
+	var logs []string
+	runner.SetEventCallback(func(e event.Event) { logs = append(logs, e.Text) })
+
+	result, err := runner.RunIteration(context.Background(), dir, []string{"a.go"})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"always", "small"}, ran)
+	assert.Len(t, result.AllIssues(), 2, "issue validator skipped for a 20-line change")
+	assert.Contains(t, strings.Join(logs, "\n"), "Skipping large: change of 20 line(s) in 1 file(s) is outside lines >= 100")
+}
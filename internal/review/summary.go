@@ -0,0 +1,256 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	gitutil "github.com/alexander-akhmetov/programmator/internal/git"
+)
+
+// manifestFiles are read into a repository summary and its fingerprint:
+// a changed dependency or module path is a reason to summarize again.
+var manifestFiles = []string{"go.mod", "package.json", "Cargo.toml", "pyproject.toml"}
+
+// Limits keeping the summary small next to the review prompt.
+const (
+	maxSummaryDirs  = 150  // directories listed
+	maxSummaryBytes = 8000 // summary text
+)
+
+// repoSummary is a cached overview of a repository for review prompts, so
+// agents start knowing its layout instead of exploring it with tools.
+type repoSummary struct {
+	Fingerprint string    `json:"fingerprint"` // manifests and directory structure it was built from
+	Text        string    `json:"text"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// skipSummaryDir reports whether a directory is left out of summaries:
+// hidden, vendored, and generated trees.
+func skipSummaryDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" || name == "testdata"
+}
+
+// summaryDirs returns the directories under root, slash-separated and
+// relative to it, in walk order.
+func summaryDirs(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != root && skipSummaryDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		dirs = append(dirs, filepath.ToSlash(rel))
+		return nil
+	})
+	return dirs, err
+}
+
+// summaryFingerprint hashes the manifests and directory structure of root.
+func summaryFingerprint(root string, dirs []string) string {
+	h := sha256.New()
+	for _, name := range manifestFiles {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err == nil { //nolint:gosec // fixed name under the repository root
+			fmt.Fprintf(h, "%s\x00%s\x00", name, data)
+		}
+	}
+	for _, dir := range dirs {
+		fmt.Fprintf(h, "%s\x00", dir)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)[:16])
+}
+
+// buildRepoSummary describes root: its module or package name from the
+// manifests, and its directories with the first sentence of each Go
+// package's doc comment.
+func buildRepoSummary(root string, dirs []string) string {
+	var b strings.Builder
+	for _, name := range manifestFiles {
+		if line := manifestHeadline(filepath.Join(root, name)); line != "" {
+			fmt.Fprintf(&b, "%s: %s\n", name, line)
+		}
+	}
+
+	b.WriteString("Directories:\n")
+	listed := 0
+	for _, dir := range dirs {
+		if dir == "." {
+			continue
+		}
+		if listed == maxSummaryDirs {
+			fmt.Fprintf(&b, "- ... %d more\n", len(dirs)-1-listed)
+			break
+		}
+		listed++
+		b.WriteString("- " + dir)
+		if synopsis := packageSynopsis(filepath.Join(root, dir)); synopsis != "" {
+			b.WriteString(": " + synopsis)
+		}
+		b.WriteString("\n")
+	}
+
+	text := b.String()
+	if len(text) > maxSummaryBytes {
+		cut := strings.LastIndexByte(text[:maxSummaryBytes], '\n')
+		text = text[:cut+1] + "- ... (truncated)\n"
+	}
+	return text
+}
+
+// manifestHeadline returns what names the project in a manifest: the
+// module line of go.mod, or the first name field of the others.
+func manifestHeadline(path string) string {
+	data, err := os.ReadFile(path) //nolint:gosec // fixed name under the repository root
+	if err != nil {
+		return ""
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if filepath.Base(path) == "go.mod" {
+			if module, ok := strings.CutPrefix(line, "module "); ok {
+				return strings.TrimSpace(module)
+			}
+			continue
+		}
+		line = strings.TrimSuffix(line, ",")
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.Trim(key, `"`) == "name" {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && strings.TrimSpace(key) == "name" {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// packageSynopsis returns the first sentence of the doc comment of the Go
+// package in dir, or "" when it has none.
+func packageSynopsis(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	fset := token.NewFileSet()
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil || f.Doc == nil {
+			continue
+		}
+		text := strings.Join(strings.Fields(f.Doc.Text()), " ")
+		if i := strings.Index(text, ". "); i >= 0 {
+			text = text[:i+1]
+		}
+		return text
+	}
+	return ""
+}
+
+// loadRepoSummary returns the summary of the repository containing
+// workingDir, from the cache in summaryDir while its fingerprint matches,
+// and rebuilt and cached otherwise.
+func loadRepoSummary(summaryDir, workingDir string) (string, error) {
+	root := workingDir
+	if repo, err := gitutil.NewRepo(workingDir); err == nil {
+		root = repo.Root()
+	}
+	dirs, err := summaryDirs(root)
+	if err != nil {
+		return "", fmt.Errorf("scan repository: %w", err)
+	}
+	fingerprint := summaryFingerprint(root, dirs)
+
+	path := historyPath(summaryDir, root)
+	var cached repoSummary
+	data, err := os.ReadFile(path) //nolint:gosec // path is derived from the state dir
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return "", fmt.Errorf("read repository summary: %w", err)
+	default:
+		if json.Unmarshal(data, &cached) == nil && cached.Fingerprint == fingerprint {
+			return cached.Text, nil
+		}
+	}
+
+	summary := repoSummary{Fingerprint: fingerprint, Text: buildRepoSummary(root, dirs), GeneratedAt: time.Now()}
+	if err := saveRepoSummary(path, summary); err != nil {
+		return summary.Text, err
+	}
+	return summary.Text, nil
+}
+
+func saveRepoSummary(path string, summary repoSummary) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create repository summary dir: %w", err)
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal repository summary: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".summary-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("write repository summary: %w", err)
+	}
+	return os.Rename(tmpName, path)
+}
+
+// refreshRepoSummary loads the repository summary before an iteration when
+// Config.SummaryDir is set. Failures are logged and leave the previous
+// summary, if any, in place.
+func (r *Runner) refreshRepoSummary(workingDir string) {
+	if r.config.SummaryDir == "" {
+		return
+	}
+	summary, err := loadRepoSummary(r.config.SummaryDir, workingDir)
+	if err != nil {
+		r.log(fmt.Sprintf("Warning: repository summary: %v", err))
+	}
+	if summary != "" {
+		r.summary = summary
+	}
+}
+
+// addRepoSummary prepends the repository summary to an agent prompt.
+func addRepoSummary(prompt, summary string) string {
+	if summary == "" {
+		return prompt
+	}
+	return "## Repository Overview\n" +
+		"A cached outline of this repository. Use it to find your way; read files only for what it does not cover.\n\n" +
+		summary + "\n" + prompt
+}
+
+// getsRepoSummary reports whether an agent gets the repository summary:
+// reviewers do, validators, which only judge findings, do not.
+func getsRepoSummary(name string) bool {
+	return !slices.Contains([]string{simplificationValidatorName, issueValidatorName}, name)
+}
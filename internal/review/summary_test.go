@@ -0,0 +1,115 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSummaryRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":               "module example.com/app\n\ngo 1.22\n",
+		"internal/store/db.go": "// Package store persists orders in SQLite. It owns the schema.\npackage store\n",
+		"cmd/app/main.go":      "package main\n",
+		".git/HEAD":            "ref: refs/heads/main\n",
+		"vendor/x/x.go":        "// Package x is vendored.\npackage x\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return root
+}
+
+func TestBuildRepoSummary(t *testing.T) {
+	root := writeSummaryRepo(t)
+	dirs, err := summaryDirs(root)
+	require.NoError(t, err)
+
+	summary := buildRepoSummary(root, dirs)
+	assert.Contains(t, summary, "go.mod: example.com/app\n")
+	assert.Contains(t, summary, "- internal/store: Package store persists orders in SQLite.\n")
+	assert.Contains(t, summary, "- cmd/app\n")
+	assert.NotContains(t, summary, "vendor")
+	assert.NotContains(t, summary, ".git")
+}
+
+func TestLoadRepoSummary_CachedUntilStructureChanges(t *testing.T) {
+	root := writeSummaryRepo(t)
+	cacheDir := t.TempDir()
+
+	first, err := loadRepoSummary(cacheDir, root)
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+
+	// A cached summary is reused while the fingerprint matches.
+	path := historyPath(cacheDir, root)
+	var cached repoSummary
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &cached))
+	cached.Text = "cached"
+	data, err = json.Marshal(cached)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	again, err := loadRepoSummary(cacheDir, root)
+	require.NoError(t, err)
+	assert.Equal(t, "cached", again)
+
+	// A new directory changes the structure.
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "internal", "api"), 0o755))
+	rebuilt, err := loadRepoSummary(cacheDir, root)
+	require.NoError(t, err)
+	assert.Contains(t, rebuilt, "- internal/api\n")
+
+	// So does an edited go.mod.
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/renamed\n"), 0o600))
+	rebuilt, err = loadRepoSummary(cacheDir, root)
+	require.NoError(t, err)
+	assert.Contains(t, rebuilt, "go.mod: example.com/renamed\n")
+}
+
+func TestBuildRepoSummary_Truncates(t *testing.T) {
+	root := t.TempDir()
+	for i := range maxSummaryDirs + 5 {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, fmt.Sprintf("%s%03d", strings.Repeat("d", 80), i)), 0o755))
+	}
+	dirs, err := summaryDirs(root)
+	require.NoError(t, err)
+
+	summary := buildRepoSummary(root, dirs)
+	assert.LessOrEqual(t, len(summary), maxSummaryBytes+len("- ... (truncated)\n"))
+	assert.True(t, strings.HasSuffix(summary, "- ... (truncated)\n"))
+}
+
+func TestDefaultAgentFactory_PrependsRepoSummary(t *testing.T) {
+	root := writeSummaryRepo(t)
+	runner := NewRunner(Config{SummaryDir: t.TempDir()})
+	runner.refreshRepoSummary(root)
+
+	agent := runner.getOrCreateAgent(AgentConfig{Name: "quality"})
+	prompt := agent.(*ClaudeAgent).prompt
+	assert.True(t, strings.HasPrefix(prompt, "## Repository Overview\n"))
+	assert.Contains(t, prompt, "internal/store: Package store persists orders in SQLite.")
+
+	validator := runner.getOrCreateAgent(AgentConfig{Name: issueValidatorName})
+	assert.NotContains(t, validator.(*ClaudeAgent).prompt, "## Repository Overview")
+
+	// A changed summary rebuilds the agent.
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "internal", "api"), 0o755))
+	runner.refreshRepoSummary(root)
+	rebuilt := runner.getOrCreateAgent(AgentConfig{Name: "quality"})
+	assert.NotSame(t, agent, rebuilt)
+	assert.Contains(t, rebuilt.(*ClaudeAgent).prompt, "- internal/api\n")
+	assert.Same(t, rebuilt, runner.getOrCreateAgent(AgentConfig{Name: "quality"}))
+}
@@ -0,0 +1,82 @@
+package review
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// promptTemplateSuffix marks a prompt_file as a Go template. Other prompt
+// files are used as written, so braces in them need no escaping.
+const promptTemplateSuffix = ".tmpl"
+
+// promptTemplateData holds the variables available to prompt_file templates.
+type promptTemplateData struct {
+	Agent       string   // agent name
+	Files       []string // files under review
+	FilesList   string   // Files as a markdown bullet list
+	BaseBranch  string   // branch the changes are compared against, may be empty
+	MinSeverity Severity // the agent's severity filter, empty when unset
+
+	ticketContext string
+	ticketUsed    bool // the template placed the ticket context
+}
+
+// TicketContext returns the full ticket or plan content, fenced unless
+// review.injection.sanitize is off; empty for standalone reviews.
+func (d *promptTemplateData) TicketContext() string {
+	d.ticketUsed = true
+	return d.ticketContext
+}
+
+// loadPromptFile reads an agent's prompt_file, rendering it as a Go template
+// when its name ends in promptTemplateSuffix. The file is read on every call
+// so edits take effect on the next review without restarting programmator.
+// The second result reports whether the template placed the ticket context
+// itself, so it is not appended again.
+func (r *Runner) loadPromptFile(cfg AgentConfig, workingDir string, filesChanged []string) (string, bool, error) {
+	path := cfg.PromptFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // prompt file path is user-configured
+	if err != nil {
+		return "", false, fmt.Errorf("read prompt_file %q: %w", cfg.PromptFile, err)
+	}
+
+	if !strings.HasSuffix(path, promptTemplateSuffix) {
+		return string(data), false, nil
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return "", false, fmt.Errorf("parse prompt_file %q: %w", cfg.PromptFile, err)
+	}
+
+	vars := &promptTemplateData{
+		Agent:         cfg.Name,
+		Files:         filesChanged,
+		FilesList:     formatFilesList(filesChanged),
+		BaseBranch:    r.config.BaseBranch,
+		MinSeverity:   cfg.MinSeverity,
+		ticketContext: r.untrustedTicketContext(),
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", false, fmt.Errorf("render prompt_file %q: %w", cfg.PromptFile, err)
+	}
+	return b.String(), vars.ticketUsed, nil
+}
+
+func formatFilesList(files []string) string {
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString("- ")
+		b.WriteString(f)
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,65 @@
+package review
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// runValidators runs the simplification and issue validators that apply to
+// a change of size (nil = unknown) on results and merges what they keep. The
+// validators are independent, as the issue validator skips simplification
+// results, so with Config.Parallel they run concurrently.
+func (r *Runner) runValidators(ctx context.Context, workingDir string, results []*Result, size *ChangeSize) []*Result {
+	var simplifications []int
+	if r.config.ValidateSimplifications && (size == nil || r.config.SimplificationValidatorWhen.Allows(*size)) {
+		for i, res := range results {
+			if res.AgentName == "simplification" && len(res.Issues) > 0 {
+				simplifications = append(simplifications, i)
+			}
+		}
+	}
+	validateIssues := r.config.ValidateIssues && (size == nil || r.config.IssueValidatorWhen.Allows(*size)) &&
+		countValidatable(results) > 0
+	if len(simplifications) == 0 && !validateIssues {
+		return results
+	}
+	r.startPhase(PhaseValidation)
+
+	var wg sync.WaitGroup
+	run := func(f func()) {
+		if r.config.Parallel {
+			wg.Go(f)
+		} else {
+			f()
+		}
+	}
+	simplified := make([]*Result, len(simplifications))
+	for j, i := range simplifications {
+		run(func() { simplified[j] = r.validateSimplifications(ctx, workingDir, results[i]) })
+	}
+	validated := results
+	if validateIssues {
+		run(func() { validated = r.validateIssues(ctx, workingDir, results) })
+	}
+	wg.Wait()
+
+	merged := slices.Clone(validated)
+	for j, i := range simplifications {
+		merged[i] = simplified[j]
+		assignIssueIDs([]*Result{merged[i]})
+	}
+	return merged
+}
+
+// countValidatable returns the number of issues the issue validator checks:
+// those of every result but simplification.
+func countValidatable(results []*Result) int {
+	n := 0
+	for _, res := range results {
+		if res.AgentName != "simplification" {
+			n += len(res.Issues)
+		}
+	}
+	return n
+}
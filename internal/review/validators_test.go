@@ -0,0 +1,117 @@
+package review
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/event"
+)
+
+func TestRunner_RunIteration_ValidatorsRunConcurrently(t *testing.T) {
+	runner := NewRunner(Config{
+		Parallel:                true,
+		Agents:                  []AgentConfig{{Name: "quality"}, {Name: "simplification"}},
+		ValidateIssues:          true,
+		ValidateSimplifications: true,
+	})
+
+	// Each validator waits for the other to start, so they only both finish
+	// when run at the same time.
+	var started sync.WaitGroup
+	started.Add(2)
+	wait := func() error {
+		started.Done()
+		done := make(chan struct{})
+		go func() { started.Wait(); close(done) }()
+		select {
+		case <-done:
+			return nil
+		case <-time.After(5 * time.Second):
+			return context.DeadlineExceeded
+		}
+	}
+
+	runner.SetAgentFactory(func(cfg AgentConfig, _ string) Agent {
+		mock := NewMockAgent(cfg.Name)
+		mock.SetReviewFunc(func(context.Context, string, []string) (*Result, error) {
+			switch cfg.Name {
+			case "quality":
+				return &Result{AgentName: cfg.Name, Issues: []Issue{
+					{ID: "q1", File: "a.go", Severity: SeverityHigh, Description: "Real bug"},
+					{ID: "q2", File: "a.go", Severity: SeverityLow, Description: "Not a bug"},
+				}}, nil
+			case "simplification":
+				return &Result{AgentName: cfg.Name, Issues: []Issue{
+					{File: "a.go", Severity: SeverityLow, Description: "Inline helper"},
+					{File: "a.go", Severity: SeverityLow, Description: "Drop wrapper"},
+				}}, nil
+			case issueValidatorName:
+				if err := wait(); err != nil {
+					return nil, err
+				}
+				return &Result{AgentName: cfg.Name, Issues: []Issue{
+					{ID: "q1", Verdict: "valid"},
+					{ID: "q2", Verdict: "false_positive"},
+				}}, nil
+			case simplificationValidatorName:
+				if err := wait(); err != nil {
+					return nil, err
+				}
+				return &Result{AgentName: cfg.Name, Issues: []Issue{
+					{File: "a.go", Severity: SeverityLow, Description: "Drop wrapper"},
+				}}, nil
+			}
+			return &Result{AgentName: cfg.Name}, nil
+		})
+		return mock
+	})
+
+	result, err := runner.RunIteration(context.Background(), t.TempDir(), []string{"a.go"})
+	require.NoError(t, err)
+
+	kept := map[string][]string{}
+	for _, res := range result.Results {
+		for _, issue := range res.Issues {
+			kept[res.AgentName] = append(kept[res.AgentName], issue.Description)
+			assert.NotEmpty(t, issue.ID)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"quality":        {"Real bug"},
+		"simplification": {"Drop wrapper"},
+	}, kept)
+}
+
+func TestRunner_RunIteration_ValidatorTimeout(t *testing.T) {
+	runner := NewRunner(Config{
+		Agents:           []AgentConfig{{Name: "quality"}},
+		ValidateIssues:   true,
+		ValidatorTimeout: 1,
+	})
+	runner.SetAgentFactory(func(cfg AgentConfig, _ string) Agent {
+		mock := NewMockAgent(cfg.Name)
+		mock.SetReviewFunc(func(ctx context.Context, _ string, _ []string) (*Result, error) {
+			if cfg.Name == issueValidatorName {
+				<-ctx.Done()
+				return &Result{AgentName: cfg.Name}, nil
+			}
+			return &Result{AgentName: cfg.Name, Issues: []Issue{
+				{File: "a.go", Severity: SeverityHigh, Description: "Real bug"},
+			}}, nil
+		})
+		return mock
+	})
+	var logs []string
+	runner.SetEventCallback(func(e event.Event) { logs = append(logs, e.Text) })
+
+	result, err := runner.RunIteration(context.Background(), t.TempDir(), []string{"a.go"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TotalIssues, "findings are kept unfiltered")
+	assert.Contains(t, strings.Join(logs, "\n"), "issue-validator timed out after 1s")
+}
@@ -0,0 +1,96 @@
+package safety
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Limit scopes: whether MaxIterations counts the iterations of one session
+// or of every session run on the same work item.
+const (
+	LimitScopeSession  = "session"
+	LimitScopeWorkItem = "work_item"
+)
+
+// Checkpoint is the part of a State that outlives a session: the cumulative
+// counts a later run of the same work item continues from when limits apply
+// to the work item's lifetime.
+type Checkpoint struct {
+	Iterations    int                    `json:"iterations"`
+	TokensByModel map[string]ModelTokens `json:"tokens_by_model,omitempty"`
+	Sessions      int                    `json:"sessions"` // sessions that contributed to the counts
+	UpdatedAt     time.Time              `json:"updated_at,omitzero"`
+}
+
+// Checkpoint captures the cumulative counts of s. sessions is the number of
+// sessions they span, including the current one.
+func (s *State) Checkpoint(sessions int) Checkpoint {
+	cp := Checkpoint{Iterations: s.Iteration, Sessions: sessions, UpdatedAt: time.Now()}
+	for model, t := range s.TokensByModel {
+		if cp.TokensByModel == nil {
+			cp.TokensByModel = make(map[string]ModelTokens, len(s.TokensByModel))
+		}
+		cp.TokensByModel[model] = *t
+	}
+	return cp
+}
+
+// Resume continues the iteration and token counts of cp. Counters that
+// detect a stuck session, like ConsecutiveNoChanges, start over.
+func (s *State) Resume(cp Checkpoint) {
+	s.Iteration += cp.Iterations
+	for model, t := range cp.TokensByModel {
+		if s.TokensByModel[model] == nil {
+			s.TokensByModel[model] = &ModelTokens{}
+		}
+		s.TokensByModel[model].InputTokens += t.InputTokens
+		s.TokensByModel[model].OutputTokens += t.OutputTokens
+	}
+}
+
+// LoadCheckpoint reads the checkpoint at path. A missing file is an empty
+// checkpoint.
+func LoadCheckpoint(path string) (Checkpoint, error) {
+	var cp Checkpoint
+	data, err := os.ReadFile(path) //nolint:gosec // path under the state dir
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, fmt.Errorf("read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, fmt.Errorf("parse checkpoint %s: %w", path, err)
+	}
+	return cp, nil
+}
+
+// SaveCheckpoint writes cp to path atomically.
+func SaveCheckpoint(path string, cp Checkpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create checkpoint dir: %w", err)
+	}
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".checkpoint-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	return os.Rename(tmpName, path)
+}
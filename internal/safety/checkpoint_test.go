@@ -0,0 +1,58 @@
+package safety
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestState_CheckpointResume(t *testing.T) {
+	prev := NewState()
+	prev.Iteration = 7
+	prev.FinalizeIterTokens("opus", 100, 10)
+	cp := prev.Checkpoint(1)
+
+	state := NewState()
+	state.ConsecutiveNoChanges = 2
+	state.FinalizeIterTokens("opus", 5, 1)
+	state.Resume(cp)
+
+	if state.Iteration != 7 {
+		t.Errorf("Iteration = %d, want 7", state.Iteration)
+	}
+	if in, out := state.TotalTokens(); in != 105 || out != 11 {
+		t.Errorf("TotalTokens = %d/%d, want 105/11", in, out)
+	}
+	if state.ConsecutiveNoChanges != 2 {
+		t.Errorf("ConsecutiveNoChanges = %d, want 2 (not carried over)", state.ConsecutiveNoChanges)
+	}
+
+	// The checkpoint is a copy; later token updates do not change it.
+	prev.FinalizeIterTokens("opus", 1, 1)
+	if cp.TokensByModel["opus"].InputTokens != 100 {
+		t.Errorf("checkpoint tokens changed with the state")
+	}
+}
+
+func TestSaveLoadCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "plan.checkpoint.json")
+
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint(missing) error = %v", err)
+	}
+	if cp.Iterations != 0 || cp.Sessions != 0 {
+		t.Errorf("LoadCheckpoint(missing) = %+v, want empty", cp)
+	}
+
+	want := Checkpoint{Iterations: 12, Sessions: 2, TokensByModel: map[string]ModelTokens{"opus": {InputTokens: 3, OutputTokens: 4}}}
+	if err := SaveCheckpoint(path, want); err != nil {
+		t.Fatalf("SaveCheckpoint() error = %v", err)
+	}
+	got, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if got.Iterations != 12 || got.Sessions != 2 || got.TokensByModel["opus"] != want.TokensByModel["opus"] {
+		t.Errorf("LoadCheckpoint() = %+v, want %+v", got, want)
+	}
+}
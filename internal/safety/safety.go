@@ -2,6 +2,7 @@
 package safety
 
 import (
+	"slices"
 	"time"
 )
 
@@ -23,12 +24,25 @@ const (
 	ExitReasonUserInterrupt    ExitReason = "user_interrupt"
 	ExitReasonReviewFailed     ExitReason = "review_failed"
 	ExitReasonMaxReviewRetries ExitReason = "max_review_retries"
+	ExitReasonReviewStuck      ExitReason = "review_not_converging"
 )
 
+// ExitReasons lists every exit reason.
+var ExitReasons = []ExitReason{
+	ExitReasonComplete, ExitReasonMaxIterations, ExitReasonStagnation, ExitReasonBlocked, ExitReasonError,
+	ExitReasonUserInterrupt, ExitReasonReviewFailed, ExitReasonMaxReviewRetries, ExitReasonReviewStuck,
+}
+
+// ValidExitReason reports whether r is a known exit reason.
+func ValidExitReason(r ExitReason) bool {
+	return slices.Contains(ExitReasons, r)
+}
+
 type Config struct {
 	MaxIterations       int
 	StagnationLimit     int
 	Timeout             int
+	LivenessTimeout     int // seconds without executor output before an invocation is aborted (0 = never)
 	MaxReviewIterations int
 }
 
@@ -47,9 +61,38 @@ type State struct {
 	StartTime            time.Time
 	Model                string
 	TokensByModel        map[string]*ModelTokens
-	CurrentIterTokens    *ModelTokens // live tokens for current iteration
-	ReviewIterations     int          // number of review iterations performed
-	InReviewPhase        bool         // whether we're currently in review phase
+	CurrentIterTokens    *ModelTokens    // live tokens for current iteration
+	ReviewIterations     int             // number of review iterations performed
+	InReviewPhase        bool            // whether we're currently in review phase
+	Review               *ReviewProgress // the review pass running now, nil between passes
+	ActiveFiles          []string        // files the running invocation has edited, relative to the working directory
+}
+
+// ReviewProgress describes a running review pass, for display.
+type ReviewProgress struct {
+	Phase  string          // e.g. "agents", "agents, step 2/3", "validation"
+	Agents []AgentProgress // agents started in this phase, in start order
+}
+
+// AgentProgress is a review agent started in the current review phase.
+type AgentProgress struct {
+	Name    string
+	Elapsed time.Duration // time running so far, or the total once done
+	Done    bool
+}
+
+// Running returns the names of the agents that have not finished.
+func (p *ReviewProgress) Running() []string {
+	if p == nil {
+		return nil
+	}
+	var names []string
+	for _, a := range p.Agents {
+		if !a.Done {
+			names = append(names, a.Name)
+		}
+	}
+	return names
 }
 
 func NewState() *State {
@@ -146,6 +146,17 @@ func TestCheck_Continue(t *testing.T) {
 	}
 }
 
+func TestValidExitReason(t *testing.T) {
+	for _, r := range ExitReasons {
+		if !ValidExitReason(r) {
+			t.Errorf("ValidExitReason(%q) = false, want true", r)
+		}
+	}
+	if ValidExitReason("finished") {
+		t.Error(`ValidExitReason("finished") = true, want false`)
+	}
+}
+
 func TestExitReasonValues(t *testing.T) {
 	tests := []struct {
 		reason ExitReason
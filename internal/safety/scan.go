@@ -0,0 +1,193 @@
+package safety
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ScanRule flags a dangerous change in the executor's diff. Path and
+// ExcludePath select files; the rule fires when a selected file is deleted
+// (Deleted), gains a line matching Added, or loses a line matching Removed.
+// Removed lines that reappear elsewhere in the diff count as moved, not removed.
+type ScanRule struct {
+	Name        string `yaml:"name"`
+	Path        string `yaml:"path"`         // regexp on the repo-relative path; empty matches all files
+	ExcludePath string `yaml:"exclude_path"` // regexp on the path; matching files are skipped
+	Added       string `yaml:"added"`        // regexp on added lines
+	Removed     string `yaml:"removed"`      // regexp on removed lines
+	Deleted     bool   `yaml:"deleted"`      // fire when a matching file is deleted
+}
+
+// ScanFinding is one rule violation found in a diff.
+type ScanFinding struct {
+	Rule string
+	File string
+	Line string // offending line, empty for deletions
+}
+
+func (f ScanFinding) String() string {
+	if f.Line == "" {
+		return fmt.Sprintf("%s: %s deleted", f.Rule, f.File)
+	}
+	return fmt.Sprintf("%s: %s: %s", f.Rule, f.File, f.Line)
+}
+
+// testPathPattern matches common test file and directory names.
+const testPathPattern = `(_test\.go|_test\.py|\.(test|spec)\.[cm]?[jt]sx?)$|(^|/)(test_[^/]*\.py|tests?/)`
+
+// ciPathPattern matches CI configuration files.
+const ciPathPattern = `^(\.github/workflows/|\.gitlab-ci\.yml$|\.circleci/|\.buildkite/|Jenkinsfile$)`
+
+// DefaultScanRules returns the built-in rules: deleted tests, disabled CI,
+// removed auth checks, and new panic/os.RemoveAll calls in non-test Go code.
+func DefaultScanRules() []ScanRule {
+	return []ScanRule{
+		{Name: "deleted-test", Path: testPathPattern, Deleted: true},
+		{Name: "disabled-ci", Path: ciPathPattern, Deleted: true},
+		{Name: "disabled-ci", Path: ciPathPattern, Added: `^\s*if:\s*(false|\$\{\{\s*false\s*\}\})\s*$`},
+		{
+			Name:        "removed-auth-check",
+			ExcludePath: testPathPattern,
+			Removed:     `(?i)(authori[sz]|authenticat|require_?auth|check_?perm|has_?perm|is_?admin|verify_?token|csrf)`,
+		},
+		{Name: "panic", Path: `\.go$`, ExcludePath: `_test\.go$`, Added: `\bpanic\(`},
+		{Name: "remove-all", Path: `\.go$`, ExcludePath: `_test\.go$`, Added: `\bos\.RemoveAll\(`},
+	}
+}
+
+type compiledRule struct {
+	name    string
+	path    *regexp.Regexp
+	exclude *regexp.Regexp
+	added   *regexp.Regexp
+	removed *regexp.Regexp
+	deleted bool
+}
+
+// Scanner checks unified diffs against a set of rules.
+type Scanner struct {
+	rules []compiledRule
+}
+
+// NewScanner compiles rules. Every rule needs a name and at least one of
+// Added, Removed, or Deleted.
+func NewScanner(rules []ScanRule) (*Scanner, error) {
+	s := &Scanner{}
+	for i, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("scan rule %d: name is required", i+1)
+		}
+		if r.Added == "" && r.Removed == "" && !r.Deleted {
+			return nil, fmt.Errorf("scan rule %q: needs added, removed, or deleted", r.Name)
+		}
+		cr := compiledRule{name: r.Name, deleted: r.Deleted}
+		for _, p := range []struct {
+			field string
+			expr  string
+			dst   **regexp.Regexp
+		}{
+			{"path", r.Path, &cr.path},
+			{"exclude_path", r.ExcludePath, &cr.exclude},
+			{"added", r.Added, &cr.added},
+			{"removed", r.Removed, &cr.removed},
+		} {
+			if p.expr == "" {
+				continue
+			}
+			re, err := regexp.Compile(p.expr)
+			if err != nil {
+				return nil, fmt.Errorf("scan rule %q: invalid %s: %w", r.Name, p.field, err)
+			}
+			*p.dst = re
+		}
+		s.rules = append(s.rules, cr)
+	}
+	return s, nil
+}
+
+// diffFile holds the parts of one file's diff the rules look at.
+type diffFile struct {
+	path    string
+	deleted bool
+	added   []string
+	removed []string
+}
+
+// Scan returns the rule violations in a unified diff.
+func (s *Scanner) Scan(diff string) []ScanFinding {
+	files := parseDiff(diff)
+
+	// Lines added anywhere, to tell moved code from removed code.
+	addedAnywhere := make(map[string]bool)
+	for _, f := range files {
+		for _, line := range f.added {
+			addedAnywhere[normalizeLine(line)] = true
+		}
+	}
+
+	var findings []ScanFinding
+	for _, f := range files {
+		for _, r := range s.rules {
+			if (r.path != nil && !r.path.MatchString(f.path)) || (r.exclude != nil && r.exclude.MatchString(f.path)) {
+				continue
+			}
+			if r.deleted && f.deleted {
+				findings = append(findings, ScanFinding{Rule: r.name, File: f.path})
+			}
+			if r.added != nil {
+				for _, line := range f.added {
+					if r.added.MatchString(line) {
+						findings = append(findings, ScanFinding{Rule: r.name, File: f.path, Line: strings.TrimSpace(line)})
+					}
+				}
+			}
+			if r.removed != nil && !f.deleted {
+				for _, line := range f.removed {
+					if r.removed.MatchString(line) && !addedAnywhere[normalizeLine(line)] {
+						findings = append(findings, ScanFinding{Rule: r.name, File: f.path, Line: strings.TrimSpace(line)})
+					}
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// normalizeLine collapses whitespace so re-indented lines compare equal.
+func normalizeLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// parseDiff splits a unified diff into per-file added and removed lines.
+func parseDiff(diff string) []*diffFile {
+	var files []*diffFile
+	var cur *diffFile
+	inHunk := false
+	for line := range strings.Lines(diff) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			cur = &diffFile{}
+			if _, b, ok := strings.Cut(line, " b/"); ok {
+				cur.path = b
+			}
+			files = append(files, cur)
+			inHunk = false
+		case cur == nil:
+		case strings.HasPrefix(line, "deleted file mode"):
+			cur.deleted = true
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+			if b, ok := strings.CutPrefix(line, "+++ b/"); ok {
+				cur.path = b
+			}
+		case strings.HasPrefix(line, "+"):
+			cur.added = append(cur.added, line[1:])
+		case strings.HasPrefix(line, "-"):
+			cur.removed = append(cur.removed, line[1:])
+		}
+	}
+	return files
+}
@@ -0,0 +1,108 @@
+package safety
+
+import (
+	"slices"
+	"testing"
+)
+
+const scanTestDiff = `diff --git a/auth/middleware.go b/auth/middleware.go
+index 1111111..2222222 100644
+--- a/auth/middleware.go
++++ b/auth/middleware.go
+@@ -10,7 +10,6 @@ func Handler(next http.Handler) http.Handler {
+ 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+-		if !authorize(r) {
+-			http.Error(w, "forbidden", 403)
+-		}
++		panic("todo")
+ 		next.ServeHTTP(w, r)
+ 	})
+ }
+diff --git a/store/store_test.go b/store/store_test.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/store/store_test.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package store
+-
+-func TestStore(t *testing.T) {}
+diff --git a/.github/workflows/ci.yml b/.github/workflows/ci.yml
+index 4444444..5555555 100644
+--- a/.github/workflows/ci.yml
++++ b/.github/workflows/ci.yml
+@@ -5,6 +5,7 @@ jobs:
+   test:
++    if: false
+     runs-on: ubuntu-latest
+diff --git a/api/routes.go b/api/routes.go
+index 6666666..7777777 100644
+--- a/api/routes.go
++++ b/api/routes.go
+@@ -1,4 +1,4 @@
+-	mux.Handle("/admin", requireAuth(admin))
++	mux.Handle("/admin",  requireAuth(admin))
+diff --git a/cleanup_test.go b/cleanup_test.go
+index 8888888..9999999 100644
+--- a/cleanup_test.go
++++ b/cleanup_test.go
+@@ -1,2 +1,3 @@
++	defer os.RemoveAll(dir)
+`
+
+func TestScanner_DefaultRules(t *testing.T) {
+	s, err := NewScanner(DefaultScanRules())
+	if err != nil {
+		t.Fatalf("NewScanner() error = %v", err)
+	}
+
+	var got []string
+	for _, f := range s.Scan(scanTestDiff) {
+		got = append(got, f.String())
+	}
+
+	want := []string{
+		"removed-auth-check: auth/middleware.go: if !authorize(r) {",
+		"panic: auth/middleware.go: panic(\"todo\")",
+		"deleted-test: store/store_test.go deleted",
+		"disabled-ci: .github/workflows/ci.yml: if: false",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Scan() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestScanner_CleanDiff(t *testing.T) {
+	s, err := NewScanner(DefaultScanRules())
+	if err != nil {
+		t.Fatalf("NewScanner() error = %v", err)
+	}
+
+	diff := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1,2 @@
++fmt.Println("hello")
+`
+	if findings := s.Scan(diff); len(findings) != 0 {
+		t.Errorf("Scan() = %v, want no findings", findings)
+	}
+}
+
+func TestNewScanner_InvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule ScanRule
+	}{
+		{"missing name", ScanRule{Added: "x"}},
+		{"no trigger", ScanRule{Name: "r", Path: `\.go$`}},
+		{"bad regexp", ScanRule{Name: "r", Added: "("}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewScanner([]ScanRule{tt.rule}); err == nil {
+				t.Error("NewScanner() error = nil, want error")
+			}
+		})
+	}
+}
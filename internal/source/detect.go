@@ -4,16 +4,28 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/alexander-akhmetov/programmator/internal/forge"
+	"github.com/alexander-akhmetov/programmator/internal/ticket"
 )
 
 // Detect determines the appropriate Source for the given identifier.
-// It returns a TicketSource if the id is a ticket ID, or a PlanSource if it's a file path.
+// It returns a PullRequestSource for a pull request reference, a TicketSource
+// if the id is a ticket ID, or a PlanSource if it's a file path.
 //
 // Detection logic:
+//   - If id is a pull request reference (pr:123, mr:45 or a PR/MR URL), treat as pull request
 //   - If id looks like a file path (contains "/" or "\" or ends with ".md"), treat as plan
 //   - If id exists as a file, treat as plan
+//   - If id is "name:ticket" and name is one of workspaces, treat as a ticket
+//     in that workspace
 //   - Otherwise, treat as ticket
-func Detect(id, ticketCommand string) (Source, string) {
+func Detect(id, ticketCommand string, workspaces map[string]ticket.Workspace) (Source, string) {
+	// Checked first: pull request URLs contain path separators
+	if ref, ok := forge.ParseRef(id); ok {
+		return NewPullRequestSource(nil, ref), ref.String()
+	}
+
 	// Check if it looks like a file path
 	if looksLikeFilePath(id) {
 		return NewPlanSource(id), id
@@ -25,6 +37,12 @@ func Detect(id, ticketCommand string) (Source, string) {
 		return NewPlanSource(absPath), absPath
 	}
 
+	if name, _, ok := strings.Cut(id, ":"); ok {
+		if ws, found := workspaces[name]; found {
+			return NewWorkspaceTicketSource(name, ws, ticketCommand), id
+		}
+	}
+
 	// Default to ticket
 	return NewTicketSource(nil, ticketCommand), id
 }
@@ -51,6 +69,9 @@ func looksLikeFilePath(s string) bool {
 
 // IsPlanPath returns true if the path is a plan file path.
 func IsPlanPath(path string) bool {
+	if _, ok := forge.ParseRef(path); ok {
+		return false
+	}
 	return looksLikeFilePath(path) || fileExists(path)
 }
 
@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/ticket"
 )
 
 func TestLooksLikeFilePath(t *testing.T) {
@@ -48,22 +50,48 @@ func TestDetect_PlanFile(t *testing.T) {
 	err := os.WriteFile(planPath, []byte(content), 0644)
 	require.NoError(t, err)
 
-	source, id := Detect(planPath, "")
+	source, id := Detect(planPath, "", nil)
 	assert.IsType(t, &PlanSource{}, source)
 	assert.Equal(t, planPath, id)
 	assert.Equal(t, TypePlan, source.Type())
 }
 
 func TestDetect_TicketID(t *testing.T) {
-	source, id := Detect("pro-1234", "")
+	source, id := Detect("pro-1234", "", nil)
 	assert.IsType(t, &TicketSource{}, source)
 	assert.Equal(t, "pro-1234", id)
 	assert.Equal(t, TypeTicket, source.Type())
 }
 
+func TestDetect_TicketWorkspace(t *testing.T) {
+	workspaces := map[string]ticket.Workspace{"work": {Dir: t.TempDir()}}
+
+	source, id := Detect("work:pro-1234", "", workspaces)
+	require.IsType(t, &TicketSource{}, source)
+	assert.Equal(t, "work:pro-1234", id, "the prefix keeps the ID unique across workspaces")
+	assert.Equal(t, "work", source.(*TicketSource).workspace)
+
+	source, _ = Detect("home:pro-1234", "", workspaces)
+	assert.Empty(t, source.(*TicketSource).workspace, "unknown workspaces go to the default store")
+
+	source, _ = Detect("pr:12", "", map[string]ticket.Workspace{"pr": {Dir: t.TempDir()}})
+	assert.IsType(t, &PullRequestSource{}, source, "pull request references come first")
+}
+
+func TestDetect_PullRequest(t *testing.T) {
+	source, id := Detect("https://github.com/owner/repo/pull/42", "", nil)
+	assert.IsType(t, &PullRequestSource{}, source)
+	assert.Equal(t, "pr:owner/repo#42", id)
+
+	source, id = Detect("mr:7", "", nil)
+	assert.IsType(t, &PullRequestSource{}, source)
+	assert.Equal(t, "mr:7", id)
+	assert.False(t, IsPlanPath("https://github.com/owner/repo/pull/42"))
+}
+
 func TestDetect_RelativePath(t *testing.T) {
 	// Test with path that looks like a file but doesn't exist
-	source, id := Detect("./nonexistent/plan.md", "")
+	source, id := Detect("./nonexistent/plan.md", "", nil)
 	assert.IsType(t, &PlanSource{}, source)
 	assert.Equal(t, "./nonexistent/plan.md", id)
 }
@@ -76,7 +104,7 @@ func TestDetect_ExistingFile(t *testing.T) {
 	require.NoError(t, err)
 
 	// Since it exists, should be treated as plan
-	source, id := Detect(planPath, "")
+	source, id := Detect(planPath, "", nil)
 	assert.IsType(t, &PlanSource{}, source)
 	assert.NotEmpty(t, id)
 }
@@ -1,21 +1,36 @@
 package source
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	"github.com/alexander-akhmetov/programmator/internal/domain"
 	"github.com/alexander-akhmetov/programmator/internal/plan"
 	"github.com/alexander-akhmetov/programmator/internal/protocol"
 )
 
 // PlanSource adapts plan files to the Source interface.
-// It also implements Mover for plan-file relocation.
+// It also implements Mover for plan-file relocation, Splitter for nesting
+// subtasks under a task, PhaseAdder for planning a plan without tasks,
+// PhaseEditor for adding and removing single tasks, and Filer for writing
+// follow-up plans next to it.
 type PlanSource struct {
 	filePath string
 }
 
 // Compile-time interface checks.
 var (
-	_ Source = (*PlanSource)(nil)
-	_ Mover  = (*PlanSource)(nil)
+	_ Source        = (*PlanSource)(nil)
+	_ Mover         = (*PlanSource)(nil)
+	_ Locator       = (*PlanSource)(nil)
+	_ Splitter      = (*PlanSource)(nil)
+	_ PhaseAdder    = (*PlanSource)(nil)
+	_ PhaseEditor   = (*PlanSource)(nil)
+	_ Filer         = (*PlanSource)(nil)
+	_ PhaseReopener = (*PlanSource)(nil)
 )
 
 // NewPlanSource creates a new PlanSource for the given file path.
@@ -47,6 +62,104 @@ func (s *PlanSource) UpdatePhase(_ string, phaseName string) error {
 	return p.SaveFile()
 }
 
+// ReopenPhases clears the checkboxes of the tasks at indices in the plan
+// file.
+func (s *PlanSource) ReopenPhases(_ string, indices []int) error {
+	p, err := plan.ParseFile(s.filePath)
+	if err != nil {
+		return err
+	}
+	p.ReopenTasks(indices)
+	return p.SaveFile()
+}
+
+// SplitPhase nests subtasks under a task in the plan file.
+func (s *PlanSource) SplitPhase(_ string, phaseName string, subtasks []string) error {
+	p, err := plan.ParseFile(s.filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := p.SplitTask(phaseName, subtasks); err != nil {
+		return err
+	}
+
+	return p.SaveFile()
+}
+
+// AddPhases appends tasks to a plan file that has none.
+func (s *PlanSource) AddPhases(_ string, phases []string) error {
+	p, err := plan.ParseFile(s.filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := p.AddTasks(phases); err != nil {
+		return err
+	}
+
+	return p.SaveFile()
+}
+
+// AppendPhase adds a top-level task after the last one in the plan file.
+func (s *PlanSource) AppendPhase(_ string, name string) error {
+	return s.edit(func(p *plan.Plan) error { return p.AppendTask(name) })
+}
+
+// InsertPhase adds a task before the task at index in the plan file.
+func (s *PlanSource) InsertPhase(_ string, index int, name string) error {
+	return s.edit(func(p *plan.Plan) error { return p.InsertTask(index, name) })
+}
+
+// RemovePhase deletes the task at index, with its sub-tasks, from the plan
+// file.
+func (s *PlanSource) RemovePhase(_ string, index int) error {
+	return s.edit(func(p *plan.Plan) error { return p.RemoveTask(index) })
+}
+
+// edit parses the plan file, applies change and saves the result.
+func (s *PlanSource) edit(change func(*plan.Plan) error) error {
+	p, err := plan.ParseFile(s.filePath)
+	if err != nil {
+		return err
+	}
+	if err := change(p); err != nil {
+		return err
+	}
+	return p.SaveFile()
+}
+
+// File writes a new plan next to this one, named after it with a
+// "-followup" suffix (numbered when taken), and returns its path.
+func (s *PlanSource) File(title, body string, phases []string) (string, error) {
+	content := "# " + title + "\n"
+	if body = strings.TrimSpace(body); body != "" {
+		content += "\n" + body + "\n"
+	}
+	content = domain.AppendPhaseList(content, phases)
+
+	base := strings.TrimSuffix(s.filePath, filepath.Ext(s.filePath)) + "-followup"
+	path := base + ".md"
+	for n := 2; ; n++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644) //nolint:gosec // next to the plan being run
+		if os.IsExist(err) {
+			path = base + "-" + strconv.Itoa(n) + ".md"
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("create follow-up plan: %w", err)
+		}
+		_, err = f.WriteString(content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", fmt.Errorf("write follow-up plan: %w", err)
+		}
+		return path, nil
+	}
+}
+
 // AddNote is a no-op for plan files.
 // Plan files don't have a notes section like tickets.
 func (s *PlanSource) AddNote(_, _ string) error {
@@ -69,6 +182,11 @@ func (s *PlanSource) FilePath() string {
 	return s.filePath
 }
 
+// WorkItemFile returns the plan file path.
+func (s *PlanSource) WorkItemFile(_ string) (string, error) {
+	return s.filePath, nil
+}
+
 // MoveTo moves the plan file to a new directory.
 // Returns the new file path.
 func (s *PlanSource) MoveTo(destDir string) (string, error) {
@@ -94,6 +212,9 @@ func planToWorkItem(p *plan.Plan) *domain.WorkItem {
 		phases[i] = domain.Phase{
 			Name:      t.Name,
 			Completed: t.Completed,
+			Depth:     t.Depth,
+			DependsOn: t.DependsOn,
+			Access:    t.Access,
 		}
 	}
 
@@ -80,6 +80,64 @@ func TestPlanSource_UpdatePhase(t *testing.T) {
 	assert.Contains(t, string(savedContent), "- [ ] Task 2")
 }
 
+func TestPlanSource_SplitPhase(t *testing.T) {
+	tmpDir := t.TempDir()
+	planPath := filepath.Join(tmpDir, "test-plan.md")
+	content := "# Plan: Test\n\n- [ ] Task 1\n- [ ] Task 2\n"
+	require.NoError(t, os.WriteFile(planPath, []byte(content), 0644))
+
+	source := NewPlanSource(planPath)
+	require.NoError(t, source.SplitPhase(planPath, "Task 1", []string{"Step A", "Step B"}))
+
+	item, err := source.Get(planPath)
+	require.NoError(t, err)
+	require.Len(t, item.Phases, 4)
+	assert.Equal(t, "Step A", item.CurrentPhase().Name)
+	assert.Equal(t, 1, item.Phases[1].Depth)
+
+	require.NoError(t, source.UpdatePhase(planPath, "Step A"))
+	require.NoError(t, source.UpdatePhase(planPath, "Step B"))
+	item, err = source.Get(planPath)
+	require.NoError(t, err)
+	assert.True(t, item.Phases[0].Completed, "parent completes with its last subtask")
+	assert.Equal(t, "Task 2", item.CurrentPhase().Name)
+}
+
+func TestPlanSource_ReopenPhases(t *testing.T) {
+	tmpDir := t.TempDir()
+	planPath := filepath.Join(tmpDir, "test-plan.md")
+	content := "# Plan: Test\n\n- [x] Task 1\n- [x] Task 2\n- [x] Task 3\n"
+	require.NoError(t, os.WriteFile(planPath, []byte(content), 0644))
+
+	source := NewPlanSource(planPath)
+	require.NoError(t, source.ReopenPhases(planPath, []int{1, 2}))
+
+	item, err := source.Get(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Task 2", item.CurrentPhase().Name)
+	assert.True(t, item.Phases[0].Completed)
+	assert.False(t, item.Phases[2].Completed)
+}
+
+func TestPlanSource_EditPhases(t *testing.T) {
+	tmpDir := t.TempDir()
+	planPath := filepath.Join(tmpDir, "test-plan.md")
+	content := "# Plan: Test\n\n- [x] Task 1\n- [ ] Task 2\n"
+	require.NoError(t, os.WriteFile(planPath, []byte(content), 0644))
+
+	source := NewPlanSource(planPath)
+	require.NoError(t, source.InsertPhase(planPath, 1, "Task 1b"))
+	require.NoError(t, source.AppendPhase(planPath, "Task 3"))
+	require.NoError(t, source.RemovePhase(planPath, 2))
+
+	item, err := source.Get(planPath)
+	require.NoError(t, err)
+	require.Len(t, item.Phases, 3)
+	assert.Equal(t, []string{"Task 1", "Task 1b", "Task 3"}, []string{item.Phases[0].Name, item.Phases[1].Name, item.Phases[2].Name})
+	assert.True(t, item.Phases[0].Completed)
+	assert.Equal(t, "Task 1b", item.CurrentPhase().Name)
+}
+
 func TestPlanSource_AddNote_NoOp(t *testing.T) {
 	tmpDir := t.TempDir()
 	planPath := filepath.Join(tmpDir, "test-plan.md")
@@ -219,3 +277,25 @@ Some important notes here.
 	assert.Contains(t, string(savedContent), "### Notes")
 	assert.Contains(t, string(savedContent), "Some important notes here.")
 }
+
+func TestPlanSource_File(t *testing.T) {
+	tmpDir := t.TempDir()
+	planPath := filepath.Join(tmpDir, "feature.md")
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan: Feature\n\n- [x] Task 1\n"), 0644))
+	src := NewPlanSource(planPath)
+
+	path, err := src.File("Follow up", "Found in run 1.", []string{"Fix nil check", "Rename helper"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "feature-followup.md"), path)
+
+	item, err := NewPlanSource(path).Get(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Follow up", item.Title)
+	require.Len(t, item.Phases, 2)
+	assert.Equal(t, "Fix nil check", item.Phases[0].Name)
+	assert.Contains(t, item.RawContent, "Found in run 1.")
+
+	path, err = src.File("Follow up", "", []string{"Fix it"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "feature-followup-2.md"), path, "an existing follow-up is kept")
+}
@@ -0,0 +1,205 @@
+package source
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/forge"
+	"github.com/alexander-akhmetov/programmator/internal/plan"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+)
+
+// PullRequestSource adapts an existing GitHub pull request or GitLab merge
+// request to the Source interface. The checklist in its description provides
+// the phases; a description without one yields a single completed phase, so
+// the run goes straight to reviewing the pull request's diff.
+//
+// Completed phases are ticked in the description, notes are collected and
+// posted with the summary comment Report adds at the end of the run.
+type PullRequestSource struct {
+	client forge.Client
+	ref    forge.Ref
+
+	mu      sync.Mutex
+	notes   []string
+	inPlace bool // UpdateSummary was called: Report edits the summary comment
+}
+
+// summaryMarker identifies the summary comment, so that updates, and later
+// runs, edit it instead of adding comments.
+const summaryMarker = "<!-- programmator:summary -->"
+
+// commitStatusName is the name commit statuses are reported under.
+const commitStatusName = "programmator"
+
+// maxStatusDescription is the longest commit status description GitHub
+// accepts.
+const maxStatusDescription = 140
+
+// Compile-time interface checks.
+var (
+	_ Source         = (*PullRequestSource)(nil)
+	_ Checkouter     = (*PullRequestSource)(nil)
+	_ Reporter       = (*PullRequestSource)(nil)
+	_ SummaryUpdater = (*PullRequestSource)(nil)
+	_ PhaseReopener  = (*PullRequestSource)(nil)
+)
+
+// NewPullRequestSource creates a source for ref. If client is nil, the gh
+// and glab CLIs are used.
+func NewPullRequestSource(client forge.Client, ref forge.Ref) *PullRequestSource {
+	if client == nil {
+		client = forge.NewClient()
+	}
+	return &PullRequestSource{client: client, ref: ref}
+}
+
+// Get fetches the pull request and returns it as a WorkItem.
+func (s *PullRequestSource) Get(_ string) (*domain.WorkItem, error) {
+	pr, err := s.client.Get(s.ref)
+	if err != nil {
+		return nil, err
+	}
+	p, err := plan.Parse("", pr.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	item := planToWorkItem(p)
+	item.ID = s.ref.String()
+	item.Title = pr.Title
+	if len(item.Phases) == 0 {
+		item.Phases = []domain.Phase{{Name: pr.Title, Completed: true}}
+	}
+	if !strings.HasPrefix(strings.TrimSpace(pr.Body), "#") {
+		item.RawContent = "# " + pr.Title + "\n\n" + pr.Body
+	}
+	return item, nil
+}
+
+// UpdatePhase ticks the phase's checkbox in the pull request description.
+// Phases that are not in the description, such as the one standing in for a
+// description without a checklist, are ignored.
+func (s *PullRequestSource) UpdatePhase(_ string, phaseName string) error {
+	if phaseName == "" || phaseName == protocol.NullPhase {
+		return nil
+	}
+
+	pr, err := s.client.Get(s.ref)
+	if err != nil {
+		return err
+	}
+	p, err := plan.Parse("", pr.Body)
+	if err != nil {
+		return err
+	}
+	if len(p.Tasks) == 0 {
+		return nil
+	}
+
+	if err := p.MarkTaskComplete(phaseName); err != nil {
+		return err
+	}
+	return s.client.SetBody(s.ref, p.Content())
+}
+
+// ReopenPhases clears the checkboxes of phases in the pull request
+// description.
+func (s *PullRequestSource) ReopenPhases(_ string, indices []int) error {
+	pr, err := s.client.Get(s.ref)
+	if err != nil {
+		return err
+	}
+	p, err := plan.Parse("", pr.Body)
+	if err != nil {
+		return err
+	}
+	p.ReopenTasks(indices)
+	return s.client.SetBody(s.ref, p.Content())
+}
+
+// AddNote keeps the note for the summary comment.
+func (s *PullRequestSource) AddNote(_, note string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notes = append(s.notes, note)
+	return nil
+}
+
+// SetStatus is a no-op: the pull request stays open for human review.
+func (s *PullRequestSource) SetStatus(_, _ string) error {
+	return nil
+}
+
+// Type returns "pull_request".
+func (s *PullRequestSource) Type() string {
+	return TypePullRequest
+}
+
+// Checkout checks out the pull request's head branch in dir and returns its
+// base branch.
+func (s *PullRequestSource) Checkout(_, dir string) (string, error) {
+	pr, err := s.client.Get(s.ref)
+	if err != nil {
+		return "", err
+	}
+	if err := s.client.Checkout(s.ref, dir); err != nil {
+		return "", fmt.Errorf("check out %s: %w", s.ref, err)
+	}
+	return pr.BaseBranch, nil
+}
+
+// Report comments summary on the pull request, followed by the notes
+// collected during the run. After UpdateSummary, it replaces the summary
+// comment instead.
+func (s *PullRequestSource) Report(_, summary string) error {
+	s.mu.Lock()
+	notes := s.notes
+	s.notes = nil
+	inPlace := s.inPlace
+	s.mu.Unlock()
+
+	if inPlace {
+		return s.client.UpsertComment(s.ref, summaryMarker, summaryMarker+"\n"+withNotes(summary, notes))
+	}
+	return s.client.Comment(s.ref, withNotes(summary, notes))
+}
+
+// UpdateSummary adds or replaces the summary comment on the pull request,
+// with the notes collected so far.
+func (s *PullRequestSource) UpdateSummary(_, summary string) error {
+	s.mu.Lock()
+	s.inPlace = true
+	notes := slices.Clone(s.notes)
+	s.mu.Unlock()
+
+	return s.client.UpsertComment(s.ref, summaryMarker, summaryMarker+"\n"+withNotes(summary, notes))
+}
+
+// SetCommitStatus sets the programmator commit status of sha.
+func (s *PullRequestSource) SetCommitStatus(_, sha string, state forge.CommitState, description string) error {
+	if len(description) > maxStatusDescription {
+		description = description[:maxStatusDescription-3] + "..."
+	}
+	return s.client.SetCommitStatus(s.ref, sha, forge.CommitStatus{Name: commitStatusName, State: state, Description: description})
+}
+
+// withNotes appends notes to summary in a collapsed section.
+func withNotes(summary string, notes []string) string {
+	if len(notes) == 0 {
+		return summary
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(summary, "\n"))
+	b.WriteString("\n\n<details>\n<summary>Run notes</summary>\n\n")
+	for _, note := range notes {
+		b.WriteString(note)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("</details>\n")
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package source
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/internal/forge"
+	"github.com/alexander-akhmetov/programmator/internal/plan"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+)
+
+func TestPullRequestSource_Get(t *testing.T) {
+	client := forge.NewMockClient(forge.PullRequest{
+		Title: "Add cache",
+		Body:  "Speeds up lookups.\n\n- [x] Add the cache\n- [ ] Invalidate on write\n",
+	})
+	src := NewPullRequestSource(client, forge.Ref{Kind: forge.GitHub, Repo: "o/r", Number: 7})
+
+	item, err := src.Get("pr:o/r#7")
+	require.NoError(t, err)
+	assert.Equal(t, "pr:o/r#7", item.ID)
+	assert.Equal(t, "Add cache", item.Title)
+	require.Len(t, item.Phases, 2)
+	assert.True(t, item.Phases[0].Completed)
+	assert.Equal(t, "Invalidate on write", item.CurrentPhase().Name)
+	assert.Contains(t, item.RawContent, "# Add cache\n\nSpeeds up lookups.")
+	assert.Equal(t, TypePullRequest, src.Type())
+}
+
+func TestPullRequestSource_GetWithoutChecklist(t *testing.T) {
+	client := forge.NewMockClient(forge.PullRequest{Title: "Fix typo", Body: "Just a typo."})
+	src := NewPullRequestSource(client, forge.Ref{Kind: forge.GitLab, Number: 3})
+
+	item, err := src.Get("mr:3")
+	require.NoError(t, err)
+	require.Len(t, item.Phases, 1)
+	assert.True(t, item.AllPhasesComplete(), "goes straight to review")
+
+	require.NoError(t, src.UpdatePhase("mr:3", "Fix typo"))
+	assert.Equal(t, "Just a typo.", client.PR.Body)
+}
+
+func TestPullRequestSource_UpdatePhase(t *testing.T) {
+	client := forge.NewMockClient(forge.PullRequest{Body: "- [ ] One\n- [ ] Two\n"})
+	src := NewPullRequestSource(client, forge.Ref{Kind: forge.GitHub, Number: 1})
+
+	require.NoError(t, src.UpdatePhase("pr:1", "Two"))
+	assert.Equal(t, "- [ ] One\n- [x] Two\n", client.PR.Body)
+
+	require.NoError(t, src.UpdatePhase("pr:1", protocol.NullPhase))
+	assert.ErrorIs(t, src.UpdatePhase("pr:1", "Three"), plan.ErrTaskNotFound)
+}
+
+func TestPullRequestSource_ReopenPhases(t *testing.T) {
+	client := forge.NewMockClient(forge.PullRequest{Body: "Intro.\n\n- [x] One\n- [x] Two\n- [x] Three\n"})
+	src := NewPullRequestSource(client, forge.Ref{Kind: forge.GitHub, Number: 1})
+
+	require.NoError(t, src.ReopenPhases("pr:1", []int{1, 2}))
+	assert.Equal(t, "Intro.\n\n- [x] One\n- [ ] Two\n- [ ] Three\n", client.PR.Body)
+}
+
+func TestPullRequestSource_CheckoutAndReport(t *testing.T) {
+	client := forge.NewMockClient(forge.PullRequest{BaseBranch: "develop"})
+	src := NewPullRequestSource(client, forge.Ref{Kind: forge.GitHub, Number: 1})
+
+	base, err := src.Checkout("pr:1", "/work")
+	require.NoError(t, err)
+	assert.Equal(t, "develop", base)
+	assert.Equal(t, []string{"/work"}, client.Checkouts)
+
+	require.NoError(t, src.AddNote("pr:1", "progress: [iter 1] Completed One"))
+	require.NoError(t, src.Report("pr:1", "finished"))
+	require.Len(t, client.Comments, 1)
+	assert.Contains(t, client.Comments[0], "finished\n\n<details>")
+	assert.Contains(t, client.Comments[0], "progress: [iter 1] Completed One")
+
+	require.NoError(t, src.Report("pr:1", "again"))
+	assert.Equal(t, "again", client.Comments[1], "notes are reported once")
+}
+
+func TestPullRequestSource_UpdateSummary(t *testing.T) {
+	client := forge.NewMockClient(forge.PullRequest{})
+	client.Comments = []string{"LGTM"}
+	src := NewPullRequestSource(client, forge.Ref{Kind: forge.GitHub, Number: 1})
+
+	require.NoError(t, src.UpdateSummary("pr:1", "running: iteration 1"))
+	require.NoError(t, src.AddNote("pr:1", "progress: [iter 1] Completed One"))
+	require.NoError(t, src.UpdateSummary("pr:1", "running: iteration 2"))
+	require.Len(t, client.Comments, 2)
+	assert.Contains(t, client.Comments[1], "running: iteration 2")
+	assert.Contains(t, client.Comments[1], "Completed One")
+
+	require.NoError(t, src.Report("pr:1", "finished"))
+	require.Len(t, client.Comments, 2, "the summary comment is edited in place")
+	assert.Equal(t, "LGTM", client.Comments[0])
+	assert.Contains(t, client.Comments[1], "finished\n\n<details>")
+	assert.NotContains(t, client.Comments[1], "running")
+}
+
+func TestPullRequestSource_SetCommitStatus(t *testing.T) {
+	client := forge.NewMockClient(forge.PullRequest{})
+	src := NewPullRequestSource(client, forge.Ref{Kind: forge.GitHub, Number: 1})
+
+	require.NoError(t, src.SetCommitStatus("pr:1", "abc123", forge.StateFailure, strings.Repeat("x", 200)))
+	require.Len(t, client.Statuses, 1)
+	assert.Equal(t, []string{"abc123"}, client.StatusSHAs)
+	assert.Equal(t, "programmator", client.Statuses[0].Name)
+	assert.Equal(t, forge.StateFailure, client.Statuses[0].State)
+	assert.Len(t, client.Statuses[0].Description, 140)
+}
@@ -1,4 +1,4 @@
-// Package source provides a common interface for work sources (tickets and plans).
+// Package source provides a common interface for work sources (tickets, plans and pull requests).
 // This abstraction allows the loop to work with either ticket files or plan files
 // as the source of work items and phases.
 package source
@@ -7,13 +7,15 @@ import (
 	"errors"
 
 	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/forge"
 	"github.com/alexander-akhmetov/programmator/internal/protocol"
 )
 
 // Re-export source type constants for use by source implementations.
 const (
-	TypePlan   = protocol.SourceTypePlan
-	TypeTicket = protocol.SourceTypeTicket
+	TypePlan        = protocol.SourceTypePlan
+	TypeTicket      = protocol.SourceTypeTicket
+	TypePullRequest = protocol.SourceTypePullRequest
 )
 
 // Sentinel errors returned by source implementations.
@@ -60,9 +62,91 @@ type Mover interface {
 	MoveTo(destDir string) (string, error)
 }
 
+// Locator reports the file a work item is kept in, which only the loop
+// writes to. Plan sources support this, and ticket sources whose client
+// keeps tickets as files.
+type Locator interface {
+	WorkItemFile(id string) (string, error)
+}
+
+// Splitter can replace a phase with smaller sub-phases nested under it.
+// Only plan sources support this.
+type Splitter interface {
+	// SplitPhase adds subtasks, in order, as open sub-phases of phaseName.
+	SplitPhase(id, phaseName string, subtasks []string) error
+}
+
+// PhaseAdder writes phases into a work item that has none, as a checklist
+// under a "Plan" heading. Plan and ticket sources support this.
+type PhaseAdder interface {
+	AddPhases(id string, phases []string) error
+}
+
+// PhaseEditor adds and removes single phases of a work item, for features
+// that change the task list as a run goes instead of rewriting its content.
+// Plan sources support this, and ticket sources whose client supports it.
+type PhaseEditor interface {
+	// AppendPhase adds an open top-level phase after the last one.
+	AppendPhase(id, name string) error
+	// InsertPhase adds an open phase before the phase at index, into
+	// WorkItem.Phases, at its nesting depth; index len(Phases) appends.
+	InsertPhase(id string, index int, name string) error
+	// RemovePhase deletes the phase at index with its sub-phases.
+	RemovePhase(id string, index int) error
+}
+
+// Filer creates a new work item, such as a follow-up for findings a run
+// left open, and returns its ID. Plan and ticket sources support this.
+type Filer interface {
+	File(title, body string, phases []string) (string, error)
+}
+
+// Checkouter works on a branch that already exists, such as the head branch
+// of a pull request. Only pull request sources support this.
+type Checkouter interface {
+	// Checkout checks out the work item's branch in dir and returns the
+	// branch it is to be merged into.
+	Checkout(id, dir string) (string, error)
+}
+
+// Reporter publishes a summary of the finished run on the work item.
+// Only pull request sources support this.
+type Reporter interface {
+	Report(id, summary string) error
+}
+
+// PhaseReopener clears the completion of phases, so a run works on them
+// again. Implemented by plan and pull request sources, and ticket sources
+// whose client supports it.
+type PhaseReopener interface {
+	// ReopenPhases marks the phases at indices, into WorkItem.Phases, as not
+	// completed.
+	ReopenPhases(id string, indices []int) error
+}
+
+// SummaryUpdater keeps a summary of the running run on the work item: one
+// comment, edited in place as the run progresses, and a commit status. Once
+// UpdateSummary was called, Report writes the final summary into the same
+// comment. Only pull request sources support this.
+type SummaryUpdater interface {
+	UpdateSummary(id, summary string) error
+	SetCommitStatus(id, sha string, state forge.CommitState, description string) error
+}
+
+// Refresher can be set fresh, to write notes and status changes a work
+// item already has from an earlier run instead of skipping them. Ticket
+// sources support this.
+type Refresher interface {
+	SetFresh(fresh bool)
+}
+
 // Source is the common interface for ticket and plan sources.
 // It composes the core capability interfaces. Implementations may
-// additionally satisfy Mover for plan-file relocation.
+// additionally satisfy Mover for plan-file relocation, Locator for guarding
+// the work item file, Splitter for phase decomposition, PhaseAdder for
+// planning phaseless work items, PhaseEditor for editing the phase list,
+// Filer for follow-up work items, PhaseReopener for re-running phases, Refresher for fresh runs, and Checkouter, Reporter, and
+// SummaryUpdater for existing pull requests.
 type Source interface {
 	Reader
 	PhaseUpdater
@@ -1,16 +1,43 @@
 package source
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+
 	"github.com/alexander-akhmetov/programmator/internal/domain"
 	"github.com/alexander-akhmetov/programmator/internal/ticket"
 )
 
 // TicketSource adapts a ticket.Client to the Source interface.
+//
+// Notes the ticket already has and status changes to the status it is in
+// are skipped, so running a ticket again does not repeat what an earlier run
+// wrote; SetFresh turns this off.
 type TicketSource struct {
-	client ticket.Client
+	client    ticket.Client
+	workspace string // prefix of the work item IDs, see NewWorkspaceTicketSource
+	fresh     bool
+
+	mu   sync.Mutex
+	seen map[string]*ticketRecord // by work item ID
+}
+
+// ticketRecord is what the source last saw of a ticket.
+type ticketRecord struct {
+	status string
+	notes  string // note fingerprint of the content, plus the notes added since
 }
 
-var _ Source = (*TicketSource)(nil)
+var (
+	_ Source        = (*TicketSource)(nil)
+	_ PhaseAdder    = (*TicketSource)(nil)
+	_ PhaseEditor   = (*TicketSource)(nil)
+	_ Filer         = (*TicketSource)(nil)
+	_ PhaseReopener = (*TicketSource)(nil)
+	_ Locator       = (*TicketSource)(nil)
+	_ Refresher     = (*TicketSource)(nil)
+)
 
 // NewTicketSource creates a new TicketSource with the given client.
 // If client is nil, a default CLIClient is created using the given command name.
@@ -21,29 +48,203 @@ func NewTicketSource(client ticket.Client, ticketCommand string) *TicketSource {
 	return &TicketSource{client: client}
 }
 
+// NewWorkspaceTicketSource creates a TicketSource for the tickets of the
+// named workspace. Its work item IDs keep the "name:" prefix, so tickets with
+// the same ID in different workspaces do not share branches or run state; the
+// prefix is removed before calling the ticket CLI.
+func NewWorkspaceTicketSource(name string, ws ticket.Workspace, ticketCommand string) *TicketSource {
+	return &TicketSource{client: ticket.NewWorkspaceClient(ws, ticketCommand), workspace: name}
+}
+
+// ticketID is id without the workspace prefix.
+func (s *TicketSource) ticketID(id string) string {
+	if s.workspace == "" {
+		return id
+	}
+	return strings.TrimPrefix(id, s.workspace+":")
+}
+
 // Get retrieves a ticket by ID and converts it to a WorkItem.
 func (s *TicketSource) Get(id string) (*domain.WorkItem, error) {
-	t, err := s.client.Get(id)
+	t, err := s.client.Get(s.ticketID(id))
 	if err != nil {
 		return nil, err
 	}
-	return t.ToWorkItem(), nil
+	s.remember(id, t)
+	item := t.ToWorkItem()
+	item.ID = id
+	return item, nil
 }
 
 // UpdatePhase marks a phase as completed in the ticket.
 // The underlying client handles phaseless tickets (empty/null phase names).
 func (s *TicketSource) UpdatePhase(id, phaseName string) error {
-	return s.client.UpdatePhase(id, phaseName)
+	return s.client.UpdatePhase(s.ticketID(id), phaseName)
+}
+
+// ReopenPhases clears the checkboxes of phases in the ticket, when the
+// ticket client supports it.
+func (s *TicketSource) ReopenPhases(id string, indices []int) error {
+	reopener, ok := s.client.(ticket.PhaseReopener)
+	if !ok {
+		return fmt.Errorf("ticket client cannot reopen phases")
+	}
+	return reopener.ReopenPhases(s.ticketID(id), indices)
+}
+
+// WorkItemFile returns the ticket's file, when the ticket client keeps
+// tickets as files.
+func (s *TicketSource) WorkItemFile(id string) (string, error) {
+	locator, ok := s.client.(ticket.Locator)
+	if !ok {
+		return "", fmt.Errorf("ticket client does not keep tickets as files")
+	}
+	return locator.Path(s.ticketID(id))
+}
+
+// AddPhases writes phases into a ticket without any, when the ticket
+// client supports it.
+func (s *TicketSource) AddPhases(id string, phases []string) error {
+	adder, ok := s.client.(ticket.PhaseAdder)
+	if !ok {
+		return fmt.Errorf("ticket client cannot add phases")
+	}
+	return adder.AddPhases(s.ticketID(id), phases)
+}
+
+// AppendPhase adds a phase after the last one in the ticket, when the
+// ticket client supports it.
+func (s *TicketSource) AppendPhase(id, name string) error {
+	editor, err := s.phaseEditor()
+	if err != nil {
+		return err
+	}
+	return editor.AppendPhase(s.ticketID(id), name)
 }
 
-// AddNote adds a progress note to the ticket.
+// InsertPhase adds a phase before the phase at index in the ticket, when
+// the ticket client supports it.
+func (s *TicketSource) InsertPhase(id string, index int, name string) error {
+	editor, err := s.phaseEditor()
+	if err != nil {
+		return err
+	}
+	return editor.InsertPhase(s.ticketID(id), index, name)
+}
+
+// RemovePhase deletes the phase at index, with its sub-phases, from the
+// ticket, when the ticket client supports it.
+func (s *TicketSource) RemovePhase(id string, index int) error {
+	editor, err := s.phaseEditor()
+	if err != nil {
+		return err
+	}
+	return editor.RemovePhase(s.ticketID(id), index)
+}
+
+func (s *TicketSource) phaseEditor() (ticket.PhaseEditor, error) {
+	editor, ok := s.client.(ticket.PhaseEditor)
+	if !ok {
+		return nil, fmt.Errorf("ticket client cannot edit phases")
+	}
+	return editor, nil
+}
+
+// File creates a ticket with phases, when the ticket client supports it.
+// The returned ID carries the workspace prefix, like the IDs Get takes.
+func (s *TicketSource) File(title, body string, phases []string) (string, error) {
+	creator, ok := s.client.(ticket.Creator)
+	if !ok {
+		return "", fmt.Errorf("ticket client cannot create tickets")
+	}
+	id, err := creator.Create(title, body)
+	if err != nil {
+		return "", err
+	}
+	if s.workspace != "" {
+		id = s.workspace + ":" + id
+	}
+	if len(phases) > 0 {
+		if err := s.AddPhases(id, phases); err != nil {
+			return id, fmt.Errorf("add phases to ticket %s: %w", id, err)
+		}
+	}
+	return id, nil
+}
+
+// AddNote adds a progress note to the ticket, unless it has the note.
 func (s *TicketSource) AddNote(id, note string) error {
-	return s.client.AddNote(id, note)
+	fp := noteFingerprint(note)
+	if rec := s.record(id); rec != nil && strings.Contains(rec.notes, fp) {
+		return nil
+	}
+	if err := s.client.AddNote(s.ticketID(id), note); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if rec := s.seen[id]; rec != nil {
+		rec.notes += "\n" + fp
+	}
+	s.mu.Unlock()
+	return nil
 }
 
-// SetStatus updates the ticket's status.
+// SetStatus updates the ticket's status, unless it is in that status.
 func (s *TicketSource) SetStatus(id, status string) error {
-	return s.client.SetStatus(id, status)
+	if rec := s.record(id); rec != nil && rec.status == status {
+		return nil
+	}
+	if err := s.client.SetStatus(s.ticketID(id), status); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if rec := s.seen[id]; rec != nil {
+		rec.status = status
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// SetFresh makes AddNote and SetStatus write whatever the ticket has.
+func (s *TicketSource) SetFresh(fresh bool) {
+	s.fresh = fresh
+}
+
+// record returns what the source saw of ticket id, reading the ticket when
+// it has not yet; nil for a fresh source or a ticket it cannot read.
+func (s *TicketSource) record(id string) *ticketRecord {
+	if s.fresh {
+		return nil
+	}
+	s.mu.Lock()
+	rec := s.seen[id]
+	s.mu.Unlock()
+	if rec != nil {
+		return rec
+	}
+	if _, err := s.Get(id); err != nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seen[id]
+}
+
+// remember records the status and notes of t, read as work item id.
+func (s *TicketSource) remember(id string, t *ticket.Ticket) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen == nil {
+		s.seen = make(map[string]*ticketRecord)
+	}
+	s.seen[id] = &ticketRecord{status: t.Status, notes: noteFingerprint(t.RawContent)}
+}
+
+// noteFingerprint is note with runs of whitespace collapsed to one space, so
+// a note matches the ticket text it was written as however the ticket CLI
+// wrapped or indented it.
+func noteFingerprint(note string) string {
+	return strings.Join(strings.Fields(note), " ")
 }
 
 // Type returns "ticket".
@@ -2,6 +2,8 @@ package source
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -97,6 +99,24 @@ func TestTicketSource_Get(t *testing.T) {
 	assert.Empty(t, item.ValidationCommands)
 }
 
+func TestTicketSource_WorkspacePrefix(t *testing.T) {
+	mock := newMockTicketClient()
+	mock.tickets["pro-123"] = &ticket.Ticket{ID: "pro-123", Title: "Work ticket"}
+	source := &TicketSource{client: mock, workspace: "work"}
+
+	item, err := source.Get("work:pro-123")
+	require.NoError(t, err)
+	assert.Equal(t, "work:pro-123", item.ID)
+	assert.Equal(t, "Work ticket", item.Title)
+
+	require.NoError(t, source.UpdatePhase("work:pro-123", "Phase 1"))
+	require.NoError(t, source.AddNote("work:pro-123", "progress: done"))
+	require.NoError(t, source.SetStatus("work:pro-123", protocol.WorkItemClosed))
+	assert.Equal(t, "pro-123", mock.updatedPhases[0].ID)
+	assert.Equal(t, "pro-123", mock.addedNotes[0].ID)
+	assert.Equal(t, "pro-123", mock.statusChanges[0].ID)
+}
+
 func TestTicketSource_UpdatePhase(t *testing.T) {
 	mock := newMockTicketClient()
 	source := NewTicketSource(mock, "")
@@ -247,3 +267,93 @@ func TestTicketSource_Get_Phaseless(t *testing.T) {
 	assert.Nil(t, item.CurrentPhase())
 	assert.False(t, item.AllPhasesComplete())
 }
+
+func TestTicketSource_AddPhases(t *testing.T) {
+	mock := ticket.NewMockClient()
+	src := NewTicketSource(mock, "")
+
+	require.NoError(t, src.AddPhases("t-1", []string{"Lexer", "Parser"}))
+	require.Len(t, mock.AddPhasesCalls, 1)
+	assert.Equal(t, "t-1", mock.AddPhasesCalls[0].ID)
+	assert.Equal(t, []string{"Lexer", "Parser"}, mock.AddPhasesCalls[0].Phases)
+}
+
+func TestTicketSource_File(t *testing.T) {
+	mock := ticket.NewMockClient()
+	src := &TicketSource{client: mock, workspace: "work"}
+
+	id, err := src.File("Follow up", "details", []string{"Fix nil check"})
+	require.NoError(t, err)
+	assert.Equal(t, "work:new-1", id)
+	require.Len(t, mock.CreateCalls, 1)
+	assert.Equal(t, "Follow up", mock.CreateCalls[0].Title)
+	assert.Equal(t, "details", mock.CreateCalls[0].Description)
+	require.Len(t, mock.AddPhasesCalls, 1)
+	assert.Equal(t, "new-1", mock.AddPhasesCalls[0].ID)
+
+	_, err = NewTicketSource(newMockTicketClient(), "").File("Follow up", "", nil)
+	require.Error(t, err, "clients that cannot create tickets")
+}
+
+func TestTicketSource_WorkItemFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pro-1.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Ticket\n"), 0o600))
+	src := NewWorkspaceTicketSource("work", ticket.Workspace{Dir: dir}, "")
+
+	got, err := src.WorkItemFile("work:pro-1")
+	require.NoError(t, err)
+	assert.Equal(t, path, got)
+
+	_, err = src.WorkItemFile("work:pro-2")
+	require.ErrorIs(t, err, ticket.ErrTicketNotFound)
+
+	_, err = NewTicketSource(newMockTicketClient(), "").WorkItemFile("pro-1")
+	require.Error(t, err)
+}
+
+func TestTicketSource_EditPhases(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pro-1.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Ticket\n\n- [ ] Backend\n"), 0o600))
+	src := NewWorkspaceTicketSource("work", ticket.Workspace{Dir: dir}, "")
+
+	require.NoError(t, src.AppendPhase("work:pro-1", "Docs"))
+	require.NoError(t, src.InsertPhase("work:pro-1", 0, "Schema"))
+	require.NoError(t, src.RemovePhase("work:pro-1", 1))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Ticket\n\n- [ ] Schema\n- [ ] Docs\n", string(data))
+
+	require.Error(t, NewTicketSource(newMockTicketClient(), "").AppendPhase("pro-1", "Docs"))
+}
+
+func TestTicketSource_SkipsWhatTheTicketHas(t *testing.T) {
+	mock := newMockTicketClient()
+	mock.tickets["test-123"] = &ticket.Ticket{
+		ID:         "test-123",
+		Status:     protocol.WorkItemInProgress,
+		RawContent: "# Ticket\n\n## Notes\n\n**2026-10-01**\n\nprogress: Completed   all\nphases in 3 iterations\n",
+	}
+	source := NewTicketSource(mock, "")
+
+	require.NoError(t, source.SetStatus("test-123", protocol.WorkItemInProgress))
+	require.NoError(t, source.AddNote("test-123", "progress: Completed all phases in 3 iterations"))
+	assert.Empty(t, mock.statusChanges)
+	assert.Empty(t, mock.addedNotes)
+
+	require.NoError(t, source.AddNote("test-123", "progress: Review passed"))
+	require.NoError(t, source.AddNote("test-123", "progress: Review passed"))
+	require.NoError(t, source.SetStatus("test-123", protocol.WorkItemClosed))
+	require.NoError(t, source.SetStatus("test-123", protocol.WorkItemClosed))
+	assert.Len(t, mock.addedNotes, 1)
+	assert.Len(t, mock.statusChanges, 1)
+
+	source = NewTicketSource(mock, "")
+	source.SetFresh(true)
+	require.NoError(t, source.SetStatus("test-123", protocol.WorkItemInProgress))
+	require.NoError(t, source.AddNote("test-123", "progress: Completed all phases in 3 iterations"))
+	assert.Len(t, mock.statusChanges, 2)
+	assert.Len(t, mock.addedNotes, 2)
+}
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -22,6 +23,8 @@ var (
 	ErrTicketNotFound = errors.New("ticket not found")
 	// ErrPhaseNotFound is returned when a phase cannot be found in the ticket.
 	ErrPhaseNotFound = errors.New("phase not found")
+	// ErrHasPhases is returned when phases are added to a ticket that already has some.
+	ErrHasPhases = errors.New("ticket already has phases")
 )
 
 type Ticket struct {
@@ -30,9 +33,11 @@ type Ticket struct {
 	Status      string
 	Priority    int
 	Type        string
+	Tags        []string // frontmatter tags, or labels
 	Description string
 	Phases      []domain.Phase
 	RawContent  string
+	Metadata    map[string]string // frontmatter fields other than title and status
 }
 
 type Client interface {
@@ -42,12 +47,59 @@ type Client interface {
 	SetStatus(id, status string) error
 }
 
+// PhaseAdder is implemented by clients that can write phases into a ticket
+// without any.
+type PhaseAdder interface {
+	AddPhases(id string, phases []string) error
+}
+
+// PhaseReopener is implemented by clients that can clear the checkboxes of
+// completed phases.
+type PhaseReopener interface {
+	// ReopenPhases marks the phases at indices, into Ticket.Phases, as not
+	// completed.
+	ReopenPhases(id string, indices []int) error
+}
+
+// PhaseEditor is implemented by clients that can add and remove single
+// phases of a ticket.
+type PhaseEditor interface {
+	// AppendPhase adds an open top-level phase after the last one.
+	AppendPhase(id, name string) error
+	// InsertPhase adds an open phase before the phase at index, into
+	// Ticket.Phases, at its nesting depth; index len(Phases) appends a
+	// top-level phase.
+	InsertPhase(id string, index int, name string) error
+	// RemovePhase deletes the phase at index with its sub-phases.
+	RemovePhase(id string, index int) error
+}
+
+// Locator is implemented by clients that keep each ticket in a file.
+type Locator interface {
+	// Path returns the file holding the ticket.
+	Path(id string) (string, error)
+}
+
+// Creator is implemented by clients that can create tickets.
+type Creator interface {
+	// Create creates an open ticket and returns its ID.
+	Create(title, description string) (string, error)
+}
+
 type CLIClient struct {
 	ticketsDir string
 	command    string
+	env        []string // extra environment for the ticket CLI
 }
 
-var _ Client = (*CLIClient)(nil)
+var (
+	_ Client        = (*CLIClient)(nil)
+	_ PhaseAdder    = (*CLIClient)(nil)
+	_ PhaseReopener = (*CLIClient)(nil)
+	_ PhaseEditor   = (*CLIClient)(nil)
+	_ Creator       = (*CLIClient)(nil)
+	_ Locator       = (*CLIClient)(nil)
+)
 
 var validIDRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.\-]*$`)
 
@@ -74,11 +126,37 @@ func NewClient(command string) *CLIClient {
 	return &CLIClient{ticketsDir: dir, command: command}
 }
 
+// Workspace is a named ticket store: its directory and the CLI that
+// manages it.
+type Workspace struct {
+	Dir     string `yaml:"dir"`
+	Command string `yaml:"command,omitempty"` // "" uses the default ticket command
+}
+
+// NewWorkspaceClient returns a client for the tickets in ws.Dir. The ticket
+// CLI runs with TICKETS_DIR pointing there, so stores do not mix.
+func NewWorkspaceClient(ws Workspace, defaultCommand string) *CLIClient {
+	dir := ws.Dir
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, rest)
+		}
+	}
+	command := ws.Command
+	if command == "" {
+		command = defaultCommand
+	}
+	c := NewClient(command)
+	c.ticketsDir = dir
+	c.env = []string{"TICKETS_DIR=" + dir}
+	return c
+}
+
 func (c *CLIClient) Get(id string) (*Ticket, error) {
 	if err := ValidateID(id); err != nil {
 		return nil, err
 	}
-	out, err := exec.Command(c.command, "show", id).CombinedOutput()
+	out, err := c.run("show", id)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s: %s", ErrTicketNotFound, id, strings.TrimSpace(string(out)))
 	}
@@ -108,7 +186,7 @@ func (c *CLIClient) UpdatePhase(id string, phaseName string) error {
 	}
 
 	lines := strings.Split(string(content), "\n")
-	result := updatePhaseLines(lines, normalizePhase(phaseName))
+	result := updatePhaseLines(lines, domain.NormalizePhaseName(phaseName))
 	if !result.found {
 		return fmt.Errorf("%w: %s", ErrPhaseNotFound, phaseName)
 	}
@@ -119,6 +197,139 @@ func (c *CLIClient) UpdatePhase(id string, phaseName string) error {
 	return writeFileAtomically(filePath, []byte(strings.Join(lines, "\n")))
 }
 
+// ReopenPhases clears the checkboxes of the phases at indices.
+func (c *CLIClient) ReopenPhases(id string, indices []int) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+	filePath, err := c.findTicketFile(id)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("read ticket file: %w", err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	boxes := findCheckboxes(lines)
+	for _, i := range indices {
+		if i < 0 || i >= len(boxes) || !boxes[i].done {
+			continue
+		}
+		line := lines[boxes[i].line]
+		m := phaseRegex.FindStringSubmatchIndex(line)
+		lines[boxes[i].line] = line[:m[2]] + " " + line[m[3]:]
+	}
+	return writeFileAtomically(filePath, []byte(strings.Join(lines, "\n")))
+}
+
+// AddPhases appends phases as a checklist under a "## Plan" heading to a
+// ticket that has no phases.
+func (c *CLIClient) AddPhases(id string, phases []string) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+	filePath, err := c.findTicketFile(id)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("read ticket file: %w", err)
+	}
+	if len(parsePhases(string(content))) > 0 {
+		return fmt.Errorf("%w: %s", ErrHasPhases, id)
+	}
+
+	return writeFileAtomically(filePath, []byte(domain.AppendPhaseList(string(content), phases)))
+}
+
+// AppendPhase adds an open phase after the last phase, at the indentation
+// of the first one. A ticket without phases gets it under a "## Plan"
+// heading.
+func (c *CLIClient) AppendPhase(id, name string) error {
+	return c.insertPhase(id, -1, name)
+}
+
+// InsertPhase adds an open phase before the phase at index, with the same
+// indentation, or appends it when index is the number of phases.
+func (c *CLIClient) InsertPhase(id string, index int, name string) error {
+	if index < 0 {
+		return fmt.Errorf("%w: index %d", ErrPhaseNotFound, index)
+	}
+	return c.insertPhase(id, index, name)
+}
+
+// insertPhase is InsertPhase, appending when index is negative.
+func (c *CLIClient) insertPhase(id string, index int, name string) error {
+	if name = strings.Join(strings.Fields(name), " "); name == "" {
+		return errors.New("empty phase name")
+	}
+	filePath, lines, err := c.readTicketLines(id)
+	if err != nil {
+		return err
+	}
+	boxes := findCheckboxes(lines)
+	if index < 0 {
+		index = len(boxes)
+	}
+	if index > len(boxes) {
+		return fmt.Errorf("%w: index %d", ErrPhaseNotFound, index)
+	}
+	if len(boxes) == 0 {
+		content := domain.AppendPhaseList(strings.Join(lines, "\n"), []string{name})
+		return writeFileAtomically(filePath, []byte(content))
+	}
+
+	at, indent := boxes[len(boxes)-1].line+1, boxes[0].indent
+	if index < len(boxes) {
+		at, indent = boxes[index].line, boxes[index].indent
+	}
+	lines = slices.Insert(lines, at, indent+"- [ ] "+name)
+	return writeFileAtomically(filePath, []byte(strings.Join(lines, "\n")))
+}
+
+// RemovePhase deletes the checkbox line of the phase at index and those of
+// its sub-phases.
+func (c *CLIClient) RemovePhase(id string, index int) error {
+	filePath, lines, err := c.readTicketLines(id)
+	if err != nil {
+		return err
+	}
+	boxes := findCheckboxes(lines)
+	if index < 0 || index >= len(boxes) {
+		return fmt.Errorf("%w: index %d", ErrPhaseNotFound, index)
+	}
+	phases := checkboxPhases(boxes)
+	last := index
+	for last+1 < len(phases) && phases[last+1].Depth > phases[index].Depth {
+		last++
+	}
+	for j := last; j >= index; j-- {
+		lines = slices.Delete(lines, boxes[j].line, boxes[j].line+1)
+	}
+	return writeFileAtomically(filePath, []byte(strings.Join(lines, "\n")))
+}
+
+// readTicketLines returns the file of ticket id and its lines.
+func (c *CLIClient) readTicketLines(id string) (string, []string, error) {
+	if err := ValidateID(id); err != nil {
+		return "", nil, err
+	}
+	filePath, err := c.findTicketFile(id)
+	if err != nil {
+		return "", nil, err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", nil, fmt.Errorf("read ticket file: %w", err)
+	}
+	return filePath, strings.Split(string(content), "\n"), nil
+}
+
 type phaseUpdateResult struct {
 	found       bool
 	alreadyDone bool
@@ -128,23 +339,32 @@ func updatePhaseLines(lines []string, normalizedPhase string) phaseUpdateResult
 	return updatePhaseInCheckboxes(lines, normalizedPhase)
 }
 
+// updatePhaseInCheckboxes checks the phase named normalizedPhase, or else
+// the first one matching it loosely, its sub-phases, and any parent phase
+// left with no open sub-phases.
 func updatePhaseInCheckboxes(lines []string, normalizedPhase string) phaseUpdateResult {
-	for i, line := range lines {
-		match := phaseRegex.FindStringSubmatch(line)
-		if match == nil {
+	boxes := findCheckboxes(lines)
+	exact := slices.IndexFunc(boxes, func(box checkbox) bool { return domain.NormalizePhaseName(box.name) == normalizedPhase })
+	for i, box := range boxes {
+		if exact >= 0 && i != exact {
 			continue
 		}
-
-		existingPhase := normalizePhase(match[2])
+		existingPhase := domain.NormalizePhaseName(box.name)
 		if !phaseMatches(existingPhase, normalizedPhase) {
 			continue
 		}
 
-		if match[1] != " " {
+		if box.done {
 			return phaseUpdateResult{found: true, alreadyDone: true}
 		}
 
-		lines[i] = strings.Replace(line, "- [ ]", "- [x]", 1)
+		w := &domain.WorkItem{Phases: checkboxPhases(boxes)}
+		w.CompletePhase(i)
+		for j, b := range boxes {
+			if w.Phases[j].Completed && !b.done {
+				lines[b.line] = strings.Replace(lines[b.line], "- [ ]", "- [x]", 1)
+			}
+		}
 		return phaseUpdateResult{found: true}
 	}
 	return phaseUpdateResult{}
@@ -192,6 +412,14 @@ func writeFileAtomically(path string, data []byte) error {
 	return nil
 }
 
+// Path returns the markdown file of the ticket in the tickets directory.
+func (c *CLIClient) Path(id string) (string, error) {
+	if err := ValidateID(id); err != nil {
+		return "", err
+	}
+	return c.findTicketFile(id)
+}
+
 func (c *CLIClient) findTicketFile(id string) (string, error) {
 	path := filepath.Clean(filepath.Join(c.ticketsDir, id+".md"))
 	dir := filepath.Clean(c.ticketsDir)
@@ -204,32 +432,29 @@ func (c *CLIClient) findTicketFile(id string) (string, error) {
 	return "", fmt.Errorf("%w: %s", ErrTicketNotFound, id)
 }
 
-var normalizePrefixRegex = regexp.MustCompile(`^(phase|step)\s*\d+[:.]\s*`)
-
-var escapeSequenceCanonicalizer = strings.NewReplacer(
-	`\\n`, `\n`,
-	`\\r`, `\r`,
-	`\\t`, `\t`,
-	"\r\n", "\n",
-	"\r", "\n",
-	"\n", `\n`,
-	"\t", `\t`,
-)
-
-func normalizePhase(s string) string {
-	s = strings.ToLower(s)
-	s = strings.TrimSpace(s)
-	s = normalizePrefixRegex.ReplaceAllString(s, "")
-	s = escapeSequenceCanonicalizer.Replace(s)
-	s = strings.Join(strings.Fields(s), " ")
-	return s
+// Create runs "create" with the title and description, and returns the ID
+// the ticket CLI prints.
+func (c *CLIClient) Create(title, description string) (string, error) {
+	out, err := c.run("create", title, "-d", description)
+	if err != nil {
+		return "", fmt.Errorf("create ticket: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("create ticket: no ID in output")
+	}
+	id := fields[len(fields)-1]
+	if err := ValidateID(id); err != nil {
+		return "", fmt.Errorf("create ticket: unexpected output %q", strings.TrimSpace(string(out)))
+	}
+	return id, nil
 }
 
 func (c *CLIClient) AddNote(id string, note string) error {
 	if err := ValidateID(id); err != nil {
 		return err
 	}
-	out, err := exec.Command(c.command, "add-note", id, note).CombinedOutput()
+	out, err := c.run("add-note", id, note)
 	if err != nil {
 		return fmt.Errorf("add note to ticket %s: %s: %w", id, strings.TrimSpace(string(out)), err)
 	}
@@ -246,13 +471,22 @@ func (c *CLIClient) SetStatus(id string, status string) error {
 	default:
 		return fmt.Errorf("invalid status: %s", status)
 	}
-	out, err := exec.Command(c.command, "set-status", id, status).CombinedOutput()
+	out, err := c.run("set-status", id, status)
 	if err != nil {
 		return fmt.Errorf("set status for ticket %s: %s: %w", id, strings.TrimSpace(string(out)), err)
 	}
 	return nil
 }
 
+// run runs the ticket CLI and returns its combined output.
+func (c *CLIClient) run(args ...string) ([]byte, error) {
+	cmd := exec.Command(c.command, args...)
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+	return cmd.CombinedOutput()
+}
+
 func parseTicket(id string, content string) (*Ticket, error) {
 	ticket := &Ticket{
 		ID:         id,
@@ -277,6 +511,8 @@ func parseTicket(id string, content string) (*Ticket, error) {
 				if typ, ok := frontmatter["type"].(string); ok {
 					ticket.Type = typ
 				}
+				ticket.Tags = frontmatterTags(frontmatter)
+				ticket.Metadata = frontmatterMetadata(frontmatter)
 			}
 		}
 	}
@@ -294,24 +530,122 @@ func parseTicket(id string, content string) (*Ticket, error) {
 	return ticket, nil
 }
 
+// frontmatterTags returns the ticket's tags, from the "tags" field as the
+// ticket CLI writes it or from "labels", each a YAML list or a
+// comma-separated string.
+func frontmatterTags(frontmatter map[string]any) []string {
+	var tags []string
+	for _, key := range []string{"tags", "labels"} {
+		switch v := frontmatter[key].(type) {
+		case []any:
+			for _, item := range v {
+				if tag := strings.TrimSpace(fmt.Sprint(item)); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+		case string:
+			for tag := range strings.SplitSeq(v, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// frontmatterMetadata converts the frontmatter fields other than title and
+// status, which have WorkItem fields of their own, to strings: scalars as
+// written, lists and maps as YAML.
+func frontmatterMetadata(frontmatter map[string]any) map[string]string {
+	metadata := make(map[string]string, len(frontmatter))
+	for key, value := range frontmatter {
+		if key == "title" || key == "status" {
+			continue
+		}
+		switch v := value.(type) {
+		case nil:
+			metadata[key] = ""
+		case string:
+			metadata[key] = v
+		case []any, map[string]any:
+			out, err := yaml.Marshal(v)
+			if err != nil {
+				continue
+			}
+			metadata[key] = strings.TrimRight(string(out), "\n")
+		default:
+			metadata[key] = fmt.Sprint(v)
+		}
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
 var phaseRegex = regexp.MustCompile(`- \[([ xX])\] (.+)`)
 var titleRegex = regexp.MustCompile(`(?m)^# (.+)$`)
 
-func parsePhases(content string) []domain.Phase {
-	matches := phaseRegex.FindAllStringSubmatch(content, -1)
-	if len(matches) == 0 {
-		return nil
+// checkbox is a phase checkbox on one line of a ticket.
+type checkbox struct {
+	line   int
+	indent string // leading whitespace of the line
+	name   string
+	done   bool
+}
+
+// findCheckboxes returns the phase checkboxes of lines, leaving out the
+// acceptance criteria.
+func findCheckboxes(lines []string) []checkbox {
+	criteria := domain.AcceptanceLines(lines)
+	var boxes []checkbox
+	for i, line := range lines {
+		if criteria[i] {
+			continue
+		}
+		match := phaseRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		boxes = append(boxes, checkbox{
+			line:   i,
+			indent: line[:len(line)-len(strings.TrimLeft(line, " \t"))],
+			name:   match[2],
+			done:   match[1] != " ",
+		})
 	}
-	phases := make([]domain.Phase, 0, len(matches))
-	for _, match := range matches {
+	return boxes
+}
+
+// checkboxPhases converts checkboxes to phases, nesting indented items
+// under the item above them.
+func checkboxPhases(boxes []checkbox) []domain.Phase {
+	indents := make([]string, len(boxes))
+	for i, b := range boxes {
+		indents[i] = b.indent
+	}
+	depths := domain.NestingDepths(indents)
+
+	phases := make([]domain.Phase, 0, len(boxes))
+	for i, b := range boxes {
 		phases = append(phases, domain.Phase{
-			Name:      strings.TrimSpace(match[2]),
-			Completed: match[1] != " ",
+			Name:      strings.TrimSpace(b.name),
+			Completed: b.done,
+			Depth:     depths[i],
 		})
 	}
 	return phases
 }
 
+func parsePhases(content string) []domain.Phase {
+	boxes := findCheckboxes(strings.Split(content, "\n"))
+	if len(boxes) == 0 {
+		return nil
+	}
+	return checkboxPhases(boxes)
+}
+
 // ToWorkItem converts a Ticket to a domain.WorkItem.
 func (t *Ticket) ToWorkItem() *domain.WorkItem {
 	return &domain.WorkItem{
@@ -320,5 +654,6 @@ func (t *Ticket) ToWorkItem() *domain.WorkItem {
 		Status:     t.Status,
 		Phases:     t.Phases,
 		RawContent: t.RawContent,
+		Metadata:   t.Metadata,
 	}
 }
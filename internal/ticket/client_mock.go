@@ -9,14 +9,25 @@ type MockClient struct {
 	UpdatePhaseFunc func(id, phaseName string) error
 	AddNoteFunc     func(id, note string) error
 	SetStatusFunc   func(id, status string) error
+	AddPhasesFunc   func(id string, phases []string) error
+	CreateFunc      func(title, description string) (string, error)
 
 	GetCalls         []string
 	UpdatePhaseCalls []struct{ ID, PhaseName string }
 	AddNoteCalls     []struct{ ID, Note string }
 	SetStatusCalls   []struct{ ID, Status string }
+	AddPhasesCalls   []struct {
+		ID     string
+		Phases []string
+	}
+	CreateCalls []struct{ Title, Description string }
 }
 
-var _ Client = (*MockClient)(nil)
+var (
+	_ Client     = (*MockClient)(nil)
+	_ PhaseAdder = (*MockClient)(nil)
+	_ Creator    = (*MockClient)(nil)
+)
 
 func NewMockClient() *MockClient {
 	return &MockClient{
@@ -70,3 +81,28 @@ func (m *MockClient) SetStatus(id, status string) error {
 	}
 	return nil
 }
+
+func (m *MockClient) AddPhases(id string, phases []string) error {
+	m.mu.Lock()
+	m.AddPhasesCalls = append(m.AddPhasesCalls, struct {
+		ID     string
+		Phases []string
+	}{id, phases})
+	m.mu.Unlock()
+
+	if m.AddPhasesFunc != nil {
+		return m.AddPhasesFunc(id, phases)
+	}
+	return nil
+}
+
+func (m *MockClient) Create(title, description string) (string, error) {
+	m.mu.Lock()
+	m.CreateCalls = append(m.CreateCalls, struct{ Title, Description string }{title, description})
+	m.mu.Unlock()
+
+	if m.CreateFunc != nil {
+		return m.CreateFunc(title, description)
+	}
+	return "new-1", nil
+}
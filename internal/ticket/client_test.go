@@ -68,6 +68,19 @@ func TestParsePhases(t *testing.T) {
 				{Name: "Phase 4: Implement prompt_builder", Completed: false},
 			},
 		},
+		{
+			name: "nested phases",
+			content: `- [ ] Phase 1: Backend
+  - [x] Schema
+  - [ ] API
+- [ ] Phase 2: Frontend`,
+			expected: []domain.Phase{
+				{Name: "Phase 1: Backend"},
+				{Name: "Schema", Completed: true, Depth: 1},
+				{Name: "API", Depth: 1},
+				{Name: "Phase 2: Frontend"},
+			},
+		},
 		{
 			name: "phases with colons and descriptions",
 			content: `- [ ] Phase 1: Investigation (gather info)
@@ -77,6 +90,21 @@ func TestParsePhases(t *testing.T) {
 				{Name: "Phase 2: Implementation - the main work", Completed: true},
 			},
 		},
+		{
+			name: "acceptance criteria are not phases",
+			content: `## Design
+- [ ] Phase 1: Setup
+
+## Acceptance Criteria
+- [ ] Setup is idempotent
+
+## Notes
+- [ ] Phase 2: Document`,
+			expected: []domain.Phase{
+				{Name: "Phase 1: Setup"},
+				{Name: "Phase 2: Document"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -92,6 +120,9 @@ func TestParsePhases(t *testing.T) {
 				if phase.Completed != tt.expected[i].Completed {
 					t.Errorf("phase %d: expected completed=%v, got %v", i, tt.expected[i].Completed, phase.Completed)
 				}
+				if phase.Depth != tt.expected[i].Depth {
+					t.Errorf("phase %d: expected depth=%d, got %d", i, tt.expected[i].Depth, phase.Depth)
+				}
 			}
 		})
 	}
@@ -176,6 +207,36 @@ title: "Simple task"
 	}
 }
 
+func TestParseTicket_Metadata(t *testing.T) {
+	content := `---
+title: "Billing export"
+status: open
+priority: 2
+type: feature
+component: billing
+owner:
+acceptance:
+  - CSV has a header row
+  - Totals match the invoice
+---
+# Billing export
+`
+	ticket, err := parseTicket("t-1", content)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"priority":   "2",
+		"type":       "feature",
+		"component":  "billing",
+		"owner":      "",
+		"acceptance": "- CSV has a header row\n- Totals match the invoice",
+	}, ticket.Metadata)
+	assert.Equal(t, ticket.Metadata, ticket.ToWorkItem().Metadata)
+
+	ticket, err = parseTicket("t-2", "---\ntitle: Plain\n---\n# Plain\n")
+	require.NoError(t, err)
+	assert.Nil(t, ticket.Metadata)
+}
+
 func TestTicket_ToWorkItem(t *testing.T) {
 	ticket := &Ticket{
 		ID:         "t-123",
@@ -302,39 +363,6 @@ func TestMockClientImplementsInterface(t *testing.T) {
 	require.Implements(t, (*Client)(nil), &CLIClient{})
 }
 
-func TestNormalizePhase(t *testing.T) {
-	tests := []struct {
-		name  string
-		input string
-		want  string
-	}{
-		{"lowercase", "Setup Project", "setup project"},
-		{"trims whitespace", "  setup  ", "setup"},
-		{"strips Phase N:", "Phase 1: Setup", "setup"},
-		{"strips Phase N.", "Phase 2. Implementation", "implementation"},
-		{"strips Step N:", "Step 3: Testing", "testing"},
-		{"no prefix", "just a task", "just a task"},
-		{"case insensitive prefix", "phase 1: Setup", "setup"},
-		{
-			name:  "normalizes escaped newlines and real newlines",
-			input: "Phase 2: Implement `Load()` and unescape `\\n` to `\n`",
-			want:  "implement `load()` and unescape `\\n` to `\\n`",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := normalizePhase(tt.input)
-			assert.Equal(t, tt.want, got)
-		})
-	}
-}
-
-func TestNormalizePhase_EscapeVariantEquivalence(t *testing.T) {
-	fromTicket := "Phase 2: Implement `Load()` in `internal/cli/history.go` to read file entries, split lines, unescape `\\\\n` to `\\n`, and cap to `max`."
-	fromYAML := "Phase 2: Implement `Load()` in `internal/cli/history.go` to read file entries, split lines, unescape `\\n` to `\n`, and cap to `max`."
-	assert.Equal(t, normalizePhase(fromTicket), normalizePhase(fromYAML))
-}
-
 func TestUpdatePhase(t *testing.T) {
 	setup := func(t *testing.T, content string) (*CLIClient, string) {
 		t.Helper()
@@ -413,6 +441,20 @@ func TestUpdatePhase(t *testing.T) {
 	})
 }
 
+func TestUpdatePhase_NestedCompletesParent(t *testing.T) {
+	dir := t.TempDir()
+	content := "## Design\n- [ ] Backend\n  - [x] Schema\n  - [ ] API\n- [ ] Frontend\n"
+	path := filepath.Join(dir, "t-1234.md")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	client := &CLIClient{ticketsDir: dir}
+
+	require.NoError(t, client.UpdatePhase("t-1234", "API"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "## Design\n- [x] Backend\n  - [x] Schema\n  - [x] API\n- [ ] Frontend\n", string(data))
+}
+
 func TestUpdatePhase_OverlappingNames(t *testing.T) {
 	dir := t.TempDir()
 	content := "## Design\n- [ ] Setup\n- [ ] Setup Tests\n- [ ] Setup Integration Tests\n"
@@ -431,6 +473,60 @@ func TestUpdatePhase_OverlappingNames(t *testing.T) {
 	assert.Contains(t, string(data), "- [ ] Setup Integration Tests")
 }
 
+func TestUpdatePhase_PrefersExactMatch(t *testing.T) {
+	dir := t.TempDir()
+	content := "## Design\n- [ ] Add tests for parser\n- [ ] Add tests\n"
+	path := filepath.Join(dir, "t-1234.md")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	client := &CLIClient{ticketsDir: dir}
+
+	require.NoError(t, client.UpdatePhase("t-1234", "Add tests"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "## Design\n- [ ] Add tests for parser\n- [x] Add tests\n", string(data))
+}
+
+func TestReopenPhases(t *testing.T) {
+	dir := t.TempDir()
+	content := "## Design\n- [x] Backend\n  - [X] Schema\n- [ ] Frontend\n- [x] Docs\n"
+	path := filepath.Join(dir, "t-1234.md")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	client := &CLIClient{ticketsDir: dir}
+
+	require.NoError(t, client.ReopenPhases("t-1234", []int{0, 1, 2, 9}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "## Design\n- [ ] Backend\n  - [ ] Schema\n- [ ] Frontend\n- [x] Docs\n", string(data))
+
+	assert.ErrorIs(t, client.ReopenPhases("t-9999", []int{0}), ErrTicketNotFound)
+}
+
+func TestEditPhases(t *testing.T) {
+	dir := t.TempDir()
+	content := "## Design\n- [x] Backend\n  - [x] Schema\n- [ ] Frontend\n\n## Notes\n"
+	path := filepath.Join(dir, "t-1234.md")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	client := &CLIClient{ticketsDir: dir}
+
+	require.NoError(t, client.InsertPhase("t-1234", 1, "Migrations"))
+	require.NoError(t, client.AppendPhase("t-1234", "Docs"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "## Design\n- [x] Backend\n  - [ ] Migrations\n  - [x] Schema\n- [ ] Frontend\n- [ ] Docs\n\n## Notes\n", string(data))
+
+	require.NoError(t, client.RemovePhase("t-1234", 0))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "## Design\n- [ ] Frontend\n- [ ] Docs\n\n## Notes\n", string(data))
+
+	assert.ErrorIs(t, client.RemovePhase("t-1234", 2), ErrPhaseNotFound)
+	assert.ErrorIs(t, client.InsertPhase("t-1234", -1, "Early"), ErrPhaseNotFound)
+	assert.Error(t, client.AppendPhase("t-1234", " "))
+	assert.ErrorIs(t, client.AppendPhase("t-9999", "Docs"), ErrTicketNotFound)
+}
+
 func TestFindTicketFile(t *testing.T) {
 	setup := func(t *testing.T, filenames ...string) *CLIClient {
 		t.Helper()
@@ -542,6 +638,41 @@ func TestNewClient_EnvHandling(t *testing.T) {
 	})
 }
 
+func TestNewWorkspaceClient(t *testing.T) {
+	t.Setenv("TICKETS_DIR", "/personal/tickets")
+	dir := t.TempDir()
+	script := filepath.Join(t.TempDir(), "fake-tk")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nprintf -- '---\\ntitle: \"%s\"\\n---\\n' \"$TICKETS_DIR\"\n"), 0o755))
+
+	client := NewWorkspaceClient(Workspace{Dir: dir}, script)
+	assert.Equal(t, dir, client.ticketsDir)
+	assert.Equal(t, script, client.command, "falls back to the default command")
+
+	tk, err := client.Get("pro-123")
+	require.NoError(t, err)
+	assert.Equal(t, dir, tk.Title, "the CLI sees the workspace directory")
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	client = NewWorkspaceClient(Workspace{Dir: "~/work-tickets", Command: "ticket"}, "tk")
+	assert.Equal(t, filepath.Join(home, "work-tickets"), client.ticketsDir)
+	assert.Equal(t, "ticket", client.command)
+}
+
+func TestCLIClient_Create(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "fake-tk")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\n[ \"$1 $3\" = 'create -d' ] && echo 'pro-42'\n"), 0o755))
+
+	id, err := NewClient(script).Create("Follow up", "details")
+	require.NoError(t, err)
+	assert.Equal(t, "pro-42", id)
+
+	failing := filepath.Join(t.TempDir(), "fake-tk")
+	require.NoError(t, os.WriteFile(failing, []byte("#!/bin/sh\necho 'no such command'\nexit 1\n"), 0o755))
+	_, err = NewClient(failing).Create("Follow up", "details")
+	require.Error(t, err)
+}
+
 func TestPhaselessTicketParsing(t *testing.T) {
 	content := `---
 title: "Phaseless ticket"
@@ -564,3 +695,20 @@ This ticket has no checkbox phases - it should be treated as a single task.
 	require.Nil(t, item.CurrentPhase())
 	require.False(t, item.AllPhasesComplete())
 }
+
+func TestAddPhases(t *testing.T) {
+	dir := t.TempDir()
+	content := "---\nid: t-1234\n---\n# Parser\n\nWrite a parser.\n"
+	path := filepath.Join(dir, "t-1234.md")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	client := &CLIClient{ticketsDir: dir}
+
+	require.NoError(t, client.AddPhases("t-1234", []string{"Lexer", "Parser"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content+"\n## Plan\n\n- [ ] Lexer\n- [ ] Parser\n", string(data))
+
+	assert.ErrorIs(t, client.AddPhases("t-1234", []string{"More"}), ErrHasPhases)
+	assert.ErrorIs(t, client.AddPhases("t-9999", []string{"Lexer"}), ErrTicketNotFound)
+}
@@ -0,0 +1,152 @@
+package ticket
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Lister is implemented by clients that can enumerate their tickets.
+type Lister interface {
+	// List returns every ticket of the store.
+	List() ([]*Ticket, error)
+}
+
+var _ Lister = (*CLIClient)(nil)
+
+// List parses every ticket file in the tickets directory, in ID order. A
+// missing directory has no tickets.
+func (c *CLIClient) List() ([]*Ticket, error) {
+	files, err := filepath.Glob(filepath.Join(c.ticketsDir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	tickets := make([]*Ticket, 0, len(files))
+	for _, f := range files {
+		id := strings.TrimSuffix(filepath.Base(f), ".md")
+		if ValidateID(id) != nil {
+			continue
+		}
+		content, err := os.ReadFile(f) //nolint:gosec // path under the tickets dir
+		if err != nil {
+			return nil, fmt.Errorf("read ticket %s: %w", id, err)
+		}
+		t, err := parseTicket(id, string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parse ticket %s: %w", id, err)
+		}
+		tickets = append(tickets, t)
+	}
+	return tickets, nil
+}
+
+// Query selects tickets by their frontmatter, written as space-separated
+// field:value terms, e.g. "status:open label:programmator". A ticket
+// matches when it matches every term, and a term matches when any of its
+// comma-separated values does.
+//
+// The fields are status, label (or tag), type, and priority. The
+// workspace field names the ticket workspace to search instead of
+// TICKETS_DIR; it does not filter.
+type Query struct {
+	Workspace string
+	terms     []queryTerm
+}
+
+type queryTerm struct {
+	field  string
+	values []string
+}
+
+// ParseQuery parses a ticket query. An empty query matches every ticket.
+func ParseQuery(s string) (Query, error) {
+	var q Query
+	for _, term := range strings.Fields(s) {
+		field, value, ok := strings.Cut(term, ":")
+		field = strings.ToLower(field)
+		if !ok || value == "" {
+			return Query{}, fmt.Errorf("invalid query term %q: want field:value", term)
+		}
+		var values []string
+		for v := range strings.SplitSeq(value, ",") {
+			if v != "" {
+				values = append(values, v)
+			}
+		}
+		switch field {
+		case "tag":
+			field = "label"
+		case "status", "label", "type":
+		case "priority":
+			for _, v := range values {
+				if _, err := strconv.Atoi(v); err != nil {
+					return Query{}, fmt.Errorf("invalid query term %q: priority must be a number", term)
+				}
+			}
+		case "workspace":
+			if q.Workspace != "" || len(values) != 1 {
+				return Query{}, fmt.Errorf("invalid query term %q: a query searches one workspace", term)
+			}
+			q.Workspace = values[0]
+			continue
+		default:
+			return Query{}, fmt.Errorf("unknown query field %q (supported: status, label, type, priority, workspace)", field)
+		}
+		q.terms = append(q.terms, queryTerm{field: field, values: values})
+	}
+	return q, nil
+}
+
+// Match reports whether t matches every term of the query. Values compare
+// case-insensitively.
+func (q Query) Match(t *Ticket) bool {
+	for _, term := range q.terms {
+		var have []string
+		switch term.field {
+		case "status":
+			have = []string{t.Status}
+		case "label":
+			have = t.Tags
+		case "type":
+			have = []string{t.Type}
+		case "priority":
+			if t.HasPriority() {
+				have = []string{strconv.Itoa(t.Priority)}
+			}
+		}
+		if !slices.ContainsFunc(term.values, func(want string) bool {
+			return slices.ContainsFunc(have, func(h string) bool { return strings.EqualFold(h, want) })
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// SortByPriority orders tickets by priority, 0 (the ticket CLI's highest)
+// first. Tickets without a priority go last, and ties keep ID order.
+func SortByPriority(tickets []*Ticket) {
+	slices.SortStableFunc(tickets, func(a, b *Ticket) int {
+		if a.HasPriority() != b.HasPriority() {
+			if a.HasPriority() {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(a.Priority, b.Priority); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+// HasPriority reports whether the ticket's frontmatter sets a numeric
+// priority.
+func (t *Ticket) HasPriority() bool {
+	_, err := strconv.Atoi(t.Metadata["priority"])
+	return err == nil
+}
@@ -0,0 +1,120 @@
+package ticket
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTicket_Tags(t *testing.T) {
+	ticket, err := parseTicket("t-1", "---\ntitle: Cache\ntags: [programmator, backend]\nlabels: urgent, \n---\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"programmator", "backend", "urgent"}, ticket.Tags)
+}
+
+func TestCLIClient_List(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b-2.md"), []byte("---\nstatus: open\n---\n# Second\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a-1.md"), []byte("---\nstatus: closed\n---\n# First\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a ticket"), 0o644))
+
+	tickets, err := (&CLIClient{ticketsDir: dir}).List()
+	require.NoError(t, err)
+	require.Len(t, tickets, 2)
+	assert.Equal(t, "a-1", tickets[0].ID)
+	assert.Equal(t, "First", tickets[0].Title)
+	assert.Equal(t, "b-2", tickets[1].ID)
+
+	tickets, err = (&CLIClient{ticketsDir: filepath.Join(dir, "missing")}).List()
+	require.NoError(t, err)
+	assert.Empty(t, tickets)
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery("status:open,in_progress tag:programmator workspace:work")
+	require.NoError(t, err)
+	assert.Equal(t, "work", q.Workspace)
+	assert.Equal(t, []queryTerm{
+		{field: "status", values: []string{"open", "in_progress"}},
+		{field: "label", values: []string{"programmator"}},
+	}, q.terms)
+
+	q, err = ParseQuery("  ")
+	require.NoError(t, err)
+	assert.Empty(t, q.terms)
+
+	for query, wantErr := range map[string]string{
+		"open":                          "want field:value",
+		"status:":                       "want field:value",
+		"owner:me":                      "unknown query field",
+		"priority:high":                 "priority must be a number",
+		"workspace:a,b":                 "one workspace",
+		"workspace:a workspace:b":       "one workspace",
+		"status:open label:programmtor": "",
+	} {
+		_, err := ParseQuery(query)
+		if wantErr == "" {
+			assert.NoError(t, err, query)
+		} else {
+			assert.ErrorContains(t, err, wantErr, query)
+		}
+	}
+}
+
+func TestQuery_Match(t *testing.T) {
+	ticket := &Ticket{
+		Status:   "open",
+		Type:     "bug",
+		Tags:     []string{"programmator", "backend"},
+		Priority: 1,
+		Metadata: map[string]string{"priority": "1"},
+	}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"status:open label:programmator", true},
+		{"status:OPEN label:Backend", true},
+		{"status:closed label:programmator", false},
+		{"label:programmator label:frontend", false},
+		{"label:frontend,backend", true},
+		{"type:bug priority:0,1", true},
+		{"priority:2", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := ParseQuery(tc.query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, q.Match(ticket))
+		})
+	}
+
+	q, err := ParseQuery("priority:0")
+	require.NoError(t, err)
+	assert.False(t, q.Match(&Ticket{}), "a ticket without a priority has none to match")
+}
+
+func TestSortByPriority(t *testing.T) {
+	withPriority := func(id, p string) *Ticket {
+		ticket, err := parseTicket(id, "---\npriority: "+p+"\n---\n")
+		require.NoError(t, err)
+		return ticket
+	}
+	tickets := []*Ticket{
+		{ID: "a-none"},
+		withPriority("c-2", "2"),
+		withPriority("b-2", "2"),
+		withPriority("d-0", "0"),
+		withPriority("e-bad", "high"),
+	}
+	SortByPriority(tickets)
+	var ids []string
+	for _, t := range tickets {
+		ids = append(ids, t.ID)
+	}
+	assert.Equal(t, []string{"d-0", "b-2", "c-2", "a-none", "e-bad"}, ids)
+}
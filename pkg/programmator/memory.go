@@ -0,0 +1,116 @@
+package programmator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// MemorySource is a Source backed by work items held in memory, for callers
+// that build work items themselves instead of writing plan files.
+type MemorySource struct {
+	mu    sync.Mutex
+	items map[string]*WorkItem
+	notes map[string][]string
+}
+
+var _ Source = (*MemorySource)(nil)
+
+// NewMemorySource returns a MemorySource serving copies of items, keyed by ID.
+func NewMemorySource(items ...*WorkItem) *MemorySource {
+	s := &MemorySource{
+		items: make(map[string]*WorkItem, len(items)),
+		notes: make(map[string][]string),
+	}
+	for _, item := range items {
+		s.items[item.ID] = cloneWorkItem(item)
+	}
+	return s
+}
+
+// Get returns a copy of the work item, so the loop never sees later updates
+// until it asks again.
+func (s *MemorySource) Get(id string) (*WorkItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return nil, fmt.Errorf("work item %q: %w", id, source.ErrNotFound)
+	}
+	return cloneWorkItem(item), nil
+}
+
+// UpdatePhase marks the first incomplete phase whose name matches
+// (case-insensitively) as completed, along with its sub-phases and any
+// parent phase left with no open sub-phases.
+func (s *MemorySource) UpdatePhase(id, phaseName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return fmt.Errorf("work item %q: %w", id, source.ErrNotFound)
+	}
+	name := strings.TrimSpace(phaseName)
+	for i := range item.Phases {
+		if strings.EqualFold(strings.TrimSpace(item.Phases[i].Name), name) {
+			if item.Phases[i].Completed {
+				continue
+			}
+			item.CompletePhase(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("phase %q: %w", phaseName, source.ErrNotFound)
+}
+
+// SetStatus records the work item's status.
+func (s *MemorySource) SetStatus(id, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return fmt.Errorf("work item %q: %w", id, source.ErrNotFound)
+	}
+	item.Status = status
+	return nil
+}
+
+// AddNote records a progress note on the work item.
+func (s *MemorySource) AddNote(id, note string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[id]; !ok {
+		return fmt.Errorf("work item %q: %w", id, source.ErrNotFound)
+	}
+	s.notes[id] = append(s.notes[id], note)
+	return nil
+}
+
+// Notes returns the progress notes added to the work item so far.
+func (s *MemorySource) Notes(id string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.notes[id])
+}
+
+// Type reports memory sources as tickets: the loop keeps no plan file to
+// move or commit.
+func (s *MemorySource) Type() string {
+	return protocol.SourceTypeTicket
+}
+
+func cloneWorkItem(item *WorkItem) *WorkItem {
+	out := *item
+	out.Phases = slices.Clone(item.Phases)
+	out.ValidationCommands = slices.Clone(item.ValidationCommands)
+	out.ContextFiles = slices.Clone(item.ContextFiles)
+	return &out
+}
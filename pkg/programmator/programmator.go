@@ -0,0 +1,343 @@
+// Package programmator runs programmator's autonomous coding loop from other
+// Go programs. It wraps the loop, sources, review, and configuration behind a
+// small API: load a Config, pick a Source (a plan file, a ticket, or work
+// items built in memory), optionally provide an Invoker, and call Run.
+//
+//	cfg, err := programmator.LoadConfig(dir)
+//	src := programmator.NewMemorySource(&programmator.WorkItem{
+//		ID:     "feature",
+//		Title:  "Add feature",
+//		Phases: []programmator.Phase{{Name: "Implement feature"}},
+//	})
+//	result, err := programmator.Run(ctx, "feature", programmator.Options{
+//		WorkingDir: dir,
+//		Config:     cfg,
+//		Source:     src,
+//		OnEvent:    func(ev programmator.Event) { log.Println(ev.Text) },
+//	})
+package programmator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alexander-akhmetov/programmator/internal/config"
+	"github.com/alexander-akhmetov/programmator/internal/domain"
+	"github.com/alexander-akhmetov/programmator/internal/event"
+	"github.com/alexander-akhmetov/programmator/internal/llm"
+	"github.com/alexander-akhmetov/programmator/internal/loop"
+	"github.com/alexander-akhmetov/programmator/internal/prompt"
+	"github.com/alexander-akhmetov/programmator/internal/protocol"
+	"github.com/alexander-akhmetov/programmator/internal/review"
+	"github.com/alexander-akhmetov/programmator/internal/safety"
+	"github.com/alexander-akhmetov/programmator/internal/source"
+)
+
+// Public names for the types exchanged with the loop.
+type (
+	// Config is the unified programmator configuration.
+	Config = config.Config
+	// WorkItem is a ticket or plan with its phases.
+	WorkItem = domain.WorkItem
+	// Phase is one step of a WorkItem.
+	Phase = domain.Phase
+	// Source supplies work items and records progress on them.
+	Source = source.Source
+	// Invoker runs a single LLM invocation.
+	Invoker = llm.Invoker
+	// InvokeOptions configures a single invocation.
+	InvokeOptions = llm.InvokeOptions
+	// InvokeResult holds an invocation's output.
+	InvokeResult = llm.InvokeResult
+	// Purpose is the kind of work an invocation does (InvokeOptions.Purpose).
+	Purpose = llm.Purpose
+	// Status is the status an executor reports at the end of an invocation.
+	Status = protocol.Status
+	// ReviewIssue is one finding reported by a review agent.
+	ReviewIssue = review.Issue
+	// Severity is how serious a ReviewIssue is.
+	Severity = review.Severity
+	// Event is a progress, tool, review, or output event emitted during a run.
+	Event = event.Event
+	// EventKind identifies the type of an Event.
+	EventKind = event.Kind
+	// State is the loop's safety state (iteration, stagnation counters).
+	State = safety.State
+	// Result describes how a run ended.
+	Result = loop.Result
+	// ExitReason is why a run ended.
+	ExitReason = safety.ExitReason
+	// ExecutorSwitch is a move to a fallback executor (Result.ExecutorSwitches).
+	ExecutorSwitch = loop.ExecutorSwitch
+	// InvocationStats summarizes the invocations of a run (Result.Invocations).
+	InvocationStats = llm.InvocationStats
+	// FailureClass is why an invocation failed.
+	FailureClass = llm.FailureClass
+)
+
+// Exit reasons reported in Result.ExitReason.
+const (
+	ExitReasonComplete         = safety.ExitReasonComplete
+	ExitReasonMaxIterations    = safety.ExitReasonMaxIterations
+	ExitReasonStagnation       = safety.ExitReasonStagnation
+	ExitReasonBlocked          = safety.ExitReasonBlocked
+	ExitReasonError            = safety.ExitReasonError
+	ExitReasonUserInterrupt    = safety.ExitReasonUserInterrupt
+	ExitReasonReviewFailed     = safety.ExitReasonReviewFailed
+	ExitReasonMaxReviewRetries = safety.ExitReasonMaxReviewRetries
+	ExitReasonReviewStuck      = safety.ExitReasonReviewStuck
+)
+
+// Failure classes counted in InvocationStats.Failures.
+const (
+	FailureTimeout   = llm.FailureTimeout
+	FailureRateLimit = llm.FailureRateLimit
+	FailureExit      = llm.FailureExit
+	FailureParse     = llm.FailureParse
+	FailureOther     = llm.FailureOther
+)
+
+// Invocation purposes reported in InvokeOptions.Purpose.
+const (
+	PurposeMain      = llm.PurposeMain
+	PurposeReview    = llm.PurposeReview
+	PurposeValidator = llm.PurposeValidator
+	PurposePlanning  = llm.PurposePlanning
+)
+
+// Statuses an executor reports.
+const (
+	StatusContinue = protocol.StatusContinue
+	StatusDone     = protocol.StatusDone
+	StatusBlocked  = protocol.StatusBlocked
+)
+
+// Severities of review issues.
+const (
+	SeverityCritical = review.SeverityCritical
+	SeverityHigh     = review.SeverityHigh
+	SeverityMedium   = review.SeverityMedium
+	SeverityLow      = review.SeverityLow
+	SeverityInfo     = review.SeverityInfo
+)
+
+// Event kinds reported in Event.Kind.
+const (
+	EventProg               = event.KindProg
+	EventToolUse            = event.KindToolUse
+	EventToolResult         = event.KindToolResult
+	EventReview             = event.KindReview
+	EventDiffAdd            = event.KindDiffAdd
+	EventDiffDel            = event.KindDiffDel
+	EventDiffCtx            = event.KindDiffCtx
+	EventDiffHunk           = event.KindDiffHunk
+	EventMarkdown           = event.KindMarkdown
+	EventStreamingText      = event.KindStreamingText
+	EventIterationSeparator = event.KindIterationSeparator
+	EventAgentActivity      = event.KindAgentActivity
+	EventPhaseAmbiguous     = event.KindPhaseAmbiguous
+	EventIssueLifecycle     = event.KindIssueLifecycle
+)
+
+// Options configure an embedded run.
+type Options struct {
+	// WorkingDir is the repository the executor works in. Required.
+	WorkingDir string
+
+	// Config to run with. Nil loads the configuration for WorkingDir, the
+	// same way the CLI does.
+	Config *Config
+
+	// Source supplies the work item. Nil detects a plan file or ticket from
+	// the id passed to Run.
+	Source Source
+
+	// Invoker replaces the configured executor for both coding and review
+	// invocations. Nil uses the executor from Config.
+	Invoker Invoker
+
+	// AutoBranch creates a programmator/<slug> branch before the first phase.
+	AutoBranch bool
+
+	// Labels tag the run; they are returned in Result.Labels.
+	Labels []string
+
+	// Fresh starts the work item over: completed phases are reopened, and
+	// notes and status changes an earlier run wrote are written again
+	// instead of skipped.
+	Fresh bool
+
+	// OnEvent receives every event emitted during the run.
+	OnEvent func(Event)
+
+	// OnStateChange is called after each iteration with the loop state and
+	// the current work item. Both must be treated as read-only.
+	OnStateChange func(*State, *WorkItem)
+
+	// OnQuestions is called when the executor reports BLOCKED, with its
+	// error and the questions it asked (or the error alone when it asked
+	// none). A non-empty answer is passed to the next invocation and the run
+	// continues; an empty answer ends the run as blocked.
+	OnQuestions func(blockedError string, questions []string) (string, error)
+
+	// OnAmbiguousPhase is called when the phase the executor reports done
+	// matches several open phases loosely and none exactly. It returns the
+	// phase to mark, or "" to mark none. Nil marks none.
+	OnAmbiguousPhase func(reported string, candidates []string) (string, error)
+}
+
+// LoadConfig loads and validates the configuration that applies to dir:
+// embedded defaults, the global config, and any .programmator.yaml found
+// walking up from dir.
+func LoadConfig(dir string) (*Config, error) {
+	cfg, err := config.LoadForDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// NewPlanSource returns a Source backed by a markdown plan file.
+func NewPlanSource(path string) Source {
+	return source.NewPlanSource(path)
+}
+
+// Run executes the work item id until it completes or a safety limit stops
+// it. Canceling ctx stops the run after the current invocation.
+func Run(ctx context.Context, id string, opts Options) (*Result, error) {
+	if opts.WorkingDir == "" {
+		return nil, fmt.Errorf("working dir is required")
+	}
+
+	cfg := opts.Config
+	if cfg == nil {
+		var err error
+		if cfg, err = LoadConfig(opts.WorkingDir); err != nil {
+			return nil, fmt.Errorf("load config: %w", err)
+		}
+	}
+
+	l, err := newLoop(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.Stop()
+		case <-done:
+		}
+	}()
+
+	return l.Run(id)
+}
+
+// newLoop builds a loop configured like `programmator start`.
+func newLoop(cfg *Config, opts Options) (*loop.Loop, error) {
+	llm.SetMaxConcurrentInvocations(cfg.MaxConcurrentInvocations)
+
+	promptBuilder, err := prompt.NewBuilder(cfg.Prompts)
+	if err != nil {
+		return nil, fmt.Errorf("create prompt builder: %w", err)
+	}
+	reviewCfg, err := cfg.ToReviewConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid review config: %w", err)
+	}
+	reviewCfg.Invoker = opts.Invoker
+	diffScanner, err := cfg.ToDiffScanner()
+	if err != nil {
+		return nil, fmt.Errorf("invalid diff_scan config: %w", err)
+	}
+
+	var onStateChange loop.StateCallback
+	if opts.OnStateChange != nil {
+		onStateChange = func(state *safety.State, item *domain.WorkItem, _ []string) {
+			opts.OnStateChange(state, item)
+		}
+	}
+
+	l := loop.New(cfg.ToSafetyConfig(), opts.WorkingDir, onStateChange, opts.OnEvent != nil)
+	if opts.OnEvent != nil {
+		l.SetEventCallback(opts.OnEvent)
+	}
+	if opts.Source != nil {
+		l.SetSource(opts.Source)
+	}
+	if opts.Invoker != nil {
+		l.SetInvoker(opts.Invoker)
+	}
+	l.SetLabels(opts.Labels)
+	l.SetFresh(opts.Fresh)
+	if opts.OnQuestions != nil {
+		l.SetQuestionHandler(opts.OnQuestions)
+	}
+	if opts.OnAmbiguousPhase != nil {
+		l.SetPhasePicker(opts.OnAmbiguousPhase)
+	}
+	l.SetReviewConfig(reviewCfg)
+	l.SetPromptBuilder(promptBuilder)
+	l.SetStatusKey(cfg.StatusBlock.RunKey())
+	l.SetMessages(cfg.Messages)
+	l.SetPacing(loop.PacingConfig{
+		Cooldown:    time.Duration(cfg.Pacing.Cooldown) * time.Second,
+		Adaptive:    cfg.Pacing.Adaptive,
+		MaxCooldown: time.Duration(cfg.Pacing.MaxCooldown) * time.Second,
+		WorkHours:   cfg.Pacing.WorkHoursWindow(),
+	})
+	l.SetActiveFiles(loop.ActiveFilesConfig{
+		StatusFile:  cfg.ActiveFiles.StatusFile,
+		OnConflict:  cfg.ActiveFiles.OnConflict,
+		QuietPeriod: time.Duration(cfg.ActiveFiles.QuietPeriod) * time.Second,
+	})
+	if cfg.TicketCommand != "" {
+		l.SetTicketCommand(cfg.TicketCommand)
+	}
+	l.SetTicketWorkspaces(cfg.TicketWorkspaces)
+	l.SetGitWorkflowConfig(loop.GitWorkflowConfig{
+		AutoCommit:         cfg.Git.AutoCommit,
+		MoveCompletedPlans: cfg.Git.MoveCompletedPlans,
+		CompletedPlansDir:  cfg.Git.CompletedPlansDir,
+		BranchPrefix:       cfg.Git.BranchPrefix,
+		AutoBranch:         opts.AutoBranch,
+		AutoPush:           cfg.Git.AutoPush,
+		PushRemote:         cfg.Git.PushRemote,
+		PushEvery:          cfg.Git.PushEvery,
+		BaseBranch:         cfg.Git.BaseBranch,
+		BaseSync:           cfg.Git.BaseSync,
+		Snapshots:          cfg.Git.Snapshots.Enabled,
+		SquashSnapshots:    cfg.Git.Snapshots.Squash,
+		PRComment:          cfg.Git.PRComment,
+		PRStatus:           cfg.Git.PRStatus,
+		Squash:             cfg.Git.Squash,
+		SignOffPaths:       cfg.Git.SignOffPaths,
+	})
+	l.SetPrices(llm.DefaultPrices().WithOverrides(cfg.Pricing))
+	l.SetExecutorConfig(cfg.ToExecutorConfig())
+	l.SetSecrets(cfg.ExecutorSecrets())
+	l.SetFallback(loop.FallbackConfig{After: cfg.Fallback.After, Chain: cfg.ToFallbackExecutors()})
+	l.SetParallelPhases(cfg.ParallelPhases)
+	l.SetSessionReuse(cfg.ReuseSessions)
+	if cfg.Supervisor.Enabled {
+		l.SetSupervisor(cfg.Supervisor.MaxInterventions)
+	}
+	if cfg.Split.Enabled {
+		l.SetPhaseSplitting(cfg.Split.AfterAttempts, cfg.Split.MaxPhaseLength)
+	}
+	l.SetDocsPhase(cfg.DocsPhase)
+	l.SetCoverageGate(loop.CoverageConfig(cfg.Coverage))
+	l.SetAutoPhasing(cfg.AutoPhase)
+	l.SetMinimalPermissions(cfg.MinimalPermissions)
+	l.SetVerifyFilesChanged(cfg.VerifyFilesChanged)
+	l.SetValidationCommands(cfg.ValidationCommands)
+	l.SetDiffScanner(diffScanner)
+	l.SetNotesConfig(cfg.ToNotesConfig())
+	return l, nil
+}
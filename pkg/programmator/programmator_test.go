@@ -0,0 +1,130 @@
+package programmator_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/pkg/programmator"
+	"github.com/alexander-akhmetov/programmator/pkg/programmator/programmatortest"
+)
+
+func TestRun_MemorySource(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+
+	cfg, err := programmator.LoadConfig(dir)
+	require.NoError(t, err)
+
+	src := programmator.NewMemorySource(&programmator.WorkItem{
+		ID:     "feature",
+		Title:  "Add feature",
+		Phases: []programmator.Phase{{Name: "Implement feature"}},
+	})
+	invoker := programmatortest.NewInvoker(programmatortest.Response{
+		PhaseCompleted: "Implement feature",
+		Status:         programmator.StatusDone,
+		FilesChanged:   []string{"feature.go"},
+		Summary:        "Implemented feature",
+	})
+	var events []programmator.Event
+
+	result, err := programmator.Run(context.Background(), "feature", programmator.Options{
+		WorkingDir: dir,
+		Config:     cfg,
+		Source:     src,
+		Invoker:    invoker,
+		OnEvent:    func(ev programmator.Event) { events = append(events, ev) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, programmator.ExitReasonComplete, result.ExitReason)
+	assert.Equal(t, []string{"feature.go"}, result.TotalFilesChanged)
+
+	item, err := src.Get("feature")
+	require.NoError(t, err)
+	assert.True(t, item.Phases[0].Completed)
+	assert.NotEmpty(t, src.Notes("feature"))
+	assert.Contains(t, invoker.Calls()[0].Prompt, "Implement feature")
+	assert.NotEmpty(t, events)
+}
+
+func TestRun_RedactsSecrets(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+
+	cfg, err := programmator.LoadConfig(dir)
+	require.NoError(t, err)
+	cfg.ExecutorEnv = map[string]string{"STAGING_API_TOKEN": "s3cret-t0ken"}
+
+	src := programmator.NewMemorySource(&programmator.WorkItem{
+		ID:     "feature",
+		Title:  "Add feature",
+		Phases: []programmator.Phase{{Name: "Implement feature"}},
+	})
+	invoker := programmatortest.NewInvoker(programmatortest.Response{
+		PhaseCompleted: "Implement feature",
+		Status:         programmator.StatusDone,
+		FilesChanged:   []string{"feature.go"},
+		Summary:        "Called staging with s3cret-t0ken",
+	})
+	var events []programmator.Event
+
+	_, err = programmator.Run(context.Background(), "feature", programmator.Options{
+		WorkingDir: dir,
+		Config:     cfg,
+		Source:     src,
+		Invoker:    invoker,
+		OnEvent:    func(ev programmator.Event) { events = append(events, ev) },
+	})
+	require.NoError(t, err)
+
+	notes := src.Notes("feature")
+	require.NotEmpty(t, notes)
+	for _, note := range notes {
+		assert.NotContains(t, note, "s3cret-t0ken")
+	}
+	require.NotEmpty(t, events)
+	for _, ev := range events {
+		assert.NotContains(t, ev.Text, "s3cret-t0ken")
+	}
+}
+
+func TestRun_RequiresWorkingDir(t *testing.T) {
+	_, err := programmator.Run(context.Background(), "feature", programmator.Options{})
+	require.Error(t, err)
+}
+
+func TestMemorySource_UpdatePhase(t *testing.T) {
+	src := programmator.NewMemorySource(&programmator.WorkItem{
+		ID:     "item",
+		Phases: []programmator.Phase{{Name: "Step one"}, {Name: "Step two"}},
+	})
+
+	require.NoError(t, src.UpdatePhase("item", "step ONE"))
+	require.Error(t, src.UpdatePhase("item", "Step one"))
+	require.Error(t, src.UpdatePhase("item", "Step three"))
+	_, err := src.Get("missing")
+	require.Error(t, err)
+
+	item, err := src.Get("item")
+	require.NoError(t, err)
+	assert.True(t, item.Phases[0].Completed)
+	assert.False(t, item.Phases[1].Completed)
+}
+
+func TestNewPlanSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Plan: Feature\n\n## Tasks\n- [x] Step one\n- [ ] Step two\n"), 0o644))
+
+	item, err := programmator.NewPlanSource(path).Get(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Feature", item.Title)
+	require.Len(t, item.Phases, 2)
+	assert.True(t, item.Phases[0].Completed)
+}
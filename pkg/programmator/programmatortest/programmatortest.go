@@ -0,0 +1,188 @@
+// Package programmatortest provides deterministic fakes for testing code
+// that embeds programmator: an Invoker that replays scripted executor
+// responses and review results, and a helper building in-memory sources.
+//
+//	inv := programmatortest.NewInvoker(
+//		programmatortest.Response{PhaseCompleted: "Implement feature", Status: programmator.StatusDone},
+//	)
+//	result, err := programmator.Run(ctx, "feature", programmator.Options{
+//		WorkingDir: dir,
+//		Source:     programmatortest.NewSource("feature", "Implement feature"),
+//		Invoker:    inv,
+//	})
+package programmatortest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/alexander-akhmetov/programmator/pkg/programmator"
+)
+
+// Response scripts one coding invocation: the status block the executor
+// reports, and the files it edits before reporting it.
+type Response struct {
+	// PhaseCompleted names the phase reported done (empty for none).
+	PhaseCompleted string
+	// Status reported: StatusContinue, StatusDone, or StatusBlocked.
+	Status programmator.Status
+	// FilesChanged lists the files reported as changed.
+	FilesChanged []string
+	// Summary describes what was done.
+	Summary string
+	// Error explains a StatusBlocked response.
+	Error string
+	// FileEdits maps file paths, relative to the invocation's working
+	// directory unless absolute, to the content written to them.
+	FileEdits map[string]string
+}
+
+// Review scripts one review agent invocation.
+type Review struct {
+	Issues  []programmator.ReviewIssue
+	Summary string
+}
+
+// Call records one invocation.
+type Call struct {
+	Prompt string
+	Opts   programmator.InvokeOptions
+}
+
+// Invoker is a programmator.Invoker that answers from scripts. Coding and
+// planning invocations get the next Response, and a blocked status once
+// the responses run out. Review agents get the next Review, and no issues
+// once the reviews run out, so an Invoker without reviews passes every
+// review. Validators keep every issue they are shown.
+type Invoker struct {
+	mu        sync.Mutex
+	responses []Response
+	reviews   []Review
+	calls     []Call
+}
+
+var _ programmator.Invoker = (*Invoker)(nil)
+
+// NewInvoker returns an Invoker replaying responses in order.
+func NewInvoker(responses ...Response) *Invoker {
+	return &Invoker{responses: responses}
+}
+
+// WithReviews scripts the results of review agent invocations, in order,
+// and returns the Invoker.
+func (i *Invoker) WithReviews(reviews ...Review) *Invoker {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.reviews = append(i.reviews, reviews...)
+	return i
+}
+
+// Invoke records the call and returns the scripted answer for it.
+func (i *Invoker) Invoke(_ context.Context, prompt string, opts programmator.InvokeOptions) (*programmator.InvokeResult, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.calls = append(i.calls, Call{Prompt: prompt, Opts: opts})
+
+	switch opts.Purpose {
+	case programmator.PurposeValidator:
+		return reviewResult(Review{Summary: "Validation skipped"})
+	case programmator.PurposeReview:
+		review := Review{Summary: "No issues found"}
+		if len(i.reviews) > 0 {
+			review, i.reviews = i.reviews[0], i.reviews[1:]
+		}
+		return reviewResult(review)
+	}
+
+	resp := Response{
+		Status:  programmator.StatusBlocked,
+		Summary: "No more scripted responses",
+		Error:   "programmatortest: invoker ran out of scripted responses",
+	}
+	if len(i.responses) > 0 {
+		resp, i.responses = i.responses[0], i.responses[1:]
+	}
+	for path, content := range resp.FileEdits {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(opts.WorkingDir, path)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil { //nolint:gosec // test fixture
+			return nil, fmt.Errorf("programmatortest: edit %s: %w", path, err)
+		}
+	}
+	return statusResult(resp)
+}
+
+// Calls returns the invocations so far.
+func (i *Invoker) Calls() []Call {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return slices.Clone(i.calls)
+}
+
+// Remaining returns the number of scripted responses not used yet.
+func (i *Invoker) Remaining() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return len(i.responses)
+}
+
+func statusResult(resp Response) (*programmator.InvokeResult, error) {
+	block := struct {
+		PhaseCompleted *string             `yaml:"phase_completed"`
+		Status         programmator.Status `yaml:"status"`
+		FilesChanged   []string            `yaml:"files_changed"`
+		Summary        string              `yaml:"summary"`
+		Error          string              `yaml:"error,omitempty"`
+	}{
+		Status:       resp.Status,
+		FilesChanged: resp.FilesChanged,
+		Summary:      resp.Summary,
+		Error:        resp.Error,
+	}
+	if resp.PhaseCompleted != "" {
+		block.PhaseCompleted = &resp.PhaseCompleted
+	}
+	if block.FilesChanged == nil {
+		block.FilesChanged = []string{}
+	}
+	return yamlResult("PROGRAMMATOR_STATUS", block)
+}
+
+func reviewResult(review Review) (*programmator.InvokeResult, error) {
+	issues := review.Issues
+	if issues == nil {
+		issues = []programmator.ReviewIssue{}
+	}
+	return yamlResult("REVIEW_RESULT", struct {
+		Issues  []programmator.ReviewIssue `yaml:"issues"`
+		Summary string                     `yaml:"summary"`
+	}{issues, review.Summary})
+}
+
+// yamlResult renders block under key in a fenced YAML block, the way
+// executors report results.
+func yamlResult(key string, block any) (*programmator.InvokeResult, error) {
+	data, err := yaml.Marshal(map[string]any{key: block})
+	if err != nil {
+		return nil, fmt.Errorf("programmatortest: marshal %s: %w", key, err)
+	}
+	return &programmator.InvokeResult{Text: "```yaml\n" + string(data) + "```\n"}, nil
+}
+
+// NewSource returns an in-memory source holding one work item with the
+// given phases, none completed.
+func NewSource(id string, phases ...string) *programmator.MemorySource {
+	item := &programmator.WorkItem{ID: id, Title: id}
+	for _, name := range phases {
+		item.Phases = append(item.Phases, programmator.Phase{Name: name})
+	}
+	return programmator.NewMemorySource(item)
+}
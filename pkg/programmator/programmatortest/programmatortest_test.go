@@ -0,0 +1,90 @@
+package programmatortest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-akhmetov/programmator/pkg/programmator"
+	"github.com/alexander-akhmetov/programmator/pkg/programmator/programmatortest"
+)
+
+func TestInvoker_ReviewFixCycle(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("PROGRAMMATOR_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+
+	cfg, err := programmator.LoadConfig(dir)
+	require.NoError(t, err)
+
+	inv := programmatortest.NewInvoker(
+		programmatortest.Response{
+			PhaseCompleted: "Implement feature",
+			Status:         programmator.StatusDone,
+			FilesChanged:   []string{"feature.go"},
+			Summary:        "Implemented feature",
+			FileEdits:      map[string]string{"feature.go": "package feature\n"},
+		},
+		programmatortest.Response{
+			Status:       programmator.StatusDone,
+			FilesChanged: []string{"feature.go"},
+			Summary:      "Handled the error",
+			FileEdits:    map[string]string{"feature.go": "package feature // fixed\n"},
+		},
+	).WithReviews(programmatortest.Review{
+		Issues: []programmator.ReviewIssue{{
+			File:        "feature.go",
+			Line:        1,
+			Severity:    programmator.SeverityHigh,
+			Category:    "error handling",
+			Description: "Error is ignored: it's never checked",
+		}},
+		Summary: "One issue",
+	})
+
+	result, err := programmator.Run(context.Background(), "feature", programmator.Options{
+		WorkingDir: dir,
+		Config:     cfg,
+		Source:     programmatortest.NewSource("feature", "Implement feature"),
+		Invoker:    inv,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, programmator.ExitReasonComplete, result.ExitReason)
+	assert.Zero(t, inv.Remaining())
+
+	content, err := os.ReadFile(filepath.Join(dir, "feature.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package feature // fixed\n", string(content))
+
+	var fixPrompt string
+	for _, call := range inv.Calls()[1:] {
+		if call.Opts.Purpose == programmator.PurposeMain {
+			fixPrompt = call.Prompt
+			break
+		}
+	}
+	assert.Contains(t, fixPrompt, "Error is ignored")
+}
+
+func TestInvoker_BlocksWhenScriptRunsOut(t *testing.T) {
+	inv := programmatortest.NewInvoker()
+	res, err := inv.Invoke(context.Background(), "prompt", programmator.InvokeOptions{Purpose: programmator.PurposeMain})
+	require.NoError(t, err)
+	assert.Contains(t, res.Text, "status: BLOCKED")
+	assert.Contains(t, res.Text, "ran out of scripted responses")
+	require.Len(t, inv.Calls(), 1)
+	assert.Equal(t, "prompt", inv.Calls()[0].Prompt)
+}
+
+func TestNewSource(t *testing.T) {
+	src := programmatortest.NewSource("item", "Step one", "Step two")
+	item, err := src.Get("item")
+	require.NoError(t, err)
+	require.Len(t, item.Phases, 2)
+	assert.Equal(t, "Step two", item.Phases[1].Name)
+	assert.False(t, item.Phases[0].Completed)
+}